@@ -15,6 +15,11 @@ const (
 type PaginationParams struct {
 	Page     int
 	PageSize int
+	// Cursor is set when the request has a cursor param, even an empty one: the list is then read
+	// with keyset pagination from that cursor instead of by page
+	Cursor *string
+	// IncludeTotal asks for the total in cursor mode (include_total=true); pages always have it
+	IncludeTotal bool
 }
 
 func ParsePagination(r *http.Request) (PaginationParams, error) {
@@ -36,10 +41,25 @@ func ParsePagination(r *http.Request) (PaginationParams, error) {
 		pageSize = value
 	}
 
-	return PaginationParams{
+	params := PaginationParams{
 		Page:     page,
 		PageSize: pageSize,
-	}, nil
+	}
+	if r.URL.Query().Has("cursor") {
+		if r.URL.Query().Get("page") != "" {
+			return PaginationParams{}, errors.New("page cannot be combined with cursor")
+		}
+		cursor := r.URL.Query().Get("cursor")
+		params.Cursor = &cursor
+	}
+	if raw := r.URL.Query().Get("include_total"); raw != "" {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return PaginationParams{}, errors.New("include_total must be true or false")
+		}
+		params.IncludeTotal = value
+	}
+	return params, nil
 }
 
 func (p PaginationParams) Offset() int {
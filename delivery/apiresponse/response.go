@@ -24,6 +24,12 @@ type Pagination struct {
 
 type Meta struct {
 	Pagination *Pagination `json:"pagination,omitempty"`
+	Facets     interface{} `json:"facets,omitempty"`
+	// Keyset-paginated lists return the cursors of the pages before and after instead of
+	// pagination, left out when there is no such page, and the total when it was asked for
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	TotalItems *int   `json:"total_items,omitempty"`
 }
 
 func Success(w http.ResponseWriter, statusCode int, message string, data interface{}, meta interface{}) {
@@ -60,6 +66,16 @@ func PaginatedSuccess(w http.ResponseWriter, statusCode int, message string, ite
 	)
 }
 
+func CursorSuccess(w http.ResponseWriter, statusCode int, message string, items interface{}, nextCursor, prevCursor string, totalItems *int) {
+	Success(
+		w,
+		statusCode,
+		message,
+		map[string]interface{}{"items": items},
+		Meta{NextCursor: nextCursor, PrevCursor: prevCursor, TotalItems: totalItems},
+	)
+}
+
 func InternalServerError(w http.ResponseWriter) {
 	Error(w, http.StatusInternalServerError, "Internal server error", []string{"An unexpected error occurred"})
 }
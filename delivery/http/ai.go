@@ -2,37 +2,33 @@ package http
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
+
+	"expense_tracker/domain"
 )
 
+// aiStatusError is returned when the AI service answers with a status other than 2xx
+type aiStatusError struct {
+	statusCode int
+	message    string
+}
+
+func (e *aiStatusError) Error() string { return e.message }
+
 // generateInsight sends the prompt to a generative API and returns a short text insight.
 // Works with Groq APIs.
 func generateInsight(ctx context.Context, prompt string) (string, error) {
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return "", errors.New("GEMINI_API_KEY not set")
-	}
-
-	apiURL := os.Getenv("GEMINI_API_URL")
-	if apiURL == "" {
-		apiURL = "https://api.groq.com/openai/v1/chat/completions"
-	}
-
-	model := os.Getenv("GEMINI_MODEL")
-	if model == "" {
-		model = "llama-3.3-70b-versatile"
-	}
-
 	// OpenAI-compatible request format (works with Groq)
 	requestBody := map[string]interface{}{
-		"model": model,
 		"messages": []map[string]interface{}{
 			{
 				"role":    "user",
@@ -43,6 +39,39 @@ func generateInsight(ctx context.Context, prompt string) (string, error) {
 		"temperature": 0.3,
 	}
 
+	insight, err := chatCompletion(ctx, requestBody, 15*time.Second)
+	if err != nil {
+		return "", err
+	}
+	if insight == "" {
+		return "", errors.New("empty insight from AI")
+	}
+
+	return insight, nil
+}
+
+// chatCompletion sends an OpenAI-compatible chat completion request, configured by GEMINI_API_KEY,
+// GEMINI_API_URL and GEMINI_MODEL (unless requestBody names a model), and returns the trimmed
+// content of the first choice
+func chatCompletion(ctx context.Context, requestBody map[string]interface{}, timeout time.Duration) (string, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return "", errors.New("GEMINI_API_KEY not set")
+	}
+
+	apiURL := os.Getenv("GEMINI_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.groq.com/openai/v1/chat/completions"
+	}
+
+	if model, _ := requestBody["model"].(string); model == "" {
+		model = os.Getenv("GEMINI_MODEL")
+		if model == "" {
+			model = "llama-3.3-70b-versatile"
+		}
+		requestBody["model"] = model
+	}
+
 	b, err := json.Marshal(requestBody)
 	if err != nil {
 		return "", err
@@ -56,7 +85,7 @@ func generateInsight(ctx context.Context, prompt string) (string, error) {
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := &http.Client{Timeout: timeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
@@ -71,9 +100,9 @@ func generateInsight(ctx context.Context, prompt string) (string, error) {
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyStr := strings.TrimSpace(string(respBody))
 		if bodyStr == "" {
-			return "", fmt.Errorf("ai service returned status %s", resp.Status)
+			return "", &aiStatusError{statusCode: resp.StatusCode, message: fmt.Sprintf("ai service returned status %s", resp.Status)}
 		}
-		return "", fmt.Errorf("ai service returned status %s: %s", resp.Status, bodyStr)
+		return "", &aiStatusError{statusCode: resp.StatusCode, message: fmt.Sprintf("ai service returned status %s: %s", resp.Status, bodyStr)}
 	}
 
 	// Parse OpenAI-compatible response
@@ -94,10 +123,250 @@ func generateInsight(ctx context.Context, prompt string) (string, error) {
 		return "", errors.New("no choices in response")
 	}
 
-	insight := strings.TrimSpace(openAIResp.Choices[0].Message.Content)
-	if insight == "" {
-		return "", errors.New("empty insight from AI")
+	return strings.TrimSpace(openAIResp.Choices[0].Message.Content), nil
+}
+
+// maxReceiptPromptText caps how much of a PDF's text is sent to the AI service
+const maxReceiptPromptText = 12000
+
+const receiptPrompt = `Read this shop receipt. Reply with only a JSON object with these keys:
+"merchant" (the shop's name), "date" (the purchase date as YYYY-MM-DD), "total" (the amount paid as a number),
+"currency" (ISO 4217 code, only when the receipt shows it), and "line_items" (an array of objects with
+"description", "quantity" and "amount", the price of the whole line, as numbers).
+Use null for anything the receipt does not show.`
+
+// AIReceiptExtractor reads receipts with the OpenAI-compatible chat API that generateInsight uses.
+// Images are sent as they are, which needs a vision model; PDFs are sent as the text of their
+// text layer. HEIC images are left to other extractors, since chat APIs do not take them.
+type AIReceiptExtractor struct {
+	model   string
+	pdfText func([]byte) (string, error)
+}
+
+// NewAIReceiptExtractor returns an extractor asking model ("" = GEMINI_MODEL); pdfText reads the
+// text layer of PDFs, which are left to other extractors when it is nil
+func NewAIReceiptExtractor(model string, pdfText func([]byte) (string, error)) *AIReceiptExtractor {
+	return &AIReceiptExtractor{model: model, pdfText: pdfText}
+}
+
+func (e *AIReceiptExtractor) Name() string { return "ai" }
+
+// Extract asks the AI service for the receipt's fields. Requests it refuses, such as an image
+// given to a model without vision, and replies that are not the JSON asked for make the receipt
+// unreadable; failed connections, rate limits and server errors are worth retrying.
+func (e *AIReceiptExtractor) Extract(ctx context.Context, contentType string, content []byte) (*domain.ReceiptData, error) {
+	var message map[string]interface{}
+	switch contentType {
+	case "application/pdf":
+		if e.pdfText == nil {
+			return nil, fmt.Errorf("%w: PDFs are not sent to the AI service", domain.ErrUnreadableReceipt)
+		}
+		text, err := e.pdfText(content)
+		if err != nil || strings.TrimSpace(text) == "" {
+			return nil, fmt.Errorf("%w: the PDF has no text layer", domain.ErrUnreadableReceipt)
+		}
+		if len(text) > maxReceiptPromptText {
+			text = text[:maxReceiptPromptText]
+		}
+		message = map[string]interface{}{"role": "user", "content": receiptPrompt + "\n\nReceipt text:\n" + text}
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		message = map[string]interface{}{
+			"role": "user",
+			"content": []map[string]interface{}{
+				{"type": "text", "text": receiptPrompt},
+				{"type": "image_url", "image_url": map[string]string{
+					"url": "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(content),
+				}},
+			},
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s is not sent to the AI service", domain.ErrUnreadableReceipt, contentType)
 	}
 
-	return insight, nil
+	requestBody := map[string]interface{}{
+		"messages":    []map[string]interface{}{message},
+		"max_tokens":  1000,
+		"temperature": 0,
+	}
+	if e.model != "" {
+		requestBody["model"] = e.model
+	}
+	reply, err := chatCompletion(ctx, requestBody, 60*time.Second)
+	var statusErr *aiStatusError
+	if errors.As(err, &statusErr) && statusErr.statusCode >= 400 && statusErr.statusCode < 500 && statusErr.statusCode != http.StatusTooManyRequests {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUnreadableReceipt, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseReceiptReply(reply)
+}
+
+// parseReceiptReply reads the JSON object in the AI service's reply, which may be wrapped in prose
+// or a code block
+func parseReceiptReply(reply string) (*domain.ReceiptData, error) {
+	object, ok := replyObject(reply)
+	if !ok {
+		return nil, fmt.Errorf("%w: the AI service did not reply with JSON", domain.ErrUnreadableReceipt)
+	}
+	var parsed struct {
+		Merchant  *string       `json:"merchant"`
+		Date      *string       `json:"date"`
+		Total     *domain.Money `json:"total"`
+		Currency  *string       `json:"currency"`
+		LineItems []struct {
+			Description *string       `json:"description"`
+			Quantity    *float64      `json:"quantity"`
+			Amount      *domain.Money `json:"amount"`
+		} `json:"line_items"`
+	}
+	if err := json.Unmarshal([]byte(object), &parsed); err != nil {
+		return nil, fmt.Errorf("%w: the AI service replied with invalid JSON: %v", domain.ErrUnreadableReceipt, err)
+	}
+
+	data := &domain.ReceiptData{Total: parsed.Total}
+	if parsed.Merchant != nil {
+		data.Merchant = strings.TrimSpace(*parsed.Merchant)
+	}
+	if parsed.Date != nil {
+		if t, err := time.Parse("2006-01-02", strings.TrimSpace(*parsed.Date)); err == nil {
+			data.Date = &t
+		}
+	}
+	if parsed.Currency != nil {
+		if currency := domain.NormalizeCurrency(*parsed.Currency); domain.ValidCurrency(currency) {
+			data.Currency = currency
+		}
+	}
+	for _, item := range parsed.LineItems {
+		if item.Description == nil || item.Amount == nil || strings.TrimSpace(*item.Description) == "" {
+			continue
+		}
+		line := domain.ReceiptLineItem{Description: strings.TrimSpace(*item.Description), Amount: *item.Amount}
+		if item.Quantity != nil && *item.Quantity > 0 {
+			line.Quantity = *item.Quantity
+		}
+		data.LineItems = append(data.LineItems, line)
+	}
+	return data, nil
+}
+
+// replyObject returns the JSON object in a reply of the AI service, which may wrap it in prose or
+// a code block
+func replyObject(reply string) (string, bool) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return "", false
+	}
+	return reply[start : end+1], true
+}
+
+// maxQuickAddCategories caps how many category names are listed in the quick-add prompt
+const maxQuickAddCategories = 100
+
+const quickAddPrompt = `Turn this note about money, written by a user of an expense tracker, into a record.
+Today is %s. Reply with only a JSON object with these keys:
+"kind" ("expense" for money spent, "debt" for money lent or borrowed), "amount" (a number),
+"currency" (ISO 4217 code, only when the note names one), "date" (YYYY-MM-DD: the day an expense was
+spent, or the day a debt is due), "category" (one of these names, or null: %s), "note" (a short
+description), "debt_type" ("lent" when the user gave the money, "borrowed" when they received it)
+and "person" (who the debt is with).
+Use null for anything the note does not say.
+
+Note: %s`
+
+// AIQuickAddParser reads quick-add texts that the grammar cannot with the OpenAI-compatible chat
+// API that generateInsight uses
+type AIQuickAddParser struct {
+	model string
+}
+
+// NewAIQuickAddParser returns a parser asking model ("" = GEMINI_MODEL)
+func NewAIQuickAddParser(model string) *AIQuickAddParser {
+	return &AIQuickAddParser{model: model}
+}
+
+func (p *AIQuickAddParser) Name() string { return "ai" }
+
+// Parse asks the AI service for the record. Replies that are not the JSON asked for, and requests
+// it refuses, mean the text was not understood.
+func (p *AIQuickAddParser) Parse(ctx context.Context, text string, hints domain.QuickAddHints) (*domain.QuickAddResult, error) {
+	names := make([]string, 0, min(len(hints.Categories), maxQuickAddCategories))
+	for _, c := range hints.Categories {
+		if len(names) == maxQuickAddCategories {
+			break
+		}
+		names = append(names, strconv.Quote(c.Name))
+	}
+	prompt := fmt.Sprintf(quickAddPrompt, hints.Today.Format("2006-01-02 (Monday)"), strings.Join(names, ", "), text)
+	requestBody := map[string]interface{}{
+		"messages":    []map[string]interface{}{{"role": "user", "content": prompt}},
+		"max_tokens":  300,
+		"temperature": 0,
+	}
+	if p.model != "" {
+		requestBody["model"] = p.model
+	}
+	reply, err := chatCompletion(ctx, requestBody, 15*time.Second)
+	var statusErr *aiStatusError
+	if errors.As(err, &statusErr) && statusErr.statusCode >= 400 && statusErr.statusCode < 500 && statusErr.statusCode != http.StatusTooManyRequests {
+		return nil, fmt.Errorf("%w: %v", domain.ErrQuickAddNotUnderstood, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseQuickAddReply(reply, hints)
+}
+
+// parseQuickAddReply reads the record in the AI service's reply, matching the category name to
+// hints.Categories
+func parseQuickAddReply(reply string, hints domain.QuickAddHints) (*domain.QuickAddResult, error) {
+	object, ok := replyObject(reply)
+	if !ok {
+		return nil, fmt.Errorf("%w: the AI service did not reply with JSON", domain.ErrQuickAddNotUnderstood)
+	}
+	var parsed struct {
+		Kind     string        `json:"kind"`
+		Amount   *domain.Money `json:"amount"`
+		Currency *string       `json:"currency"`
+		Date     *string       `json:"date"`
+		Category *string       `json:"category"`
+		Note     *string       `json:"note"`
+		DebtType *string       `json:"debt_type"`
+		Person   *string       `json:"person"`
+	}
+	if err := json.Unmarshal([]byte(object), &parsed); err != nil {
+		return nil, fmt.Errorf("%w: the AI service replied with invalid JSON: %v", domain.ErrQuickAddNotUnderstood, err)
+	}
+	if parsed.Amount == nil {
+		return nil, fmt.Errorf("%w: the AI service found no amount", domain.ErrQuickAddNotUnderstood)
+	}
+
+	text := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return strings.TrimSpace(*s)
+	}
+	var date time.Time
+	if t, err := time.Parse("2006-01-02", text(parsed.Date)); err == nil {
+		date = t
+	}
+
+	if domain.QuickAddKind(parsed.Kind) == domain.QuickAddDebt {
+		debt := &domain.Debt{Type: text(parsed.DebtType), PeerName: text(parsed.Person), Amount: *parsed.Amount,
+			Currency: text(parsed.Currency), DueDate: date}
+		if note := text(parsed.Note); note != "" {
+			debt.Note = &note
+		}
+		return &domain.QuickAddResult{Kind: domain.QuickAddDebt, Debt: debt}, nil
+	}
+	expense := &domain.CreateExpenseInput{Amount: *parsed.Amount, Currency: text(parsed.Currency), Note: text(parsed.Note), ExpenseDate: date}
+	for _, c := range hints.Categories {
+		if name := text(parsed.Category); name != "" && strings.EqualFold(c.Name, name) {
+			expense.CategoryID = &c.ID
+			break
+		}
+	}
+	return &domain.QuickAddResult{Kind: domain.QuickAddExpense, Expense: expense}, nil
 }
@@ -0,0 +1,176 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/usecases"
+)
+
+// AttachmentHandler serves the files kept with expenses; JWTAuthMiddleware sets the user
+type AttachmentHandler struct {
+	attachmentUC *usecases.AttachmentUseCase
+}
+
+// NewAttachmentHandler creates a new attachment handler
+func NewAttachmentHandler(uc *usecases.AttachmentUseCase) *AttachmentHandler {
+	return &AttachmentHandler{attachmentUC: uc}
+}
+
+// Upload reads a file sent as multipart/form-data in the field "file" straight into the blob store
+func (h *AttachmentHandler) Upload(w http.ResponseWriter, r *http.Request, expenseID string) {
+	userID := UserIDFromRequest(r)
+	if userID == "" {
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"authorization required"})
+		return
+	}
+	if !isValidUUID(expenseID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid expense id"})
+		return
+	}
+
+	part, ok := uploadedFile(w, r)
+	if !ok {
+		return
+	}
+	defer part.Close()
+	attachment, err := h.attachmentUC.Upload(r.Context(), userID, expenseID, part.FileName(), part)
+	if err != nil {
+		writeAttachmentError(w, uploadReadError(err))
+		return
+	}
+	apiresponse.Success(w, http.StatusCreated, "Attachment uploaded successfully", attachment, nil)
+}
+
+func (h *AttachmentHandler) List(w http.ResponseWriter, r *http.Request, expenseID string) {
+	if !isValidUUID(expenseID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid expense id"})
+		return
+	}
+	attachments, err := h.attachmentUC.List(r.Context(), UserIDFromRequest(r), expenseID)
+	if err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Attachments retrieved successfully", attachments, nil)
+}
+
+// Download sends the file itself, with its sniffed content type, as a download
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request, expenseID, id string) {
+	if !isValidUUID(expenseID) || !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid expense or attachment id"})
+		return
+	}
+	attachment, content, err := h.attachmentUC.Open(r.Context(), UserIDFromRequest(r), expenseID, id)
+	if err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if seeker, ok := content.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, attachment.FileName, attachment.CreatedAt, seeker)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	_, _ = io.Copy(w, content)
+}
+
+func (h *AttachmentHandler) Delete(w http.ResponseWriter, r *http.Request, expenseID, id string) {
+	if !isValidUUID(expenseID) || !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid expense or attachment id"})
+		return
+	}
+	if err := h.attachmentUC.Delete(r.Context(), UserIDFromRequest(r), expenseID, id); err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Attachment deleted successfully", nil, nil)
+}
+
+// extractAttachmentPath returns the IDs of /expenses/{id}/attachments and
+// /expenses/{id}/attachments/{attachmentId}; ok is false for other paths
+func extractAttachmentPath(path string) (expenseID, attachmentID string, ok bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 || len(parts) > 4 || parts[0] != "expenses" || parts[2] != "attachments" || parts[1] == "" {
+		return "", "", false
+	}
+	if len(parts) == 4 {
+		if parts[3] == "" {
+			return "", "", false
+		}
+		return parts[1], parts[3], true
+	}
+	return parts[1], "", true
+}
+
+// uploadedFile returns the part named "file" of a multipart/form-data request, whose body may be at
+// most an attachment and its form; the caller closes it. It writes the error response when there
+// is no such part.
+func uploadedFile(w http.ResponseWriter, r *http.Request) (*multipart.Part, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, usecases.MaxAttachmentSize+(1<<20))
+	reader, err := r.MultipartReader()
+	if err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"body must be multipart/form-data with a file"})
+		return nil, false
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"file is required"})
+			return nil, false
+		}
+		if err != nil {
+			if err = uploadReadError(err); errors.Is(err, usecases.ErrAttachmentTooLarge) {
+				writeAttachmentError(w, err)
+			} else {
+				apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid multipart body"})
+			}
+			return nil, false
+		}
+		if part.FormName() == "file" && part.FileName() != "" {
+			return part, true
+		}
+		part.Close()
+	}
+}
+
+// uploadReadError reports a body cut off by MaxBytesReader as ErrAttachmentTooLarge
+func uploadReadError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return usecases.ErrAttachmentTooLarge
+	}
+	return err
+}
+
+func writeAttachmentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrAttachmentNotFound):
+		apiresponse.Error(w, http.StatusNotFound, "Attachment not found", []string{err.Error()})
+	case errors.Is(err, usecases.ErrAttachmentExpenseNotFound):
+		apiresponse.Error(w, http.StatusNotFound, "Expense not found", []string{err.Error()})
+	case errors.Is(err, usecases.ErrUserIDRequired):
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"authorization required"})
+	case errors.Is(err, usecases.ErrLedgerReadOnly):
+		apiresponse.Error(w, http.StatusForbidden, "Forbidden", []string{err.Error()})
+	case errors.Is(err, usecases.ErrAttachmentTooLarge):
+		apiresponse.Error(w, http.StatusRequestEntityTooLarge, "Validation failed", []string{err.Error()})
+	case errors.Is(err, usecases.ErrUnsupportedAttachmentType):
+		apiresponse.Error(w, http.StatusUnsupportedMediaType, "Validation failed", []string{err.Error()})
+	case errors.Is(err, usecases.ErrTooManyAttachments):
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+	default:
+		apiresponse.InternalServerError(w)
+	}
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+)
+
+// BudgetHandler serves monthly budget endpoints
+type BudgetHandler struct {
+	budgetUC *usecases.BudgetUseCase
+	jwt      *auth.JWTService
+}
+
+// NewBudgetHandler creates a new budget handler
+func NewBudgetHandler(uc *usecases.BudgetUseCase, jwt *auth.JWTService) *BudgetHandler {
+	return &BudgetHandler{budgetUC: uc, jwt: jwt}
+}
+
+// CreateBudgetRequest is the JSON body for POST /budgets; omit category_id for an overall budget
+type CreateBudgetRequest struct {
+	CategoryID *string             `json:"category_id,omitempty"`
+	Amount     domain.Money        `json:"amount"`
+	Bucket     domain.BudgetBucket `json:"bucket,omitempty"` // needs, wants or savings (50/30/20 style)
+}
+
+// UpdateBudgetRequest is the JSON body for PUT /budgets/{id}; omit bucket to keep it
+type UpdateBudgetRequest struct {
+	Amount domain.Money         `json:"amount"`
+	Bucket *domain.BudgetBucket `json:"bucket,omitempty"`
+}
+
+func (h *BudgetHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	var req CreateBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+	if req.CategoryID != nil && *req.CategoryID == "" {
+		req.CategoryID = nil
+	}
+	if req.CategoryID != nil && !isValidUUID(*req.CategoryID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"category_id must be a valid UUID"})
+		return
+	}
+
+	budget, err := h.budgetUC.Create(r.Context(), userID.String(), LedgerIDFromRequest(r), req.CategoryID, req.Amount, req.Bucket)
+	if err != nil {
+		writeBudgetError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusCreated, "Budget created successfully", budget, nil)
+}
+
+func (h *BudgetHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	budgets, err := h.budgetUC.List(r.Context(), userID.String(), LedgerIDFromRequest(r))
+	if err != nil {
+		writeBudgetError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Budgets retrieved successfully", budgets, nil)
+}
+
+func (h *BudgetHandler) GetByID(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid budget id"})
+		return
+	}
+
+	budget, err := h.budgetUC.GetByID(r.Context(), userID.String(), id)
+	if err != nil {
+		writeBudgetError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Budget retrieved successfully", budget, nil)
+}
+
+func (h *BudgetHandler) Update(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid budget id"})
+		return
+	}
+
+	var req UpdateBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+
+	budget, err := h.budgetUC.Update(r.Context(), userID.String(), id, req.Amount, req.Bucket)
+	if err != nil {
+		writeBudgetError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Budget updated successfully", budget, nil)
+}
+
+func (h *BudgetHandler) Delete(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid budget id"})
+		return
+	}
+
+	if err := h.budgetUC.Delete(r.Context(), userID.String(), id); err != nil {
+		writeBudgetError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Budget deleted successfully", nil, nil)
+}
+
+func writeBudgetError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrBudgetNotFound):
+		apiresponse.Error(w, http.StatusNotFound, "Budget not found", []string{err.Error()})
+	case errors.Is(err, usecases.ErrBudgetExists):
+		apiresponse.Error(w, http.StatusConflict, "Budget already exists", []string{err.Error()})
+	case errors.Is(err, usecases.ErrLedgerReadOnly):
+		apiresponse.Error(w, http.StatusForbidden, "Forbidden", []string{err.Error()})
+	case errors.Is(err, usecases.ErrInvalidBudgetAmount),
+		errors.Is(err, usecases.ErrAmountPrecision),
+		errors.Is(err, usecases.ErrBudgetCategoryNotFound),
+		errors.Is(err, usecases.ErrInvalidBudgetBucket),
+		errors.Is(err, usecases.ErrOverallBudgetBucket):
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+	default:
+		apiresponse.InternalServerError(w)
+	}
+}
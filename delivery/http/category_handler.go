@@ -57,6 +57,11 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	input := domain.CreateCategoryInput{Name: req.Name, UserID: createUserID}
+	if ledgerID := LedgerIDFromRequest(r); ledgerID != nil {
+		// Ledger categories are shared by the ledger's members; user_id records who added them
+		input.UserID = &userID
+		input.LedgerID = ledgerID
+	}
 	cat, err := h.categoryUC.Create(r.Context(), input)
 	if err != nil {
 		apiresponse.Error(w, http.StatusBadRequest, "Category creation failed", []string{"unable to create category"})
@@ -79,10 +84,17 @@ func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
 	if uid := UserIDFromRequest(r); uid != "" {
 		userID = &uid
 	}
-	list, total, err := h.categoryUC.List(r.Context(), userID, repository.ListOptions{
+	options := repository.ListOptions{
 		Limit:  pagination.PageSize,
 		Offset: pagination.Offset(),
-	})
+	}
+	var list []*domain.Category
+	var total int
+	if ledgerID := LedgerIDFromRequest(r); ledgerID != nil && userID != nil {
+		list, total, err = h.categoryUC.ListByLedger(r.Context(), *ledgerID, *userID, options)
+	} else {
+		list, total, err = h.categoryUC.List(r.Context(), userID, options)
+	}
 	if err != nil {
 		apiresponse.InternalServerError(w)
 		return
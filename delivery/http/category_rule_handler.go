@@ -0,0 +1,191 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/usecases"
+)
+
+// CategoryRuleHandler serves category rule endpoints; JWTAuthMiddleware sets the user and ledger
+type CategoryRuleHandler struct {
+	ruleUC *usecases.CategoryRuleUseCase
+}
+
+// NewCategoryRuleHandler creates a new category rule handler
+func NewCategoryRuleHandler(uc *usecases.CategoryRuleUseCase) *CategoryRuleHandler {
+	return &CategoryRuleHandler{ruleUC: uc}
+}
+
+// CategoryRuleRequest is the JSON body for POST /categories/rules and PUT /categories/rules/{id};
+// at least one condition is required
+type CategoryRuleRequest struct {
+	CategoryID   string         `json:"category_id"`
+	NoteContains string         `json:"note_contains,omitempty"`
+	NotePattern  string         `json:"note_pattern,omitempty"` // regular expression, e.g. (?i)^uber
+	MinAmount    *domain.Money  `json:"min_amount,omitempty"`
+	MaxAmount    *domain.Money  `json:"max_amount,omitempty"`
+	Weekdays     []time.Weekday `json:"weekdays,omitempty"` // 0 = Sunday ... 6 = Saturday
+	Priority     int            `json:"priority"`           // higher is tried first
+}
+
+// ApplyCategoryRulesRequest is the JSON body for POST /categories/rules/apply; every field is optional
+type ApplyCategoryRulesRequest struct {
+	FromDate          string `json:"from_date,omitempty"`          // YYYY-MM-DD
+	ToDate            string `json:"to_date,omitempty"`            // YYYY-MM-DD
+	OnlyUncategorized *bool  `json:"only_uncategorized,omitempty"` // default true
+	DryRun            bool   `json:"dry_run,omitempty"`
+}
+
+func (h *CategoryRuleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := UserIDFromRequest(r)
+	input, ok := decodeCategoryRule(w, r)
+	if !ok {
+		return
+	}
+	rule, err := h.ruleUC.Create(r.Context(), userID, LedgerIDFromRequest(r), input)
+	if err != nil {
+		writeCategoryRuleError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusCreated, "Category rule created successfully", rule, nil)
+}
+
+func (h *CategoryRuleHandler) List(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.ruleUC.List(r.Context(), UserIDFromRequest(r), LedgerIDFromRequest(r))
+	if err != nil {
+		writeCategoryRuleError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Category rules retrieved successfully", rules, nil)
+}
+
+func (h *CategoryRuleHandler) GetByID(w http.ResponseWriter, r *http.Request, id string) {
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid category rule id"})
+		return
+	}
+	rule, err := h.ruleUC.GetByID(r.Context(), UserIDFromRequest(r), id)
+	if err != nil {
+		writeCategoryRuleError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Category rule retrieved successfully", rule, nil)
+}
+
+func (h *CategoryRuleHandler) Update(w http.ResponseWriter, r *http.Request, id string) {
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid category rule id"})
+		return
+	}
+	input, ok := decodeCategoryRule(w, r)
+	if !ok {
+		return
+	}
+	rule, err := h.ruleUC.Update(r.Context(), UserIDFromRequest(r), id, input)
+	if err != nil {
+		writeCategoryRuleError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Category rule updated successfully", rule, nil)
+}
+
+func (h *CategoryRuleHandler) Delete(w http.ResponseWriter, r *http.Request, id string) {
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid category rule id"})
+		return
+	}
+	if err := h.ruleUC.Delete(r.Context(), UserIDFromRequest(r), id); err != nil {
+		writeCategoryRuleError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Category rule deleted successfully", nil, nil)
+}
+
+// Apply recategorizes existing expenses by the rules, or only reports what would change on a dry run
+func (h *CategoryRuleHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	var req ApplyCategoryRulesRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+			return
+		}
+	}
+	options := domain.ApplyCategoryRulesOptions{OnlyUncategorized: true, DryRun: req.DryRun}
+	if req.OnlyUncategorized != nil {
+		options.OnlyUncategorized = *req.OnlyUncategorized
+	}
+	if req.FromDate != "" {
+		t, err := parseDate(req.FromDate)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"from_date must use YYYY-MM-DD"})
+			return
+		}
+		options.FromDate = &t
+	}
+	if req.ToDate != "" {
+		t, err := parseDate(req.ToDate)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"to_date must use YYYY-MM-DD"})
+			return
+		}
+		options.ToDate = &t
+	}
+
+	result, err := h.ruleUC.Apply(r.Context(), UserIDFromRequest(r), LedgerIDFromRequest(r), options)
+	if err != nil {
+		writeCategoryRuleError(w, err)
+		return
+	}
+	message := "Category rules applied successfully"
+	if result.DryRun {
+		message = "Category rules previewed successfully"
+	}
+	apiresponse.Success(w, http.StatusOK, message, result, nil)
+}
+
+// decodeCategoryRule reads a CategoryRuleRequest, writing the validation error when it cannot
+func decodeCategoryRule(w http.ResponseWriter, r *http.Request) (domain.CategoryRuleInput, bool) {
+	var req CategoryRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return domain.CategoryRuleInput{}, false
+	}
+	if !isValidUUID(req.CategoryID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"category_id must be a valid UUID"})
+		return domain.CategoryRuleInput{}, false
+	}
+	return domain.CategoryRuleInput{
+		CategoryID:   req.CategoryID,
+		NoteContains: req.NoteContains,
+		NotePattern:  req.NotePattern,
+		MinAmount:    req.MinAmount,
+		MaxAmount:    req.MaxAmount,
+		Weekdays:     req.Weekdays,
+		Priority:     req.Priority,
+	}, true
+}
+
+func writeCategoryRuleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrCategoryRuleNotFound):
+		apiresponse.Error(w, http.StatusNotFound, "Category rule not found", []string{err.Error()})
+	case errors.Is(err, usecases.ErrUserIDRequired):
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"authorization required"})
+	case errors.Is(err, usecases.ErrLedgerReadOnly):
+		apiresponse.Error(w, http.StatusForbidden, "Forbidden", []string{err.Error()})
+	case errors.Is(err, usecases.ErrCategoryRuleCategoryNotFound),
+		errors.Is(err, usecases.ErrCategoryRuleNoCondition),
+		errors.Is(err, usecases.ErrInvalidCategoryRulePattern),
+		errors.Is(err, usecases.ErrInvalidCategoryRuleAmounts),
+		errors.Is(err, usecases.ErrInvalidCategoryRuleWeekday),
+		errors.Is(err, usecases.ErrInvalidDateRange):
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+	default:
+		apiresponse.InternalServerError(w)
+	}
+}
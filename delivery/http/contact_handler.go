@@ -0,0 +1,167 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+)
+
+// ContactHandler serves contact endpoints
+type ContactHandler struct {
+	contactUC *usecases.ContactUseCase
+	jwt       *auth.JWTService
+}
+
+// NewContactHandler creates a new contact handler
+func NewContactHandler(uc *usecases.ContactUseCase, jwt *auth.JWTService) *ContactHandler {
+	return &ContactHandler{contactUC: uc, jwt: jwt}
+}
+
+// ContactRequest is the JSON body for POST /contacts and PUT /contacts/{id}
+type ContactRequest struct {
+	Name string  `json:"name"`
+	Note *string `json:"note,omitempty"`
+}
+
+type contactBalanceResponse struct {
+	Contact  *domain.Contact         `json:"contact"`
+	Balances []domain.ContactBalance `json:"balances"`
+}
+
+func (h *ContactHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	var req ContactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+
+	contact, err := h.contactUC.Create(r.Context(), userID.String(), req.Name, req.Note)
+	if err != nil {
+		writeContactError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusCreated, "Contact created successfully", contact, nil)
+}
+
+func (h *ContactHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	contacts, err := h.contactUC.List(r.Context(), userID.String())
+	if err != nil {
+		writeContactError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Contacts retrieved successfully", contacts, nil)
+}
+
+func (h *ContactHandler) GetByID(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid contact id"})
+		return
+	}
+
+	contact, err := h.contactUC.GetByID(r.Context(), userID.String(), id)
+	if err != nil {
+		writeContactError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Contact retrieved successfully", contact, nil)
+}
+
+func (h *ContactHandler) Update(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid contact id"})
+		return
+	}
+
+	var req ContactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+
+	contact, err := h.contactUC.Update(r.Context(), userID.String(), id, req.Name, req.Note)
+	if err != nil {
+		writeContactError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Contact updated successfully", contact, nil)
+}
+
+func (h *ContactHandler) Delete(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid contact id"})
+		return
+	}
+
+	if err := h.contactUC.Delete(r.Context(), userID.String(), id); err != nil {
+		writeContactError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Contact deleted successfully", nil, nil)
+}
+
+// Balance returns what is outstanding with the contact, per currency
+func (h *ContactHandler) Balance(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid contact id"})
+		return
+	}
+
+	contact, balances, err := h.contactUC.Balances(r.Context(), userID.String(), id)
+	if err != nil {
+		writeContactError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Contact balance retrieved successfully", contactBalanceResponse{Contact: contact, Balances: balances}, nil)
+}
+
+func writeContactError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrContactNotFound):
+		apiresponse.Error(w, http.StatusNotFound, "Contact not found", []string{err.Error()})
+	case errors.Is(err, usecases.ErrContactExists):
+		apiresponse.Error(w, http.StatusConflict, "Contact already exists", []string{err.Error()})
+	case errors.Is(err, usecases.ErrContactHasDebts):
+		apiresponse.Error(w, http.StatusConflict, "Contact has debts", []string{err.Error()})
+	case errors.Is(err, usecases.ErrContactNameRequired):
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+	default:
+		apiresponse.InternalServerError(w)
+	}
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"expense_tracker/delivery/apiresponse"
 	"expense_tracker/domain"
 	"expense_tracker/infrastructure/auth"
@@ -23,23 +24,27 @@ func NewDebtHandler(usecase *usecases.DebtUsecase, jwt *auth.JWTService) *DebtHa
 }
 
 type createDebtRequest struct {
-	ID              string  `json:"id"`
-	UserID          string  `json:"user_id"`
-	Type            string  `json:"type"`
-	PeerName        string  `json:"peer_name"`
-	Amount          float64 `json:"amount"`
-	DueDate         string  `json:"due_date"`
-	ReminderEnabled bool    `json:"reminder_enabled"`
-	Note            *string `json:"note"`
+	ID              string       `json:"id"`
+	UserID          string       `json:"user_id"`
+	Type            string       `json:"type"`
+	PeerName        string       `json:"peer_name"`
+	ContactID       *string      `json:"contact_id"`
+	Amount          domain.Money `json:"amount"`
+	Currency        string       `json:"currency"`
+	DueDate         string       `json:"due_date"`
+	ReminderEnabled bool         `json:"reminder_enabled"`
+	Note            *string      `json:"note"`
 }
 
 type updateDebtRequest struct {
-	Type            string  `json:"type"`
-	PeerName        string  `json:"peer_name"`
-	Amount          float64 `json:"amount"`
-	DueDate         string  `json:"due_date"`
-	ReminderEnabled bool    `json:"reminder_enabled"`
-	Note            *string `json:"note"`
+	Type            string       `json:"type"`
+	PeerName        string       `json:"peer_name"`
+	ContactID       *string      `json:"contact_id"`
+	Amount          domain.Money `json:"amount"`
+	Currency        string       `json:"currency"`
+	DueDate         string       `json:"due_date"`
+	ReminderEnabled bool         `json:"reminder_enabled"`
+	Note            *string      `json:"note"`
 }
 
 func (h *DebtHandler) CreateDebt(w http.ResponseWriter, r *http.Request) {
@@ -53,6 +58,13 @@ func (h *DebtHandler) CreateDebt(w http.ResponseWriter, r *http.Request) {
 		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
 		return
 	}
+	if req.ContactID != nil && *req.ContactID == "" {
+		req.ContactID = nil
+	}
+	if req.ContactID != nil && !isValidUUID(*req.ContactID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"contact_id must be a valid UUID"})
+		return
+	}
 
 	dueDate, err := time.Parse("2006-01-02", req.DueDate)
 	if err != nil {
@@ -65,7 +77,9 @@ func (h *DebtHandler) CreateDebt(w http.ResponseWriter, r *http.Request) {
 		UserID:          userID,
 		Type:            req.Type,
 		PeerName:        req.PeerName,
+		ContactID:       req.ContactID,
 		Amount:          req.Amount,
+		Currency:        req.Currency,
 		DueDate:         dueDate,
 		ReminderEnabled: req.ReminderEnabled,
 		Note:            req.Note,
@@ -106,6 +120,13 @@ func (h *DebtHandler) UpdateDebt(w http.ResponseWriter, r *http.Request) {
 		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
 		return
 	}
+	if req.ContactID != nil && *req.ContactID == "" {
+		req.ContactID = nil
+	}
+	if req.ContactID != nil && !isValidUUID(*req.ContactID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"contact_id must be a valid UUID"})
+		return
+	}
 
 	dueDate, err := time.Parse("2006-01-02", req.DueDate)
 	if err != nil {
@@ -117,7 +138,9 @@ func (h *DebtHandler) UpdateDebt(w http.ResponseWriter, r *http.Request) {
 		ID:              debtID,
 		Type:            req.Type,
 		PeerName:        req.PeerName,
+		ContactID:       req.ContactID,
 		Amount:          req.Amount,
+		Currency:        req.Currency,
 		DueDate:         dueDate,
 		ReminderEnabled: req.ReminderEnabled,
 		Note:            req.Note,
@@ -174,6 +197,25 @@ func (h *DebtHandler) ListDebts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if pagination.Cursor != nil {
+		cursor, err := domain.DecodePageCursor(*pagination.Cursor)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+			return
+		}
+		debts, total, cursors, err := h.usecase.ListPageByUser(r.Context(), userID, repository.ListOptions{
+			Limit:     pagination.PageSize,
+			SkipTotal: !pagination.IncludeTotal,
+		}, cursor)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Unable to retrieve debts", []string{err.Error()})
+			return
+		}
+		apiresponse.CursorSuccess(w, http.StatusOK, "Debts retrieved successfully", debts,
+			cursors.Next, cursors.Prev, cursorTotal(pagination, total))
+		return
+	}
+
 	debts, total, err := h.usecase.ListByUser(r.Context(), userID, repository.ListOptions{
 		Limit:  pagination.PageSize,
 		Offset: pagination.Offset(),
@@ -233,6 +275,208 @@ func (h *DebtHandler) ListUpcomingDebts(w http.ResponseWriter, r *http.Request)
 	)
 }
 
+type debtPaymentRequest struct {
+	Amount   domain.Money `json:"amount"`
+	PaidDate string       `json:"paid_date"` // YYYY-MM-DD; defaults to today
+	Note     *string      `json:"note"`
+}
+
+type debtPaymentResponse struct {
+	Payment *domain.DebtPayment `json:"payment"`
+	Debt    *domain.Debt        `json:"debt"`
+}
+
+func (h *DebtHandler) ListPayments(w http.ResponseWriter, r *http.Request, debtID string) {
+	if _, ok := h.ownedDebt(w, r, debtID); !ok {
+		return
+	}
+
+	payments, err := h.usecase.ListPayments(r.Context(), debtID)
+	if err != nil {
+		apiresponse.InternalServerError(w)
+		return
+	}
+
+	apiresponse.Success(w, http.StatusOK, "Debt payments retrieved successfully", payments, nil)
+}
+
+func (h *DebtHandler) CreatePayment(w http.ResponseWriter, r *http.Request, debtID string) {
+	debt, ok := h.ownedDebt(w, r, debtID)
+	if !ok {
+		return
+	}
+
+	var req debtPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+	var paidDate time.Time
+	if req.PaidDate != "" {
+		parsed, err := parseDate(req.PaidDate)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"paid_date must use YYYY-MM-DD"})
+			return
+		}
+		paidDate = parsed
+	}
+
+	payment, updated, err := h.usecase.AddPayment(r.Context(), debt, req.Amount, paidDate, req.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecases.ErrAmountMustBePositive),
+			errors.Is(err, usecases.ErrAmountPrecision),
+			errors.Is(err, usecases.ErrPaymentExceedsDebt),
+			errors.Is(err, usecases.ErrPaidDateInFuture),
+			errors.Is(err, usecases.ErrDebtAlreadyPaid):
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+		default:
+			apiresponse.InternalServerError(w)
+		}
+		return
+	}
+
+	apiresponse.Success(w, http.StatusCreated, "Debt payment recorded successfully", debtPaymentResponse{Payment: payment, Debt: updated}, nil)
+}
+
+type reminderRequest struct {
+	RemindAt *time.Time `json:"remind_at"`
+	Enabled  *bool      `json:"enabled"`
+}
+
+func (h *DebtHandler) ListReminders(w http.ResponseWriter, r *http.Request, debtID string) {
+	if _, ok := h.ownedDebt(w, r, debtID); !ok {
+		return
+	}
+
+	reminders, err := h.usecase.ListReminders(r.Context(), debtID)
+	if err != nil {
+		apiresponse.InternalServerError(w)
+		return
+	}
+
+	apiresponse.Success(w, http.StatusOK, "Reminders retrieved successfully", reminders, nil)
+}
+
+func (h *DebtHandler) CreateReminder(w http.ResponseWriter, r *http.Request, debtID string) {
+	debt, ok := h.ownedDebt(w, r, debtID)
+	if !ok {
+		return
+	}
+
+	var req reminderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body; remind_at must be RFC 3339"})
+		return
+	}
+	if req.RemindAt == nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{usecases.ErrRemindAtRequired.Error()})
+		return
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	reminder, err := h.usecase.CreateReminder(r.Context(), debt, *req.RemindAt, enabled)
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	apiresponse.Success(w, http.StatusCreated, "Reminder created successfully", reminder, nil)
+}
+
+func (h *DebtHandler) UpdateReminder(w http.ResponseWriter, r *http.Request, debtID, reminderID string) {
+	if _, ok := h.ownedDebt(w, r, debtID); !ok {
+		return
+	}
+
+	var req reminderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body; remind_at must be RFC 3339"})
+		return
+	}
+
+	reminder, err := h.usecase.UpdateReminder(r.Context(), debtID, reminderID, req.RemindAt, req.Enabled)
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	apiresponse.Success(w, http.StatusOK, "Reminder updated successfully", reminder, nil)
+}
+
+func (h *DebtHandler) DeleteReminder(w http.ResponseWriter, r *http.Request, debtID, reminderID string) {
+	if _, ok := h.ownedDebt(w, r, debtID); !ok {
+		return
+	}
+
+	if err := h.usecase.DeleteReminder(r.Context(), debtID, reminderID); err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	apiresponse.Success(w, http.StatusOK, "Reminder deleted successfully", nil, nil)
+}
+
+// ownedDebt loads the debt and checks it belongs to the authenticated user
+func (h *DebtHandler) ownedDebt(w http.ResponseWriter, r *http.Request, debtID string) (*domain.Debt, bool) {
+	userID, ok := h.authenticatedUserID(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	debt, err := h.usecase.GetByID(r.Context(), debtID)
+	if err != nil {
+		apiresponse.Error(w, http.StatusNotFound, "Debt not found", []string{"debt not found"})
+		return nil, false
+	}
+	if debt.UserID != userID {
+		apiresponse.Error(w, http.StatusForbidden, "Forbidden", []string{"forbidden"})
+		return nil, false
+	}
+	return debt, true
+}
+
+func writeReminderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrReminderNotFound):
+		apiresponse.Error(w, http.StatusNotFound, "Reminder not found", []string{err.Error()})
+	case errors.Is(err, usecases.ErrRemindAtRequired),
+		errors.Is(err, usecases.ErrRemindAtInPast),
+		errors.Is(err, usecases.ErrTooManyReminders),
+		errors.Is(err, usecases.ErrDebtAlreadyPaid):
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+	default:
+		apiresponse.InternalServerError(w)
+	}
+}
+
+// extractReminderPath splits /debts/{id}/reminders[/{reminderID}]; ok is false for other paths
+func extractReminderPath(path string) (debtID, reminderID string, ok bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 || len(parts) > 4 || parts[0] != "debts" || parts[2] != "reminders" || parts[1] == "" {
+		return "", "", false
+	}
+	if len(parts) == 4 {
+		if parts[3] == "" {
+			return "", "", false
+		}
+		return parts[1], parts[3], true
+	}
+	return parts[1], "", true
+}
+
+// extractPaymentsPath returns the debt ID of /debts/{id}/payments; ok is false for other paths
+func extractPaymentsPath(path string) (debtID string, ok bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "debts" || parts[2] != "payments" || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
 func extractDebtID(path string) string {
 	path = strings.TrimSuffix(path, "/")
 	parts := strings.Split(path, "/")
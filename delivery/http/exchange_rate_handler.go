@@ -0,0 +1,116 @@
+package http
+
+import (
+	"crypto/subtle"
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+)
+
+// maxExchangeRateBody caps the size of an exchange rate upload
+const maxExchangeRateBody = 5 << 20
+
+// ExchangeRateHandler serves exchange rate endpoints
+type ExchangeRateHandler struct {
+	rateUC   *usecases.ExchangeRateUseCase
+	jwt      *auth.JWTService
+	adminKey string
+}
+
+// NewExchangeRateHandler creates a new exchange rate handler. Imports through the admin endpoint
+// need the X-Admin-Key header to match adminKey; they are disabled when adminKey is empty.
+func NewExchangeRateHandler(uc *usecases.ExchangeRateUseCase, jwt *auth.JWTService, adminKey string) *ExchangeRateHandler {
+	return &ExchangeRateHandler{rateUC: uc, jwt: jwt, adminKey: adminKey}
+}
+
+func (h *ExchangeRateHandler) List(w http.ResponseWriter, r *http.Request) {
+	if _, err := authenticateRequest(r, h.jwt); err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	pagination, err := apiresponse.ParsePagination(r)
+	if err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+		return
+	}
+
+	q := r.URL.Query()
+	filter := domain.ExchangeRateFilter{
+		Base:   q.Get("base"),
+		Quote:  q.Get("quote"),
+		Limit:  pagination.PageSize,
+		Offset: pagination.Offset(),
+	}
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{{"from_date", &filter.FromDate}, {"to_date", &filter.ToDate}} {
+		if s := q.Get(param.name); s != "" {
+			t, err := parseDate(s)
+			if err != nil {
+				apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{param.name + " must use YYYY-MM-DD"})
+				return
+			}
+			*param.dest = &t
+		}
+	}
+
+	rates, total, err := h.rateUC.List(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, usecases.ErrInvalidCurrency) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+			return
+		}
+		apiresponse.InternalServerError(w)
+		return
+	}
+	apiresponse.PaginatedSuccess(
+		w,
+		http.StatusOK,
+		"Exchange rates retrieved successfully",
+		rates,
+		apiresponse.NewPaginationMeta(pagination.Page, pagination.PageSize, total),
+	)
+}
+
+// Import stores the uploaded rates. A text/csv body is read as CSV, anything else as JSON.
+func (h *ExchangeRateHandler) Import(w http.ResponseWriter, r *http.Request) {
+	if h.adminKey == "" {
+		apiresponse.Error(w, http.StatusForbidden, "Forbidden", []string{"exchange rate imports are disabled"})
+		return
+	}
+	key := r.Header.Get("X-Admin-Key")
+	if subtle.ConstantTimeCompare([]byte(key), []byte(h.adminKey)) != 1 {
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"invalid admin key"})
+		return
+	}
+
+	format := usecases.ExchangeRateFormatJSON
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		format = usecases.ExchangeRateFormatCSV
+	}
+	rates, err := usecases.ParseExchangeRates(http.MaxBytesReader(w, r.Body, maxExchangeRateBody), format)
+	if err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+		return
+	}
+
+	imported, err := h.rateUC.Import(r.Context(), rates)
+	if err != nil {
+		if errors.Is(err, usecases.ErrNoExchangeRates) || errors.Is(err, usecases.ErrTooManyExchangeRates) ||
+			errors.Is(err, usecases.ErrInvalidExchangeRate) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+			return
+		}
+		apiresponse.InternalServerError(w)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Exchange rates imported successfully", map[string]int{"imported": imported}, nil)
+}
@@ -2,7 +2,9 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"expense_tracker/delivery/apiresponse"
@@ -14,7 +16,8 @@ import (
 
 // ExpenseHandler handles expense HTTP endpoints
 type ExpenseHandler struct {
-	expenseUC *usecases.ExpenseUseCase
+	expenseUC   *usecases.ExpenseUseCase
+	attachments *AttachmentHandler
 }
 
 // NewExpenseHandler creates a new expense handler
@@ -22,29 +25,81 @@ func NewExpenseHandler(expenseUC *usecases.ExpenseUseCase) *ExpenseHandler {
 	return &ExpenseHandler{expenseUC: expenseUC}
 }
 
+// SetAttachmentHandler serves /expenses/{id}/attachments with attachments
+func (h *ExpenseHandler) SetAttachmentHandler(attachments *AttachmentHandler) {
+	h.attachments = attachments
+}
+
+// maxRecurrenceInterval, maxRecurrenceCount and maxPreviewOccurrences bound recurrence rules and previews
+const (
+	maxRecurrenceInterval = 999
+	maxRecurrenceCount    = 1000
+	maxPreviewOccurrences = 100
+)
+
 // CreateExpenseRequest is the JSON body for POST /expenses
 type CreateExpenseRequest struct {
-	ID              string  `json:"id"`
-	Amount          float64 `json:"amount"`
-	CategoryID      *string `json:"category_id,omitempty"`
-	IsRecurring     bool    `json:"is_recurring"`
-	RecurrenceType  string  `json:"recurrence_type,omitempty"`
-	NextDueDate     *string `json:"next_due_date,omitempty"` // YYYY-MM-DD
-	ReminderEnabled bool    `json:"reminder_enabled"`
-	Note            string  `json:"note,omitempty"`
-	ExpenseDate     string  `json:"expense_date"` // YYYY-MM-DD required
+	ID              string                 `json:"id"`
+	Amount          domain.Money           `json:"amount"`
+	Currency        string                 `json:"currency,omitempty"` // defaults to the user's default_currency
+	CategoryID      *string                `json:"category_id,omitempty"`
+	IsRecurring     bool                   `json:"is_recurring"`
+	RecurrenceType  string                 `json:"recurrence_type,omitempty"`
+	RecurrenceRule  *RecurrenceRuleRequest `json:"recurrence_rule,omitempty"`
+	NextDueDate     *string                `json:"next_due_date,omitempty"` // YYYY-MM-DD
+	ReminderEnabled bool                   `json:"reminder_enabled"`
+	Note            string                 `json:"note,omitempty"`
+	ExpenseDate     string                 `json:"expense_date"` // YYYY-MM-DD required
+	Split           *SplitRequest          `json:"split,omitempty"`
+}
+
+// SplitRequest shares a new expense among participants; amount is then the total paid
+type SplitRequest struct {
+	Method       string                    `json:"method"`             // equal, exact, percentage or shares
+	DueDate      string                    `json:"due_date,omitempty"` // YYYY-MM-DD; when the others' debts are due
+	Participants []domain.SplitParticipant `json:"participants"`
+}
+
+// splitExpenseResponse is returned for a split expense: the user's share and the debts of the others
+type splitExpenseResponse struct {
+	Expense *domain.Expense `json:"expense"`
+	Debts   []*domain.Debt  `json:"debts"`
 }
 
 // UpdateExpenseRequest is the JSON body for PUT /expenses/:id
 type UpdateExpenseRequest struct {
-	Amount          *float64 `json:"amount,omitempty"`
-	CategoryID      *string  `json:"category_id,omitempty"`
-	IsRecurring     *bool    `json:"is_recurring,omitempty"`
-	RecurrenceType  *string  `json:"recurrence_type,omitempty"`
-	NextDueDate     *string  `json:"next_due_date,omitempty"`
-	ReminderEnabled *bool    `json:"reminder_enabled,omitempty"`
-	Note            *string  `json:"note,omitempty"`
-	ExpenseDate     *string  `json:"expense_date,omitempty"`
+	Amount          *domain.Money          `json:"amount,omitempty"`
+	Currency        *string                `json:"currency,omitempty"`
+	CategoryID      *string                `json:"category_id,omitempty"`
+	IsRecurring     *bool                  `json:"is_recurring,omitempty"`
+	RecurrenceType  *string                `json:"recurrence_type,omitempty"`
+	RecurrenceRule  *RecurrenceRuleRequest `json:"recurrence_rule,omitempty"`
+	NextDueDate     *string                `json:"next_due_date,omitempty"`
+	ReminderEnabled *bool                  `json:"reminder_enabled,omitempty"`
+	Note            *string                `json:"note,omitempty"`
+	ExpenseDate     *string                `json:"expense_date,omitempty"`
+}
+
+// RecurrenceRuleRequest is the JSON form of an RRULE-style recurrence rule
+type RecurrenceRuleRequest struct {
+	Frequency  string   `json:"frequency"`
+	Interval   int      `json:"interval,omitempty"`
+	ByWeekday  []string `json:"by_weekday,omitempty"`
+	ByMonthDay []int    `json:"by_month_day,omitempty"`
+	Until      *string  `json:"until,omitempty"` // YYYY-MM-DD
+	Count      int      `json:"count,omitempty"`
+}
+
+// RecurrencePreviewRequest is the JSON body for POST /expenses/recurrence-preview
+type RecurrencePreviewRequest struct {
+	RecurrenceRule *RecurrenceRuleRequest `json:"recurrence_rule"`
+	StartDate      string                 `json:"start_date"` // YYYY-MM-DD
+	Limit          int                    `json:"limit,omitempty"`
+}
+
+// RecurrencePreviewResponse lists the dates a recurrence rule expands to
+type RecurrencePreviewResponse struct {
+	Dates []string `json:"dates"`
 }
 
 func (h *ExpenseHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -64,62 +119,60 @@ func (h *ExpenseHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validation
-	if req.Amount <= 0 {
-		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"amount must be positive"})
+	input, errMsg := buildCreateExpenseInput(userID, req)
+	if errMsg != "" {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{errMsg})
 		return
 	}
-	expenseDate, err := parseDate(req.ExpenseDate)
-	if err != nil {
-		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"expense_date is required and must use YYYY-MM-DD"})
-		return
+	if input.ID == "" {
+		input.ID = uuid.New().String()
 	}
-	if req.ID != "" && !isValidUUID(req.ID) {
-		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"id must be a valid UUID"})
+	input.LedgerID = LedgerIDFromRequest(r)
+	if req.Split != nil {
+		h.createSplit(w, r, input, *req.Split)
 		return
 	}
-	if req.CategoryID != nil && *req.CategoryID != "" && !isValidUUID(*req.CategoryID) {
-		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"category_id must be a valid UUID"})
-		return
-	}
-	recType := domain.RecurrenceType(req.RecurrenceType)
-	if req.IsRecurring && recType != domain.RecurrenceDaily && recType != domain.RecurrenceWeekly && recType != domain.RecurrenceMonthly {
-		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"recurrence_type must be daily, weekly, or monthly when is_recurring is true"})
+
+	expense, err := h.expenseUC.Create(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, usecases.ErrAmountPrecision) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+			return
+		}
+		apiresponse.Error(w, http.StatusBadRequest, "Expense creation failed", []string{"unable to create expense"})
 		return
 	}
-	var nextDue *time.Time
-	if req.NextDueDate != nil && *req.NextDueDate != "" {
-		t, err := parseDate(*req.NextDueDate)
+	apiresponse.Success(w, http.StatusCreated, "Expense created successfully", expense, nil)
+}
+
+func (h *ExpenseHandler) createSplit(w http.ResponseWriter, r *http.Request, input domain.CreateExpenseInput, req SplitRequest) {
+	split := domain.Split{Method: domain.SplitMethod(req.Method), Participants: req.Participants}
+	if req.DueDate != "" {
+		t, err := parseDate(req.DueDate)
 		if err != nil {
-			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"next_due_date must use YYYY-MM-DD"})
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"split.due_date must use YYYY-MM-DD"})
 			return
 		}
-		nextDue = &t
+		split.DueDate = t
 	}
-
-	expenseID := req.ID
-	if expenseID == "" {
-		expenseID = uuid.New().String()
+	for _, p := range split.Participants {
+		if p.ContactID != nil && !isValidUUID(*p.ContactID) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"contact_id must be a valid UUID"})
+			return
+		}
 	}
 
-	input := domain.CreateExpenseInput{
-		ID:              expenseID,
-		UserID:          userID,
-		Amount:          req.Amount,
-		CategoryID:      req.CategoryID,
-		IsRecurring:     req.IsRecurring,
-		RecurrenceType:  recType,
-		NextDueDate:     nextDue,
-		ReminderEnabled: req.ReminderEnabled,
-		Note:            req.Note,
-		ExpenseDate:     expenseDate,
-	}
-	expense, err := h.expenseUC.Create(r.Context(), input)
+	expense, debts, err := h.expenseUC.CreateSplit(r.Context(), input, split)
 	if err != nil {
+		if errors.Is(err, usecases.ErrInvalidSplit) || errors.Is(err, usecases.ErrContactNotFound) ||
+			errors.Is(err, usecases.ErrDueDateInPast) || errors.Is(err, usecases.ErrAmountPrecision) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+			return
+		}
 		apiresponse.Error(w, http.StatusBadRequest, "Expense creation failed", []string{"unable to create expense"})
 		return
 	}
-	apiresponse.Success(w, http.StatusCreated, "Expense created successfully", expense, nil)
+	apiresponse.Success(w, http.StatusCreated, "Expense created successfully", splitExpenseResponse{Expense: expense, Debts: debts}, nil)
 }
 
 func (h *ExpenseHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -166,9 +219,27 @@ func (h *ExpenseHandler) List(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filter := usecases.ParseExpenseFilter(userID, fromDate, toDate, categoryID)
+	filter.LedgerID = LedgerIDFromRequest(r)
 	filter.Limit = pagination.PageSize
 	filter.Offset = pagination.Offset()
 
+	if pagination.Cursor != nil {
+		cursor, err := domain.DecodePageCursor(*pagination.Cursor)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+			return
+		}
+		filter.SkipTotal = !pagination.IncludeTotal
+		list, total, cursors, err := h.expenseUC.ListPage(r.Context(), filter, cursor)
+		if err != nil {
+			apiresponse.InternalServerError(w)
+			return
+		}
+		apiresponse.CursorSuccess(w, http.StatusOK, "Expenses retrieved successfully", list,
+			cursors.Next, cursors.Prev, cursorTotal(pagination, total))
+		return
+	}
+
 	list, total, err := h.expenseUC.List(r.Context(), filter)
 	if err != nil {
 		apiresponse.InternalServerError(w)
@@ -233,12 +304,20 @@ func (h *ExpenseHandler) Update(w http.ResponseWriter, r *http.Request, id strin
 
 	input := domain.UpdateExpenseInput{}
 	if req.Amount != nil {
-		if *req.Amount <= 0 {
+		if !req.Amount.IsPositive() {
 			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"amount must be positive"})
 			return
 		}
 		input.Amount = req.Amount
 	}
+	if req.Currency != nil {
+		currency := domain.NormalizeCurrency(*req.Currency)
+		if !domain.ValidCurrency(currency) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{usecases.ErrInvalidCurrency.Error()})
+			return
+		}
+		input.Currency = &currency
+	}
 	input.CategoryID = req.CategoryID
 	if req.CategoryID != nil && *req.CategoryID != "" && !isValidUUID(*req.CategoryID) {
 		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"category_id must be a valid UUID"})
@@ -247,8 +326,23 @@ func (h *ExpenseHandler) Update(w http.ResponseWriter, r *http.Request, id strin
 	input.IsRecurring = req.IsRecurring
 	if req.RecurrenceType != nil {
 		rt := domain.RecurrenceType(*req.RecurrenceType)
+		if !domain.ValidRecurrenceType(rt) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"recurrence_type must be daily, weekly, monthly, or yearly"})
+			return
+		}
 		input.RecurrenceType = &rt
 	}
+	if req.RecurrenceRule != nil {
+		rule, errMsg := buildRecurrenceRule(*req.RecurrenceRule)
+		if errMsg == "" && input.RecurrenceType != nil && *input.RecurrenceType != rule.Frequency {
+			errMsg = "recurrence_type must match recurrence_rule.frequency"
+		}
+		if errMsg != "" {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{errMsg})
+			return
+		}
+		input.RecurrenceRule = rule
+	}
 	if req.NextDueDate != nil {
 		t, err := parseDate(*req.NextDueDate)
 		if err != nil {
@@ -270,6 +364,10 @@ func (h *ExpenseHandler) Update(w http.ResponseWriter, r *http.Request, id strin
 
 	expense, err := h.expenseUC.Update(r.Context(), id, userID, input)
 	if err != nil {
+		if errors.Is(err, usecases.ErrAmountPrecision) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+			return
+		}
 		apiresponse.InternalServerError(w)
 		return
 	}
@@ -306,3 +404,169 @@ func (h *ExpenseHandler) Delete(w http.ResponseWriter, r *http.Request, id strin
 	}
 	apiresponse.Success(w, http.StatusOK, "Expense deleted successfully", nil, nil)
 }
+
+// buildCreateExpenseInput validates a create request and converts it to the domain input.
+// It returns a non-empty message when validation fails. ID is left empty when the client omits it.
+func buildCreateExpenseInput(userID string, req CreateExpenseRequest) (domain.CreateExpenseInput, string) {
+	if !req.Amount.IsPositive() {
+		return domain.CreateExpenseInput{}, "amount must be positive"
+	}
+	expenseDate, err := parseDate(req.ExpenseDate)
+	if err != nil {
+		return domain.CreateExpenseInput{}, "expense_date is required and must use YYYY-MM-DD"
+	}
+	if req.ID != "" && !isValidUUID(req.ID) {
+		return domain.CreateExpenseInput{}, "id must be a valid UUID"
+	}
+	currency := domain.NormalizeCurrency(req.Currency)
+	if currency != "" && !domain.ValidCurrency(currency) {
+		return domain.CreateExpenseInput{}, usecases.ErrInvalidCurrency.Error()
+	}
+	if req.CategoryID != nil && *req.CategoryID != "" && !isValidUUID(*req.CategoryID) {
+		return domain.CreateExpenseInput{}, "category_id must be a valid UUID"
+	}
+	recType := domain.RecurrenceType(req.RecurrenceType)
+	var rule *domain.RecurrenceRule
+	if req.IsRecurring && req.RecurrenceRule != nil {
+		var errMsg string
+		if rule, errMsg = buildRecurrenceRule(*req.RecurrenceRule); errMsg != "" {
+			return domain.CreateExpenseInput{}, errMsg
+		}
+		if recType != "" && recType != rule.Frequency {
+			return domain.CreateExpenseInput{}, "recurrence_type must match recurrence_rule.frequency"
+		}
+		recType = rule.Frequency
+	}
+	if req.IsRecurring && !domain.ValidRecurrenceType(recType) {
+		return domain.CreateExpenseInput{}, "recurrence_type must be daily, weekly, monthly, or yearly when is_recurring is true"
+	}
+	var nextDue *time.Time
+	if req.NextDueDate != nil && *req.NextDueDate != "" {
+		t, err := parseDate(*req.NextDueDate)
+		if err != nil {
+			return domain.CreateExpenseInput{}, "next_due_date must use YYYY-MM-DD"
+		}
+		nextDue = &t
+	}
+
+	return domain.CreateExpenseInput{
+		ID:              req.ID,
+		UserID:          userID,
+		Amount:          req.Amount,
+		Currency:        currency,
+		CategoryID:      req.CategoryID,
+		IsRecurring:     req.IsRecurring,
+		RecurrenceType:  recType,
+		RecurrenceRule:  rule,
+		NextDueDate:     nextDue,
+		ReminderEnabled: req.ReminderEnabled,
+		Note:            req.Note,
+		ExpenseDate:     expenseDate,
+	}, ""
+}
+
+// PreviewRecurrence expands a recurrence rule into its first dates without saving anything
+func (h *ExpenseHandler) PreviewRecurrence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		return
+	}
+	if UserIDFromRequest(r) == "" {
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"authorization required"})
+		return
+	}
+
+	var req RecurrencePreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+	if req.RecurrenceRule == nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"recurrence_rule is required"})
+		return
+	}
+	rule, errMsg := buildRecurrenceRule(*req.RecurrenceRule)
+	if errMsg != "" {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{errMsg})
+		return
+	}
+	start, err := parseDate(req.StartDate)
+	if err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"start_date is required and must use YYYY-MM-DD"})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+	if req.Limit < 1 || req.Limit > maxPreviewOccurrences {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"limit must be between 1 and 100"})
+		return
+	}
+
+	dates := h.expenseUC.PreviewRecurrence(*rule, start, req.Limit)
+	resp := RecurrencePreviewResponse{Dates: make([]string, 0, len(dates))}
+	for _, d := range dates {
+		resp.Dates = append(resp.Dates, d.Format("2006-01-02"))
+	}
+	apiresponse.Success(w, http.StatusOK, "Recurrence preview generated successfully", resp, nil)
+}
+
+// buildRecurrenceRule validates a recurrence rule request and converts it to the domain rule.
+// It returns a non-empty message when validation fails.
+func buildRecurrenceRule(req RecurrenceRuleRequest) (*domain.RecurrenceRule, string) {
+	rule := &domain.RecurrenceRule{
+		Frequency: domain.RecurrenceType(req.Frequency),
+		Interval:  req.Interval,
+		Count:     req.Count,
+	}
+	if !domain.ValidRecurrenceType(rule.Frequency) {
+		return nil, "recurrence_rule.frequency must be daily, weekly, monthly, or yearly"
+	}
+	if rule.Interval == 0 {
+		rule.Interval = 1
+	}
+	if rule.Interval < 1 || rule.Interval > maxRecurrenceInterval {
+		return nil, "recurrence_rule.interval must be between 1 and 999"
+	}
+	if len(req.ByWeekday) > 0 {
+		if rule.Frequency != domain.RecurrenceWeekly {
+			return nil, "recurrence_rule.by_weekday is only allowed with weekly frequency"
+		}
+		seen := make(map[string]bool, len(req.ByWeekday))
+		for _, code := range req.ByWeekday {
+			code = strings.ToUpper(code)
+			if _, ok := domain.ParseWeekday(code); !ok {
+				return nil, "recurrence_rule.by_weekday entries must be one of MO, TU, WE, TH, FR, SA, SU"
+			}
+			if !seen[code] {
+				seen[code] = true
+				rule.ByWeekday = append(rule.ByWeekday, code)
+			}
+		}
+	}
+	if len(req.ByMonthDay) > 0 {
+		if rule.Frequency != domain.RecurrenceMonthly {
+			return nil, "recurrence_rule.by_month_day is only allowed with monthly frequency"
+		}
+		for _, day := range req.ByMonthDay {
+			if day == 0 || day < -31 || day > 31 {
+				return nil, "recurrence_rule.by_month_day entries must be 1..31 or -31..-1"
+			}
+		}
+		rule.ByMonthDay = req.ByMonthDay
+	}
+	if req.Until != nil && *req.Until != "" {
+		t, err := parseDate(*req.Until)
+		if err != nil {
+			return nil, "recurrence_rule.until must use YYYY-MM-DD"
+		}
+		rule.Until = &t
+	}
+	if rule.Count < 0 || rule.Count > maxRecurrenceCount {
+		return nil, "recurrence_rule.count must be between 1 and 1000"
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, "recurrence_rule cannot set both until and count"
+	}
+	return rule, ""
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/usecases"
+)
+
+// Search finds expenses by the words of their note or category name, narrowed by facets. Query
+// params: q, category_id (repeatable or comma-separated), min_amount, max_amount, from_date,
+// to_date, recurring, has_attachment, sort (date or amount), order (asc or desc), page, page_size.
+// The facet counts are returned in meta.facets.
+func (h *ExpenseHandler) Search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		return
+	}
+	userID := UserIDFromRequest(r)
+	if userID == "" {
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"authorization required"})
+		return
+	}
+
+	pagination, err := apiresponse.ParsePagination(r)
+	if err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+		return
+	}
+	search, errs := parseExpenseSearch(r)
+	if len(errs) > 0 {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", errs)
+		return
+	}
+	search.UserID = userID
+	search.LedgerID = LedgerIDFromRequest(r)
+	search.Limit = pagination.PageSize
+	search.Offset = pagination.Offset()
+
+	list, total, facets, err := h.expenseUC.Search(r.Context(), search)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecases.ErrSearchQueryTooLong), errors.Is(err, usecases.ErrInvalidSearchAmounts),
+			errors.Is(err, usecases.ErrInvalidSearchSort), errors.Is(err, usecases.ErrTooManySearchCategories),
+			errors.Is(err, usecases.ErrInvalidDateRange):
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+		default:
+			apiresponse.InternalServerError(w)
+		}
+		return
+	}
+	meta := apiresponse.NewPaginationMeta(pagination.Page, pagination.PageSize, total)
+	apiresponse.Success(w, http.StatusOK, "Expenses retrieved successfully", map[string]interface{}{"items": list},
+		apiresponse.Meta{Pagination: &meta, Facets: facets})
+}
+
+// parseExpenseSearch reads the search query params of r, returning every invalid one
+func parseExpenseSearch(r *http.Request) (domain.ExpenseSearch, []string) {
+	query := r.URL.Query()
+	search := domain.ExpenseSearch{Query: query.Get("q")}
+	var errs []string
+
+	for _, value := range query["category_id"] {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id == "" {
+				continue
+			}
+			if !isValidUUID(id) {
+				errs = append(errs, "category_id must be a valid UUID")
+				break
+			}
+			search.CategoryIDs = append(search.CategoryIDs, id)
+		}
+	}
+	for _, bound := range []struct {
+		name   string
+		target **domain.Money
+	}{{"min_amount", &search.MinAmount}, {"max_amount", &search.MaxAmount}} {
+		if s := query.Get(bound.name); s != "" {
+			amount, err := domain.ParseMoney(s)
+			if err != nil {
+				errs = append(errs, bound.name+" must be a number")
+				continue
+			}
+			*bound.target = &amount
+		}
+	}
+	if s := query.Get("from_date"); s != "" {
+		t, err := parseDate(s)
+		if err != nil {
+			errs = append(errs, "from_date must use YYYY-MM-DD")
+		} else {
+			search.FromDate = &t
+		}
+	}
+	if s := query.Get("to_date"); s != "" {
+		t, err := parseDate(s)
+		if err != nil {
+			errs = append(errs, "to_date must use YYYY-MM-DD")
+		} else {
+			search.ToDate = &t
+		}
+	}
+	recurring, err := formBool(r, "recurring")
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	search.RecurringOnly = recurring
+	if s := query.Get("has_attachment"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			errs = append(errs, "has_attachment must be true or false")
+		} else {
+			search.HasAttachment = &b
+		}
+	}
+	search.Sort = domain.ExpenseSearchSort(query.Get("sort"))
+	switch strings.ToLower(query.Get("order")) {
+	case "", "desc":
+	case "asc":
+		search.Ascending = true
+	default:
+		errs = append(errs, "order must be asc or desc")
+	}
+	return search, errs
+}
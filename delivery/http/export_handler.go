@@ -0,0 +1,125 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/usecases"
+)
+
+// exportContentTypes are the Content-Type headers of the export formats
+var exportContentTypes = map[domain.ExportFormat]string{
+	domain.ExportFormatCSV:  "text/csv; charset=utf-8",
+	domain.ExportFormatJSON: "application/json",
+	domain.ExportFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportHandler serves file exports; JWTAuthMiddleware sets the user and ledger
+type ExportHandler struct {
+	exportUC *usecases.ExportUseCase
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(uc *usecases.ExportUseCase) *ExportHandler {
+	return &ExportHandler{exportUC: uc}
+}
+
+// Export streams expenses, debts or a report as a file download. Query parameters: type
+// (required), format (default csv), from and to (YYYY-MM-DD) and, for expenses, category_id.
+func (h *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userID := UserIDFromRequest(r)
+	if userID == "" {
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"authorization required"})
+		return
+	}
+
+	query := r.URL.Query()
+	request := domain.ExportRequest{
+		Type:   domain.ExportType(strings.ToLower(query.Get("type"))),
+		Format: domain.ExportFormat(strings.ToLower(query.Get("format"))),
+	}
+	if request.Format == "" {
+		request.Format = domain.ExportFormatCSV
+	}
+	var fromDate, toDate *time.Time
+	if s := query.Get("from"); s != "" {
+		t, err := parseDate(s)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"from must use YYYY-MM-DD"})
+			return
+		}
+		fromDate = &t
+	}
+	if s := query.Get("to"); s != "" {
+		t, err := parseDate(s)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"to must use YYYY-MM-DD"})
+			return
+		}
+		toDate = &t
+	}
+	var categoryID *string
+	if s := query.Get("category_id"); s != "" {
+		if !isValidUUID(s) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"category_id must be a valid UUID"})
+			return
+		}
+		categoryID = &s
+	}
+	request.Filter = usecases.ParseExpenseFilter(userID, fromDate, toDate, categoryID)
+	request.Filter.LedgerID = LedgerIDFromRequest(r)
+
+	if err := h.exportUC.Validate(userID, request); err != nil {
+		writeExportError(w, err)
+		return
+	}
+	out := &exportResponse{w: w, contentType: exportContentTypes[request.Format],
+		filename: string(request.Type) + "." + string(request.Format)}
+	if err := h.exportUC.Export(r.Context(), out, userID, request); err != nil {
+		if !out.started {
+			writeExportError(w, err)
+			return
+		}
+		// the download has begun, so all that is left is to cut it short
+		log.Printf("export: %s export for user %s failed part way: %v", request.Type, userID, err)
+	}
+}
+
+// exportResponse sends the download headers with the first bytes of the file, so an export that
+// fails before writing anything can still answer with a JSON error
+type exportResponse struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (e *exportResponse) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.w.Header().Set("Content-Type", e.contentType)
+		e.w.Header().Set("Content-Disposition", `attachment; filename="`+e.filename+`"`)
+		e.w.Header().Set("Cache-Control", "no-store")
+		e.w.WriteHeader(http.StatusOK)
+	}
+	return e.w.Write(p)
+}
+
+func writeExportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrUserIDRequired):
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"authorization required"})
+	case errors.Is(err, usecases.ErrInvalidExportType),
+		errors.Is(err, usecases.ErrInvalidExportFormat),
+		errors.Is(err, usecases.ErrExportDatesRequired),
+		errors.Is(err, usecases.ErrInvalidDateRange):
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+	default:
+		apiresponse.InternalServerError(w)
+	}
+}
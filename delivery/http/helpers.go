@@ -22,6 +22,14 @@ func isValidUUID(s string) bool {
 	return err == nil
 }
 
+// cursorTotal is the total of a keyset-paginated list, nil when it was not counted
+func cursorTotal(pagination apiresponse.PaginationParams, total int) *int {
+	if !pagination.IncludeTotal {
+		return nil
+	}
+	return &total
+}
+
 func isErrNoRows(err error) bool {
 	return errors.Is(err, sql.ErrNoRows)
 }
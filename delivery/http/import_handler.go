@@ -0,0 +1,150 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/usecases"
+)
+
+// maxImportSize caps the size of an uploaded statement, in bytes
+const maxImportSize = 5 << 20
+
+// ImportHandler serves bank statement imports; JWTAuthMiddleware sets the user and ledger
+type ImportHandler struct {
+	importUC *usecases.ImportUseCase
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(uc *usecases.ImportUseCase) *ImportHandler {
+	return &ImportHandler{importUC: uc}
+}
+
+// Import reads a statement uploaded as multipart/form-data in the field "file" and previews the
+// import, or imports it when commit is true. The other form fields are the import options.
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	userID := UserIDFromRequest(r)
+	if userID == "" {
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"authorization required"})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize+(1<<20))
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"body must be multipart/form-data with a file of at most 5 MB"})
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"file is required"})
+		return
+	}
+	defer file.Close()
+
+	options, errs := importOptions(r, header.Filename)
+	commit, err := formBool(r, "commit")
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	includeDuplicates, err := formBool(r, "include_duplicates")
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", errs)
+		return
+	}
+
+	ledgerID := LedgerIDFromRequest(r)
+	if !commit {
+		result, err := h.importUC.Preview(r.Context(), userID, ledgerID, file, options)
+		if err != nil {
+			writeImportError(w, err)
+			return
+		}
+		apiresponse.Success(w, http.StatusOK, "Import previewed successfully", result, nil)
+		return
+	}
+	result, err := h.importUC.Commit(r.Context(), userID, ledgerID, file, options, includeDuplicates)
+	if err != nil {
+		writeImportError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusCreated, "Expenses imported successfully", result, nil)
+}
+
+// importOptions reads the import options from the form. Without a format field the format is
+// taken from the file name's extension.
+func importOptions(r *http.Request, filename string) (domain.ImportOptions, []string) {
+	var errs []string
+	options := domain.ImportOptions{
+		Format:         domain.ImportFormat(strings.ToLower(strings.TrimSpace(r.FormValue("format")))),
+		DateColumn:     r.FormValue("date_column"),
+		AmountColumn:   r.FormValue("amount_column"),
+		NoteColumn:     r.FormValue("note_column"),
+		CategoryColumn: r.FormValue("category_column"),
+		CurrencyColumn: r.FormValue("currency_column"),
+		DateFormats:    r.Form["date_format"],
+		Currency:       r.FormValue("currency"),
+	}
+	if options.Format == "" {
+		options.Format = domain.ImportFormat(strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")))
+	}
+	if !domain.ValidImportFormat(options.Format) {
+		errs = append(errs, usecases.ErrInvalidImportFormat.Error())
+	}
+
+	switch delimiter := r.FormValue("delimiter"); {
+	case delimiter == "":
+	case delimiter == "tab" || delimiter == `\t`:
+		options.Delimiter = '\t'
+	case utf8.RuneCountInString(delimiter) == 1:
+		options.Delimiter, _ = utf8.DecodeRuneInString(delimiter)
+	default:
+		errs = append(errs, "delimiter must be a single character or tab")
+	}
+
+	for name, target := range map[string]*bool{
+		"no_header":      &options.NoHeader,
+		"decimal_comma":  &options.DecimalComma,
+		"invert_amounts": &options.InvertAmounts,
+	} {
+		value, err := formBool(r, name)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		*target = value
+	}
+	return options, errs
+}
+
+// formBool reads a true/false form field; a missing field is false
+func formBool(r *http.Request, name string) (bool, error) {
+	value := r.FormValue(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New(name + " must be true or false")
+	}
+	return b, nil
+}
+
+func writeImportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrInvalidImportFormat),
+		errors.Is(err, usecases.ErrInvalidImportFile),
+		errors.Is(err, usecases.ErrTooManyImportRows),
+		errors.Is(err, usecases.ErrInvalidCurrency):
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+	default:
+		apiresponse.InternalServerError(w)
+	}
+}
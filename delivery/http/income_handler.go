@@ -0,0 +1,343 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+)
+
+// IncomeHandler serves income and income category endpoints
+type IncomeHandler struct {
+	incomeUC *usecases.IncomeUseCase
+	jwt      *auth.JWTService
+}
+
+// NewIncomeHandler creates a new income handler
+func NewIncomeHandler(uc *usecases.IncomeUseCase, jwt *auth.JWTService) *IncomeHandler {
+	return &IncomeHandler{incomeUC: uc, jwt: jwt}
+}
+
+// CreateIncomeRequest is the JSON body for POST /income
+type CreateIncomeRequest struct {
+	Source         string                 `json:"source"`
+	Amount         domain.Money           `json:"amount"`
+	Currency       string                 `json:"currency,omitempty"` // defaults to the user's default_currency
+	CategoryID     *string                `json:"category_id,omitempty"`
+	IsRecurring    bool                   `json:"is_recurring"`
+	RecurrenceRule *RecurrenceRuleRequest `json:"recurrence_rule,omitempty"` // required when is_recurring
+	NextDueDate    *string                `json:"next_due_date,omitempty"`   // YYYY-MM-DD
+	Note           string                 `json:"note,omitempty"`
+	ReceivedDate   string                 `json:"received_date"` // YYYY-MM-DD required
+}
+
+// UpdateIncomeRequest is the JSON body for PUT /income/{id}; an empty category_id clears it
+type UpdateIncomeRequest struct {
+	Source         *string                `json:"source,omitempty"`
+	Amount         *domain.Money          `json:"amount,omitempty"`
+	Currency       *string                `json:"currency,omitempty"`
+	CategoryID     *string                `json:"category_id,omitempty"`
+	IsRecurring    *bool                  `json:"is_recurring,omitempty"`
+	RecurrenceRule *RecurrenceRuleRequest `json:"recurrence_rule,omitempty"`
+	NextDueDate    *string                `json:"next_due_date,omitempty"`
+	Note           *string                `json:"note,omitempty"`
+	ReceivedDate   *string                `json:"received_date,omitempty"`
+}
+
+// CreateIncomeCategoryRequest is the JSON body for POST /income/categories
+type CreateIncomeCategoryRequest struct {
+	Name string `json:"name"`
+}
+
+func (h *IncomeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	var req CreateIncomeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+
+	input := domain.CreateIncomeInput{
+		UserID:      userID.String(),
+		Source:      req.Source,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		IsRecurring: req.IsRecurring,
+		Note:        req.Note,
+	}
+	received, err := parseDate(req.ReceivedDate)
+	if err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"received_date is required and must use YYYY-MM-DD"})
+		return
+	}
+	input.ReceivedDate = received
+	if req.CategoryID != nil && *req.CategoryID != "" {
+		if !isValidUUID(*req.CategoryID) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"category_id must be a valid UUID"})
+			return
+		}
+		input.CategoryID = req.CategoryID
+	}
+	if req.RecurrenceRule != nil {
+		rule, errMsg := buildRecurrenceRule(*req.RecurrenceRule)
+		if errMsg != "" {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{errMsg})
+			return
+		}
+		input.RecurrenceRule = rule
+	}
+	if req.NextDueDate != nil {
+		t, err := parseDate(*req.NextDueDate)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"next_due_date must use YYYY-MM-DD"})
+			return
+		}
+		input.NextDueDate = &t
+	}
+
+	income, err := h.incomeUC.Create(r.Context(), input)
+	if err != nil {
+		writeIncomeError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusCreated, "Income created successfully", income, nil)
+}
+
+func (h *IncomeHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	pagination, err := apiresponse.ParsePagination(r)
+	if err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+		return
+	}
+
+	filter := domain.IncomeFilter{
+		UserID: userID.String(),
+		Limit:  pagination.PageSize,
+		Offset: pagination.Offset(),
+	}
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{{"from_date", &filter.FromDate}, {"to_date", &filter.ToDate}} {
+		if s := r.URL.Query().Get(param.name); s != "" {
+			t, err := parseDate(s)
+			if err != nil {
+				apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{param.name + " must use YYYY-MM-DD"})
+				return
+			}
+			*param.dest = &t
+		}
+	}
+	if s := r.URL.Query().Get("category_id"); s != "" {
+		if !isValidUUID(s) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"category_id must be a valid UUID"})
+			return
+		}
+		filter.CategoryID = &s
+	}
+
+	list, total, err := h.incomeUC.List(r.Context(), filter)
+	if err != nil {
+		writeIncomeError(w, err)
+		return
+	}
+	apiresponse.PaginatedSuccess(
+		w,
+		http.StatusOK,
+		"Income retrieved successfully",
+		list,
+		apiresponse.NewPaginationMeta(pagination.Page, pagination.PageSize, total),
+	)
+}
+
+func (h *IncomeHandler) GetByID(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid income id"})
+		return
+	}
+
+	income, err := h.incomeUC.GetByID(r.Context(), userID.String(), id)
+	if err != nil {
+		writeIncomeError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Income retrieved successfully", income, nil)
+}
+
+func (h *IncomeHandler) Update(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid income id"})
+		return
+	}
+
+	var req UpdateIncomeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+
+	input := domain.UpdateIncomeInput{
+		Source:      req.Source,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		CategoryID:  req.CategoryID,
+		IsRecurring: req.IsRecurring,
+		Note:        req.Note,
+	}
+	if req.CategoryID != nil && *req.CategoryID != "" && !isValidUUID(*req.CategoryID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"category_id must be a valid UUID"})
+		return
+	}
+	if req.RecurrenceRule != nil {
+		rule, errMsg := buildRecurrenceRule(*req.RecurrenceRule)
+		if errMsg != "" {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{errMsg})
+			return
+		}
+		input.RecurrenceRule = rule
+	}
+	if req.NextDueDate != nil {
+		t, err := parseDate(*req.NextDueDate)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"next_due_date must use YYYY-MM-DD"})
+			return
+		}
+		input.NextDueDate = &t
+	}
+	if req.ReceivedDate != nil {
+		t, err := parseDate(*req.ReceivedDate)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"received_date must use YYYY-MM-DD"})
+			return
+		}
+		input.ReceivedDate = &t
+	}
+
+	income, err := h.incomeUC.Update(r.Context(), userID.String(), id, input)
+	if err != nil {
+		writeIncomeError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Income updated successfully", income, nil)
+}
+
+func (h *IncomeHandler) Delete(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid income id"})
+		return
+	}
+
+	if err := h.incomeUC.Delete(r.Context(), userID.String(), id); err != nil {
+		writeIncomeError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Income deleted successfully", nil, nil)
+}
+
+func (h *IncomeHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	categories, err := h.incomeUC.ListCategories(r.Context(), userID.String())
+	if err != nil {
+		writeIncomeError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Income categories retrieved successfully", categories, nil)
+}
+
+func (h *IncomeHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	var req CreateIncomeCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+
+	category, err := h.incomeUC.CreateCategory(r.Context(), userID.String(), req.Name)
+	if err != nil {
+		writeIncomeError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusCreated, "Income category created successfully", category, nil)
+}
+
+func (h *IncomeHandler) DeleteCategory(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid income category id"})
+		return
+	}
+
+	if err := h.incomeUC.DeleteCategory(r.Context(), userID.String(), id); err != nil {
+		if errors.Is(err, usecases.ErrIncomeCategoryNotFound) {
+			apiresponse.Error(w, http.StatusNotFound, "Income category not found", []string{err.Error()})
+			return
+		}
+		writeIncomeError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Income category deleted successfully", nil, nil)
+}
+
+func writeIncomeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrIncomeNotFound):
+		apiresponse.Error(w, http.StatusNotFound, "Income not found", []string{err.Error()})
+	case errors.Is(err, usecases.ErrGlobalIncomeCategoryReadOnly):
+		apiresponse.Error(w, http.StatusForbidden, "Forbidden", []string{err.Error()})
+	case errors.Is(err, usecases.ErrIncomeCategoryNotFound),
+		errors.Is(err, usecases.ErrInvalidIncomeAmount),
+		errors.Is(err, usecases.ErrAmountPrecision),
+		errors.Is(err, usecases.ErrInvalidCurrency),
+		errors.Is(err, usecases.ErrIncomeSourceRequired),
+		errors.Is(err, usecases.ErrIncomeRecurrenceRequired),
+		errors.Is(err, usecases.ErrIncomeCategoryNameRequired):
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+	default:
+		apiresponse.InternalServerError(w)
+	}
+}
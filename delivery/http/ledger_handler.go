@@ -0,0 +1,265 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+)
+
+// LedgerHandler serves shared ledger and ledger member endpoints
+type LedgerHandler struct {
+	ledgerUC *usecases.LedgerUseCase
+	jwt      *auth.JWTService
+}
+
+// NewLedgerHandler creates a new ledger handler
+func NewLedgerHandler(uc *usecases.LedgerUseCase, jwt *auth.JWTService) *LedgerHandler {
+	return &LedgerHandler{ledgerUC: uc, jwt: jwt}
+}
+
+// LedgerRequest is the JSON body for POST /ledgers and PUT /ledgers/{id}
+type LedgerRequest struct {
+	Name string `json:"name"`
+}
+
+// AddLedgerMemberRequest is the JSON body for POST /ledgers/{id}/members
+type AddLedgerMemberRequest struct {
+	Email string            `json:"email"`
+	Role  domain.LedgerRole `json:"role"`
+}
+
+// UpdateLedgerMemberRequest is the JSON body for PUT /ledgers/{id}/members/{userId}
+type UpdateLedgerMemberRequest struct {
+	Role domain.LedgerRole `json:"role"`
+}
+
+func (h *LedgerHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	var req LedgerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+
+	ledger, err := h.ledgerUC.Create(r.Context(), userID.String(), req.Name)
+	if err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusCreated, "Ledger created successfully", ledger, nil)
+}
+
+func (h *LedgerHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	ledgers, err := h.ledgerUC.List(r.Context(), userID.String())
+	if err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Ledgers retrieved successfully", ledgers, nil)
+}
+
+func (h *LedgerHandler) GetByID(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid ledger id"})
+		return
+	}
+
+	ledger, err := h.ledgerUC.GetByID(r.Context(), userID.String(), id)
+	if err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Ledger retrieved successfully", ledger, nil)
+}
+
+func (h *LedgerHandler) Update(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid ledger id"})
+		return
+	}
+
+	var req LedgerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+
+	ledger, err := h.ledgerUC.Rename(r.Context(), userID.String(), id, req.Name)
+	if err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Ledger updated successfully", ledger, nil)
+}
+
+func (h *LedgerHandler) Delete(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid ledger id"})
+		return
+	}
+
+	if err := h.ledgerUC.Delete(r.Context(), userID.String(), id); err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Ledger deleted successfully", nil, nil)
+}
+
+func (h *LedgerHandler) ListMembers(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid ledger id"})
+		return
+	}
+
+	members, err := h.ledgerUC.Members(r.Context(), userID.String(), id)
+	if err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Ledger members retrieved successfully", members, nil)
+}
+
+func (h *LedgerHandler) AddMember(w http.ResponseWriter, r *http.Request, id string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid ledger id"})
+		return
+	}
+
+	var req AddLedgerMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+	if strings.TrimSpace(req.Email) == "" {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"email is required"})
+		return
+	}
+
+	member, err := h.ledgerUC.AddMember(r.Context(), userID.String(), id, req.Email, req.Role)
+	if err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusCreated, "Ledger member added successfully", member, nil)
+}
+
+func (h *LedgerHandler) UpdateMember(w http.ResponseWriter, r *http.Request, id, memberID string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) || !isValidUUID(memberID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid ledger or user id"})
+		return
+	}
+
+	var req UpdateLedgerMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+
+	member, err := h.ledgerUC.UpdateMemberRole(r.Context(), userID.String(), id, memberID, req.Role)
+	if err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Ledger member updated successfully", member, nil)
+}
+
+// RemoveMember removes a member; members remove themselves to leave the ledger
+func (h *LedgerHandler) RemoveMember(w http.ResponseWriter, r *http.Request, id, memberID string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(id) || !isValidUUID(memberID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid ledger or user id"})
+		return
+	}
+
+	if err := h.ledgerUC.RemoveMember(r.Context(), userID.String(), id, memberID); err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Ledger member removed successfully", nil, nil)
+}
+
+// extractLedgerMembersPath returns the IDs of /ledgers/{id}/members and
+// /ledgers/{id}/members/{userId}; ok is false for other paths
+func extractLedgerMembersPath(path string) (ledgerID, memberID string, ok bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 || len(parts) > 4 || parts[0] != "ledgers" || parts[2] != "members" || parts[1] == "" {
+		return "", "", false
+	}
+	if len(parts) == 4 {
+		if parts[3] == "" {
+			return "", "", false
+		}
+		return parts[1], parts[3], true
+	}
+	return parts[1], "", true
+}
+
+func writeLedgerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrLedgerNotFound),
+		errors.Is(err, usecases.ErrLedgerMemberNotFound),
+		errors.Is(err, usecases.ErrLedgerUserNotFound):
+		apiresponse.Error(w, http.StatusNotFound, "Not found", []string{err.Error()})
+	case errors.Is(err, usecases.ErrLedgerOwnerRequired):
+		apiresponse.Error(w, http.StatusForbidden, "Forbidden", []string{err.Error()})
+	case errors.Is(err, usecases.ErrLedgerMemberExists),
+		errors.Is(err, usecases.ErrLastLedgerOwner):
+		apiresponse.Error(w, http.StatusConflict, "Conflict", []string{err.Error()})
+	case errors.Is(err, usecases.ErrLedgerNameRequired),
+		errors.Is(err, usecases.ErrInvalidLedgerRole):
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+	default:
+		apiresponse.InternalServerError(w)
+	}
+}
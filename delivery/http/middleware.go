@@ -6,19 +6,37 @@ import (
 	"strings"
 
 	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
 	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
 )
 
 type contextKey string
 
 const UserIDContextKey contextKey = "user_id"
 
-// JWTAuthMiddleware validates Bearer token for /expenses and /categories; sets user ID in context.
+// LedgerIDContextKey holds the shared ledger a request works on, when it names one
+const LedgerIDContextKey contextKey = "ledger_id"
+
+// LedgerHeader names the shared ledger a request works on; the ledger_id query parameter does the same
+const LedgerHeader = "X-Ledger-ID"
+
+// LedgerRoles looks up a user's role in a shared ledger; "" means the user is not a member
+type LedgerRoles interface {
+	Role(ctx context.Context, ledgerID, userID string) (domain.LedgerRole, error)
+}
+
+// JWTAuthMiddleware validates Bearer token for /expenses, /categories, /budgets, /imports, /exports, /quick-add and /sync; sets user ID in context.
+// A request naming a ledger (X-Ledger-ID header or ledger_id query parameter) must come from one of its
+// members, and only owners and editors may send anything but GET; the ledger ID is then set in context too.
+// ledgers may be nil, which rejects every ledger. Sync always works on the user's own records.
 // /api-docs and / are left public (no auth required).
-func JWTAuthMiddleware(jwtSvc *auth.JWTService, next http.Handler) http.Handler {
+func JWTAuthMiddleware(jwtSvc *auth.JWTService, ledgers LedgerRoles, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
-		if strings.HasPrefix(path, "/expenses") || strings.HasPrefix(path, "/categories") {
+		if strings.HasPrefix(path, "/expenses") || strings.HasPrefix(path, "/categories") || strings.HasPrefix(path, "/budgets") ||
+			strings.HasPrefix(path, "/imports") || strings.HasPrefix(path, "/exports") || strings.HasPrefix(path, "/quick-add") ||
+			strings.HasPrefix(path, "/sync") {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
 				apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"missing authorization header"})
@@ -35,6 +53,33 @@ func JWTAuthMiddleware(jwtSvc *auth.JWTService, next http.Handler) http.Handler
 				return
 			}
 			ctx := context.WithValue(r.Context(), UserIDContextKey, userID.String())
+
+			ledgerID := r.Header.Get(LedgerHeader)
+			if ledgerID == "" {
+				ledgerID = r.URL.Query().Get("ledger_id")
+			}
+			if ledgerID != "" && !strings.HasPrefix(path, "/sync") {
+				if !isValidUUID(ledgerID) {
+					apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"ledger_id must be a valid UUID"})
+					return
+				}
+				var role domain.LedgerRole
+				if ledgers != nil {
+					if role, err = ledgers.Role(r.Context(), ledgerID, userID.String()); err != nil {
+						apiresponse.InternalServerError(w)
+						return
+					}
+				}
+				if role == "" {
+					apiresponse.Error(w, http.StatusForbidden, "Forbidden", []string{"you are not a member of this ledger"})
+					return
+				}
+				if r.Method != http.MethodGet && r.Method != http.MethodHead && !role.CanWrite() {
+					apiresponse.Error(w, http.StatusForbidden, "Forbidden", []string{usecases.ErrLedgerReadOnly.Error()})
+					return
+				}
+				ctx = context.WithValue(ctx, LedgerIDContextKey, ledgerID)
+			}
 			r = r.WithContext(ctx)
 		}
 		next.ServeHTTP(w, r)
@@ -52,3 +97,12 @@ func UserIDFromRequest(r *http.Request) string {
 	}
 	return ""
 }
+
+// LedgerIDFromRequest returns the shared ledger the request works on (set by JWTAuthMiddleware),
+// or nil for the user's own records
+func LedgerIDFromRequest(r *http.Request) *string {
+	if s, ok := r.Context().Value(LedgerIDContextKey).(string); ok && s != "" {
+		return &s
+	}
+	return nil
+}
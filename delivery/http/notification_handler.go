@@ -0,0 +1,125 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/repository"
+	"expense_tracker/usecases"
+)
+
+// NotificationHandler serves notification preferences and delivery history
+type NotificationHandler struct {
+	notificationUC *usecases.NotificationUseCase
+	jwt            *auth.JWTService
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(uc *usecases.NotificationUseCase, jwt *auth.JWTService) *NotificationHandler {
+	return &NotificationHandler{notificationUC: uc, jwt: jwt}
+}
+
+// NotificationPreferencesRequest is the JSON body for PUT /user/notification-preferences
+type NotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceRequest `json:"preferences"`
+}
+
+type NotificationPreferenceRequest struct {
+	Channel string `json:"channel"`
+	Target  string `json:"target"`
+	Enabled *bool  `json:"enabled"`
+}
+
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	prefs, err := h.notificationUC.GetPreferences(r.Context(), userID.String())
+	if err != nil {
+		apiresponse.InternalServerError(w)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Notification preferences retrieved successfully", prefs, nil)
+}
+
+// UpdatePreferences replaces the user's channel preferences. An empty list restores the default
+// (email when configured, otherwise log); listing a channel with enabled=false opts out of it.
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	var req NotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+
+	prefs := make([]*domain.NotificationPreference, 0, len(req.Preferences))
+	for _, p := range req.Preferences {
+		enabled := true
+		if p.Enabled != nil {
+			enabled = *p.Enabled
+		}
+		prefs = append(prefs, &domain.NotificationPreference{
+			Channel: domain.NotificationChannel(p.Channel),
+			Target:  p.Target,
+			Enabled: enabled,
+		})
+	}
+
+	updated, err := h.notificationUC.UpdatePreferences(r.Context(), userID.String(), prefs)
+	if err != nil {
+		if errors.Is(err, usecases.ErrInvalidNotificationChannel) ||
+			errors.Is(err, usecases.ErrDuplicateNotificationChannel) ||
+			errors.Is(err, usecases.ErrInvalidNotificationEmail) ||
+			errors.Is(err, usecases.ErrInvalidNotificationWebhook) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+			return
+		}
+		apiresponse.InternalServerError(w)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Notification preferences updated successfully", updated, nil)
+}
+
+// ListNotifications returns the user's notifications with delivery status, newest first
+func (h *NotificationHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	pagination, err := apiresponse.ParsePagination(r)
+	if err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+		return
+	}
+
+	notifications, total, err := h.notificationUC.ListNotifications(r.Context(), userID.String(), repository.ListOptions{
+		Limit:  pagination.PageSize,
+		Offset: pagination.Offset(),
+	})
+	if err != nil {
+		apiresponse.InternalServerError(w)
+		return
+	}
+
+	apiresponse.PaginatedSuccess(
+		w,
+		http.StatusOK,
+		"Notifications retrieved successfully",
+		notifications,
+		apiresponse.NewPaginationMeta(pagination.Page, pagination.PageSize, total),
+	)
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/usecases"
+)
+
+// QuickAddHandler turns short texts into expenses and debts
+type QuickAddHandler struct {
+	quickAddUC *usecases.QuickAddUseCase
+}
+
+// NewQuickAddHandler creates a new quick-add handler
+func NewQuickAddHandler(uc *usecases.QuickAddUseCase) *QuickAddHandler {
+	return &QuickAddHandler{quickAddUC: uc}
+}
+
+// QuickAddRequest is the JSON body for POST /quick-add
+type QuickAddRequest struct {
+	Text   string `json:"text"`
+	Today  string `json:"today,omitempty"` // YYYY-MM-DD, the user's date that relative dates count from
+	Commit bool   `json:"commit"`
+}
+
+// QuickAdd previews the expense or debt a text describes, or creates it when commit is true
+func (h *QuickAddHandler) QuickAdd(w http.ResponseWriter, r *http.Request) {
+	userID := UserIDFromRequest(r)
+	if userID == "" {
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"authorization required"})
+		return
+	}
+
+	var req QuickAddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+	var today time.Time
+	if req.Today != "" {
+		t, err := parseDate(req.Today)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"today must use YYYY-MM-DD"})
+			return
+		}
+		today = t
+	}
+
+	ledgerID := LedgerIDFromRequest(r)
+	if !req.Commit {
+		result, err := h.quickAddUC.Preview(r.Context(), userID, ledgerID, req.Text, today)
+		if err != nil {
+			writeQuickAddError(w, err)
+			return
+		}
+		apiresponse.Success(w, http.StatusOK, "Quick add previewed successfully", result, nil)
+		return
+	}
+	result, expense, err := h.quickAddUC.Commit(r.Context(), userID, ledgerID, req.Text, today)
+	if err != nil {
+		writeQuickAddError(w, err)
+		return
+	}
+	if result.Kind == domain.QuickAddDebt {
+		apiresponse.Success(w, http.StatusCreated, "Debt created successfully", result.Debt, nil)
+		return
+	}
+	apiresponse.Success(w, http.StatusCreated, "Expense created successfully", expense, nil)
+}
+
+func writeQuickAddError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrQuickAddNotUnderstood):
+		apiresponse.Error(w, http.StatusBadRequest, "Text not understood", []string{err.Error()})
+	case errors.Is(err, usecases.ErrQuickAddTextRequired), errors.Is(err, usecases.ErrQuickAddTextTooLong),
+		errors.Is(err, usecases.ErrQuickAddDebtInLedger), errors.Is(err, usecases.ErrDueDateInPast),
+		errors.Is(err, usecases.ErrInvalidCurrency), errors.Is(err, usecases.ErrAmountMustBePositive),
+		errors.Is(err, usecases.ErrAmountPrecision),
+		errors.Is(err, usecases.ErrPeerNameRequired), errors.Is(err, usecases.ErrDebtTypeRequired):
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+	case errors.Is(err, usecases.ErrQuickAddNotStored):
+		apiresponse.Error(w, http.StatusBadRequest, "Expense creation failed", []string{"unable to create expense"})
+	default:
+		apiresponse.InternalServerError(w)
+	}
+}
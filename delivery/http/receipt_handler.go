@@ -0,0 +1,148 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/usecases"
+)
+
+// ReceiptHandler serves the drafts made from uploaded receipts; JWTAuthMiddleware sets the user and ledger
+type ReceiptHandler struct {
+	receiptUC *usecases.ReceiptUseCase
+}
+
+// NewReceiptHandler creates a new receipt handler
+func NewReceiptHandler(uc *usecases.ReceiptUseCase) *ReceiptHandler {
+	return &ReceiptHandler{receiptUC: uc}
+}
+
+// ConfirmReceiptDraftRequest is the optional JSON body for POST /expenses/drafts/{id}/confirm;
+// fields that are set replace what was read from the receipt
+type ConfirmReceiptDraftRequest struct {
+	Amount      *domain.Money `json:"amount,omitempty"`
+	Currency    *string       `json:"currency,omitempty"`
+	CategoryID  *string       `json:"category_id,omitempty"`
+	Note        *string       `json:"note,omitempty"`
+	ExpenseDate *string       `json:"expense_date,omitempty"` // YYYY-MM-DD
+}
+
+// Upload stores a receipt sent as multipart/form-data in the field "file" as a pending draft,
+// which is read in the background
+func (h *ReceiptHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	userID := UserIDFromRequest(r)
+	if userID == "" {
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"authorization required"})
+		return
+	}
+	part, ok := uploadedFile(w, r)
+	if !ok {
+		return
+	}
+	defer part.Close()
+	draft, err := h.receiptUC.Upload(r.Context(), userID, LedgerIDFromRequest(r), part.FileName(), part)
+	if err != nil {
+		writeReceiptError(w, uploadReadError(err))
+		return
+	}
+	apiresponse.Success(w, http.StatusAccepted, "Receipt uploaded, it is being read", draft, nil)
+}
+
+func (h *ReceiptHandler) List(w http.ResponseWriter, r *http.Request) {
+	drafts, err := h.receiptUC.List(r.Context(), UserIDFromRequest(r), LedgerIDFromRequest(r))
+	if err != nil {
+		writeReceiptError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Receipt drafts retrieved successfully", drafts, nil)
+}
+
+func (h *ReceiptHandler) GetByID(w http.ResponseWriter, r *http.Request, id string) {
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid draft id"})
+		return
+	}
+	draft, err := h.receiptUC.Get(r.Context(), UserIDFromRequest(r), LedgerIDFromRequest(r), id)
+	if err != nil {
+		writeReceiptError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Receipt draft retrieved successfully", draft, nil)
+}
+
+func (h *ReceiptHandler) Delete(w http.ResponseWriter, r *http.Request, id string) {
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid draft id"})
+		return
+	}
+	if err := h.receiptUC.Delete(r.Context(), UserIDFromRequest(r), LedgerIDFromRequest(r), id); err != nil {
+		writeReceiptError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Receipt draft deleted successfully", nil, nil)
+}
+
+// Confirm creates the expense of a read draft, with the changes in the body if any
+func (h *ReceiptHandler) Confirm(w http.ResponseWriter, r *http.Request, id string) {
+	if !isValidUUID(id) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid draft id"})
+		return
+	}
+	var req ConfirmReceiptDraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+
+	changes := domain.UpdateExpenseInput{Amount: req.Amount, CategoryID: req.CategoryID, Note: req.Note}
+	if req.Amount != nil && !req.Amount.IsPositive() {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"amount must be positive"})
+		return
+	}
+	if req.Currency != nil {
+		currency := domain.NormalizeCurrency(*req.Currency)
+		if currency != "" && !domain.ValidCurrency(currency) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{usecases.ErrInvalidCurrency.Error()})
+			return
+		}
+		changes.Currency = &currency
+	}
+	if req.CategoryID != nil && *req.CategoryID != "" && !isValidUUID(*req.CategoryID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"category_id must be a valid UUID"})
+		return
+	}
+	if req.ExpenseDate != nil {
+		t, err := parseDate(*req.ExpenseDate)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"expense_date must use YYYY-MM-DD"})
+			return
+		}
+		changes.ExpenseDate = &t
+	}
+
+	expense, err := h.receiptUC.Confirm(r.Context(), UserIDFromRequest(r), LedgerIDFromRequest(r), id, changes)
+	if err != nil {
+		writeReceiptError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusCreated, "Expense created successfully", expense, nil)
+}
+
+func writeReceiptError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrReceiptDraftNotFound):
+		apiresponse.Error(w, http.StatusNotFound, "Receipt draft not found", []string{err.Error()})
+	case errors.Is(err, usecases.ErrReceiptDraftNotReady):
+		apiresponse.Error(w, http.StatusConflict, "Receipt not read yet", []string{err.Error()})
+	case errors.Is(err, usecases.ErrTooManyReceiptDrafts), errors.Is(err, usecases.ErrInvalidReceiptExpense):
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+	case errors.Is(err, usecases.ErrReceiptExpenseNotStored):
+		apiresponse.Error(w, http.StatusBadRequest, "Expense creation failed", []string{"unable to create expense"})
+	default:
+		writeAttachmentError(w, err)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"strconv"
 	"time"
 )
 
@@ -202,14 +201,10 @@ func (h *ReportHandler) GetMonthlyReport(w http.ResponseWriter, r *http.Request)
 
 // buildWeeklyPrompt composes a concise prompt for the AI based on current and previous weekly reports.
 func buildWeeklyPrompt(cur usecases.WeeklyReport, prev usecases.WeeklyReport) string {
-	return "Provide a short insight (1-2 sentences) comparing this week's spending to last week's. Include the main habit or change and one suggested action. Current week: total_expense=" + formatFloat(cur.TotalExpense) + ", total_lent=" + formatFloat(cur.TotalLent) + ", total_borrowed=" + formatFloat(cur.TotalBorrowed) + ". Previous week: total_expense=" + formatFloat(prev.TotalExpense) + ", total_lent=" + formatFloat(prev.TotalLent) + ", total_borrowed=" + formatFloat(prev.TotalBorrowed) + "."
+	return "Provide a short insight (1-2 sentences) comparing this week's spending to last week's. Include the main habit or change and one suggested action. Current week: total_expense=" + cur.TotalExpense.String() + ", total_lent=" + cur.TotalLent.String() + ", total_borrowed=" + cur.TotalBorrowed.String() + ". Previous week: total_expense=" + prev.TotalExpense.String() + ", total_lent=" + prev.TotalLent.String() + ", total_borrowed=" + prev.TotalBorrowed.String() + "."
 }
 
 // buildMonthlyPrompt composes a concise prompt for the AI based on current and previous monthly reports.
 func buildMonthlyPrompt(cur usecases.MonthlyReport, prev usecases.MonthlyReport) string {
-	return "Provide a short insight (1-2 sentences) comparing this month's spending to last month's and state the current habit based on last month's data. Include one practical suggestion. Current month: total_expense=" + formatFloat(cur.TotalExpense) + ", total_lent=" + formatFloat(cur.TotalLent) + ", total_borrowed=" + formatFloat(cur.TotalBorrowed) + ". Previous month: total_expense=" + formatFloat(prev.TotalExpense) + ", total_lent=" + formatFloat(prev.TotalLent) + ", total_borrowed=" + formatFloat(prev.TotalBorrowed) + "."
-}
-
-func formatFloat(f float64) string {
-	return strconv.FormatFloat(f, 'f', 2, 64)
+	return "Provide a short insight (1-2 sentences) comparing this month's spending to last month's and state the current habit based on last month's data. Include one practical suggestion. Current month: total_expense=" + cur.TotalExpense.String() + ", total_lent=" + cur.TotalLent.String() + ", total_borrowed=" + cur.TotalBorrowed.String() + ". Previous month: total_expense=" + prev.TotalExpense.String() + ", total_lent=" + prev.TotalLent.String() + ", total_borrowed=" + prev.TotalBorrowed.String() + "."
 }
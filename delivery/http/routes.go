@@ -33,6 +33,34 @@ func RegisterDebtRoutes(mux *http.ServeMux, handler *DebtHandler) {
 	})
 
 	mux.HandleFunc("/debts/", func(w http.ResponseWriter, r *http.Request) {
+		if debtID, reminderID, ok := extractReminderPath(r.URL.Path); ok {
+			switch {
+			case reminderID == "" && r.Method == http.MethodGet:
+				handler.ListReminders(w, r, debtID)
+			case reminderID == "" && r.Method == http.MethodPost:
+				handler.CreateReminder(w, r, debtID)
+			case reminderID != "" && r.Method == http.MethodPatch:
+				handler.UpdateReminder(w, r, debtID, reminderID)
+			case reminderID != "" && r.Method == http.MethodDelete:
+				handler.DeleteReminder(w, r, debtID, reminderID)
+			default:
+				apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			}
+			return
+		}
+
+		if debtID, ok := extractPaymentsPath(r.URL.Path); ok {
+			switch r.Method {
+			case http.MethodGet:
+				handler.ListPayments(w, r, debtID)
+			case http.MethodPost:
+				handler.CreatePayment(w, r, debtID)
+			default:
+				apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			}
+			return
+		}
+
 		if strings.HasSuffix(r.URL.Path, "/pay") {
 			if r.Method != http.MethodPatch {
 				apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
@@ -69,7 +97,24 @@ func RegisterExpenseRoutes(mux *http.ServeMux, handler *ExpenseHandler) {
 			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
 		}
 	})
+	mux.HandleFunc("/expenses/recurrence-preview", handler.PreviewRecurrence)
+	mux.HandleFunc("/expenses/search", handler.Search)
 	mux.HandleFunc("/expenses/", func(w http.ResponseWriter, r *http.Request) {
+		if expenseID, attachmentID, ok := extractAttachmentPath(r.URL.Path); ok && handler.attachments != nil {
+			switch {
+			case attachmentID == "" && r.Method == http.MethodGet:
+				handler.attachments.List(w, r, expenseID)
+			case attachmentID == "" && r.Method == http.MethodPost:
+				handler.attachments.Upload(w, r, expenseID)
+			case attachmentID != "" && r.Method == http.MethodGet:
+				handler.attachments.Download(w, r, expenseID, attachmentID)
+			case attachmentID != "" && r.Method == http.MethodDelete:
+				handler.attachments.Delete(w, r, expenseID, attachmentID)
+			default:
+				apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			}
+			return
+		}
 		id := extractPathID(r.URL.Path, "/expenses/")
 		if id == "" {
 			http.NotFound(w, r)
@@ -88,6 +133,141 @@ func RegisterExpenseRoutes(mux *http.ServeMux, handler *ExpenseHandler) {
 	})
 }
 
+// RegisterSyncRoutes registers offline sync endpoints on mux.
+func RegisterSyncRoutes(mux *http.ServeMux, handler *SyncHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/sync/expenses", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			return
+		}
+		handler.SyncExpenses(w, r)
+	})
+	mux.HandleFunc("/sync/changes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			return
+		}
+		handler.GetChanges(w, r)
+	})
+}
+
+// RegisterNotificationRoutes registers notification preference and history endpoints on mux.
+func RegisterNotificationRoutes(mux *http.ServeMux, handler *NotificationHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/user/notification-preferences", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handler.GetPreferences(w, r)
+		case http.MethodPut:
+			handler.UpdatePreferences(w, r)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+	mux.HandleFunc("/user/notifications", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			return
+		}
+		handler.ListNotifications(w, r)
+	})
+}
+
+// RegisterBudgetRoutes registers monthly budget endpoints on mux.
+func RegisterBudgetRoutes(mux *http.ServeMux, handler *BudgetHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/budgets", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handler.List(w, r)
+		case http.MethodPost:
+			handler.Create(w, r)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+	mux.HandleFunc("/budgets/", func(w http.ResponseWriter, r *http.Request) {
+		id := extractPathID(r.URL.Path, "/budgets/")
+		if id == "" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			handler.GetByID(w, r, id)
+		case http.MethodPut:
+			handler.Update(w, r, id)
+		case http.MethodDelete:
+			handler.Delete(w, r, id)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+}
+
+// RegisterIncomeRoutes registers income and income category endpoints on mux.
+func RegisterIncomeRoutes(mux *http.ServeMux, handler *IncomeHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/income", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handler.List(w, r)
+		case http.MethodPost:
+			handler.Create(w, r)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+	mux.HandleFunc("/income/categories", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handler.ListCategories(w, r)
+		case http.MethodPost:
+			handler.CreateCategory(w, r)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+	mux.HandleFunc("/income/categories/", func(w http.ResponseWriter, r *http.Request) {
+		id := extractPathID(r.URL.Path, "/income/categories/")
+		if id == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodDelete {
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			return
+		}
+		handler.DeleteCategory(w, r, id)
+	})
+	mux.HandleFunc("/income/", func(w http.ResponseWriter, r *http.Request) {
+		id := extractPathID(r.URL.Path, "/income/")
+		if id == "" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			handler.GetByID(w, r, id)
+		case http.MethodPut:
+			handler.Update(w, r, id)
+		case http.MethodDelete:
+			handler.Delete(w, r, id)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+}
+
 // RegisterCategoryRoutes registers category endpoints on mux (Team 2)
 func RegisterCategoryRoutes(mux *http.ServeMux, handler *CategoryHandler) {
 	if mux == nil || handler == nil {
@@ -134,3 +314,272 @@ func extractPathID(path, prefix string) string {
 	}
 	return strings.TrimPrefix(path, prefix)
 }
+
+// RegisterExchangeRateRoutes registers exchange rate endpoints on mux.
+func RegisterExchangeRateRoutes(mux *http.ServeMux, handler *ExchangeRateHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/exchange-rates", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			return
+		}
+		handler.List(w, r)
+	})
+	mux.HandleFunc("/admin/exchange-rates", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			return
+		}
+		handler.Import(w, r)
+	})
+}
+
+// RegisterContactRoutes registers contact endpoints on mux.
+func RegisterContactRoutes(mux *http.ServeMux, handler *ContactHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/contacts", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handler.List(w, r)
+		case http.MethodPost:
+			handler.Create(w, r)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+	mux.HandleFunc("/contacts/", func(w http.ResponseWriter, r *http.Request) {
+		id := extractPathID(r.URL.Path, "/contacts/")
+		if id == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if contactID, ok := strings.CutSuffix(id, "/balance"); ok {
+			if r.Method != http.MethodGet {
+				apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+				return
+			}
+			handler.Balance(w, r, contactID)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			handler.GetByID(w, r, id)
+		case http.MethodPut:
+			handler.Update(w, r, id)
+		case http.MethodDelete:
+			handler.Delete(w, r, id)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+}
+
+// RegisterLedgerRoutes registers shared ledger endpoints on mux.
+func RegisterLedgerRoutes(mux *http.ServeMux, handler *LedgerHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/ledgers", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handler.List(w, r)
+		case http.MethodPost:
+			handler.Create(w, r)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+	mux.HandleFunc("/ledgers/", func(w http.ResponseWriter, r *http.Request) {
+		if ledgerID, memberID, ok := extractLedgerMembersPath(r.URL.Path); ok {
+			switch {
+			case memberID == "" && r.Method == http.MethodGet:
+				handler.ListMembers(w, r, ledgerID)
+			case memberID == "" && r.Method == http.MethodPost:
+				handler.AddMember(w, r, ledgerID)
+			case memberID != "" && r.Method == http.MethodPut:
+				handler.UpdateMember(w, r, ledgerID, memberID)
+			case memberID != "" && r.Method == http.MethodDelete:
+				handler.RemoveMember(w, r, ledgerID, memberID)
+			default:
+				apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			}
+			return
+		}
+
+		id := extractPathID(r.URL.Path, "/ledgers/")
+		if id == "" || strings.Contains(id, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			handler.GetByID(w, r, id)
+		case http.MethodPut:
+			handler.Update(w, r, id)
+		case http.MethodDelete:
+			handler.Delete(w, r, id)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+}
+
+func RegisterSettlementRoutes(mux *http.ServeMux, handler *SettlementHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/groups/", func(w http.ResponseWriter, r *http.Request) {
+		groupID, ok := extractGroupSettlePath(r.URL.Path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			handler.Settle(w, r, groupID)
+		case http.MethodPost:
+			handler.Record(w, r, groupID)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+}
+
+// RegisterImportRoutes registers statement import endpoints on mux.
+func RegisterImportRoutes(mux *http.ServeMux, handler *ImportHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/imports", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			return
+		}
+		handler.Import(w, r)
+	})
+}
+
+// RegisterCategoryRuleRoutes registers category rule endpoints on mux; they take precedence over
+// /categories/{id}
+func RegisterCategoryRuleRoutes(mux *http.ServeMux, handler *CategoryRuleHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/categories/rules", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handler.List(w, r)
+		case http.MethodPost:
+			handler.Create(w, r)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+	mux.HandleFunc("/categories/rules/", func(w http.ResponseWriter, r *http.Request) {
+		id := extractPathID(r.URL.Path, "/categories/rules/")
+		if id == "" || strings.Contains(id, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		if id == "apply" {
+			if r.Method != http.MethodPost {
+				apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+				return
+			}
+			handler.Apply(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			handler.GetByID(w, r, id)
+		case http.MethodPut:
+			handler.Update(w, r, id)
+		case http.MethodDelete:
+			handler.Delete(w, r, id)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+}
+
+// RegisterExportRoutes registers file export endpoints on mux.
+func RegisterExportRoutes(mux *http.ServeMux, handler *ExportHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/exports", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			return
+		}
+		handler.Export(w, r)
+	})
+}
+
+// RegisterReceiptRoutes registers the receipt draft endpoints on mux; they take precedence over
+// /expenses/{id}
+func RegisterReceiptRoutes(mux *http.ServeMux, handler *ReceiptHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/expenses/drafts", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			return
+		}
+		handler.List(w, r)
+	})
+	mux.HandleFunc("/expenses/drafts/", func(w http.ResponseWriter, r *http.Request) {
+		id := extractPathID(r.URL.Path, "/expenses/drafts/")
+		if id == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if id == "from-receipt" {
+			if r.Method != http.MethodPost {
+				apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+				return
+			}
+			handler.Upload(w, r)
+			return
+		}
+		if draftID, ok := strings.CutSuffix(id, "/confirm"); ok && !strings.Contains(draftID, "/") {
+			if r.Method != http.MethodPost {
+				apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+				return
+			}
+			handler.Confirm(w, r, draftID)
+			return
+		}
+		if strings.Contains(id, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			handler.GetByID(w, r, id)
+		case http.MethodDelete:
+			handler.Delete(w, r, id)
+		default:
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		}
+	})
+}
+
+// RegisterQuickAddRoutes registers the quick-add endpoint on mux.
+func RegisterQuickAddRoutes(mux *http.ServeMux, handler *QuickAddHandler) {
+	if mux == nil || handler == nil {
+		return
+	}
+	mux.HandleFunc("/quick-add", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+			return
+		}
+		handler.QuickAdd(w, r)
+	})
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+)
+
+// SettlementHandler serves the settle-up endpoints of groups (shared ledgers)
+type SettlementHandler struct {
+	settlementUC *usecases.SettlementUseCase
+	jwt          *auth.JWTService
+}
+
+// NewSettlementHandler creates a new settlement handler
+func NewSettlementHandler(uc *usecases.SettlementUseCase, jwt *auth.JWTService) *SettlementHandler {
+	return &SettlementHandler{settlementUC: uc, jwt: jwt}
+}
+
+// SettlementPaymentRequest is the JSON body for POST /groups/{id}/settle
+type SettlementPaymentRequest struct {
+	FromUserID string       `json:"from_user_id"`
+	ToUserID   string       `json:"to_user_id"`
+	Amount     domain.Money `json:"amount"`
+	Currency   string       `json:"currency"`
+	PaidDate   string       `json:"paid_date"` // YYYY-MM-DD; defaults to today
+	Note       *string      `json:"note"`
+}
+
+type settlementPaymentResponse struct {
+	PayerDebt *domain.Debt `json:"payer_debt"`
+	PayeeDebt *domain.Debt `json:"payee_debt"`
+}
+
+// Settle returns the members' balances and the transfers that settle them up
+func (h *SettlementHandler) Settle(w http.ResponseWriter, r *http.Request, groupID string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(groupID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid group id"})
+		return
+	}
+
+	settlement, err := h.settlementUC.Settle(r.Context(), userID.String(), groupID)
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Settlement computed successfully", settlement, nil)
+}
+
+// Record records a settle-up payment between two members as paid debts
+func (h *SettlementHandler) Record(w http.ResponseWriter, r *http.Request, groupID string) {
+	userID, err := authenticateRequest(r, h.jwt)
+	if err != nil {
+		writeUnauthorized(w, err)
+		return
+	}
+	if !isValidUUID(groupID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid group id"})
+		return
+	}
+
+	var req SettlementPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+	if !isValidUUID(req.FromUserID) || !isValidUUID(req.ToUserID) {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"from_user_id and to_user_id must be valid user ids"})
+		return
+	}
+	var paidDate time.Time
+	if req.PaidDate != "" {
+		parsed, err := parseDate(req.PaidDate)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"paid_date must use YYYY-MM-DD"})
+			return
+		}
+		paidDate = parsed
+	}
+
+	payer, payee, err := h.settlementUC.Record(r.Context(), userID.String(), groupID, domain.SettlementPayment{
+		FromUserID: req.FromUserID,
+		ToUserID:   req.ToUserID,
+		Amount:     req.Amount,
+		Currency:   req.Currency,
+		PaidDate:   paidDate,
+		Note:       req.Note,
+	})
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+	apiresponse.Success(w, http.StatusCreated, "Settlement recorded successfully", settlementPaymentResponse{PayerDebt: payer, PayeeDebt: payee}, nil)
+}
+
+// extractGroupSettlePath returns the ID of /groups/{id}/settle; ok is false for other paths
+func extractGroupSettlePath(path string) (groupID string, ok bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "groups" || parts[1] == "" || parts[2] != "settle" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func writeSettlementError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrLedgerNotFound):
+		apiresponse.Error(w, http.StatusNotFound, "Not found", []string{"group not found"})
+	case errors.Is(err, usecases.ErrLedgerReadOnly), errors.Is(err, usecases.ErrSettlementNotParty):
+		apiresponse.Error(w, http.StatusForbidden, "Forbidden", []string{err.Error()})
+	case errors.Is(err, usecases.ErrSettlementParties),
+		errors.Is(err, usecases.ErrAmountMustBePositive),
+		errors.Is(err, usecases.ErrAmountPrecision),
+		errors.Is(err, usecases.ErrInvalidCurrency),
+		errors.Is(err, usecases.ErrPaidDateInFuture):
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+	default:
+		apiresponse.InternalServerError(w)
+	}
+}
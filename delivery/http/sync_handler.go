@@ -0,0 +1,129 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"expense_tracker/delivery/apiresponse"
+	"expense_tracker/domain"
+	"expense_tracker/usecases"
+)
+
+// SyncHandler handles offline sync endpoints for mobile clients
+type SyncHandler struct {
+	syncUC *usecases.SyncUseCase
+}
+
+// NewSyncHandler creates a new sync handler
+func NewSyncHandler(syncUC *usecases.SyncUseCase) *SyncHandler {
+	return &SyncHandler{syncUC: syncUC}
+}
+
+// SyncExpensesRequest is the JSON body for POST /sync/expenses
+type SyncExpensesRequest struct {
+	Expenses []CreateExpenseRequest `json:"expenses"`
+}
+
+// SyncExpenses accepts a batch of expenses created offline, each with a client-generated UUID.
+// Invalid items are reported individually and do not prevent the rest of the batch from syncing.
+func (h *SyncHandler) SyncExpenses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		return
+	}
+	userID := UserIDFromRequest(r)
+	if userID == "" {
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"authorization required"})
+		return
+	}
+
+	var req SyncExpensesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{"invalid request body"})
+		return
+	}
+	if len(req.Expenses) == 0 {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{usecases.ErrSyncBatchEmpty.Error()})
+		return
+	}
+	if len(req.Expenses) > usecases.MaxSyncBatchSize {
+		apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{usecases.ErrSyncBatchTooLarge.Error()})
+		return
+	}
+
+	// Validate each item; only valid ones go to the usecase, results are merged back in order
+	items := make([]domain.SyncItemResult, len(req.Expenses))
+	valid := make([]domain.CreateExpenseInput, 0, len(req.Expenses))
+	validIdx := make([]int, 0, len(req.Expenses))
+	for i, item := range req.Expenses {
+		if item.ID == "" {
+			items[i] = domain.SyncItemResult{Status: domain.SyncItemInvalid, Reason: "id is required for sync"}
+			continue
+		}
+		input, errMsg := buildCreateExpenseInput(userID, item)
+		if errMsg != "" {
+			items[i] = domain.SyncItemResult{ID: item.ID, Status: domain.SyncItemInvalid, Reason: errMsg}
+			continue
+		}
+		valid = append(valid, input)
+		validIdx = append(validIdx, i)
+	}
+
+	if len(valid) > 0 {
+		synced, err := h.syncUC.SyncExpenses(r.Context(), userID, valid)
+		if err != nil {
+			if errors.Is(err, usecases.ErrSyncBatchEmpty) || errors.Is(err, usecases.ErrSyncBatchTooLarge) {
+				apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+				return
+			}
+			apiresponse.InternalServerError(w)
+			return
+		}
+		for j, item := range synced.Items {
+			items[validIdx[j]] = item
+		}
+	}
+
+	result := domain.SyncResult{Items: make([]domain.SyncItemResult, 0, len(items))}
+	for _, item := range items {
+		result.Add(item)
+	}
+	apiresponse.Success(w, http.StatusOK, "Expenses synced successfully", result, nil)
+}
+
+// GetChanges returns expenses, debts and categories changed since the `since` cursor, including
+// tombstones for deleted records. Clients store next_cursor and keep calling while has_more is true.
+func (h *SyncHandler) GetChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apiresponse.Error(w, http.StatusMethodNotAllowed, "Method not allowed", []string{"method not allowed"})
+		return
+	}
+	userID := UserIDFromRequest(r)
+	if userID == "" {
+		apiresponse.Error(w, http.StatusUnauthorized, "Unauthorized", []string{"authorization required"})
+		return
+	}
+
+	limit := usecases.DefaultSyncChangesLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{usecases.ErrInvalidSyncLimit.Error()})
+			return
+		}
+		limit = value
+	}
+
+	changes, err := h.syncUC.GetChanges(r.Context(), userID, r.URL.Query().Get("since"), limit)
+	if err != nil {
+		if errors.Is(err, usecases.ErrInvalidSyncCursor) || errors.Is(err, usecases.ErrInvalidSyncLimit) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+			return
+		}
+		apiresponse.InternalServerError(w)
+		return
+	}
+	apiresponse.Success(w, http.StatusOK, "Changes retrieved successfully", changes, nil)
+}
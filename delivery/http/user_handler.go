@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"expense_tracker/delivery/apiresponse"
@@ -48,6 +49,11 @@ func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.userUC.Update(r.Context(), userID, input); err != nil {
+		if errors.Is(err, usecases.ErrInvalidBudgetingStyle) || errors.Is(err, usecases.ErrInvalidMonthlyIncome) ||
+			errors.Is(err, usecases.ErrAmountPrecision) {
+			apiresponse.Error(w, http.StatusBadRequest, "Validation failed", []string{err.Error()})
+			return
+		}
 		apiresponse.Error(w, http.StatusBadRequest, "Profile update failed", []string{"unable to update profile"})
 		return
 	}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"bytes"
+	"time"
+)
+
+// Attachment is a file kept with an expense, such as a receipt. Its content lives in a blob store
+// under StorageKey.
+type Attachment struct {
+	ID          string    `json:"id"`
+	ExpenseID   string    `json:"expense_id"`
+	UserID      string    `json:"user_id"` // who uploaded it
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"` // sniffed from the content, not taken from the upload
+	Size        int64     `json:"size"`         // in bytes
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AttachmentSniffLen is how many leading bytes SniffAttachmentType needs at most
+const AttachmentSniffLen = 16
+
+// SniffAttachmentType returns the content type of a file starting with head, or "" when it is not
+// one attachments may have: a JPEG, PNG, GIF, WebP or HEIC image or a PDF
+func SniffAttachmentType(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("\xFF\xD8\xFF")):
+		return "image/jpeg"
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1A\n")):
+		return "image/png"
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return "image/gif"
+	case len(head) >= 12 && bytes.Equal(head[:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WEBP")):
+		return "image/webp"
+	case len(head) >= 12 && bytes.Equal(head[4:8], []byte("ftyp")):
+		switch string(head[8:12]) {
+		case "heic", "heix", "hevc", "hevx", "mif1", "msf1":
+			return "image/heic"
+		}
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return "application/pdf"
+	}
+	return ""
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// Budget is a monthly spending limit for one of the user's categories, or for all spending when
+// CategoryID is nil. The limit applies to every calendar month. Bucket places a category budget
+// in the needs, wants or savings share of the 50/30/20 style. A budget with a LedgerID limits the
+// shared ledger's spending rather than the user's own.
+type Budget struct {
+	ID           string       `json:"id"`
+	UserID       string       `json:"user_id"`
+	LedgerID     *string      `json:"ledger_id,omitempty"`
+	CategoryID   *string      `json:"category_id"` // nil = overall budget
+	CategoryName string       `json:"category_name,omitempty"`
+	Amount       Money        `json:"amount"`
+	Bucket       BudgetBucket `json:"bucket,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
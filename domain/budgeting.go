@@ -0,0 +1,43 @@
+package domain
+
+// BudgetingStyle selects how a user's budgets are planned and reported
+type BudgetingStyle string
+
+const (
+	BudgetingStyleFlexible          BudgetingStyle = "flexible"   // independent monthly limits
+	BudgetingStyleEnvelope          BudgetingStyle = "envelope"   // unspent amounts carry into later months
+	BudgetingStyleZeroBased         BudgetingStyle = "zero_based" // all monthly income is assigned to budgets
+	BudgetingStyleFiftyThirtyTwenty BudgetingStyle = "50_30_20"   // needs, wants and savings shares of income
+)
+
+// BudgetingStyles lists the supported styles
+var BudgetingStyles = []BudgetingStyle{
+	BudgetingStyleFlexible,
+	BudgetingStyleEnvelope,
+	BudgetingStyleZeroBased,
+	BudgetingStyleFiftyThirtyTwenty,
+}
+
+// ValidBudgetingStyle reports whether s is a supported style
+func ValidBudgetingStyle(s BudgetingStyle) bool {
+	for _, style := range BudgetingStyles {
+		if s == style {
+			return true
+		}
+	}
+	return false
+}
+
+// BudgetBucket groups category budgets for the 50/30/20 style
+type BudgetBucket string
+
+const (
+	BudgetBucketNeeds   BudgetBucket = "needs"
+	BudgetBucketWants   BudgetBucket = "wants"
+	BudgetBucketSavings BudgetBucket = "savings"
+)
+
+// ValidBudgetBucket reports whether b is a supported bucket
+func ValidBudgetBucket(b BudgetBucket) bool {
+	return b == BudgetBucketNeeds || b == BudgetBucketWants || b == BudgetBucketSavings
+}
@@ -1,17 +1,25 @@
 package domain
 
-// Category represents a spending category (global or user-defined)
-// user_id nil = global category; non-nil = user-defined
+import "time"
+
+// Category represents a spending category (global, user-defined or shared in a ledger)
+// user_id nil = global category; non-nil = user-defined, or the creator when ledger_id is set
 type Category struct {
-	ID     string  `json:"id"`
-	Name   string  `json:"name"`
-	UserID *string `json:"user_id,omitempty"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	UserID    *string    `json:"user_id,omitempty"`
+	LedgerID  *string    `json:"ledger_id,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Version   int64      `json:"version"`
+	SyncTxID  uint64     `json:"-"` // transaction that last wrote the category; orders the sync change feed
 }
 
 // CreateCategoryInput is the input for creating a category
 type CreateCategoryInput struct {
-	Name   string  `json:"name"`
-	UserID *string `json:"user_id,omitempty"`
+	Name     string  `json:"name"`
+	UserID   *string `json:"user_id,omitempty"`
+	LedgerID *string `json:"ledger_id,omitempty"`
 }
 
 // UpdateCategoryInput is the input for updating a category (e.g. name)
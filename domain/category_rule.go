@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CategoryRule files expenses that have no category under CategoryID when all of its conditions
+// match; conditions left empty match anything, but a rule has at least one. Rules are tried
+// highest Priority first (oldest first on ties) and the first match wins. A rule with a LedgerID
+// categorizes the shared ledger's expenses rather than the user's own.
+type CategoryRule struct {
+	ID           string         `json:"id"`
+	UserID       string         `json:"user_id"`
+	LedgerID     *string        `json:"ledger_id,omitempty"`
+	CategoryID   string         `json:"category_id"`
+	CategoryName string         `json:"category_name,omitempty"`
+	NoteContains string         `json:"note_contains,omitempty"` // matched ignoring case
+	NotePattern  string         `json:"note_pattern,omitempty"`  // regular expression (RE2 syntax)
+	MinAmount    *Money         `json:"min_amount,omitempty"`    // inclusive, in the expense's own currency
+	MaxAmount    *Money         `json:"max_amount,omitempty"`    // inclusive
+	Weekdays     []time.Weekday `json:"weekdays,omitempty"`      // days of the expense date; 0 = Sunday
+	Priority     int            `json:"priority"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+
+	pattern *regexp.Regexp
+}
+
+// CategoryRuleInput is the input for creating or replacing a category rule
+type CategoryRuleInput struct {
+	CategoryID   string
+	NoteContains string
+	NotePattern  string
+	MinAmount    *Money
+	MaxAmount    *Money
+	Weekdays     []time.Weekday
+	Priority     int
+}
+
+// HasCondition reports whether the rule has at least one condition
+func (r *CategoryRule) HasCondition() bool {
+	return strings.TrimSpace(r.NoteContains) != "" || r.NotePattern != "" || r.MinAmount != nil ||
+		r.MaxAmount != nil || len(r.Weekdays) > 0
+}
+
+// Compile parses NotePattern; Matches compiles it on first use when Compile was not called
+func (r *CategoryRule) Compile() error {
+	r.pattern = nil
+	if r.NotePattern == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile(r.NotePattern)
+	if err != nil {
+		return err
+	}
+	r.pattern = pattern
+	return nil
+}
+
+// Matches reports whether an expense with the given amount, note and date meets every condition
+// of the rule. A rule whose pattern does not compile matches nothing.
+func (r *CategoryRule) Matches(amount Money, note string, date time.Time) bool {
+	if contains := strings.TrimSpace(r.NoteContains); contains != "" && !strings.Contains(strings.ToLower(note), strings.ToLower(contains)) {
+		return false
+	}
+	if r.NotePattern != "" {
+		if r.pattern == nil && r.Compile() != nil {
+			return false
+		}
+		if !r.pattern.MatchString(note) {
+			return false
+		}
+	}
+	if r.MinAmount != nil && amount.Cmp(*r.MinAmount) < 0 {
+		return false
+	}
+	if r.MaxAmount != nil && amount.Cmp(*r.MaxAmount) > 0 {
+		return false
+	}
+	if len(r.Weekdays) > 0 {
+		found := false
+		for _, day := range r.Weekdays {
+			if day == date.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyCategoryRulesOptions limits which existing expenses the rules are applied to
+type ApplyCategoryRulesOptions struct {
+	FromDate          *time.Time // optional, inclusive
+	ToDate            *time.Time // optional, inclusive
+	OnlyUncategorized bool       // leave expenses that have a category alone
+	DryRun            bool       // report the changes without making them
+}
+
+// CategoryChange is an expense the rules file under another category
+type CategoryChange struct {
+	ExpenseID      string  `json:"expense_id"`
+	RuleID         string  `json:"rule_id"`
+	FromCategoryID *string `json:"from_category_id"` // nil = uncategorized
+	CategoryID     string  `json:"category_id"`
+}
+
+// ApplyCategoryRulesResult is the outcome of applying the rules to existing expenses
+type ApplyCategoryRulesResult struct {
+	Checked int              `json:"checked"` // expenses the rules were tried on
+	Changed int              `json:"changed"` // expenses recategorized, or that would be on a dry run
+	DryRun  bool             `json:"dry_run"`
+	Changes []CategoryChange `json:"changes"`
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Contact is a person the user lends money to or borrows from. Debts link to a contact so that
+// everything outstanding with one person can be seen together.
+type Contact struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"` // unique per user, ignoring case
+	Note      *string   `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ContactBalance is what is outstanding with a contact in one currency. Net is positive when the
+// contact owes the user and negative when the user owes the contact.
+type ContactBalance struct {
+	Currency  string `json:"currency"`
+	OwedToYou Money  `json:"owed_to_you"` // unpaid balance of debts of type lent
+	YouOwe    Money  `json:"you_owe"`     // unpaid balance of debts of type borrowed
+	Net       Money  `json:"net"`
+}
+
+// NormalizeContactName trims the name and collapses runs of whitespace, so that "Abebe" and
+// " abebe " name the same contact
+func NormalizeContactName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
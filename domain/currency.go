@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// ExchangeRate is how many units of Quote one unit of Base was worth on Date
+type ExchangeRate struct {
+	Base      string    `json:"base"`
+	Quote     string    `json:"quote"`
+	Rate      float64   `json:"rate"`
+	Date      time.Time `json:"date"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExchangeRateFilter for listing exchange rates
+type ExchangeRateFilter struct {
+	Base     string     // optional
+	Quote    string     // optional
+	FromDate *time.Time // optional start date (inclusive)
+	ToDate   *time.Time // optional end date (inclusive)
+	Limit    int
+	Offset   int
+}
+
+// NormalizeCurrency trims and upper-cases a currency code
+func NormalizeCurrency(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// currencyExponents are the ISO 4217 minor units other than two decimal places. The units of
+// account with four (CLF, UYW) are beyond Money and take the default.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyExponent returns the number of decimal places of currency's minor unit per ISO 4217:
+// 0 for JPY, 3 for KWD and 2 for most currencies, including unknown codes. The SQL function
+// currency_exponent holds the same table.
+func CurrencyExponent(code string) int {
+	if exp, ok := currencyExponents[code]; ok {
+		return exp
+	}
+	return 2
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 code (three upper-case letters)
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// MissingRate is money a report leaves out of its totals because no stored exchange rate converts
+// it into the user's default currency: Count amounts of one kind in Currency, adding up to Amount
+type MissingRate struct {
+	Kind     string `json:"kind"` // expense, income, lent, borrowed, lent_repaid or borrowed_repaid
+	Currency string `json:"currency"`
+	Count    int    `json:"count"`
+	Amount   Money  `json:"amount"` // in Currency
+}
@@ -14,8 +14,14 @@ type Debt struct {
 	ID              string     `json:"id"`
 	UserID          string     `json:"user_id"`
 	Type            string     `json:"type"`
-	PeerName        string     `json:"peer_name"`
-	Amount          float64    `json:"amount"`
+	PeerName        string     `json:"peer_name"`            // the contact's name
+	ContactID       *string    `json:"contact_id"`           // nil once the contact is deleted
+	ExpenseID       *string    `json:"expense_id,omitempty"` // split expense the debt was created for
+	LedgerID        *string    `json:"ledger_id,omitempty"`  // shared ledger the debt settles up in; read-only
+	Amount          Money      `json:"amount"`
+	Currency        string     `json:"currency"`    // ISO 4217 code; empty on create = the user's default currency
+	PaidAmount      Money      `json:"paid_amount"` // sum of the debt's payments; read-only
+	Balance         Money      `json:"balance"`     // amount still outstanding; read-only
 	DueDate         time.Time  `json:"due_date"`
 	ReminderEnabled bool       `json:"reminder_enabled"`
 	RemindAt        *time.Time `json:"remind_at,omitempty"`
@@ -23,4 +29,8 @@ type Debt struct {
 	Status          DebtStatus `json:"status"`
 	Note            *string    `json:"note,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+	Version         int64      `json:"version"`
+	SyncTxID        uint64     `json:"-"` // transaction that last wrote the debt; orders the sync change feed
 }
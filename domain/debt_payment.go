@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// DebtPayment is one repayment of a debt, in the debt's currency. A debt is paid once its
+// payments add up to its amount.
+type DebtPayment struct {
+	ID        string    `json:"id"`
+	DebtID    string    `json:"debt_id"`
+	Amount    Money     `json:"amount"`
+	PaidDate  time.Time `json:"paid_date"`
+	Note      *string   `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -9,45 +9,70 @@ const (
 	RecurrenceDaily   RecurrenceType = "daily"
 	RecurrenceWeekly  RecurrenceType = "weekly"
 	RecurrenceMonthly RecurrenceType = "monthly"
+	RecurrenceYearly  RecurrenceType = "yearly"
 )
 
 // Expense represents a single expense record
 type Expense struct {
-	ID              string         `json:"id"`
-	UserID          string         `json:"user_id"`
-	Amount          float64        `json:"amount"`
-	CategoryID      *string        `json:"category_id,omitempty"`
-	IsRecurring     bool           `json:"is_recurring"`
-	RecurrenceType  RecurrenceType `json:"recurrence_type,omitempty"`
-	NextDueDate     *time.Time     `json:"next_due_date,omitempty"`
-	ReminderEnabled bool           `json:"reminder_enabled"`
-	ReminderSentAt  *time.Time     `json:"reminder_sent_at,omitempty"`
-	Note            string         `json:"note,omitempty"`
-	ExpenseDate     time.Time      `json:"expense_date"`
-	CreatedAt       time.Time      `json:"created_at"`
+	ID              string          `json:"id"`
+	UserID          string          `json:"user_id"`             // who recorded it
+	LedgerID        *string         `json:"ledger_id,omitempty"` // shared ledger it belongs to; nil = the user's own
+	Amount          Money           `json:"amount"`
+	Currency        string          `json:"currency"` // ISO 4217 code
+	CategoryID      *string         `json:"category_id,omitempty"`
+	IsRecurring     bool            `json:"is_recurring"`
+	RecurrenceType  RecurrenceType  `json:"recurrence_type,omitempty"`
+	RecurrenceRule  *RecurrenceRule `json:"recurrence_rule,omitempty"`
+	NextDueDate     *time.Time      `json:"next_due_date,omitempty"`
+	RecurrenceStart *time.Time      `json:"recurrence_start,omitempty"`     // anchor of the recurring series
+	ParentID        *string         `json:"recurrence_parent_id,omitempty"` // template this occurrence was generated from
+	ReminderEnabled bool            `json:"reminder_enabled"`
+	ReminderSentAt  *time.Time      `json:"reminder_sent_at,omitempty"`
+	Note            string          `json:"note,omitempty"`
+	ExpenseDate     time.Time       `json:"expense_date"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	DeletedAt       *time.Time      `json:"deleted_at,omitempty"` // set on tombstones returned by the sync change feed
+	Version         int64           `json:"version"`
+	SyncTxID        uint64          `json:"-"` // transaction that last wrote the expense; orders the sync change feed
+}
+
+// Rule returns the expense's recurrence rule; expenses without one recur every 1 RecurrenceType
+func (e *Expense) Rule() RecurrenceRule {
+	if e.RecurrenceRule != nil {
+		return *e.RecurrenceRule
+	}
+	return RecurrenceRule{Frequency: e.RecurrenceType}
 }
 
 // CreateExpenseInput is the input for creating an expense
 type CreateExpenseInput struct {
-	ID              string         `json:"id"`
-	UserID          string         `json:"user_id"`
-	Amount          float64        `json:"amount"`
-	CategoryID      *string        `json:"category_id,omitempty"`
-	IsRecurring     bool           `json:"is_recurring"`
-	RecurrenceType  RecurrenceType `json:"recurrence_type,omitempty"`
-	NextDueDate     *time.Time     `json:"next_due_date,omitempty"`
-	ReminderEnabled bool           `json:"reminder_enabled"`
-	Note            string         `json:"note,omitempty"`
-	ExpenseDate     time.Time      `json:"expense_date"`
+	ID              string          `json:"id"`
+	UserID          string          `json:"user_id"`
+	LedgerID        *string         `json:"ledger_id,omitempty"`
+	Amount          Money           `json:"amount"`
+	Currency        string          `json:"currency,omitempty"` // empty = the user's default currency
+	CategoryID      *string         `json:"category_id,omitempty"`
+	IsRecurring     bool            `json:"is_recurring"`
+	RecurrenceType  RecurrenceType  `json:"recurrence_type,omitempty"`
+	RecurrenceRule  *RecurrenceRule `json:"recurrence_rule,omitempty"`
+	NextDueDate     *time.Time      `json:"next_due_date,omitempty"`
+	RecurrenceStart *time.Time      `json:"recurrence_start,omitempty"`
+	ReminderEnabled bool            `json:"reminder_enabled"`
+	Note            string          `json:"note,omitempty"`
+	ExpenseDate     time.Time       `json:"expense_date"`
 }
 
 // UpdateExpenseInput is the input for updating an expense (partial update)
 type UpdateExpenseInput struct {
-	Amount          *float64        `json:"amount,omitempty"`
+	Amount          *Money          `json:"amount,omitempty"`
+	Currency        *string         `json:"currency,omitempty"`
 	CategoryID      *string         `json:"category_id,omitempty"`
 	IsRecurring     *bool           `json:"is_recurring,omitempty"`
 	RecurrenceType  *RecurrenceType `json:"recurrence_type,omitempty"`
+	RecurrenceRule  *RecurrenceRule `json:"recurrence_rule,omitempty"`
 	NextDueDate     *time.Time      `json:"next_due_date,omitempty"`
+	RecurrenceStart *time.Time      `json:"recurrence_start,omitempty"`
 	ReminderEnabled *bool           `json:"reminder_enabled,omitempty"`
 	Note            *string         `json:"note,omitempty"`
 	ExpenseDate     *time.Time      `json:"expense_date,omitempty"`
@@ -55,10 +80,13 @@ type UpdateExpenseInput struct {
 
 // ExpenseFilter for listing expenses
 type ExpenseFilter struct {
-	UserID     string     // required for ownership
-	CategoryID *string    // optional filter by category
-	FromDate   *time.Time // optional start date (inclusive)
-	ToDate     *time.Time // optional end date (inclusive)
+	UserID     string      // required for ownership
+	LedgerID   *string     // list this shared ledger's expenses instead of the user's own
+	CategoryID *string     // optional filter by category
+	FromDate   *time.Time  // optional start date (inclusive)
+	ToDate     *time.Time  // optional end date (inclusive)
+	Cursor     *PageCursor // keyset pagination on (expense_date, id) from this position instead of Offset
+	SkipTotal  bool        // return a total of 0 instead of counting the matches
 	Limit      int
 	Offset     int
 }
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// ExpenseSearchSort is what expense search results are ordered by
+type ExpenseSearchSort string
+
+const (
+	ExpenseSortDate   ExpenseSearchSort = "date"
+	ExpenseSortAmount ExpenseSearchSort = "amount"
+)
+
+// ExpenseSearch is a full-text search over expenses narrowed by facets
+type ExpenseSearch struct {
+	UserID        string
+	LedgerID      *string           // search this shared ledger's expenses instead of the user's own
+	Query         string            // words matched against notes and category names; empty matches all
+	CategoryIDs   []string          // any of these categories; empty = all
+	MinAmount     *Money            // inclusive
+	MaxAmount     *Money            // inclusive
+	FromDate      *time.Time        // inclusive
+	ToDate        *time.Time        // inclusive
+	RecurringOnly bool              // only recurring expenses
+	HasAttachment *bool             // only expenses with (true) or without (false) attachments
+	Sort          ExpenseSearchSort // default date
+	Ascending     bool              // default newest or largest first
+	Limit         int
+	Offset        int
+}
+
+// ExpenseFacets counts the expenses a search matches by facet. Each facet is counted with every
+// filter but its own, so the counts show how many results picking that facet value would give.
+type ExpenseFacets struct {
+	Categories    []CategoryFacet `json:"categories"`
+	Recurring     int             `json:"recurring"`
+	HasAttachment int             `json:"has_attachment"`
+}
+
+// CategoryFacet is how many matching expenses are filed under a category
+type CategoryFacet struct {
+	CategoryID   *string `json:"category_id"` // nil for uncategorized expenses
+	CategoryName string  `json:"category_name"`
+	Count        int     `json:"count"`
+}
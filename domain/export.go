@@ -0,0 +1,44 @@
+package domain
+
+// ExportType is what an export holds
+type ExportType string
+
+const (
+	ExportTypeExpenses ExportType = "expenses"
+	ExportTypeDebts    ExportType = "debts"
+	ExportTypeReport   ExportType = "report"
+)
+
+// ValidExportType reports whether t is expenses, debts or report
+func ValidExportType(t ExportType) bool {
+	return t == ExportTypeExpenses || t == ExportTypeDebts || t == ExportTypeReport
+}
+
+// ExportFormat is the file format of an export
+type ExportFormat string
+
+const (
+	ExportFormatCSV  ExportFormat = "csv"
+	ExportFormatJSON ExportFormat = "json"
+	ExportFormatXLSX ExportFormat = "xlsx"
+)
+
+// ValidExportFormat reports whether f is csv, json or xlsx
+func ValidExportFormat(f ExportFormat) bool {
+	return f == ExportFormatCSV || f == ExportFormatJSON || f == ExportFormatXLSX
+}
+
+// ExportRequest says what to export and how. Expenses are filtered like GET /expenses (Limit and
+// Offset are ignored); debts are filtered by due date and are always the user's own; a report
+// covers FromDate to ToDate, which are then required.
+type ExportRequest struct {
+	Type   ExportType
+	Format ExportFormat
+	Filter ExpenseFilter
+}
+
+// ExpenseRecord is an exported expense with the name of its category
+type ExpenseRecord struct {
+	Expense
+	CategoryName string `json:"category_name,omitempty"`
+}
@@ -0,0 +1,94 @@
+package domain
+
+import "time"
+
+// ImportFormat is the file format of a bank statement
+type ImportFormat string
+
+const (
+	ImportFormatCSV ImportFormat = "csv"
+	ImportFormatOFX ImportFormat = "ofx"
+	ImportFormatQIF ImportFormat = "qif"
+)
+
+// ValidImportFormat reports whether f is csv, ofx or qif
+func ValidImportFormat(f ImportFormat) bool {
+	return f == ImportFormatCSV || f == ImportFormatOFX || f == ImportFormatQIF
+}
+
+// ImportOptions says how to read a bank statement. The column, delimiter and number options
+// only apply to CSV files; DateFormats apply to CSV and QIF files (OFX dates have a fixed form).
+type ImportOptions struct {
+	Format         ImportFormat
+	DateColumn     string   // header name or 1-based position; default "date"
+	AmountColumn   string   // default "amount"
+	NoteColumn     string   // default the first of description, note, memo, payee found in the header
+	CategoryColumn string   // optional category name column; default "category" when present
+	CurrencyColumn string   // optional; default "currency" when present
+	DateFormats    []string // such as "DD/MM/YYYY" or "M/D/YY", tried in order
+	Delimiter      rune     // 0 = ','
+	NoHeader       bool     // the first CSV line is a transaction; columns must be positions
+	DecimalComma   bool     // amounts are written like 1.234,56
+	InvertAmounts  bool     // expenses are positive in the file instead of negative
+	Currency       string   // for rows without one; empty = the user's default currency
+}
+
+// StatementRow is one transaction read from a statement, before it becomes an expense. Money
+// going out is negative, as on the statement.
+type StatementRow struct {
+	Line     int // line of the row (CSV, QIF) or position of the transaction (OFX)
+	Date     time.Time
+	Amount   Money
+	Currency string
+	Note     string
+	Category string
+	Error    string // why the row could not be read; the other fields may be empty
+}
+
+// ImportRowStatus is what an import does with a statement row
+type ImportRowStatus string
+
+const (
+	ImportRowNew       ImportRowStatus = "new"       // will be imported
+	ImportRowImported  ImportRowStatus = "imported"  // was imported
+	ImportRowDuplicate ImportRowStatus = "duplicate" // an expense with the same date, amount and note exists
+	ImportRowSkipped   ImportRowStatus = "skipped"   // money coming in, or zero
+	ImportRowInvalid   ImportRowStatus = "invalid"   // could not be read
+)
+
+// ImportRow is the outcome of one statement row
+type ImportRow struct {
+	Line        int                 `json:"line"`
+	Status      ImportRowStatus     `json:"status"`
+	Expense     *CreateExpenseInput `json:"expense,omitempty"`
+	DuplicateOf *string             `json:"duplicate_of,omitempty"` // the existing expense
+	Message     string              `json:"message,omitempty"`      // why the row is skipped or invalid, or a warning
+}
+
+// ImportResult is the preview, or the outcome once committed, of a statement import
+type ImportResult struct {
+	Format     ImportFormat `json:"format"`
+	Committed  bool         `json:"committed"`
+	New        int          `json:"new"` // rows to import, or imported once committed
+	Duplicates int          `json:"duplicates"`
+	Skipped    int          `json:"skipped"`
+	Invalid    int          `json:"invalid"`
+	Rows       []ImportRow  `json:"rows"`
+}
+
+// Count sets the totals from the statuses of the rows; imported rows count as new
+func (r *ImportResult) Count() {
+	r.New, r.Duplicates, r.Skipped, r.Invalid = 0, 0, 0, 0
+	for _, row := range r.Rows {
+		switch row.Status {
+		case ImportRowNew, ImportRowImported:
+			r.New++
+		case ImportRowDuplicate:
+			r.Duplicates++
+		case ImportRowSkipped:
+			r.Skipped++
+		case ImportRowInvalid:
+			r.Invalid++
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package domain
+
+import "time"
+
+// IncomeCategory classifies income (salary, freelance, ...). Income categories are separate from
+// expense categories; UserID nil = global category, non-nil = user-defined.
+type IncomeCategory struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	UserID    *string   `json:"user_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Income is money received. A recurring income is a template: its occurrences are generated as
+// separate incomes on each date of RecurrenceRule, like recurring expenses.
+type Income struct {
+	ID              string          `json:"id"`
+	UserID          string          `json:"user_id"`
+	Source          string          `json:"source"` // who paid, e.g. an employer or client
+	Amount          Money           `json:"amount"`
+	Currency        string          `json:"currency"` // ISO 4217 code
+	CategoryID      *string         `json:"category_id,omitempty"`
+	CategoryName    string          `json:"category_name,omitempty"`
+	IsRecurring     bool            `json:"is_recurring"`
+	RecurrenceRule  *RecurrenceRule `json:"recurrence_rule,omitempty"`
+	NextDueDate     *time.Time      `json:"next_due_date,omitempty"`
+	RecurrenceStart *time.Time      `json:"recurrence_start,omitempty"`
+	ParentID        *string         `json:"recurrence_parent_id,omitempty"` // template this occurrence was generated from
+	Note            string          `json:"note,omitempty"`
+	ReceivedDate    time.Time       `json:"received_date"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// CreateIncomeInput is the input for recording an income
+type CreateIncomeInput struct {
+	UserID          string
+	Source          string
+	Amount          Money
+	Currency        string // empty = the user's default currency
+	CategoryID      *string
+	IsRecurring     bool
+	RecurrenceRule  *RecurrenceRule
+	NextDueDate     *time.Time
+	RecurrenceStart *time.Time
+	Note            string
+	ReceivedDate    time.Time
+}
+
+// UpdateIncomeInput is the input for updating an income (partial update; empty CategoryID clears it)
+type UpdateIncomeInput struct {
+	Source          *string
+	Amount          *Money
+	Currency        *string
+	CategoryID      *string
+	IsRecurring     *bool
+	RecurrenceRule  *RecurrenceRule
+	NextDueDate     *time.Time
+	RecurrenceStart *time.Time
+	Note            *string
+	ReceivedDate    *time.Time
+}
+
+// IncomeFilter for listing incomes
+type IncomeFilter struct {
+	UserID     string     // required for ownership
+	CategoryID *string    // optional filter by income category
+	FromDate   *time.Time // optional start date (inclusive)
+	ToDate     *time.Time // optional end date (inclusive)
+	Limit      int
+	Offset     int
+}
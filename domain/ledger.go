@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// LedgerRole is what a member may do in a shared ledger
+type LedgerRole string
+
+const (
+	LedgerRoleOwner  LedgerRole = "owner"  // manages the ledger and its members, reads and writes records
+	LedgerRoleEditor LedgerRole = "editor" // reads and writes records
+	LedgerRoleViewer LedgerRole = "viewer" // reads records
+)
+
+// ValidLedgerRole reports whether r is owner, editor or viewer
+func ValidLedgerRole(r LedgerRole) bool {
+	switch r {
+	case LedgerRoleOwner, LedgerRoleEditor, LedgerRoleViewer:
+		return true
+	}
+	return false
+}
+
+// CanWrite reports whether the role may add, change and delete the ledger's records
+func (r LedgerRole) CanWrite() bool {
+	return r == LedgerRoleOwner || r == LedgerRoleEditor
+}
+
+// Ledger is a shared book of expenses, categories and budgets, such as a household's. Records
+// with a ledger ID belong to the ledger rather than to the user who recorded them.
+type Ledger struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	CreatedBy string     `json:"created_by"`
+	Role      LedgerRole `json:"role,omitempty"` // the requesting user's role
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// LedgerMember is a user's membership of a ledger
+type LedgerMember struct {
+	LedgerID  string     `json:"ledger_id"`
+	UserID    string     `json:"user_id"`
+	Name      string     `json:"name,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	Role      LedgerRole `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+}
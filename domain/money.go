@@ -0,0 +1,257 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMoney is returned when an amount is not a decimal number or does not fit in Money
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// MaxMoneyPlaces is the most decimal places an amount can have: the minor unit of currencies
+// such as KWD and BHD is a thousandth
+const MaxMoneyPlaces = 3
+
+// Money is an exact amount with up to three decimal places, kept as a whole number of
+// thousandths so that sums match the DECIMAL columns they come from. It reads and writes JSON as
+// a plain number and implements sql.Scanner and driver.Valuer using the decimal text form.
+type Money struct {
+	mills int64
+}
+
+// Cents returns the amount for a whole number of cents
+func Cents(n int64) Money {
+	return Money{mills: n * 10}
+}
+
+// Mills returns the amount for a whole number of thousandths
+func Mills(n int64) Money {
+	return Money{mills: n}
+}
+
+// MoneyFromFloat rounds f to the nearest cent, half away from zero
+func MoneyFromFloat(f float64) Money {
+	return Cents(int64(math.Round(f * 100)))
+}
+
+// ParseMoney reads a decimal string such as "12.5", "-0.005" or "1e3" exactly. Digits past
+// the third decimal place are rounded half away from zero.
+func ParseMoney(s string) (Money, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || strings.Contains(s, "/") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	r.Mul(r, big.NewRat(1000, 1))
+
+	// round half away from zero: truncate |r| + 1/2
+	neg := r.Sign() < 0
+	r.Abs(r)
+	r.Add(r, big.NewRat(1, 2))
+	mills := new(big.Int).Quo(r.Num(), r.Denom())
+	if !mills.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %q is too large", ErrInvalidMoney, s)
+	}
+	if neg {
+		mills.Neg(mills)
+	}
+	return Money{mills: mills.Int64()}, nil
+}
+
+// Float64 returns the amount as a float, for ratios and percentages only
+func (m Money) Float64() float64 { return float64(m.mills) / 1000 }
+
+func (m Money) Add(o Money) Money { return Money{mills: m.mills + o.mills} }
+func (m Money) Sub(o Money) Money { return Money{mills: m.mills - o.mills} }
+func (m Money) Neg() Money        { return Money{mills: -m.mills} }
+
+// Places returns the number of decimal places the amount needs, at least two: 3 for 1.005
+func (m Money) Places() int {
+	if m.mills%10 != 0 {
+		return 3
+	}
+	return 2
+}
+
+// Round rounds the amount half away from zero to places decimal places, from 0 to 3
+func (m Money) Round(places int) Money {
+	unit := placeUnit(places)
+	half := unit / 2
+	if m.mills < 0 {
+		half = -half
+	}
+	return Money{mills: (m.mills + half) / unit * unit}
+}
+
+// FitsCurrency reports whether the amount has no more decimal places than currency's minor unit
+func (m Money) FitsCurrency(currency string) bool {
+	return m.Round(CurrencyExponent(currency)) == m
+}
+
+// placeUnit is the number of thousandths in one unit of the given decimal place
+func placeUnit(places int) int64 {
+	unit := int64(1)
+	for i := places; i < MaxMoneyPlaces; i++ {
+		unit *= 10
+	}
+	return unit
+}
+
+// Mul multiplies the amount by a factor such as a share of a month, rounded to the amount's own
+// places: to the cent, or to the thousandth for amounts that have a third decimal place
+func (m Money) Mul(factor float64) Money {
+	return Money{mills: int64(math.Round(float64(m.mills) * factor))}.Round(m.Places())
+}
+
+// Allocate divides the amount into parts proportional to weights that add up to it exactly. Each
+// part is rounded toward zero to places decimal places (the currency's minor unit) and the units
+// left over go to the parts that lost the most, earlier parts first. The amount must fit in
+// places. Weights must be finite and not negative; all parts are zero when the weights add up to
+// zero.
+func (m Money) Allocate(weights []float64, places int) []Money {
+	parts := make([]Money, len(weights))
+	rats := make([]*big.Rat, len(weights))
+	sum := new(big.Rat)
+	for i, w := range weights {
+		rats[i] = new(big.Rat).SetFloat64(w)
+		sum.Add(sum, rats[i])
+	}
+	if sum.Sign() == 0 {
+		return parts
+	}
+
+	unit := placeUnit(places)
+	units := m.mills / unit
+	if units < 0 {
+		units = -units
+	}
+	left := units
+	fracs := make([]*big.Rat, len(weights))
+	for i, w := range rats {
+		share := new(big.Rat).Mul(new(big.Rat).SetInt64(units), w)
+		share.Quo(share, sum)
+		whole := new(big.Int).Quo(share.Num(), share.Denom())
+		parts[i].mills = whole.Int64()
+		left -= parts[i].mills
+		fracs[i] = share.Sub(share, new(big.Rat).SetInt(whole))
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return fracs[order[a]].Cmp(fracs[order[b]]) > 0 })
+	for k := int64(0); k < left; k++ {
+		parts[order[k]].mills++
+	}
+
+	for i := range parts {
+		parts[i].mills *= unit
+		if m.mills < 0 {
+			parts[i].mills = -parts[i].mills
+		}
+	}
+	return parts
+}
+
+// Cmp returns -1, 0 or +1 as m is less than, equal to or greater than o
+func (m Money) Cmp(o Money) int {
+	switch {
+	case m.mills < o.mills:
+		return -1
+	case m.mills > o.mills:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) IsZero() bool     { return m.mills == 0 }
+func (m Money) IsPositive() bool { return m.mills > 0 }
+func (m Money) IsNegative() bool { return m.mills < 0 }
+
+// String formats the amount with two decimal places, or three when it has a third, e.g.
+// "-1234.50" or "12.345"
+func (m Money) String() string {
+	sign := ""
+	if m.mills < 0 {
+		sign = "-"
+	}
+	whole, frac := m.mills/1000, m.mills%1000
+	if whole < 0 {
+		whole = -whole
+	}
+	if frac < 0 {
+		frac = -frac
+	}
+	if m.Places() == 2 {
+		return fmt.Sprintf("%s%d.%02d", sign, whole, frac/10)
+	}
+	return fmt.Sprintf("%s%d.%03d", sign, whole, frac)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding one; null leaves the amount unchanged
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidMoney, s)
+		}
+		s = unquoted
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads a DECIMAL column. NULL scans as zero, so a SUM over no rows is zero.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case int64:
+		if v > math.MaxInt64/1000 || v < math.MinInt64/1000 {
+			return fmt.Errorf("%w: %d is too large", ErrInvalidMoney, v)
+		}
+		*m = Money{mills: v * 1000}
+	case float64:
+		*m = Money{mills: int64(math.Round(v * 1000))}
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidMoney, src)
+	}
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value writes the amount as decimal text so the database stores it exactly
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
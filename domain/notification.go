@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"net/netip"
+	"time"
+)
+
+// NotificationChannel is a way of delivering a notification to a user
+type NotificationChannel string
+
+const (
+	NotificationChannelEmail   NotificationChannel = "email"
+	NotificationChannelWebhook NotificationChannel = "webhook"
+	NotificationChannelLog     NotificationChannel = "log"
+)
+
+// NotificationStatus is the delivery state of a notification
+type NotificationStatus string
+
+const (
+	NotificationStatusPending NotificationStatus = "pending" // waiting for its first or next attempt
+	NotificationStatusSent    NotificationStatus = "sent"
+	NotificationStatusFailed  NotificationStatus = "failed" // gave up after the last retry
+)
+
+// Subjects a notification can be about
+const (
+	NotificationSubjectDebt             = "debt"
+	NotificationSubjectRecurringExpense = "recurring_expense"
+)
+
+// Notification is one message to deliver on one channel, with its delivery status
+type Notification struct {
+	ID            string              `json:"id"`
+	UserID        string              `json:"user_id"`
+	Channel       NotificationChannel `json:"channel"`
+	Target        string              `json:"target"` // email address or webhook URL
+	SubjectType   string              `json:"subject_type"`
+	SubjectID     string              `json:"subject_id"`
+	DedupeKey     string              `json:"-"`
+	Title         string              `json:"title"`
+	Body          string              `json:"body"`
+	Status        NotificationStatus  `json:"status"`
+	Attempts      int                 `json:"attempts"`
+	LastError     *string             `json:"last_error,omitempty"`
+	NextAttemptAt time.Time           `json:"next_attempt_at"`
+	SentAt        *time.Time          `json:"sent_at,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+// NotificationPreference enables a channel for a user
+type NotificationPreference struct {
+	UserID  string              `json:"user_id"`
+	Channel NotificationChannel `json:"channel"`
+	Target  string              `json:"target"`
+	Enabled bool                `json:"enabled"`
+}
+
+// blockedWebhookPrefixes are ranges webhooks may not reach besides loopback, private, link-local,
+// multicast and unspecified addresses: "this network", shared (CGNAT), IETF protocol, benchmarking
+// and reserved IPv4 space, and NAT64 addresses that embed any IPv4 address
+var blockedWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// WebhookAddressAllowed reports whether webhooks may be delivered to addr. Only public unicast
+// addresses are allowed, so a webhook URL cannot reach the server's own host or network.
+func WebhookAddressAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range blockedWebhookPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
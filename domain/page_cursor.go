@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned for a page cursor that was not issued by the API
+var ErrInvalidCursor = errors.New("cursor is invalid")
+
+// PageCursor is a position in a list read with keyset pagination: the (date, id) sort key of a
+// row, and whether the page ends before that row or starts after it. The zero cursor is the
+// start of the list.
+type PageCursor struct {
+	Date     time.Time
+	ID       string
+	Backward bool // the page is the one before the row (a previous page)
+}
+
+// IsStart reports whether c is the start of the list
+func (c PageCursor) IsStart() bool {
+	return c.ID == ""
+}
+
+// pageCursorJSON is how a PageCursor is encoded
+type pageCursorJSON struct {
+	Date     string `json:"d"`
+	ID       string `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// Encode returns c as an opaque URL-safe string
+func (c PageCursor) Encode() string {
+	data, _ := json.Marshal(pageCursorJSON{Date: c.Date.Format("2006-01-02"), ID: c.ID, Backward: c.Backward})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePageCursor reads a cursor made by Encode; the empty string is the start of the list
+func DecodePageCursor(s string) (PageCursor, error) {
+	if s == "" {
+		return PageCursor{}, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return PageCursor{}, ErrInvalidCursor
+	}
+	var raw pageCursorJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return PageCursor{}, ErrInvalidCursor
+	}
+	if _, err := uuid.Parse(raw.ID); err != nil {
+		return PageCursor{}, ErrInvalidCursor
+	}
+	date, err := time.Parse("2006-01-02", raw.Date)
+	if err != nil {
+		return PageCursor{}, ErrInvalidCursor
+	}
+	return PageCursor{Date: date, ID: raw.ID, Backward: raw.Backward}, nil
+}
+
+// PageCursors are the cursors of the pages around a keyset-paginated page; empty when there is
+// no such page
+type PageCursors struct {
+	Next string
+	Prev string
+}
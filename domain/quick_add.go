@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrQuickAddNotUnderstood is returned by quick-add parsers for text they cannot turn into an
+// expense or a debt; the next parser is tried
+var ErrQuickAddNotUnderstood = errors.New("the text could not be understood")
+
+// QuickAddKind is the kind of record a quick-add text describes
+type QuickAddKind string
+
+const (
+	QuickAddExpense QuickAddKind = "expense"
+	QuickAddDebt    QuickAddKind = "debt"
+)
+
+// QuickAddHints is what quick-add parsers know besides the text
+type QuickAddHints struct {
+	Today      time.Time   // the user's date, which relative dates count from
+	Categories []*Category // the categories an expense can be filed under
+}
+
+// QuickAddResult is the record read from a quick-add text: Expense for an expense, Debt for a debt.
+// Parsers fill in what the text says; the rest (IDs, user, defaults) is set before it is saved.
+type QuickAddResult struct {
+	Kind    QuickAddKind        `json:"kind"`
+	Parser  string              `json:"parser"` // which parser read the text
+	Expense *CreateExpenseInput `json:"expense,omitempty"`
+	Debt    *Debt               `json:"debt,omitempty"`
+}
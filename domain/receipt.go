@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnreadableReceipt is returned by receipt extractors for files they cannot read, such as an
+// image given to a PDF parser or a scanned PDF without a text layer; the next extractor is tried
+var ErrUnreadableReceipt = errors.New("the receipt could not be read")
+
+// ReceiptDraftStatus is how far reading a receipt draft got
+type ReceiptDraftStatus string
+
+const (
+	ReceiptDraftPending ReceiptDraftStatus = "pending" // waiting for its first or next extraction attempt
+	ReceiptDraftReady   ReceiptDraftStatus = "ready"   // read; Expense is the expense to confirm
+	ReceiptDraftFailed  ReceiptDraftStatus = "failed"  // nothing could be read; it can still be confirmed with the user's values
+)
+
+// ReceiptLineItem is one purchased item on a receipt
+type ReceiptLineItem struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity,omitempty"` // 0 when the receipt does not say
+	Amount      Money   `json:"amount"`             // for the whole line
+}
+
+// ReceiptData is what an extractor read from a receipt; fields it could not find are empty
+type ReceiptData struct {
+	Merchant  string            `json:"merchant,omitempty"`
+	Date      *time.Time        `json:"date,omitempty"`
+	Total     *Money            `json:"total,omitempty"`
+	Currency  string            `json:"currency,omitempty"` // ISO 4217 code
+	LineItems []ReceiptLineItem `json:"line_items,omitempty"`
+}
+
+// ReceiptDraft is an uploaded receipt on its way to becoming an expense. Once read, Expense holds
+// the expense it suggests, which the user confirms as is or with changes. The file lives in a
+// blob store under StorageKey and becomes an attachment of the confirmed expense.
+type ReceiptDraft struct {
+	ID            string              `json:"id"`
+	UserID        string              `json:"user_id"`
+	LedgerID      *string             `json:"ledger_id,omitempty"`
+	FileName      string              `json:"file_name"`
+	ContentType   string              `json:"content_type"`
+	Size          int64               `json:"size"`
+	StorageKey    string              `json:"-"`
+	Status        ReceiptDraftStatus  `json:"status"`
+	Extractor     string              `json:"extractor,omitempty"` // which extractor read the receipt
+	Receipt       *ReceiptData        `json:"receipt,omitempty"`
+	Expense       *CreateExpenseInput `json:"expense,omitempty"`
+	Attempts      int                 `json:"attempts"`
+	LastError     *string             `json:"last_error,omitempty"`
+	NextAttemptAt time.Time           `json:"-"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
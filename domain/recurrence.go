@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// RecurrenceRule is an RRULE-style (RFC 5545 subset) schedule for a recurring expense
+type RecurrenceRule struct {
+	Frequency  RecurrenceType `json:"frequency"`
+	Interval   int            `json:"interval,omitempty"`     // every N days/weeks/months/years; 0 means 1
+	ByWeekday  []string       `json:"by_weekday,omitempty"`   // weekly only: MO, TU, WE, TH, FR, SA, SU
+	ByMonthDay []int          `json:"by_month_day,omitempty"` // monthly only: 1..31, or -1..-31 counted from month end
+	Until      *time.Time     `json:"until,omitempty"`        // last possible occurrence date (inclusive)
+	Count      int            `json:"count,omitempty"`        // total occurrences from the series start; 0 means unlimited
+}
+
+// maxRecurrencePeriods bounds how many periods a rule is expanded over
+const maxRecurrencePeriods = 100000
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// ParseWeekday converts an RRULE weekday code (MO..SU) to a time.Weekday
+func ParseWeekday(code string) (time.Weekday, bool) {
+	d, ok := weekdayCodes[code]
+	return d, ok
+}
+
+// ValidRecurrenceType reports whether t is a supported recurrence
+func ValidRecurrenceType(t RecurrenceType) bool {
+	switch t {
+	case RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly, RecurrenceYearly:
+		return true
+	}
+	return false
+}
+
+// NextOccurrence returns the first occurrence of a simple (every 1 unit) series anchored at start
+// that falls strictly after `after`. ok is false for an unknown type.
+func NextOccurrence(t RecurrenceType, start, after time.Time) (next time.Time, ok bool) {
+	return RecurrenceRule{Frequency: t}.Next(start, after)
+}
+
+// Next returns the first occurrence of the series anchored at start that falls strictly after
+// `after`. ok is false when the series has ended or the rule is invalid.
+func (r RecurrenceRule) Next(start, after time.Time) (next time.Time, ok bool) {
+	after = dateOnly(after)
+	r.each(start, func(d time.Time) bool {
+		if d.After(after) {
+			next, ok = d, true
+			return false
+		}
+		return true
+	})
+	return next, ok
+}
+
+// First returns the series' first occurrence on or after start
+func (r RecurrenceRule) First(start time.Time) (time.Time, bool) {
+	return r.Next(start, dateOnly(start).AddDate(0, 0, -1))
+}
+
+// Occurrences returns up to limit occurrences of the series anchored at start that fall between
+// from and to (both inclusive). A zero `to` means no upper bound.
+func (r RecurrenceRule) Occurrences(start, from, to time.Time, limit int) []time.Time {
+	from = dateOnly(from)
+	if !to.IsZero() {
+		to = dateOnly(to)
+	}
+	var dates []time.Time
+	if limit <= 0 {
+		return dates
+	}
+	r.each(start, func(d time.Time) bool {
+		if !to.IsZero() && d.After(to) {
+			return false
+		}
+		if !d.Before(from) {
+			dates = append(dates, d)
+		}
+		return len(dates) < limit
+	})
+	return dates
+}
+
+// each calls fn with every occurrence in order until fn returns false or the series ends.
+// Monthly and yearly dates past the end of a shorter month are clamped to its last day
+// (a series starting Jan 31 goes Feb 28/29, Mar 31, Apr 30, ...).
+func (r RecurrenceRule) each(start time.Time, fn func(time.Time) bool) {
+	if !ValidRecurrenceType(r.Frequency) {
+		return
+	}
+	start = dateOnly(start)
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	var until time.Time
+	if r.Until != nil {
+		until = dateOnly(*r.Until)
+	}
+
+	emitted := 0
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		for _, d := range r.periodDates(start, period*interval) {
+			if d.Before(start) {
+				continue
+			}
+			if (!until.IsZero() && d.After(until)) || (r.Count > 0 && emitted >= r.Count) {
+				return
+			}
+			emitted++
+			if !fn(d) {
+				return
+			}
+		}
+	}
+}
+
+// periodDates returns the candidate dates, in order, of the period `offset` units after start's
+func (r RecurrenceRule) periodDates(start time.Time, offset int) []time.Time {
+	switch r.Frequency {
+	case RecurrenceDaily:
+		return []time.Time{start.AddDate(0, 0, offset)}
+	case RecurrenceWeekly:
+		if len(r.ByWeekday) == 0 {
+			return []time.Time{start.AddDate(0, 0, 7*offset)}
+		}
+		monday := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*offset)
+		dates := make([]time.Time, 0, len(r.ByWeekday))
+		for _, code := range r.ByWeekday {
+			if wd, ok := ParseWeekday(code); ok {
+				dates = append(dates, monday.AddDate(0, 0, (int(wd)+6)%7))
+			}
+		}
+		return sortedUnique(dates)
+	case RecurrenceMonthly:
+		if len(r.ByMonthDay) == 0 {
+			return []time.Time{AddMonthsClamped(start, offset)}
+		}
+		first := AddMonthsClamped(time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC), offset)
+		lastDay := first.AddDate(0, 1, -1).Day()
+		dates := make([]time.Time, 0, len(r.ByMonthDay))
+		for _, day := range r.ByMonthDay {
+			if day < 0 {
+				day = lastDay + 1 + day
+			}
+			if day < 1 {
+				day = 1
+			}
+			if day > lastDay {
+				day = lastDay
+			}
+			dates = append(dates, first.AddDate(0, 0, day-1))
+		}
+		return sortedUnique(dates)
+	case RecurrenceYearly:
+		return []time.Time{AddMonthsClamped(start, 12*offset)}
+	}
+	return nil
+}
+
+func sortedUnique(dates []time.Time) []time.Time {
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	unique := dates[:0]
+	for i, d := range dates {
+		if i == 0 || !d.Equal(dates[i-1]) {
+			unique = append(unique, d)
+		}
+	}
+	return unique
+}
+
+// AddMonthsClamped adds n months to t, clamping the day to the last day of the target month
+func AddMonthsClamped(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -1 +1,14 @@
 package domain
+
+import "time"
+
+// Reminder is one scheduled reminder for a debt. A debt with no reminders falls back to the
+// default schedule (3 days before, 1 day before and on the due date).
+type Reminder struct {
+	ID        string     `json:"id"`
+	DebtID    string     `json:"debt_id"`
+	RemindAt  time.Time  `json:"remind_at"`
+	Enabled   bool       `json:"enabled"`
+	FiredAt   *time.Time `json:"fired_at,omitempty"` // set once the reminder job has queued it
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+// MemberBalance is where a member of a group (a shared ledger) stands in one currency
+type MemberBalance struct {
+	UserID   string `json:"user_id"`
+	Name     string `json:"name"`
+	Currency string `json:"currency"`
+	Paid     Money  `json:"paid"`    // the group's expenses the member recorded, and so paid for
+	Share    Money  `json:"share"`   // the member's equal share of the group's expenses
+	Settled  Money  `json:"settled"` // settle-up payments the member made, less those received
+	Net      Money  `json:"net"`     // paid - share + settled; positive when the others owe the member
+}
+
+// Transfer is one payment between two members that settles their balances
+type Transfer struct {
+	FromUserID string `json:"from_user_id"`
+	FromName   string `json:"from_name"`
+	ToUserID   string `json:"to_user_id"`
+	ToName     string `json:"to_name"`
+	Amount     Money  `json:"amount"`
+	Currency   string `json:"currency"`
+}
+
+// Settlement is how the members of a group stand and the transfers that settle them up
+type Settlement struct {
+	GroupID   string          `json:"group_id"`
+	Balances  []MemberBalance `json:"balances"`
+	Transfers []Transfer      `json:"transfers"`
+}
+
+// SettlementPayment is a settle-up payment one member made to another
+type SettlementPayment struct {
+	FromUserID string
+	ToUserID   string
+	Amount     Money
+	Currency   string
+	PaidDate   time.Time // zero = today
+	Note       *string
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// SplitMethod selects how an expense is divided among its participants
+type SplitMethod string
+
+const (
+	SplitEqual      SplitMethod = "equal"      // the same amount each
+	SplitExact      SplitMethod = "exact"      // each participant's amount is given
+	SplitPercentage SplitMethod = "percentage" // percentages adding up to 100
+	SplitShares     SplitMethod = "shares"     // amounts proportional to each participant's shares
+)
+
+// ValidSplitMethod reports whether m is a supported split method
+func ValidSplitMethod(m SplitMethod) bool {
+	return m == SplitEqual || m == SplitExact || m == SplitPercentage || m == SplitShares
+}
+
+// SplitParticipant is one person sharing an expense: the user (Self), or a contact given by
+// ContactID or by Name. Only the field for the split's method is read.
+type SplitParticipant struct {
+	Self      bool    `json:"self,omitempty"`
+	ContactID *string `json:"contact_id,omitempty"`
+	Name      string  `json:"name,omitempty"`
+	Amount    Money   `json:"amount,omitzero"`  // exact
+	Percent   float64 `json:"percent,omitzero"` // percentage
+	Shares    float64 `json:"shares,omitzero"`  // shares
+}
+
+// Split divides an expense the user paid for. The user's share is recorded as the expense and
+// every other participant's share as a lent debt due on DueDate.
+type Split struct {
+	Method       SplitMethod
+	Participants []SplitParticipant
+	DueDate      time.Time // zero = 30 days from today
+}
@@ -0,0 +1,67 @@
+package domain
+
+// SyncItemStatus is the outcome of syncing a single client-created record
+type SyncItemStatus string
+
+const (
+	SyncItemInserted SyncItemStatus = "inserted"
+	SyncItemSkipped  SyncItemStatus = "skipped"
+	SyncItemConflict SyncItemStatus = "conflict"
+	SyncItemInvalid  SyncItemStatus = "invalid"
+)
+
+// SyncItemResult reports what happened to one item of a sync batch
+type SyncItemResult struct {
+	ID     string         `json:"id"`
+	Status SyncItemStatus `json:"status"`
+	Reason string         `json:"reason,omitempty"`
+}
+
+// SyncResult summarizes a sync batch (POST /sync/expenses)
+type SyncResult struct {
+	Inserted  int              `json:"inserted"`
+	Skipped   int              `json:"skipped"`
+	Conflicts int              `json:"conflicts"`
+	Invalid   int              `json:"invalid"`
+	Items     []SyncItemResult `json:"items"`
+}
+
+// Add appends an item result and updates the matching counter
+func (r *SyncResult) Add(item SyncItemResult) {
+	switch item.Status {
+	case SyncItemInserted:
+		r.Inserted++
+	case SyncItemSkipped:
+		r.Skipped++
+	case SyncItemConflict:
+		r.Conflicts++
+	case SyncItemInvalid:
+		r.Invalid++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// SyncPosition is a place in the sync change feed, which is ordered by the transaction that last
+// wrote each record, then by version. The zero position is the start of the feed.
+type SyncPosition struct {
+	TxID    uint64
+	Version int64
+}
+
+// Before reports whether p comes before q in the change feed
+func (p SyncPosition) Before(q SyncPosition) bool {
+	if p.TxID != q.TxID {
+		return p.TxID < q.TxID
+	}
+	return p.Version < q.Version
+}
+
+// SyncChanges is one page of the server change feed (GET /sync/changes). Records with
+// deleted_at set are tombstones the client should remove locally.
+type SyncChanges struct {
+	Expenses   []*Expense  `json:"expenses"`
+	Debts      []*Debt     `json:"debts"`
+	Categories []*Category `json:"categories"`
+	NextCursor string      `json:"next_cursor"`
+	HasMore    bool        `json:"has_more"`
+}
@@ -7,17 +7,19 @@ import (
 )
 
 type User struct {
-	UserID          uuid.UUID `json:"id"`
-	Name            string    `json:"name"`
-	Email           string    `json:"email"`
-	PasswordHash    string    `json:"-"`
-	BudgetingStyle  string    `json:"budgeting_style"`
-	DefaultCurrency string    `json:"default_currency"`
-	CreatedAt       time.Time `json:"created_at"`
+	UserID          uuid.UUID      `json:"id"`
+	Name            string         `json:"name"`
+	Email           string         `json:"email"`
+	PasswordHash    string         `json:"-"`
+	BudgetingStyle  BudgetingStyle `json:"budgeting_style"`
+	MonthlyIncome   Money          `json:"monthly_income"` // planned, used by zero-based and 50/30/20 budgeting
+	DefaultCurrency string         `json:"default_currency"`
+	CreatedAt       time.Time      `json:"created_at"`
 }
 
 type UpdateUserInput struct {
 	Name            *string
-	BudgetingStyle  *string
+	BudgetingStyle  *BudgetingStyle
+	MonthlyIncome   *Money
 	DefaultCurrency *string
 }
@@ -0,0 +1,71 @@
+-- +goose Up
+-- Every insert/update on a synced table takes the next value of one shared sequence and
+-- records the transaction that wrote it (sync_xid). Versions are handed out in write order,
+-- not commit order: a transaction can commit a row with a lower version than rows already
+-- read. The change feed is therefore ordered by (sync_xid, version) and stops at the oldest
+-- transaction still running (pg_snapshot_xmin), so nothing can later commit behind a cursor.
+CREATE SEQUENCE IF NOT EXISTS sync_version_seq;
+
+ALTER TABLE expenses
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT nextval('sync_version_seq'),
+    ADD COLUMN IF NOT EXISTS sync_xid XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+ALTER TABLE debts
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT nextval('sync_version_seq'),
+    ADD COLUMN IF NOT EXISTS sync_xid XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+ALTER TABLE categories
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT nextval('sync_version_seq'),
+    ADD COLUMN IF NOT EXISTS sync_xid XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION bump_sync_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.sync_xid := pg_current_xact_id();
+    NEW.version := nextval('sync_version_seq');
+    NEW.updated_at := NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER expenses_sync_version BEFORE INSERT OR UPDATE ON expenses
+    FOR EACH ROW EXECUTE FUNCTION bump_sync_version();
+CREATE TRIGGER debts_sync_version BEFORE INSERT OR UPDATE ON debts
+    FOR EACH ROW EXECUTE FUNCTION bump_sync_version();
+CREATE TRIGGER categories_sync_version BEFORE INSERT OR UPDATE ON categories
+    FOR EACH ROW EXECUTE FUNCTION bump_sync_version();
+
+CREATE INDEX IF NOT EXISTS idx_expenses_user_sync ON expenses(user_id, sync_xid, version);
+CREATE INDEX IF NOT EXISTS idx_debts_user_sync ON debts(user_id, sync_xid, version);
+CREATE INDEX IF NOT EXISTS idx_categories_user_sync ON categories(user_id, sync_xid, version);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_categories_user_sync;
+DROP INDEX IF EXISTS idx_debts_user_sync;
+DROP INDEX IF EXISTS idx_expenses_user_sync;
+
+DROP TRIGGER IF EXISTS categories_sync_version ON categories;
+DROP TRIGGER IF EXISTS debts_sync_version ON debts;
+DROP TRIGGER IF EXISTS expenses_sync_version ON expenses;
+DROP FUNCTION IF EXISTS bump_sync_version();
+
+-- Tombstones would reappear once deleted_at is gone. Reminders of deleted debts go with them,
+-- and expenses still filed under a deleted category become uncategorized.
+DELETE FROM expenses WHERE deleted_at IS NOT NULL;
+DELETE FROM reminders WHERE debt_id IN (SELECT id FROM debts WHERE deleted_at IS NOT NULL);
+DELETE FROM debts WHERE deleted_at IS NOT NULL;
+UPDATE expenses SET category_id = NULL WHERE category_id IN (SELECT id FROM categories WHERE deleted_at IS NOT NULL);
+DELETE FROM categories WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS sync_xid, DROP COLUMN IF EXISTS version, DROP COLUMN IF EXISTS deleted_at, DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE debts DROP COLUMN IF EXISTS sync_xid, DROP COLUMN IF EXISTS version, DROP COLUMN IF EXISTS deleted_at, DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE expenses DROP COLUMN IF EXISTS sync_xid, DROP COLUMN IF EXISTS version, DROP COLUMN IF EXISTS deleted_at, DROP COLUMN IF EXISTS updated_at;
+
+DROP SEQUENCE IF EXISTS sync_version_seq;
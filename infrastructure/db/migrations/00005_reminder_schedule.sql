@@ -0,0 +1,18 @@
+-- +goose Up
+-- Turn the reminders table into a per-debt schedule: fired_at marks reminders the job has
+-- already queued, so each one fires exactly once.
+ALTER TABLE reminders
+    ADD COLUMN IF NOT EXISTS fired_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+UPDATE reminders SET enabled = TRUE WHERE enabled IS NULL;
+ALTER TABLE reminders ALTER COLUMN enabled SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_reminders_debt ON reminders(debt_id);
+CREATE INDEX IF NOT EXISTS idx_reminders_due ON reminders(remind_at) WHERE fired_at IS NULL AND enabled;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_reminders_due;
+DROP INDEX IF EXISTS idx_reminders_debt;
+ALTER TABLE reminders ALTER COLUMN enabled DROP NOT NULL;
+ALTER TABLE reminders DROP COLUMN IF EXISTS created_at, DROP COLUMN IF EXISTS fired_at;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- recurrence_start anchors a recurring series (monthly occurrences keep its day of month, clamped
+-- to short months); recurrence_parent_id links generated occurrences to their template expense.
+ALTER TABLE expenses
+    ADD COLUMN IF NOT EXISTS recurrence_start DATE NULL,
+    ADD COLUMN IF NOT EXISTS recurrence_parent_id UUID NULL REFERENCES expenses(id);
+
+UPDATE expenses SET recurrence_start = next_due_date
+WHERE is_recurring = TRUE AND recurrence_start IS NULL AND next_due_date IS NOT NULL;
+
+-- One occurrence per template and date, so materializing twice never duplicates
+CREATE UNIQUE INDEX IF NOT EXISTS idx_expenses_recurrence_occurrence
+    ON expenses(recurrence_parent_id, expense_date) WHERE recurrence_parent_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_expenses_recurring_due
+    ON expenses(next_due_date) WHERE is_recurring = TRUE AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_expenses_recurring_due;
+DROP INDEX IF EXISTS idx_expenses_recurrence_occurrence;
+ALTER TABLE expenses DROP COLUMN IF EXISTS recurrence_parent_id, DROP COLUMN IF EXISTS recurrence_start;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- recurrence_rule holds the RRULE-style schedule (frequency, interval, by_weekday, by_month_day,
+-- until, count); recurrence_type stays in sync with its frequency for older clients.
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS recurrence_rule JSONB NULL;
+
+UPDATE expenses SET recurrence_rule = jsonb_build_object('frequency', recurrence_type)
+WHERE is_recurring = TRUE AND recurrence_rule IS NULL AND recurrence_type IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE expenses DROP COLUMN IF EXISTS recurrence_rule;
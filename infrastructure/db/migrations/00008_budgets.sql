@@ -0,0 +1,18 @@
+-- +goose Up
+-- Monthly spending limits: one per user and category, plus at most one overall (category_id NULL)
+CREATE TABLE IF NOT EXISTS budgets (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    category_id UUID NULL REFERENCES categories(id),
+    amount DECIMAL NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_user_category
+    ON budgets(user_id, category_id) WHERE category_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_user_overall
+    ON budgets(user_id) WHERE category_id IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS budgets;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- budgeting_style used to be free text; anything unknown falls back to flexible
+UPDATE users SET budgeting_style = 'flexible'
+    WHERE budgeting_style NOT IN ('flexible', 'envelope', 'zero_based', '50_30_20');
+ALTER TABLE users ADD CONSTRAINT users_budgeting_style_check
+    CHECK (budgeting_style IN ('flexible', 'envelope', 'zero_based', '50_30_20'));
+
+-- Planned monthly income for zero-based and 50/30/20 budgeting
+ALTER TABLE users ADD COLUMN IF NOT EXISTS monthly_income DECIMAL NOT NULL DEFAULT 0 CHECK (monthly_income >= 0);
+
+-- 50/30/20 bucket of a category budget
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS bucket TEXT NULL CHECK (bucket IN ('needs', 'wants', 'savings'));
+
+-- +goose Down
+ALTER TABLE budgets DROP COLUMN IF EXISTS bucket;
+ALTER TABLE users DROP COLUMN IF EXISTS monthly_income;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_budgeting_style_check;
@@ -0,0 +1,43 @@
+-- +goose Up
+-- Income categories are separate from expense categories; user_id NULL = global
+CREATE TABLE IF NOT EXISTS income_categories (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    user_id UUID NULL REFERENCES users(user_id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO income_categories (id, name) VALUES
+    ('7a1d2c3e-0001-4a5b-9c6d-000000000001', 'Salary'),
+    ('7a1d2c3e-0001-4a5b-9c6d-000000000002', 'Freelance'),
+    ('7a1d2c3e-0001-4a5b-9c6d-000000000003', 'Business'),
+    ('7a1d2c3e-0001-4a5b-9c6d-000000000004', 'Investments'),
+    ('7a1d2c3e-0001-4a5b-9c6d-000000000005', 'Gifts'),
+    ('7a1d2c3e-0001-4a5b-9c6d-000000000006', 'Other')
+ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS incomes (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    source TEXT NOT NULL,
+    amount DECIMAL NOT NULL CHECK (amount > 0),
+    category_id UUID NULL REFERENCES income_categories(id) ON DELETE SET NULL,
+    is_recurring BOOLEAN NOT NULL DEFAULT FALSE,
+    recurrence_rule JSONB NULL,
+    next_due_date DATE NULL,
+    recurrence_start DATE NULL,
+    recurrence_parent_id UUID NULL REFERENCES incomes(id) ON DELETE SET NULL,
+    note TEXT NULL,
+    received_date DATE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incomes_user_date ON incomes(user_id, received_date);
+CREATE INDEX IF NOT EXISTS idx_incomes_recurring_due ON incomes(next_due_date) WHERE is_recurring = TRUE;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_incomes_parent_date
+    ON incomes(recurrence_parent_id, received_date) WHERE recurrence_parent_id IS NOT NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS incomes;
+DROP TABLE IF EXISTS income_categories;
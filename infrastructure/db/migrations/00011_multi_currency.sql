@@ -0,0 +1,62 @@
+-- +goose Up
+-- Currency of every amount; existing rows are in their owner's default currency
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS currency TEXT NULL;
+UPDATE expenses e SET currency = u.default_currency FROM users u WHERE u.user_id = e.user_id AND e.currency IS NULL;
+ALTER TABLE expenses ALTER COLUMN currency SET NOT NULL;
+
+ALTER TABLE debts ADD COLUMN IF NOT EXISTS currency TEXT NULL;
+UPDATE debts d SET currency = u.default_currency FROM users u WHERE u.user_id = d.user_id AND d.currency IS NULL;
+ALTER TABLE debts ALTER COLUMN currency SET NOT NULL;
+
+ALTER TABLE incomes ADD COLUMN IF NOT EXISTS currency TEXT NULL;
+UPDATE incomes i SET currency = u.default_currency FROM users u WHERE u.user_id = i.user_id AND i.currency IS NULL;
+ALTER TABLE incomes ALTER COLUMN currency SET NOT NULL;
+
+-- One unit of base_currency was worth rate units of quote_currency on rate_date
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    base_currency TEXT NOT NULL,
+    quote_currency TEXT NOT NULL,
+    rate_date DATE NOT NULL,
+    rate DECIMAL NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (base_currency, quote_currency, rate_date),
+    CHECK (base_currency <> quote_currency)
+);
+
+-- exchange_rate returns the rate from one currency to another as of on_date, using the pair in
+-- either direction: the latest rate on or before on_date, else the earliest one after it.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION exchange_rate(from_currency TEXT, to_currency TEXT, on_date DATE)
+RETURNS DECIMAL AS $$
+    SELECT x.rate FROM (
+        SELECT rate, rate_date FROM exchange_rates
+            WHERE base_currency = from_currency AND quote_currency = to_currency
+        UNION ALL
+        SELECT 1 / rate, rate_date FROM exchange_rates
+            WHERE base_currency = to_currency AND quote_currency = from_currency
+    ) x
+    ORDER BY x.rate_date > on_date, ABS(x.rate_date - on_date)
+    LIMIT 1
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- convert_currency converts amount as of on_date, directly or through USD. It is NULL when no
+-- usable rate exists, so sums leave the amount out rather than count it at an invented rate.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION convert_currency(amount DECIMAL, from_currency TEXT, to_currency TEXT, on_date DATE)
+RETURNS DECIMAL AS $$
+    SELECT CASE WHEN from_currency = to_currency THEN amount
+        ELSE amount * COALESCE(
+            exchange_rate(from_currency, to_currency, on_date),
+            exchange_rate(from_currency, 'USD', on_date) * exchange_rate('USD', to_currency, on_date))
+    END
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION IF EXISTS convert_currency(DECIMAL, TEXT, TEXT, DATE);
+DROP FUNCTION IF EXISTS exchange_rate(TEXT, TEXT, DATE);
+DROP TABLE IF EXISTS exchange_rates;
+ALTER TABLE incomes DROP COLUMN IF EXISTS currency;
+ALTER TABLE debts DROP COLUMN IF EXISTS currency;
+ALTER TABLE expenses DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,85 @@
+-- +goose Up
+-- currency_exponent is the number of decimal places in a currency's minor unit (ISO 4217): 0
+-- for JPY, 3 for KWD, 2 for anything not listed. domain.CurrencyExponent holds the same table.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION currency_exponent(code TEXT)
+RETURNS INT AS $$
+    SELECT CASE
+        WHEN code IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX',
+                      'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 0
+        WHEN code IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 3
+        ELSE 2
+    END
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- Amounts are kept exactly, never rounded: the migration stops when an amount has more decimal
+-- places than its currency's minor unit so the rows can be fixed by hand first. Budgets and the
+-- monthly income are in the owner's default currency.
+-- +goose StatementBegin
+DO $$
+DECLARE
+    bad TEXT;
+BEGIN
+    SELECT string_agg(DISTINCT source, ', ') INTO bad FROM (
+        SELECT 'expenses' AS source FROM expenses WHERE amount <> ROUND(amount, currency_exponent(currency))
+        UNION ALL
+        SELECT 'debts' FROM debts WHERE amount <> ROUND(amount, currency_exponent(currency))
+        UNION ALL
+        SELECT 'incomes' FROM incomes WHERE amount <> ROUND(amount, currency_exponent(currency))
+        UNION ALL
+        SELECT 'budgets' FROM budgets b JOIN users u ON u.user_id = b.user_id
+        WHERE b.amount <> ROUND(b.amount, currency_exponent(u.default_currency))
+        UNION ALL
+        SELECT 'users.monthly_income' FROM users
+        WHERE monthly_income <> ROUND(monthly_income, currency_exponent(default_currency))
+    ) AS rows;
+    IF bad IS NOT NULL THEN
+        RAISE EXCEPTION 'amounts with more decimal places than their currency allows in %', bad
+            USING HINT = 'Correct these amounts before running this migration; they are not rounded automatically.';
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- Three decimal places hold every supported minor unit, matching the Money type the API reads
+-- them into
+ALTER TABLE expenses ALTER COLUMN amount TYPE DECIMAL(14, 3);
+ALTER TABLE debts ALTER COLUMN amount TYPE DECIMAL(14, 3);
+ALTER TABLE incomes ALTER COLUMN amount TYPE DECIMAL(14, 3);
+ALTER TABLE budgets ALTER COLUMN amount TYPE DECIMAL(14, 3);
+ALTER TABLE users ALTER COLUMN monthly_income TYPE DECIMAL(14, 3);
+
+-- convert_currency rounds each converted amount to the target currency's minor unit, so
+-- category totals add up to the report total exactly
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION convert_currency(amount DECIMAL, from_currency TEXT, to_currency TEXT, on_date DATE)
+RETURNS DECIMAL AS $$
+    SELECT CASE WHEN from_currency = to_currency THEN amount
+        ELSE ROUND(amount * COALESCE(
+            exchange_rate(from_currency, to_currency, on_date),
+            exchange_rate(from_currency, 'USD', on_date) * exchange_rate('USD', to_currency, on_date)),
+            currency_exponent(to_currency))
+    END
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION convert_currency(amount DECIMAL, from_currency TEXT, to_currency TEXT, on_date DATE)
+RETURNS DECIMAL AS $$
+    SELECT CASE WHEN from_currency = to_currency THEN amount
+        ELSE amount * COALESCE(
+            exchange_rate(from_currency, to_currency, on_date),
+            exchange_rate(from_currency, 'USD', on_date) * exchange_rate('USD', to_currency, on_date))
+    END
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+ALTER TABLE users ALTER COLUMN monthly_income TYPE DECIMAL;
+ALTER TABLE budgets ALTER COLUMN amount TYPE DECIMAL;
+ALTER TABLE incomes ALTER COLUMN amount TYPE DECIMAL;
+ALTER TABLE debts ALTER COLUMN amount TYPE DECIMAL;
+ALTER TABLE expenses ALTER COLUMN amount TYPE DECIMAL;
+
+DROP FUNCTION IF EXISTS currency_exponent(TEXT);
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Repayments of a debt. The outstanding balance is the debt amount minus its payments.
+CREATE TABLE IF NOT EXISTS debt_payments (
+    id UUID PRIMARY KEY,
+    debt_id UUID NOT NULL REFERENCES debts(id) ON DELETE CASCADE,
+    amount DECIMAL(14, 3) NOT NULL CHECK (amount > 0),
+    paid_date DATE NOT NULL,
+    note TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_debt_payments_debt ON debt_payments(debt_id);
+CREATE INDEX IF NOT EXISTS idx_debt_payments_date ON debt_payments(paid_date);
+
+-- Debts marked paid before payments existed were repaid in full when they were marked
+INSERT INTO debt_payments (id, debt_id, amount, paid_date)
+SELECT gen_random_uuid(), id, amount, COALESCE(sent_at, updated_at, created_at)::date
+FROM debts
+WHERE status = 'paid' AND deleted_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS debt_payments;
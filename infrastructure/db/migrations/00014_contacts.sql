@@ -0,0 +1,42 @@
+-- +goose Up
+-- People the user lends to or borrows from; names are unique per user, ignoring case
+CREATE TABLE IF NOT EXISTS contacts (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    name TEXT NOT NULL,
+    note TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_contacts_user_name ON contacts(user_id, LOWER(name));
+
+ALTER TABLE debts ADD COLUMN IF NOT EXISTS contact_id UUID NULL REFERENCES contacts(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_debts_contact ON debts(contact_id);
+
+-- One contact per peer name, trimmed, with inner whitespace collapsed and case ignored.
+-- The earliest spelling becomes the contact's name.
+INSERT INTO contacts (id, user_id, name, created_at)
+SELECT gen_random_uuid(), user_id, name, first_used
+FROM (
+    SELECT DISTINCT ON (user_id, LOWER(name)) user_id, name, first_used
+    FROM (
+        SELECT user_id,
+            BTRIM(REGEXP_REPLACE(peer_name, '\s+', ' ', 'g')) AS name,
+            COALESCE(created_at, NOW()) AS first_used
+        FROM debts
+    ) peers
+    WHERE name <> ''
+    ORDER BY user_id, LOWER(name), first_used
+) names;
+
+UPDATE debts d
+SET contact_id = c.id, peer_name = c.name
+FROM contacts c
+WHERE c.user_id = d.user_id
+    AND LOWER(c.name) = LOWER(BTRIM(REGEXP_REPLACE(d.peer_name, '\s+', ' ', 'g')));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_debts_contact;
+ALTER TABLE debts DROP COLUMN IF EXISTS contact_id;
+DROP TABLE IF EXISTS contacts;
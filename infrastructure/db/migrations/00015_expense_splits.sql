@@ -0,0 +1,8 @@
+-- +goose Up
+-- Debts created for the other participants of a split expense point back at the expense
+ALTER TABLE debts ADD COLUMN IF NOT EXISTS expense_id UUID NULL REFERENCES expenses(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_debts_expense ON debts(expense_id) WHERE expense_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_debts_expense;
+ALTER TABLE debts DROP COLUMN IF EXISTS expense_id;
@@ -0,0 +1,58 @@
+-- +goose Up
+-- Shared ledgers: expenses, categories and budgets kept together by several users
+CREATE TABLE IF NOT EXISTS ledgers (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(user_id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS ledger_members (
+    ledger_id UUID NOT NULL REFERENCES ledgers(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (ledger_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_members_user ON ledger_members(user_id);
+
+-- Deleting a ledger hands its expenses and categories back to the users who recorded them;
+-- its budgets go with it
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS ledger_id UUID NULL REFERENCES ledgers(id) ON DELETE SET NULL;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS ledger_id UUID NULL REFERENCES ledgers(id) ON DELETE SET NULL;
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS ledger_id UUID NULL REFERENCES ledgers(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_expenses_ledger ON expenses(ledger_id, expense_date) WHERE ledger_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_categories_ledger ON categories(ledger_id) WHERE ledger_id IS NOT NULL;
+
+-- Budgets are unique per category within the user's own budgets or within one ledger
+DROP INDEX IF EXISTS idx_budgets_user_category;
+DROP INDEX IF EXISTS idx_budgets_user_overall;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_user_category
+    ON budgets(user_id, category_id) WHERE category_id IS NOT NULL AND ledger_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_user_overall
+    ON budgets(user_id) WHERE category_id IS NULL AND ledger_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_ledger_category
+    ON budgets(ledger_id, category_id) WHERE category_id IS NOT NULL AND ledger_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_ledger_overall
+    ON budgets(ledger_id) WHERE category_id IS NULL AND ledger_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_budgets_ledger_overall;
+DROP INDEX IF EXISTS idx_budgets_ledger_category;
+DROP INDEX IF EXISTS idx_budgets_user_overall;
+DROP INDEX IF EXISTS idx_budgets_user_category;
+DELETE FROM budgets WHERE ledger_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_user_category
+    ON budgets(user_id, category_id) WHERE category_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_user_overall
+    ON budgets(user_id) WHERE category_id IS NULL;
+DROP INDEX IF EXISTS idx_categories_ledger;
+DROP INDEX IF EXISTS idx_expenses_ledger;
+ALTER TABLE budgets DROP COLUMN IF EXISTS ledger_id;
+ALTER TABLE categories DROP COLUMN IF EXISTS ledger_id;
+ALTER TABLE expenses DROP COLUMN IF EXISTS ledger_id;
+DROP TABLE IF EXISTS ledger_members;
+DROP TABLE IF EXISTS ledgers;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Settle-up payments between the members of a shared ledger are recorded as paid debts tagged
+-- with the ledger: a borrowed debt for the member who paid and a lent debt for the one paid
+ALTER TABLE debts ADD COLUMN IF NOT EXISTS ledger_id UUID NULL REFERENCES ledgers(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_debts_ledger ON debts(ledger_id) WHERE ledger_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_debts_ledger;
+ALTER TABLE debts DROP COLUMN IF EXISTS ledger_id;
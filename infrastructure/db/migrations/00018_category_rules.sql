@@ -0,0 +1,24 @@
+-- +goose Up
+-- Rules filing uncategorized expenses under a category by note, amount and day of week.
+-- A rule with a ledger_id categorizes that shared ledger's expenses and goes with it.
+CREATE TABLE IF NOT EXISTS category_rules (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    ledger_id UUID NULL REFERENCES ledgers(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(id),
+    note_contains TEXT NULL,
+    note_pattern TEXT NULL,
+    min_amount DECIMAL NULL,
+    max_amount DECIMAL NULL,
+    weekdays SMALLINT[] NULL,
+    priority INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (min_amount IS NULL OR max_amount IS NULL OR min_amount <= max_amount)
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_rules_user ON category_rules(user_id) WHERE ledger_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_category_rules_ledger ON category_rules(ledger_id) WHERE ledger_id IS NOT NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS category_rules;
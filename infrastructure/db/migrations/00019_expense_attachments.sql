@@ -0,0 +1,18 @@
+-- +goose Up
+-- Files kept with expenses, such as receipts. The content is in the blob store under storage_key;
+-- deleting an expense removes its attachments.
+CREATE TABLE IF NOT EXISTS expense_attachments (
+    id UUID PRIMARY KEY,
+    expense_id UUID NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes >= 0),
+    storage_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_attachments_expense ON expense_attachments(expense_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS expense_attachments;
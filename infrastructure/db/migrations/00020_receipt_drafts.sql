@@ -0,0 +1,27 @@
+-- +goose Up
+-- Uploaded receipts waiting to be read and confirmed as expenses. The file is in the blob store
+-- under storage_key; confirming a draft deletes it and keeps the file as an expense attachment.
+CREATE TABLE IF NOT EXISTS receipt_drafts (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    ledger_id UUID NULL REFERENCES ledgers(id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes >= 0),
+    storage_key TEXT NOT NULL UNIQUE,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+    extractor TEXT NULL,
+    receipt JSONB NULL,
+    expense JSONB NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_receipt_drafts_user ON receipt_drafts(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_receipt_drafts_due ON receipt_drafts(next_attempt_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS receipt_drafts;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Keyset pagination reads expenses by (expense_date, id) and debts by (due_date, id) from a
+-- cursor; these indexes let a page start at the cursor instead of scanning the rows before it.
+CREATE INDEX IF NOT EXISTS idx_expenses_user_keyset ON expenses(user_id, expense_date, id)
+    WHERE ledger_id IS NULL AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_expenses_ledger_keyset ON expenses(ledger_id, expense_date, id)
+    WHERE ledger_id IS NOT NULL AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_debts_user_keyset ON debts(user_id, due_date, id)
+    WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_debts_user_keyset;
+DROP INDEX IF EXISTS idx_expenses_ledger_keyset;
+DROP INDEX IF EXISTS idx_expenses_user_keyset;
@@ -0,0 +1,66 @@
+// Package export writes exports as CSV, JSON or XLSX files for the export usecase, row by row
+// as they are read
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"expense_tracker/domain"
+	"expense_tracker/usecases"
+)
+
+// CSVEncoder writes comma separated files with a header row. Text starting with =, +, - or @ is
+// prefixed with ' so spreadsheets do not run it as a formula.
+type CSVEncoder struct{}
+
+func (CSVEncoder) NewWriter(w io.Writer, _ string) usecases.ExportWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) WriteHeader(columns []string) error {
+	return c.w.Write(columns)
+}
+
+func (c *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = cellText(v)
+		if _, ok := v.(string); ok && record[i] != "" {
+			switch record[i][0] {
+			case '=', '+', '-', '@':
+				record[i] = "'" + record[i]
+			}
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// cellText formats a row value as text; nil is empty
+func cellText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case domain.Money:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		return fmt.Sprint(v)
+	}
+}
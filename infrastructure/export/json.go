@@ -0,0 +1,68 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"expense_tracker/usecases"
+)
+
+// JSONEncoder writes a JSON array with one object per row, keyed by column in header order.
+// Amounts are numbers and missing values null.
+type JSONEncoder struct{}
+
+func (JSONEncoder) NewWriter(w io.Writer, _ string) usecases.ExportWriter {
+	return &jsonWriter{w: bufio.NewWriter(w)}
+}
+
+type jsonWriter struct {
+	w       *bufio.Writer
+	columns [][]byte // the columns as JSON strings
+	rows    int
+}
+
+func (j *jsonWriter) WriteHeader(columns []string) error {
+	j.columns = make([][]byte, len(columns))
+	for i, column := range columns {
+		key, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		j.columns[i] = key
+	}
+	_, err := j.w.WriteString("[")
+	return err
+}
+
+func (j *jsonWriter) WriteRow(values []interface{}) error {
+	if j.rows > 0 {
+		j.w.WriteString(",")
+	}
+	j.rows++
+	j.w.WriteString("\n{")
+	for i, v := range values {
+		if i > 0 {
+			j.w.WriteString(",")
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		j.w.Write(j.columns[i])
+		j.w.WriteString(":")
+		if _, err := j.w.Write(value); err != nil {
+			return err
+		}
+	}
+	_, err := j.w.WriteString("}")
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	if j.rows > 0 {
+		j.w.WriteString("\n")
+	}
+	j.w.WriteString("]\n")
+	return j.w.Flush()
+}
@@ -0,0 +1,160 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"expense_tracker/domain"
+	"expense_tracker/usecases"
+)
+
+// XLSXEncoder writes Office Open XML workbooks with one worksheet. Amounts are number cells and
+// text is stored inline, so rows go straight into the zip instead of a shared string table.
+type XLSXEncoder struct{}
+
+func (XLSXEncoder) NewWriter(w io.Writer, sheet string) usecases.ExportWriter {
+	if sheet == "" {
+		sheet = "Sheet1"
+	}
+	return &xlsxWriter{zip: zip.NewWriter(w), sheet: sheet}
+}
+
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet string
+	out   *bufio.Writer // the worksheet part, once started
+	rows  int
+}
+
+// xlsxParts are the parts of the workbook besides the worksheet; %s in xl/workbook.xml is the sheet name
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`<xf numFmtId="2" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+// Cell styles of xl/styles.xml
+const (
+	xlsxStyleHeader = "1" // bold
+	xlsxStyleAmount = "2" // 0.00
+)
+
+// WriteHeader writes the fixed parts, then starts the worksheet with columns as a bold first row
+func (x *xlsxWriter) WriteHeader(columns []string) error {
+	for _, part := range xlsxParts {
+		f, err := x.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		content := part.content
+		if part.name == "xl/workbook.xml" {
+			content = fmt.Sprintf(content, xmlText(x.sheet))
+		}
+		if _, err := io.WriteString(f, content); err != nil {
+			return err
+		}
+	}
+	f, err := x.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	x.out = bufio.NewWriter(f)
+	x.out.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+		`<sheetData>`)
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = column
+	}
+	return x.writeRow(values, xlsxStyleHeader)
+}
+
+func (x *xlsxWriter) WriteRow(values []interface{}) error {
+	return x.writeRow(values, "")
+}
+
+func (x *xlsxWriter) writeRow(values []interface{}, style string) error {
+	x.rows++
+	row := strconv.Itoa(x.rows)
+	x.out.WriteString(`<row r="` + row + `">`)
+	for i, v := range values {
+		ref := xlsxColumn(i) + row
+		switch v := v.(type) {
+		case nil:
+		case domain.Money:
+			x.out.WriteString(`<c r="` + ref + `" s="` + xlsxStyleAmount + `"><v>` + v.String() + `</v></c>`)
+		case bool:
+			value := "0"
+			if v {
+				value = "1"
+			}
+			x.out.WriteString(`<c r="` + ref + `" t="b"><v>` + value + `</v></c>`)
+		default:
+			x.out.WriteString(`<c r="` + ref + `" t="inlineStr"`)
+			if style != "" {
+				x.out.WriteString(` s="` + style + `"`)
+			}
+			x.out.WriteString(`><is><t xml:space="preserve">` + xmlText(cellText(v)) + `</t></is></c>`)
+		}
+	}
+	_, err := x.out.WriteString(`</row>`)
+	return err
+}
+
+// Close ends the worksheet and writes the zip's central directory
+func (x *xlsxWriter) Close() error {
+	if x.out == nil {
+		if err := x.WriteHeader(nil); err != nil {
+			return err
+		}
+	}
+	x.out.WriteString(`</sheetData></worksheet>`)
+	if err := x.out.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// xlsxColumn returns the letters of the 0-based column i: A, B, ... Z, AA, AB, ...
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xmlText escapes s for XML text, replacing characters XML cannot hold
+func xmlText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"context"
+	"log"
+
+	"expense_tracker/domain"
+)
+
+// LogNotifier writes notifications to the server log. It is the fallback channel when no
+// real delivery is configured and is handy in development.
+type LogNotifier struct{}
+
+// NewLogNotifier returns a notifier that only logs
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (LogNotifier) Channel() domain.NotificationChannel {
+	return domain.NotificationChannelLog
+}
+
+func (LogNotifier) Send(_ context.Context, n *domain.Notification) error {
+	log.Printf("notify: user %s: %s - %s", n.UserID, n.Title, n.Body)
+	return nil
+}
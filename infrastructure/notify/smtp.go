@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"expense_tracker/domain"
+)
+
+// SMTPNotifier sends notifications as plain-text email
+type SMTPNotifier struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPNotifier returns an email notifier for the given server
+func NewSMTPNotifier(host, port, username, password, from string) *SMTPNotifier {
+	return &SMTPNotifier{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// SMTPNotifierFromEnv builds an SMTP notifier from SMTP_HOST, SMTP_PORT, SMTP_USERNAME,
+// SMTP_PASSWORD and SMTP_FROM. It returns nil when SMTP_HOST is not set.
+func SMTPNotifierFromEnv() *SMTPNotifier {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USERNAME")
+	}
+	return NewSMTPNotifier(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+}
+
+func (s *SMTPNotifier) Channel() domain.NotificationChannel {
+	return domain.NotificationChannelEmail
+}
+
+func (s *SMTPNotifier) Send(ctx context.Context, n *domain.Notification) error {
+	if n.Target == "" {
+		return errors.New("email target is empty")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+	return smtp.SendMail(s.addr, auth, s.from, []string{n.Target}, s.message(n))
+}
+
+func (s *SMTPNotifier) message(n *domain.Notification) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", n.Target)
+	fmt.Fprintf(&b, "Subject: %s\r\n", n.Title)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(n.Body)
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
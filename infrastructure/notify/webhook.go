@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"expense_tracker/domain"
+)
+
+// Headers sent with every webhook delivery. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with WEBHOOK_SIGNING_SECRET.
+const (
+	WebhookSignatureHeader = "X-Expense-Tracker-Signature"
+	WebhookTimestampHeader = "X-Expense-Tracker-Timestamp"
+)
+
+// ErrWebhookAddressBlocked is returned when a webhook URL leads to a loopback, private,
+// link-local or otherwise non-public address
+var ErrWebhookAddressBlocked = errors.New("webhook address is not public")
+
+// WebhookNotifier POSTs notifications as signed JSON to the user's webhook URL
+type WebhookNotifier struct {
+	secret       []byte
+	client       *http.Client
+	allowPrivate bool
+}
+
+// NewWebhookNotifier returns a webhook notifier that signs payloads with secret. Webhooks are
+// only delivered to public addresses, and redirects are not followed.
+func NewWebhookNotifier(secret string) *WebhookNotifier {
+	w := &WebhookNotifier{secret: []byte(secret)}
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: w.checkAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // connect to the target itself, so its address is the one checked
+	transport.DialContext = dialer.DialContext
+	w.client = &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		// A redirect could point anywhere; the response to the registered URL is the answer
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return w
+}
+
+// AllowPrivateNetworks lets the notifier deliver to loopback and private addresses, for
+// receivers on the local machine during development and tests
+func (w *WebhookNotifier) AllowPrivateNetworks() {
+	w.allowPrivate = true
+}
+
+// checkAddress refuses connections to non-public addresses. It runs on the resolved address of
+// every connection, so a name cannot be checked once and then resolve somewhere internal.
+func (w *WebhookNotifier) checkAddress(_, address string, _ syscall.RawConn) error {
+	if w.allowPrivate {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil || !domain.WebhookAddressAllowed(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressBlocked, address)
+	}
+	return nil
+}
+
+// WebhookNotifierFromEnv builds a webhook notifier from WEBHOOK_SIGNING_SECRET.
+// It returns nil when the secret is not set.
+func WebhookNotifierFromEnv() *WebhookNotifier {
+	secret := os.Getenv("WEBHOOK_SIGNING_SECRET")
+	if secret == "" {
+		return nil
+	}
+	return NewWebhookNotifier(secret)
+}
+
+// webhookPayload is the JSON body of a webhook delivery
+type webhookPayload struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	SubjectType string    `json:"subject_type"`
+	SubjectID   string    `json:"subject_id"`
+	Title       string    `json:"title"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (w *WebhookNotifier) Channel() domain.NotificationChannel {
+	return domain.NotificationChannelWebhook
+}
+
+func (w *WebhookNotifier) Send(ctx context.Context, n *domain.Notification) error {
+	if n.Target == "" {
+		return errors.New("webhook target is empty")
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		ID:          n.ID,
+		UserID:      n.UserID,
+		SubjectType: n.SubjectType,
+		SubjectID:   n.SubjectID,
+		Title:       n.Title,
+		Body:        n.Body,
+		CreatedAt:   n.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.Target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(w.secret, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 signature receivers should compare against
+func SignWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
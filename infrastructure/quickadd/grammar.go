@@ -0,0 +1,620 @@
+package quickadd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"expense_tracker/domain"
+)
+
+// Grammar reads quick-add text such as "coffee 85 birr yesterday" or "lent Sara 500 due Friday"
+// with fixed rules: an amount with an optional currency, a relative or calendar date, a category
+// named in the text and the keywords of debts. Whatever is left over becomes the note.
+type Grammar struct{}
+
+func (Grammar) Name() string { return "grammar" }
+
+var (
+	// numberPattern matches amounts such as 85, 12.50, 12,50, 1,200.75 or 1.5k
+	numberPattern = regexp.MustCompile(`^(\d{1,3}(?:,\d{3})+|\d+)(?:[.,](\d{1,2}))?(k)?$`)
+	dayPattern    = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?$`)
+	yearPattern   = regexp.MustCompile(`^\d{4}$`)
+	isoDate       = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// currencySymbols map to their ISO code; "$" is used by too many currencies to tell, so it
+// leaves the currency to the user's default
+var currencySymbols = map[string]string{"$": "", "€": "EUR", "£": "GBP", "¥": "JPY", "₹": "INR", "₦": "NGN"}
+
+// currencyWords are the everyday names of currencies
+var currencyWords = map[string]string{
+	"birr": "ETB", "dollar": "USD", "dollars": "USD", "buck": "USD", "bucks": "USD", "euro": "EUR", "euros": "EUR",
+	"pound": "GBP", "pounds": "GBP", "quid": "GBP", "yen": "JPY", "yuan": "CNY", "rmb": "CNY", "rupee": "INR",
+	"rupees": "INR", "naira": "NGN", "rand": "ZAR", "franc": "CHF", "francs": "CHF", "shilling": "KES",
+	"shillings": "KES", "ksh": "KES", "zloty": "PLN",
+}
+
+// currencyCodes are the ISO 4217 codes recognised in the text; other three-letter words are too
+// likely to be notes ("tea", "gym")
+var currencyCodes = map[string]bool{
+	"usd": true, "eur": true, "gbp": true, "jpy": true, "chf": true, "cad": true, "aud": true, "nzd": true,
+	"sek": true, "nok": true, "dkk": true, "pln": true, "czk": true, "huf": true, "inr": true, "cny": true,
+	"hkd": true, "sgd": true, "mxn": true, "brl": true, "zar": true, "etb": true, "kes": true, "ngn": true,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+var months = []string{"january", "february", "march", "april", "may", "june", "july", "august", "september",
+	"october", "november", "december"}
+
+// counts are the numbers spelled out in phrases such as "in two weeks" or "a month ago"
+var counts = map[string]int{"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10}
+
+// debtKeywords give the debt type of the verbs that make a text a debt
+var debtKeywords = map[string]string{
+	"lent": "lent", "lend": "lent", "loaned": "lent", "owes": "lent",
+	"borrowed": "borrowed", "borrow": "borrowed", "owe": "borrowed",
+}
+
+// fillers are dropped from the ends of notes, and never start a person's name
+var fillers = map[string]bool{
+	"spent": true, "paid": true, "pay": true, "bought": true, "for": true, "on": true, "at": true, "in": true,
+	"to": true, "from": true, "of": true, "the": true, "a": true, "an": true, "and": true, "with": true, "i": true,
+	"me": true, "due": true, "by": true,
+}
+
+// possessives start names such as "my brother"
+var possessives = map[string]bool{"my": true, "our": true}
+
+type token struct {
+	raw  string // as typed, without surrounding punctuation
+	word string // raw, lower-cased
+	used bool
+}
+
+// Parse reads an expense, or a debt when the text has one of the debt keywords. Dates in an
+// expense are the day it was spent, so weekdays and dates without a year are the latest one up
+// to today; dates in a debt are its due date, the next one from today.
+func (Grammar) Parse(_ context.Context, text string, hints domain.QuickAddHints) (*domain.QuickAddResult, error) {
+	tokens := tokenize(text)
+	debtType, keyword := findDebtKeyword(tokens)
+	today := time.Date(hints.Today.Year(), hints.Today.Month(), hints.Today.Day(), 0, 0, 0, 0, time.UTC)
+
+	date := findDate(tokens, today, debtType != "")
+	amount, currency, ok := findAmount(tokens)
+	if !ok {
+		return nil, fmt.Errorf("%w: no amount found", domain.ErrQuickAddNotUnderstood)
+	}
+
+	if debtType != "" {
+		peer := findPeer(tokens, keyword)
+		if peer == "" {
+			return nil, fmt.Errorf("%w: no person found for the debt", domain.ErrQuickAddNotUnderstood)
+		}
+		debt := &domain.Debt{Type: debtType, PeerName: peer, Amount: amount, Currency: currency}
+		if date != nil {
+			debt.DueDate = *date
+		}
+		if note := leftover(tokens); note != "" {
+			debt.Note = &note
+		}
+		return &domain.QuickAddResult{Kind: domain.QuickAddDebt, Debt: debt}, nil
+	}
+
+	expense := &domain.CreateExpenseInput{Amount: amount, Currency: currency, ExpenseDate: today}
+	if date != nil {
+		expense.ExpenseDate = *date
+	}
+	expense.CategoryID = findCategory(tokens, hints.Categories)
+	expense.Note = leftover(tokens)
+	return &domain.QuickAddResult{Kind: domain.QuickAddExpense, Expense: expense}, nil
+}
+
+func tokenize(text string) []*token {
+	var tokens []*token
+	for _, field := range strings.Fields(text) {
+		raw := strings.Trim(field, ",;:!?\"()[]")
+		raw = strings.TrimRight(raw, ".")
+		if raw == "" {
+			continue
+		}
+		tokens = append(tokens, &token{raw: raw, word: strings.ToLower(raw)})
+	}
+	return tokens
+}
+
+// findDebtKeyword returns the debt type and position of the first debt keyword; "owe" only
+// counts first or after "i" ("I owe Sara 200"), so that "owe" in a note does not make a debt
+func findDebtKeyword(tokens []*token) (string, int) {
+	for i, t := range tokens {
+		debtType, ok := debtKeywords[t.word]
+		if !ok {
+			continue
+		}
+		if t.word == "owe" && i > 0 && tokens[i-1].word != "i" {
+			continue
+		}
+		t.used = true
+		if t.word == "owe" && i > 0 {
+			tokens[i-1].used = true
+		}
+		return debtType, i
+	}
+	return "", -1
+}
+
+// findDate finds the first date phrase and marks it used, with a "due", "by", "on" or "until"
+// before it
+func findDate(tokens []*token, today time.Time, future bool) *time.Time {
+	for i := range tokens {
+		if tokens[i].used {
+			continue
+		}
+		date, n := dateAt(tokens[i:], today, future)
+		if n == 0 {
+			continue
+		}
+		for _, t := range tokens[i : i+n] {
+			t.used = true
+		}
+		if i > 0 && !tokens[i-1].used {
+			switch tokens[i-1].word {
+			case "due", "by", "on", "until":
+				tokens[i-1].used = true
+			}
+		}
+		return &date
+	}
+	return nil
+}
+
+// dateAt reads a date phrase at the start of tokens and returns it with the number of tokens it took
+func dateAt(tokens []*token, today time.Time, future bool) (time.Time, int) {
+	word := func(i int) string {
+		if i < len(tokens) && !tokens[i].used {
+			return tokens[i].word
+		}
+		return ""
+	}
+
+	switch word(0) {
+	case "today", "tonight":
+		return today, 1
+	case "yesterday":
+		return today.AddDate(0, 0, -1), 1
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), 1
+	case "last", "past", "next", "this":
+		if day, ok := weekdays[word(1)]; ok {
+			switch word(0) {
+			case "next":
+				return nextWeekday(today.AddDate(0, 0, 1), day), 2
+			case "this":
+				if future {
+					return nextWeekday(today, day), 2
+				}
+				return lastWeekday(today, day), 2
+			default:
+				return lastWeekday(today.AddDate(0, 0, -1), day), 2
+			}
+		}
+		sign := 1
+		if word(0) == "last" || word(0) == "past" {
+			sign = -1
+		}
+		switch word(1) {
+		case "week":
+			return today.AddDate(0, 0, 7*sign), 2
+		case "month":
+			return addMonths(today, sign), 2
+		}
+	case "in":
+		if date, ok := shift(today, word(1), word(2), 1); ok {
+			return date, 3
+		}
+	}
+	if day, ok := weekdays[word(0)]; ok {
+		if future {
+			return nextWeekday(today, day), 1
+		}
+		return lastWeekday(today, day), 1
+	}
+	if word(2) == "ago" {
+		if date, ok := shift(today, word(0), word(1), -1); ok {
+			return date, 3
+		}
+	}
+	if isoDate.MatchString(word(0)) {
+		if date, err := time.Parse("2006-01-02", word(0)); err == nil {
+			return date, 1
+		}
+	}
+
+	// "March 3", "mar 3rd 2026", "3 March"
+	month, day := monthOf(word(0)), dayPattern.FindStringSubmatch(word(1))
+	if month == 0 || day == nil {
+		month, day = monthOf(word(1)), dayPattern.FindStringSubmatch(word(0))
+	}
+	if month != 0 && day != nil {
+		d, _ := strconv.Atoi(day[1])
+		if yearPattern.MatchString(word(2)) {
+			year, _ := strconv.Atoi(word(2))
+			if date, ok := calendarDate(year, month, d); ok {
+				return date, 3
+			}
+			return time.Time{}, 0
+		}
+		date, ok := calendarDate(today.Year(), month, d)
+		if !ok {
+			return time.Time{}, 0
+		}
+		if future && date.Before(today) {
+			date, ok = calendarDate(today.Year()+1, month, d)
+		} else if !future && date.After(today) {
+			date, ok = calendarDate(today.Year()-1, month, d)
+		}
+		if ok {
+			return date, 2
+		}
+	}
+	return time.Time{}, 0
+}
+
+// shift moves today by count ("3", "two", "a") units of unit ("days", "week", ...) in direction sign
+func shift(today time.Time, count, unit string, sign int) (time.Time, bool) {
+	n, ok := counts[count]
+	if !ok {
+		var err error
+		if n, err = strconv.Atoi(count); err != nil || n <= 0 || n > 366 {
+			return time.Time{}, false
+		}
+	}
+	switch strings.TrimSuffix(unit, "s") {
+	case "day":
+		return today.AddDate(0, 0, n*sign), true
+	case "week":
+		return today.AddDate(0, 0, 7*n*sign), true
+	case "month":
+		return addMonths(today, n*sign), true
+	}
+	return time.Time{}, false
+}
+
+// nextWeekday returns the first day on or after from that falls on day
+func nextWeekday(from time.Time, day time.Weekday) time.Time {
+	return from.AddDate(0, 0, (int(day)-int(from.Weekday())+7)%7)
+}
+
+// lastWeekday returns the last day on or before from that falls on day
+func lastWeekday(from time.Time, day time.Weekday) time.Time {
+	return from.AddDate(0, 0, -((int(from.Weekday()) - int(day) + 7) % 7))
+}
+
+// addMonths moves t by n months, keeping the day of month but clamping it to shorter months
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
+
+// monthOf returns the month a word names in full or by its first three letters or more, or 0
+func monthOf(word string) time.Month {
+	if len(word) < 3 {
+		return 0
+	}
+	for i, name := range months {
+		if strings.HasPrefix(name, word) {
+			return time.Month(i + 1)
+		}
+	}
+	return 0
+}
+
+func calendarDate(year int, month time.Month, day int) (time.Time, bool) {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return date, date.Month() == month && day > 0
+}
+
+// findAmount picks the amount: the first one with a currency next to it, else the first number
+// not followed by a word, so that "2 coffees 170" is 170 and "300 for 2 tickets" is 300, else
+// the first number
+func findAmount(tokens []*token) (domain.Money, string, bool) {
+	type candidate struct {
+		index    int
+		amount   domain.Money
+		currency string
+		marked   bool // a currency was found next to it
+	}
+	var candidates []candidate
+	for i, t := range tokens {
+		if t.used {
+			continue
+		}
+		amount, currency, marked, ok := parseAmount(t.word)
+		if !ok {
+			continue
+		}
+		c := candidate{index: i, amount: amount, currency: currency, marked: marked}
+		if !marked {
+			if i+1 < len(tokens) && !tokens[i+1].used {
+				c.currency, c.marked = currencyOf(tokens[i+1].word)
+			}
+			if !c.marked && i > 0 && !tokens[i-1].used {
+				c.currency, c.marked = currencyOf(tokens[i-1].word)
+			}
+		}
+		candidates = append(candidates, c)
+	}
+	if len(candidates) == 0 {
+		return domain.Money{}, "", false
+	}
+
+	chosen := candidates[0]
+	found := false
+	for _, c := range candidates {
+		if c.marked {
+			chosen, found = c, true
+			break
+		}
+	}
+	if !found {
+		for _, c := range candidates {
+			i := c.index + 1
+			if i >= len(tokens) || tokens[i].used || fillers[tokens[i].word] || !startsWithLetter(tokens[i].word) {
+				chosen = c
+				break
+			}
+		}
+	}
+
+	tokens[chosen.index].used = true
+	if chosen.marked {
+		for _, i := range []int{chosen.index + 1, chosen.index - 1} {
+			if i >= 0 && i < len(tokens) && !tokens[i].used {
+				if _, ok := currencyOf(tokens[i].word); ok {
+					tokens[i].used = true
+					break
+				}
+			}
+		}
+	}
+	return chosen.amount, chosen.currency, true
+}
+
+// parseAmount reads a positive amount with an optional currency symbol, code or name stuck to it
+// ("$12", "85birr", "usd20")
+func parseAmount(word string) (domain.Money, string, bool, bool) {
+	number, currency, marked := word, "", false
+	for symbol, code := range currencySymbols {
+		if rest, ok := strings.CutPrefix(word, symbol); ok {
+			number, currency, marked = rest, code, true
+			break
+		}
+		if rest, ok := strings.CutSuffix(word, symbol); ok {
+			number, currency, marked = rest, code, true
+			break
+		}
+	}
+	if !marked && !numberPattern.MatchString(number) {
+		start := strings.IndexFunc(word, unicode.IsDigit)
+		end := strings.LastIndexFunc(word, unicode.IsDigit) + 1
+		if start < 0 {
+			return domain.Money{}, "", false, false
+		}
+		affix := word[:start] + word[end:]
+		if word[:start] != "" && word[end:] != "" {
+			return domain.Money{}, "", false, false
+		}
+		if currency, marked = currencyOf(affix); !marked {
+			return domain.Money{}, "", false, false
+		}
+		number = word[start:end]
+	}
+
+	m := numberPattern.FindStringSubmatch(number)
+	if m == nil {
+		return domain.Money{}, "", false, false
+	}
+	text := strings.ReplaceAll(m[1], ",", "")
+	if m[2] != "" {
+		text += "." + m[2]
+	}
+	amount, err := domain.ParseMoney(text)
+	if err != nil {
+		return domain.Money{}, "", false, false
+	}
+	if m[3] != "" {
+		amount = amount.Mul(1000)
+	}
+	if !amount.IsPositive() {
+		return domain.Money{}, "", false, false
+	}
+	return amount, currency, marked, true
+}
+
+// currencyOf returns the ISO code a currency symbol, code or name stands for ("" for "$")
+func currencyOf(word string) (string, bool) {
+	if code, ok := currencySymbols[word]; ok {
+		return code, true
+	}
+	if code, ok := currencyWords[word]; ok {
+		return code, true
+	}
+	if currencyCodes[word] {
+		return strings.ToUpper(word), true
+	}
+	return "", false
+}
+
+// findPeer reads the person of a debt: the name right after the keyword or its "to" or "from"
+// ("lent Sara", "borrowed from Abebe"), after a later "to" or "from" ("borrowed 200 from Abebe"),
+// or before "owes" ("Sara owes me 300"). Capitalized names are preferred over other words.
+func findPeer(tokens []*token, keyword int) string {
+	if tokens[keyword].word == "owes" {
+		start := keyword
+		for start > 0 && start > keyword-3 && isName(tokens[start-1]) {
+			start--
+		}
+		if !capitalized(tokens[start].raw) {
+			start = keyword - 1
+		}
+		if keyword+1 < len(tokens) && tokens[keyword+1].word == "me" {
+			tokens[keyword+1].used = true
+		}
+		if start < 0 {
+			return ""
+		}
+		return takeName(tokens[start:keyword])
+	}
+
+	after := tokens[keyword+1:]
+	if len(after) > 1 && (after[0].word == "to" || after[0].word == "from") && isName(after[1]) {
+		after[0].used = true
+		return takeName(after[1:])
+	}
+	if len(after) > 0 && isName(after[0]) && capitalized(after[0].raw) {
+		return takeName(after)
+	}
+	for i, t := range after {
+		if !t.used && (t.word == "to" || t.word == "from") && i+1 < len(after) && isName(after[i+1]) {
+			t.used = true
+			return takeName(after[i+1:])
+		}
+	}
+	return takeName(after)
+}
+
+// takeName marks and returns the name at the start of tokens: one word, up to three capitalized
+// words when the first one is ("Sara Bekele"), or two after "my" ("my brother")
+func takeName(tokens []*token) string {
+	var parts []string
+	for _, t := range tokens {
+		if !isName(t) || len(parts) == 3 {
+			break
+		}
+		if len(parts) > 0 && !(capitalized(parts[0]) && capitalized(t.raw)) && (len(parts) > 1 || !possessives[strings.ToLower(parts[0])]) {
+			break
+		}
+		parts = append(parts, t.raw)
+		t.used = true
+	}
+	return strings.Join(parts, " ")
+}
+
+func capitalized(word string) bool {
+	r, _ := utf8.DecodeRuneInString(word)
+	return unicode.IsUpper(r)
+}
+
+func isName(t *token) bool {
+	if t.used || fillers[t.word] || !startsWithLetter(t.word) {
+		return false
+	}
+	_, isCurrency := currencyOf(t.word)
+	_, isKeyword := debtKeywords[t.word]
+	return !isCurrency && !isKeyword
+}
+
+// findCategory returns the category named by a hashtag ("#eating-out"), which is then left out
+// of the note, or else by the longest run of words of the text; plurals match singulars
+func findCategory(tokens []*token, categories []*domain.Category) *string {
+	for _, t := range tokens {
+		name, ok := strings.CutPrefix(t.word, "#")
+		if t.used || !ok {
+			continue
+		}
+		name = strings.NewReplacer("-", " ", "_", " ").Replace(name)
+		for _, c := range categories {
+			if sameWords(strings.Fields(strings.ToLower(c.Name)), strings.Fields(name)) {
+				t.used = true
+				return &c.ID
+			}
+		}
+	}
+
+	var words []string
+	for _, t := range tokens {
+		if !t.used {
+			words = append(words, t.word)
+		}
+	}
+	var best *domain.Category
+	bestLen := 0
+	for _, c := range categories {
+		name := strings.Fields(strings.ToLower(c.Name))
+		if len(name) <= bestLen {
+			continue
+		}
+		for i := 0; i+len(name) <= len(words); i++ {
+			if sameWords(name, words[i:i+len(name)]) {
+				best, bestLen = c, len(name)
+				break
+			}
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return &best.ID
+}
+
+func sameWords(a, b []string) bool {
+	if len(a) != len(b) || len(a) == 0 {
+		return false
+	}
+	for i := range a {
+		if singular(a[i]) != singular(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// singular strips the plural ending of a word: groceries -> grocery, drinks -> drink
+func singular(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// leftover joins the unused tokens into a note, without fillers at either end
+func leftover(tokens []*token) string {
+	var parts []*token
+	for _, t := range tokens {
+		if !t.used {
+			parts = append(parts, t)
+		}
+	}
+	for len(parts) > 0 && fillers[parts[0].word] {
+		parts = parts[1:]
+	}
+	for len(parts) > 0 && fillers[parts[len(parts)-1].word] {
+		parts = parts[:len(parts)-1]
+	}
+	words := make([]string, len(parts))
+	for i, t := range parts {
+		words[i] = t.raw
+	}
+	return strings.Join(words, " ")
+}
+
+func startsWithLetter(word string) bool {
+	for _, r := range word {
+		return unicode.IsLetter(r)
+	}
+	return false
+}
@@ -0,0 +1,924 @@
+// Package receipt reads receipts for the receipt usecase: PDFExtractor finds the merchant, date,
+// total and line items in the text layer of PDF receipts, such as those sent by online shops.
+package receipt
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"expense_tracker/domain"
+)
+
+// maxDecodedSize caps how many bytes reading one PDF may decompress, across all of its streams
+// and the page contents put together from them
+const maxDecodedSize = 16 << 20
+
+// errTooLarge is returned for PDFs that decompress to more than maxDecodedSize
+var errTooLarge = fmt.Errorf("the PDF decompresses to more than %d MB", maxDecodedSize>>20)
+
+// maxPages caps how many pages are read; receipts are short
+const maxPages = 20
+
+// PDFExtractor reads receipts from the text layer of PDFs. Scanned PDFs without one, and images,
+// are unreadable to it.
+type PDFExtractor struct{}
+
+func (PDFExtractor) Name() string { return "pdf-text" }
+
+func (PDFExtractor) Extract(_ context.Context, contentType string, content []byte) (*domain.ReceiptData, error) {
+	if contentType != "application/pdf" {
+		return nil, fmt.Errorf("%w: not a PDF", domain.ErrUnreadableReceipt)
+	}
+	text, err := PDFText(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUnreadableReceipt, err)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("%w: the PDF has no text layer", domain.ErrUnreadableReceipt)
+	}
+	data := ParseText(text)
+	return &data, nil
+}
+
+var (
+	objectHeader = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+	rootRef      = regexp.MustCompile(`/Root\s+(\d+)\s+\d+\s+R`)
+	refPattern   = regexp.MustCompile(`^(\d+)\s+\d+\s+R`)
+	refsPattern  = regexp.MustCompile(`(\d+)\s+\d+\s+R`)
+	refTail      = regexp.MustCompile(`^(\d+)\s+R\b`)
+)
+
+// pdfObject is an indirect object: its dictionary (or other value) and its stream, if any. The
+// stream is decoded the first time it is needed.
+type pdfObject struct {
+	dict    string
+	raw     []byte // the stream's data as stored in the file; nil without a stream
+	stream  []byte // the decoded stream, once decoded
+	decoded bool
+}
+
+// pdfFile holds the objects of a PDF by number. Streams are only decoded when a page uses them,
+// and all decoding shares one budget of maxDecodedSize bytes; err is set once it runs out.
+type pdfFile struct {
+	objects    map[int]*pdfObject
+	objStreams []*pdfObject // object streams not unpacked yet
+	budget     int
+	err        error
+}
+
+// PDFText returns the text of a PDF's text layer, pages in order and one line per line of text.
+// It reads uncompressed and Flate-compressed streams, object streams, and fonts with a ToUnicode
+// map or a single-byte encoding; text drawn inside form XObjects is not read.
+func PDFText(content []byte) (string, error) {
+	if !bytes.HasPrefix(content, []byte("%PDF-")) {
+		return "", errors.New("not a PDF")
+	}
+	file := parsePDF(content)
+	if len(file.objects) == 0 {
+		return "", errors.New("no objects found")
+	}
+
+	var out strings.Builder
+	for _, page := range file.pages(content) {
+		fonts := file.fonts(page.resources)
+		var contents []byte
+		for _, n := range file.refs(dictValue(page.dict, "Contents")) {
+			// the same stream may be listed many times, so the copies count against the budget too
+			if stream := file.stream(file.object(n)); stream != nil && file.spend(len(stream)+1) {
+				contents = append(contents, stream...)
+				contents = append(contents, '\n')
+			}
+		}
+		if file.err != nil {
+			return "", file.err
+		}
+		out.WriteString(contentText(contents, fonts))
+		out.WriteString("\n")
+	}
+	if file.err != nil {
+		return "", file.err
+	}
+	return out.String(), nil
+}
+
+// parsePDF reads the indirect objects of the file, later definitions replacing earlier ones,
+// without decoding their streams
+func parsePDF(content []byte) *pdfFile {
+	file := &pdfFile{objects: make(map[int]*pdfObject), budget: maxDecodedSize}
+	pos := 0
+	for pos < len(content) {
+		loc := objectHeader.FindSubmatchIndex(content[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(content[pos+loc[2] : pos+loc[3]]))
+		start := pos + loc[1]
+		obj, end := readObject(content, start)
+		file.objects[num] = obj
+		if dictValue(obj.dict, "Type") == "/ObjStm" {
+			file.objStreams = append(file.objStreams, obj)
+		}
+		pos = end
+	}
+	return file
+}
+
+// object returns object n. Objects not defined directly in the file are looked for in the object
+// streams, which are unpacked one at a time until it is found.
+func (f *pdfFile) object(n int) *pdfObject {
+	for {
+		if obj := f.objects[n]; obj != nil {
+			return obj
+		}
+		if len(f.objStreams) == 0 || f.err != nil {
+			return nil
+		}
+		s := f.objStreams[0]
+		f.objStreams = f.objStreams[1:]
+		for num, obj := range objectStreamObjects(s.dict, f.stream(s)) {
+			if _, ok := f.objects[num]; !ok {
+				f.objects[num] = obj
+			}
+		}
+	}
+}
+
+// stream returns the decoded stream of obj, decoding it on first use, or nil when obj has no
+// stream, uses a filter other than FlateDecode or the budget has run out
+func (f *pdfFile) stream(obj *pdfObject) []byte {
+	if obj == nil || obj.raw == nil {
+		return nil
+	}
+	if !obj.decoded && f.err == nil {
+		obj.stream, f.err = decodeStream(obj.dict, obj.raw, f.budget)
+		obj.decoded = true
+		f.spend(len(obj.stream))
+	}
+	return obj.stream
+}
+
+// spend takes n bytes from the budget, reporting whether there were enough
+func (f *pdfFile) spend(n int) bool {
+	if f.err != nil {
+		return false
+	}
+	if n > f.budget {
+		f.budget, f.err = 0, errTooLarge
+		return false
+	}
+	f.budget -= n
+	return true
+}
+
+// readObject reads the object starting at start and returns it with the position after it
+func readObject(content []byte, start int) (*pdfObject, int) {
+	end := bytes.Index(content[start:], []byte("endobj"))
+	if end < 0 {
+		end = len(content) - start
+	}
+	body := content[start : start+end]
+	streamAt := bytes.Index(body, []byte("stream"))
+	if streamAt < 0 {
+		return &pdfObject{dict: string(bytes.TrimSpace(body))}, start + end + len("endobj")
+	}
+
+	obj := &pdfObject{dict: string(bytes.TrimSpace(body[:streamAt]))}
+	dataStart := start + streamAt + len("stream")
+	if dataStart < len(content) && content[dataStart] == '\r' {
+		dataStart++
+	}
+	if dataStart < len(content) && content[dataStart] == '\n' {
+		dataStart++
+	}
+	// the length is used when given directly, so "endobj" inside binary data cannot end the object early
+	dataEnd := -1
+	if length, err := strconv.Atoi(dictValue(obj.dict, "Length")); err == nil && length >= 0 && dataStart+length <= len(content) {
+		if rest := bytes.TrimLeft(content[dataStart+length:], "\r\n \t"); bytes.HasPrefix(rest, []byte("endstream")) {
+			dataEnd = dataStart + length
+		}
+	}
+	if dataEnd < 0 {
+		i := bytes.Index(content[dataStart:], []byte("endstream"))
+		if i < 0 {
+			return obj, len(content)
+		}
+		dataEnd = dataStart + i
+	}
+	obj.raw = content[dataStart:dataEnd:dataEnd]
+
+	next := bytes.Index(content[dataEnd:], []byte("endobj"))
+	if next < 0 {
+		return obj, len(content)
+	}
+	return obj, dataEnd + next + len("endobj")
+}
+
+// decodeStream returns the decoded data of a stream, or nil for filters other than FlateDecode.
+// Decompressing more than budget bytes fails with errTooLarge. Uncompressed data is returned as it
+// is, without a copy.
+func decodeStream(dict string, data []byte, budget int) ([]byte, error) {
+	filter := dictValue(dict, "Filter")
+	switch strings.Trim(strings.TrimSpace(filter), "[] ") {
+	case "":
+		return data, nil
+	case "/FlateDecode", "/Fl":
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil
+		}
+		defer r.Close()
+		// streams cut short still give the text before the damage
+		decoded, _ := io.ReadAll(io.LimitReader(r, int64(budget)+1))
+		if len(decoded) > budget {
+			return nil, errTooLarge
+		}
+		return decoded, nil
+	}
+	return nil, nil
+}
+
+// objectStreamObjects returns the objects packed in an object stream with dictionary dict and
+// decoded data stream
+func objectStreamObjects(dict string, stream []byte) map[int]*pdfObject {
+	first, err := strconv.Atoi(dictValue(dict, "First"))
+	if err != nil || first < 0 || first > len(stream) {
+		return nil
+	}
+	fields := strings.Fields(string(stream[:first]))
+	type entry struct{ num, offset int }
+	entries := make([]entry, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		num, err1 := strconv.Atoi(fields[i])
+		offset, err2 := strconv.Atoi(fields[i+1])
+		if err1 != nil || err2 != nil || first+offset > len(stream) {
+			return nil
+		}
+		entries = append(entries, entry{num, first + offset})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].offset < entries[j].offset })
+
+	objects := make(map[int]*pdfObject, len(entries))
+	for i, e := range entries {
+		end := len(stream)
+		if i+1 < len(entries) {
+			end = entries[i+1].offset
+		}
+		objects[e.num] = &pdfObject{dict: strings.TrimSpace(string(stream[e.offset:end]))}
+	}
+	return objects
+}
+
+// pdfPage is a page's dictionary with the resources it uses, which may be inherited
+type pdfPage struct {
+	dict      string
+	resources string
+}
+
+// pages returns the pages in order, following the page tree from the catalog; without one the
+// page objects are taken in object number order
+func (f *pdfFile) pages(content []byte) []pdfPage {
+	var pages []pdfPage
+	if m := rootRef.FindAllSubmatch(content, -1); len(m) > 0 {
+		root, _ := strconv.Atoi(string(m[len(m)-1][1]))
+		if catalog := f.object(root); catalog != nil {
+			if n, ok := refNumber(dictValue(catalog.dict, "Pages")); ok {
+				f.walkPages(n, "", map[int]bool{}, &pages)
+			}
+		}
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	f.object(-1) // there is no object -1, so every object stream is unpacked
+	nums := make([]int, 0, len(f.objects))
+	for n, obj := range f.objects {
+		if isPage(obj.dict) {
+			nums = append(nums, n)
+		}
+	}
+	sort.Ints(nums)
+	for _, n := range nums {
+		if len(pages) == maxPages {
+			break
+		}
+		dict := f.objects[n].dict
+		pages = append(pages, pdfPage{dict: dict, resources: f.resolve(dictValue(dict, "Resources"))})
+	}
+	return pages
+}
+
+func (f *pdfFile) walkPages(n int, inherited string, seen map[int]bool, pages *[]pdfPage) {
+	obj := f.object(n)
+	if obj == nil || seen[n] || len(*pages) >= maxPages {
+		return
+	}
+	seen[n] = true
+	resources := inherited
+	if r := dictValue(obj.dict, "Resources"); r != "" {
+		resources = f.resolve(r)
+	}
+	if isPage(obj.dict) {
+		*pages = append(*pages, pdfPage{dict: obj.dict, resources: resources})
+		return
+	}
+	for _, kid := range f.refs(dictValue(obj.dict, "Kids")) {
+		f.walkPages(kid, resources, seen, pages)
+	}
+}
+
+func isPage(dict string) bool {
+	t := dictValue(dict, "Type")
+	return t == "/Page"
+}
+
+// fonts returns the character maps of the fonts in a page's resources by resource name
+func (f *pdfFile) fonts(resources string) map[string]*fontMap {
+	fonts := make(map[string]*fontMap)
+	fontDict := f.resolve(dictValue(resources, "Font"))
+	for _, entry := range dictEntries(fontDict) {
+		fontObj := f.resolve(entry.value)
+		fm := &fontMap{width: 1}
+		var cmap []byte
+		if n, ok := refNumber(dictValue(fontObj, "ToUnicode")); ok {
+			cmap = f.stream(f.object(n))
+		}
+		if cmap != nil {
+			fm = parseCMap(cmap)
+		} else if dictValue(fontObj, "Subtype") == "/Type0" {
+			fm.width = 2
+		}
+		fonts[entry.key] = fm
+	}
+	return fonts
+}
+
+// resolve returns the object value refers to when it is a reference, and value otherwise
+func (f *pdfFile) resolve(value string) string {
+	for i := 0; i < 8; i++ {
+		n, ok := refNumber(value)
+		if !ok {
+			return value
+		}
+		obj := f.object(n)
+		if obj == nil {
+			return value
+		}
+		value = obj.dict
+	}
+	return value
+}
+
+// refs returns the object numbers in a reference or an array of references; an array may itself
+// be referenced
+func (f *pdfFile) refs(value string) []int {
+	if n, ok := refNumber(value); ok {
+		if obj := f.object(n); obj != nil && obj.raw == nil && strings.HasPrefix(obj.dict, "[") {
+			value = obj.dict
+		}
+	}
+	var nums []int
+	for _, m := range refsPattern.FindAllStringSubmatch(value, -1) {
+		n, _ := strconv.Atoi(m[1])
+		nums = append(nums, n)
+	}
+	return nums
+}
+
+func refNumber(value string) (int, bool) {
+	m := refPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	return n, err == nil
+}
+
+// dictValue returns the raw value of /key in the dictionary text dict: a nested dictionary or
+// array with its brackets, a reference like "12 0 R", or a single token. It is "" when missing.
+func dictValue(dict, key string) string {
+	for _, entry := range dictEntries(dict) {
+		if entry.key == key {
+			return entry.value
+		}
+	}
+	return ""
+}
+
+type dictEntry struct{ key, value string }
+
+// dictEntries returns the top-level entries of the dictionary text dict
+func dictEntries(dict string) []dictEntry {
+	dict = strings.TrimSpace(dict)
+	if !strings.HasPrefix(dict, "<<") {
+		return nil
+	}
+	s := &scanner{data: []byte(dict), pos: 2}
+	var entries []dictEntry
+	for {
+		s.skipSpace()
+		if s.pos >= len(s.data) || s.peek(">>") {
+			return entries
+		}
+		if s.data[s.pos] != '/' {
+			s.pos++
+			continue
+		}
+		key := s.name()
+		s.skipSpace()
+		start := s.pos
+		s.skipValue()
+		value := strings.TrimSpace(string(s.data[start:s.pos]))
+		// a reference is three tokens: number, generation and R
+		if rest := strings.TrimSpace(string(s.data[s.pos:])); isInt(value) {
+			if m := refTail.FindStringIndex(rest); m != nil {
+				s.skipSpace()
+				s.pos += m[1]
+				value = strings.TrimSpace(string(s.data[start:s.pos]))
+			}
+		}
+		entries = append(entries, dictEntry{key: key, value: value})
+	}
+}
+
+func isInt(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// fontMap turns the character codes of a font into text
+type fontMap struct {
+	width int // bytes per code
+	codes map[uint32]string
+}
+
+var cmapCodespace = regexp.MustCompile(`begincodespacerange\s*<([0-9A-Fa-f]+)>`)
+
+// parseCMap reads the bfchar and bfrange mappings of a ToUnicode CMap
+func parseCMap(data []byte) *fontMap {
+	fm := &fontMap{width: 1, codes: make(map[uint32]string)}
+	if m := cmapCodespace.FindSubmatch(data); m != nil && len(m[1]) >= 4 {
+		fm.width = len(m[1]) / 2
+	}
+	s := &scanner{data: data}
+	var section string
+	var operands []pdfToken
+	for {
+		tok, ok := s.token()
+		if !ok {
+			return fm
+		}
+		if tok.kind != tokenOperator {
+			operands = append(operands, tok)
+			continue
+		}
+		switch tok.text {
+		case "beginbfchar", "beginbfrange":
+			section = tok.text
+		case "endbfchar", "endbfrange":
+			fm.addMappings(section, operands)
+			section = ""
+		}
+		operands = operands[:0]
+	}
+}
+
+func (fm *fontMap) addMappings(section string, operands []pdfToken) {
+	switch section {
+	case "beginbfchar":
+		for i := 0; i+1 < len(operands); i += 2 {
+			fm.codes[codeOf(operands[i].bytes)] = utf16Text(operands[i+1].bytes)
+		}
+	case "beginbfrange":
+		for i := 0; i+2 < len(operands); i += 3 {
+			lo, hi := codeOf(operands[i].bytes), codeOf(operands[i+1].bytes)
+			if hi < lo || hi-lo > 0xFFFF {
+				continue
+			}
+			dst := operands[i+2]
+			if dst.kind == tokenArray {
+				for j, item := range dst.items {
+					if lo+uint32(j) > hi {
+						break
+					}
+					fm.codes[lo+uint32(j)] = utf16Text(item.bytes)
+				}
+				continue
+			}
+			base := []rune(utf16Text(dst.bytes))
+			if len(base) == 0 {
+				continue
+			}
+			for code := lo; code <= hi; code++ {
+				r := append([]rune(nil), base...)
+				r[len(r)-1] += rune(code - lo)
+				fm.codes[code] = string(r)
+			}
+		}
+	}
+}
+
+// decode turns the bytes of a shown string into text
+func (fm *fontMap) decode(b []byte) string {
+	if fm == nil {
+		fm = &fontMap{width: 1}
+	}
+	var out strings.Builder
+	for i := 0; i+fm.width <= len(b); i += fm.width {
+		code := codeOf(b[i : i+fm.width])
+		if text, ok := fm.codes[code]; ok {
+			out.WriteString(text)
+		} else if fm.width == 1 {
+			out.WriteRune(winAnsiRune(b[i]))
+		}
+	}
+	return out.String()
+}
+
+func codeOf(b []byte) uint32 {
+	var code uint32
+	for _, c := range b {
+		code = code<<8 | uint32(c)
+	}
+	return code
+}
+
+func utf16Text(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// winAnsiRune decodes a byte of the WinAnsi encoding most single-byte fonts use
+func winAnsiRune(c byte) rune {
+	switch c {
+	case 0x80:
+		return '€'
+	case 0x91, 0x92:
+		return '\''
+	case 0x93, 0x94:
+		return '"'
+	case 0x96, 0x97:
+		return '-'
+	}
+	if c < 0x20 || (c >= 0x7F && c < 0xA0) {
+		return ' '
+	}
+	return rune(c)
+}
+
+// contentText runs the text operators of a page's content stream and returns its lines. Text drawn
+// at a different height starts a new line; text further along the same line is separated by a space.
+func contentText(contents []byte, fonts map[string]*fontMap) string {
+	var lines []string
+	var line strings.Builder
+	newLine := func() {
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	var font *fontMap
+	var x, y, lineX, lineY, leading float64
+	shownY := math.NaN()
+	moveTo := func(nx, ny float64) {
+		if math.IsNaN(shownY) || math.Abs(ny-shownY) > 1 {
+			newLine()
+		} else if nx > x {
+			line.WriteByte(' ')
+		}
+		x, y = nx, ny
+	}
+	nextLine := func() {
+		lineY -= leading
+		newLine()
+		x, y, shownY = lineX, lineY, math.NaN()
+	}
+	show := func(b []byte) {
+		line.WriteString(font.decode(b))
+		shownY = y
+	}
+
+	s := &scanner{data: contents}
+	var operands []pdfToken
+	for {
+		tok, ok := s.token()
+		if !ok {
+			break
+		}
+		if tok.kind != tokenOperator {
+			operands = append(operands, tok)
+			continue
+		}
+		num := func(i int) float64 {
+			if i < len(operands) {
+				return operands[i].number
+			}
+			return 0
+		}
+		switch tok.text {
+		case "BT":
+			lineX, lineY = 0, 0
+		case "Tf":
+			if len(operands) > 0 {
+				font = fonts[strings.TrimPrefix(operands[0].text, "/")]
+			}
+		case "TL":
+			leading = num(0)
+		case "Td", "TD":
+			if tok.text == "TD" {
+				leading = -num(1)
+			}
+			lineX, lineY = lineX+num(0), lineY+num(1)
+			moveTo(lineX, lineY)
+		case "Tm":
+			lineX, lineY = num(4), num(5)
+			moveTo(lineX, lineY)
+		case "T*":
+			nextLine()
+		case "Tj":
+			if len(operands) > 0 {
+				show(operands[0].bytes)
+			}
+		case "'", "\"":
+			nextLine()
+			if len(operands) > 0 {
+				show(operands[len(operands)-1].bytes)
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				for _, item := range operands[0].items {
+					if item.kind == tokenString {
+						show(item.bytes)
+					} else if item.kind == tokenNumber && item.number < -200 {
+						line.WriteByte(' ')
+					}
+				}
+			}
+		case "ID":
+			s.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+	newLine()
+	return strings.Join(lines, "\n")
+}
+
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenString
+	tokenName
+	tokenArray
+	tokenDict
+	tokenOperator
+)
+
+type pdfToken struct {
+	kind   tokenKind
+	text   string // names and operators
+	number float64
+	bytes  []byte     // strings
+	items  []pdfToken // arrays
+}
+
+// scanner reads the tokens of content streams and CMaps, and the values of dictionaries
+type scanner struct {
+	data []byte
+	pos  int
+}
+
+func (s *scanner) peek(prefix string) bool {
+	return bytes.HasPrefix(s.data[s.pos:], []byte(prefix))
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return isSpace(c) || strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (s *scanner) skipSpace() {
+	for s.pos < len(s.data) {
+		switch c := s.data[s.pos]; {
+		case isSpace(c):
+			s.pos++
+		case c == '%':
+			for s.pos < len(s.data) && s.data[s.pos] != '\n' && s.data[s.pos] != '\r' {
+				s.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (s *scanner) token() (pdfToken, bool) {
+	s.skipSpace()
+	if s.pos >= len(s.data) {
+		return pdfToken{}, false
+	}
+	switch c := s.data[s.pos]; {
+	case c == '(':
+		return pdfToken{kind: tokenString, bytes: s.literalString()}, true
+	case s.peek("<<"):
+		s.skipValue()
+		return pdfToken{kind: tokenDict}, true
+	case c == '<':
+		return pdfToken{kind: tokenString, bytes: s.hexString()}, true
+	case c == '[':
+		s.pos++
+		var items []pdfToken
+		for {
+			s.skipSpace()
+			if s.pos >= len(s.data) {
+				break
+			}
+			if s.data[s.pos] == ']' {
+				s.pos++
+				break
+			}
+			item, ok := s.token()
+			if !ok {
+				break
+			}
+			items = append(items, item)
+		}
+		return pdfToken{kind: tokenArray, items: items}, true
+	case c == '/':
+		return pdfToken{kind: tokenName, text: "/" + s.name()}, true
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		s.pos++
+		return s.token()
+	}
+	start := s.pos
+	for s.pos < len(s.data) && !isDelimiter(s.data[s.pos]) {
+		s.pos++
+	}
+	word := string(s.data[start:s.pos])
+	if n, err := strconv.ParseFloat(word, 64); err == nil {
+		return pdfToken{kind: tokenNumber, number: n}, true
+	}
+	return pdfToken{kind: tokenOperator, text: word}, true
+}
+
+// name reads a name at a '/', decoding #xx escapes, and returns it without the slash
+func (s *scanner) name() string {
+	s.pos++
+	var out []byte
+	for s.pos < len(s.data) && !isDelimiter(s.data[s.pos]) {
+		c := s.data[s.pos]
+		if c == '#' && s.pos+2 < len(s.data) {
+			if v, err := strconv.ParseUint(string(s.data[s.pos+1:s.pos+3]), 16, 8); err == nil {
+				out = append(out, byte(v))
+				s.pos += 3
+				continue
+			}
+		}
+		out = append(out, c)
+		s.pos++
+	}
+	return string(out)
+}
+
+func (s *scanner) literalString() []byte {
+	s.pos++
+	var out []byte
+	depth := 1
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		s.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return out
+			}
+		case '\\':
+			if s.pos >= len(s.data) {
+				return out
+			}
+			e := s.data[s.pos]
+			s.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if s.pos < len(s.data) && s.data[s.pos] == '\n' {
+					s.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && s.pos < len(s.data) && s.data[s.pos] >= '0' && s.data[s.pos] <= '7'; i++ {
+						v = v*8 + int(s.data[s.pos]-'0')
+						s.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (s *scanner) hexString() []byte {
+	s.pos++
+	var digits []byte
+	for s.pos < len(s.data) && s.data[s.pos] != '>' {
+		if c := s.data[s.pos]; strings.IndexByte("0123456789abcdefABCDEF", c) >= 0 {
+			digits = append(digits, c)
+		}
+		s.pos++
+	}
+	s.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(v)
+	}
+	return out
+}
+
+// skipValue moves past one value: a dictionary or array with everything nested in it, a string,
+// a name or a single token
+func (s *scanner) skipValue() {
+	if s.pos >= len(s.data) {
+		return
+	}
+	switch c := s.data[s.pos]; {
+	case s.peek("<<"):
+		s.pos += 2
+		for s.skipSpace(); s.pos < len(s.data) && !s.peek(">>"); s.skipSpace() {
+			s.skipValue()
+		}
+		s.pos += 2
+	case c == '[':
+		s.pos++
+		for s.skipSpace(); s.pos < len(s.data) && s.data[s.pos] != ']'; s.skipSpace() {
+			s.skipValue()
+		}
+		s.pos++
+	case c == '(':
+		s.literalString()
+	case c == '<':
+		s.hexString()
+	case c == '/':
+		s.name()
+	default:
+		start := s.pos
+		for s.pos < len(s.data) && !isDelimiter(s.data[s.pos]) {
+			s.pos++
+		}
+		if s.pos == start {
+			s.pos++
+		}
+	}
+	if s.pos > len(s.data) {
+		s.pos = len(s.data)
+	}
+}
+
+// skipInlineImage moves past the data of an inline image, which ends at an EI operator
+func (s *scanner) skipInlineImage() {
+	for i := s.pos; i+2 < len(s.data); i++ {
+		if s.data[i] == 'E' && s.data[i+1] == 'I' && isSpace(s.data[i-1]) && isDelimiter(s.data[i+2]) {
+			s.pos = i + 2
+			return
+		}
+	}
+	s.pos = len(s.data)
+}
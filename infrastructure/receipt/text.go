@@ -0,0 +1,354 @@
+package receipt
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"expense_tracker/domain"
+)
+
+// maxLineItems caps how many line items are kept from one receipt
+const maxLineItems = 100
+
+var (
+	// amountPattern matches amounts with two decimals such as 1,234.56, 1.234,56, 12.50 or €3,20
+	amountPattern = regexp.MustCompile(`(-)?\s?([$€£¥₹])?\s?(\d{1,3}(?:[,.']\d{3})+|\d+)[.,](\d{2})\b`)
+	quantityLead  = regexp.MustCompile(`^(\d+(?:[.,]\d+)?)\s*[xX×*@]\s*`)
+	quantityTail  = regexp.MustCompile(`\s(\d+(?:[.,]\d+)?)\s*[xX×@]\s*$`)
+
+	isoDate     = regexp.MustCompile(`\b(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})\b`)
+	numericDate = regexp.MustCompile(`\b(\d{1,2})([./-])(\d{1,2})[./-](\d{4}|\d{2})\b`)
+	dayMonth    = regexp.MustCompile(`(?i)\b(\d{1,2})(?:st|nd|rd|th)?[ -]([a-z]{3,9})\.?,?[ -](\d{4})\b`)
+	monthDay    = regexp.MustCompile(`(?i)\b([a-z]{3,9})\.? (\d{1,2})(?:st|nd|rd|th)?,? (\d{4})\b`)
+)
+
+var monthNames = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April, "may": time.May, "jun": time.June,
+	"jul": time.July, "aug": time.August, "sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+var currencySymbols = map[string]string{"€": "EUR", "£": "GBP", "¥": "JPY", "₹": "INR"}
+
+// currencyCodes are the ISO 4217 codes recognised in receipt text; a "$" alone is not enough to
+// tell dollars apart, so it leaves the currency to the user's default
+var currencyCodes = map[string]bool{
+	"USD": true, "EUR": true, "GBP": true, "JPY": true, "CHF": true, "CAD": true, "AUD": true, "NZD": true,
+	"SEK": true, "NOK": true, "DKK": true, "PLN": true, "CZK": true, "HUF": true, "INR": true, "CNY": true,
+	"HKD": true, "SGD": true, "MXN": true, "BRL": true, "ZAR": true, "ETB": true, "KES": true, "NGN": true,
+}
+
+// totalKeywords rank the labels of a receipt's total; higher ranks win
+var totalKeywords = []struct {
+	label string
+	rank  int
+}{
+	{"grand total", 4}, {"total due", 4}, {"amount due", 4}, {"balance due", 4}, {"total to pay", 4}, {"total amount", 4},
+	{"amount paid", 3}, {"total paid", 3}, {"gesamtbetrag", 3}, {"summe", 2}, {"gesamt", 2}, {"totale", 2},
+	{"total", 1},
+}
+
+// notTotal are labels that contain "total" without being the total
+var notTotal = []string{"subtotal", "sub total", "sub-total", "total tax", "total vat", "total savings",
+	"total discount", "total items", "total qty", "total quantity"}
+
+// notItem are labels of lines that are not purchased items
+var notItem = []string{"total", "tax", "vat", "change", "cash", "card", "visa", "mastercard", "amex", "tip",
+	"gratuity", "tendered", "balance", "payment", "paid", "rounding", "discount", "savings", "summe", "gesamt", "mwst"}
+
+// ParseText reads the merchant, date, total, currency and line items of a receipt from its text,
+// one line of the receipt per line. The merchant is the first line near the top that reads like a
+// name; the total is the amount on the line with the strongest total label; line items are the
+// lines above the totals ending in an amount. Dates such as 03/04/2024 are read month first with
+// slashes and day first with dots or dashes, unless only one reading is a valid date.
+func ParseText(text string) domain.ReceiptData {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	var data domain.ReceiptData
+	merchantLine := -1
+	for i := 0; i < len(lines) && i < 6; i++ {
+		if looksLikeName(lines[i]) {
+			data.Merchant = truncate(lines[i], 100)
+			merchantLine = i
+			break
+		}
+	}
+	for _, line := range lines {
+		if date, ok := findDate(line); ok {
+			data.Date = &date
+			break
+		}
+	}
+	data.Currency = findCurrency(lines)
+
+	totalLine, bestRank := -1, 0
+	for i, line := range lines {
+		rank := totalRank(line)
+		if rank == 0 || rank < bestRank {
+			continue
+		}
+		amount, ok := lastAmount(line)
+		if !ok && i+1 < len(lines) && totalRank(lines[i+1]) == 0 {
+			amount, ok = lastAmount(lines[i+1])
+		}
+		// among lines of the same rank the largest amount wins, so a "total" of savings below
+		// the real one does not replace it
+		if ok && amount.IsPositive() && (rank > bestRank || data.Total == nil || amount.Cmp(*data.Total) > 0) {
+			total := amount
+			data.Total, bestRank, totalLine = &total, rank, i
+		}
+	}
+
+	end := len(lines)
+	for i, line := range lines {
+		if totalRank(line) > 0 || hasLabel(line, notTotal) {
+			end = i
+			break
+		}
+	}
+	if totalLine >= 0 && totalLine < end {
+		end = totalLine
+	}
+	for i := merchantLine + 1; i < end && len(data.LineItems) < maxLineItems; i++ {
+		if item, ok := lineItem(lines[i]); ok {
+			data.LineItems = append(data.LineItems, item)
+		}
+	}
+	return data
+}
+
+// looksLikeName reports whether a line could be a merchant's name: mostly letters, and not a
+// date, an amount, an address number, a phone number or a receipt heading
+func looksLikeName(line string) bool {
+	letters, digits := 0, 0
+	for _, r := range line {
+		switch {
+		case unicode.IsLetter(r):
+			letters++
+		case unicode.IsDigit(r):
+			digits++
+		}
+	}
+	if letters < 2 || digits > letters {
+		return false
+	}
+	if _, ok := findDate(line); ok {
+		return false
+	}
+	if _, ok := lastAmount(line); ok {
+		return false
+	}
+	lower := strings.ToLower(line)
+	for _, heading := range []string{"receipt", "invoice", "welcome", "tel", "phone", "www.", "http", "order #", "order no"} {
+		if strings.HasPrefix(lower, heading) {
+			return false
+		}
+	}
+	return true
+}
+
+// findDate returns the first date on a line
+func findDate(line string) (time.Time, bool) {
+	if m := isoDate.FindStringSubmatch(line); m != nil {
+		if t, ok := makeDate(m[1], m[2], m[3]); ok {
+			return t, true
+		}
+	}
+	if m := numericDate.FindStringSubmatch(line); m != nil {
+		first, second := m[1], m[3]
+		a, _ := strconv.Atoi(first)
+		b, _ := strconv.Atoi(second)
+		monthFirst := m[2] == "/"
+		if a > 12 {
+			monthFirst = false
+		} else if b > 12 {
+			monthFirst = true
+		}
+		if monthFirst {
+			first, second = second, first
+		}
+		if t, ok := makeDate(m[4], second, first); ok {
+			return t, true
+		}
+	}
+	if m := dayMonth.FindStringSubmatch(line); m != nil {
+		if t, ok := namedDate(m[3], m[2], m[1]); ok {
+			return t, true
+		}
+	}
+	if m := monthDay.FindStringSubmatch(line); m != nil {
+		if t, ok := namedDate(m[3], m[1], m[2]); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func namedDate(year, month, day string) (time.Time, bool) {
+	if len(month) < 3 {
+		return time.Time{}, false
+	}
+	m, ok := monthNames[strings.ToLower(month[:3])]
+	if !ok {
+		return time.Time{}, false
+	}
+	return makeDate(year, strconv.Itoa(int(m)), day)
+}
+
+// makeDate builds a valid date; two-digit years are in this century
+func makeDate(year, month, day string) (time.Time, bool) {
+	y, err1 := strconv.Atoi(year)
+	m, err2 := strconv.Atoi(month)
+	d, err3 := strconv.Atoi(day)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return time.Time{}, false
+	}
+	if len(year) == 2 {
+		y += 2000
+	}
+	t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	if t.Year() != y || t.Month() != time.Month(m) || t.Day() != d || y < 1990 {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// lastAmount returns the last amount on a line, ignoring dates and percentages
+func lastAmount(line string) (domain.Money, bool) {
+	line = isoDate.ReplaceAllString(line, " ")
+	line = numericDate.ReplaceAllString(line, " ")
+	matches := amountPattern.FindAllStringSubmatchIndex(line, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+		if m[1] < len(line) && line[m[1]] == '%' {
+			continue
+		}
+		whole := strings.NewReplacer(",", "", ".", "", " ", "", "'", "").Replace(line[m[6]:m[7]])
+		amount, err := domain.ParseMoney(whole + "." + line[m[8]:m[9]])
+		if err != nil {
+			continue
+		}
+		if m[2] >= 0 {
+			amount = amount.Neg()
+		}
+		return amount, true
+	}
+	return domain.Money{}, false
+}
+
+// findCurrency returns the ISO code or currency symbol used on the receipt, or "" when none is
+func findCurrency(lines []string) string {
+	for _, line := range lines {
+		for _, word := range strings.FieldsFunc(line, func(r rune) bool { return !unicode.IsLetter(r) }) {
+			if currencyCodes[word] {
+				return word
+			}
+		}
+	}
+	for _, line := range lines {
+		for symbol, code := range currencySymbols {
+			if strings.Contains(line, symbol) {
+				return code
+			}
+		}
+	}
+	return ""
+}
+
+// totalRank returns how strongly a line is labelled as the total, 0 when it is not
+func totalRank(line string) int {
+	lower := strings.ToLower(line)
+	if hasLabel(lower, notTotal) {
+		return 0
+	}
+	for _, k := range totalKeywords {
+		if containsWord(lower, k.label) {
+			return k.rank
+		}
+	}
+	return 0
+}
+
+func hasLabel(line string, labels []string) bool {
+	lower := strings.ToLower(line)
+	for _, label := range labels {
+		if containsWord(lower, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether s holds word with no letter right before or after it
+func containsWord(s, word string) bool {
+	for from := 0; ; {
+		i := strings.Index(s[from:], word)
+		if i < 0 {
+			return false
+		}
+		i += from
+		end := i + len(word)
+		before := i == 0 || !isLetterByte(s[i-1])
+		after := end == len(s) || !isLetterByte(s[end])
+		if before && after {
+			return true
+		}
+		from = i + 1
+	}
+}
+
+func isLetterByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// lineItem reads a purchased item from a line such as "2 x Coffee 7.00" or "Bread 1 @ 2.50 2.50"
+func lineItem(line string) (domain.ReceiptLineItem, bool) {
+	if hasLabel(line, notItem) {
+		return domain.ReceiptLineItem{}, false
+	}
+	amount, ok := lastAmount(line)
+	if !ok || !amount.IsPositive() {
+		return domain.ReceiptLineItem{}, false
+	}
+	if _, isDate := findDate(line); isDate {
+		return domain.ReceiptLineItem{}, false
+	}
+
+	item := domain.ReceiptLineItem{Amount: amount}
+	description := line
+	if m := quantityLead.FindStringSubmatch(description); m != nil {
+		item.Quantity, _ = strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+		description = description[len(m[0]):]
+	}
+	// drop the amounts (unit price and line total), then a trailing quantity such as "2 x"
+	if loc := amountPattern.FindStringIndex(description); loc != nil {
+		description = description[:loc[0]]
+	}
+	if m := quantityTail.FindStringSubmatchIndex(description); m != nil {
+		if item.Quantity == 0 {
+			item.Quantity, _ = strconv.ParseFloat(strings.Replace(description[m[2]:m[3]], ",", ".", 1), 64)
+		}
+		description = description[:m[0]]
+	}
+	description = strings.Trim(description, " -:.*")
+	if !strings.ContainsFunc(description, unicode.IsLetter) {
+		return domain.ReceiptLineItem{}, false
+	}
+	item.Description = truncate(description, 200)
+	return item, true
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"expense_tracker/domain"
+
+	"github.com/google/uuid"
+)
+
+// AttachmentRepoPG implements AttachmentRepository with PostgreSQL
+type AttachmentRepoPG struct {
+	db *sql.DB
+}
+
+// NewAttachmentRepoPG returns a new PostgreSQL attachment repository
+func NewAttachmentRepoPG(db *sql.DB) *AttachmentRepoPG {
+	return &AttachmentRepoPG{db: db}
+}
+
+const attachmentColumns = `id, expense_id, user_id, file_name, content_type, size_bytes, storage_key, created_at`
+
+// Create inserts the attachment when its uploader may change the expense, which must not be deleted
+func (r *AttachmentRepoPG) Create(ctx context.Context, a *domain.Attachment) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	query := `INSERT INTO expense_attachments (id, expense_id, user_id, file_name, content_type, size_bytes, storage_key)
+		SELECT $1, $2, $3, $4, $5, $6, $7
+		WHERE EXISTS (SELECT 1 FROM expenses WHERE id = $2 AND deleted_at IS NULL AND ` + writableBy("", 3) + `)
+		RETURNING created_at`
+	return r.db.QueryRowContext(ctx, query,
+		a.ID, a.ExpenseID, a.UserID, a.FileName, a.ContentType, a.Size, a.StorageKey,
+	).Scan(&a.CreatedAt)
+}
+
+func (r *AttachmentRepoPG) GetByID(ctx context.Context, expenseID, id string) (*domain.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM expense_attachments WHERE id = $1 AND expense_id = $2`
+	a, err := scanAttachment(r.db.QueryRowContext(ctx, query, id, expenseID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+func (r *AttachmentRepoPG) ListByExpense(ctx context.Context, expenseID string) ([]*domain.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM expense_attachments WHERE expense_id = $1 ORDER BY created_at ASC, id ASC`
+	rows, err := r.db.QueryContext(ctx, query, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make([]*domain.Attachment, 0)
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+func (r *AttachmentRepoPG) Delete(ctx context.Context, expenseID, id, userID string) error {
+	query := `DELETE FROM expense_attachments a USING expenses e
+		WHERE a.id = $1 AND a.expense_id = $2 AND e.id = a.expense_id AND ` + writableBy("e", 3)
+	result, err := r.db.ExecContext(ctx, query, id, expenseID, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanAttachment(row rowScanner) (*domain.Attachment, error) {
+	var a domain.Attachment
+	if err := row.Scan(&a.ID, &a.ExpenseID, &a.UserID, &a.FileName, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
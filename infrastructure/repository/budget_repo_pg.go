@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"expense_tracker/domain"
+
+	"github.com/google/uuid"
+)
+
+// BudgetRepoPG implements BudgetRepository with PostgreSQL
+type BudgetRepoPG struct {
+	db *sql.DB
+}
+
+// NewBudgetRepoPG returns a new PostgreSQL budget repository
+func NewBudgetRepoPG(db *sql.DB) *BudgetRepoPG {
+	return &BudgetRepoPG{db: db}
+}
+
+const budgetColumns = `b.id, b.user_id, b.ledger_id, b.category_id, c.name, b.amount, b.bucket, b.created_at, b.updated_at`
+
+const budgetFrom = ` FROM budgets b LEFT JOIN categories c ON c.id = b.category_id`
+
+func (r *BudgetRepoPG) Create(ctx context.Context, budget *domain.Budget) error {
+	if budget.ID == "" {
+		budget.ID = uuid.New().String()
+	}
+	var categoryID interface{}
+	if budget.CategoryID != nil {
+		categoryID = *budget.CategoryID
+	}
+	query := `INSERT INTO budgets (id, user_id, category_id, amount, bucket, ledger_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at, updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		budget.ID, budget.UserID, categoryID, budget.Amount, nullBucket(budget.Bucket), nullStrPtr(budget.LedgerID),
+	).Scan(&budget.CreatedAt, &budget.UpdatedAt)
+}
+
+func (r *BudgetRepoPG) GetByID(ctx context.Context, id, userID string) (*domain.Budget, error) {
+	query := `SELECT ` + budgetColumns + budgetFrom + ` WHERE b.id = $1 AND ` + readableBy("b", 2)
+	budget, err := scanBudget(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return budget, err
+}
+
+func (r *BudgetRepoPG) GetByCategory(ctx context.Context, userID string, ledgerID, categoryID *string) (*domain.Budget, error) {
+	var catID interface{}
+	if categoryID != nil {
+		catID = *categoryID
+	}
+	query := `SELECT ` + budgetColumns + budgetFrom + ` WHERE b.user_id = $1 AND b.ledger_id IS NULL AND b.category_id IS NOT DISTINCT FROM $2::uuid`
+	owner := userID
+	if ledgerID != nil {
+		query = `SELECT ` + budgetColumns + budgetFrom + ` WHERE b.ledger_id = $1 AND b.category_id IS NOT DISTINCT FROM $2::uuid`
+		owner = *ledgerID
+	}
+	budget, err := scanBudget(r.db.QueryRowContext(ctx, query, owner, catID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return budget, err
+}
+
+// ListByUser returns the user's own budgets, the overall budget first, then category budgets
+func (r *BudgetRepoPG) ListByUser(ctx context.Context, userID string) ([]*domain.Budget, error) {
+	return r.list(ctx, ` WHERE b.user_id = $1 AND b.ledger_id IS NULL`, userID)
+}
+
+// ListByLedger returns the ledger's budgets, the overall budget first, then category budgets
+func (r *BudgetRepoPG) ListByLedger(ctx context.Context, ledgerID string) ([]*domain.Budget, error) {
+	return r.list(ctx, ` WHERE b.ledger_id = $1`, ledgerID)
+}
+
+func (r *BudgetRepoPG) list(ctx context.Context, where, owner string) ([]*domain.Budget, error) {
+	query := `SELECT ` + budgetColumns + budgetFrom + where + `
+		ORDER BY b.category_id IS NOT NULL, b.created_at ASC`
+	rows, err := r.db.QueryContext(ctx, query, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := make([]*domain.Budget, 0)
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+	return budgets, rows.Err()
+}
+
+func (r *BudgetRepoPG) Update(ctx context.Context, budget *domain.Budget, userID string) error {
+	query := `UPDATE budgets SET amount = $1, bucket = $2, updated_at = NOW()
+		WHERE id = $3 AND ` + writableBy("", 4) + `
+		RETURNING updated_at`
+	return r.db.QueryRowContext(ctx, query, budget.Amount, nullBucket(budget.Bucket), budget.ID, userID).Scan(&budget.UpdatedAt)
+}
+
+func (r *BudgetRepoPG) Delete(ctx context.Context, id, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE id = $1 AND `+writableBy("", 2), id, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanBudget(row rowScanner) (*domain.Budget, error) {
+	var budget domain.Budget
+	var ledgerID, categoryID, categoryName, bucket sql.NullString
+	if err := row.Scan(&budget.ID, &budget.UserID, &ledgerID, &categoryID, &categoryName, &budget.Amount, &bucket, &budget.CreatedAt, &budget.UpdatedAt); err != nil {
+		return nil, err
+	}
+	budget.CategoryName = categoryName.String
+	budget.Bucket = domain.BudgetBucket(bucket.String)
+	if ledgerID.Valid {
+		budget.LedgerID = &ledgerID.String
+	}
+	if categoryID.Valid {
+		budget.CategoryID = &categoryID.String
+	}
+	return &budget, nil
+}
+
+func nullBucket(bucket domain.BudgetBucket) interface{} {
+	if bucket == "" {
+		return nil
+	}
+	return string(bucket)
+}
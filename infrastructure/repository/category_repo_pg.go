@@ -28,61 +28,64 @@ func (r *CategoryRepoPG) Create(ctx context.Context, input domain.CreateCategory
 	} else {
 		userID = nil
 	}
-	query := `INSERT INTO categories (id, name, user_id) VALUES ($1, $2, $3)`
-	_, err := r.db.ExecContext(ctx, query, id, input.Name, userID)
-	if err != nil {
+	query := `INSERT INTO categories (id, name, user_id, ledger_id) VALUES ($1, $2, $3, $4) RETURNING updated_at, version`
+	c := &domain.Category{
+		ID:       id,
+		Name:     input.Name,
+		UserID:   input.UserID,
+		LedgerID: input.LedgerID,
+	}
+	if err := r.db.QueryRowContext(ctx, query, id, input.Name, userID, nullStrPtr(input.LedgerID)).Scan(&c.UpdatedAt, &c.Version); err != nil {
 		return nil, err
 	}
-	return &domain.Category{
-		ID:     id,
-		Name:   input.Name,
-		UserID: input.UserID,
-	}, nil
+	return c, nil
 }
 
 func (r *CategoryRepoPG) GetByID(ctx context.Context, id string, userID *string) (*domain.Category, error) {
-	// Category is visible if global (user_id IS NULL) or belongs to user
-	query := `SELECT id, name, user_id FROM categories WHERE id = $1`
+	// Category is visible if global (user_id IS NULL), belongs to user or to one of the user's ledgers
+	query := `SELECT id, name, user_id, ledger_id, updated_at, deleted_at, version FROM categories WHERE id = $1 AND deleted_at IS NULL`
 	args := []interface{}{id}
 	if userID != nil {
-		query += ` AND (user_id IS NULL OR user_id = $2)`
+		query += ` AND (user_id IS NULL OR ` + readableBy("", 2) + `)`
 		args = append(args, *userID)
 	}
-	row := r.db.QueryRowContext(ctx, query, args...)
-	var c domain.Category
-	var uid sql.NullString
-	err := row.Scan(&c.ID, &c.Name, &uid)
+	c, err := scanCategory(r.db.QueryRowContext(ctx, query, args...))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if err != nil {
-		return nil, err
-	}
-	if uid.Valid {
-		c.UserID = &uid.String
-	}
-	return &c, nil
+	return c, err
 }
 
-// List returns categories: if userID is nil, only global; otherwise global + user's categories
+// List returns categories: if userID is nil, only global; otherwise global + user's own categories
 func (r *CategoryRepoPG) List(ctx context.Context, userID *string, options pkgrepo.ListOptions) ([]*domain.Category, int, error) {
 	var baseQuery string
 	var args []interface{}
 	if userID == nil {
-		baseQuery = ` FROM categories WHERE user_id IS NULL`
+		baseQuery = ` FROM categories WHERE user_id IS NULL AND deleted_at IS NULL`
 		args = nil
 	} else {
-		baseQuery = ` FROM categories WHERE user_id IS NULL OR user_id = $1`
+		baseQuery = ` FROM categories WHERE (user_id IS NULL OR (user_id = $1 AND ledger_id IS NULL)) AND deleted_at IS NULL`
 		args = []interface{}{*userID}
 	}
+	return r.list(ctx, baseQuery, args, options)
+}
+
+// ListByLedger returns global categories and the ledger's categories when the user is a member of it
+func (r *CategoryRepoPG) ListByLedger(ctx context.Context, ledgerID, userID string, options pkgrepo.ListOptions) ([]*domain.Category, int, error) {
+	baseQuery := ` FROM categories WHERE (user_id IS NULL
+			OR (ledger_id = $1 AND EXISTS (SELECT 1 FROM ledger_members m WHERE m.ledger_id = $1 AND m.user_id = $2)))
+		AND deleted_at IS NULL`
+	return r.list(ctx, baseQuery, []interface{}{ledgerID, userID}, options)
+}
 
+func (r *CategoryRepoPG) list(ctx context.Context, baseQuery string, args []interface{}, options pkgrepo.ListOptions) ([]*domain.Category, int, error) {
 	countQuery := `SELECT COUNT(*)` + baseQuery
 	var total int
 	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
-	query := `SELECT id, name, user_id` + baseQuery + ` ORDER BY name LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
+	query := `SELECT id, name, user_id, ledger_id, updated_at, deleted_at, version` + baseQuery + ` ORDER BY name LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
 	args = append(args, options.Limit, options.Offset)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -90,19 +93,8 @@ func (r *CategoryRepoPG) List(ctx context.Context, userID *string, options pkgre
 		return nil, 0, err
 	}
 	defer rows.Close()
-	var list []*domain.Category
-	for rows.Next() {
-		var c domain.Category
-		var uid sql.NullString
-		if err := rows.Scan(&c.ID, &c.Name, &uid); err != nil {
-			return nil, 0, err
-		}
-		if uid.Valid {
-			c.UserID = &uid.String
-		}
-		list = append(list, &c)
-	}
-	if err := rows.Err(); err != nil {
+	list, err := scanCategories(rows)
+	if err != nil {
 		return nil, 0, err
 	}
 	return list, total, nil
@@ -120,14 +112,17 @@ func (r *CategoryRepoPG) Update(ctx context.Context, id string, userID *string,
 	var query string
 	var args []interface{}
 	if userID != nil {
-		// User can only update their own categories (not global)
-		query = `UPDATE categories SET name = $1 WHERE id = $2 AND user_id = $3`
+		// User can only update their own categories and those of ledgers they edit (not global)
+		query = `UPDATE categories SET name = $1 WHERE id = $2 AND ` + writableBy("", 3) + ` AND deleted_at IS NULL RETURNING updated_at, version`
 		args = []interface{}{name, id, *userID}
 	} else {
-		query = `UPDATE categories SET name = $1 WHERE id = $2 AND user_id IS NULL`
+		query = `UPDATE categories SET name = $1 WHERE id = $2 AND user_id IS NULL AND deleted_at IS NULL RETURNING updated_at, version`
 		args = []interface{}{name, id}
 	}
-	_, err = r.db.ExecContext(ctx, query, args...)
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&existing.UpdatedAt, &existing.Version)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -135,14 +130,15 @@ func (r *CategoryRepoPG) Update(ctx context.Context, id string, userID *string,
 	return existing, nil
 }
 
+// Delete soft-deletes the category, leaving a tombstone for syncing clients
 func (r *CategoryRepoPG) Delete(ctx context.Context, id string, userID *string) error {
 	var query string
 	var args []interface{}
 	if userID == nil {
-		query = `DELETE FROM categories WHERE id = $1 AND user_id IS NULL`
+		query = `UPDATE categories SET deleted_at = NOW() WHERE id = $1 AND user_id IS NULL AND deleted_at IS NULL`
 		args = []interface{}{id}
 	} else {
-		query = `DELETE FROM categories WHERE id = $1 AND user_id = $2`
+		query = `UPDATE categories SET deleted_at = NOW() WHERE id = $1 AND ` + writableBy("", 2) + ` AND deleted_at IS NULL`
 		args = []interface{}{id, *userID}
 	}
 	result, err := r.db.ExecContext(ctx, query, args...)
@@ -155,3 +151,59 @@ func (r *CategoryRepoPG) Delete(ctx context.Context, id string, userID *string)
 	}
 	return nil
 }
+
+// ListChangedSince returns global and the user's own categories (including tombstones) changed
+// after since by transactions before the watermark, oldest change first (sync change feed)
+func (r *CategoryRepoPG) ListChangedSince(ctx context.Context, userID string, since domain.SyncPosition, before uint64, limit int) ([]*domain.Category, error) {
+	where, args := changeFeedWhere(since, before, 2)
+	query := `SELECT id, name, user_id, ledger_id, updated_at, deleted_at, version, sync_xid FROM categories
+		WHERE (user_id IS NULL OR (user_id = $1 AND ledger_id IS NULL)) AND ` + where + `
+		ORDER BY sync_xid, version LIMIT $5`
+	rows, err := r.db.QueryContext(ctx, query, append(append([]interface{}{userID}, args...), limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*domain.Category
+	for rows.Next() {
+		var txID uint64
+		c, err := scanCategory(changeRow{rows: rows, txID: &txID})
+		if err != nil {
+			return nil, err
+		}
+		c.SyncTxID = txID
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func scanCategory(row rowScanner) (*domain.Category, error) {
+	var c domain.Category
+	var uid, ledgerID sql.NullString
+	var deletedAt sql.NullTime
+	if err := row.Scan(&c.ID, &c.Name, &uid, &ledgerID, &c.UpdatedAt, &deletedAt, &c.Version); err != nil {
+		return nil, err
+	}
+	if uid.Valid {
+		c.UserID = &uid.String
+	}
+	if ledgerID.Valid {
+		c.LedgerID = &ledgerID.String
+	}
+	if deletedAt.Valid {
+		c.DeletedAt = &deletedAt.Time
+	}
+	return &c, nil
+}
+
+func scanCategories(rows *sql.Rows) ([]*domain.Category, error) {
+	var list []*domain.Category
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"expense_tracker/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// CategoryRuleRepoPG implements CategoryRuleRepository with PostgreSQL
+type CategoryRuleRepoPG struct {
+	db *sql.DB
+}
+
+// NewCategoryRuleRepoPG returns a new PostgreSQL category rule repository
+func NewCategoryRuleRepoPG(db *sql.DB) *CategoryRuleRepoPG {
+	return &CategoryRuleRepoPG{db: db}
+}
+
+const categoryRuleColumns = `cr.id, cr.user_id, cr.ledger_id, cr.category_id, c.name, cr.note_contains, cr.note_pattern,
+	cr.min_amount, cr.max_amount, cr.weekdays, cr.priority, cr.created_at, cr.updated_at`
+
+const categoryRuleFrom = ` FROM category_rules cr JOIN categories c ON c.id = cr.category_id AND c.deleted_at IS NULL`
+
+func (r *CategoryRuleRepoPG) Create(ctx context.Context, rule *domain.CategoryRule) error {
+	if rule.ID == "" {
+		rule.ID = uuid.New().String()
+	}
+	query := `INSERT INTO category_rules (
+			id, user_id, ledger_id, category_id, note_contains, note_pattern, min_amount, max_amount, weekdays, priority
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at, updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		rule.ID, rule.UserID, nullStrPtr(rule.LedgerID), rule.CategoryID, nullStr(rule.NoteContains), nullStr(rule.NotePattern),
+		nullMoney(rule.MinAmount), nullMoney(rule.MaxAmount), nullWeekdays(rule.Weekdays), rule.Priority,
+	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+}
+
+func (r *CategoryRuleRepoPG) GetByID(ctx context.Context, id, userID string) (*domain.CategoryRule, error) {
+	query := `SELECT ` + categoryRuleColumns + categoryRuleFrom + ` WHERE cr.id = $1 AND ` + readableBy("cr", 2)
+	rule, err := scanCategoryRule(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rule, err
+}
+
+func (r *CategoryRuleRepoPG) ListByUser(ctx context.Context, userID string) ([]*domain.CategoryRule, error) {
+	return r.list(ctx, ` WHERE cr.user_id = $1 AND cr.ledger_id IS NULL`, userID)
+}
+
+func (r *CategoryRuleRepoPG) ListByLedger(ctx context.Context, ledgerID string) ([]*domain.CategoryRule, error) {
+	return r.list(ctx, ` WHERE cr.ledger_id = $1`, ledgerID)
+}
+
+func (r *CategoryRuleRepoPG) list(ctx context.Context, where, owner string) ([]*domain.CategoryRule, error) {
+	query := `SELECT ` + categoryRuleColumns + categoryRuleFrom + where + `
+		ORDER BY cr.priority DESC, cr.created_at ASC, cr.id ASC`
+	rows, err := r.db.QueryContext(ctx, query, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]*domain.CategoryRule, 0)
+	for rows.Next() {
+		rule, err := scanCategoryRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *CategoryRuleRepoPG) Update(ctx context.Context, rule *domain.CategoryRule, userID string) error {
+	query := `UPDATE category_rules SET category_id = $1, note_contains = $2, note_pattern = $3, min_amount = $4,
+			max_amount = $5, weekdays = $6, priority = $7, updated_at = NOW()
+		WHERE id = $8 AND ` + writableBy("", 9) + `
+		RETURNING updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		rule.CategoryID, nullStr(rule.NoteContains), nullStr(rule.NotePattern), nullMoney(rule.MinAmount),
+		nullMoney(rule.MaxAmount), nullWeekdays(rule.Weekdays), rule.Priority, rule.ID, userID,
+	).Scan(&rule.UpdatedAt)
+}
+
+func (r *CategoryRuleRepoPG) Delete(ctx context.Context, id, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM category_rules WHERE id = $1 AND `+writableBy("", 2), id, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *CategoryRuleRepoPG) Recategorize(ctx context.Context, userID string, categories map[string]string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `UPDATE expenses SET category_id = $1
+		WHERE id = $2 AND category_id IS DISTINCT FROM $1::uuid AND `+writableBy("", 3)+` AND deleted_at IS NULL`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	changed := 0
+	for expenseID, categoryID := range categories {
+		result, err := stmt.ExecContext(ctx, categoryID, expenseID, userID)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := result.RowsAffected()
+		changed += int(n)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+func scanCategoryRule(row rowScanner) (*domain.CategoryRule, error) {
+	var rule domain.CategoryRule
+	var ledgerID, noteContains, notePattern, minAmount, maxAmount sql.NullString
+	var weekdays pq.Int64Array
+	if err := row.Scan(&rule.ID, &rule.UserID, &ledgerID, &rule.CategoryID, &rule.CategoryName, &noteContains, &notePattern,
+		&minAmount, &maxAmount, &weekdays, &rule.Priority, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if ledgerID.Valid {
+		rule.LedgerID = &ledgerID.String
+	}
+	rule.NoteContains = noteContains.String
+	rule.NotePattern = notePattern.String
+	var err error
+	if rule.MinAmount, err = moneyPtr(minAmount); err != nil {
+		return nil, err
+	}
+	if rule.MaxAmount, err = moneyPtr(maxAmount); err != nil {
+		return nil, err
+	}
+	for _, day := range weekdays {
+		rule.Weekdays = append(rule.Weekdays, time.Weekday(day))
+	}
+	return &rule, nil
+}
+
+// moneyPtr reads a nullable DECIMAL column; NULL is nil
+func moneyPtr(s sql.NullString) (*domain.Money, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	amount, err := domain.ParseMoney(s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &amount, nil
+}
+
+func nullMoney(m *domain.Money) interface{} {
+	if m == nil {
+		return nil
+	}
+	return *m
+}
+
+// nullWeekdays encodes days of the week for the SMALLINT[] weekdays column
+func nullWeekdays(days []time.Weekday) interface{} {
+	if len(days) == 0 {
+		return nil
+	}
+	ints := make(pq.Int64Array, len(days))
+	for i, day := range days {
+		ints[i] = int64(day)
+	}
+	return ints
+}
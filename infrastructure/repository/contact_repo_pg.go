@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"expense_tracker/domain"
+
+	"github.com/google/uuid"
+)
+
+// ContactRepoPG implements ContactRepository with PostgreSQL
+type ContactRepoPG struct {
+	db *sql.DB
+}
+
+// NewContactRepoPG returns a new PostgreSQL contact repository
+func NewContactRepoPG(db *sql.DB) *ContactRepoPG {
+	return &ContactRepoPG{db: db}
+}
+
+const contactColumns = `id, user_id, name, note, created_at, updated_at`
+
+func (r *ContactRepoPG) Create(ctx context.Context, contact *domain.Contact) error {
+	if contact.ID == "" {
+		contact.ID = uuid.New().String()
+	}
+	query := `INSERT INTO contacts (id, user_id, name, note)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at, updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		contact.ID, contact.UserID, contact.Name, contact.Note,
+	).Scan(&contact.CreatedAt, &contact.UpdatedAt)
+}
+
+func (r *ContactRepoPG) GetByID(ctx context.Context, id, userID string) (*domain.Contact, error) {
+	query := `SELECT ` + contactColumns + ` FROM contacts WHERE id = $1 AND user_id = $2`
+	contact, err := scanContact(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return contact, err
+}
+
+func (r *ContactRepoPG) GetByName(ctx context.Context, userID, name string) (*domain.Contact, error) {
+	query := `SELECT ` + contactColumns + ` FROM contacts WHERE user_id = $1 AND LOWER(name) = LOWER($2)`
+	contact, err := scanContact(r.db.QueryRowContext(ctx, query, userID, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return contact, err
+}
+
+// List returns the user's contacts by name
+func (r *ContactRepoPG) List(ctx context.Context, userID string) ([]*domain.Contact, error) {
+	query := `SELECT ` + contactColumns + ` FROM contacts WHERE user_id = $1 ORDER BY LOWER(name) ASC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := make([]*domain.Contact, 0)
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+	return contacts, rows.Err()
+}
+
+// Update saves the contact and copies its name onto its debts' peer_name in one transaction
+func (r *ContactRepoPG) Update(ctx context.Context, contact *domain.Contact) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `UPDATE contacts SET name = $1, note = $2, updated_at = NOW()
+		WHERE id = $3 AND user_id = $4
+		RETURNING updated_at`,
+		contact.Name, contact.Note, contact.ID, contact.UserID,
+	).Scan(&contact.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE debts SET peer_name = $1
+		WHERE contact_id = $2 AND peer_name <> $1`, contact.Name, contact.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *ContactRepoPG) Delete(ctx context.Context, id, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM contacts WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *ContactRepoPG) CountDebts(ctx context.Context, id string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM debts WHERE contact_id = $1 AND deleted_at IS NULL`, id).Scan(&count)
+	return count, err
+}
+
+// Balances returns one row per currency the contact has debts in, ordered by currency
+func (r *ContactRepoPG) Balances(ctx context.Context, id string) ([]domain.ContactBalance, error) {
+	query := `
+		SELECT currency,
+			COALESCE(SUM(CASE WHEN type = 'lent' THEN amount - paid ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN type = 'borrowed' THEN amount - paid ELSE 0 END), 0)
+		FROM (
+			SELECT type, currency, amount, ` + debtPaidColumn + ` AS paid
+			FROM debts
+			WHERE contact_id = $1 AND deleted_at IS NULL
+		) outstanding
+		GROUP BY currency
+		ORDER BY currency ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := make([]domain.ContactBalance, 0)
+	for rows.Next() {
+		var balance domain.ContactBalance
+		if err := rows.Scan(&balance.Currency, &balance.OwedToYou, &balance.YouOwe); err != nil {
+			return nil, err
+		}
+		balance.Net = balance.OwedToYou.Sub(balance.YouOwe)
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
+
+func scanContact(row rowScanner) (*domain.Contact, error) {
+	var contact domain.Contact
+	var note sql.NullString
+	if err := row.Scan(&contact.ID, &contact.UserID, &contact.Name, &note, &contact.CreatedAt, &contact.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if note.Valid {
+		contact.Note = &note.String
+	}
+	return &contact, nil
+}
+
+// linkContactTx points the debt at the user's contact named debt.PeerName, creating the contact
+// when there is none, within tx. PeerName takes the contact's spelling.
+func linkContactTx(ctx context.Context, tx *sql.Tx, userID string, debt *domain.Debt) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO contacts (id, user_id, name) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, (LOWER(name))) DO NOTHING`, uuid.New().String(), userID, debt.PeerName)
+	if err != nil {
+		return err
+	}
+	var contactID string
+	err = tx.QueryRowContext(ctx, `SELECT id, name FROM contacts WHERE user_id = $1 AND LOWER(name) = LOWER($2)`,
+		userID, debt.PeerName).Scan(&contactID, &debt.PeerName)
+	if err != nil {
+		return err
+	}
+	debt.ContactID = &contactID
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"expense_tracker/domain"
+
+	"github.com/google/uuid"
+)
+
+// DebtPaymentRepoPG implements DebtPaymentRepository with PostgreSQL
+type DebtPaymentRepoPG struct {
+	db *sql.DB
+}
+
+// NewDebtPaymentRepoPG returns a new PostgreSQL debt payment repository
+func NewDebtPaymentRepoPG(db *sql.DB) *DebtPaymentRepoPG {
+	return &DebtPaymentRepoPG{db: db}
+}
+
+// Create records the payment and marks the debt paid once its payments cover its amount, in one
+// transaction holding a lock on the debt. It returns nil, nil without recording anything when
+// the debt is gone or the payment is more than its outstanding balance.
+func (r *DebtPaymentRepoPG) Create(ctx context.Context, payment *domain.DebtPayment) (*domain.Debt, error) {
+	if payment.ID == "" {
+		payment.ID = uuid.New().String()
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var amount, paid domain.Money
+	err = tx.QueryRowContext(ctx, `SELECT amount, `+debtPaidColumn+` FROM debts
+		WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, payment.DebtID).Scan(&amount, &paid)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if payment.Amount.Cmp(amount.Sub(paid)) > 0 {
+		return nil, nil
+	}
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO debt_payments (id, debt_id, amount, paid_date, note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`,
+		payment.ID, payment.DebtID, payment.Amount, payment.PaidDate.Format("2006-01-02"), payment.Note,
+	).Scan(&payment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Always touch the debt so sync clients see the new balance
+	debt, err := scanDebt(tx.QueryRowContext(ctx, `
+		UPDATE debts
+		SET status = CASE WHEN amount <= `+debtPaidColumn+` THEN $2 ELSE status END
+		WHERE id = $1
+		RETURNING id, user_id, type, peer_name, contact_id, expense_id, ledger_id, amount, currency, due_date,
+			reminder_enabled, remind_at, sent_at, status, note, created_at,
+			updated_at, deleted_at, version, `+debtPaidColumn,
+		payment.DebtID, domain.DebtStatusPaid))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return debt, nil
+}
+
+// ListByDebt returns the debt's payments, oldest first
+func (r *DebtPaymentRepoPG) ListByDebt(ctx context.Context, debtID string) ([]*domain.DebtPayment, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, debt_id, amount, paid_date, note, created_at
+		FROM debt_payments WHERE debt_id = $1 ORDER BY paid_date ASC, created_at ASC`, debtID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := make([]*domain.DebtPayment, 0)
+	for rows.Next() {
+		var payment domain.DebtPayment
+		var note sql.NullString
+		if err := rows.Scan(&payment.ID, &payment.DebtID, &payment.Amount, &payment.PaidDate, &note, &payment.CreatedAt); err != nil {
+			return nil, err
+		}
+		if note.Valid {
+			payment.Note = &note.String
+		}
+		payments = append(payments, &payment)
+	}
+	return payments, rows.Err()
+}
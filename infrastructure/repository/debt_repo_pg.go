@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"expense_tracker/domain"
 	pkgrepo "expense_tracker/repository"
+	"slices"
+	"strconv"
 )
 
 type DebtRepositoryPG struct {
@@ -15,17 +17,24 @@ func NewDebtRepositoryPG(db *sql.DB) *DebtRepositoryPG {
 	return &DebtRepositoryPG{DB: db}
 }
 
+// debtPaidColumn selects the sum of a debt's payments; scanDebt derives the balance from it
+const debtPaidColumn = `(SELECT COALESCE(SUM(p.amount), 0) FROM debt_payments p WHERE p.debt_id = debts.id)`
+
 func (r *DebtRepositoryPG) Create(ctx context.Context, debt *domain.Debt) error {
 	query := `
 		INSERT INTO debts (
 			id, user_id, type, peer_name, amount, due_date,
-			reminder_enabled, remind_at, sent_at, status, note
+			reminder_enabled, remind_at, sent_at, status, note, currency, contact_id
 		) VALUES (
 			$1, $2, $3, $4, $5, $6,
-			$7, $8, $9, $10, $11
-		)`
+			$7, $8, $9, $10, $11, ` + currencyOrDefault(12, 2) + `, $13
+		)
+		RETURNING currency, updated_at, version`
+
+	debt.PaidAmount = domain.Money{}
+	debt.Balance = debt.Amount
 
-	_, err := r.DB.ExecContext(
+	return r.DB.QueryRowContext(
 		ctx,
 		query,
 		debt.ID,
@@ -39,8 +48,9 @@ func (r *DebtRepositoryPG) Create(ctx context.Context, debt *domain.Debt) error
 		debt.SentAt,
 		debt.Status,
 		debt.Note,
-	)
-	return err
+		nullStr(debt.Currency),
+		debt.ContactID,
+	).Scan(&debt.Currency, &debt.UpdatedAt, &debt.Version)
 }
 
 func (r *DebtRepositoryPG) Update(ctx context.Context, debt *domain.Debt) error {
@@ -54,11 +64,14 @@ func (r *DebtRepositoryPG) Update(ctx context.Context, debt *domain.Debt) error
 			remind_at = $6,
 			sent_at = $7,
 			status = $8,
-			note = $9
-		WHERE id = $10
+			note = $9,
+			currency = $11,
+			contact_id = $12
+		WHERE id = $10 AND deleted_at IS NULL
+		RETURNING updated_at, version, ` + debtPaidColumn + `
 	`
 
-	_, err := r.DB.ExecContext(
+	err := r.DB.QueryRowContext(
 		ctx,
 		query,
 		debt.Type,
@@ -71,34 +84,71 @@ func (r *DebtRepositoryPG) Update(ctx context.Context, debt *domain.Debt) error
 		debt.Status,
 		debt.Note,
 		debt.ID,
-	)
+		debt.Currency,
+		debt.ContactID,
+	).Scan(&debt.UpdatedAt, &debt.Version, &debt.PaidAmount)
+	debt.Balance = debt.Amount.Sub(debt.PaidAmount)
 	return err
 }
 
 func (r *DebtRepositoryPG) GetByID(ctx context.Context, id string) (*domain.Debt, error) {
 	query := `
-		SELECT id, user_id, type, peer_name, amount, due_date,
-			reminder_enabled, remind_at, sent_at, status, note, created_at
+		SELECT id, user_id, type, peer_name, contact_id, expense_id, ledger_id, amount, currency, due_date,
+			reminder_enabled, remind_at, sent_at, status, note, created_at,
+			updated_at, deleted_at, version, ` + debtPaidColumn + `
 		FROM debts
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	row := r.DB.QueryRowContext(ctx, query, id)
 	return scanDebt(row)
 }
 
+// ListByUser returns the user's debts, soonest due first. With a cursor it returns the page at
+// the cursor, ordered by (due_date, id).
 func (r *DebtRepositoryPG) ListByUser(ctx context.Context, userID string, options pkgrepo.ListOptions) ([]*domain.Debt, int, error) {
-	countQuery := `SELECT COUNT(*) FROM debts WHERE user_id = $1`
 	var total int
-	if err := r.DB.QueryRowContext(ctx, countQuery, userID).Scan(&total); err != nil {
-		return nil, 0, err
+	if !options.SkipTotal {
+		countQuery := `SELECT COUNT(*) FROM debts WHERE user_id = $1 AND deleted_at IS NULL`
+		if err := r.DB.QueryRowContext(ctx, countQuery, userID).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if options.Cursor != nil {
+		where, order, cursorArgs := keysetPage("due_date", false, *options.Cursor, 2)
+		args := append([]interface{}{userID}, cursorArgs...)
+		query := `
+		SELECT id, user_id, type, peer_name, contact_id, expense_id, ledger_id, amount, currency, due_date,
+			reminder_enabled, remind_at, sent_at, status, note, created_at,
+			updated_at, deleted_at, version, ` + debtPaidColumn + `
+		FROM debts
+		WHERE user_id = $1 AND deleted_at IS NULL` + where + `
+		ORDER BY ` + order + `
+		LIMIT $` + strconv.Itoa(len(args)+1)
+		args = append(args, options.Limit)
+
+		rows, err := r.DB.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer rows.Close()
+		items, err := scanDebts(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		if options.Cursor.Backward {
+			slices.Reverse(items)
+		}
+		return items, total, nil
 	}
 
 	query := `
-		SELECT id, user_id, type, peer_name, amount, due_date,
-			reminder_enabled, remind_at, sent_at, status, note, created_at
+		SELECT id, user_id, type, peer_name, contact_id, expense_id, ledger_id, amount, currency, due_date,
+			reminder_enabled, remind_at, sent_at, status, note, created_at,
+			updated_at, deleted_at, version, ` + debtPaidColumn + `
 		FROM debts
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL
 		ORDER BY due_date ASC
 		LIMIT $2 OFFSET $3
 	`
@@ -121,6 +171,7 @@ func (r *DebtRepositoryPG) ListUpcoming(ctx context.Context, userID string, days
 		SELECT COUNT(*)
 		FROM debts
 		WHERE user_id = $1
+			AND deleted_at IS NULL
 			AND status = $2
 			AND due_date >= CURRENT_DATE
 			AND due_date <= CURRENT_DATE + ($3 * INTERVAL '1 day')
@@ -131,10 +182,12 @@ func (r *DebtRepositoryPG) ListUpcoming(ctx context.Context, userID string, days
 	}
 
 	query := `
-		SELECT id, user_id, type, peer_name, amount, due_date,
-			reminder_enabled, remind_at, sent_at, status, note, created_at
+		SELECT id, user_id, type, peer_name, contact_id, expense_id, ledger_id, amount, currency, due_date,
+			reminder_enabled, remind_at, sent_at, status, note, created_at,
+			updated_at, deleted_at, version, ` + debtPaidColumn + `
 		FROM debts
 		WHERE user_id = $1
+			AND deleted_at IS NULL
 			AND status = $2
 			AND due_date >= CURRENT_DATE
 			AND due_date <= CURRENT_DATE + ($3 * INTERVAL '1 day')
@@ -160,9 +213,10 @@ func (r *DebtRepositoryPG) MarkPaid(ctx context.Context, id string) (*domain.Deb
 		UPDATE debts
 		SET status = $1,
 			sent_at = NOW()
-		WHERE id = $2
-		RETURNING id, user_id, type, peer_name, amount, due_date,
-			reminder_enabled, remind_at, sent_at, status, note, created_at
+		WHERE id = $2 AND deleted_at IS NULL
+		RETURNING id, user_id, type, peer_name, contact_id, expense_id, ledger_id, amount, currency, due_date,
+			reminder_enabled, remind_at, sent_at, status, note, created_at,
+			updated_at, deleted_at, version, ` + debtPaidColumn + `
 	`
 
 	row := r.DB.QueryRowContext(ctx, query, domain.DebtStatusPaid, id)
@@ -174,6 +228,7 @@ func (r *DebtRepositoryPG) SetOverdue(ctx context.Context, nowUTC string) (int64
 		UPDATE debts
 		SET status = $1
 		WHERE status = $2
+			AND deleted_at IS NULL
 			AND due_date < $3::date
 	`
 
@@ -185,19 +240,24 @@ func (r *DebtRepositoryPG) SetOverdue(ctx context.Context, nowUTC string) (int64
 	return result.RowsAffected()
 }
 
+// GetDueForReminder returns debts due for a default reminder (3 days before, 1 day before and on
+// the due date). Debts with their own reminder schedule are handled by ReminderRepository.ListDue.
 func (r *DebtRepositoryPG) GetDueForReminder(ctx context.Context, nowUTC string) ([]*domain.Debt, error) {
 	query := `
-		SELECT id, user_id, type, peer_name, amount, due_date,
-			reminder_enabled, remind_at, sent_at, status, note, created_at
+		SELECT id, user_id, type, peer_name, contact_id, expense_id, ledger_id, amount, currency, due_date,
+			reminder_enabled, remind_at, sent_at, status, note, created_at,
+			updated_at, deleted_at, version, ` + debtPaidColumn + `
 		FROM debts
 		WHERE status = $1
+			AND deleted_at IS NULL
 			AND reminder_enabled = TRUE
 			AND (
 				due_date = $2::date
 				OR due_date = ($2::date + INTERVAL '1 day')
 				OR due_date = ($2::date + INTERVAL '3 day')
 			)
-			AND (sent_at IS NULL OR sent_at::date < $2::date)
+			AND (remind_at IS NULL OR remind_at::date < $2::date)
+			AND NOT EXISTS (SELECT 1 FROM reminders WHERE reminders.debt_id = debts.id)
 		ORDER BY due_date ASC
 	`
 
@@ -222,22 +282,77 @@ func (r *DebtRepositoryPG) UpdateReminder(ctx context.Context, id string, remind
 	return err
 }
 
-type debtScanner interface {
+// SetRemindAt records that a reminder was queued without marking it sent;
+// sent_at is only stamped once a notification is actually delivered
+func (r *DebtRepositoryPG) SetRemindAt(ctx context.Context, id string, remindAtUTC string) error {
+	query := `
+		UPDATE debts
+		SET remind_at = $1::timestamp
+		WHERE id = $2
+	`
+
+	_, err := r.DB.ExecContext(ctx, query, remindAtUTC, id)
+	return err
+}
+
+// ListChangedSince returns the user's debts (including tombstones) changed after since by
+// transactions before the watermark, oldest change first (sync change feed)
+func (r *DebtRepositoryPG) ListChangedSince(ctx context.Context, userID string, since domain.SyncPosition, before uint64, limit int) ([]*domain.Debt, error) {
+	where, args := changeFeedWhere(since, before, 2)
+	query := `
+		SELECT id, user_id, type, peer_name, contact_id, expense_id, ledger_id, amount, currency, due_date,
+			reminder_enabled, remind_at, sent_at, status, note, created_at,
+			updated_at, deleted_at, version, ` + debtPaidColumn + `, sync_xid
+		FROM debts
+		WHERE user_id = $1 AND ` + where + `
+		ORDER BY sync_xid, version
+		LIMIT $5
+	`
+
+	rows, err := r.DB.QueryContext(ctx, query, append(append([]interface{}{userID}, args...), limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var debts []*domain.Debt
+	for rows.Next() {
+		var txID uint64
+		debt, err := scanDebt(changeRow{rows: rows, txID: &txID})
+		if err != nil {
+			return nil, err
+		}
+		debt.SyncTxID = txID
+		debts = append(debts, debt)
+	}
+	return debts, rows.Err()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
 	Scan(dest ...any) error
 }
 
-func scanDebt(row debtScanner) (*domain.Debt, error) {
+func scanDebt(row rowScanner) (*domain.Debt, error) {
 	var debt domain.Debt
 	var remindAt sql.NullTime
 	var sentAt sql.NullTime
 	var note sql.NullString
+	var contactID sql.NullString
+	var expenseID sql.NullString
+	var ledgerID sql.NullString
+	var deletedAt sql.NullTime
 
 	if err := row.Scan(
 		&debt.ID,
 		&debt.UserID,
 		&debt.Type,
 		&debt.PeerName,
+		&contactID,
+		&expenseID,
+		&ledgerID,
 		&debt.Amount,
+		&debt.Currency,
 		&debt.DueDate,
 		&debt.ReminderEnabled,
 		&remindAt,
@@ -245,9 +360,14 @@ func scanDebt(row debtScanner) (*domain.Debt, error) {
 		&debt.Status,
 		&note,
 		&debt.CreatedAt,
+		&debt.UpdatedAt,
+		&deletedAt,
+		&debt.Version,
+		&debt.PaidAmount,
 	); err != nil {
 		return nil, err
 	}
+	debt.Balance = debt.Amount.Sub(debt.PaidAmount)
 
 	if remindAt.Valid {
 		debt.RemindAt = &remindAt.Time
@@ -258,6 +378,18 @@ func scanDebt(row debtScanner) (*domain.Debt, error) {
 	if note.Valid {
 		debt.Note = &note.String
 	}
+	if contactID.Valid {
+		debt.ContactID = &contactID.String
+	}
+	if expenseID.Valid {
+		debt.ExpenseID = &expenseID.String
+	}
+	if ledgerID.Valid {
+		debt.LedgerID = &ledgerID.String
+	}
+	if deletedAt.Valid {
+		debt.DeletedAt = &deletedAt.Time
+	}
 
 	return &debt, nil
 }
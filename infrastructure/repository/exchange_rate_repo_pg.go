@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"expense_tracker/domain"
+
+	"github.com/google/uuid"
+)
+
+// ExchangeRateRepoPG implements ExchangeRateRepository with PostgreSQL
+type ExchangeRateRepoPG struct {
+	db *sql.DB
+}
+
+// NewExchangeRateRepoPG returns a new PostgreSQL exchange rate repository
+func NewExchangeRateRepoPG(db *sql.DB) *ExchangeRateRepoPG {
+	return &ExchangeRateRepoPG{db: db}
+}
+
+// Upsert stores the rates in one transaction, replacing any rate already stored for the same
+// pair and date. It returns the number of rates written.
+func (r *ExchangeRateRepoPG) Upsert(ctx context.Context, rates []domain.ExchangeRate) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO exchange_rates (base_currency, quote_currency, rate_date, rate)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (base_currency, quote_currency, rate_date) DO UPDATE SET rate = EXCLUDED.rate, updated_at = NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.ExecContext(ctx, rate.Base, rate.Quote, rate.Date.Format("2006-01-02"), rate.Rate); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// List returns the stored rates matching the filter, newest first
+func (r *ExchangeRateRepoPG) List(ctx context.Context, filter domain.ExchangeRateFilter) ([]*domain.ExchangeRate, int, error) {
+	baseWhere := ` FROM exchange_rates WHERE TRUE`
+	args := []interface{}{}
+	pos := 1
+	if filter.Base != "" {
+		baseWhere += ` AND base_currency = $` + strconv.Itoa(pos)
+		args = append(args, filter.Base)
+		pos++
+	}
+	if filter.Quote != "" {
+		baseWhere += ` AND quote_currency = $` + strconv.Itoa(pos)
+		args = append(args, filter.Quote)
+		pos++
+	}
+	if filter.FromDate != nil {
+		baseWhere += ` AND rate_date >= $` + strconv.Itoa(pos)
+		args = append(args, filter.FromDate.Format("2006-01-02"))
+		pos++
+	}
+	if filter.ToDate != nil {
+		baseWhere += ` AND rate_date <= $` + strconv.Itoa(pos)
+		args = append(args, filter.ToDate.Format("2006-01-02"))
+		pos++
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*)`+baseWhere, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT base_currency, quote_currency, rate, rate_date, updated_at` + baseWhere +
+		` ORDER BY rate_date DESC, base_currency, quote_currency LIMIT $` + strconv.Itoa(pos) +
+		` OFFSET $` + strconv.Itoa(pos+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	list := make([]*domain.ExchangeRate, 0)
+	for rows.Next() {
+		var rate domain.ExchangeRate
+		if err := rows.Scan(&rate.Base, &rate.Quote, &rate.Rate, &rate.Date, &rate.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+		list = append(list, &rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// MissingRates returns, per kind and currency, the user's amounts dated in the inclusive range
+// that convert_currency cannot convert into the user's default currency. The filters match the
+// report sums, which leave these amounts out.
+func (r *ExchangeRateRepoPG) MissingRates(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]domain.MissingRate, error) {
+	query := `SELECT x.kind, x.currency, COUNT(*), SUM(x.amount)
+		FROM (
+			SELECT 'expense' AS kind, e.currency, e.amount, e.expense_date AS on_date FROM expenses e
+				WHERE e.user_id = $1 AND e.deleted_at IS NULL AND e.expense_date BETWEEN $2 AND $3
+			UNION ALL
+			SELECT 'income', i.currency, i.amount, i.received_date FROM incomes i
+				WHERE i.user_id = $1 AND i.received_date BETWEEN $2 AND $3
+			UNION ALL
+			SELECT d.type, d.currency, d.amount, d.due_date FROM debts d
+				WHERE d.user_id = $1 AND d.deleted_at IS NULL AND d.due_date BETWEEN $2 AND $3
+			UNION ALL
+			SELECT d.type || '_repaid', d.currency, p.amount, p.paid_date FROM debt_payments p JOIN debts d ON d.id = p.debt_id
+				WHERE d.user_id = $1 AND d.deleted_at IS NULL AND p.paid_date BETWEEN $2 AND $3
+		) x JOIN users u ON u.user_id = $1
+		WHERE convert_currency(x.amount, x.currency, u.default_currency, x.on_date) IS NULL
+		GROUP BY x.kind, x.currency ORDER BY x.kind, x.currency`
+	rows, err := r.db.QueryContext(ctx, query, userID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	missing := make([]domain.MissingRate, 0)
+	for rows.Next() {
+		var m domain.MissingRate
+		if err := rows.Scan(&m.Kind, &m.Currency, &m.Count, &m.Amount); err != nil {
+			return nil, err
+		}
+		missing = append(missing, m)
+	}
+	return missing, rows.Err()
+}
@@ -3,17 +3,47 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"expense_tracker/domain"
 	pkgrepo "expense_tracker/repository"
+	"log"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const expenseColumns = `id, user_id, amount, currency, category_id, is_recurring, recurrence_type, recurrence_rule,
+	next_due_date, recurrence_start, recurrence_parent_id, reminder_enabled, reminder_sent_at,
+	note, expense_date, created_at, updated_at, deleted_at, version, ledger_id`
+
+// insertExpenseQuery inserts one expense; insertExpenseArgs builds its arguments
+var insertExpenseQuery = `INSERT INTO expenses (
+		id, user_id, amount, category_id, is_recurring, recurrence_type, recurrence_rule,
+		next_due_date, recurrence_start, reminder_enabled, note, expense_date, created_at, currency, ledger_id
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, ` + currencyOrDefault(14, 2) + `, $15)`
+
+// insertExpenseArgs returns the arguments of insertExpenseQuery for input
+func insertExpenseArgs(id string, input domain.CreateExpenseInput, createdAt time.Time) []interface{} {
+	var categoryID interface{}
+	if input.CategoryID != nil {
+		categoryID = *input.CategoryID
+	}
+	return []interface{}{
+		id, input.UserID, input.Amount, categoryID,
+		input.IsRecurring, string(input.RecurrenceType), nullRule(input.RecurrenceRule),
+		nullDate(input.NextDueDate), nullDate(input.RecurrenceStart),
+		input.ReminderEnabled, nullStr(input.Note), input.ExpenseDate.Format("2006-01-02"), createdAt,
+		nullStr(input.Currency), nullStrPtr(input.LedgerID),
+	}
+}
+
 // ExpenseRepoPG implements ExpenseRepository with PostgreSQL
 type ExpenseRepoPG struct {
-	db *sql.DB
+	db    *sql.DB
+	blobs pkgrepo.BlobStore
 }
 
 // NewExpenseRepoPG returns a new PostgreSQL expense repository
@@ -21,6 +51,11 @@ func NewExpenseRepoPG(db *sql.DB) *ExpenseRepoPG {
 	return &ExpenseRepoPG{db: db}
 }
 
+// SetBlobStore makes Delete remove the content of the deleted expense's attachments from blobs
+func (r *ExpenseRepoPG) SetBlobStore(blobs pkgrepo.BlobStore) {
+	r.blobs = blobs
+}
+
 func (r *ExpenseRepoPG) Create(ctx context.Context, input domain.CreateExpenseInput) (*domain.Expense, error) {
 	expenseID := input.ID
 	if expenseID == "" {
@@ -28,91 +63,121 @@ func (r *ExpenseRepoPG) Create(ctx context.Context, input domain.CreateExpenseIn
 	}
 	now := time.Now().UTC()
 
-	var categoryID interface{}
-	if input.CategoryID != nil {
-		categoryID = *input.CategoryID
-	} else {
-		categoryID = nil
-	}
-	var nextDue interface{}
-	if input.NextDueDate != nil {
-		nextDue = input.NextDueDate.Format("2006-01-02")
-	} else {
-		nextDue = nil
-	}
-
-	query := `INSERT INTO expenses (
-		id, user_id, amount, category_id, is_recurring, recurrence_type,
-		next_due_date, reminder_enabled, note, expense_date, created_at
-	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
-	_, err := r.db.ExecContext(ctx, query,
-		expenseID, input.UserID, input.Amount, categoryID,
-		input.IsRecurring, string(input.RecurrenceType), nextDue,
-		input.ReminderEnabled, nullStr(input.Note), input.ExpenseDate.Format("2006-01-02"), now,
-	)
+	query := insertExpenseQuery + ` RETURNING currency, updated_at, version`
+	var currency string
+	var updatedAt time.Time
+	var version int64
+	err := r.db.QueryRowContext(ctx, query, insertExpenseArgs(expenseID, input, now)...).Scan(&currency, &updatedAt, &version)
 	if err != nil {
 		return nil, err
 	}
 
+	return newExpense(expenseID, input, currency, now, updatedAt, version), nil
+}
+
+// newExpense is the expense stored for input by insertExpenseQuery
+func newExpense(id string, input domain.CreateExpenseInput, currency string, createdAt, updatedAt time.Time, version int64) *domain.Expense {
 	return &domain.Expense{
-		ID:              expenseID,
+		ID:              id,
 		UserID:          input.UserID,
+		LedgerID:        input.LedgerID,
 		Amount:          input.Amount,
+		Currency:        currency,
 		CategoryID:      input.CategoryID,
 		IsRecurring:     input.IsRecurring,
 		RecurrenceType:  input.RecurrenceType,
+		RecurrenceRule:  input.RecurrenceRule,
 		NextDueDate:     input.NextDueDate,
+		RecurrenceStart: input.RecurrenceStart,
 		ReminderEnabled: input.ReminderEnabled,
 		Note:            input.Note,
 		ExpenseDate:     input.ExpenseDate,
-		CreatedAt:       now,
-	}, nil
+		CreatedAt:       createdAt,
+		UpdatedAt:       updatedAt,
+		Version:         version,
+	}
 }
 
+// GetByID returns the expense if it is the user's own or belongs to one of the user's ledgers
 func (r *ExpenseRepoPG) GetByID(ctx context.Context, id, userID string) (*domain.Expense, error) {
-	query := `SELECT id, user_id, amount, category_id, is_recurring, recurrence_type,
-		next_due_date, reminder_enabled, reminder_sent_at, note, expense_date, created_at
-		FROM expenses WHERE id = $1 AND user_id = $2`
-	row := r.db.QueryRowContext(ctx, query, id, userID)
-	var e domain.Expense
-	var catID sql.NullString
-	var nextDue, expDate sql.NullTime
-	var remSent sql.NullTime
-	var note sql.NullString
-	var recType sql.NullString
-	err := row.Scan(
-		&e.ID, &e.UserID, &e.Amount, &catID, &e.IsRecurring, &recType,
-		&nextDue, &e.ReminderEnabled, &remSent, &note, &expDate, &e.CreatedAt,
-	)
+	query := `SELECT ` + expenseColumns + `
+		FROM expenses WHERE id = $1 AND ` + readableBy("", 2) + ` AND deleted_at IS NULL`
+	e, err := scanExpense(r.db.QueryRowContext(ctx, query, id, userID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if err != nil {
-		return nil, err
-	}
-	if catID.Valid {
-		e.CategoryID = &catID.String
-	}
-	if nextDue.Valid {
-		e.NextDueDate = &nextDue.Time
+	return e, err
+}
+
+// List returns the user's own expenses, or the expenses of filter.LedgerID when the user is a
+// member of that ledger. With a cursor it returns the page at the cursor, ordered by
+// (expense_date, id) newest first.
+func (r *ExpenseRepoPG) List(ctx context.Context, filter domain.ExpenseFilter) ([]*domain.Expense, int, error) {
+	baseWhere, args := expenseFilterWhere(filter)
+	pos := len(args) + 1
+
+	var total int
+	if !filter.SkipTotal {
+		countQuery := `SELECT COUNT(*)` + baseWhere
+		if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
 	}
-	e.ExpenseDate = expDate.Time
-	if remSent.Valid {
-		e.ReminderSentAt = &remSent.Time
+
+	if filter.Cursor != nil {
+		where, order, cursorArgs := keysetPage("expense_date", true, *filter.Cursor, pos)
+		args = append(args, cursorArgs...)
+		query := `SELECT ` + expenseColumns +
+			baseWhere + where +
+			` ORDER BY ` + order + ` LIMIT $` + strconv.Itoa(len(args)+1)
+		args = append(args, filter.Limit)
+
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer rows.Close()
+		items, err := scanExpenses(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		if filter.Cursor.Backward {
+			slices.Reverse(items)
+		}
+		return items, total, nil
 	}
-	if note.Valid {
-		e.Note = note.String
+
+	query := `SELECT ` + expenseColumns +
+		baseWhere +
+		` ORDER BY expense_date DESC, created_at DESC LIMIT $` + strconv.Itoa(pos) +
+		` OFFSET $` + strconv.Itoa(pos+1)
+
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
 	}
-	if recType.Valid {
-		e.RecurrenceType = domain.RecurrenceType(recType.String)
+	defer rows.Close()
+	items, err := scanExpenses(rows)
+	if err != nil {
+		return nil, 0, err
 	}
-	return &e, nil
+	return items, total, nil
 }
 
-func (r *ExpenseRepoPG) List(ctx context.Context, filter domain.ExpenseFilter) ([]*domain.Expense, int, error) {
-	baseWhere := ` FROM expenses WHERE user_id = $1`
+// expenseFilterWhere returns the FROM and WHERE clauses selecting the expenses filter matches
+// (ignoring Limit and Offset) with their arguments
+func expenseFilterWhere(filter domain.ExpenseFilter) (string, []interface{}) {
+	baseWhere := ` FROM expenses WHERE user_id = $1 AND ledger_id IS NULL AND deleted_at IS NULL`
 	args := []interface{}{filter.UserID}
 	pos := 2
+	if filter.LedgerID != nil {
+		baseWhere = ` FROM expenses WHERE ledger_id = $2 AND deleted_at IS NULL
+			AND EXISTS (SELECT 1 FROM ledger_members m WHERE m.ledger_id = $2 AND m.user_id = $1)`
+		args = append(args, *filter.LedgerID)
+		pos++
+	}
 	if filter.CategoryID != nil {
 		baseWhere += ` AND category_id = $` + strconv.Itoa(pos)
 		args = append(args, *filter.CategoryID)
@@ -126,35 +191,150 @@ func (r *ExpenseRepoPG) List(ctx context.Context, filter domain.ExpenseFilter) (
 	if filter.ToDate != nil {
 		baseWhere += ` AND expense_date <= $` + strconv.Itoa(pos)
 		args = append(args, filter.ToDate.Format("2006-01-02"))
-		pos++
 	}
+	return baseWhere, args
+}
+
+// Search returns a page of the expenses search matches, how many there are and the facet counts.
+// Query uses web search syntax ("quoted phrases", or, -word) and matches words of the note or the
+// category name.
+func (r *ExpenseRepoPG) Search(ctx context.Context, search domain.ExpenseSearch) ([]*domain.Expense, int, *domain.ExpenseFacets, error) {
+	baseWhere, args := expenseSearchWhere(search)
+	pos := len(args) + 1
 
-	countQuery := `SELECT COUNT(*)` + baseWhere
 	var total int
-	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return nil, 0, err
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*)`+baseWhere, args...).Scan(&total); err != nil {
+		return nil, 0, nil, err
 	}
 
-	query := `SELECT id, user_id, amount, category_id, is_recurring, recurrence_type,
-		next_due_date, reminder_enabled, reminder_sent_at, note, expense_date, created_at` +
+	direction := ` DESC`
+	if search.Ascending {
+		direction = ` ASC`
+	}
+	order := `expense_date` + direction + `, created_at` + direction
+	if search.Sort == domain.ExpenseSortAmount {
+		order = `amount` + direction + `, ` + order
+	}
+	query := `SELECT ` + expenseColumns +
 		baseWhere +
-		` ORDER BY expense_date DESC, created_at DESC LIMIT $` + strconv.Itoa(pos) +
+		` ORDER BY ` + order + ` LIMIT $` + strconv.Itoa(pos) +
 		` OFFSET $` + strconv.Itoa(pos+1)
-
-	args = append(args, filter.Limit, filter.Offset)
+	args = append(args, search.Limit, search.Offset)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, nil, err
 	}
 	defer rows.Close()
 	items, err := scanExpenses(rows)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, nil, err
 	}
-	return items, total, nil
+
+	facets, err := r.searchFacets(ctx, search)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return items, total, facets, nil
 }
 
+// searchFacets counts the matches of search by category, recurring and attachment, each without
+// its own filter
+func (r *ExpenseRepoPG) searchFacets(ctx context.Context, search domain.ExpenseSearch) (*domain.ExpenseFacets, error) {
+	facets := &domain.ExpenseFacets{Categories: []domain.CategoryFacet{}}
+
+	anyCategory := search
+	anyCategory.CategoryIDs = nil
+	baseWhere, args := expenseSearchWhere(anyCategory)
+	rows, err := r.db.QueryContext(ctx, `SELECT f.category_id, COALESCE(c.name, ''), f.n
+		FROM (SELECT category_id, COUNT(*) AS n`+baseWhere+` GROUP BY category_id) f
+		LEFT JOIN categories c ON c.id = f.category_id
+		ORDER BY f.n DESC, c.name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var categoryID sql.NullString
+		var facet domain.CategoryFacet
+		if err := rows.Scan(&categoryID, &facet.CategoryName, &facet.Count); err != nil {
+			return nil, err
+		}
+		if categoryID.Valid {
+			facet.CategoryID = &categoryID.String
+		}
+		facets.Categories = append(facets.Categories, facet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	anyRecurrence := search
+	anyRecurrence.RecurringOnly = false
+	baseWhere, args = expenseSearchWhere(anyRecurrence)
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*)`+baseWhere+` AND `+recurringExpense, args...).Scan(&facets.Recurring); err != nil {
+		return nil, err
+	}
+
+	anyAttachment := search
+	anyAttachment.HasAttachment = nil
+	baseWhere, args = expenseSearchWhere(anyAttachment)
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*)`+baseWhere+` AND `+attachedExpense, args...).Scan(&facets.HasAttachment); err != nil {
+		return nil, err
+	}
+	return facets, nil
+}
+
+// recurringExpense matches recurring templates and the occurrences generated from them
+const recurringExpense = `(is_recurring OR recurrence_parent_id IS NOT NULL)`
+
+// attachedExpense matches expenses with at least one attachment
+const attachedExpense = `EXISTS (SELECT 1 FROM expense_attachments a WHERE a.expense_id = expenses.id)`
+
+// expenseSearchWhere returns the FROM and WHERE clauses selecting the expenses search matches
+// (ignoring Sort, Limit and Offset) with their arguments
+func expenseSearchWhere(search domain.ExpenseSearch) (string, []interface{}) {
+	baseWhere, args := expenseFilterWhere(domain.ExpenseFilter{
+		UserID:   search.UserID,
+		LedgerID: search.LedgerID,
+		FromDate: search.FromDate,
+		ToDate:   search.ToDate,
+	})
+	pos := len(args) + 1
+	if search.Query != "" {
+		baseWhere += ` AND to_tsvector('simple', COALESCE(note, '') || ' ' || COALESCE(` + expenseCategoryNameColumn + `, ''))
+			@@ websearch_to_tsquery('simple', $` + strconv.Itoa(pos) + `)`
+		args = append(args, search.Query)
+		pos++
+	}
+	if len(search.CategoryIDs) > 0 {
+		baseWhere += ` AND category_id = ANY($` + strconv.Itoa(pos) + `)`
+		args = append(args, pq.Array(search.CategoryIDs))
+		pos++
+	}
+	if search.MinAmount != nil {
+		baseWhere += ` AND amount >= $` + strconv.Itoa(pos)
+		args = append(args, *search.MinAmount)
+		pos++
+	}
+	if search.MaxAmount != nil {
+		baseWhere += ` AND amount <= $` + strconv.Itoa(pos)
+		args = append(args, *search.MaxAmount)
+	}
+	if search.RecurringOnly {
+		baseWhere += ` AND ` + recurringExpense
+	}
+	if search.HasAttachment != nil {
+		if *search.HasAttachment {
+			baseWhere += ` AND ` + attachedExpense
+		} else {
+			baseWhere += ` AND NOT ` + attachedExpense
+		}
+	}
+	return baseWhere, args
+}
+
+// Update changes the user's own expense or one in a ledger where the user is not a viewer
 func (r *ExpenseRepoPG) Update(ctx context.Context, id, userID string, input domain.UpdateExpenseInput) (*domain.Expense, error) {
 	// Fetch existing for ownership and to merge
 	existing, err := r.GetByID(ctx, id, userID)
@@ -166,6 +346,10 @@ func (r *ExpenseRepoPG) Update(ctx context.Context, id, userID string, input dom
 	if input.Amount != nil {
 		amount = *input.Amount
 	}
+	currency := existing.Currency
+	if input.Currency != nil {
+		currency = *input.Currency
+	}
 	catID := existing.CategoryID
 	if input.CategoryID != nil {
 		catID = input.CategoryID
@@ -178,10 +362,18 @@ func (r *ExpenseRepoPG) Update(ctx context.Context, id, userID string, input dom
 	if input.RecurrenceType != nil {
 		recType = *input.RecurrenceType
 	}
+	rule := existing.RecurrenceRule
+	if input.RecurrenceRule != nil {
+		rule = input.RecurrenceRule
+	}
 	nextDue := existing.NextDueDate
 	if input.NextDueDate != nil {
 		nextDue = input.NextDueDate
 	}
+	recStart := existing.RecurrenceStart
+	if input.RecurrenceStart != nil {
+		recStart = input.RecurrenceStart
+	}
 	remEnabled := existing.ReminderEnabled
 	if input.ReminderEnabled != nil {
 		remEnabled = *input.ReminderEnabled
@@ -201,38 +393,49 @@ func (r *ExpenseRepoPG) Update(ctx context.Context, id, userID string, input dom
 	} else {
 		categoryID = nil
 	}
-	var nextDueVal interface{}
-	if nextDue != nil {
-		nextDueVal = nextDue.Format("2006-01-02")
-	} else {
-		nextDueVal = nil
-	}
-
 	query := `UPDATE expenses SET
-		amount = $1, category_id = $2, is_recurring = $3, recurrence_type = $4,
-		next_due_date = $5, reminder_enabled = $6, note = $7, expense_date = $8
-		WHERE id = $9 AND user_id = $10`
-	_, err = r.db.ExecContext(ctx, query,
-		amount, categoryID, isRecurring, string(recType), nextDueVal,
-		remEnabled, nullStr(note), expDate.Format("2006-01-02"), id, userID,
-	)
+		amount = $1, category_id = $2, is_recurring = $3, recurrence_type = $4, recurrence_rule = $5,
+		next_due_date = $6, recurrence_start = $7, reminder_enabled = $8, note = $9, expense_date = $10,
+		currency = $13
+		WHERE id = $11 AND ` + writableBy("", 12) + ` AND deleted_at IS NULL
+		RETURNING updated_at, version`
+	err = r.db.QueryRowContext(ctx, query,
+		amount, categoryID, isRecurring, string(recType), nullRule(rule), nullDate(nextDue), nullDate(recStart),
+		remEnabled, nullStr(note), expDate.Format("2006-01-02"), id, userID, currency,
+	).Scan(&existing.UpdatedAt, &existing.Version)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 	existing.Amount = amount
+	existing.Currency = currency
 	existing.CategoryID = catID
 	existing.IsRecurring = isRecurring
 	existing.RecurrenceType = recType
+	existing.RecurrenceRule = rule
 	existing.NextDueDate = nextDue
+	existing.RecurrenceStart = recStart
 	existing.ReminderEnabled = remEnabled
 	existing.Note = note
 	existing.ExpenseDate = expDate
 	return existing, nil
 }
 
+// Delete soft-deletes the expense, leaving a tombstone so syncing clients learn about the deletion.
+// Ledger expenses can be deleted by the ledger's owners and editors. The expense's attachments
+// are removed with it; their content is removed from the blob store once the deletion is
+// committed, and a blob that cannot be removed is only logged.
 func (r *ExpenseRepoPG) Delete(ctx context.Context, id, userID string) error {
-	query := `DELETE FROM expenses WHERE id = $1 AND user_id = $2`
-	result, err := r.db.ExecContext(ctx, query, id, userID)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE expenses SET deleted_at = NOW() WHERE id = $1 AND ` + writableBy("", 2) + ` AND deleted_at IS NULL`
+	result, err := tx.ExecContext(ctx, query, id, userID)
 	if err != nil {
 		return err
 	}
@@ -240,28 +443,237 @@ func (r *ExpenseRepoPG) Delete(ctx context.Context, id, userID string) error {
 	if rows == 0 {
 		return sql.ErrNoRows
 	}
+
+	keyRows, err := tx.QueryContext(ctx, `DELETE FROM expense_attachments WHERE expense_id = $1 RETURNING storage_key`, id)
+	if err != nil {
+		return err
+	}
+	var keys []string
+	for keyRows.Next() {
+		var key string
+		if err := keyRows.Scan(&key); err != nil {
+			keyRows.Close()
+			return err
+		}
+		keys = append(keys, key)
+	}
+	keyRows.Close()
+	if err := keyRows.Err(); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if r.blobs != nil {
+		for _, key := range keys {
+			if err := r.blobs.Delete(ctx, key); err != nil {
+				log.Printf("attachments: failed to remove %s of deleted expense %s: %v", key, id, err)
+			}
+		}
+	}
 	return nil
 }
 
-// SumByDateRange returns total expense amount for the user in the date range (report usecase)
-func (r *ExpenseRepoPG) SumByDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) (float64, error) {
-	query := `SELECT COALESCE(SUM(amount), 0) FROM expenses WHERE user_id = $1 AND expense_date >= $2 AND expense_date <= $3`
-	var total sql.NullFloat64
-	if err := r.db.QueryRowContext(ctx, query, userID.String(), startDate, endDate).Scan(&total); err != nil {
-		return 0, err
+// GetByIDs returns the expenses with the given IDs regardless of owner or deletion (sync uses it
+// to detect client UUIDs that already exist, including ones owned by another user)
+func (r *ExpenseRepoPG) GetByIDs(ctx context.Context, ids []string) ([]*domain.Expense, error) {
+	if len(ids) == 0 {
+		return nil, nil
 	}
-	if !total.Valid {
+	query := `SELECT ` + expenseColumns + `
+		FROM expenses WHERE id = ANY($1::uuid[])`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanExpenses(rows)
+}
+
+// CreateBatch inserts all inputs in a single transaction. Rows whose ID already exists are left
+// untouched; the IDs that were actually inserted are returned.
+func (r *ExpenseRepoPG) CreateBatch(ctx context.Context, inputs []domain.CreateExpenseInput) ([]string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := insertExpenseQuery + ` ON CONFLICT (id) DO NOTHING`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	inserted := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		result, err := stmt.ExecContext(ctx, insertExpenseArgs(input.ID, input, now)...)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			inserted = append(inserted, input.ID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return inserted, nil
+}
+
+// ListRecurringDueForReminder returns recurring expenses with reminders enabled whose next
+// occurrence is today or tomorrow
+func (r *ExpenseRepoPG) ListRecurringDueForReminder(ctx context.Context, nowUTC string) ([]*domain.Expense, error) {
+	query := `SELECT ` + expenseColumns + `
+		FROM expenses
+		WHERE is_recurring = TRUE
+			AND reminder_enabled = TRUE
+			AND deleted_at IS NULL
+			AND next_due_date BETWEEN $1::date AND ($1::date + INTERVAL '1 day')
+		ORDER BY next_due_date ASC`
+	rows, err := r.db.QueryContext(ctx, query, nowUTC)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanExpenses(rows)
+}
+
+func (r *ExpenseRepoPG) MarkReminderSent(ctx context.Context, id string, sentAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE expenses SET reminder_sent_at = $1 WHERE id = $2`, sentAt, id)
+	return err
+}
+
+// ListRecurringDue returns recurring templates (any user) whose next occurrence is on or before nowUTC
+func (r *ExpenseRepoPG) ListRecurringDue(ctx context.Context, nowUTC string, limit int) ([]*domain.Expense, error) {
+	query := `SELECT ` + expenseColumns + `
+		FROM expenses
+		WHERE is_recurring = TRUE
+			AND deleted_at IS NULL
+			AND next_due_date IS NOT NULL
+			AND next_due_date <= $1::date
+		ORDER BY next_due_date ASC
+		LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, nowUTC, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanExpenses(rows)
+}
+
+// MaterializeOccurrences inserts one expense per date, linked to the template, and advances the
+// template's next_due_date to nextDue (nil once the series has ended) in the same transaction. The advance only applies while
+// next_due_date still holds the value the caller read, so concurrent runs cannot double-insert;
+// dates already generated are skipped by the (recurrence_parent_id, expense_date) unique index.
+// It returns the number of occurrences inserted.
+func (r *ExpenseRepoPG) MaterializeOccurrences(ctx context.Context, template *domain.Expense, dates []time.Time, nextDue *time.Time) (int, error) {
+	if template.NextDueDate == nil {
 		return 0, nil
 	}
-	return total.Float64, nil
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE expenses SET next_due_date = $1
+		WHERE id = $2 AND next_due_date = $3 AND is_recurring = TRUE AND deleted_at IS NULL`,
+		nullDate(nextDue), template.ID, template.NextDueDate.Format("2006-01-02"),
+	)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return 0, nil // advanced by another run, or no longer recurring
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO expenses (
+		id, user_id, amount, currency, category_id, recurrence_parent_id, note, expense_date, created_at, ledger_id
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	ON CONFLICT (recurrence_parent_id, expense_date) WHERE recurrence_parent_id IS NOT NULL DO NOTHING`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var categoryID interface{}
+	if template.CategoryID != nil {
+		categoryID = *template.CategoryID
+	}
+	now := time.Now().UTC()
+	inserted := 0
+	for _, date := range dates {
+		result, err := stmt.ExecContext(ctx,
+			uuid.New().String(), template.UserID, template.Amount, template.Currency, categoryID, template.ID,
+			nullStr(template.Note), date.Format("2006-01-02"), now, nullStrPtr(template.LedgerID),
+		)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			inserted++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+// ListChangedSince returns the user's own expenses (including tombstones, excluding ledger
+// expenses) changed after since by transactions before the watermark, oldest change first
+// (sync change feed)
+func (r *ExpenseRepoPG) ListChangedSince(ctx context.Context, userID string, since domain.SyncPosition, before uint64, limit int) ([]*domain.Expense, error) {
+	where, args := changeFeedWhere(since, before, 2)
+	query := `SELECT ` + expenseColumns + `, sync_xid
+		FROM expenses WHERE user_id = $1 AND ledger_id IS NULL AND ` + where + `
+		ORDER BY sync_xid, version LIMIT $5`
+	rows, err := r.db.QueryContext(ctx, query, append(append([]interface{}{userID}, args...), limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*domain.Expense
+	for rows.Next() {
+		var txID uint64
+		e, err := scanExpense(changeRow{rows: rows, txID: &txID})
+		if err != nil {
+			return nil, err
+		}
+		e.SyncTxID = txID
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// SumByDateRange returns total expense amount for the user in the date range, converted into the
+// user's default currency as of each expense date (report usecase)
+func (r *ExpenseRepoPG) SumByDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) (domain.Money, error) {
+	query := `SELECT COALESCE(SUM(convert_currency(e.amount, e.currency, u.default_currency, e.expense_date)), 0)
+		FROM expenses e JOIN users u ON u.user_id = e.user_id
+		WHERE e.user_id = $1 AND e.deleted_at IS NULL AND e.expense_date >= $2 AND e.expense_date <= $3`
+	var total domain.Money
+	if err := r.db.QueryRowContext(ctx, query, userID.String(), startDate, endDate).Scan(&total); err != nil {
+		return domain.Money{}, err
+	}
+	return total, nil
 }
 
-// CategoryBreakdownByDateRange returns per-category totals for the user in the date range (report usecase)
+// CategoryBreakdownByDateRange returns per-category totals for the user in the date range, converted
+// into the user's default currency (report usecase)
 func (r *ExpenseRepoPG) CategoryBreakdownByDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]pkgrepo.CategoryTotal, error) {
-	query := `SELECT COALESCE(c.name, 'Uncategorized') AS category_name, COALESCE(SUM(e.amount), 0) AS total
-		FROM expenses e LEFT JOIN categories c ON e.category_id = c.id
-		WHERE e.user_id = $1 AND e.expense_date >= $2 AND e.expense_date <= $3
-		GROUP BY category_name ORDER BY total DESC`
+	query := `SELECT e.category_id, COALESCE(c.name, 'Uncategorized') AS category_name,
+			COALESCE(SUM(convert_currency(e.amount, e.currency, u.default_currency, e.expense_date)), 0) AS total
+		FROM expenses e JOIN users u ON u.user_id = e.user_id LEFT JOIN categories c ON e.category_id = c.id
+		WHERE e.user_id = $1 AND e.deleted_at IS NULL AND e.expense_date >= $2 AND e.expense_date <= $3
+		GROUP BY e.category_id, category_name ORDER BY total DESC`
 	rows, err := r.db.QueryContext(ctx, query, userID.String(), startDate, endDate)
 	if err != nil {
 		return nil, err
@@ -269,60 +681,115 @@ func (r *ExpenseRepoPG) CategoryBreakdownByDateRange(ctx context.Context, userID
 	defer rows.Close()
 	var results []pkgrepo.CategoryTotal
 	for rows.Next() {
+		var categoryID sql.NullString
 		var name string
-		var total sql.NullFloat64
-		if err := rows.Scan(&name, &total); err != nil {
+		var total domain.Money
+		if err := rows.Scan(&categoryID, &name, &total); err != nil {
 			return nil, err
 		}
-		t := 0.0
-		if total.Valid {
-			t = total.Float64
+		item := pkgrepo.CategoryTotal{CategoryName: name, Total: total}
+		if categoryID.Valid {
+			item.CategoryID = &categoryID.String
 		}
-		results = append(results, pkgrepo.CategoryTotal{CategoryName: name, Total: t})
+		results = append(results, item)
 	}
 	return results, rows.Err()
 }
 
+func scanExpense(row rowScanner) (*domain.Expense, error) {
+	var e domain.Expense
+	var catID, parentID, ledgerID sql.NullString
+	var nextDue, recStart, expDate sql.NullTime
+	var remSent sql.NullTime
+	var note sql.NullString
+	var recType sql.NullString
+	var rule []byte
+	var deletedAt sql.NullTime
+	err := row.Scan(
+		&e.ID, &e.UserID, &e.Amount, &e.Currency, &catID, &e.IsRecurring, &recType, &rule,
+		&nextDue, &recStart, &parentID, &e.ReminderEnabled, &remSent,
+		&note, &expDate, &e.CreatedAt, &e.UpdatedAt, &deletedAt, &e.Version, &ledgerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if catID.Valid {
+		e.CategoryID = &catID.String
+	}
+	if ledgerID.Valid {
+		e.LedgerID = &ledgerID.String
+	}
+	if nextDue.Valid {
+		e.NextDueDate = &nextDue.Time
+	}
+	if recStart.Valid {
+		e.RecurrenceStart = &recStart.Time
+	}
+	if parentID.Valid {
+		e.ParentID = &parentID.String
+	}
+	e.ExpenseDate = expDate.Time
+	if remSent.Valid {
+		e.ReminderSentAt = &remSent.Time
+	}
+	if note.Valid {
+		e.Note = note.String
+	}
+	if recType.Valid {
+		e.RecurrenceType = domain.RecurrenceType(recType.String)
+	}
+	if len(rule) > 0 {
+		e.RecurrenceRule = &domain.RecurrenceRule{}
+		if err := json.Unmarshal(rule, e.RecurrenceRule); err != nil {
+			return nil, err
+		}
+	}
+	if deletedAt.Valid {
+		e.DeletedAt = &deletedAt.Time
+	}
+	return &e, nil
+}
+
 func scanExpenses(rows *sql.Rows) ([]*domain.Expense, error) {
 	var list []*domain.Expense
 	for rows.Next() {
-		var e domain.Expense
-		var catID sql.NullString
-		var nextDue, expDate sql.NullTime
-		var remSent sql.NullTime
-		var note sql.NullString
-		var recType sql.NullString
-		err := rows.Scan(
-			&e.ID, &e.UserID, &e.Amount, &catID, &e.IsRecurring, &recType,
-			&nextDue, &e.ReminderEnabled, &remSent, &note, &expDate, &e.CreatedAt,
-		)
+		e, err := scanExpense(rows)
 		if err != nil {
 			return nil, err
 		}
-		if catID.Valid {
-			e.CategoryID = &catID.String
-		}
-		if nextDue.Valid {
-			e.NextDueDate = &nextDue.Time
-		}
-		e.ExpenseDate = expDate.Time
-		if remSent.Valid {
-			e.ReminderSentAt = &remSent.Time
-		}
-		if note.Valid {
-			e.Note = note.String
-		}
-		if recType.Valid {
-			e.RecurrenceType = domain.RecurrenceType(recType.String)
-		}
-		list = append(list, &e)
+		list = append(list, e)
 	}
 	return list, rows.Err()
 }
 
+// currencyOrDefault is the SQL for a currency parameter that falls back to the default currency
+// of the user in parameter userPos
+func currencyOrDefault(pos, userPos int) string {
+	return `COALESCE($` + strconv.Itoa(pos) + `, (SELECT default_currency FROM users WHERE user_id = $` + strconv.Itoa(userPos) + `))`
+}
+
 func nullStr(s string) interface{} {
 	if s == "" {
 		return nil
 	}
 	return s
 }
+
+// nullRule encodes a recurrence rule for the JSONB recurrence_rule column
+func nullRule(rule *domain.RecurrenceRule) interface{} {
+	if rule == nil {
+		return nil
+	}
+	b, err := json.Marshal(rule)
+	if err != nil {
+		return nil
+	}
+	return string(b)
+}
+
+func nullDate(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"expense_tracker/domain"
+
+	"github.com/google/uuid"
+)
+
+// CreateSplit implements ExpenseSplitRepository: the expense, any new contacts and the debts are
+// written in one transaction so a failure leaves nothing behind
+func (r *ExpenseRepoPG) CreateSplit(ctx context.Context, input domain.CreateExpenseInput, debts []*domain.Debt) (*domain.Expense, error) {
+	expenseID := input.ID
+	if expenseID == "" {
+		expenseID = uuid.New().String()
+	}
+	now := time.Now().UTC()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var currency string
+	var updatedAt time.Time
+	var version int64
+	err = tx.QueryRowContext(ctx, insertExpenseQuery+` RETURNING currency, updated_at, version`,
+		insertExpenseArgs(expenseID, input, now)...).Scan(&currency, &updatedAt, &version)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, debt := range debts {
+		if debt.ContactID == nil {
+			if err := linkContactTx(ctx, tx, input.UserID, debt); err != nil {
+				return nil, err
+			}
+		}
+
+		debt.ExpenseID = &expenseID
+		debt.Currency = currency
+		debt.PaidAmount = domain.Money{}
+		debt.Balance = debt.Amount
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO debts (
+				id, user_id, type, peer_name, contact_id, expense_id, amount, currency, due_date,
+				reminder_enabled, status, note
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			RETURNING created_at, updated_at, version`,
+			debt.ID, input.UserID, debt.Type, debt.PeerName, debt.ContactID, expenseID, debt.Amount, currency,
+			debt.DueDate, debt.ReminderEnabled, debt.Status, debt.Note,
+		).Scan(&debt.CreatedAt, &debt.UpdatedAt, &debt.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return newExpense(expenseID, input, currency, now, updatedAt, version), nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"expense_tracker/domain"
+	"strconv"
+	"time"
+)
+
+// ExportRepoPG implements ExportRepository with PostgreSQL, reading rows as the export writes them
+type ExportRepoPG struct {
+	db *sql.DB
+}
+
+// NewExportRepoPG returns a new PostgreSQL export repository
+func NewExportRepoPG(db *sql.DB) *ExportRepoPG {
+	return &ExportRepoPG{db: db}
+}
+
+// expenseCategoryNameColumn selects the name of an expense's category, deleted or not
+const expenseCategoryNameColumn = `(SELECT c.name FROM categories c WHERE c.id = expenses.category_id)`
+
+func (r *ExportRepoPG) StreamExpenses(ctx context.Context, filter domain.ExpenseFilter, fn func(*domain.ExpenseRecord) error) error {
+	baseWhere, args := expenseFilterWhere(filter)
+	query := `SELECT ` + expenseColumns + `, ` + expenseCategoryNameColumn +
+		baseWhere +
+		` ORDER BY expense_date ASC, created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var categoryName sql.NullString
+		expense, err := scanExpense(trailingColumns{row: rows, dest: []interface{}{&categoryName}})
+		if err != nil {
+			return err
+		}
+		if err := fn(&domain.ExpenseRecord{Expense: *expense, CategoryName: categoryName.String}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *ExportRepoPG) StreamDebts(ctx context.Context, userID string, from, to *time.Time, fn func(*domain.Debt) error) error {
+	query := `
+		SELECT id, user_id, type, peer_name, contact_id, expense_id, ledger_id, amount, currency, due_date,
+			reminder_enabled, remind_at, sent_at, status, note, created_at,
+			updated_at, deleted_at, version, ` + debtPaidColumn + `
+		FROM debts
+		WHERE user_id = $1 AND deleted_at IS NULL`
+	args := []interface{}{userID}
+	if from != nil {
+		args = append(args, from.Format("2006-01-02"))
+		query += ` AND due_date >= $` + strconv.Itoa(len(args))
+	}
+	if to != nil {
+		args = append(args, to.Format("2006-01-02"))
+		query += ` AND due_date <= $` + strconv.Itoa(len(args))
+	}
+	query += ` ORDER BY due_date ASC, created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		debt, err := scanDebt(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(debt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// trailingColumns scans the columns a query selects after those a scan function knows into dest
+type trailingColumns struct {
+	row  rowScanner
+	dest []interface{}
+}
+
+func (t trailingColumns) Scan(dest ...interface{}) error {
+	return t.row.Scan(append(dest, t.dest...)...)
+}
@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"expense_tracker/domain"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const incomeColumns = `i.id, i.user_id, i.source, i.amount, i.currency, i.category_id, c.name, i.is_recurring, i.recurrence_rule,
+	i.next_due_date, i.recurrence_start, i.recurrence_parent_id, i.note, i.received_date, i.created_at, i.updated_at`
+
+const incomeFrom = ` FROM incomes i LEFT JOIN income_categories c ON c.id = i.category_id`
+
+// IncomeRepoPG implements IncomeRepository with PostgreSQL
+type IncomeRepoPG struct {
+	db *sql.DB
+}
+
+// NewIncomeRepoPG returns a new PostgreSQL income repository
+func NewIncomeRepoPG(db *sql.DB) *IncomeRepoPG {
+	return &IncomeRepoPG{db: db}
+}
+
+func (r *IncomeRepoPG) Create(ctx context.Context, input domain.CreateIncomeInput) (*domain.Income, error) {
+	id := uuid.New().String()
+	query := `INSERT INTO incomes (
+		id, user_id, source, amount, category_id, is_recurring, recurrence_rule,
+		next_due_date, recurrence_start, note, received_date, currency
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, ` + currencyOrDefault(12, 2) + `)`
+	_, err := r.db.ExecContext(ctx, query,
+		id, input.UserID, input.Source, input.Amount, nullStrPtr(input.CategoryID),
+		input.IsRecurring, nullRule(input.RecurrenceRule), nullDate(input.NextDueDate), nullDate(input.RecurrenceStart),
+		nullStr(input.Note), input.ReceivedDate.Format("2006-01-02"), nullStr(input.Currency),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id, input.UserID)
+}
+
+func (r *IncomeRepoPG) GetByID(ctx context.Context, id, userID string) (*domain.Income, error) {
+	query := `SELECT ` + incomeColumns + incomeFrom + ` WHERE i.id = $1 AND i.user_id = $2`
+	income, err := scanIncome(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return income, err
+}
+
+func (r *IncomeRepoPG) List(ctx context.Context, filter domain.IncomeFilter) ([]*domain.Income, int, error) {
+	baseWhere := incomeFrom + ` WHERE i.user_id = $1`
+	args := []interface{}{filter.UserID}
+	if filter.CategoryID != nil {
+		args = append(args, *filter.CategoryID)
+		baseWhere += ` AND i.category_id = $` + strconv.Itoa(len(args))
+	}
+	if filter.FromDate != nil {
+		args = append(args, filter.FromDate.Format("2006-01-02"))
+		baseWhere += ` AND i.received_date >= $` + strconv.Itoa(len(args))
+	}
+	if filter.ToDate != nil {
+		args = append(args, filter.ToDate.Format("2006-01-02"))
+		baseWhere += ` AND i.received_date <= $` + strconv.Itoa(len(args))
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*)`+baseWhere, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + incomeColumns + baseWhere +
+		` ORDER BY i.received_date DESC, i.created_at DESC LIMIT $` + strconv.Itoa(len(args)+1) +
+		` OFFSET $` + strconv.Itoa(len(args)+2)
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	items, err := scanIncomes(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *IncomeRepoPG) Update(ctx context.Context, id, userID string, input domain.UpdateIncomeInput) (*domain.Income, error) {
+	existing, err := r.GetByID(ctx, id, userID)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	if input.Source != nil {
+		existing.Source = *input.Source
+	}
+	if input.Amount != nil {
+		existing.Amount = *input.Amount
+	}
+	if input.Currency != nil {
+		existing.Currency = *input.Currency
+	}
+	if input.CategoryID != nil {
+		existing.CategoryID = input.CategoryID
+		if *input.CategoryID == "" {
+			existing.CategoryID = nil
+		}
+	}
+	if input.IsRecurring != nil {
+		existing.IsRecurring = *input.IsRecurring
+	}
+	if input.RecurrenceRule != nil {
+		existing.RecurrenceRule = input.RecurrenceRule
+	}
+	if input.NextDueDate != nil {
+		existing.NextDueDate = input.NextDueDate
+	}
+	if input.RecurrenceStart != nil {
+		existing.RecurrenceStart = input.RecurrenceStart
+	}
+	if input.Note != nil {
+		existing.Note = *input.Note
+	}
+	if input.ReceivedDate != nil {
+		existing.ReceivedDate = *input.ReceivedDate
+	}
+
+	query := `UPDATE incomes SET
+		source = $1, amount = $2, category_id = $3, is_recurring = $4, recurrence_rule = $5,
+		next_due_date = $6, recurrence_start = $7, note = $8, received_date = $9, currency = $12, updated_at = NOW()
+		WHERE id = $10 AND user_id = $11`
+	_, err = r.db.ExecContext(ctx, query,
+		existing.Source, existing.Amount, nullStrPtr(existing.CategoryID), existing.IsRecurring,
+		nullRule(existing.RecurrenceRule), nullDate(existing.NextDueDate), nullDate(existing.RecurrenceStart),
+		nullStr(existing.Note), existing.ReceivedDate.Format("2006-01-02"), id, userID, existing.Currency,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id, userID)
+}
+
+func (r *IncomeRepoPG) Delete(ctx context.Context, id, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM incomes WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *IncomeRepoPG) ListRecurringDue(ctx context.Context, nowUTC string, limit int) ([]*domain.Income, error) {
+	query := `SELECT ` + incomeColumns + incomeFrom + `
+		WHERE i.is_recurring = TRUE
+			AND i.next_due_date IS NOT NULL
+			AND i.next_due_date <= $1::date
+		ORDER BY i.next_due_date ASC
+		LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, nowUTC, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanIncomes(rows)
+}
+
+// MaterializeOccurrences inserts one income per date, linked to the template, and advances the
+// template's next_due_date to nextDue in the same transaction; see ExpenseRepoPG.MaterializeOccurrences.
+func (r *IncomeRepoPG) MaterializeOccurrences(ctx context.Context, template *domain.Income, dates []time.Time, nextDue *time.Time) (int, error) {
+	if template.NextDueDate == nil {
+		return 0, nil
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE incomes SET next_due_date = $1
+		WHERE id = $2 AND next_due_date = $3 AND is_recurring = TRUE`,
+		nullDate(nextDue), template.ID, template.NextDueDate.Format("2006-01-02"),
+	)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return 0, nil // advanced by another run, or no longer recurring
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO incomes (
+		id, user_id, source, amount, currency, category_id, recurrence_parent_id, note, received_date
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (recurrence_parent_id, received_date) WHERE recurrence_parent_id IS NOT NULL DO NOTHING`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	inserted := 0
+	for _, date := range dates {
+		result, err := stmt.ExecContext(ctx,
+			uuid.New().String(), template.UserID, template.Source, template.Amount, template.Currency, nullStrPtr(template.CategoryID),
+			template.ID, nullStr(template.Note), date.Format("2006-01-02"),
+		)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			inserted++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+// SumByDateRange returns the user's income received in the inclusive date range, converted into
+// the user's default currency. Recurring templates count once on their own received_date, like
+// any income.
+func (r *IncomeRepoPG) SumByDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) (domain.Money, error) {
+	var total domain.Money
+	query := `SELECT SUM(convert_currency(i.amount, i.currency, u.default_currency, i.received_date))
+		FROM incomes i JOIN users u ON u.user_id = i.user_id
+		WHERE i.user_id = $1 AND i.received_date BETWEEN $2 AND $3`
+	err := r.db.QueryRowContext(ctx, query, userID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).Scan(&total)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	return total, nil
+}
+
+func scanIncome(row rowScanner) (*domain.Income, error) {
+	var i domain.Income
+	var categoryID, categoryName, parentID, note sql.NullString
+	var nextDue, recStart sql.NullTime
+	var rule []byte
+	err := row.Scan(
+		&i.ID, &i.UserID, &i.Source, &i.Amount, &i.Currency, &categoryID, &categoryName, &i.IsRecurring, &rule,
+		&nextDue, &recStart, &parentID, &note, &i.ReceivedDate, &i.CreatedAt, &i.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if categoryID.Valid {
+		i.CategoryID = &categoryID.String
+	}
+	i.CategoryName = categoryName.String
+	if nextDue.Valid {
+		i.NextDueDate = &nextDue.Time
+	}
+	if recStart.Valid {
+		i.RecurrenceStart = &recStart.Time
+	}
+	if parentID.Valid {
+		i.ParentID = &parentID.String
+	}
+	i.Note = note.String
+	if len(rule) > 0 {
+		i.RecurrenceRule = &domain.RecurrenceRule{}
+		if err := json.Unmarshal(rule, i.RecurrenceRule); err != nil {
+			return nil, err
+		}
+	}
+	return &i, nil
+}
+
+func scanIncomes(rows *sql.Rows) ([]*domain.Income, error) {
+	list := make([]*domain.Income, 0)
+	for rows.Next() {
+		i, err := scanIncome(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, i)
+	}
+	return list, rows.Err()
+}
+
+func nullStrPtr(s *string) interface{} {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return *s
+}
+
+// IncomeCategoryRepoPG implements IncomeCategoryRepository with PostgreSQL
+type IncomeCategoryRepoPG struct {
+	db *sql.DB
+}
+
+// NewIncomeCategoryRepoPG returns a new PostgreSQL income category repository
+func NewIncomeCategoryRepoPG(db *sql.DB) *IncomeCategoryRepoPG {
+	return &IncomeCategoryRepoPG{db: db}
+}
+
+func (r *IncomeCategoryRepoPG) Create(ctx context.Context, category *domain.IncomeCategory) error {
+	if category.ID == "" {
+		category.ID = uuid.New().String()
+	}
+	query := `INSERT INTO income_categories (id, name, user_id) VALUES ($1, $2, $3) RETURNING created_at`
+	return r.db.QueryRowContext(ctx, query, category.ID, category.Name, nullStrPtr(category.UserID)).Scan(&category.CreatedAt)
+}
+
+func (r *IncomeCategoryRepoPG) GetByID(ctx context.Context, id, userID string) (*domain.IncomeCategory, error) {
+	query := `SELECT id, name, user_id, created_at FROM income_categories
+		WHERE id = $1 AND (user_id IS NULL OR user_id = $2)`
+	category, err := scanIncomeCategory(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return category, err
+}
+
+// List returns global categories and the user's own, by name
+func (r *IncomeCategoryRepoPG) List(ctx context.Context, userID string) ([]*domain.IncomeCategory, error) {
+	query := `SELECT id, name, user_id, created_at FROM income_categories
+		WHERE user_id IS NULL OR user_id = $1
+		ORDER BY name`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := make([]*domain.IncomeCategory, 0)
+	for rows.Next() {
+		category, err := scanIncomeCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+// Delete removes one of the user's own categories; global categories cannot be deleted
+func (r *IncomeCategoryRepoPG) Delete(ctx context.Context, id, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM income_categories WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanIncomeCategory(row rowScanner) (*domain.IncomeCategory, error) {
+	var category domain.IncomeCategory
+	var userID sql.NullString
+	if err := row.Scan(&category.ID, &category.Name, &userID, &category.CreatedAt); err != nil {
+		return nil, err
+	}
+	if userID.Valid {
+		category.UserID = &userID.String
+	}
+	return &category, nil
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"expense_tracker/domain"
+	"strconv"
+)
+
+// keysetPage returns the condition (starting with AND, empty at the start of the list) and the
+// ORDER BY of the page at cursor in a list ordered by (dateColumn, id), newest first when
+// descending, with the condition's arguments numbered from pos. A backward page is read in
+// reverse order, nearest the cursor first; the caller reverses the rows it reads.
+func keysetPage(dateColumn string, descending bool, cursor domain.PageCursor, pos int) (string, string, []interface{}) {
+	desc := descending != cursor.Backward
+	direction, compare := ` ASC`, ` > `
+	if desc {
+		direction, compare = ` DESC`, ` < `
+	}
+	order := dateColumn + direction + `, id` + direction
+	if cursor.IsStart() {
+		return "", order, nil
+	}
+	where := ` AND (` + dateColumn + `, id)` + compare + `($` + strconv.Itoa(pos) + `::date, $` + strconv.Itoa(pos+1) + `::uuid)`
+	return where, order, []interface{}{cursor.Date.Format("2006-01-02"), cursor.ID}
+}
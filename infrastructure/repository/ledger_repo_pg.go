@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"expense_tracker/domain"
+
+	"github.com/google/uuid"
+)
+
+// LedgerRepoPG implements LedgerRepository with PostgreSQL
+type LedgerRepoPG struct {
+	db *sql.DB
+}
+
+// NewLedgerRepoPG returns a new PostgreSQL ledger repository
+func NewLedgerRepoPG(db *sql.DB) *LedgerRepoPG {
+	return &LedgerRepoPG{db: db}
+}
+
+// readableBy is the SQL condition for a row of alias (empty for none) that the user in parameter
+// pos may read: their own rows outside any ledger and the rows of ledgers they are a member of
+func readableBy(alias string, pos int) string {
+	return accessibleBy(alias, pos, `m.user_id = $`+strconv.Itoa(pos))
+}
+
+// writableBy is readableBy without the ledgers where the user is only a viewer
+func writableBy(alias string, pos int) string {
+	return accessibleBy(alias, pos, `m.user_id = $`+strconv.Itoa(pos)+` AND m.role <> 'viewer'`)
+}
+
+func accessibleBy(alias string, pos int, member string) string {
+	if alias != "" {
+		alias += "."
+	}
+	return `((` + alias + `ledger_id IS NULL AND ` + alias + `user_id = $` + strconv.Itoa(pos) + `) OR ` +
+		alias + `ledger_id IN (SELECT m.ledger_id FROM ledger_members m WHERE ` + member + `))`
+}
+
+const ledgerColumns = `l.id, l.name, l.created_by, m.role, l.created_at, l.updated_at`
+
+func (r *LedgerRepoPG) Create(ctx context.Context, ledger *domain.Ledger) error {
+	if ledger.ID == "" {
+		ledger.ID = uuid.New().String()
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO ledgers (id, name, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING created_at, updated_at`,
+		ledger.ID, ledger.Name, ledger.CreatedBy,
+	).Scan(&ledger.CreatedAt, &ledger.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO ledger_members (ledger_id, user_id, role) VALUES ($1, $2, $3)`,
+		ledger.ID, ledger.CreatedBy, string(domain.LedgerRoleOwner)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	ledger.Role = domain.LedgerRoleOwner
+	return nil
+}
+
+func (r *LedgerRepoPG) GetByID(ctx context.Context, id, userID string) (*domain.Ledger, error) {
+	query := `SELECT ` + ledgerColumns + ` FROM ledgers l
+		JOIN ledger_members m ON m.ledger_id = l.id
+		WHERE l.id = $1 AND m.user_id = $2`
+	ledger, err := scanLedger(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return ledger, err
+}
+
+// ListByUser returns the ledgers the user is a member of, by name
+func (r *LedgerRepoPG) ListByUser(ctx context.Context, userID string) ([]*domain.Ledger, error) {
+	query := `SELECT ` + ledgerColumns + ` FROM ledgers l
+		JOIN ledger_members m ON m.ledger_id = l.id
+		WHERE m.user_id = $1
+		ORDER BY LOWER(l.name) ASC, l.created_at ASC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ledgers := make([]*domain.Ledger, 0)
+	for rows.Next() {
+		ledger, err := scanLedger(rows)
+		if err != nil {
+			return nil, err
+		}
+		ledgers = append(ledgers, ledger)
+	}
+	return ledgers, rows.Err()
+}
+
+func (r *LedgerRepoPG) Update(ctx context.Context, ledger *domain.Ledger) error {
+	return r.db.QueryRowContext(ctx, `UPDATE ledgers SET name = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING updated_at`, ledger.Name, ledger.ID).Scan(&ledger.UpdatedAt)
+}
+
+func (r *LedgerRepoPG) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM ledgers WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+const ledgerMemberColumns = `m.ledger_id, m.user_id, COALESCE(u.name, ''), u.email, m.role, m.created_at`
+
+func (r *LedgerRepoPG) GetMember(ctx context.Context, ledgerID, userID string) (*domain.LedgerMember, error) {
+	query := `SELECT ` + ledgerMemberColumns + ` FROM ledger_members m
+		JOIN users u ON u.user_id = m.user_id
+		WHERE m.ledger_id = $1 AND m.user_id = $2`
+	member, err := scanLedgerMember(r.db.QueryRowContext(ctx, query, ledgerID, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return member, err
+}
+
+// ListMembers returns the ledger's members, owners first, then in the order they joined
+func (r *LedgerRepoPG) ListMembers(ctx context.Context, ledgerID string) ([]*domain.LedgerMember, error) {
+	query := `SELECT ` + ledgerMemberColumns + ` FROM ledger_members m
+		JOIN users u ON u.user_id = m.user_id
+		WHERE m.ledger_id = $1
+		ORDER BY m.role <> 'owner', m.created_at ASC`
+	rows, err := r.db.QueryContext(ctx, query, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]*domain.LedgerMember, 0)
+	for rows.Next() {
+		member, err := scanLedgerMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func (r *LedgerRepoPG) AddMember(ctx context.Context, member *domain.LedgerMember) error {
+	query := `INSERT INTO ledger_members (ledger_id, user_id, role)
+		VALUES ($1, $2, $3)
+		RETURNING created_at`
+	return r.db.QueryRowContext(ctx, query, member.LedgerID, member.UserID, string(member.Role)).Scan(&member.CreatedAt)
+}
+
+func (r *LedgerRepoPG) UpdateMemberRole(ctx context.Context, ledgerID, userID string, role domain.LedgerRole) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE ledger_members SET role = $1 WHERE ledger_id = $2 AND user_id = $3`,
+		string(role), ledgerID, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *LedgerRepoPG) RemoveMember(ctx context.Context, ledgerID, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM ledger_members WHERE ledger_id = $1 AND user_id = $2`, ledgerID, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *LedgerRepoPG) CountOwners(ctx context.Context, ledgerID string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM ledger_members WHERE ledger_id = $1 AND role = 'owner'`,
+		ledgerID).Scan(&count)
+	return count, err
+}
+
+func scanLedger(row rowScanner) (*domain.Ledger, error) {
+	var ledger domain.Ledger
+	var role string
+	if err := row.Scan(&ledger.ID, &ledger.Name, &ledger.CreatedBy, &role, &ledger.CreatedAt, &ledger.UpdatedAt); err != nil {
+		return nil, err
+	}
+	ledger.Role = domain.LedgerRole(role)
+	return &ledger, nil
+}
+
+func scanLedgerMember(row rowScanner) (*domain.LedgerMember, error) {
+	var member domain.LedgerMember
+	var role string
+	if err := row.Scan(&member.LedgerID, &member.UserID, &member.Name, &member.Email, &role, &member.CreatedAt); err != nil {
+		return nil, err
+	}
+	member.Role = domain.LedgerRole(role)
+	return &member, nil
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"expense_tracker/domain"
+	pkgrepo "expense_tracker/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationRepoPG implements NotificationRepository with PostgreSQL
+type NotificationRepoPG struct {
+	db *sql.DB
+}
+
+// NewNotificationRepoPG returns a new PostgreSQL notification repository
+func NewNotificationRepoPG(db *sql.DB) *NotificationRepoPG {
+	return &NotificationRepoPG{db: db}
+}
+
+const notificationColumns = `id, user_id, channel, target, subject_type, subject_id, dedupe_key,
+	title, body, status, attempts, last_error, next_attempt_at, sent_at, created_at, updated_at`
+
+// Enqueue stores a pending notification. A notification with the same dedupe key is left
+// untouched, so re-running the reminder job never queues the same message twice.
+func (r *NotificationRepoPG) Enqueue(ctx context.Context, n *domain.Notification) (bool, error) {
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	if n.Status == "" {
+		n.Status = domain.NotificationStatusPending
+	}
+	query := `INSERT INTO notifications (
+		id, user_id, channel, target, subject_type, subject_id, dedupe_key,
+		title, body, status, next_attempt_at
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	ON CONFLICT (dedupe_key) DO NOTHING
+	RETURNING created_at, updated_at`
+	err := r.db.QueryRowContext(ctx, query,
+		n.ID, n.UserID, string(n.Channel), n.Target, n.SubjectType, n.SubjectID, n.DedupeKey,
+		n.Title, n.Body, string(n.Status), n.NextAttemptAt,
+	).Scan(&n.CreatedAt, &n.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListDue returns pending notifications whose next attempt is due, oldest first
+func (r *NotificationRepoPG) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Notification, error) {
+	query := `SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at ASC
+		LIMIT $3`
+	rows, err := r.db.QueryContext(ctx, query, string(domain.NotificationStatusPending), now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanNotifications(rows)
+}
+
+func (r *NotificationRepoPG) MarkSent(ctx context.Context, id string, sentAt time.Time) error {
+	query := `UPDATE notifications
+		SET status = $1, attempts = attempts + 1, sent_at = $2, last_error = NULL, updated_at = NOW()
+		WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, string(domain.NotificationStatusSent), sentAt, id)
+	return err
+}
+
+// MarkAttemptFailed records a failed attempt. status stays pending while retries remain.
+func (r *NotificationRepoPG) MarkAttemptFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time, status domain.NotificationStatus) error {
+	query := `UPDATE notifications
+		SET status = $1, attempts = attempts + 1, last_error = $2, next_attempt_at = $3, updated_at = NOW()
+		WHERE id = $4`
+	_, err := r.db.ExecContext(ctx, query, string(status), lastError, nextAttemptAt, id)
+	return err
+}
+
+// ListByUser returns the user's notifications, newest first
+func (r *NotificationRepoPG) ListByUser(ctx context.Context, userID string, options pkgrepo.ListOptions) ([]*domain.Notification, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notifications WHERE user_id = $1`, userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3`
+	rows, err := r.db.QueryContext(ctx, query, userID, options.Limit, options.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	notifications, err := scanNotifications(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+func (r *NotificationRepoPG) GetPreferences(ctx context.Context, userID string) ([]*domain.NotificationPreference, error) {
+	query := `SELECT user_id, channel, target, enabled
+		FROM notification_preferences
+		WHERE user_id = $1
+		ORDER BY channel ASC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := make([]*domain.NotificationPreference, 0)
+	for rows.Next() {
+		var p domain.NotificationPreference
+		var channel string
+		if err := rows.Scan(&p.UserID, &channel, &p.Target, &p.Enabled); err != nil {
+			return nil, err
+		}
+		p.Channel = domain.NotificationChannel(channel)
+		prefs = append(prefs, &p)
+	}
+	return prefs, rows.Err()
+}
+
+// ReplacePreferences swaps the user's whole preference set in one transaction
+func (r *NotificationRepoPG) ReplacePreferences(ctx context.Context, userID string, prefs []*domain.NotificationPreference) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM notification_preferences WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, p := range prefs {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO notification_preferences (user_id, channel, target, enabled) VALUES ($1, $2, $3, $4)`,
+			userID, string(p.Channel), p.Target, p.Enabled,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func scanNotification(row rowScanner) (*domain.Notification, error) {
+	var n domain.Notification
+	var channel, status string
+	var lastError sql.NullString
+	var sentAt sql.NullTime
+	err := row.Scan(
+		&n.ID, &n.UserID, &channel, &n.Target, &n.SubjectType, &n.SubjectID, &n.DedupeKey,
+		&n.Title, &n.Body, &status, &n.Attempts, &lastError, &n.NextAttemptAt, &sentAt,
+		&n.CreatedAt, &n.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	n.Channel = domain.NotificationChannel(channel)
+	n.Status = domain.NotificationStatus(status)
+	if lastError.Valid {
+		n.LastError = &lastError.String
+	}
+	if sentAt.Valid {
+		n.SentAt = &sentAt.Time
+	}
+	return &n, nil
+}
+
+func scanNotifications(rows *sql.Rows) ([]*domain.Notification, error) {
+	notifications := make([]*domain.Notification, 0)
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
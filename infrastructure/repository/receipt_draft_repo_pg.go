@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"expense_tracker/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReceiptDraftRepoPG implements ReceiptDraftRepository with PostgreSQL
+type ReceiptDraftRepoPG struct {
+	db *sql.DB
+}
+
+// NewReceiptDraftRepoPG returns a new PostgreSQL receipt draft repository
+func NewReceiptDraftRepoPG(db *sql.DB) *ReceiptDraftRepoPG {
+	return &ReceiptDraftRepoPG{db: db}
+}
+
+const receiptDraftColumns = `id, user_id, ledger_id, file_name, content_type, size_bytes, storage_key, status,
+	extractor, receipt, expense, attempts, last_error, next_attempt_at, created_at, updated_at`
+
+// receiptDraftOwner matches the drafts of the user in parameter $2 uploaded to the ledger in $3 (NULL = their own)
+const receiptDraftOwner = `user_id = $2 AND ledger_id IS NOT DISTINCT FROM $3::uuid`
+
+func (r *ReceiptDraftRepoPG) Create(ctx context.Context, d *domain.ReceiptDraft) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	if d.Status == "" {
+		d.Status = domain.ReceiptDraftPending
+	}
+	query := `INSERT INTO receipt_drafts (id, user_id, ledger_id, file_name, content_type, size_bytes, storage_key, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING created_at, updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		d.ID, d.UserID, nullStrPtr(d.LedgerID), d.FileName, d.ContentType, d.Size, d.StorageKey, string(d.Status), d.NextAttemptAt,
+	).Scan(&d.CreatedAt, &d.UpdatedAt)
+}
+
+func (r *ReceiptDraftRepoPG) GetByID(ctx context.Context, id, userID string, ledgerID *string) (*domain.ReceiptDraft, error) {
+	query := `SELECT ` + receiptDraftColumns + ` FROM receipt_drafts WHERE id = $1 AND ` + receiptDraftOwner
+	d, err := scanReceiptDraft(r.db.QueryRowContext(ctx, query, id, userID, nullStrPtr(ledgerID)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
+func (r *ReceiptDraftRepoPG) List(ctx context.Context, userID string, ledgerID *string) ([]*domain.ReceiptDraft, error) {
+	query := `SELECT ` + receiptDraftColumns + ` FROM receipt_drafts
+		WHERE user_id = $1 AND ledger_id IS NOT DISTINCT FROM $2::uuid
+		ORDER BY created_at DESC, id DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID, nullStrPtr(ledgerID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanReceiptDrafts(rows)
+}
+
+func (r *ReceiptDraftRepoPG) Delete(ctx context.Context, id, userID string, ledgerID *string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM receipt_drafts WHERE id = $1 AND `+receiptDraftOwner, id, userID, nullStrPtr(ledgerID))
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListDue returns pending drafts whose next attempt is due, oldest first
+func (r *ReceiptDraftRepoPG) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.ReceiptDraft, error) {
+	query := `SELECT ` + receiptDraftColumns + `
+		FROM receipt_drafts
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at ASC
+		LIMIT $3`
+	rows, err := r.db.QueryContext(ctx, query, string(domain.ReceiptDraftPending), now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanReceiptDrafts(rows)
+}
+
+// MarkExtracted stores what was read from the receipt and makes the draft ready. A draft deleted
+// in the meantime is left deleted.
+func (r *ReceiptDraftRepoPG) MarkExtracted(ctx context.Context, id, extractor string, receipt *domain.ReceiptData, expense *domain.CreateExpenseInput) error {
+	receiptJSON, err := json.Marshal(receipt)
+	if err != nil {
+		return err
+	}
+	expenseJSON, err := json.Marshal(expense)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE receipt_drafts
+		SET status = $1, extractor = $2, receipt = $3, expense = $4, attempts = attempts + 1, last_error = NULL, updated_at = NOW()
+		WHERE id = $5`
+	_, err = r.db.ExecContext(ctx, query, string(domain.ReceiptDraftReady), extractor, string(receiptJSON), string(expenseJSON), id)
+	return err
+}
+
+// MarkAttemptFailed records a failed attempt. status stays pending while retries remain.
+func (r *ReceiptDraftRepoPG) MarkAttemptFailed(ctx context.Context, id, lastError string, nextAttemptAt time.Time, status domain.ReceiptDraftStatus) error {
+	query := `UPDATE receipt_drafts
+		SET status = $1, attempts = attempts + 1, last_error = $2, next_attempt_at = $3, updated_at = NOW()
+		WHERE id = $4`
+	_, err := r.db.ExecContext(ctx, query, string(status), lastError, nextAttemptAt, id)
+	return err
+}
+
+func (r *ReceiptDraftRepoPG) Attach(ctx context.Context, id, userID string, a *domain.Attachment) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM receipt_drafts WHERE id = $1 AND user_id = $2 AND storage_key = $3`, id, userID, a.StorageKey)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	query := `INSERT INTO expense_attachments (id, expense_id, user_id, file_name, content_type, size_bytes, storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at`
+	err = tx.QueryRowContext(ctx, query,
+		a.ID, a.ExpenseID, a.UserID, a.FileName, a.ContentType, a.Size, a.StorageKey,
+	).Scan(&a.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanReceiptDraft(row rowScanner) (*domain.ReceiptDraft, error) {
+	var d domain.ReceiptDraft
+	var ledgerID, extractor, lastError sql.NullString
+	var status string
+	var receipt, expense []byte
+	if err := row.Scan(&d.ID, &d.UserID, &ledgerID, &d.FileName, &d.ContentType, &d.Size, &d.StorageKey, &status,
+		&extractor, &receipt, &expense, &d.Attempts, &lastError, &d.NextAttemptAt, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	d.Status = domain.ReceiptDraftStatus(status)
+	if ledgerID.Valid {
+		d.LedgerID = &ledgerID.String
+	}
+	d.Extractor = extractor.String
+	if lastError.Valid {
+		d.LastError = &lastError.String
+	}
+	if len(receipt) > 0 {
+		d.Receipt = &domain.ReceiptData{}
+		if err := json.Unmarshal(receipt, d.Receipt); err != nil {
+			return nil, err
+		}
+	}
+	if len(expense) > 0 {
+		d.Expense = &domain.CreateExpenseInput{}
+		if err := json.Unmarshal(expense, d.Expense); err != nil {
+			return nil, err
+		}
+	}
+	return &d, nil
+}
+
+func scanReceiptDrafts(rows *sql.Rows) ([]*domain.ReceiptDraft, error) {
+	drafts := make([]*domain.ReceiptDraft, 0)
+	for rows.Next() {
+		d, err := scanReceiptDraft(rows)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, d)
+	}
+	return drafts, rows.Err()
+}
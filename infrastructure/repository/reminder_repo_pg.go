@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"expense_tracker/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReminderRepoPG implements ReminderRepository with PostgreSQL
+type ReminderRepoPG struct {
+	db *sql.DB
+}
+
+// NewReminderRepoPG returns a new PostgreSQL reminder repository
+func NewReminderRepoPG(db *sql.DB) *ReminderRepoPG {
+	return &ReminderRepoPG{db: db}
+}
+
+const reminderColumns = `id, debt_id, remind_at, enabled, fired_at, created_at`
+
+func (r *ReminderRepoPG) Create(ctx context.Context, reminder *domain.Reminder) error {
+	if reminder.ID == "" {
+		reminder.ID = uuid.New().String()
+	}
+	query := `INSERT INTO reminders (id, debt_id, remind_at, enabled)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`
+	return r.db.QueryRowContext(ctx, query,
+		reminder.ID, reminder.DebtID, reminder.RemindAt.UTC(), reminder.Enabled,
+	).Scan(&reminder.CreatedAt)
+}
+
+func (r *ReminderRepoPG) GetByID(ctx context.Context, id string) (*domain.Reminder, error) {
+	query := `SELECT ` + reminderColumns + ` FROM reminders WHERE id = $1`
+	reminder, err := scanReminder(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return reminder, err
+}
+
+// ListByDebt returns the debt's reminders in firing order
+func (r *ReminderRepoPG) ListByDebt(ctx context.Context, debtID string) ([]*domain.Reminder, error) {
+	query := `SELECT ` + reminderColumns + ` FROM reminders WHERE debt_id = $1 ORDER BY remind_at ASC`
+	rows, err := r.db.QueryContext(ctx, query, debtID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanReminders(rows)
+}
+
+func (r *ReminderRepoPG) CountByDebt(ctx context.Context, debtID string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM reminders WHERE debt_id = $1`, debtID).Scan(&count)
+	return count, err
+}
+
+// Update saves remind_at and enabled; fired_at is saved too so a rescheduled reminder can fire again
+func (r *ReminderRepoPG) Update(ctx context.Context, reminder *domain.Reminder) error {
+	query := `UPDATE reminders SET remind_at = $1, enabled = $2, fired_at = $3 WHERE id = $4`
+	var firedAt interface{}
+	if reminder.FiredAt != nil {
+		firedAt = *reminder.FiredAt
+	}
+	result, err := r.db.ExecContext(ctx, query, reminder.RemindAt.UTC(), reminder.Enabled, firedAt, reminder.ID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *ReminderRepoPG) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM reminders WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *ReminderRepoPG) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Reminder, error) {
+	query := `SELECT r.id, r.debt_id, r.remind_at, r.enabled, r.fired_at, r.created_at
+		FROM reminders r
+		JOIN debts d ON d.id = r.debt_id
+		WHERE r.enabled = TRUE
+			AND r.fired_at IS NULL
+			AND r.remind_at <= $1
+			AND d.deleted_at IS NULL
+			AND d.reminder_enabled = TRUE
+			AND d.status <> $2
+		ORDER BY r.remind_at ASC
+		LIMIT $3`
+	rows, err := r.db.QueryContext(ctx, query, now.UTC(), domain.DebtStatusPaid, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanReminders(rows)
+}
+
+func (r *ReminderRepoPG) MarkFired(ctx context.Context, id string, firedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE reminders SET fired_at = $1 WHERE id = $2`, firedAt.UTC(), id)
+	return err
+}
+
+func scanReminder(row rowScanner) (*domain.Reminder, error) {
+	var reminder domain.Reminder
+	var firedAt sql.NullTime
+	if err := row.Scan(&reminder.ID, &reminder.DebtID, &reminder.RemindAt, &reminder.Enabled, &firedAt, &reminder.CreatedAt); err != nil {
+		return nil, err
+	}
+	if firedAt.Valid {
+		reminder.FiredAt = &firedAt.Time
+	}
+	return &reminder, nil
+}
+
+func scanReminders(rows *sql.Rows) ([]*domain.Reminder, error) {
+	reminders := make([]*domain.Reminder, 0)
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, rows.Err()
+}
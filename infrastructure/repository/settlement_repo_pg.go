@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"expense_tracker/domain"
+	pkgrepo "expense_tracker/repository"
+
+	"github.com/google/uuid"
+)
+
+// SettlementRepoPG implements SettlementRepository with PostgreSQL
+type SettlementRepoPG struct {
+	db *sql.DB
+}
+
+// NewSettlementRepoPG returns a new PostgreSQL settlement repository
+func NewSettlementRepoPG(db *sql.DB) *SettlementRepoPG {
+	return &SettlementRepoPG{db: db}
+}
+
+func (r *SettlementRepoPG) PaidByMember(ctx context.Context, ledgerID string) ([]pkgrepo.MemberTotal, error) {
+	query := `SELECT user_id, currency, COALESCE(SUM(amount), 0)
+		FROM expenses
+		WHERE ledger_id = $1 AND deleted_at IS NULL
+		GROUP BY user_id, currency`
+	return r.memberTotals(ctx, query, ledgerID)
+}
+
+func (r *SettlementRepoPG) SettledByMember(ctx context.Context, ledgerID string) ([]pkgrepo.MemberTotal, error) {
+	query := `SELECT d.user_id, d.currency,
+			COALESCE(SUM(CASE WHEN d.type = 'borrowed' THEN p.amount ELSE -p.amount END), 0)
+		FROM debts d JOIN debt_payments p ON p.debt_id = d.id
+		WHERE d.ledger_id = $1 AND d.deleted_at IS NULL
+		GROUP BY d.user_id, d.currency`
+	return r.memberTotals(ctx, query, ledgerID)
+}
+
+func (r *SettlementRepoPG) memberTotals(ctx context.Context, query, ledgerID string) ([]pkgrepo.MemberTotal, error) {
+	rows, err := r.db.QueryContext(ctx, query, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make([]pkgrepo.MemberTotal, 0)
+	for rows.Next() {
+		var t pkgrepo.MemberTotal
+		if err := rows.Scan(&t.UserID, &t.Currency, &t.Total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+func (r *SettlementRepoPG) Record(ctx context.Context, payer, payee *domain.Debt) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, debt := range []*domain.Debt{payer, payee} {
+		if err := linkContactTx(ctx, tx, debt.UserID, debt); err != nil {
+			return err
+		}
+		if debt.ID == "" {
+			debt.ID = uuid.New().String()
+		}
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO debts (
+				id, user_id, type, peer_name, contact_id, ledger_id, amount, currency, due_date,
+				reminder_enabled, status, note
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, FALSE, $10, $11)
+			RETURNING created_at, updated_at, version`,
+			debt.ID, debt.UserID, debt.Type, debt.PeerName, debt.ContactID, debt.LedgerID, debt.Amount, debt.Currency,
+			debt.DueDate, debt.Status, debt.Note,
+		).Scan(&debt.CreatedAt, &debt.UpdatedAt, &debt.Version)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO debt_payments (id, debt_id, amount, paid_date, note)
+			VALUES ($1, $2, $3, $4, $5)`,
+			uuid.New().String(), debt.ID, debt.Amount, debt.DueDate.Format("2006-01-02"), debt.Note)
+		if err != nil {
+			return err
+		}
+		debt.PaidAmount = debt.Amount
+		debt.Balance = domain.Money{}
+	}
+	return tx.Commit()
+}
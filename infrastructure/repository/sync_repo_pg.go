@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"expense_tracker/domain"
+)
+
+// SyncRepoPG implements SyncRepository with PostgreSQL
+type SyncRepoPG struct {
+	db *sql.DB
+}
+
+// NewSyncRepoPG returns a new PostgreSQL sync repository
+func NewSyncRepoPG(db *sql.DB) *SyncRepoPG {
+	return &SyncRepoPG{db: db}
+}
+
+// Watermark returns the xmin of a fresh snapshot: every transaction before it has committed or
+// aborted, and transactions that have not written yet will get a later id
+func (r *SyncRepoPG) Watermark(ctx context.Context) (uint64, error) {
+	var xmin uint64
+	if err := r.db.QueryRowContext(ctx, `SELECT pg_snapshot_xmin(pg_current_snapshot())`).Scan(&xmin); err != nil {
+		return 0, err
+	}
+	return xmin, nil
+}
+
+// changeRow reads a row of the sync change feed: the columns its caller scans, then sync_xid
+type changeRow struct {
+	rows *sql.Rows
+	txID *uint64
+}
+
+func (r changeRow) Scan(dest ...interface{}) error {
+	return r.rows.Scan(append(dest, r.txID)...)
+}
+
+// changeFeedWhere returns the condition of a change feed query, with its arguments numbered from
+// pos: records after since, written by transactions before the watermark
+func changeFeedWhere(since domain.SyncPosition, before uint64, pos int) (string, []interface{}) {
+	where := `(sync_xid, version) > ($` + strconv.Itoa(pos) + `::xid8, $` + strconv.Itoa(pos+1) + `) AND sync_xid < $` + strconv.Itoa(pos+2) + `::xid8`
+	return where, []interface{}{strconv.FormatUint(since.TxID, 10), since.Version, strconv.FormatUint(before, 10)}
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"time"
 
+	"expense_tracker/domain"
+
 	"github.com/google/uuid"
 )
 
@@ -16,17 +18,30 @@ func NewDebtRepoPG(db *sql.DB) *DebtRepoPG {
 	return &DebtRepoPG{DB: db}
 }
 
-func (r *DebtRepoPG) SumByDateRangeAndType(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time, debtType string) (float64, error) {
-	query := `SELECT COALESCE(SUM(amount), 0)
-	FROM debts
-	WHERE user_id = $1 AND type = $2 AND due_date >= $3 AND due_date <= $4`
+// SumByDateRangeAndType totals the user's debts of one type due in the date range, converted into
+// the user's default currency as of each due date
+func (r *DebtRepoPG) SumByDateRangeAndType(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time, debtType string) (domain.Money, error) {
+	query := `SELECT COALESCE(SUM(convert_currency(d.amount, d.currency, u.default_currency, d.due_date)), 0)
+	FROM debts d JOIN users u ON u.user_id = d.user_id
+	WHERE d.user_id = $1 AND d.type = $2 AND d.deleted_at IS NULL AND d.due_date >= $3 AND d.due_date <= $4`
 
-	var total sql.NullFloat64
+	var total domain.Money
 	if err := r.DB.QueryRowContext(ctx, query, userID, debtType, startDate, endDate).Scan(&total); err != nil {
-		return 0, err
+		return domain.Money{}, err
 	}
-	if !total.Valid {
-		return 0, nil
+	return total, nil
+}
+
+// SumPaymentsByDateRangeAndType totals repayments made in the date range on the user's debts of
+// one type, converted into the user's default currency as of each payment date
+func (r *DebtRepoPG) SumPaymentsByDateRangeAndType(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time, debtType string) (domain.Money, error) {
+	query := `SELECT COALESCE(SUM(convert_currency(p.amount, d.currency, u.default_currency, p.paid_date)), 0)
+	FROM debt_payments p JOIN debts d ON d.id = p.debt_id JOIN users u ON u.user_id = d.user_id
+	WHERE d.user_id = $1 AND d.type = $2 AND d.deleted_at IS NULL AND p.paid_date >= $3 AND p.paid_date <= $4`
+
+	var total domain.Money
+	if err := r.DB.QueryRowContext(ctx, query, userID, debtType, startDate, endDate).Scan(&total); err != nil {
+		return domain.Money{}, err
 	}
-	return total.Float64, nil
+	return total, nil
 }
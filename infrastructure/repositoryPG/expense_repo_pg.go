@@ -3,6 +3,7 @@ package repositoryPG
 import (
 	"context"
 	"database/sql"
+	"expense_tracker/domain"
 	"expense_tracker/repository"
 	"time"
 
@@ -17,19 +18,16 @@ func NewExpenseRepoPG(db *sql.DB) *ExpenseRepoPG {
 	return &ExpenseRepoPG{DB: db}
 }
 
-func (r *ExpenseRepoPG) SumByDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) (float64, error) {
+func (r *ExpenseRepoPG) SumByDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) (domain.Money, error) {
 	query := `SELECT COALESCE(SUM(amount), 0)
 	FROM expenses
-	WHERE user_id = $1 AND expense_date >= $2 AND expense_date <= $3`
+	WHERE user_id = $1 AND deleted_at IS NULL AND expense_date >= $2 AND expense_date <= $3`
 
-	var total sql.NullFloat64
+	var total domain.Money
 	if err := r.DB.QueryRowContext(ctx, query, userID, startDate, endDate).Scan(&total); err != nil {
-		return 0, err
+		return domain.Money{}, err
 	}
-	if !total.Valid {
-		return 0, nil
-	}
-	return total.Float64, nil
+	return total, nil
 }
 
 func (r *ExpenseRepoPG) CategoryBreakdownByDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]repository.CategoryTotal, error) {
@@ -37,7 +35,7 @@ func (r *ExpenseRepoPG) CategoryBreakdownByDateRange(ctx context.Context, userID
 		COALESCE(SUM(e.amount), 0) AS total
 	FROM expenses e
 	LEFT JOIN categories c ON e.category_id = c.id
-	WHERE e.user_id = $1 AND e.expense_date >= $2 AND e.expense_date <= $3
+	WHERE e.user_id = $1 AND e.deleted_at IS NULL AND e.expense_date >= $2 AND e.expense_date <= $3
 	GROUP BY category_name
 	ORDER BY total DESC`
 
@@ -50,17 +48,13 @@ func (r *ExpenseRepoPG) CategoryBreakdownByDateRange(ctx context.Context, userID
 	results := []repository.CategoryTotal{}
 	for rows.Next() {
 		var name string
-		var total sql.NullFloat64
+		var total domain.Money
 		if err := rows.Scan(&name, &total); err != nil {
 			return nil, err
 		}
-		itemTotal := 0.0
-		if total.Valid {
-			itemTotal = total.Float64
-		}
 		results = append(results, repository.CategoryTotal{
 			CategoryName: name,
-			Total:        itemTotal,
+			Total:        total,
 		})
 	}
 
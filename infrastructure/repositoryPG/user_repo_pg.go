@@ -18,8 +18,8 @@ func NewUserRepoPG(db *sql.DB) *UserRepoPG {
 
 func (r *UserRepoPG) Create(ctx context.Context, u *domain.User) error {
 	query := `INSERT INTO users
-	(user_id, name, email, password_hash, budgeting_style, default_currency)
-	VALUES ($1, $2, $3, $4, $5, $6)`
+	(user_id, name, email, password_hash, budgeting_style, monthly_income, default_currency)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
 
 	_, err := r.DB.ExecContext(
 		ctx,
@@ -29,6 +29,7 @@ func (r *UserRepoPG) Create(ctx context.Context, u *domain.User) error {
 		u.Email,
 		u.PasswordHash,
 		u.BudgetingStyle,
+		u.MonthlyIncome,
 		u.DefaultCurrency,
 	)
 	return err
@@ -37,12 +38,12 @@ func (r *UserRepoPG) Create(ctx context.Context, u *domain.User) error {
 func (r *UserRepoPG) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	u := &domain.User{}
 
-	query := `SELECT user_id, name, email, password_hash, budgeting_style, default_currency, created_at
+	query := `SELECT user_id, name, email, password_hash, budgeting_style, monthly_income, default_currency, created_at
 	FROM users
 	WHERE email=$1`
 
 	err := r.DB.QueryRowContext(ctx, query, email).
-		Scan(&u.UserID, &u.Name, &u.Email, &u.PasswordHash, &u.BudgetingStyle, &u.DefaultCurrency, &u.CreatedAt)
+		Scan(&u.UserID, &u.Name, &u.Email, &u.PasswordHash, &u.BudgetingStyle, &u.MonthlyIncome, &u.DefaultCurrency, &u.CreatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -52,12 +53,12 @@ func (r *UserRepoPG) GetByEmail(ctx context.Context, email string) (*domain.User
 
 func (r *UserRepoPG) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	u := &domain.User{}
-	query := `SELECT user_id, name, email, budgeting_style, default_currency, created_at
+	query := `SELECT user_id, name, email, budgeting_style, monthly_income, default_currency, created_at
 	FROM users
 	WHERE user_id=$1`
 
 	err := r.DB.QueryRowContext(ctx, query, id).
-		Scan(&u.UserID, &u.Name, &u.Email, &u.BudgetingStyle, &u.DefaultCurrency, &u.CreatedAt)
+		Scan(&u.UserID, &u.Name, &u.Email, &u.BudgetingStyle, &u.MonthlyIncome, &u.DefaultCurrency, &u.CreatedAt)
 
 	return u, err
 }
@@ -67,14 +68,16 @@ func (r *UserRepoPG) Update(ctx context.Context, u *domain.User) error {
 	SET
 		name = $1,
 		budgeting_style = $2,
-		default_currency = $3
-	WHERE user_id = $4`
+		monthly_income = $3,
+		default_currency = $4
+	WHERE user_id = $5`
 
 	_, err := r.DB.ExecContext(
 		ctx,
 		query,
 		u.Name,
 		u.BudgetingStyle,
+		u.MonthlyIncome,
 		u.DefaultCurrency,
 		u.UserID,
 	)
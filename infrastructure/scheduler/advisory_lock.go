@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// PGAdvisoryLocker implements Locker with Postgres session-level advisory locks,
+// so only one replica runs each job when the app is scaled out.
+type PGAdvisoryLocker struct {
+	db *sql.DB
+}
+
+// NewPGAdvisoryLocker returns a locker backed by the given database
+func NewPGAdvisoryLocker(db *sql.DB) *PGAdvisoryLocker {
+	return &PGAdvisoryLocker{db: db}
+}
+
+// TryLock takes the advisory lock for the job name without waiting. Advisory locks belong to a
+// session, so the lock is taken on a dedicated connection that is held until unlock is called.
+func (l *PGAdvisoryLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := "expense_tracker:job:" + name
+	var ok bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, key).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// Use a fresh context: the job context may already be cancelled during shutdown
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, key); err != nil {
+			log.Printf("scheduler: release lock for %q: %v", name, err)
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
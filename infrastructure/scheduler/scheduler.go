@@ -0,0 +1,202 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// historySize is how many runs are kept per job
+const historySize = 20
+
+// Job is a unit of periodic work. Run returns the number of rows it affected.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) (int64, error)
+}
+
+// RunRecord is the outcome of one job execution
+type RunRecord struct {
+	Job          string    `json:"job"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	RowsAffected int64     `json:"rows_affected"`
+	Error        string    `json:"error,omitempty"`
+	Skipped      bool      `json:"skipped"` // another replica held the job lock
+}
+
+// Locker makes sure only one replica runs a job at a time.
+// When ok is true the caller must call unlock once the job finishes.
+type Locker interface {
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+// Scheduler runs registered jobs on their intervals until its context is cancelled
+type Scheduler struct {
+	locker  Locker
+	jobs    []Job
+	mu      sync.Mutex
+	history map[string][]RunRecord
+	wg      sync.WaitGroup
+	now     func() time.Time
+}
+
+// New creates a scheduler. locker may be nil when a single replica is running.
+func New(locker Locker) *Scheduler {
+	return &Scheduler{
+		locker:  locker,
+		history: make(map[string][]RunRecord),
+		now:     time.Now,
+	}
+}
+
+// Register adds a job; it must be called before Start
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job once immediately and then on its interval, each in its own goroutine.
+// It returns right away; cancel ctx and call Wait to shut down gracefully.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		if job.Interval <= 0 || job.Run == nil {
+			log.Printf("scheduler: job %q has no interval or run func, not scheduled", job.Name)
+			continue
+		}
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+		log.Printf("scheduler: job %q scheduled every %s", job.Name, job.Interval)
+	}
+}
+
+// Wait blocks until all job loops have returned, including any run in progress
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// History returns the recorded runs of a job, oldest first
+func (s *Scheduler) History(name string) []RunRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RunRecord(nil), s.history[name]...)
+}
+
+// LastRun returns the most recent run of a job, if any
+func (s *Scheduler) LastRun(name string) (RunRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := s.history[name]
+	if len(runs) == 0 {
+		return RunRecord{}, false
+	}
+	return runs[len(runs)-1], true
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	s.runOnce(ctx, job)
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, job)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	if ctx.Err() != nil {
+		return
+	}
+	record := RunRecord{Job: job.Name, StartedAt: s.now().UTC()}
+
+	if s.locker != nil {
+		unlock, ok, err := s.locker.TryLock(ctx, job.Name)
+		if err != nil {
+			record.Error = "acquire lock: " + err.Error()
+			s.finish(record)
+			return
+		}
+		if !ok {
+			record.Skipped = true
+			s.finish(record)
+			return
+		}
+		defer unlock()
+	}
+
+	rows, err := s.safeRun(ctx, job)
+	record.RowsAffected = rows
+	if err != nil {
+		record.Error = err.Error()
+	}
+	s.finish(record)
+}
+
+// safeRun keeps a panicking job from taking the whole server down
+func (s *Scheduler) safeRun(ctx context.Context, job Job) (rows int64, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func (s *Scheduler) finish(record RunRecord) {
+	record.FinishedAt = s.now().UTC()
+
+	switch {
+	case record.Error != "":
+		log.Printf("scheduler: job %q failed: %s", record.Job, record.Error)
+	case record.Skipped:
+		log.Printf("scheduler: job %q skipped, another replica holds the lock", record.Job)
+	default:
+		log.Printf("scheduler: job %q done, rows affected: %d", record.Job, record.RowsAffected)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := append(s.history[record.Job], record)
+	if len(runs) > historySize {
+		runs = runs[len(runs)-historySize:]
+	}
+	s.history[record.Job] = runs
+}
+
+// IntervalFromEnv reads a Go duration (e.g. "15m", "1h") from key, falling back when unset or invalid
+func IntervalFromEnv(key string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value <= 0 {
+		log.Printf("scheduler: invalid %s=%q, using %s", key, raw, fallback)
+		return fallback
+	}
+	return value
+}
+
+// BoolFromEnv reads a boolean flag from key, falling back when unset or invalid
+func BoolFromEnv(key string, fallback bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,46 @@
+package statement
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// windows1252 holds the characters of the bytes 0x80-0x9F in Windows-1252; the other bytes are
+// the Latin-1 characters of the same value. The bytes it leaves undefined map to C1 controls.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008d', 'Ž', '\u008f',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u009d', 'ž', 'Ÿ',
+}
+
+// utf8Text returns data as UTF-8 text: unchanged when it is valid UTF-8, and read as Windows-1252
+// otherwise. Bank exports that are not UTF-8 are almost always Windows-1252 or Latin-1 (OFX 1.x
+// files declare CHARSET:1252), and Latin-1 text reads the same as Windows-1252. Some files declare
+// 1252 but are UTF-8, so the declaration is not trusted over the bytes.
+func utf8Text(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	var out strings.Builder
+	out.Grow(len(data) + len(data)/4)
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			out.WriteByte(c)
+		case c < 0xA0:
+			out.WriteRune(windows1252[c-0x80])
+		default:
+			out.WriteRune(rune(c))
+		}
+	}
+	return out.String()
+}
+
+// utf8Reader reads all of r and returns it as UTF-8 text, like utf8Text
+func utf8Reader(r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(utf8Text(data)), nil
+}
@@ -0,0 +1,163 @@
+package statement
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"expense_tracker/domain"
+)
+
+// noteColumns are the header names tried, in order, when no note column is given
+var noteColumns = []string{"description", "note", "memo", "payee", "narrative", "details"}
+
+// CSVParser reads delimited statements, with a header row unless options.NoHeader is set
+type CSVParser struct{}
+
+// csvColumns holds the 0-based positions of the mapped columns; -1 when a column is absent
+type csvColumns struct {
+	date, amount, note, category, currency int
+}
+
+func (CSVParser) Parse(r io.Reader, options domain.ImportOptions) ([]domain.StatementRow, error) {
+	layouts, err := dateLayouts(options.DateFormats, "YYYY-MM-DD")
+	if err != nil {
+		return nil, err
+	}
+	if r, err = utf8Reader(r); err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(r)
+	if options.Delimiter != 0 {
+		reader.Comma = options.Delimiter
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var header []string
+	if !options.NoHeader {
+		header, err = reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("the file is empty")
+		}
+		if err != nil {
+			return nil, err
+		}
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	cols, err := mapColumns(header, options)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]domain.StatementRow, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if blank(record) {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, csvRow(record, line, cols, layouts, options.DecimalComma))
+	}
+	return rows, nil
+}
+
+func csvRow(record []string, line int, cols csvColumns, layouts []string, decimalComma bool) domain.StatementRow {
+	field := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	row := domain.StatementRow{
+		Line:     line,
+		Note:     field(cols.note),
+		Category: field(cols.category),
+		Currency: field(cols.currency),
+	}
+	date, err := parseDate(field(cols.date), layouts)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Date = date
+	amount, err := parseAmount(field(cols.amount), decimalComma)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Amount = amount
+	return row
+}
+
+// mapColumns finds the configured columns in the header, or takes them as 1-based positions
+func mapColumns(header []string, options domain.ImportOptions) (csvColumns, error) {
+	find := func(spec, fallback string, required bool) (int, error) {
+		given := strings.TrimSpace(spec) != ""
+		if !given {
+			spec = fallback
+		}
+		if n, err := strconv.Atoi(spec); err == nil {
+			if n < 1 {
+				return -1, fmt.Errorf("column %d: positions start at 1", n)
+			}
+			return n - 1, nil
+		}
+		for i, name := range header {
+			if spec != "" && strings.EqualFold(strings.TrimSpace(name), spec) {
+				return i, nil
+			}
+		}
+		if given || required {
+			if header == nil {
+				return -1, fmt.Errorf("column %q: without a header row, columns must be positions", spec)
+			}
+			return -1, fmt.Errorf("column %q not found in the header", spec)
+		}
+		return -1, nil
+	}
+
+	var cols csvColumns
+	var err error
+	if cols.date, err = find(options.DateColumn, "date", true); err != nil {
+		return cols, err
+	}
+	if cols.amount, err = find(options.AmountColumn, "amount", true); err != nil {
+		return cols, err
+	}
+	note := ""
+	for _, name := range noteColumns {
+		if i, _ := find("", name, false); i >= 0 {
+			note = name
+			break
+		}
+	}
+	if cols.note, err = find(options.NoteColumn, note, false); err != nil {
+		return cols, err
+	}
+	if cols.category, err = find(options.CategoryColumn, "category", false); err != nil {
+		return cols, err
+	}
+	if cols.currency, err = find(options.CurrencyColumn, "currency", false); err != nil {
+		return cols, err
+	}
+	return cols, nil
+}
+
+func blank(record []string) bool {
+	for _, f := range record {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+// Package statement reads bank statement files (CSV, OFX and QIF) into statement rows for the
+// import usecase
+package statement
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"expense_tracker/domain"
+)
+
+// dateLayout turns a date format such as "DD/MM/YYYY" into a time layout. YYYY, YY, MMM (Jan),
+// MM and DD (two digits), and M and D (one or two digits) are replaced; characters other than
+// letters are kept as they are.
+func dateLayout(format string) (string, error) {
+	tokens := []struct{ token, layout string }{
+		{"YYYY", "2006"}, {"YY", "06"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"}, {"DD", "02"}, {"D", "2"},
+	}
+	var layout strings.Builder
+	var year, month, day bool
+	for rest := strings.ToUpper(strings.TrimSpace(format)); rest != ""; {
+		matched := false
+		for _, t := range tokens {
+			if strings.HasPrefix(rest, t.token) {
+				layout.WriteString(t.layout)
+				rest = rest[len(t.token):]
+				year = year || t.token[0] == 'Y'
+				month = month || t.token[0] == 'M'
+				day = day || t.token[0] == 'D'
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		r := []rune(rest)[0]
+		if unicode.IsLetter(r) {
+			return "", fmt.Errorf("date format %q: unknown %q; use YYYY, YY, MMM, MM, M, DD and D", format, string(r))
+		}
+		layout.WriteRune(r)
+		rest = rest[len(string(r)):]
+	}
+	if !year || !month || !day {
+		return "", fmt.Errorf("date format %q needs a year, a month and a day", format)
+	}
+	return layout.String(), nil
+}
+
+// dateLayouts converts formats, or defaults when formats is empty
+func dateLayouts(formats []string, defaults ...string) ([]string, error) {
+	if len(formats) == 0 {
+		formats = defaults
+	}
+	layouts := make([]string, 0, len(formats))
+	for _, f := range formats {
+		layout, err := dateLayout(f)
+		if err != nil {
+			return nil, err
+		}
+		layouts = append(layouts, layout)
+	}
+	return layouts, nil
+}
+
+// parseDate tries each layout in turn
+func parseDate(value string, layouts []string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %q does not match the date formats", value)
+}
+
+// parseAmount reads an amount as written on a statement: a sign, a trailing minus or
+// parentheses for negatives, thousands separators, and currency symbols or codes around it
+func parseAmount(value string, decimalComma bool) (domain.Money, error) {
+	s := strings.TrimSpace(value)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+	}
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r == '.', r == ',':
+			digits.WriteRune(r)
+		case r == '-':
+			negative = !negative
+		}
+	}
+	number := digits.String()
+	if decimalComma {
+		number = strings.ReplaceAll(strings.ReplaceAll(number, ".", ""), ",", ".")
+	} else {
+		number = strings.ReplaceAll(number, ",", "")
+	}
+	if number == "" || strings.Count(number, ".") > 1 {
+		return domain.Money{}, fmt.Errorf("amount %q is not a number", value)
+	}
+	amount, err := domain.ParseMoney(number)
+	if err != nil {
+		return domain.Money{}, fmt.Errorf("amount %q is not a number", value)
+	}
+	if negative {
+		amount = amount.Neg()
+	}
+	return amount, nil
+}
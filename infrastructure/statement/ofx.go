@@ -0,0 +1,94 @@
+package statement
+
+import (
+	"errors"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"expense_tracker/domain"
+)
+
+// ofxField matches a tag and its value in both SGML (OFX 1.x, no closing tags) and XML (OFX 2.x)
+var ofxField = regexp.MustCompile(`<([A-Za-z0-9.]+)>([^<\r\n]*)`)
+
+// Tags are matched case-insensitively on the text itself, so the positions found index that text
+var (
+	ofxRoot           = regexp.MustCompile(`(?i)<OFX>`)
+	ofxTransaction    = regexp.MustCompile(`(?i)<STMTTRN>`)
+	ofxTransactionEnd = regexp.MustCompile(`(?i)</STMTTRN>|<STMTTRN>|</BANKTRANLIST>`)
+)
+
+// OFXParser reads OFX bank and credit card statements. Dates and amounts have a fixed form, so
+// only options.Currency is used, for statements without a CURDEF.
+type OFXParser struct{}
+
+func (OFXParser) Parse(r io.Reader, options domain.ImportOptions) ([]domain.StatementRow, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := utf8Text(data)
+	if !ofxRoot.MatchString(text) {
+		return nil, errors.New("not an OFX file")
+	}
+
+	currency := ""
+	rows := make([]domain.StatementRow, 0)
+	for i, start := 0, 0; ; i++ {
+		loc := ofxTransaction.FindStringIndex(text[start:])
+		if loc == nil {
+			break
+		}
+		start += loc[1]
+		if currency == "" {
+			currency = ofxFields(text[:start])["CURDEF"]
+		}
+		end := len(text)
+		if loc := ofxTransactionEnd.FindStringIndex(text[start:]); loc != nil {
+			end = start + loc[0]
+		}
+		rows = append(rows, ofxRow(ofxFields(text[start:end]), i+1, currency))
+	}
+	return rows, nil
+}
+
+// ofxFields returns the first value of each tag in the text
+func ofxFields(text string) map[string]string {
+	fields := make(map[string]string)
+	for _, m := range ofxField.FindAllStringSubmatch(text, -1) {
+		tag := strings.ToUpper(m[1])
+		if _, ok := fields[tag]; !ok {
+			fields[tag] = html.UnescapeString(strings.TrimSpace(m[2]))
+		}
+	}
+	return fields
+}
+
+func ofxRow(fields map[string]string, position int, currency string) domain.StatementRow {
+	row := domain.StatementRow{Line: position, Currency: currency, Note: fields["NAME"]}
+	if row.Note == "" {
+		row.Note = fields["MEMO"]
+	}
+	posted := fields["DTPOSTED"]
+	if len(posted) < 8 {
+		row.Error = "missing or invalid DTPOSTED"
+		return row
+	}
+	date, err := time.Parse("20060102", posted[:8])
+	if err != nil {
+		row.Error = "missing or invalid DTPOSTED"
+		return row
+	}
+	row.Date = date
+	value := fields["TRNAMT"]
+	amount, err := parseAmount(value, strings.Contains(value, ",") && !strings.Contains(value, "."))
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Amount = amount
+	return row
+}
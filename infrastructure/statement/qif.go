@@ -0,0 +1,113 @@
+package statement
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"expense_tracker/domain"
+)
+
+// QIFParser reads QIF statements. Only the transactions of bank, cash and card accounts are read;
+// category, class and account lists are skipped.
+type QIFParser struct{}
+
+func (QIFParser) Parse(r io.Reader, options domain.ImportOptions) ([]domain.StatementRow, error) {
+	layouts, err := dateLayouts(options.DateFormats, "M/D/YYYY", "M/D/YY")
+	if err != nil {
+		return nil, err
+	}
+	if r, err = utf8Reader(r); err != nil {
+		return nil, err
+	}
+
+	rows := make([]domain.StatementRow, 0)
+	transactions := true
+	var record map[byte]string
+	start := 0
+	flush := func() {
+		if record != nil && transactions {
+			rows = append(rows, qifRow(record, start, layouts, options.DecimalComma))
+		}
+		record = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		switch {
+		case strings.TrimSpace(text) == "":
+			continue
+		case text[0] == '!':
+			flush()
+			header := strings.ToLower(strings.TrimSpace(text))
+			if strings.HasPrefix(header, "!type:") {
+				switch strings.TrimSpace(strings.TrimPrefix(header, "!type:")) {
+				case "bank", "cash", "ccard", "oth a", "oth l":
+					transactions = true
+				default:
+					transactions = false
+				}
+			} else if strings.HasPrefix(header, "!account") {
+				transactions = false
+			}
+		case text[0] == '^':
+			flush()
+		default:
+			if record == nil {
+				record = make(map[byte]string)
+				start = line
+			}
+			if _, seen := record[text[0]]; !seen { // split lines (S, E, $) repeat; the first of each field counts
+				record[text[0]] = strings.TrimSpace(text[1:])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return rows, nil
+}
+
+func qifRow(record map[byte]string, line int, layouts []string, decimalComma bool) domain.StatementRow {
+	row := domain.StatementRow{Line: line, Note: record['P']}
+	if row.Note == "" {
+		row.Note = record['M']
+	}
+	// L holds "Category:Subcategory/Class", or "[Account]" for transfers
+	if category := record['L']; category != "" && !strings.HasPrefix(category, "[") {
+		row.Category, _, _ = strings.Cut(category, "/")
+	}
+
+	dateValue := strings.ReplaceAll(strings.ReplaceAll(record['D'], "'", "/"), " ", "")
+	if dateValue == "" {
+		row.Error = "missing date"
+		return row
+	}
+	date, err := parseDate(dateValue, layouts)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Date = date
+
+	value, ok := record['T']
+	if !ok {
+		value, ok = record['U']
+	}
+	if !ok {
+		row.Error = "missing amount"
+		return row
+	}
+	amount, err := parseAmount(value, decimalComma)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Amount = amount
+	return row
+}
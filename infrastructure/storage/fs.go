@@ -0,0 +1,100 @@
+// Package storage keeps blobs, such as expense attachments, for the repositories and usecases
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pkgrepo "expense_tracker/repository"
+)
+
+// ErrInvalidKey is returned for keys that are empty, absolute or climb out of the store
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// FileSystemStore implements BlobStore with one file per key under a root directory. Files are
+// written to a temporary name and renamed into place, so a failed upload never leaves a partial blob.
+type FileSystemStore struct {
+	root string
+}
+
+// NewFileSystemStore returns a store keeping files under root, which is created when missing
+func NewFileSystemStore(root string) (*FileSystemStore, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+	return &FileSystemStore{root: filepath.Clean(root)}, nil
+}
+
+func (s *FileSystemStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+
+	size, err := io.Copy(tmp, r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func (s *FileSystemStore) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, pkgrepo.ErrBlobNotFound
+	}
+	return f, err
+}
+
+// Delete removes the file of key, and its directory when that is left empty
+func (s *FileSystemStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != s.root {
+		_ = os.Remove(dir) // only succeeds when empty
+	}
+	return nil
+}
+
+// path returns the file of key under the root
+func (s *FileSystemStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == "." || clean == ".." ||
+		strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.root, clean), nil
+}
@@ -1,17 +1,30 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
 	httpdelivery "expense_tracker/delivery/http"
+	"expense_tracker/domain"
 	"expense_tracker/infrastructure/auth"
 	"expense_tracker/infrastructure/db"
+	"expense_tracker/infrastructure/export"
+	"expense_tracker/infrastructure/notify"
+	"expense_tracker/infrastructure/quickadd"
+	"expense_tracker/infrastructure/receipt"
 	infrarepo "expense_tracker/infrastructure/repository"
 	"expense_tracker/infrastructure/repositoryPG"
+	"expense_tracker/infrastructure/scheduler"
+	"expense_tracker/infrastructure/statement"
+	"expense_tracker/infrastructure/storage"
 	"expense_tracker/usecases"
 )
 
@@ -32,16 +45,102 @@ func main() {
 	debtReportRepo := repositoryPG.NewDebtRepoPG(db.DB)
 	debtRepo := infrarepo.NewDebtRepositoryPG(db.DB)
 	categoryRepo := infrarepo.NewCategoryRepoPG(db.DB)
+	notificationRepo := infrarepo.NewNotificationRepoPG(db.DB)
+	reminderRepo := infrarepo.NewReminderRepoPG(db.DB)
+	debtPaymentRepo := infrarepo.NewDebtPaymentRepoPG(db.DB)
+	budgetRepo := infrarepo.NewBudgetRepoPG(db.DB)
+	incomeRepo := infrarepo.NewIncomeRepoPG(db.DB)
+	incomeCategoryRepo := infrarepo.NewIncomeCategoryRepoPG(db.DB)
+	exchangeRateRepo := infrarepo.NewExchangeRateRepoPG(db.DB)
+	syncRepo := infrarepo.NewSyncRepoPG(db.DB)
+	contactRepo := infrarepo.NewContactRepoPG(db.DB)
+	ledgerRepo := infrarepo.NewLedgerRepoPG(db.DB)
+	settlementRepo := infrarepo.NewSettlementRepoPG(db.DB)
+	categoryRuleRepo := infrarepo.NewCategoryRuleRepoPG(db.DB)
+	exportRepo := infrarepo.NewExportRepoPG(db.DB)
+	attachmentRepo := infrarepo.NewAttachmentRepoPG(db.DB)
+	receiptDraftRepo := infrarepo.NewReceiptDraftRepoPG(db.DB)
+
+	// Expense attachments are kept on the local filesystem; deleting an expense removes its files
+	attachmentsDir := os.Getenv("ATTACHMENTS_DIR")
+	if attachmentsDir == "" {
+		attachmentsDir = "data/attachments"
+	}
+	blobStore, err := storage.NewFileSystemStore(attachmentsDir)
+	if err != nil {
+		log.Fatalf("failed to open attachment storage %s: %v", attachmentsDir, err)
+	}
+	expenseRepo.SetBlobStore(blobStore)
 
 	hasher := auth.BcryptHasher{}
 	jwtSvc := auth.NewJWTService(os.Getenv("JWT_SECRET"))
 
 	authUC := usecases.NewAuthUsecase(userRepo, refreshTokenRepo, hasher, jwtSvc)
 	userUC := usecases.NewUserUsecase(userRepo)
-	reportUC := usecases.NewReportUsecase(expenseRepo, debtReportRepo)
-	debtUsecase := usecases.NewDebtUsecase(debtRepo)
-	expenseUC := usecases.NewExpenseUseCase(expenseRepo)
+	reportUC := usecases.NewReportUsecase(expenseRepo, debtReportRepo, budgetRepo, userRepo, incomeRepo, exchangeRateRepo)
+	debtUsecase := usecases.NewDebtUsecase(debtRepo, userRepo, reminderRepo, debtPaymentRepo, contactRepo)
+	expenseUC := usecases.NewExpenseUseCase(expenseRepo, userRepo)
+	expenseUC.SetSplitRepository(expenseRepo, contactRepo)
 	categoryUC := usecases.NewCategoryUseCase(categoryRepo)
+	budgetUC := usecases.NewBudgetUseCase(budgetRepo, categoryRepo, userRepo)
+	incomeUC := usecases.NewIncomeUseCase(incomeRepo, incomeCategoryRepo, userRepo)
+	syncUC := usecases.NewSyncUseCase(expenseRepo, debtRepo, categoryRepo, userRepo, syncRepo)
+	exchangeRateUC := usecases.NewExchangeRateUseCase(exchangeRateRepo)
+	contactUC := usecases.NewContactUseCase(contactRepo)
+	ledgerUC := usecases.NewLedgerUseCase(ledgerRepo, userRepo)
+	settlementUC := usecases.NewSettlementUseCase(settlementRepo, ledgerRepo)
+	importUC := usecases.NewImportUseCase(expenseRepo, categoryRepo, userRepo, map[domain.ImportFormat]usecases.StatementParser{
+		domain.ImportFormatCSV: statement.CSVParser{},
+		domain.ImportFormatOFX: statement.OFXParser{},
+		domain.ImportFormatQIF: statement.QIFParser{},
+	})
+	attachmentUC := usecases.NewAttachmentUseCase(attachmentRepo, expenseRepo, blobStore)
+	// Receipts are read from their PDF text layer; the AI service is tried after it when enabled
+	receiptExtractors := []usecases.ReceiptExtractor{receipt.PDFExtractor{}}
+	if scheduler.BoolFromEnv("RECEIPT_AI_ENABLED", false) && os.Getenv("GEMINI_API_KEY") != "" {
+		receiptExtractors = append(receiptExtractors, httpdelivery.NewAIReceiptExtractor(os.Getenv("RECEIPT_AI_MODEL"), receipt.PDFText))
+	}
+	receiptUC := usecases.NewReceiptUseCase(receiptDraftRepo, expenseUC, blobStore, receiptExtractors...)
+	// Quick-add texts are read by the local grammar; the AI service is tried after it when enabled
+	quickAddParsers := []usecases.QuickAddParser{quickadd.Grammar{}}
+	if scheduler.BoolFromEnv("QUICK_ADD_AI_ENABLED", false) && os.Getenv("GEMINI_API_KEY") != "" {
+		quickAddParsers = append(quickAddParsers, httpdelivery.NewAIQuickAddParser(os.Getenv("QUICK_ADD_AI_MODEL")))
+	}
+	quickAddUC := usecases.NewQuickAddUseCase(categoryRepo, expenseUC, debtUsecase, quickAddParsers...)
+	exportUC := usecases.NewExportUseCase(exportRepo, reportUC, map[domain.ExportFormat]usecases.ExportEncoder{
+		domain.ExportFormatCSV:  export.CSVEncoder{},
+		domain.ExportFormatJSON: export.JSONEncoder{},
+		domain.ExportFormatXLSX: export.XLSXEncoder{},
+	})
+
+	// Category rules file new expenses without a category, whether created, imported, synced, quick-added or read from a receipt
+	categoryRuleUC := usecases.NewCategoryRuleUseCase(categoryRuleRepo, categoryRepo, expenseRepo)
+	expenseUC.SetCategorizer(categoryRuleUC)
+	importUC.SetCategorizer(categoryRuleUC)
+	syncUC.SetCategorizer(categoryRuleUC)
+	receiptUC.SetCategorizer(categoryRuleUC)
+	quickAddUC.SetCategorizer(categoryRuleUC)
+
+	// Exchange rates for converting report totals can be preloaded from a local CSV or JSON file
+	if path := os.Getenv("EXCHANGE_RATES_FILE"); path != "" {
+		n, err := exchangeRateUC.LoadFile(context.Background(), path)
+		if err != nil {
+			log.Printf("exchange rates: failed to load %s: %v", path, err)
+		} else {
+			log.Printf("exchange rates: loaded %d rates from %s", n, path)
+		}
+	}
+
+	// Notification channels are enabled by config; the log notifier is always available
+	notifiers := []usecases.Notifier{notify.NewLogNotifier()}
+	if smtpNotifier := notify.SMTPNotifierFromEnv(); smtpNotifier != nil {
+		notifiers = append(notifiers, smtpNotifier)
+	}
+	if webhookNotifier := notify.WebhookNotifierFromEnv(); webhookNotifier != nil {
+		notifiers = append(notifiers, webhookNotifier)
+	}
+	notificationUC := usecases.NewNotificationUseCase(notificationRepo, userRepo, debtRepo, expenseRepo, notifiers...)
+	debtUsecase.SetReminderQueue(notificationUC)
 
 	authHandler := httpdelivery.NewAuthHandler(authUC)
 	userHandler := httpdelivery.NewUserHandler(userUC, jwtSvc)
@@ -49,6 +148,20 @@ func main() {
 	debtHandler := httpdelivery.NewDebtHandler(debtUsecase, jwtSvc)
 	expenseHandler := httpdelivery.NewExpenseHandler(expenseUC)
 	categoryHandler := httpdelivery.NewCategoryHandler(categoryUC)
+	syncHandler := httpdelivery.NewSyncHandler(syncUC)
+	notificationHandler := httpdelivery.NewNotificationHandler(notificationUC, jwtSvc)
+	budgetHandler := httpdelivery.NewBudgetHandler(budgetUC, jwtSvc)
+	incomeHandler := httpdelivery.NewIncomeHandler(incomeUC, jwtSvc)
+	exchangeRateHandler := httpdelivery.NewExchangeRateHandler(exchangeRateUC, jwtSvc, os.Getenv("ADMIN_API_KEY"))
+	contactHandler := httpdelivery.NewContactHandler(contactUC, jwtSvc)
+	ledgerHandler := httpdelivery.NewLedgerHandler(ledgerUC, jwtSvc)
+	settlementHandler := httpdelivery.NewSettlementHandler(settlementUC, jwtSvc)
+	importHandler := httpdelivery.NewImportHandler(importUC)
+	categoryRuleHandler := httpdelivery.NewCategoryRuleHandler(categoryRuleUC)
+	exportHandler := httpdelivery.NewExportHandler(exportUC)
+	expenseHandler.SetAttachmentHandler(httpdelivery.NewAttachmentHandler(attachmentUC))
+	receiptHandler := httpdelivery.NewReceiptHandler(receiptUC)
+	quickAddHandler := httpdelivery.NewQuickAddHandler(quickAddUC)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/auth/register", authHandler.Register)
@@ -62,14 +175,95 @@ func main() {
 	mux.HandleFunc("/reports/monthly", reportHandler.GetMonthlyReport)
 	httpdelivery.RegisterDebtRoutes(mux, debtHandler)
 	httpdelivery.RegisterExpenseRoutes(mux, expenseHandler)
+	httpdelivery.RegisterReceiptRoutes(mux, receiptHandler)
 	httpdelivery.RegisterCategoryRoutes(mux, categoryHandler)
+	httpdelivery.RegisterCategoryRuleRoutes(mux, categoryRuleHandler)
+	httpdelivery.RegisterSyncRoutes(mux, syncHandler)
+	httpdelivery.RegisterNotificationRoutes(mux, notificationHandler)
+	httpdelivery.RegisterBudgetRoutes(mux, budgetHandler)
+	httpdelivery.RegisterIncomeRoutes(mux, incomeHandler)
+	httpdelivery.RegisterExchangeRateRoutes(mux, exchangeRateHandler)
+	httpdelivery.RegisterContactRoutes(mux, contactHandler)
+	httpdelivery.RegisterLedgerRoutes(mux, ledgerHandler)
+	httpdelivery.RegisterSettlementRoutes(mux, settlementHandler)
+	httpdelivery.RegisterImportRoutes(mux, importHandler)
+	httpdelivery.RegisterExportRoutes(mux, exportHandler)
+	httpdelivery.RegisterQuickAddRoutes(mux, quickAddHandler)
 	httpdelivery.ServeAPIDocs(mux)
 
-	// JWT auth for /expenses and /categories; other routes unchanged
-	handler := httpdelivery.JWTAuthMiddleware(jwtSvc, mux)
+	// JWT auth for /expenses, /categories, /budgets, /imports, /exports, /quick-add and /sync, with ledger role checks; other routes unchanged
+	handler := httpdelivery.JWTAuthMiddleware(jwtSvc, ledgerUC, mux)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background jobs: overdue marking, recurring expenses and income, reminder checks, notification delivery and receipt reading
+	var jobs *scheduler.Scheduler
+	if scheduler.BoolFromEnv("SCHEDULER_ENABLED", true) {
+		var locker scheduler.Locker
+		if scheduler.BoolFromEnv("SCHEDULER_ADVISORY_LOCK", false) {
+			locker = scheduler.NewPGAdvisoryLocker(db.DB)
+		}
+		jobs = scheduler.New(locker)
+		jobs.Register(scheduler.Job{
+			Name:     "debt-overdue-check",
+			Interval: scheduler.IntervalFromEnv("OVERDUE_CHECK_INTERVAL", time.Hour),
+			Run:      debtUsecase.RunOverdueCheck,
+		})
+		jobs.Register(scheduler.Job{
+			Name:     "debt-reminder-check",
+			Interval: scheduler.IntervalFromEnv("REMINDER_CHECK_INTERVAL", 15*time.Minute),
+			Run: func(ctx context.Context) (int64, error) {
+				debts, err := debtUsecase.RunReminderCheck(ctx)
+				return int64(len(debts)), err
+			},
+		})
+		jobs.Register(scheduler.Job{
+			Name:     "recurring-expense-materialization",
+			Interval: scheduler.IntervalFromEnv("RECURRING_EXPENSE_INTERVAL", time.Hour),
+			Run:      expenseUC.RunRecurringExpenses,
+		})
+		jobs.Register(scheduler.Job{
+			Name:     "recurring-income-materialization",
+			Interval: scheduler.IntervalFromEnv("RECURRING_INCOME_INTERVAL", time.Hour),
+			Run:      incomeUC.RunRecurringIncome,
+		})
+		jobs.Register(scheduler.Job{
+			Name:     "recurring-expense-reminder-check",
+			Interval: scheduler.IntervalFromEnv("REMINDER_CHECK_INTERVAL", 15*time.Minute),
+			Run:      notificationUC.RunRecurringExpenseReminderCheck,
+		})
+		jobs.Register(scheduler.Job{
+			Name:     "notification-delivery",
+			Interval: scheduler.IntervalFromEnv("NOTIFICATION_DELIVERY_INTERVAL", time.Minute),
+			Run:      notificationUC.DeliverPending,
+		})
+		jobs.Register(scheduler.Job{
+			Name:     "receipt-extraction",
+			Interval: scheduler.IntervalFromEnv("RECEIPT_EXTRACTION_INTERVAL", 30*time.Second),
+			Run:      receiptUC.ProcessPending,
+		})
+		jobs.Start(ctx)
+	}
+
+	server := &http.Server{Addr: ":8080", Handler: handler}
+	go func() {
+		log.Println("Server started on :8080")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("server stopped: %v", err)
+		}
+	}()
 
-	log.Println("Server started on :8080")
-	if err := http.ListenAndServe(":8080", handler); err != nil {
-		log.Fatalf("server stopped: %v", err)
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("server shutdown: %v", err)
+	}
+	if jobs != nil {
+		jobs.Wait()
 	}
+	log.Println("Server stopped")
 }
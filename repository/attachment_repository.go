@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"expense_tracker/domain"
+)
+
+// AttachmentRepository stores the records of expense attachments; their content is in a BlobStore.
+// Reads are not scoped to a user: callers check the expense is readable first.
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *domain.Attachment) error                   // sql.ErrNoRows when the uploader may not change the expense
+	GetByID(ctx context.Context, expenseID, id string) (*domain.Attachment, error)     // nil, nil when not found
+	ListByExpense(ctx context.Context, expenseID string) ([]*domain.Attachment, error) // oldest first
+	Delete(ctx context.Context, expenseID, id, userID string) error                    // sql.ErrNoRows when userID may not change the expense
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned by BlobStore.Open for a key that holds nothing
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps file contents under keys such as "expenses/<id>/<attachment id>"
+// (implementations live in infrastructure/storage)
+type BlobStore interface {
+	// Put stores everything read from r under key, replacing what was there, and returns its size.
+	// Nothing is kept when reading r fails.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error // nil when the key holds nothing
+}
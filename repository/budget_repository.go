@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"expense_tracker/domain"
+)
+
+// BudgetRepository persists monthly budgets
+type BudgetRepository interface {
+	Create(ctx context.Context, budget *domain.Budget) error
+	GetByID(ctx context.Context, id, userID string) (*domain.Budget, error) // own or in one of the user's ledgers; nil, nil when not found
+	// GetByCategory finds the user's own budget, or the ledger's when ledgerID is not nil. A nil
+	// categoryID means the overall budget; nil, nil when not set.
+	GetByCategory(ctx context.Context, userID string, ledgerID, categoryID *string) (*domain.Budget, error)
+	ListByUser(ctx context.Context, userID string) ([]*domain.Budget, error) // the user's own budgets
+	ListByLedger(ctx context.Context, ledgerID string) ([]*domain.Budget, error)
+	Update(ctx context.Context, budget *domain.Budget, userID string) error // sql.ErrNoRows when userID may not change it
+	Delete(ctx context.Context, id, userID string) error                    // sql.ErrNoRows when not found or userID may not change it
+}
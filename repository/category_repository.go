@@ -6,15 +6,19 @@ import (
 )
 
 type ListOptions struct {
-	Limit  int
-	Offset int
+	Limit     int
+	Offset    int
+	Cursor    *domain.PageCursor // keyset pagination from this position instead of Offset (debts only)
+	SkipTotal bool               // return a total of 0 instead of counting the rows (debts only)
 }
 
 // CategoryRepository defines persistence for categories
 type CategoryRepository interface {
 	Create(ctx context.Context, input domain.CreateCategoryInput) (*domain.Category, error)
 	GetByID(ctx context.Context, id string, userID *string) (*domain.Category, error)
-	List(ctx context.Context, userID *string, options ListOptions) ([]*domain.Category, int, error) // nil userID = global only; non-nil = global + user's
+	List(ctx context.Context, userID *string, options ListOptions) ([]*domain.Category, int, error)                  // nil userID = global only; non-nil = global + user's
+	ListByLedger(ctx context.Context, ledgerID, userID string, options ListOptions) ([]*domain.Category, int, error) // global + the ledger's, if userID is a member
 	Update(ctx context.Context, id string, userID *string, input domain.UpdateCategoryInput) (*domain.Category, error)
 	Delete(ctx context.Context, id string, userID *string) error
+	ListChangedSince(ctx context.Context, userID string, since domain.SyncPosition, before uint64, limit int) ([]*domain.Category, error) // global + user's, includes tombstones
 }
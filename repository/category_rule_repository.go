@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"expense_tracker/domain"
+)
+
+// CategoryRuleRepository persists category rules. Lists skip rules whose category was deleted and
+// return the rest highest priority first, oldest first on ties.
+type CategoryRuleRepository interface {
+	Create(ctx context.Context, rule *domain.CategoryRule) error
+	GetByID(ctx context.Context, id, userID string) (*domain.CategoryRule, error)  // own or in one of the user's ledgers; nil, nil when not found
+	ListByUser(ctx context.Context, userID string) ([]*domain.CategoryRule, error) // the user's own rules
+	ListByLedger(ctx context.Context, ledgerID string) ([]*domain.CategoryRule, error)
+	Update(ctx context.Context, rule *domain.CategoryRule, userID string) error // sql.ErrNoRows when userID may not change it
+	Delete(ctx context.Context, id, userID string) error                        // sql.ErrNoRows when not found or userID may not change it
+	// Recategorize files each expense (by ID) under its category (by ID) in one transaction,
+	// skipping expenses userID may not change, and returns how many were changed
+	Recategorize(ctx context.Context, userID string, categories map[string]string) (int, error)
+}
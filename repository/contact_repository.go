@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"expense_tracker/domain"
+)
+
+// ContactRepository persists the contacts debts are linked to
+type ContactRepository interface {
+	Create(ctx context.Context, contact *domain.Contact) error
+	GetByID(ctx context.Context, id, userID string) (*domain.Contact, error)     // nil, nil when not found
+	GetByName(ctx context.Context, userID, name string) (*domain.Contact, error) // case-insensitive; nil, nil when not found
+	List(ctx context.Context, userID string) ([]*domain.Contact, error)
+	Update(ctx context.Context, contact *domain.Contact) error // also renames the contact's debts
+	Delete(ctx context.Context, id, userID string) error       // sql.ErrNoRows when not found
+	CountDebts(ctx context.Context, id string) (int, error)    // debts that are not deleted
+	// Balances totals the outstanding balance of the contact's debts per currency
+	Balances(ctx context.Context, id string) ([]domain.ContactBalance, error)
+}
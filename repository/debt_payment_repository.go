@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"expense_tracker/domain"
+)
+
+// DebtPaymentRepository persists debt repayments
+type DebtPaymentRepository interface {
+	// Create records the payment and marks the debt paid once its payments cover its amount.
+	// It returns the debt with its updated balance.
+	Create(ctx context.Context, payment *domain.DebtPayment) (*domain.Debt, error)
+	ListByDebt(ctx context.Context, debtID string) ([]*domain.DebtPayment, error)
+}
@@ -18,8 +18,12 @@ type DebtRepository interface {
 	SetOverdue(ctx context.Context, nowUTC string) (int64, error)
 	GetDueForReminder(ctx context.Context, nowUTC string) ([]*domain.Debt, error)
 	UpdateReminder(ctx context.Context, id string, remindAtUTC string, sentAtUTC string) error
+	SetRemindAt(ctx context.Context, id string, remindAtUTC string) error
+	ListChangedSince(ctx context.Context, userID string, since domain.SyncPosition, before uint64, limit int) ([]*domain.Debt, error) // includes tombstones
 }
 
 type DebtReportRepository interface {
-	SumByDateRangeAndType(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time, debtType string) (float64, error)
+	SumByDateRangeAndType(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time, debtType string) (domain.Money, error)
+	// SumPaymentsByDateRangeAndType totals repayments made in the date range on debts of one type
+	SumPaymentsByDateRangeAndType(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time, debtType string) (domain.Money, error)
 }
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"expense_tracker/domain"
+
+	"github.com/google/uuid"
+)
+
+// ExchangeRateRepository persists daily exchange rates used to convert report totals
+type ExchangeRateRepository interface {
+	Upsert(ctx context.Context, rates []domain.ExchangeRate) (int, error) // one transaction; a rate for the same pair and date is replaced
+	List(ctx context.Context, filter domain.ExchangeRateFilter) ([]*domain.ExchangeRate, int, error)
+}
+
+// MissingRateRepository finds the amounts report totals leave out for lack of an exchange rate
+type MissingRateRepository interface {
+	// MissingRates groups by kind and currency the user's expenses, incomes, debts and repayments
+	// dated in the range that cannot be converted into the user's default currency
+	MissingRates(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]domain.MissingRate, error)
+}
@@ -11,8 +11,9 @@ import (
 
 // CategoryTotal holds category name and total for report breakdowns
 type CategoryTotal struct {
+	CategoryID   *string // nil for uncategorized expenses
 	CategoryName string
-	Total        float64
+	Total        domain.Money
 }
 
 // ExpenseRepository defines persistence for expenses (CRUD + report aggregation)
@@ -21,9 +22,28 @@ type ExpenseRepository interface {
 	Create(ctx context.Context, input domain.CreateExpenseInput) (*domain.Expense, error)
 	GetByID(ctx context.Context, id, userID string) (*domain.Expense, error)
 	List(ctx context.Context, filter domain.ExpenseFilter) ([]*domain.Expense, int, error)
+	Search(ctx context.Context, search domain.ExpenseSearch) ([]*domain.Expense, int, *domain.ExpenseFacets, error)
 	Update(ctx context.Context, id, userID string, input domain.UpdateExpenseInput) (*domain.Expense, error)
 	Delete(ctx context.Context, id, userID string) error
+	// Offline sync
+	GetByIDs(ctx context.Context, ids []string) ([]*domain.Expense, error)                                  // not scoped to a user; callers check ownership
+	CreateBatch(ctx context.Context, inputs []domain.CreateExpenseInput) ([]string, error)                  // one transaction; returns IDs actually inserted
+	ListChangedSince(ctx context.Context, userID string, since domain.SyncPosition, before uint64, limit int) ([]*domain.Expense, error) // includes tombstones
+	// Recurring expenses
+	ListRecurringDue(ctx context.Context, nowUTC string, limit int) ([]*domain.Expense, error) // templates of all users
+	MaterializeOccurrences(ctx context.Context, template *domain.Expense, dates []time.Time, nextDue *time.Time) (int, error)
+	// Reminders
+	ListRecurringDueForReminder(ctx context.Context, nowUTC string) ([]*domain.Expense, error)
+	MarkReminderSent(ctx context.Context, id string, sentAt time.Time) error
 	// Report aggregation (reports usecase)
-	SumByDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) (float64, error)
+	SumByDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) (domain.Money, error)
 	CategoryBreakdownByDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]CategoryTotal, error)
 }
+
+// ExpenseSplitRepository stores a split expense together with the debts of its other participants
+type ExpenseSplitRepository interface {
+	// CreateSplit inserts the expense and the debts in one transaction. Debts without a ContactID
+	// are linked to the user's contact named PeerName, which is added if missing. Each debt gets
+	// the expense's ID and currency.
+	CreateSplit(ctx context.Context, input domain.CreateExpenseInput, debts []*domain.Debt) (*domain.Expense, error)
+}
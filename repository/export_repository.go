@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"expense_tracker/domain"
+)
+
+// ExportRepository reads records for exports one at a time, so an export never holds them all
+type ExportRepository interface {
+	// StreamExpenses calls fn for each expense matching filter (Limit and Offset are ignored),
+	// oldest first; it stops at the first error fn returns
+	StreamExpenses(ctx context.Context, filter domain.ExpenseFilter, fn func(*domain.ExpenseRecord) error) error
+	// StreamDebts calls fn for each of the user's debts due between from and to (both optional,
+	// inclusive), soonest due first
+	StreamDebts(ctx context.Context, userID string, from, to *time.Time, fn func(*domain.Debt) error) error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"expense_tracker/domain"
+
+	"github.com/google/uuid"
+)
+
+// IncomeRepository defines persistence for incomes (CRUD, recurrence, report aggregation)
+type IncomeRepository interface {
+	Create(ctx context.Context, input domain.CreateIncomeInput) (*domain.Income, error)
+	GetByID(ctx context.Context, id, userID string) (*domain.Income, error)
+	List(ctx context.Context, filter domain.IncomeFilter) ([]*domain.Income, int, error)
+	Update(ctx context.Context, id, userID string, input domain.UpdateIncomeInput) (*domain.Income, error)
+	Delete(ctx context.Context, id, userID string) error
+	// Recurring incomes
+	ListRecurringDue(ctx context.Context, nowUTC string, limit int) ([]*domain.Income, error) // templates of all users
+	MaterializeOccurrences(ctx context.Context, template *domain.Income, dates []time.Time, nextDue *time.Time) (int, error)
+	// Report aggregation
+	SumByDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) (domain.Money, error)
+}
+
+// IncomeCategoryRepository defines persistence for income categories
+type IncomeCategoryRepository interface {
+	Create(ctx context.Context, category *domain.IncomeCategory) error
+	GetByID(ctx context.Context, id, userID string) (*domain.IncomeCategory, error) // global or the user's own
+	List(ctx context.Context, userID string) ([]*domain.IncomeCategory, error)      // global and the user's own
+	Delete(ctx context.Context, id, userID string) error                            // user-defined only
+}
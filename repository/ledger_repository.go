@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"expense_tracker/domain"
+)
+
+// LedgerRepository persists shared ledgers and their members
+type LedgerRepository interface {
+	// Create inserts the ledger with its creator as owner in one transaction
+	Create(ctx context.Context, ledger *domain.Ledger) error
+	GetByID(ctx context.Context, id, userID string) (*domain.Ledger, error) // nil, nil unless userID is a member; sets Role
+	ListByUser(ctx context.Context, userID string) ([]*domain.Ledger, error)
+	Update(ctx context.Context, ledger *domain.Ledger) error
+	Delete(ctx context.Context, id string) error // sql.ErrNoRows when not found
+	// Members
+	GetMember(ctx context.Context, ledgerID, userID string) (*domain.LedgerMember, error) // nil, nil when not a member
+	ListMembers(ctx context.Context, ledgerID string) ([]*domain.LedgerMember, error)
+	AddMember(ctx context.Context, member *domain.LedgerMember) error
+	UpdateMemberRole(ctx context.Context, ledgerID, userID string, role domain.LedgerRole) error // sql.ErrNoRows when not a member
+	RemoveMember(ctx context.Context, ledgerID, userID string) error                             // sql.ErrNoRows when not a member
+	CountOwners(ctx context.Context, ledgerID string) (int, error)
+}
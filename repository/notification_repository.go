@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"expense_tracker/domain"
+)
+
+// NotificationRepository persists notification deliveries and per-user channel preferences
+type NotificationRepository interface {
+	Enqueue(ctx context.Context, n *domain.Notification) (bool, error) // false when the dedupe key already exists
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Notification, error)
+	MarkSent(ctx context.Context, id string, sentAt time.Time) error
+	MarkAttemptFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time, status domain.NotificationStatus) error
+	ListByUser(ctx context.Context, userID string, options ListOptions) ([]*domain.Notification, int, error)
+	GetPreferences(ctx context.Context, userID string) ([]*domain.NotificationPreference, error)
+	ReplacePreferences(ctx context.Context, userID string, prefs []*domain.NotificationPreference) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"expense_tracker/domain"
+)
+
+// ReceiptDraftRepository stores uploaded receipts until they are confirmed as expenses. Drafts
+// belong to the user who uploaded them, in the ledger they were uploaded to (nil = their own).
+type ReceiptDraftRepository interface {
+	Create(ctx context.Context, draft *domain.ReceiptDraft) error
+	GetByID(ctx context.Context, id, userID string, ledgerID *string) (*domain.ReceiptDraft, error) // nil, nil when not found
+	List(ctx context.Context, userID string, ledgerID *string) ([]*domain.ReceiptDraft, error)      // newest first
+	Delete(ctx context.Context, id, userID string, ledgerID *string) error                          // sql.ErrNoRows when not found
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.ReceiptDraft, error)          // pending drafts due an attempt, oldest first
+	MarkExtracted(ctx context.Context, id, extractor string, receipt *domain.ReceiptData, expense *domain.CreateExpenseInput) error
+	MarkAttemptFailed(ctx context.Context, id, lastError string, nextAttemptAt time.Time, status domain.ReceiptDraftStatus) error
+	// Attach deletes the draft and records its file as attachment in one transaction;
+	// sql.ErrNoRows when the draft is gone
+	Attach(ctx context.Context, id, userID string, attachment *domain.Attachment) error
+}
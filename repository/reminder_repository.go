@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"expense_tracker/domain"
+)
+
+// ReminderRepository persists scheduled debt reminders
+type ReminderRepository interface {
+	Create(ctx context.Context, reminder *domain.Reminder) error
+	GetByID(ctx context.Context, id string) (*domain.Reminder, error) // nil, nil when not found
+	ListByDebt(ctx context.Context, debtID string) ([]*domain.Reminder, error)
+	CountByDebt(ctx context.Context, debtID string) (int, error)
+	Update(ctx context.Context, reminder *domain.Reminder) error
+	Delete(ctx context.Context, id string) error
+	// ListDue returns enabled, unfired reminders at or before now for unpaid debts with reminders on
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Reminder, error)
+	MarkFired(ctx context.Context, id string, firedAt time.Time) error
+}
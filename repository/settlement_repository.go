@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"expense_tracker/domain"
+)
+
+// MemberTotal is an amount in one currency attributed to one member of a shared ledger
+type MemberTotal struct {
+	UserID   string
+	Currency string
+	Total    domain.Money
+}
+
+// SettlementRepository reads what the members of a shared ledger paid and records how they settle up
+type SettlementRepository interface {
+	// PaidByMember totals the ledger's expenses by the user who recorded them and currency
+	PaidByMember(ctx context.Context, ledgerID string) ([]MemberTotal, error)
+	// SettledByMember totals the payments of the ledger's debts by debt owner and currency:
+	// payments on borrowed debts count as paid out, payments on lent debts as received
+	SettledByMember(ctx context.Context, ledgerID string) ([]MemberTotal, error)
+	// Record stores a settle-up payment in one transaction as two debts of the full amount, each
+	// with a payment covering it: payer's (borrowed) and payee's (lent). Both are linked to the
+	// owner's contact named PeerName, which is added if missing.
+	Record(ctx context.Context, payer, payee *domain.Debt) error
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+// SyncRepository bounds the sync change feed to changes that can no longer be overtaken
+type SyncRepository interface {
+	// Watermark returns the oldest transaction that may still be running: changes written by
+	// earlier transactions are all visible, later ones may still commit
+	Watermark(ctx context.Context) (uint64, error)
+}
@@ -50,8 +50,18 @@ type fakeExpenseRepo struct {
 	createFn func(context.Context, domain.CreateExpenseInput) (*domain.Expense, error)
 	getFn    func(context.Context, string, string) (*domain.Expense, error)
 	listFn   func(context.Context, domain.ExpenseFilter) ([]*domain.Expense, int, error)
+	searchFn func(context.Context, domain.ExpenseSearch) ([]*domain.Expense, int, *domain.ExpenseFacets, error)
 	updateFn func(context.Context, string, string, domain.UpdateExpenseInput) (*domain.Expense, error)
 	deleteFn func(context.Context, string, string) error
+
+	getByIDsFn    func(context.Context, []string) ([]*domain.Expense, error)
+	createBatchFn func(context.Context, []domain.CreateExpenseInput) ([]string, error)
+	changedFn     func(context.Context, string, domain.SyncPosition, uint64, int) ([]*domain.Expense, error)
+
+	recurringDueFn func(context.Context, string, int) ([]*domain.Expense, error)
+	materializeFn  func(context.Context, *domain.Expense, []time.Time, *time.Time) (int, error)
+
+	categoryTotals []repository.CategoryTotal
 }
 
 func (f fakeExpenseRepo) Create(ctx context.Context, in domain.CreateExpenseInput) (*domain.Expense, error) {
@@ -63,25 +73,54 @@ func (f fakeExpenseRepo) GetByID(ctx context.Context, id, userID string) (*domai
 func (f fakeExpenseRepo) List(ctx context.Context, filter domain.ExpenseFilter) ([]*domain.Expense, int, error) {
 	return f.listFn(ctx, filter)
 }
+func (f fakeExpenseRepo) Search(ctx context.Context, search domain.ExpenseSearch) ([]*domain.Expense, int, *domain.ExpenseFacets, error) {
+	return f.searchFn(ctx, search)
+}
 func (f fakeExpenseRepo) Update(ctx context.Context, id, userID string, in domain.UpdateExpenseInput) (*domain.Expense, error) {
 	return f.updateFn(ctx, id, userID, in)
 }
 func (f fakeExpenseRepo) Delete(ctx context.Context, id, userID string) error {
 	return f.deleteFn(ctx, id, userID)
 }
-func (fakeExpenseRepo) SumByDateRange(context.Context, uuid.UUID, time.Time, time.Time) (float64, error) {
-	return 0, nil
+func (f fakeExpenseRepo) GetByIDs(ctx context.Context, ids []string) ([]*domain.Expense, error) {
+	return f.getByIDsFn(ctx, ids)
+}
+func (f fakeExpenseRepo) CreateBatch(ctx context.Context, in []domain.CreateExpenseInput) ([]string, error) {
+	return f.createBatchFn(ctx, in)
+}
+func (f fakeExpenseRepo) ListChangedSince(ctx context.Context, userID string, since domain.SyncPosition, before uint64, limit int) ([]*domain.Expense, error) {
+	return f.changedFn(ctx, userID, since, before, limit)
+}
+func (f fakeExpenseRepo) ListRecurringDue(ctx context.Context, nowUTC string, limit int) ([]*domain.Expense, error) {
+	return f.recurringDueFn(ctx, nowUTC, limit)
+}
+func (f fakeExpenseRepo) MaterializeOccurrences(ctx context.Context, template *domain.Expense, dates []time.Time, nextDue *time.Time) (int, error) {
+	return f.materializeFn(ctx, template, dates, nextDue)
 }
-func (fakeExpenseRepo) CategoryBreakdownByDateRange(context.Context, uuid.UUID, time.Time, time.Time) ([]repository.CategoryTotal, error) {
+func (fakeExpenseRepo) ListRecurringDueForReminder(context.Context, string) ([]*domain.Expense, error) {
 	return nil, nil
 }
+func (fakeExpenseRepo) MarkReminderSent(context.Context, string, time.Time) error { return nil }
+func (f fakeExpenseRepo) SumByDateRange(context.Context, uuid.UUID, time.Time, time.Time) (domain.Money, error) {
+	var total domain.Money
+	for _, item := range f.categoryTotals {
+		total = total.Add(item.Total)
+	}
+	return total, nil
+}
+func (f fakeExpenseRepo) CategoryBreakdownByDateRange(context.Context, uuid.UUID, time.Time, time.Time) ([]repository.CategoryTotal, error) {
+	return f.categoryTotals, nil
+}
 
 type fakeCategoryRepo struct {
 	createFn func(context.Context, domain.CreateCategoryInput) (*domain.Category, error)
 	getFn    func(context.Context, string, *string) (*domain.Category, error)
 	listFn   func(context.Context, *string, repository.ListOptions) ([]*domain.Category, int, error)
+	ledgerFn func(context.Context, string, string, repository.ListOptions) ([]*domain.Category, int, error)
 	updateFn func(context.Context, string, *string, domain.UpdateCategoryInput) (*domain.Category, error)
 	deleteFn func(context.Context, string, *string) error
+
+	changedFn func(context.Context, string, domain.SyncPosition, uint64, int) ([]*domain.Category, error)
 }
 
 func (f fakeCategoryRepo) Create(ctx context.Context, in domain.CreateCategoryInput) (*domain.Category, error) {
@@ -93,12 +132,18 @@ func (f fakeCategoryRepo) GetByID(ctx context.Context, id string, userID *string
 func (f fakeCategoryRepo) List(ctx context.Context, userID *string, opts repository.ListOptions) ([]*domain.Category, int, error) {
 	return f.listFn(ctx, userID, opts)
 }
+func (f fakeCategoryRepo) ListByLedger(ctx context.Context, ledgerID, userID string, opts repository.ListOptions) ([]*domain.Category, int, error) {
+	return f.ledgerFn(ctx, ledgerID, userID, opts)
+}
 func (f fakeCategoryRepo) Update(ctx context.Context, id string, userID *string, in domain.UpdateCategoryInput) (*domain.Category, error) {
 	return f.updateFn(ctx, id, userID, in)
 }
 func (f fakeCategoryRepo) Delete(ctx context.Context, id string, userID *string) error {
 	return f.deleteFn(ctx, id, userID)
 }
+func (f fakeCategoryRepo) ListChangedSince(ctx context.Context, userID string, since domain.SyncPosition, before uint64, limit int) ([]*domain.Category, error) {
+	return f.changedFn(ctx, userID, since, before, limit)
+}
 
 type fakeDebtRepo struct {
 	createFn       func(context.Context, *domain.Debt) error
@@ -107,6 +152,7 @@ type fakeDebtRepo struct {
 	listByUserFn   func(context.Context, string, repository.ListOptions) ([]*domain.Debt, int, error)
 	listUpcomingFn func(context.Context, string, int, repository.ListOptions) ([]*domain.Debt, int, error)
 	markPaidFn     func(context.Context, string) (*domain.Debt, error)
+	changedFn      func(context.Context, string, domain.SyncPosition, uint64, int) ([]*domain.Debt, error)
 }
 
 func (f fakeDebtRepo) Create(ctx context.Context, debt *domain.Debt) error { return f.createFn(ctx, debt) }
@@ -124,6 +170,10 @@ func (f fakeDebtRepo) MarkPaid(ctx context.Context, id string) (*domain.Debt, er
 func (fakeDebtRepo) SetOverdue(context.Context, string) (int64, error)                 { return 0, nil }
 func (fakeDebtRepo) GetDueForReminder(context.Context, string) ([]*domain.Debt, error) { return nil, nil }
 func (fakeDebtRepo) UpdateReminder(context.Context, string, string, string) error      { return nil }
+func (fakeDebtRepo) SetRemindAt(context.Context, string, string) error                 { return nil }
+func (f fakeDebtRepo) ListChangedSince(ctx context.Context, userID string, since domain.SyncPosition, before uint64, limit int) ([]*domain.Debt, error) {
+	return f.changedFn(ctx, userID, since, before, limit)
+}
 
 func TestUserHandlers(t *testing.T) {
 	jwtSvc := auth.NewJWTService("test-secret")
@@ -162,14 +212,14 @@ func TestExpenseHandlers(t *testing.T) {
 		},
 		getFn: func(context.Context, string, string) (*domain.Expense, error) { return nil, nil },
 		listFn: func(_ context.Context, filter domain.ExpenseFilter) ([]*domain.Expense, int, error) {
-			return []*domain.Expense{{ID: "exp-1", UserID: filter.UserID, Amount: 12}}, 1, nil
+			return []*domain.Expense{{ID: "exp-1", UserID: filter.UserID, Amount: money(12)}}, 1, nil
 		},
 		updateFn: func(_ context.Context, id, userID string, _ domain.UpdateExpenseInput) (*domain.Expense, error) {
-			return &domain.Expense{ID: id, UserID: userID, Amount: 20}, nil
+			return &domain.Expense{ID: id, UserID: userID, Amount: money(20)}, nil
 		},
 		deleteFn: func(context.Context, string, string) error { return nil },
 	}
-	handler := deliveryhttp.NewExpenseHandler(usecases.NewExpenseUseCase(repo))
+	handler := deliveryhttp.NewExpenseHandler(usecases.NewExpenseUseCase(repo, defaultCurrencyUsers("ETB")))
 	jwtSvc := auth.NewJWTService("test-secret")
 	userID := uuid.New()
 	authHeader := "Bearer " + makeAccessToken(t, jwtSvc, userID)
@@ -275,7 +325,7 @@ func TestDebtHandlersAndRoutes(t *testing.T) {
 			return &domain.Debt{ID: debtID, UserID: userID.String(), Status: domain.DebtStatusPaid}, nil
 		},
 	}
-	handler := deliveryhttp.NewDebtHandler(usecases.NewDebtUsecase(repo), jwtSvc)
+	handler := deliveryhttp.NewDebtHandler(usecases.NewDebtUsecase(repo, defaultCurrencyUsers("ETB"), &fakeReminderRepo{}, nil, nil), jwtSvc)
 
 	createRec := httptest.NewRecorder()
 	createReq := newJSONRequest(t, http.MethodPost, "/debts", map[string]interface{}{
@@ -314,7 +364,7 @@ func TestReportHandlersAndMiddleware(t *testing.T) {
 	jwtSvc := auth.NewJWTService("test-secret")
 	handler := deliveryhttp.NewReportHandler(fakeReportUsecase{
 		dailyFn: func(context.Context, uuid.UUID, time.Time) (usecases.DailyReport, error) {
-			return usecases.DailyReport{Date: "2026-01-01", TotalExpense: 10}, nil
+			return usecases.DailyReport{Date: "2026-01-01", TotalExpense: money(10)}, nil
 		},
 		weeklyFn: func(_ context.Context, _ uuid.UUID, start, end time.Time) (usecases.WeeklyReport, error) {
 			if end.Before(start) {
@@ -345,7 +395,7 @@ func TestReportHandlersAndMiddleware(t *testing.T) {
 
 	middlewareRec := httptest.NewRecorder()
 	middlewareReq := newJSONRequest(t, http.MethodGet, "/expenses", nil)
-	deliveryhttp.JWTAuthMiddleware(jwtSvc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	deliveryhttp.JWTAuthMiddleware(jwtSvc, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})).ServeHTTP(middlewareRec, middlewareReq)
 	if env := decodeEnvelope(t, middlewareRec); middlewareRec.Code != http.StatusUnauthorized || env.Success {
@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/infrastructure/storage"
+	"expense_tracker/repository"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+// fakeAttachmentRepo keeps attachments in memory; readOnly makes writes fail like a ledger viewer's
+type fakeAttachmentRepo struct {
+	attachments []*domain.Attachment
+	readOnly    bool
+}
+
+func (f *fakeAttachmentRepo) Create(_ context.Context, a *domain.Attachment) error {
+	if f.readOnly {
+		return sql.ErrNoRows
+	}
+	a.CreatedAt = time.Now().UTC()
+	f.attachments = append(f.attachments, a)
+	return nil
+}
+
+func (f *fakeAttachmentRepo) GetByID(_ context.Context, expenseID, id string) (*domain.Attachment, error) {
+	for _, a := range f.attachments {
+		if a.ID == id && a.ExpenseID == expenseID {
+			return a, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeAttachmentRepo) ListByExpense(_ context.Context, expenseID string) ([]*domain.Attachment, error) {
+	attachments := make([]*domain.Attachment, 0)
+	for _, a := range f.attachments {
+		if a.ExpenseID == expenseID {
+			attachments = append(attachments, a)
+		}
+	}
+	return attachments, nil
+}
+
+func (f *fakeAttachmentRepo) Delete(_ context.Context, expenseID, id, _ string) error {
+	if f.readOnly {
+		return sql.ErrNoRows
+	}
+	for i, a := range f.attachments {
+		if a.ID == id && a.ExpenseID == expenseID {
+			f.attachments = append(f.attachments[:i], f.attachments[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+// failingReader returns its data and then an error, like an upload cut off half way
+type failingReader struct{ data []byte }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errors.New("connection reset")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestAttachmentSniffingAndFileSystemStore(t *testing.T) {
+	for head, want := range map[string]string{
+		"\xFF\xD8\xFF\xE0\x00\x10JFIF":             "image/jpeg",
+		"\x89PNG\r\n\x1A\n\x00\x00":                "image/png",
+		"GIF89a\x01\x00":                           "image/gif",
+		"RIFF\x24\x00\x00\x00WEBPVP8 ":             "image/webp",
+		"\x00\x00\x00\x18ftypheic\x00\x00\x00\x00": "image/heic",
+		"%PDF-1.7\n":                               "application/pdf",
+		"<html><script>":                           "",
+		"PK\x03\x04":                               "",
+		"":                                         "",
+	} {
+		if got := domain.SniffAttachmentType([]byte(head)); got != want {
+			t.Fatalf("SniffAttachmentType(%q) = %q, want %q", head, got, want)
+		}
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := storage.NewFileSystemStore(filepath.Join(root, "blobs"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	if size, err := store.Put(ctx, "expenses/e1/a1", strings.NewReader("%PDF-1.4 receipt")); err != nil || size != 16 {
+		t.Fatalf("put: %d %v", size, err)
+	}
+	r, err := store.Open(ctx, "expenses/e1/a1")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	content, _ := io.ReadAll(r)
+	r.Close()
+	if string(content) != "%PDF-1.4 receipt" {
+		t.Fatalf("unexpected content %q", content)
+	}
+	if _, err := store.Put(ctx, "expenses/e1/a2", &failingReader{data: []byte("partial")}); err == nil {
+		t.Fatal("expected a failed read to fail the put")
+	}
+	if _, err := store.Open(ctx, "expenses/e1/a2"); !errors.Is(err, repository.ErrBlobNotFound) {
+		t.Fatalf("expected no blob after a failed put, got %v", err)
+	}
+	for _, key := range []string{"", "../escape", "/etc/passwd", "expenses/../../escape"} {
+		if _, err := store.Put(ctx, key, strings.NewReader("x")); !errors.Is(err, storage.ErrInvalidKey) {
+			t.Fatalf("key %q: expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+	if err := store.Delete(ctx, "expenses/e1/a1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := store.Delete(ctx, "expenses/e1/a1"); err != nil {
+		t.Fatalf("deleting a missing blob should succeed, got %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(root, "blobs", "expenses")); len(entries) != 0 {
+		t.Fatalf("expected the empty expense directory to be removed, got %v", entries)
+	}
+}
+
+func TestAttachmentRoutes(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	userID := uuid.New()
+	expenseID := uuid.New().String()
+	expenses := fakeExpenseRepo{getFn: func(_ context.Context, id, _ string) (*domain.Expense, error) {
+		if id == expenseID {
+			return &domain.Expense{ID: expenseID, UserID: userID.String()}, nil
+		}
+		return nil, nil
+	}}
+	attachments := &fakeAttachmentRepo{}
+	store, err := storage.NewFileSystemStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	expenseHandler := deliveryhttp.NewExpenseHandler(usecases.NewExpenseUseCase(expenses, defaultCurrencyUsers("ETB")))
+	expenseHandler.SetAttachmentHandler(deliveryhttp.NewAttachmentHandler(usecases.NewAttachmentUseCase(attachments, expenses, store)))
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterExpenseRoutes(mux, expenseHandler)
+	handler := deliveryhttp.JWTAuthMiddleware(jwtSvc, nil, mux)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, userID))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	upload := func(expense, filename string, content []byte) (*httptest.ResponseRecorder, apiEnvelope) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		_ = form.WriteField("note", "ignored")
+		part, _ := form.CreateFormFile("file", filename)
+		_, _ = part.Write(content)
+		_ = form.Close()
+		req := httptest.NewRequest(http.MethodPost, "/expenses/"+expense+"/attachments", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		rec := serve(req)
+		return rec, decodeEnvelope(t, rec)
+	}
+
+	png := append([]byte("\x89PNG\r\n\x1A\n"), bytes.Repeat([]byte{7}, 100)...)
+	rec, env := upload(expenseID, `C:\scans\"lunch".txt`, png)
+	var attachment domain.Attachment
+	if rec.Code != http.StatusCreated || json.Unmarshal(env.Data, &attachment) != nil {
+		t.Fatalf("unexpected upload: %d %s %v", rec.Code, env.Data, env.Errors)
+	}
+	if attachment.ContentType != "image/png" || attachment.Size != int64(len(png)) || attachment.FileName != "lunch.txt" ||
+		strings.Contains(string(env.Data), "storage_key") {
+		t.Fatalf("unexpected attachment: %+v", attachment)
+	}
+
+	if rec, env := upload(expenseID, "notes.html", []byte("<html><body>hi</body></html>")); rec.Code != http.StatusUnsupportedMediaType ||
+		env.Errors[0] != usecases.ErrUnsupportedAttachmentType.Error() {
+		t.Fatalf("expected 415 for html, got %d %v", rec.Code, env.Errors)
+	}
+	tooLarge := append([]byte("%PDF-1.7\n"), make([]byte, usecases.MaxAttachmentSize)...)
+	if rec, _ := upload(expenseID, "big.pdf", tooLarge); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for an oversized file, got %d", rec.Code)
+	}
+	if rec, _ := upload(uuid.New().String(), "r.png", png); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown expense, got %d", rec.Code)
+	}
+	if len(attachments.attachments) != 1 {
+		t.Fatalf("rejected uploads must not be recorded, got %d", len(attachments.attachments))
+	}
+
+	rec = serve(httptest.NewRequest(http.MethodGet, "/expenses/"+expenseID+"/attachments", nil))
+	var listed []domain.Attachment
+	if env := decodeEnvelope(t, rec); rec.Code != http.StatusOK || json.Unmarshal(env.Data, &listed) != nil || len(listed) != 1 {
+		t.Fatalf("unexpected list: %d %s", rec.Code, env.Data)
+	}
+
+	rec = serve(httptest.NewRequest(http.MethodGet, "/expenses/"+expenseID+"/attachments/"+attachment.ID, nil))
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), png) || rec.Header().Get("Content-Type") != "image/png" ||
+		rec.Header().Get("Content-Disposition") != `attachment; filename=lunch.txt` || rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatalf("unexpected download: %d %v", rec.Code, rec.Header())
+	}
+
+	attachments.readOnly = true
+	if rec, _ := upload(expenseID, "r.png", png); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a viewer, got %d", rec.Code)
+	}
+	rec = serve(httptest.NewRequest(http.MethodDelete, "/expenses/"+expenseID+"/attachments/"+attachment.ID, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 deleting as a viewer, got %d", rec.Code)
+	}
+	attachments.readOnly = false
+	rec = serve(httptest.NewRequest(http.MethodDelete, "/expenses/"+expenseID+"/attachments/"+attachment.ID, nil))
+	if rec.Code != http.StatusOK || len(attachments.attachments) != 0 {
+		t.Fatalf("unexpected delete: %d", rec.Code)
+	}
+	if _, err := store.Open(context.Background(), "expenses/"+expenseID+"/"+attachment.ID); !errors.Is(err, repository.ErrBlobNotFound) {
+		t.Fatalf("expected the blob to be removed, got %v", err)
+	}
+	rec = serve(httptest.NewRequest(http.MethodGet, "/expenses/"+expenseID+"/attachments/"+attachment.ID, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", rec.Code)
+	}
+}
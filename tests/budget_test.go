@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/repository"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+// fakeBudgetRepo lets ledger budgets be read by any member of f.members and changed by those
+// who are not viewers
+type fakeBudgetRepo struct {
+	budgets map[string]*domain.Budget
+	members map[string]domain.LedgerRole // role by "ledgerID/userID"
+}
+
+func (f *fakeBudgetRepo) Create(_ context.Context, b *domain.Budget) error {
+	if f.budgets == nil {
+		f.budgets = map[string]*domain.Budget{}
+	}
+	b.ID = uuid.NewString()
+	f.budgets[b.ID] = b
+	return nil
+}
+func (f *fakeBudgetRepo) role(b *domain.Budget, userID string) domain.LedgerRole {
+	if b.LedgerID == nil {
+		if b.UserID == userID {
+			return domain.LedgerRoleOwner
+		}
+		return ""
+	}
+	return f.members[*b.LedgerID+"/"+userID]
+}
+func (f *fakeBudgetRepo) GetByID(_ context.Context, id, userID string) (*domain.Budget, error) {
+	if b := f.budgets[id]; b != nil && f.role(b, userID) != "" {
+		return b, nil
+	}
+	return nil, nil
+}
+func (f *fakeBudgetRepo) GetByCategory(_ context.Context, userID string, ledgerID, categoryID *string) (*domain.Budget, error) {
+	for _, b := range f.budgets {
+		if sameOptional(b.LedgerID, ledgerID) && (ledgerID != nil || b.UserID == userID) && sameOptional(b.CategoryID, categoryID) {
+			return b, nil
+		}
+	}
+	return nil, nil
+}
+func (f *fakeBudgetRepo) ListByUser(_ context.Context, userID string) ([]*domain.Budget, error) {
+	list := make([]*domain.Budget, 0)
+	for _, b := range f.budgets {
+		if b.UserID == userID && b.LedgerID == nil {
+			list = append(list, b)
+		}
+	}
+	return list, nil
+}
+func (f *fakeBudgetRepo) ListByLedger(_ context.Context, ledgerID string) ([]*domain.Budget, error) {
+	list := make([]*domain.Budget, 0)
+	for _, b := range f.budgets {
+		if b.LedgerID != nil && *b.LedgerID == ledgerID {
+			list = append(list, b)
+		}
+	}
+	return list, nil
+}
+func (f *fakeBudgetRepo) Update(_ context.Context, b *domain.Budget, userID string) error {
+	if !f.role(f.budgets[b.ID], userID).CanWrite() {
+		return sql.ErrNoRows
+	}
+	f.budgets[b.ID] = b
+	return nil
+}
+func (f *fakeBudgetRepo) Delete(_ context.Context, id, userID string) error {
+	if b := f.budgets[id]; b == nil || !f.role(b, userID).CanWrite() {
+		return sql.ErrNoRows
+	}
+	delete(f.budgets, id)
+	return nil
+}
+
+func sameOptional(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+type fakeDebtReportRepo struct {
+	repaid map[string]domain.Money // by debt type
+}
+
+func (fakeDebtReportRepo) SumByDateRangeAndType(context.Context, uuid.UUID, time.Time, time.Time, string) (domain.Money, error) {
+	return domain.Money{}, nil
+}
+func (f fakeDebtReportRepo) SumPaymentsByDateRangeAndType(_ context.Context, _ uuid.UUID, _, _ time.Time, debtType string) (domain.Money, error) {
+	return f.repaid[debtType], nil
+}
+
+func TestBudgetRoutes(t *testing.T) {
+	userID := uuid.New()
+	jwtSvc := auth.NewJWTService("test-secret")
+	categoryID := uuid.NewString()
+	categoryRepo := fakeCategoryRepo{
+		getFn: func(_ context.Context, id string, _ *string) (*domain.Category, error) {
+			if id == categoryID {
+				return &domain.Category{ID: id, Name: "Groceries"}, nil
+			}
+			return nil, nil
+		},
+	}
+	budgetRepo := &fakeBudgetRepo{}
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterBudgetRoutes(mux, deliveryhttp.NewBudgetHandler(usecases.NewBudgetUseCase(budgetRepo, categoryRepo, defaultCurrencyUsers("ETB")), jwtSvc))
+
+	do := func(method, target string, body interface{}) (*httptest.ResponseRecorder, apiEnvelope) {
+		rec := httptest.NewRecorder()
+		req := newJSONRequest(t, method, target, body)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, userID))
+		mux.ServeHTTP(rec, req)
+		return rec, decodeEnvelope(t, rec)
+	}
+
+	rec, env := do(http.MethodPost, "/budgets", map[string]interface{}{"category_id": categoryID, "amount": 300})
+	if rec.Code != http.StatusCreated || !env.Success {
+		t.Fatalf("unexpected create response: code=%d env=%+v", rec.Code, env)
+	}
+	var created domain.Budget
+	if err := json.Unmarshal(env.Data, &created); err != nil || created.CategoryName != "Groceries" {
+		t.Fatalf("unexpected budget: %+v (%v)", created, err)
+	}
+
+	if rec, _ := do(http.MethodPost, "/budgets", map[string]interface{}{"category_id": categoryID, "amount": 100}); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a second budget on the category, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodPost, "/budgets", map[string]interface{}{"category_id": uuid.NewString(), "amount": 100}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown category, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodPost, "/budgets", map[string]interface{}{"amount": 0}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for non-positive amount, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodPost, "/budgets", map[string]interface{}{"amount": 1000}); rec.Code != http.StatusCreated {
+		t.Fatalf("expected overall budget to be created, got %d", rec.Code)
+	}
+
+	rec, _ = do(http.MethodPut, "/budgets/"+created.ID, map[string]interface{}{"amount": 350})
+	if rec.Code != http.StatusOK || budgetRepo.budgets[created.ID].Amount != money(350) {
+		t.Fatalf("unexpected update response: code=%d", rec.Code)
+	}
+	if rec, _ := do(http.MethodDelete, "/budgets/"+uuid.NewString(), nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown budget, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodDelete, "/budgets/"+created.ID, nil); rec.Code != http.StatusOK || len(budgetRepo.budgets) != 1 {
+		t.Fatalf("unexpected delete response: code=%d", rec.Code)
+	}
+}
+
+func TestMonthlyReportIncludesBudgetStatus(t *testing.T) {
+	userID := uuid.New()
+	groceries, transport, rent := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	expenseRepo := fakeExpenseRepo{categoryTotals: []repository.CategoryTotal{
+		{CategoryID: &groceries, CategoryName: "Groceries", Total: money(450)},
+		{CategoryID: &transport, CategoryName: "Transport", Total: money(50)},
+	}}
+	budgetRepo := &fakeBudgetRepo{budgets: map[string]*domain.Budget{
+		"overall": {ID: "overall", UserID: userID.String(), Amount: money(1000)},
+		"g":       {ID: "g", UserID: userID.String(), CategoryID: &groceries, Amount: money(400)},
+		"r":       {ID: "r", UserID: userID.String(), CategoryID: &rent, CategoryName: "Rent", Amount: money(800)},
+	}}
+	uc := usecases.NewReportUsecase(expenseRepo, fakeDebtReportRepo{}, budgetRepo, nil, nil, nil)
+
+	report, err := uc.GetMonthlyReport(context.Background(), userID, 2026, time.February)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byName := map[string]usecases.WeeklyCategorySummary{}
+	for _, item := range report.CategoryBreakdown {
+		byName[item.CategoryName] = item
+	}
+
+	if b := byName["Groceries"].Budget; b == nil || b.Budgeted != money(400) || b.Remaining != money(-50) || b.PercentUsed != 112.5 || !b.OverBudget {
+		t.Fatalf("unexpected groceries budget: %+v", b)
+	}
+	if byName["Transport"].Budget != nil {
+		t.Fatalf("transport has no budget, got %+v", byName["Transport"].Budget)
+	}
+	if b := byName["Rent"].Budget; b == nil || !b.Spent.IsZero() || b.Remaining != money(800) {
+		t.Fatalf("budgeted category without spending should be listed: %+v", byName["Rent"])
+	}
+	if report.Budget == nil || report.Budget.Spent != money(500) || report.Budget.PercentUsed != 50 || report.Budget.OverBudget {
+		t.Fatalf("unexpected overall budget: %+v", report.Budget)
+	}
+
+	// A week is prorated: 7 of February's 28 days
+	weekly, err := uc.GetWeeklyReport(context.Background(), userID, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 2, 7, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if weekly.Budget == nil || weekly.Budget.Budgeted != money(250) {
+		t.Fatalf("unexpected weekly overall budget: %+v", weekly.Budget)
+	}
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/repository"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+// monthlyExpenseRepo returns category totals for the month a date range starts in
+type monthlyExpenseRepo struct {
+	fakeExpenseRepo
+	byMonth map[time.Month][]repository.CategoryTotal
+}
+
+func (f monthlyExpenseRepo) SumByDateRange(_ context.Context, _ uuid.UUID, start, _ time.Time) (domain.Money, error) {
+	var total domain.Money
+	for _, item := range f.byMonth[start.Month()] {
+		total = total.Add(item.Total)
+	}
+	return total, nil
+}
+func (f monthlyExpenseRepo) CategoryBreakdownByDateRange(_ context.Context, _ uuid.UUID, start, _ time.Time) ([]repository.CategoryTotal, error) {
+	return f.byMonth[start.Month()], nil
+}
+
+func TestBudgetingStylePlans(t *testing.T) {
+	userID := uuid.New()
+	groceries, rent, transport := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	created := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	expenseRepo := monthlyExpenseRepo{byMonth: map[time.Month][]repository.CategoryTotal{
+		time.January:  {{CategoryID: &groceries, CategoryName: "Groceries", Total: money(300)}},
+		time.February: {{CategoryID: &groceries, CategoryName: "Groceries", Total: money(450)}},
+		time.March: {
+			{CategoryID: &groceries, CategoryName: "Groceries", Total: money(300)},
+			{CategoryID: &rent, CategoryName: "Rent", Total: money(1200)},
+			{CategoryID: &transport, CategoryName: "Transport", Total: money(250)},
+		},
+	}}
+	budgetRepo := &fakeBudgetRepo{budgets: map[string]*domain.Budget{
+		"g": {ID: "g", UserID: userID.String(), CategoryID: &groceries, CategoryName: "Groceries", Amount: money(400), Bucket: domain.BudgetBucketNeeds, CreatedAt: created},
+		"r": {ID: "r", UserID: userID.String(), CategoryID: &rent, CategoryName: "Rent", Amount: money(1200), Bucket: domain.BudgetBucketNeeds, CreatedAt: created},
+	}}
+	userRepo := newFakeUserRepo()
+	_ = userRepo.Create(context.Background(), &domain.User{UserID: userID, Email: "plan@example.com", MonthlyIncome: money(2000)})
+	uc := usecases.NewReportUsecase(expenseRepo, fakeDebtReportRepo{}, budgetRepo, userRepo, nil, nil)
+
+	planFor := func(style domain.BudgetingStyle) *usecases.BudgetPlan {
+		t.Helper()
+		userRepo.byID[userID].BudgetingStyle = style
+		report, err := uc.GetMonthlyReport(context.Background(), userID, 2026, time.March)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.BudgetPlan == nil || report.BudgetPlan.Style != style {
+			t.Fatalf("expected a %s plan, got %+v", style, report.BudgetPlan)
+		}
+		return report.BudgetPlan
+	}
+	allocation := func(plan *usecases.BudgetPlan, name string) usecases.BudgetAllocation {
+		t.Helper()
+		for _, line := range plan.Allocations {
+			if line.Name == name {
+				return line
+			}
+		}
+		t.Fatalf("no %s allocation in %+v", name, plan.Allocations)
+		return usecases.BudgetAllocation{}
+	}
+
+	// Envelope: January leaves 100, February overspends by 50 and leaves 50 for March
+	envelope := planFor(domain.BudgetingStyleEnvelope)
+	if line := allocation(envelope, "Groceries"); line.Rollover != money(50) || line.Remaining != money(150) {
+		t.Fatalf("unexpected groceries envelope: %+v", line)
+	}
+	if line := allocation(envelope, "Rent"); line.Rollover != money(2400) || line.Remaining != money(2400) {
+		t.Fatalf("two unspent months of rent should carry over: %+v", line)
+	}
+
+	zeroBased := planFor(domain.BudgetingStyleZeroBased)
+	if zeroBased.Unallocated == nil || *zeroBased.Unallocated != money(400) {
+		t.Fatalf("expected 400 unassigned, got %+v", zeroBased.Unallocated)
+	}
+	if len(zeroBased.Warnings) != 2 {
+		t.Fatalf("expected unbudgeted spending and unassigned income warnings, got %v", zeroBased.Warnings)
+	}
+
+	// 50/30/20: groceries and rent are needs, unmapped transport counts as wants
+	split := planFor(domain.BudgetingStyleFiftyThirtyTwenty)
+	if line := allocation(split, "Needs"); line.Allocated != money(1000) || line.Spent != money(1500) {
+		t.Fatalf("unexpected needs bucket: %+v", line)
+	}
+	if line := allocation(split, "Wants"); line.Spent != money(250) {
+		t.Fatalf("unexpected wants bucket: %+v", line)
+	}
+	if len(split.Warnings) != 2 || !strings.Contains(split.Summary, "savings 12.5%") {
+		t.Fatalf("unexpected 50/30/20 plan: %q %v", split.Summary, split.Warnings)
+	}
+
+	flexible := planFor(domain.BudgetingStyleFlexible)
+	if len(flexible.Warnings) != 0 || flexible.Summary != "All 2 budgets are within their limits." {
+		t.Fatalf("unexpected flexible plan: %+v", flexible)
+	}
+}
+
+func TestUpdateProfileValidatesBudgetingStyle(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	userID := uuid.New()
+	userRepo := newFakeUserRepo()
+	_ = userRepo.Create(context.Background(), &domain.User{UserID: userID, Email: "style@example.com", BudgetingStyle: domain.BudgetingStyleFlexible})
+	handler := deliveryhttp.NewUserHandler(usecases.NewUserUsecase(userRepo), jwtSvc)
+
+	update := func(body map[string]interface{}) int {
+		req := newJSONRequest(t, http.MethodPut, "/user/update", body)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, userID))
+		rec := httptest.NewRecorder()
+		handler.UpdateProfile(rec, req)
+		return rec.Code
+	}
+
+	if code := update(map[string]interface{}{"budgeting_style": "strict"}); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown style, got %d", code)
+	}
+	if code := update(map[string]interface{}{"monthly_income": -1}); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for negative income, got %d", code)
+	}
+	if code := update(map[string]interface{}{"budgeting_style": "zero_based", "monthly_income": 2500}); code != http.StatusOK {
+		t.Fatalf("expected style update to succeed, got %d", code)
+	}
+	if user := userRepo.byID[userID]; user.BudgetingStyle != domain.BudgetingStyleZeroBased || user.MonthlyIncome != money(2500) {
+		t.Fatalf("profile not updated: %+v", user)
+	}
+}
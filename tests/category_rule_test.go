@@ -0,0 +1,277 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+type fakeCategoryRuleRepo struct {
+	rules        map[string]*domain.CategoryRule
+	recategorize map[string]string
+}
+
+func newFakeCategoryRuleRepo() *fakeCategoryRuleRepo {
+	return &fakeCategoryRuleRepo{rules: map[string]*domain.CategoryRule{}}
+}
+
+func (f *fakeCategoryRuleRepo) Create(_ context.Context, rule *domain.CategoryRule) error {
+	rule.ID = uuid.NewString()
+	rule.CreatedAt = time.Now().Add(time.Duration(len(f.rules)) * time.Second)
+	copied := *rule
+	f.rules[rule.ID] = &copied
+	return nil
+}
+
+func (f *fakeCategoryRuleRepo) GetByID(_ context.Context, id, userID string) (*domain.CategoryRule, error) {
+	if rule, ok := f.rules[id]; ok && rule.UserID == userID {
+		copied := *rule
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeCategoryRuleRepo) ListByUser(_ context.Context, userID string) ([]*domain.CategoryRule, error) {
+	return f.list(func(r *domain.CategoryRule) bool { return r.UserID == userID && r.LedgerID == nil }), nil
+}
+
+func (f *fakeCategoryRuleRepo) ListByLedger(_ context.Context, ledgerID string) ([]*domain.CategoryRule, error) {
+	return f.list(func(r *domain.CategoryRule) bool { return r.LedgerID != nil && *r.LedgerID == ledgerID }), nil
+}
+
+func (f *fakeCategoryRuleRepo) list(keep func(*domain.CategoryRule) bool) []*domain.CategoryRule {
+	rules := make([]*domain.CategoryRule, 0)
+	for _, rule := range f.rules {
+		if keep(rule) {
+			copied := *rule
+			rules = append(rules, &copied)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+	return rules
+}
+
+func (f *fakeCategoryRuleRepo) Update(_ context.Context, rule *domain.CategoryRule, userID string) error {
+	if existing, ok := f.rules[rule.ID]; !ok || existing.UserID != userID {
+		return sql.ErrNoRows
+	}
+	copied := *rule
+	f.rules[rule.ID] = &copied
+	return nil
+}
+
+func (f *fakeCategoryRuleRepo) Delete(_ context.Context, id, userID string) error {
+	if existing, ok := f.rules[id]; !ok || existing.UserID != userID {
+		return sql.ErrNoRows
+	}
+	delete(f.rules, id)
+	return nil
+}
+
+func (f *fakeCategoryRuleRepo) Recategorize(_ context.Context, _ string, categories map[string]string) (int, error) {
+	f.recategorize = categories
+	return len(categories), nil
+}
+
+// ruleCategories serves the categories named by ID: "ledger-cat" belongs to ledger-1, "other"
+// does not exist and the rest are the user's own
+func ruleCategories(userID string) fakeCategoryRepo {
+	return fakeCategoryRepo{getFn: func(_ context.Context, id string, _ *string) (*domain.Category, error) {
+		switch id {
+		case "other":
+			return nil, nil
+		case "ledger-cat":
+			ledgerID := "ledger-1"
+			return &domain.Category{ID: id, Name: id, UserID: &userID, LedgerID: &ledgerID}, nil
+		}
+		return &domain.Category{ID: id, Name: id, UserID: &userID}, nil
+	}}
+}
+
+func TestCategoryRuleValidationAndMatching(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.NewString()
+	repo := newFakeCategoryRuleRepo()
+	uc := usecases.NewCategoryRuleUseCase(repo, ruleCategories(userID), fakeExpenseRepo{})
+	money := func(cents int64) *domain.Money { m := domain.Cents(cents); return &m }
+
+	invalid := []struct {
+		input domain.CategoryRuleInput
+		want  error
+	}{
+		{domain.CategoryRuleInput{CategoryID: "food"}, usecases.ErrCategoryRuleNoCondition},
+		{domain.CategoryRuleInput{CategoryID: "food", NotePattern: "("}, usecases.ErrInvalidCategoryRulePattern},
+		{domain.CategoryRuleInput{CategoryID: "food", MinAmount: money(500), MaxAmount: money(100)}, usecases.ErrInvalidCategoryRuleAmounts},
+		{domain.CategoryRuleInput{CategoryID: "food", Weekdays: []time.Weekday{7}}, usecases.ErrInvalidCategoryRuleWeekday},
+		{domain.CategoryRuleInput{CategoryID: "other", NoteContains: "x"}, usecases.ErrCategoryRuleCategoryNotFound},
+		{domain.CategoryRuleInput{CategoryID: "ledger-cat", NoteContains: "x"}, usecases.ErrCategoryRuleCategoryNotFound},
+	}
+	for i, tc := range invalid {
+		if _, err := uc.Create(ctx, userID, nil, tc.input); !errors.Is(err, tc.want) {
+			t.Fatalf("case %d: expected %v, got %v", i, tc.want, err)
+		}
+	}
+
+	for _, input := range []domain.CategoryRuleInput{
+		{CategoryID: "treats", NoteContains: "Coffee", Weekdays: []time.Weekday{time.Sunday, time.Saturday, time.Sunday}},
+		{CategoryID: "transport", NotePattern: `(?i)^(uber|bolt)\b`, Priority: 10},
+		{CategoryID: "small", MaxAmount: money(300)},
+	} {
+		if _, err := uc.Create(ctx, userID, nil, input); err != nil {
+			t.Fatalf("create %s: %v", input.CategoryID, err)
+		}
+	}
+	rules, err := uc.List(ctx, userID, nil)
+	if err != nil || len(rules) != 3 || rules[0].CategoryID != "transport" || len(rules[1].Weekdays) != 2 {
+		t.Fatalf("expected the highest priority first and weekdays deduplicated, got %+v (%v)", rules, err)
+	}
+
+	saturday := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	own := "mine"
+	inputs := []*domain.CreateExpenseInput{
+		{Amount: domain.Cents(250), Note: "coffee beans", ExpenseDate: saturday},               // treats before small
+		{Amount: domain.Cents(250), Note: "UBER to the coffee place", ExpenseDate: saturday},   // priority wins
+		{Amount: domain.Cents(900), Note: "coffee", ExpenseDate: saturday.AddDate(0, 0, 2)},    // a Monday
+		{Amount: domain.Cents(200), Note: "gum", ExpenseDate: saturday, CategoryID: &own},      // has one
+		{Amount: domain.Cents(200), Note: "gum", ExpenseDate: saturday, LedgerID: new(string)}, // another ledger
+	}
+	if err := uc.Categorize(ctx, userID, nil, inputs); err != nil {
+		t.Fatalf("categorize: %v", err)
+	}
+	want := []string{"treats", "transport", "", "mine", ""}
+	for i, input := range inputs {
+		got := ""
+		if input.CategoryID != nil {
+			got = *input.CategoryID
+		}
+		if got != want[i] {
+			t.Fatalf("expense %d: expected category %q, got %q", i, want[i], got)
+		}
+	}
+}
+
+func TestCategoryRulesOnCreateAndApply(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.NewString()
+	repo := newFakeCategoryRuleRepo()
+	food := "food"
+	day := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	expenses := fakeExpenseRepo{
+		createFn: func(_ context.Context, in domain.CreateExpenseInput) (*domain.Expense, error) {
+			return &domain.Expense{ID: uuid.NewString(), CategoryID: in.CategoryID, Note: in.Note}, nil
+		},
+		listFn: func(_ context.Context, f domain.ExpenseFilter) ([]*domain.Expense, int, error) {
+			if f.UserID != userID || f.Offset != 0 {
+				t.Fatalf("unexpected filter %+v", f)
+			}
+			return []*domain.Expense{
+				{ID: "e1", Note: "Pizza night", ExpenseDate: day},
+				{ID: "e2", Note: "pizza", ExpenseDate: day, CategoryID: &food},
+				{ID: "e3", Note: "books", ExpenseDate: day},
+			}, 3, nil
+		},
+	}
+	uc := usecases.NewCategoryRuleUseCase(repo, ruleCategories(userID), expenses)
+	rule, err := uc.Create(ctx, userID, nil, domain.CategoryRuleInput{CategoryID: "takeaway", NoteContains: "pizza"})
+	if err != nil {
+		t.Fatalf("create rule: %v", err)
+	}
+
+	expenseUC := usecases.NewExpenseUseCase(expenses, defaultCurrencyUsers("ETB"))
+	expenseUC.SetCategorizer(uc)
+	created, err := expenseUC.Create(ctx, domain.CreateExpenseInput{UserID: userID, Amount: domain.Cents(1200), Note: "Pizza", ExpenseDate: day})
+	if err != nil || created.CategoryID == nil || *created.CategoryID != "takeaway" {
+		t.Fatalf("expected a new expense to be categorized, got %+v (%v)", created, err)
+	}
+	created, err = expenseUC.Create(ctx, domain.CreateExpenseInput{UserID: userID, Amount: domain.Cents(1200), Note: "Pizza", ExpenseDate: day, CategoryID: &food})
+	if err != nil || *created.CategoryID != food {
+		t.Fatalf("expected the given category to be kept, got %+v (%v)", created, err)
+	}
+
+	result, err := uc.Apply(ctx, userID, nil, domain.ApplyCategoryRulesOptions{OnlyUncategorized: true, DryRun: true})
+	if err != nil || result.Checked != 2 || result.Changed != 1 || result.Changes[0].ExpenseID != "e1" || result.Changes[0].RuleID != rule.ID || repo.recategorize != nil {
+		t.Fatalf("unexpected dry run: %+v (%v)", result, err)
+	}
+	result, err = uc.Apply(ctx, userID, nil, domain.ApplyCategoryRulesOptions{})
+	if err != nil || result.Checked != 3 || result.Changed != 2 || repo.recategorize["e2"] != "takeaway" || *result.Changes[1].FromCategoryID != food {
+		t.Fatalf("unexpected apply: %+v, saved %v (%v)", result, repo.recategorize, err)
+	}
+	from, to := day, day.AddDate(0, 0, -1)
+	if _, err := uc.Apply(ctx, userID, nil, domain.ApplyCategoryRulesOptions{FromDate: &from, ToDate: &to}); !errors.Is(err, usecases.ErrInvalidDateRange) {
+		t.Fatalf("expected ErrInvalidDateRange, got %v", err)
+	}
+}
+
+func TestCategoryRuleRoutes(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	userID := uuid.New()
+	repo := newFakeCategoryRuleRepo()
+	expenses := fakeExpenseRepo{listFn: func(context.Context, domain.ExpenseFilter) ([]*domain.Expense, int, error) {
+		return []*domain.Expense{{ID: "e1", Note: "Netflix", ExpenseDate: time.Now()}}, 1, nil
+	}}
+	uc := usecases.NewCategoryRuleUseCase(repo, ruleCategories(userID.String()), expenses)
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterCategoryRoutes(mux, deliveryhttp.NewCategoryHandler(usecases.NewCategoryUseCase(fakeCategoryRepo{})))
+	deliveryhttp.RegisterCategoryRuleRoutes(mux, deliveryhttp.NewCategoryRuleHandler(uc))
+	handler := deliveryhttp.JWTAuthMiddleware(jwtSvc, nil, mux)
+	token := makeAccessToken(t, jwtSvc, userID)
+	do := func(method, target string, body interface{}) (*httptest.ResponseRecorder, apiEnvelope) {
+		req := newJSONRequest(t, method, target, body)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec, decodeEnvelope(t, rec)
+	}
+
+	subscriptions := uuid.NewString()
+	rec, env := do(http.MethodPost, "/categories/rules", map[string]interface{}{"category_id": subscriptions, "note_pattern": "(?i)netflix|spotify", "priority": 5})
+	var rule domain.CategoryRule
+	if rec.Code != http.StatusCreated || json.Unmarshal(env.Data, &rule) != nil || rule.CategoryID != subscriptions || rule.Priority != 5 {
+		t.Fatalf("unexpected create: code=%d data=%s", rec.Code, env.Data)
+	}
+	if rec, _ := do(http.MethodPost, "/categories/rules", map[string]interface{}{"category_id": subscriptions}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a rule without conditions, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodGet, "/categories/rules/"+rule.ID, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected the rule, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodPut, "/categories/rules/"+rule.ID, map[string]interface{}{"category_id": subscriptions, "note_contains": "netflix"}); rec.Code != http.StatusOK || repo.rules[rule.ID].NotePattern != "" {
+		t.Fatalf("expected the rule to be replaced, got %d", rec.Code)
+	}
+
+	rec, env = do(http.MethodPost, "/categories/rules/apply", map[string]interface{}{"dry_run": true})
+	var result domain.ApplyCategoryRulesResult
+	if rec.Code != http.StatusOK || json.Unmarshal(env.Data, &result) != nil || !result.DryRun || result.Changed != 1 {
+		t.Fatalf("unexpected apply: code=%d data=%s", rec.Code, env.Data)
+	}
+	if rec, _ := do(http.MethodPost, "/categories/rules/apply", map[string]interface{}{"from_date": "June"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad date, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodGet, "/categories/rules/apply", nil); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodDelete, "/categories/rules/"+rule.ID, nil); rec.Code != http.StatusOK || len(repo.rules) != 0 {
+		t.Fatalf("expected the rule to be deleted, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodGet, "/categories/rules/"+rule.ID, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+// fakeContactRepo keeps contacts in memory; debts counts the debts linked to each contact
+type fakeContactRepo struct {
+	contacts []*domain.Contact
+	debts    map[string]int
+	balances []domain.ContactBalance
+}
+
+func (f *fakeContactRepo) Create(_ context.Context, c *domain.Contact) error {
+	f.contacts = append(f.contacts, c)
+	return nil
+}
+func (f *fakeContactRepo) GetByID(_ context.Context, id, userID string) (*domain.Contact, error) {
+	for _, c := range f.contacts {
+		if c.ID == id && c.UserID == userID {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+func (f *fakeContactRepo) GetByName(_ context.Context, userID, name string) (*domain.Contact, error) {
+	for _, c := range f.contacts {
+		if c.UserID == userID && strings.EqualFold(c.Name, name) {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+func (f *fakeContactRepo) List(_ context.Context, userID string) ([]*domain.Contact, error) {
+	return f.contacts, nil
+}
+func (f *fakeContactRepo) Update(context.Context, *domain.Contact) error { return nil }
+func (f *fakeContactRepo) Delete(_ context.Context, id, _ string) error {
+	for i, c := range f.contacts {
+		if c.ID == id {
+			f.contacts = append(f.contacts[:i], f.contacts[i+1:]...)
+		}
+	}
+	return nil
+}
+func (f *fakeContactRepo) CountDebts(_ context.Context, id string) (int, error) {
+	return f.debts[id], nil
+}
+func (f *fakeContactRepo) Balances(context.Context, string) ([]domain.ContactBalance, error) {
+	return f.balances, nil
+}
+
+func TestContactNameNormalization(t *testing.T) {
+	if got := domain.NormalizeContactName("  Abebe   Kebede \t"); got != "Abebe Kebede" {
+		t.Fatalf("unexpected normalized name %q", got)
+	}
+
+	repo := &fakeContactRepo{}
+	uc := usecases.NewContactUseCase(repo)
+	if _, err := uc.Create(context.Background(), "u1", "Abebe", nil); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := uc.Create(context.Background(), "u1", " abebe ", nil); !errors.Is(err, usecases.ErrContactExists) {
+		t.Fatalf("expected ErrContactExists for the same name in other case, got %v", err)
+	}
+	if _, err := uc.Create(context.Background(), "u1", "   ", nil); !errors.Is(err, usecases.ErrContactNameRequired) {
+		t.Fatalf("expected ErrContactNameRequired, got %v", err)
+	}
+	other, err := uc.Create(context.Background(), "u1", "Sara", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := uc.Update(context.Background(), "u1", other.ID, "ABEBE", nil); !errors.Is(err, usecases.ErrContactExists) {
+		t.Fatalf("expected ErrContactExists when renaming onto another contact, got %v", err)
+	}
+	if c, err := uc.Update(context.Background(), "u1", other.ID, "sara  t", nil); err != nil || c.Name != "sara t" {
+		t.Fatalf("unexpected rename: %+v (%v)", c, err)
+	}
+}
+
+func TestDebtsLinkToContacts(t *testing.T) {
+	userID := uuid.NewString()
+	contacts := &fakeContactRepo{}
+	abebe := &domain.Contact{ID: uuid.NewString(), UserID: userID, Name: "Abebe"}
+	contacts.contacts = append(contacts.contacts, abebe)
+
+	var saved *domain.Debt
+	uc := usecases.NewDebtUsecase(fakeDebtRepo{
+		createFn: func(_ context.Context, d *domain.Debt) error { saved = d; return nil },
+	}, defaultCurrencyUsers("ETB"), nil, nil, contacts)
+	due := time.Now().AddDate(0, 0, 7)
+
+	err := uc.Create(context.Background(), &domain.Debt{UserID: userID, Type: "lent", PeerName: "  abebe ", Amount: money(10), DueDate: due})
+	if err != nil || saved.ContactID == nil || *saved.ContactID != abebe.ID || saved.PeerName != "Abebe" {
+		t.Fatalf("expected the debt to link to the existing contact: %+v (%v)", saved, err)
+	}
+
+	err = uc.Create(context.Background(), &domain.Debt{UserID: userID, Type: "borrowed", PeerName: "Sara", Amount: money(10), DueDate: due})
+	if err != nil || len(contacts.contacts) != 2 || *saved.ContactID != contacts.contacts[1].ID {
+		t.Fatalf("expected a contact to be added for a new name: contacts=%d err=%v", len(contacts.contacts), err)
+	}
+
+	missing := uuid.NewString()
+	err = uc.Create(context.Background(), &domain.Debt{UserID: userID, Type: "lent", ContactID: &missing, Amount: money(10), DueDate: due})
+	if !errors.Is(err, usecases.ErrContactNotFound) {
+		t.Fatalf("expected ErrContactNotFound for an unknown contact_id, got %v", err)
+	}
+	err = uc.Create(context.Background(), &domain.Debt{UserID: userID, Type: "lent", ContactID: &abebe.ID, Amount: money(10), DueDate: due})
+	if err != nil || saved.PeerName != "Abebe" {
+		t.Fatalf("expected peer_name to come from the contact: %+v (%v)", saved, err)
+	}
+}
+
+func TestContactRoutes(t *testing.T) {
+	userID := uuid.New()
+	jwtSvc := auth.NewJWTService("test-secret")
+	repo := &fakeContactRepo{debts: map[string]int{}, balances: []domain.ContactBalance{
+		{Currency: "ETB", OwedToYou: money(150), YouOwe: money(40), Net: money(110)},
+	}}
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterContactRoutes(mux, deliveryhttp.NewContactHandler(usecases.NewContactUseCase(repo), jwtSvc))
+
+	do := func(method, target string, body interface{}) (*httptest.ResponseRecorder, apiEnvelope) {
+		rec := httptest.NewRecorder()
+		req := newJSONRequest(t, method, target, body)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, userID))
+		mux.ServeHTTP(rec, req)
+		return rec, decodeEnvelope(t, rec)
+	}
+
+	rec, _ := do(http.MethodPost, "/contacts", map[string]string{"name": "Abebe"})
+	if rec.Code != http.StatusCreated || len(repo.contacts) != 1 {
+		t.Fatalf("unexpected create: code=%d contacts=%d", rec.Code, len(repo.contacts))
+	}
+	id := repo.contacts[0].ID
+	if rec, _ := do(http.MethodPost, "/contacts", map[string]string{"name": "abebe "}); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a duplicate name, got %d", rec.Code)
+	}
+
+	rec, env := do(http.MethodGet, "/contacts/"+id+"/balance", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(string(env.Data), `"net":110.00`) || !strings.Contains(string(env.Data), `"name":"Abebe"`) {
+		t.Fatalf("unexpected balance: code=%d data=%s", rec.Code, env.Data)
+	}
+	if rec, _ := do(http.MethodGet, "/contacts/"+uuid.NewString()+"/balance", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another contact's balance, got %d", rec.Code)
+	}
+
+	repo.debts[id] = 2
+	if rec, _ := do(http.MethodDelete, "/contacts/"+id, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 deleting a contact with debts, got %d", rec.Code)
+	}
+	repo.debts[id] = 0
+	if rec, _ := do(http.MethodDelete, "/contacts/"+id, nil); rec.Code != http.StatusOK || len(repo.contacts) != 0 {
+		t.Fatalf("unexpected delete: code=%d contacts=%d", rec.Code, len(repo.contacts))
+	}
+}
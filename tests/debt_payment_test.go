@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+// fakeDebtPaymentRepo keeps one debt and its payments, applying payments the way the PG repo does
+type fakeDebtPaymentRepo struct {
+	debt     *domain.Debt
+	payments []*domain.DebtPayment
+}
+
+func (f *fakeDebtPaymentRepo) Create(_ context.Context, p *domain.DebtPayment) (*domain.Debt, error) {
+	if p.Amount.Cmp(f.debt.Balance) > 0 {
+		return nil, nil
+	}
+	f.payments = append(f.payments, p)
+	f.debt.PaidAmount = f.debt.PaidAmount.Add(p.Amount)
+	f.debt.Balance = f.debt.Amount.Sub(f.debt.PaidAmount)
+	if f.debt.Balance.IsZero() {
+		f.debt.Status = domain.DebtStatusPaid
+	}
+	return f.debt, nil
+}
+func (f *fakeDebtPaymentRepo) ListByDebt(context.Context, string) ([]*domain.DebtPayment, error) {
+	return f.payments, nil
+}
+
+func TestDebtPaymentRoutes(t *testing.T) {
+	userID := uuid.New()
+	jwtSvc := auth.NewJWTService("test-secret")
+	debt := &domain.Debt{ID: uuid.NewString(), UserID: userID.String(), Type: "lent", Amount: money(100), Balance: money(100), Status: domain.DebtStatusPending}
+	paymentRepo := &fakeDebtPaymentRepo{debt: debt}
+	debtRepo := fakeDebtRepo{
+		getByIDFn: func(context.Context, string) (*domain.Debt, error) {
+			copied := *debt
+			return &copied, nil
+		},
+	}
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterDebtRoutes(mux, deliveryhttp.NewDebtHandler(usecases.NewDebtUsecase(debtRepo, defaultCurrencyUsers("ETB"), &fakeReminderRepo{}, paymentRepo, nil), jwtSvc))
+
+	do := func(method, target string, body interface{}) (*httptest.ResponseRecorder, apiEnvelope) {
+		rec := httptest.NewRecorder()
+		req := newJSONRequest(t, method, target, body)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, userID))
+		mux.ServeHTTP(rec, req)
+		return rec, decodeEnvelope(t, rec)
+	}
+	path := "/debts/" + debt.ID + "/payments"
+
+	rec, env := do(http.MethodPost, path, map[string]interface{}{"amount": 40.10, "paid_date": "2026-03-01", "note": "first half"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("unexpected create response: code=%d env=%+v", rec.Code, env)
+	}
+	var created struct {
+		Payment domain.DebtPayment `json:"payment"`
+		Debt    domain.Debt        `json:"debt"`
+	}
+	if err := json.Unmarshal(env.Data, &created); err != nil {
+		t.Fatalf("decode payment: %v", err)
+	}
+	if created.Debt.Balance != money(59.90) || created.Debt.Status != domain.DebtStatusPending || created.Payment.PaidDate.Format("2006-01-02") != "2026-03-01" {
+		t.Fatalf("unexpected payment result: %+v", created)
+	}
+
+	if rec, _ := do(http.MethodPost, path, map[string]interface{}{"amount": 60}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a payment over the balance, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodPost, path, map[string]interface{}{"amount": 1, "paid_date": time.Now().AddDate(0, 0, 2).Format("2006-01-02")}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a future paid_date, got %d", rec.Code)
+	}
+
+	// Marking the debt paid records the rest as a payment
+	rec, _ = do(http.MethodPatch, "/debts/"+debt.ID+"/pay", nil)
+	if rec.Code != http.StatusOK || debt.Status != domain.DebtStatusPaid || len(paymentRepo.payments) != 2 || paymentRepo.payments[1].Amount != money(59.90) {
+		t.Fatalf("unexpected mark paid: code=%d status=%s payments=%d", rec.Code, debt.Status, len(paymentRepo.payments))
+	}
+	if rec, _ := do(http.MethodPost, path, map[string]interface{}{"amount": 1}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a payment on a paid debt, got %d", rec.Code)
+	}
+
+	rec, env = do(http.MethodGet, path, nil)
+	var listed []domain.DebtPayment
+	if err := json.Unmarshal(env.Data, &listed); err != nil || rec.Code != http.StatusOK || len(listed) != 2 {
+		t.Fatalf("unexpected list response: code=%d payments=%d (%v)", rec.Code, len(listed), err)
+	}
+}
+
+func TestDebtUpdateKeepsRepayments(t *testing.T) {
+	existing := &domain.Debt{ID: "d1", UserID: "u1", Type: "lent", PeerName: "Sam", Amount: money(100), PaidAmount: money(30), Balance: money(70), Status: domain.DebtStatusPending}
+	var saved *domain.Debt
+	uc := usecases.NewDebtUsecase(fakeDebtRepo{
+		getByIDFn: func(context.Context, string) (*domain.Debt, error) { return existing, nil },
+		updateFn:  func(_ context.Context, d *domain.Debt) error { saved = d; return nil },
+	}, defaultCurrencyUsers("ETB"), nil, nil, nil)
+	due := time.Now().AddDate(0, 0, 7)
+
+	if err := uc.Update(context.Background(), &domain.Debt{ID: "d1", Type: "lent", PeerName: "Sam", Amount: money(20), DueDate: due}); !errors.Is(err, usecases.ErrAmountBelowPaid) {
+		t.Fatalf("expected ErrAmountBelowPaid, got %v", err)
+	}
+	if err := uc.Update(context.Background(), &domain.Debt{ID: "d1", Type: "lent", PeerName: "Sam", Amount: money(30), DueDate: due}); err != nil || saved.Status != domain.DebtStatusPaid {
+		t.Fatalf("lowering the amount to what was repaid should settle the debt: err=%v debt=%+v", err, saved)
+	}
+}
+
+func TestReportsIncludeRepayments(t *testing.T) {
+	debtRepo := fakeDebtReportRepo{repaid: map[string]domain.Money{"lent": money(25), "borrowed": money(10.5)}}
+	uc := usecases.NewReportUsecase(fakeExpenseRepo{}, debtRepo, nil, nil, nil, nil)
+
+	monthly, err := uc.GetMonthlyReport(context.Background(), uuid.New(), 2026, time.March)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if monthly.LentRepaid != money(25) || monthly.BorrowedRepaid != money(10.5) {
+		t.Fatalf("unexpected repayments: lent=%s borrowed=%s", monthly.LentRepaid, monthly.BorrowedRepaid)
+	}
+	daily, err := uc.GetDailyReport(context.Background(), uuid.New(), time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil || daily.LentRepaid != money(25) {
+		t.Fatalf("unexpected daily repayments: %+v (%v)", daily, err)
+	}
+}
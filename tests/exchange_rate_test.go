@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+type fakeExchangeRateRepo struct {
+	rates []domain.ExchangeRate
+}
+
+func (f *fakeExchangeRateRepo) Upsert(_ context.Context, rates []domain.ExchangeRate) (int, error) {
+	f.rates = append(f.rates, rates...)
+	return len(rates), nil
+}
+func (f *fakeExchangeRateRepo) List(_ context.Context, filter domain.ExchangeRateFilter) ([]*domain.ExchangeRate, int, error) {
+	list := make([]*domain.ExchangeRate, 0)
+	for i := range f.rates {
+		if filter.Base == "" || f.rates[i].Base == filter.Base {
+			list = append(list, &f.rates[i])
+		}
+	}
+	return list, len(list), nil
+}
+
+type fakeMissingRateRepo struct {
+	missing []domain.MissingRate
+}
+
+func (f fakeMissingRateRepo) MissingRates(context.Context, uuid.UUID, time.Time, time.Time) ([]domain.MissingRate, error) {
+	return f.missing, nil
+}
+
+func TestParseExchangeRates(t *testing.T) {
+	csvRates, err := usecases.ParseExchangeRates(strings.NewReader("rate,date,base,quote\n57.25,2026-03-01,usd,ETB\n"), usecases.ExchangeRateFormatCSV)
+	if err != nil || len(csvRates) != 1 {
+		t.Fatalf("unexpected CSV result: %+v (%v)", csvRates, err)
+	}
+	if r := csvRates[0]; r.Base != "usd" || r.Quote != "ETB" || r.Rate != 57.25 || !r.Date.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected CSV rate: %+v", r)
+	}
+
+	jsonRates, err := usecases.ParseExchangeRates(strings.NewReader(`[{"date":"2026-03-01","base":"EUR","quote":"USD","rate":1.08}]`), usecases.ExchangeRateFormatJSON)
+	if err != nil || len(jsonRates) != 1 || jsonRates[0].Rate != 1.08 {
+		t.Fatalf("unexpected JSON result: %+v (%v)", jsonRates, err)
+	}
+
+	if _, err := usecases.ParseExchangeRates(strings.NewReader("date,base,rate\n2026-03-01,USD,1\n"), usecases.ExchangeRateFormatCSV); err == nil {
+		t.Fatal("expected an error for a CSV without a quote column")
+	}
+	if _, err := usecases.ParseExchangeRates(strings.NewReader("date,base,quote,rate\n03/01/2026,USD,ETB,57\n"), usecases.ExchangeRateFormatCSV); !errors.Is(err, usecases.ErrInvalidExchangeRate) {
+		t.Fatalf("expected ErrInvalidExchangeRate for a bad date, got %v", err)
+	}
+}
+
+func TestExchangeRateImportValidation(t *testing.T) {
+	repo := &fakeExchangeRateRepo{}
+	uc := usecases.NewExchangeRateUseCase(repo)
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	invalid := [][]domain.ExchangeRate{
+		{{Base: "US", Quote: "ETB", Rate: 57, Date: day}},
+		{{Base: "USD", Quote: "usd", Rate: 1, Date: day}},
+		{{Base: "USD", Quote: "ETB", Rate: 0, Date: day}},
+		{{Base: "USD", Quote: "ETB", Rate: 57}},
+	}
+	for _, rates := range invalid {
+		if _, err := uc.Import(context.Background(), rates); !errors.Is(err, usecases.ErrInvalidExchangeRate) {
+			t.Fatalf("expected ErrInvalidExchangeRate for %+v, got %v", rates, err)
+		}
+	}
+	if len(repo.rates) != 0 {
+		t.Fatalf("invalid imports should store nothing, got %d rates", len(repo.rates))
+	}
+
+	n, err := uc.Import(context.Background(), []domain.ExchangeRate{{Base: " eur", Quote: "etb", Rate: 62.1, Date: day}})
+	if err != nil || n != 1 || repo.rates[0].Base != "EUR" || repo.rates[0].Quote != "ETB" {
+		t.Fatalf("unexpected import: n=%d err=%v rates=%+v", n, err, repo.rates)
+	}
+}
+
+func TestExchangeRateRoutes(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	repo := &fakeExchangeRateRepo{}
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterExchangeRateRoutes(mux, deliveryhttp.NewExchangeRateHandler(usecases.NewExchangeRateUseCase(repo), jwtSvc, "admin-secret"))
+
+	importCSV := func(key, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/admin/exchange-rates", strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+		req.Header.Set("X-Admin-Key", key)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := importCSV("wrong", "date,base,quote,rate\n2026-03-01,USD,ETB,57\n"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with a wrong admin key, got %d", code)
+	}
+	if code := importCSV("admin-secret", "date,base,quote,rate\n2026-03-01,USD,ETB,-1\n"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a negative rate, got %d", code)
+	}
+	if code := importCSV("admin-secret", "date,base,quote,rate\n2026-03-01,USD,ETB,57\n2026-03-01,EUR,USD,1.08\n"); code != http.StatusOK || len(repo.rates) != 2 {
+		t.Fatalf("unexpected import: code=%d rates=%d", code, len(repo.rates))
+	}
+
+	req := newJSONRequest(t, http.MethodGet, "/exchange-rates?base=usd", nil)
+	req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, uuid.New()))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if env := decodeEnvelope(t, rec); rec.Code != http.StatusOK || !strings.Contains(string(env.Data), `"quote":"ETB"`) || strings.Contains(string(env.Data), "EUR") {
+		t.Fatalf("unexpected list response: code=%d data=%s", rec.Code, env.Data)
+	}
+
+	disabled := http.NewServeMux()
+	deliveryhttp.RegisterExchangeRateRoutes(disabled, deliveryhttp.NewExchangeRateHandler(usecases.NewExchangeRateUseCase(repo), jwtSvc, ""))
+	req = httptest.NewRequest(http.MethodPost, "/admin/exchange-rates", strings.NewReader("[]"))
+	rec = httptest.NewRecorder()
+	disabled.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 when no admin key is configured, got %d", rec.Code)
+	}
+}
+
+func TestCurrencyValidation(t *testing.T) {
+	var created domain.CreateExpenseInput
+	repo := fakeExpenseRepo{
+		createFn: func(_ context.Context, in domain.CreateExpenseInput) (*domain.Expense, error) {
+			created = in
+			return &domain.Expense{ID: in.ID, UserID: in.UserID, Currency: in.Currency}, nil
+		},
+	}
+	jwtSvc := auth.NewJWTService("test-secret")
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterExpenseRoutes(mux, deliveryhttp.NewExpenseHandler(usecases.NewExpenseUseCase(repo, defaultCurrencyUsers("ETB"))))
+	create := func(currency string) int {
+		req := newJSONRequest(t, http.MethodPost, "/expenses", map[string]interface{}{"amount": 12, "expense_date": "2026-03-01", "currency": currency})
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, uuid.New()))
+		return serveWithExpenseCategoryAuth(jwtSvc, req, mux.ServeHTTP).Code
+	}
+	if code := create("dollars"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid currency, got %d", code)
+	}
+	if code := create("usd"); code != http.StatusCreated || created.Currency != "USD" {
+		t.Fatalf("expected currency to be normalized: code=%d currency=%q", code, created.Currency)
+	}
+
+	var saved *domain.Debt
+	debtUC := usecases.NewDebtUsecase(fakeDebtRepo{
+		createFn: func(_ context.Context, d *domain.Debt) error { saved = d; return nil },
+		updateFn: func(_ context.Context, d *domain.Debt) error { saved = d; return nil },
+		getByIDFn: func(_ context.Context, id string) (*domain.Debt, error) {
+			return &domain.Debt{ID: id, UserID: "u1", Currency: "EUR", Status: domain.DebtStatusPending}, nil
+		},
+	}, defaultCurrencyUsers("ETB"), nil, nil, nil)
+	due := time.Now().AddDate(0, 0, 7)
+	if err := debtUC.Create(context.Background(), &domain.Debt{UserID: "u1", Type: "lent", PeerName: "Sam", Amount: money(5), Currency: "EURO", DueDate: due}); !errors.Is(err, usecases.ErrInvalidCurrency) {
+		t.Fatalf("expected ErrInvalidCurrency, got %v", err)
+	}
+	if err := debtUC.Update(context.Background(), &domain.Debt{ID: "d1", Type: "lent", PeerName: "Sam", Amount: money(5), DueDate: due}); err != nil || saved.Currency != "EUR" {
+		t.Fatalf("update without a currency should keep it: err=%v currency=%q", err, saved.Currency)
+	}
+}
+
+func TestReportsListMissingRates(t *testing.T) {
+	userID := uuid.New()
+	missing := []domain.MissingRate{
+		{Kind: "expense", Currency: "KES", Count: 2, Amount: domain.Cents(150000)},
+		{Kind: "lent", Currency: "NGN", Count: 1, Amount: domain.Cents(2000000)},
+	}
+	uc := usecases.NewReportUsecase(fakeExpenseRepo{}, fakeDebtReportRepo{}, nil, nil, nil, fakeMissingRateRepo{missing: missing})
+
+	daily, err := uc.GetDailyReport(context.Background(), userID, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(daily.MissingRates) != 2 || daily.MissingRates[1].Kind != "lent" || daily.MissingRates[1].Amount != domain.Cents(2000000) {
+		t.Fatalf("unexpected daily missing rates: %+v (%v)", daily.MissingRates, err)
+	}
+	monthly, err := uc.GetMonthlyReport(context.Background(), userID, 2026, time.March)
+	if err != nil || len(monthly.MissingRates) != 2 || monthly.MissingRates[0].Currency != "KES" || monthly.MissingRates[0].Count != 2 {
+		t.Fatalf("unexpected monthly missing rates: %+v (%v)", monthly.MissingRates, err)
+	}
+
+	// Complete totals still say so, as an empty list rather than null
+	uc = usecases.NewReportUsecase(fakeExpenseRepo{}, fakeDebtReportRepo{}, nil, nil, nil, fakeMissingRateRepo{})
+	weekly, err := uc.GetWeeklyReport(context.Background(), userID, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC))
+	if err != nil || weekly.MissingRates == nil || len(weekly.MissingRates) != 0 {
+		t.Fatalf("expected an empty list of missing rates: %#v (%v)", weekly.MissingRates, err)
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+func TestExpenseSearchRoute(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	userID := uuid.New()
+	coffee, food := uuid.NewString(), uuid.NewString()
+
+	var got domain.ExpenseSearch
+	var calls int
+	expenseRepo := fakeExpenseRepo{
+		searchFn: func(_ context.Context, s domain.ExpenseSearch) ([]*domain.Expense, int, *domain.ExpenseFacets, error) {
+			calls++
+			got = s
+			return []*domain.Expense{{ID: "e1", UserID: s.UserID, Amount: domain.Cents(4250), Note: "Espresso beans"}}, 12,
+				&domain.ExpenseFacets{
+					Categories:    []domain.CategoryFacet{{CategoryID: &coffee, CategoryName: "Coffee", Count: 9}, {CategoryName: "", Count: 3}},
+					Recurring:     2,
+					HasAttachment: 5,
+				}, nil
+		},
+	}
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterExpenseRoutes(mux, deliveryhttp.NewExpenseHandler(usecases.NewExpenseUseCase(expenseRepo, defaultCurrencyUsers("ETB"))))
+	handler := deliveryhttp.JWTAuthMiddleware(jwtSvc, nil, mux)
+
+	search := func(query string) (*httptest.ResponseRecorder, apiEnvelope) {
+		req := httptest.NewRequest(http.MethodGet, "/expenses/search"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, userID))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec, decodeEnvelope(t, rec)
+	}
+
+	rec, env := search("?q=%20espresso%20beans%20&category_id=" + coffee + "," + food + "&min_amount=10&max_amount=99.5" +
+		"&from_date=2026-01-01&to_date=2026-06-30&recurring=true&has_attachment=false&sort=amount&order=asc&page=2&page_size=5")
+	var data struct {
+		Items []domain.Expense `json:"items"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(env.Data, &data) != nil || len(data.Items) != 1 || data.Items[0].ID != "e1" {
+		t.Fatalf("unexpected search response: %d %s %v", rec.Code, env.Data, env.Errors)
+	}
+	if got.UserID != userID.String() || got.Query != "espresso beans" || len(got.CategoryIDs) != 2 || got.CategoryIDs[1] != food ||
+		got.MinAmount == nil || got.MinAmount.String() != "10.00" || got.MaxAmount == nil || got.MaxAmount.String() != "99.50" ||
+		got.FromDate == nil || got.FromDate.Format("2006-01-02") != "2026-01-01" || got.ToDate == nil ||
+		!got.RecurringOnly || got.HasAttachment == nil || *got.HasAttachment || got.Sort != domain.ExpenseSortAmount ||
+		!got.Ascending || got.Limit != 5 || got.Offset != 5 {
+		t.Fatalf("unexpected search: %+v", got)
+	}
+
+	pagination, _ := env.Meta["pagination"].(map[string]interface{})
+	facetsJSON, _ := json.Marshal(env.Meta["facets"])
+	var facets domain.ExpenseFacets
+	if pagination == nil || pagination["total_items"] != float64(12) || json.Unmarshal(facetsJSON, &facets) != nil ||
+		len(facets.Categories) != 2 || facets.Categories[0].Count != 9 || facets.Categories[1].CategoryID != nil ||
+		facets.Recurring != 2 || facets.HasAttachment != 5 {
+		t.Fatalf("unexpected meta: %v", env.Meta)
+	}
+
+	if rec, _ := search(""); rec.Code != http.StatusOK || got.Sort != domain.ExpenseSortDate || got.Ascending ||
+		got.HasAttachment != nil || got.RecurringOnly || got.Offset != 0 {
+		t.Fatalf("unexpected defaults: %d %+v", rec.Code, got)
+	}
+
+	calls = 0
+	for _, query := range []string{
+		"?category_id=coffee",
+		"?min_amount=ten",
+		"?min_amount=50&max_amount=10",
+		"?min_amount=-1",
+		"?from_date=2026-06-30&to_date=2026-01-01",
+		"?recurring=maybe",
+		"?has_attachment=yes%20please",
+		"?sort=relevance",
+		"?order=up",
+	} {
+		if rec, env := search(query); rec.Code != http.StatusBadRequest || env.Message != "Validation failed" {
+			t.Fatalf("%s: expected a validation error, got %d %v", query, rec.Code, env.Errors)
+		}
+	}
+	if calls != 0 {
+		t.Fatalf("invalid searches must not reach the repository")
+	}
+}
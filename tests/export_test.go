@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/infrastructure/export"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+type fakeExportRepo struct {
+	expenses []*domain.ExpenseRecord
+	debts    []*domain.Debt
+	err      error // returned before any record
+	filter   *domain.ExpenseFilter
+}
+
+func (f *fakeExportRepo) StreamExpenses(_ context.Context, filter domain.ExpenseFilter, fn func(*domain.ExpenseRecord) error) error {
+	f.filter = &filter
+	if f.err != nil {
+		return f.err
+	}
+	for _, e := range f.expenses {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeExportRepo) StreamDebts(_ context.Context, _ string, _, _ *time.Time, fn func(*domain.Debt) error) error {
+	if f.err != nil {
+		return f.err
+	}
+	for _, d := range f.debts {
+		if err := fn(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportEncoders() map[domain.ExportFormat]usecases.ExportEncoder {
+	return map[domain.ExportFormat]usecases.ExportEncoder{
+		domain.ExportFormatCSV:  export.CSVEncoder{},
+		domain.ExportFormatJSON: export.JSONEncoder{},
+		domain.ExportFormatXLSX: export.XLSXEncoder{},
+	}
+}
+
+func TestExportEncoders(t *testing.T) {
+	columns := []string{"note", "amount", "paid", "category"}
+	rows := [][]interface{}{
+		{"=SUM(A1)", domain.Cents(-1250), true, nil},
+		{"Lunch & <coffee>", domain.Cents(800), false, "Food"},
+	}
+	write := func(format domain.ExportFormat) []byte {
+		var buf bytes.Buffer
+		w := exportEncoders()[format].NewWriter(&buf, "Expenses")
+		if err := w.WriteHeader(columns); err != nil {
+			t.Fatalf("%s header: %v", format, err)
+		}
+		for _, row := range rows {
+			if err := w.WriteRow(row); err != nil {
+				t.Fatalf("%s row: %v", format, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s close: %v", format, err)
+		}
+		return buf.Bytes()
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(write(domain.ExportFormatCSV))).ReadAll()
+	if err != nil || len(records) != 3 {
+		t.Fatalf("unexpected csv: %v %v", records, err)
+	}
+	if strings.Join(records[1], "|") != "'=SUM(A1)|-12.50|true|" || strings.Join(records[2], "|") != "Lunch & <coffee>|8.00|false|Food" {
+		t.Fatalf("unexpected csv rows: %v", records)
+	}
+
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(write(domain.ExportFormatJSON), &objects); err != nil || len(objects) != 2 {
+		t.Fatalf("unexpected json: %v %v", objects, err)
+	}
+	if objects[0]["note"] != "=SUM(A1)" || objects[0]["amount"] != -12.5 || objects[0]["paid"] != true || objects[0]["category"] != nil {
+		t.Fatalf("unexpected json row: %v", objects[0])
+	}
+
+	file := write(domain.ExportFormatXLSX)
+	archive, err := zip.NewReader(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("xlsx is not a zip: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range archive.File {
+		r, _ := f.Open()
+		content, _ := io.ReadAll(r)
+		r.Close()
+		parts[f.Name] = string(content)
+	}
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	if parts["[Content_Types].xml"] == "" || !strings.Contains(parts["xl/workbook.xml"], `name="Expenses"`) {
+		t.Fatalf("missing workbook parts: %v", parts)
+	}
+	if !strings.Contains(sheet, `<c r="B2" s="2"><v>-12.50</v></c>`) || !strings.Contains(sheet, `<c r="C2" t="b"><v>1</v></c>`) ||
+		!strings.Contains(sheet, "Lunch &amp; &lt;coffee&gt;") || strings.Contains(sheet, `r="D2"`) ||
+		!strings.HasSuffix(sheet, "</sheetData></worksheet>") {
+		t.Fatalf("unexpected sheet: %s", sheet)
+	}
+}
+
+func TestExportRoute(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	categoryID := uuid.New().String()
+	repo := &fakeExportRepo{expenses: []*domain.ExpenseRecord{
+		{Expense: domain.Expense{ID: "e1", Amount: domain.Cents(1999), Currency: "USD", CategoryID: &categoryID,
+			Note: "Groceries", ExpenseDate: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)}, CategoryName: "Food"},
+		{Expense: domain.Expense{ID: "e2", Amount: domain.Cents(500), Currency: "USD",
+			ExpenseDate: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)}},
+	}}
+	reports := fakeReportUsecase{weeklyFn: func(_ context.Context, _ uuid.UUID, start, end time.Time) (usecases.WeeklyReport, error) {
+		return usecases.WeeklyReport{
+			TotalExpense: domain.Cents(2499),
+			CategoryBreakdown: []usecases.WeeklyCategorySummary{{CategoryID: &categoryID, CategoryName: "Food", Total: domain.Cents(1999),
+				Budget: &usecases.BudgetStatus{Budgeted: domain.Cents(5000), Remaining: domain.Cents(3001)}}},
+			MissingRates: []domain.MissingRate{{Kind: "expense", Currency: "KES", Count: 1, Amount: domain.Cents(90000)}},
+		}, nil
+	}}
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterExportRoutes(mux, deliveryhttp.NewExportHandler(usecases.NewExportUseCase(repo, reports, exportEncoders())))
+	handler := deliveryhttp.JWTAuthMiddleware(jwtSvc, nil, mux)
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, uuid.New()))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/exports?type=expenses&from=2026-03-01&to=2026-03-31&category_id=" + categoryID)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Disposition") != `attachment; filename="expenses.csv"` ||
+		!strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("unexpected export: %d %v", rec.Code, rec.Header())
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil || len(records) != 3 || records[0][5] != "category_name" || records[1][5] != "Food" || records[2][5] != "" ||
+		records[1][2] != "19.99" || records[1][1] != "2026-03-02" {
+		t.Fatalf("unexpected csv: %v %v", records, err)
+	}
+	if repo.filter == nil || repo.filter.CategoryID == nil || *repo.filter.CategoryID != categoryID || repo.filter.ToDate == nil {
+		t.Fatalf("filter not passed on: %+v", repo.filter)
+	}
+
+	rec = get("/exports?type=report&format=json&from=2026-03-01&to=2026-03-31")
+	var rows []map[string]interface{}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &rows) != nil || len(rows) != 9 ||
+		rows[0]["amount"] != 24.99 || rows[7]["name"] != "Food" || rows[7]["remaining"] != 30.01 ||
+		rows[8]["section"] != "missing_rate" || rows[8]["name"] != "expense KES" || rows[8]["amount"] != 900.0 {
+		t.Fatalf("unexpected report: %d %s", rec.Code, rec.Body.String())
+	}
+
+	for target, want := range map[string]string{
+		"/exports?type=incomes":                             "type must be one of expenses, debts, report",
+		"/exports?type=debts&format=pdf":                    "format must be one of csv, json, xlsx",
+		"/exports?type=report&from=2026-03-01":              "from and to are required for a report",
+		"/exports?type=debts&from=2026-03-05&to=2026-03-01": "end date must be on or after start date",
+		"/exports?type=expenses&from=03/01/2026":            "from must use YYYY-MM-DD",
+	} {
+		rec := get(target)
+		if env := decodeEnvelope(t, rec); rec.Code != http.StatusBadRequest || len(env.Errors) != 1 || env.Errors[0] != want {
+			t.Fatalf("%s: unexpected response %d %v", target, rec.Code, env.Errors)
+		}
+	}
+
+	repo.err = errors.New("connection reset")
+	rec = get("/exports?type=debts&format=xlsx")
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get("Content-Disposition") != "" {
+		t.Fatalf("expected a JSON error before the file starts, got %d %v", rec.Code, rec.Header())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/exports?type=expenses", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", rec.Code)
+	}
+}
@@ -63,7 +63,7 @@ func makeAccessToken(t *testing.T, jwtSvc *auth.JWTService, userID uuid.UUID) st
 
 func serveWithExpenseCategoryAuth(jwtSvc *auth.JWTService, req *http.Request, next http.HandlerFunc) *httptest.ResponseRecorder {
 	rec := httptest.NewRecorder()
-	deliveryhttp.JWTAuthMiddleware(jwtSvc, next).ServeHTTP(rec, req)
+	deliveryhttp.JWTAuthMiddleware(jwtSvc, nil, next).ServeHTTP(rec, req)
 	return rec
 }
 
@@ -0,0 +1,235 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/infrastructure/statement"
+	"expense_tracker/repository"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+func importParsers() map[domain.ImportFormat]usecases.StatementParser {
+	return map[domain.ImportFormat]usecases.StatementParser{
+		domain.ImportFormatCSV: statement.CSVParser{},
+		domain.ImportFormatOFX: statement.OFXParser{},
+		domain.ImportFormatQIF: statement.QIFParser{},
+	}
+}
+
+func TestStatementParsers(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	csvFile := "Booked;Details;Value;Kind\n" +
+		"03.05.2024;Supermarket;-1.234,50;Groceries\n" +
+		"\n" +
+		"04.05.2024;Salary;2.000,00;\n" +
+		"31.02.2024;Broken;-1,00;\n" +
+		"05.05.2024;\"Cafe; corner\";(4,20);\n"
+	rows, err := statement.CSVParser{}.Parse(strings.NewReader(csvFile), domain.ImportOptions{
+		DateColumn: "booked", AmountColumn: "3", NoteColumn: "Details", CategoryColumn: "kind",
+		DateFormats: []string{"DD.MM.YYYY"}, Delimiter: ';', DecimalComma: true,
+	})
+	if err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	if len(rows) != 4 || !rows[0].Date.Equal(day(2024, 5, 3)) || rows[0].Amount != domain.Cents(-123450) ||
+		rows[0].Note != "Supermarket" || rows[0].Category != "Groceries" || rows[0].Line != 2 {
+		t.Fatalf("unexpected csv rows: %+v", rows)
+	}
+	if rows[2].Error == "" || rows[2].Line != 5 {
+		t.Fatalf("expected an invalid date on line 5, got %+v", rows[2])
+	}
+	if rows[3].Amount != domain.Cents(-420) || rows[3].Note != "Cafe; corner" {
+		t.Fatalf("unexpected parenthesised amount: %+v", rows[3])
+	}
+	if _, err := (statement.CSVParser{}).Parse(strings.NewReader(csvFile), domain.ImportOptions{}); err == nil {
+		t.Fatal("expected an error when the date column is missing")
+	}
+	if _, err := (statement.CSVParser{}).Parse(strings.NewReader(csvFile), domain.ImportOptions{DateFormats: []string{"DD/QQ"}}); err == nil {
+		t.Fatal("expected an error for an unknown date format token")
+	}
+
+	ofxFile := "OFXHEADER:100\nDATA:OFXSGML\n\n<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>EUR\n<BANKTRANLIST>\n" +
+		"<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20240503120000[-5:EST]<TRNAMT>-12.30<FITID>1<NAME>Bakery &amp; Co\n</STMTTRN>\n" +
+		"<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20240504<TRNAMT>-7.00<FITID>2<MEMO>Parking\n</STMTTRN>\n" +
+		"</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>\n"
+	rows, err = statement.OFXParser{}.Parse(strings.NewReader(ofxFile), domain.ImportOptions{})
+	if err != nil {
+		t.Fatalf("ofx: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Note != "Bakery & Co" || rows[0].Amount != domain.Cents(-1230) || rows[0].Currency != "EUR" ||
+		!rows[0].Date.Equal(day(2024, 5, 3)) || rows[1].Note != "Parking" {
+		t.Fatalf("unexpected ofx rows: %+v", rows)
+	}
+	if _, err := (statement.OFXParser{}).Parse(strings.NewReader("date,amount\n"), domain.ImportOptions{}); err == nil {
+		t.Fatal("expected an error for a file that is not OFX")
+	}
+
+	// Windows-1252 text, and runes whose upper case has another length, must not shift the fields
+	latin1 := "OFXHEADER:100\nCHARSET:1252\n\n<OFX><STMTTRN><DTPOSTED>20240105<TRNAMT>-1.00<NAME>" + strings.Repeat("\xe9", 20) +
+		"\n<stmttrn><dtposted>20240106<trnamt>-2.00<name>Caf\xe9 \x80\n</stmttrn></OFX>\n"
+	rows, err = statement.OFXParser{}.Parse(strings.NewReader(latin1), domain.ImportOptions{})
+	if err != nil {
+		t.Fatalf("ofx 1252: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Note != strings.Repeat("é", 20) || rows[0].Amount != domain.Cents(-100) ||
+		rows[1].Note != "Café €" || rows[1].Amount != domain.Cents(-200) || !rows[1].Date.Equal(day(2024, 1, 6)) {
+		t.Fatalf("unexpected ofx 1252 rows: %+v", rows)
+	}
+	utf8File := "<OFX><STMTTRN><NAME>" + strings.Repeat("ıſ", 10) + "<DTPOSTED>20240107<TRNAMT>-4.00\n</STMTTRN></OFX>"
+	if rows, err := (statement.OFXParser{}).Parse(strings.NewReader(utf8File), domain.ImportOptions{}); err != nil || len(rows) != 1 ||
+		rows[0].Note != strings.Repeat("ıſ", 10) || rows[0].Amount != domain.Cents(-400) || !rows[0].Date.Equal(day(2024, 1, 7)) {
+		t.Fatalf("unexpected ofx utf-8 rows: %+v, %v", rows, err)
+	}
+	if rows, err := (statement.CSVParser{}).Parse(strings.NewReader("date,amount,note\n2024-01-05,-3.00,Cr\xe8me br\xfbl\xe9e\n"),
+		domain.ImportOptions{DateColumn: "date", AmountColumn: "amount", NoteColumn: "note"}); err != nil || len(rows) != 1 || rows[0].Note != "Crème brûlée" {
+		t.Fatalf("unexpected csv 1252 rows: %+v, %v", rows, err)
+	}
+	if rows, err := (statement.QIFParser{}).Parse(strings.NewReader("!Type:Bank\nD1/5/2024\nT-3.00\nPCaf\xe9\n^\n"), domain.ImportOptions{}); err != nil ||
+		len(rows) != 1 || rows[0].Note != "Café" {
+		t.Fatalf("unexpected qif 1252 rows: %+v, %v", rows, err)
+	}
+
+	qifFile := "!Type:Cat\nNGroceries\n^\n!Type:Bank\nD5/ 3'24\nT-45.10\nPGreengrocer\nLGroceries:Fruit/Home\n^\nD05/04/2024\nT-1,250.00\nMRent\nL[Savings]\n^\n"
+	rows, err = statement.QIFParser{}.Parse(strings.NewReader(qifFile), domain.ImportOptions{})
+	if err != nil {
+		t.Fatalf("qif: %v", err)
+	}
+	if len(rows) != 2 || !rows[0].Date.Equal(day(2024, 5, 3)) || rows[0].Amount != domain.Cents(-4510) || rows[0].Note != "Greengrocer" ||
+		rows[0].Category != "Groceries:Fruit" || rows[1].Amount != domain.Cents(-125000) || rows[1].Note != "Rent" || rows[1].Category != "" {
+		t.Fatalf("unexpected qif rows: %+v", rows)
+	}
+}
+
+func TestImportPreviewAndCommit(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.NewString()
+	groceries := "cat-groceries"
+	var batched []domain.CreateExpenseInput
+	expenses := fakeExpenseRepo{
+		listFn: func(_ context.Context, f domain.ExpenseFilter) ([]*domain.Expense, int, error) {
+			if f.FromDate.Format("2006-01-02") != "2024-05-01" || f.ToDate.Format("2006-01-02") != "2024-05-03" {
+				t.Fatalf("unexpected duplicate lookup range %v - %v", f.FromDate, f.ToDate)
+			}
+			existing := []*domain.Expense{{ID: "old", Amount: domain.Cents(500), Note: "coffee  shop", ExpenseDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}}
+			return existing, len(existing), nil
+		},
+		createBatchFn: func(_ context.Context, in []domain.CreateExpenseInput) ([]string, error) {
+			batched = in
+			ids := make([]string, len(in))
+			for i, e := range in {
+				ids[i] = e.ID
+			}
+			return ids, nil
+		},
+	}
+	categories := fakeCategoryRepo{listFn: func(context.Context, *string, repository.ListOptions) ([]*domain.Category, int, error) {
+		return []*domain.Category{{ID: groceries, Name: "Groceries"}}, 1, nil
+	}}
+	uc := usecases.NewImportUseCase(expenses, categories, defaultCurrencyUsers("ETB"), importParsers())
+
+	file := "date,description,amount,category\n" +
+		"2024-05-01,Coffee Shop,-5.00,\n" +
+		"2024-05-01,Coffee Shop,-5.00,\n" +
+		"2024-05-02,Refund,12.00,\n" +
+		"2024-05-03,Market,-20.25,groceries\n" +
+		"2024-05-03,Cinema,-9.00,Fun\n" +
+		"2024-05-03,Bad,-x,\n"
+	options := domain.ImportOptions{Format: domain.ImportFormatCSV}
+	result, err := uc.Preview(ctx, userID, nil, strings.NewReader(file), options)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	want := []domain.ImportRowStatus{domain.ImportRowDuplicate, domain.ImportRowNew, domain.ImportRowSkipped, domain.ImportRowNew, domain.ImportRowNew, domain.ImportRowInvalid}
+	for i, row := range result.Rows {
+		if row.Status != want[i] {
+			t.Fatalf("row %d: expected %s, got %+v", i, want[i], row)
+		}
+	}
+	if *result.Rows[0].DuplicateOf != "old" || result.New != 3 || result.Duplicates != 1 || result.Skipped != 1 || result.Invalid != 1 || result.Committed {
+		t.Fatalf("unexpected preview: %+v", result)
+	}
+	if e := result.Rows[3].Expense; e.Amount != domain.Cents(2025) || e.CategoryID == nil || *e.CategoryID != groceries || e.UserID != userID {
+		t.Fatalf("unexpected expense: %+v", e)
+	}
+	if result.Rows[4].Expense.CategoryID != nil || result.Rows[4].Message == "" {
+		t.Fatalf("expected a warning for an unknown category: %+v", result.Rows[4])
+	}
+	if batched != nil {
+		t.Fatal("preview should not save anything")
+	}
+
+	result, err = uc.Commit(ctx, userID, nil, strings.NewReader(file), options, false)
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if len(batched) != 3 || !result.Committed || result.New != 3 || result.Rows[1].Status != domain.ImportRowImported || result.Rows[0].Status != domain.ImportRowDuplicate {
+		t.Fatalf("unexpected commit: %d saved, %+v", len(batched), result)
+	}
+	if _, err := uc.Commit(ctx, userID, nil, strings.NewReader(file), options, true); err != nil || len(batched) != 4 {
+		t.Fatalf("expected duplicates to be imported on request, got %d (%v)", len(batched), err)
+	}
+}
+
+func TestImportRoute(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	expenses := fakeExpenseRepo{
+		listFn: func(context.Context, domain.ExpenseFilter) ([]*domain.Expense, int, error) { return nil, 0, nil },
+		createBatchFn: func(_ context.Context, in []domain.CreateExpenseInput) ([]string, error) {
+			return []string{in[0].ID}, nil
+		},
+	}
+	categories := fakeCategoryRepo{listFn: func(context.Context, *string, repository.ListOptions) ([]*domain.Category, int, error) {
+		return nil, 0, nil
+	}}
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterImportRoutes(mux, deliveryhttp.NewImportHandler(usecases.NewImportUseCase(expenses, categories, defaultCurrencyUsers("ETB"), importParsers())))
+	handler := deliveryhttp.JWTAuthMiddleware(jwtSvc, nil, mux)
+
+	upload := func(filename, content string, fields map[string]string) (*httptest.ResponseRecorder, apiEnvelope) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("file", filename)
+		_, _ = part.Write([]byte(content))
+		for k, v := range fields {
+			_ = form.WriteField(k, v)
+		}
+		_ = form.Close()
+		req := httptest.NewRequest(http.MethodPost, "/imports", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, uuid.New()))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec, decodeEnvelope(t, rec)
+	}
+
+	qif := "!Type:Bank\nD05/03/2024\nT-8.00\nPLunch\n^\n"
+	rec, env := upload("may.qif", qif, nil)
+	var result domain.ImportResult
+	if rec.Code != http.StatusOK || json.Unmarshal(env.Data, &result) != nil || result.Format != domain.ImportFormatQIF || result.New != 1 {
+		t.Fatalf("unexpected preview: code=%d data=%s", rec.Code, env.Data)
+	}
+	rec, env = upload("statement.txt", qif, map[string]string{"format": "qif", "commit": "true"})
+	if rec.Code != http.StatusCreated || json.Unmarshal(env.Data, &result) != nil || !result.Committed || result.Rows[0].Status != domain.ImportRowImported {
+		t.Fatalf("unexpected commit: code=%d data=%s", rec.Code, env.Data)
+	}
+	if rec, _ := upload("statement.txt", qif, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a known format, got %d", rec.Code)
+	}
+	if rec, _ := upload("statement.csv", "date,amount\n", map[string]string{"date_format": "QQ"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad date format, got %d", rec.Code)
+	}
+}
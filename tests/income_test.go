@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/repository"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+type fakeIncomeRepo struct {
+	incomes map[string]*domain.Income
+	total   domain.Money
+}
+
+func (f *fakeIncomeRepo) Create(_ context.Context, in domain.CreateIncomeInput) (*domain.Income, error) {
+	if f.incomes == nil {
+		f.incomes = map[string]*domain.Income{}
+	}
+	income := &domain.Income{
+		ID: uuid.NewString(), UserID: in.UserID, Source: in.Source, Amount: in.Amount, CategoryID: in.CategoryID,
+		IsRecurring: in.IsRecurring, RecurrenceRule: in.RecurrenceRule, NextDueDate: in.NextDueDate,
+		RecurrenceStart: in.RecurrenceStart, Note: in.Note, ReceivedDate: in.ReceivedDate,
+	}
+	f.incomes[income.ID] = income
+	return income, nil
+}
+func (f *fakeIncomeRepo) GetByID(_ context.Context, id, userID string) (*domain.Income, error) {
+	if i := f.incomes[id]; i != nil && i.UserID == userID {
+		return i, nil
+	}
+	return nil, nil
+}
+func (f *fakeIncomeRepo) List(_ context.Context, filter domain.IncomeFilter) ([]*domain.Income, int, error) {
+	list := make([]*domain.Income, 0)
+	for _, i := range f.incomes {
+		if i.UserID == filter.UserID {
+			list = append(list, i)
+		}
+	}
+	return list, len(list), nil
+}
+func (f *fakeIncomeRepo) Update(_ context.Context, id, _ string, in domain.UpdateIncomeInput) (*domain.Income, error) {
+	income := f.incomes[id]
+	if in.Amount != nil {
+		income.Amount = *in.Amount
+	}
+	if in.Source != nil {
+		income.Source = *in.Source
+	}
+	return income, nil
+}
+func (f *fakeIncomeRepo) Delete(_ context.Context, id, _ string) error {
+	delete(f.incomes, id)
+	return nil
+}
+func (f *fakeIncomeRepo) ListRecurringDue(context.Context, string, int) ([]*domain.Income, error) {
+	return nil, nil
+}
+func (f *fakeIncomeRepo) MaterializeOccurrences(context.Context, *domain.Income, []time.Time, *time.Time) (int, error) {
+	return 0, nil
+}
+func (f *fakeIncomeRepo) SumByDateRange(context.Context, uuid.UUID, time.Time, time.Time) (domain.Money, error) {
+	return f.total, nil
+}
+
+type fakeIncomeCategoryRepo struct {
+	categories map[string]*domain.IncomeCategory
+}
+
+func (f *fakeIncomeCategoryRepo) Create(_ context.Context, c *domain.IncomeCategory) error {
+	c.ID = uuid.NewString()
+	f.categories[c.ID] = c
+	return nil
+}
+func (f *fakeIncomeCategoryRepo) GetByID(_ context.Context, id, userID string) (*domain.IncomeCategory, error) {
+	if c := f.categories[id]; c != nil && (c.UserID == nil || *c.UserID == userID) {
+		return c, nil
+	}
+	return nil, nil
+}
+func (f *fakeIncomeCategoryRepo) List(_ context.Context, userID string) ([]*domain.IncomeCategory, error) {
+	list := make([]*domain.IncomeCategory, 0)
+	for _, c := range f.categories {
+		if c.UserID == nil || *c.UserID == userID {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+func (f *fakeIncomeCategoryRepo) Delete(_ context.Context, id, _ string) error {
+	delete(f.categories, id)
+	return nil
+}
+
+func TestIncomeRoutes(t *testing.T) {
+	userID := uuid.New()
+	jwtSvc := auth.NewJWTService("test-secret")
+	salary := uuid.NewString()
+	incomeRepo := &fakeIncomeRepo{}
+	categoryRepo := &fakeIncomeCategoryRepo{categories: map[string]*domain.IncomeCategory{
+		salary: {ID: salary, Name: "Salary"},
+	}}
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterIncomeRoutes(mux, deliveryhttp.NewIncomeHandler(usecases.NewIncomeUseCase(incomeRepo, categoryRepo, defaultCurrencyUsers("ETB")), jwtSvc))
+
+	do := func(method, target string, body interface{}) (*httptest.ResponseRecorder, apiEnvelope) {
+		rec := httptest.NewRecorder()
+		req := newJSONRequest(t, method, target, body)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, userID))
+		mux.ServeHTTP(rec, req)
+		return rec, decodeEnvelope(t, rec)
+	}
+
+	rec, env := do(http.MethodPost, "/income", map[string]interface{}{
+		"source": "Acme Corp", "amount": 3000, "category_id": salary, "received_date": "2026-03-01",
+		"is_recurring": true, "recurrence_rule": map[string]interface{}{"frequency": "monthly"},
+	})
+	if rec.Code != http.StatusCreated || !env.Success {
+		t.Fatalf("unexpected create response: code=%d env=%+v", rec.Code, env)
+	}
+	var created domain.Income
+	if err := json.Unmarshal(env.Data, &created); err != nil || created.NextDueDate == nil || created.NextDueDate.Format("2006-01-02") != "2026-04-01" {
+		t.Fatalf("recurring income should be next due a month later: %+v (%v)", created, err)
+	}
+
+	if rec, _ := do(http.MethodPost, "/income", map[string]interface{}{"source": "Acme", "amount": 10, "received_date": "2026-03-01", "is_recurring": true}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for recurring income without a rule, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodPost, "/income", map[string]interface{}{"source": " ", "amount": 10, "received_date": "2026-03-01"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for missing source, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodPost, "/income", map[string]interface{}{"source": "Gig", "amount": 10, "received_date": "2026-03-01", "category_id": uuid.NewString()}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown income category, got %d", rec.Code)
+	}
+
+	if rec, _ := do(http.MethodPut, "/income/"+created.ID, map[string]interface{}{"amount": 3200}); rec.Code != http.StatusOK || incomeRepo.incomes[created.ID].Amount != money(3200) {
+		t.Fatalf("unexpected update response: code=%d", rec.Code)
+	}
+	if rec, _ := do(http.MethodGet, "/income/"+uuid.NewString(), nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown income, got %d", rec.Code)
+	}
+
+	rec, env = do(http.MethodPost, "/income/categories", map[string]interface{}{"name": "Tutoring"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("unexpected category create response: code=%d", rec.Code)
+	}
+	var category domain.IncomeCategory
+	_ = json.Unmarshal(env.Data, &category)
+	if rec, _ := do(http.MethodDelete, "/income/categories/"+salary, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 deleting a global category, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodDelete, "/income/categories/"+category.ID, nil); rec.Code != http.StatusOK || len(categoryRepo.categories) != 1 {
+		t.Fatalf("unexpected category delete response: code=%d", rec.Code)
+	}
+}
+
+func TestReportsIncludeCashFlow(t *testing.T) {
+	userID := uuid.New()
+	expenseRepo := fakeExpenseRepo{categoryTotals: []repository.CategoryTotal{{CategoryName: "Food", Total: money(1500)}}}
+	incomeRepo := &fakeIncomeRepo{total: money(2000)}
+	userRepo := newFakeUserRepo()
+	_ = userRepo.Create(context.Background(), &domain.User{UserID: userID, Email: "flow@example.com", BudgetingStyle: domain.BudgetingStyleZeroBased})
+	uc := usecases.NewReportUsecase(expenseRepo, fakeDebtReportRepo{}, &fakeBudgetRepo{}, userRepo, incomeRepo, nil)
+
+	monthly, err := uc.GetMonthlyReport(context.Background(), userID, 2026, time.March)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if monthly.TotalIncome != money(2000) || monthly.NetCashFlow != money(500) || monthly.SavingsRate == nil || *monthly.SavingsRate != 25 {
+		t.Fatalf("unexpected cash flow: income=%v net=%v rate=%v", monthly.TotalIncome, monthly.NetCashFlow, monthly.SavingsRate)
+	}
+	// Without planned monthly income, the plan works from income received
+	if monthly.BudgetPlan == nil || monthly.BudgetPlan.Income != money(2000) {
+		t.Fatalf("expected the plan to use recorded income: %+v", monthly.BudgetPlan)
+	}
+
+	incomeRepo.total = domain.Money{}
+	daily, err := uc.GetDailyReport(context.Background(), userID, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if daily.NetCashFlow != money(-1500) || daily.SavingsRate != nil {
+		t.Fatalf("unexpected daily cash flow: net=%v rate=%v", daily.NetCashFlow, daily.SavingsRate)
+	}
+}
@@ -0,0 +1,267 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+type fakeLedgerRepo struct {
+	ledgers map[string]*domain.Ledger
+	members map[string]*domain.LedgerMember // by "ledgerID/userID"
+}
+
+func newFakeLedgerRepo() *fakeLedgerRepo {
+	return &fakeLedgerRepo{ledgers: map[string]*domain.Ledger{}, members: map[string]*domain.LedgerMember{}}
+}
+
+func (f *fakeLedgerRepo) Create(_ context.Context, l *domain.Ledger) error {
+	f.ledgers[l.ID] = l
+	f.members[l.ID+"/"+l.CreatedBy] = &domain.LedgerMember{LedgerID: l.ID, UserID: l.CreatedBy, Role: domain.LedgerRoleOwner}
+	l.Role = domain.LedgerRoleOwner
+	return nil
+}
+func (f *fakeLedgerRepo) GetByID(_ context.Context, id, userID string) (*domain.Ledger, error) {
+	l, m := f.ledgers[id], f.members[id+"/"+userID]
+	if l == nil || m == nil {
+		return nil, nil
+	}
+	copy := *l
+	copy.Role = m.Role
+	return &copy, nil
+}
+func (f *fakeLedgerRepo) ListByUser(_ context.Context, userID string) ([]*domain.Ledger, error) {
+	list := make([]*domain.Ledger, 0)
+	for id := range f.ledgers {
+		if l, _ := f.GetByID(context.Background(), id, userID); l != nil {
+			list = append(list, l)
+		}
+	}
+	return list, nil
+}
+func (f *fakeLedgerRepo) Update(context.Context, *domain.Ledger) error { return nil }
+func (f *fakeLedgerRepo) Delete(_ context.Context, id string) error {
+	delete(f.ledgers, id)
+	return nil
+}
+func (f *fakeLedgerRepo) GetMember(_ context.Context, ledgerID, userID string) (*domain.LedgerMember, error) {
+	if m := f.members[ledgerID+"/"+userID]; m != nil {
+		copy := *m
+		return &copy, nil
+	}
+	return nil, nil
+}
+func (f *fakeLedgerRepo) ListMembers(_ context.Context, ledgerID string) ([]*domain.LedgerMember, error) {
+	list := make([]*domain.LedgerMember, 0)
+	for _, m := range f.members {
+		if m.LedgerID == ledgerID {
+			list = append(list, m)
+		}
+	}
+	return list, nil
+}
+func (f *fakeLedgerRepo) AddMember(_ context.Context, m *domain.LedgerMember) error {
+	f.members[m.LedgerID+"/"+m.UserID] = m
+	return nil
+}
+func (f *fakeLedgerRepo) UpdateMemberRole(_ context.Context, ledgerID, userID string, role domain.LedgerRole) error {
+	m := f.members[ledgerID+"/"+userID]
+	if m == nil {
+		return sql.ErrNoRows
+	}
+	m.Role = role
+	return nil
+}
+func (f *fakeLedgerRepo) RemoveMember(_ context.Context, ledgerID, userID string) error {
+	delete(f.members, ledgerID+"/"+userID)
+	return nil
+}
+func (f *fakeLedgerRepo) CountOwners(_ context.Context, ledgerID string) (int, error) {
+	owners := 0
+	for _, m := range f.members {
+		if m.LedgerID == ledgerID && m.Role == domain.LedgerRoleOwner {
+			owners++
+		}
+	}
+	return owners, nil
+}
+
+func TestLedgerMembership(t *testing.T) {
+	ctx := context.Background()
+	owner, partner := uuid.New(), uuid.New()
+	users := newFakeUserRepo()
+	_ = users.Create(ctx, &domain.User{UserID: partner, Name: "Sara", Email: "sara@example.com"})
+	uc := usecases.NewLedgerUseCase(newFakeLedgerRepo(), users)
+
+	if _, err := uc.Create(ctx, owner.String(), "  "); !errors.Is(err, usecases.ErrLedgerNameRequired) {
+		t.Fatalf("expected ErrLedgerNameRequired, got %v", err)
+	}
+	ledger, err := uc.Create(ctx, owner.String(), " Home ")
+	if err != nil || ledger.Name != "Home" || ledger.Role != domain.LedgerRoleOwner {
+		t.Fatalf("unexpected ledger: %+v (%v)", ledger, err)
+	}
+
+	if _, err := uc.AddMember(ctx, owner.String(), ledger.ID, "nobody@example.com", domain.LedgerRoleEditor); !errors.Is(err, usecases.ErrLedgerUserNotFound) {
+		t.Fatalf("expected ErrLedgerUserNotFound, got %v", err)
+	}
+	if _, err := uc.AddMember(ctx, owner.String(), ledger.ID, "sara@example.com", "admin"); !errors.Is(err, usecases.ErrInvalidLedgerRole) {
+		t.Fatalf("expected ErrInvalidLedgerRole, got %v", err)
+	}
+	member, err := uc.AddMember(ctx, owner.String(), ledger.ID, "sara@example.com", domain.LedgerRoleEditor)
+	if err != nil || member.UserID != partner.String() || member.Role != domain.LedgerRoleEditor {
+		t.Fatalf("unexpected member: %+v (%v)", member, err)
+	}
+	if _, err := uc.AddMember(ctx, owner.String(), ledger.ID, "sara@example.com", domain.LedgerRoleViewer); !errors.Is(err, usecases.ErrLedgerMemberExists) {
+		t.Fatalf("expected ErrLedgerMemberExists, got %v", err)
+	}
+
+	if _, err := uc.Rename(ctx, partner.String(), ledger.ID, "Flat"); !errors.Is(err, usecases.ErrLedgerOwnerRequired) {
+		t.Fatalf("editors should not rename the ledger, got %v", err)
+	}
+	if err := uc.RemoveMember(ctx, partner.String(), ledger.ID, owner.String()); !errors.Is(err, usecases.ErrLedgerOwnerRequired) {
+		t.Fatalf("editors should not remove other members, got %v", err)
+	}
+	if _, err := uc.UpdateMemberRole(ctx, owner.String(), ledger.ID, owner.String(), domain.LedgerRoleViewer); !errors.Is(err, usecases.ErrLastLedgerOwner) {
+		t.Fatalf("expected ErrLastLedgerOwner when demoting the only owner, got %v", err)
+	}
+	if err := uc.RemoveMember(ctx, owner.String(), ledger.ID, owner.String()); !errors.Is(err, usecases.ErrLastLedgerOwner) {
+		t.Fatalf("expected ErrLastLedgerOwner when the only owner leaves, got %v", err)
+	}
+
+	if role, err := uc.Role(ctx, ledger.ID, partner.String()); err != nil || role != domain.LedgerRoleEditor {
+		t.Fatalf("unexpected role: %q (%v)", role, err)
+	}
+	if err := uc.RemoveMember(ctx, partner.String(), ledger.ID, partner.String()); err != nil {
+		t.Fatalf("members should be able to leave: %v", err)
+	}
+	if _, err := uc.GetByID(ctx, partner.String(), ledger.ID); !errors.Is(err, usecases.ErrLedgerNotFound) {
+		t.Fatalf("expected ErrLedgerNotFound after leaving, got %v", err)
+	}
+}
+
+func TestLedgerMiddlewareRoles(t *testing.T) {
+	ctx := context.Background()
+	jwtSvc := auth.NewJWTService("test-secret")
+	owner, viewer, stranger := uuid.New(), uuid.New(), uuid.New()
+	users := newFakeUserRepo()
+	_ = users.Create(ctx, &domain.User{UserID: viewer, Email: "viewer@example.com"})
+	ledgerUC := usecases.NewLedgerUseCase(newFakeLedgerRepo(), users)
+	ledger, _ := ledgerUC.Create(ctx, owner.String(), "Home")
+	if _, err := ledgerUC.AddMember(ctx, owner.String(), ledger.ID, "viewer@example.com", domain.LedgerRoleViewer); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+
+	var seenLedger *string
+	handler := deliveryhttp.JWTAuthMiddleware(jwtSvc, ledgerUC, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenLedger = deliveryhttp.LedgerIDFromRequest(r)
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(method, target string, user uuid.UUID, ledgerID string) int {
+		seenLedger = nil
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, user))
+		if ledgerID != "" {
+			req.Header.Set(deliveryhttp.LedgerHeader, ledgerID)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(http.MethodGet, "/expenses", viewer, ledger.ID); code != http.StatusOK || seenLedger == nil || *seenLedger != ledger.ID {
+		t.Fatalf("viewers should read the ledger: code=%d ledger=%v", code, seenLedger)
+	}
+	if code := serve(http.MethodPost, "/expenses", viewer, ledger.ID); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a viewer writing, got %d", code)
+	}
+	if code := serve(http.MethodPost, "/budgets", owner, ledger.ID); code != http.StatusOK || seenLedger == nil {
+		t.Fatalf("owners should write to the ledger: code=%d", code)
+	}
+	if code := serve(http.MethodGet, "/categories", stranger, ledger.ID); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a non-member, got %d", code)
+	}
+	if code := serve(http.MethodGet, "/expenses?ledger_id=not-a-uuid", owner, ""); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid ledger_id, got %d", code)
+	}
+	if code := serve(http.MethodPost, "/sync/expenses", stranger, ledger.ID); code != http.StatusOK || seenLedger != nil {
+		t.Fatalf("sync should ignore the ledger: code=%d ledger=%v", code, seenLedger)
+	}
+	if code := serve(http.MethodGet, "/expenses", owner, ""); code != http.StatusOK || seenLedger != nil {
+		t.Fatalf("requests without a ledger work on the user's own records: code=%d ledger=%v", code, seenLedger)
+	}
+}
+
+func TestLedgerBudgetsAndRoutes(t *testing.T) {
+	ctx := context.Background()
+	jwtSvc := auth.NewJWTService("test-secret")
+	owner, viewer := uuid.New(), uuid.New()
+	users := newFakeUserRepo()
+	_ = users.Create(ctx, &domain.User{UserID: viewer, Email: "viewer@example.com"})
+	ledgerUC := usecases.NewLedgerUseCase(newFakeLedgerRepo(), users)
+	budgetRepo := &fakeBudgetRepo{members: map[string]domain.LedgerRole{}}
+
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterLedgerRoutes(mux, deliveryhttp.NewLedgerHandler(ledgerUC, jwtSvc))
+	deliveryhttp.RegisterBudgetRoutes(mux, deliveryhttp.NewBudgetHandler(usecases.NewBudgetUseCase(budgetRepo, fakeCategoryRepo{}, defaultCurrencyUsers("ETB")), jwtSvc))
+	handler := deliveryhttp.JWTAuthMiddleware(jwtSvc, ledgerUC, mux)
+	do := func(method, target string, user uuid.UUID, ledgerID string, body interface{}) (*httptest.ResponseRecorder, apiEnvelope) {
+		req := newJSONRequest(t, method, target, body)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, user))
+		if ledgerID != "" {
+			req.Header.Set(deliveryhttp.LedgerHeader, ledgerID)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec, decodeEnvelope(t, rec)
+	}
+
+	rec, env := do(http.MethodPost, "/ledgers", owner, "", map[string]string{"name": "Home"})
+	var ledger domain.Ledger
+	if rec.Code != http.StatusCreated || json.Unmarshal(env.Data, &ledger) != nil || ledger.Role != domain.LedgerRoleOwner {
+		t.Fatalf("unexpected create response: code=%d data=%s", rec.Code, env.Data)
+	}
+	if rec, _ := do(http.MethodPost, "/ledgers/"+ledger.ID+"/members", owner, "", map[string]string{"email": "viewer@example.com", "role": "viewer"}); rec.Code != http.StatusCreated {
+		t.Fatalf("unexpected add member response: %d", rec.Code)
+	}
+	budgetRepo.members[ledger.ID+"/"+owner.String()] = domain.LedgerRoleOwner
+	budgetRepo.members[ledger.ID+"/"+viewer.String()] = domain.LedgerRoleViewer
+	if rec, _ := do(http.MethodPut, "/ledgers/"+ledger.ID+"/members/"+owner.String(), viewer, "", map[string]string{"role": "viewer"}); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a viewer changing roles, got %d", rec.Code)
+	}
+
+	rec, env = do(http.MethodPost, "/budgets", owner, ledger.ID, map[string]interface{}{"amount": 900})
+	var budget domain.Budget
+	if rec.Code != http.StatusCreated || json.Unmarshal(env.Data, &budget) != nil || budget.LedgerID == nil || *budget.LedgerID != ledger.ID {
+		t.Fatalf("unexpected ledger budget: code=%d data=%s", rec.Code, env.Data)
+	}
+	if rec, _ := do(http.MethodPost, "/budgets", owner, "", map[string]interface{}{"amount": 500}); rec.Code != http.StatusCreated {
+		t.Fatalf("the ledger budget should not clash with the owner's own overall budget, got %d", rec.Code)
+	}
+	if _, env := do(http.MethodGet, "/budgets", viewer, ledger.ID, nil); string(env.Data) == "[]" {
+		t.Fatal("viewers should see the ledger's budgets")
+	}
+	if rec, _ := do(http.MethodPut, "/budgets/"+budget.ID, viewer, "", map[string]interface{}{"amount": 1}); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a viewer updating a ledger budget, got %d", rec.Code)
+	}
+
+	if rec, _ := do(http.MethodDelete, "/ledgers/"+ledger.ID, viewer, "", nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a viewer deleting the ledger, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodDelete, "/ledgers/"+ledger.ID+"/members/"+viewer.String(), viewer, "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("viewers should be able to leave, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodGet, "/ledgers/"+ledger.ID, viewer, "", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after leaving, got %d", rec.Code)
+	}
+}
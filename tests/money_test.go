@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"expense_tracker/domain"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+// money is a test shorthand for an exact amount in whole units
+func money(units float64) domain.Money {
+	return domain.MoneyFromFloat(units)
+}
+
+// defaultCurrencyUsers is a user repository in which every user exists and has this default
+// currency
+type defaultCurrencyUsers string
+
+func (defaultCurrencyUsers) Create(context.Context, *domain.User) error { return nil }
+func (defaultCurrencyUsers) Update(context.Context, *domain.User) error { return nil }
+
+func (defaultCurrencyUsers) GetByEmail(context.Context, string) (*domain.User, error) {
+	return nil, nil
+}
+
+func (c defaultCurrencyUsers) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	return &domain.User{UserID: id, DefaultCurrency: string(c)}, nil
+}
+
+func TestParseMoney(t *testing.T) {
+	cases := map[string]domain.Money{
+		"12":       domain.Cents(1200),
+		"12.5":     domain.Cents(1250),
+		"-0.05":    domain.Cents(-5),
+		"0.005":    domain.Mills(5),
+		"-0.005":   domain.Mills(-5),
+		"19.994":   domain.Mills(19994),
+		"0.0005":   domain.Mills(1), // half away from zero
+		"-0.0005":  domain.Mills(-1),
+		"1e3":      domain.Cents(100000),
+		"12345.67": domain.Cents(1234567),
+	}
+	for in, want := range cases {
+		m, err := domain.ParseMoney(in)
+		if err != nil || m != want {
+			t.Fatalf("ParseMoney(%q) = %s, %v; want %s", in, m, err, want)
+		}
+	}
+	for _, in := range []string{"", "abc", "1/3", "1e30"} {
+		if _, err := domain.ParseMoney(in); !errors.Is(err, domain.ErrInvalidMoney) {
+			t.Fatalf("expected ErrInvalidMoney for %q, got %v", in, err)
+		}
+	}
+	if s := domain.Cents(-123450).String(); s != "-1234.50" {
+		t.Fatalf("unexpected String: %s", s)
+	}
+	if s := domain.Mills(-12345).String(); s != "-12.345" {
+		t.Fatalf("unexpected String: %s", s)
+	}
+}
+
+func TestMoneyCurrencyPlaces(t *testing.T) {
+	for code, places := range map[string]int{"USD": 2, "JPY": 0, "KWD": 3, "BHD": 3, "XYZ": 2} {
+		if got := domain.CurrencyExponent(code); got != places {
+			t.Fatalf("CurrencyExponent(%q) = %d; want %d", code, got, places)
+		}
+	}
+	cases := []struct {
+		amount   domain.Money
+		currency string
+		fits     bool
+	}{
+		{domain.Mills(12345), "KWD", true},
+		{domain.Mills(12345), "USD", false},
+		{domain.Cents(1250), "USD", true},
+		{domain.Cents(1250), "JPY", false},
+		{domain.Cents(1200), "JPY", true},
+	}
+	for _, c := range cases {
+		if got := c.amount.FitsCurrency(c.currency); got != c.fits {
+			t.Fatalf("%s.FitsCurrency(%q) = %v; want %v", c.amount, c.currency, got, c.fits)
+		}
+	}
+	if got := domain.Mills(-2500).Round(0); got != domain.Cents(-300) {
+		t.Fatalf("Round(0) should round half away from zero, got %s", got)
+	}
+	if got := domain.Mills(12345).Round(2); got != domain.Cents(1235) {
+		t.Fatalf("unexpected Round(2): %s", got)
+	}
+}
+
+func TestMoneySumsExactly(t *testing.T) {
+	var total domain.Money
+	for i := 0; i < 10; i++ {
+		var m domain.Money
+		if err := m.Scan([]byte("0.10")); err != nil {
+			t.Fatalf("unexpected scan error: %v", err)
+		}
+		total = total.Add(m)
+	}
+	if total != domain.Cents(100) {
+		t.Fatalf("ten times 0.10 should be exactly 1.00, got %s", total)
+	}
+	if got := domain.Cents(1000).Mul(1.0 / 3); got != domain.Cents(333) {
+		t.Fatalf("unexpected prorated amount: %s", got)
+	}
+}
+
+func TestMoneyJSONAndSQL(t *testing.T) {
+	var in struct {
+		Amount   domain.Money  `json:"amount"`
+		Optional *domain.Money `json:"optional"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 0.30000000000000004, "optional": "7.1"}`), &in); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if in.Amount != domain.Cents(30) || in.Optional == nil || *in.Optional != domain.Cents(710) {
+		t.Fatalf("unexpected decoded amounts: %s %v", in.Amount, in.Optional)
+	}
+	if err := json.Unmarshal([]byte(`{"amount": "twelve"}`), &in); !errors.Is(err, domain.ErrInvalidMoney) {
+		t.Fatalf("expected ErrInvalidMoney, got %v", err)
+	}
+
+	out, err := json.Marshal(map[string]domain.Money{"total": domain.Cents(123456)})
+	if err != nil || string(out) != `{"total":1234.56}` {
+		t.Fatalf("unexpected JSON: %s (%v)", out, err)
+	}
+
+	var scanned domain.Money
+	for src, want := range map[interface{}]domain.Money{nil: {}, "42.125": domain.Mills(42125), int64(3): domain.Cents(300), 2.5: domain.Cents(250)} {
+		if err := scanned.Scan(src); err != nil || scanned != want {
+			t.Fatalf("Scan(%v) = %s, %v; want %s", src, scanned, err, want)
+		}
+	}
+	if v, err := domain.Cents(-5).Value(); err != nil || v != "-0.05" {
+		t.Fatalf("unexpected Value: %v (%v)", v, err)
+	}
+}
+
+func TestAmountsWithoutCurrencyFitDefaultCurrency(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.NewString()
+	yen := defaultCurrencyUsers("JPY")
+	due := time.Now().AddDate(0, 0, 7)
+
+	expenses := usecases.NewExpenseUseCase(fakeExpenseRepo{
+		createFn: func(_ context.Context, in domain.CreateExpenseInput) (*domain.Expense, error) {
+			return &domain.Expense{ID: in.ID, Amount: in.Amount}, nil
+		},
+	}, yen)
+	if _, err := expenses.Create(ctx, domain.CreateExpenseInput{UserID: userID, Amount: money(12.5), ExpenseDate: due}); !errors.Is(err, usecases.ErrAmountPrecision) {
+		t.Fatalf("expected ErrAmountPrecision for 12.5 in a JPY account, got %v", err)
+	}
+	if _, err := expenses.Create(ctx, domain.CreateExpenseInput{UserID: userID, Amount: money(12.5), Currency: "USD", ExpenseDate: due}); err != nil {
+		t.Fatalf("12.5 USD should be accepted in a JPY account: %v", err)
+	}
+
+	debts := usecases.NewDebtUsecase(fakeDebtRepo{
+		createFn: func(context.Context, *domain.Debt) error { return nil },
+	}, yen, nil, nil, nil)
+	if err := debts.Create(ctx, &domain.Debt{UserID: userID, Type: "lent", PeerName: "Abebe", Amount: money(12.5), DueDate: due}); !errors.Is(err, usecases.ErrAmountPrecision) {
+		t.Fatalf("expected ErrAmountPrecision for a 12.5 debt in a JPY account, got %v", err)
+	}
+
+	incomes := usecases.NewIncomeUseCase(&fakeIncomeRepo{}, &fakeIncomeCategoryRepo{}, yen)
+	if _, err := incomes.Create(ctx, domain.CreateIncomeInput{UserID: userID, Source: "Salary", Amount: money(12.5), ReceivedDate: due}); !errors.Is(err, usecases.ErrAmountPrecision) {
+		t.Fatalf("expected ErrAmountPrecision for a 12.5 income in a JPY account, got %v", err)
+	}
+
+	budgets := usecases.NewBudgetUseCase(&fakeBudgetRepo{}, fakeCategoryRepo{}, yen)
+	if _, err := budgets.Create(ctx, userID, nil, nil, money(300.5), ""); !errors.Is(err, usecases.ErrAmountPrecision) {
+		t.Fatalf("expected ErrAmountPrecision for a 300.5 budget in a JPY account, got %v", err)
+	}
+	budget, err := budgets.Create(ctx, userID, nil, nil, money(300), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := budgets.Update(ctx, userID, budget.ID, money(300.5), nil); !errors.Is(err, usecases.ErrAmountPrecision) {
+		t.Fatalf("expected ErrAmountPrecision when updating a budget to 300.5 in a JPY account, got %v", err)
+	}
+}
+
+func TestMonthlyIncomeFitsNewDefaultCurrency(t *testing.T) {
+	users := newFakeUserRepo()
+	user := &domain.User{UserID: uuid.New(), Email: "a@example.com", DefaultCurrency: "USD", MonthlyIncome: money(100.5)}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	uc := usecases.NewUserUsecase(users)
+
+	jpy, kwd := "JPY", "KWD"
+	if err := uc.Update(context.Background(), user.UserID, usecases.UpdateUserInput{DefaultCurrency: &jpy}); !errors.Is(err, usecases.ErrAmountPrecision) {
+		t.Fatalf("expected ErrAmountPrecision when a 100.50 income is switched to JPY, got %v", err)
+	}
+	if err := uc.Update(context.Background(), user.UserID, usecases.UpdateUserInput{DefaultCurrency: &kwd}); err != nil {
+		t.Fatalf("100.50 fits KWD: %v", err)
+	}
+}
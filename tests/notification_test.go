@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/notify"
+	"expense_tracker/repository"
+	"expense_tracker/usecases"
+)
+
+type fakeNotificationRepo struct {
+	due    []*domain.Notification
+	sent   map[string]bool
+	failed map[string]domain.NotificationStatus
+	prefs  []*domain.NotificationPreference
+}
+
+func (f *fakeNotificationRepo) Enqueue(_ context.Context, n *domain.Notification) (bool, error) {
+	f.due = append(f.due, n)
+	return true, nil
+}
+func (f *fakeNotificationRepo) ListDue(context.Context, time.Time, int) ([]*domain.Notification, error) {
+	return f.due, nil
+}
+func (f *fakeNotificationRepo) MarkSent(_ context.Context, id string, _ time.Time) error {
+	f.sent[id] = true
+	return nil
+}
+func (f *fakeNotificationRepo) MarkAttemptFailed(_ context.Context, id string, _ string, _ time.Time, status domain.NotificationStatus) error {
+	f.failed[id] = status
+	return nil
+}
+func (f *fakeNotificationRepo) ListByUser(context.Context, string, repository.ListOptions) ([]*domain.Notification, int, error) {
+	return f.due, len(f.due), nil
+}
+func (f *fakeNotificationRepo) GetPreferences(context.Context, string) ([]*domain.NotificationPreference, error) {
+	return f.prefs, nil
+}
+func (f *fakeNotificationRepo) ReplacePreferences(_ context.Context, _ string, prefs []*domain.NotificationPreference) error {
+	f.prefs = prefs
+	return nil
+}
+
+type fakeNotifier struct {
+	channel domain.NotificationChannel
+	err     error
+}
+
+func (f fakeNotifier) Channel() domain.NotificationChannel { return f.channel }
+func (f fakeNotifier) Send(context.Context, *domain.Notification) error {
+	return f.err
+}
+
+func TestNotificationDeliveryRetries(t *testing.T) {
+	repo := &fakeNotificationRepo{
+		due: []*domain.Notification{
+			{ID: "ok", Channel: domain.NotificationChannelLog, SubjectType: domain.NotificationSubjectDebt, SubjectID: "debt-1"},
+			{ID: "retry", Channel: domain.NotificationChannelWebhook, Attempts: 0},
+			{ID: "give-up", Channel: domain.NotificationChannelWebhook, Attempts: usecases.MaxNotificationAttempts - 1},
+			{ID: "unconfigured", Channel: domain.NotificationChannelEmail},
+		},
+		sent:   map[string]bool{},
+		failed: map[string]domain.NotificationStatus{},
+	}
+	uc := usecases.NewNotificationUseCase(repo, &fakeUserRepo{}, fakeDebtRepo{}, fakeExpenseRepo{},
+		fakeNotifier{channel: domain.NotificationChannelLog},
+		fakeNotifier{channel: domain.NotificationChannelWebhook, err: errors.New("connection refused")},
+	)
+
+	sent, err := uc.DeliverPending(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sent != 1 || !repo.sent["ok"] {
+		t.Fatalf("expected only the log notification to be sent, got %d %v", sent, repo.sent)
+	}
+	if repo.failed["retry"] != domain.NotificationStatusPending {
+		t.Fatalf("failed send should stay pending for retry, got %q", repo.failed["retry"])
+	}
+	if repo.failed["give-up"] != domain.NotificationStatusFailed {
+		t.Fatalf("last attempt should mark notification failed, got %q", repo.failed["give-up"])
+	}
+	if repo.failed["unconfigured"] != domain.NotificationStatusPending {
+		t.Fatalf("unconfigured channel should be retried, got %q", repo.failed["unconfigured"])
+	}
+}
+
+func TestNotificationPreferencesValidation(t *testing.T) {
+	repo := &fakeNotificationRepo{}
+	uc := usecases.NewNotificationUseCase(repo, &fakeUserRepo{}, fakeDebtRepo{}, fakeExpenseRepo{})
+	ctx := context.Background()
+
+	cases := []struct {
+		name  string
+		prefs []*domain.NotificationPreference
+		want  error
+	}{
+		{"unknown channel", []*domain.NotificationPreference{{Channel: "sms", Target: "123"}}, usecases.ErrInvalidNotificationChannel},
+		{"bad email", []*domain.NotificationPreference{{Channel: domain.NotificationChannelEmail, Target: "nope"}}, usecases.ErrInvalidNotificationEmail},
+		{"bad webhook", []*domain.NotificationPreference{{Channel: domain.NotificationChannelWebhook, Target: "ftp://x"}}, usecases.ErrInvalidNotificationWebhook},
+		{"localhost webhook", []*domain.NotificationPreference{{Channel: domain.NotificationChannelWebhook, Target: "http://localhost:8080/x"}}, usecases.ErrInvalidNotificationWebhook},
+		{"loopback webhook", []*domain.NotificationPreference{{Channel: domain.NotificationChannelWebhook, Target: "http://[::1]/x"}}, usecases.ErrInvalidNotificationWebhook},
+		{"metadata webhook", []*domain.NotificationPreference{{Channel: domain.NotificationChannelWebhook, Target: "http://169.254.169.254/latest"}}, usecases.ErrInvalidNotificationWebhook},
+		{"private webhook", []*domain.NotificationPreference{{Channel: domain.NotificationChannelWebhook, Target: "https://10.0.0.5/x"}}, usecases.ErrInvalidNotificationWebhook},
+		{"duplicate", []*domain.NotificationPreference{
+			{Channel: domain.NotificationChannelLog},
+			{Channel: domain.NotificationChannelLog},
+		}, usecases.ErrDuplicateNotificationChannel},
+	}
+	for _, tc := range cases {
+		if _, err := uc.UpdatePreferences(ctx, "user-1", tc.prefs); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	prefs := []*domain.NotificationPreference{
+		{Channel: domain.NotificationChannelEmail, Target: "me@example.com", Enabled: true},
+		{Channel: domain.NotificationChannelWebhook, Target: "https://hooks.example.com/x", Enabled: false},
+	}
+	if _, err := uc.UpdatePreferences(ctx, "user-1", prefs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.prefs) != 2 || repo.prefs[0].UserID != "user-1" {
+		t.Fatalf("preferences not stored: %+v", repo.prefs)
+	}
+}
+
+func TestWebhookNotifierSignsPayload(t *testing.T) {
+	secret := "whsec-test"
+	var gotSignature, wantSignature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotSignature = r.Header.Get(notify.WebhookSignatureHeader)
+		wantSignature = "sha256=" + notify.SignWebhook([]byte(secret), r.Header.Get(notify.WebhookTimestampHeader), body)
+		if !strings.Contains(string(body), `"subject_id":"debt-1"`) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := notify.NewWebhookNotifier(secret)
+	notifier.AllowPrivateNetworks()
+	n := &domain.Notification{ID: "n-1", Target: server.URL, SubjectType: domain.NotificationSubjectDebt, SubjectID: "debt-1"}
+	if err := notifier.Send(context.Background(), n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotSignature == "" || gotSignature != wantSignature {
+		t.Fatalf("signature mismatch: got %q want %q", gotSignature, wantSignature)
+	}
+
+	n.Target = server.URL + "/missing"
+	n.SubjectID = "other"
+	if err := notifier.Send(context.Background(), n); err == nil {
+		t.Fatal("expected error on non-2xx response")
+	}
+}
+
+func TestWebhookNotifierStaysOffPrivateNetworks(t *testing.T) {
+	var hits []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits = append(hits, r.URL.Path)
+		if r.URL.Path == "/hook" {
+			http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n := &domain.Notification{ID: "n-1", Target: server.URL + "/hook", SubjectType: domain.NotificationSubjectDebt, SubjectID: "debt-1"}
+	if err := notify.NewWebhookNotifier("secret").Send(context.Background(), n); !errors.Is(err, notify.ErrWebhookAddressBlocked) || len(hits) != 0 {
+		t.Fatalf("expected a loopback target to be refused before connecting, got %v (%v)", err, hits)
+	}
+
+	notifier := notify.NewWebhookNotifier("secret")
+	notifier.AllowPrivateNetworks()
+	if err := notifier.Send(context.Background(), n); err == nil || len(hits) != 1 {
+		t.Fatalf("expected the redirect not to be followed, got %v (%v)", err, hits)
+	}
+
+	for addr, want := range map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::":              false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"::ffff:10.0.0.1": false,
+		"64:ff9b::a00:1":  false,
+	} {
+		if got := domain.WebhookAddressAllowed(netip.MustParseAddr(addr)); got != want {
+			t.Fatalf("%s: allowed = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/repository"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+// keysetRead reads the page at cursor from rows like the PostgreSQL repositories: ordered by
+// (date, id), descending or not, with backward pages read in reverse and put back in order
+func keysetRead[T any](rows []T, key func(T) domain.PageCursor, descending bool, cursor domain.PageCursor, limit int) []T {
+	less := func(a, b domain.PageCursor) bool {
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		return a.ID < b.ID
+	}
+	desc := descending != cursor.Backward
+	sorted := append([]T(nil), rows...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if desc {
+			return less(key(sorted[j]), key(sorted[i]))
+		}
+		return less(key(sorted[i]), key(sorted[j]))
+	})
+	var page []T
+	for _, row := range sorted {
+		k := key(row)
+		if !cursor.IsStart() && ((desc && !less(k, cursor)) || (!desc && !less(cursor, k))) {
+			continue
+		}
+		if len(page) == limit {
+			break
+		}
+		page = append(page, row)
+	}
+	if cursor.Backward {
+		for i, j := 0, len(page)-1; i < j; i, j = i+1, j-1 {
+			page[i], page[j] = page[j], page[i]
+		}
+	}
+	return page
+}
+
+func TestExpenseCursorPagination(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	userID := uuid.New()
+
+	// 11 expenses over 4 days, several on the same day
+	var stored []*domain.Expense
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 11; i++ {
+		stored = append(stored, &domain.Expense{ID: uuid.NewString(), UserID: userID.String(), Amount: domain.Cents(100), ExpenseDate: day.AddDate(0, 0, i%4)})
+	}
+	expenseKey := func(e *domain.Expense) domain.PageCursor { return domain.PageCursor{Date: e.ExpenseDate, ID: e.ID} }
+
+	var counted bool
+	expenseRepo := fakeExpenseRepo{
+		listFn: func(_ context.Context, f domain.ExpenseFilter) ([]*domain.Expense, int, error) {
+			if f.Cursor == nil {
+				t.Fatalf("cursor mode must pass the cursor to the repository")
+			}
+			counted = !f.SkipTotal
+			total := 0
+			if counted {
+				total = len(stored)
+			}
+			return keysetRead(stored, expenseKey, true, *f.Cursor, f.Limit), total, nil
+		},
+	}
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterExpenseRoutes(mux, deliveryhttp.NewExpenseHandler(usecases.NewExpenseUseCase(expenseRepo, defaultCurrencyUsers("ETB"))))
+	handler := deliveryhttp.JWTAuthMiddleware(jwtSvc, nil, mux)
+
+	type page struct {
+		ids        []string
+		next, prev string
+		total      interface{}
+	}
+	get := func(query string) page {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/expenses?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, userID))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		env := decodeEnvelope(t, rec)
+		var data struct {
+			Items []domain.Expense `json:"items"`
+		}
+		if rec.Code != http.StatusOK || json.Unmarshal(env.Data, &data) != nil {
+			t.Fatalf("%s: unexpected response %d %s %v", query, rec.Code, env.Data, env.Errors)
+		}
+		if _, ok := env.Meta["pagination"]; ok {
+			t.Fatalf("%s: cursor pages must not have page pagination: %v", query, env.Meta)
+		}
+		p := page{total: env.Meta["total_items"]}
+		p.next, _ = env.Meta["next_cursor"].(string)
+		p.prev, _ = env.Meta["prev_cursor"].(string)
+		for _, e := range data.Items {
+			p.ids = append(p.ids, e.ID)
+		}
+		return p
+	}
+
+	want := keysetRead(stored, expenseKey, true, domain.PageCursor{}, len(stored))
+	var wantIDs []string
+	for _, e := range want {
+		wantIDs = append(wantIDs, e.ID)
+	}
+
+	// forward through every page
+	var pages []page
+	p := get("cursor=&page_size=4")
+	if p.prev != "" || p.total != nil || counted {
+		t.Fatalf("the first page has no previous page and no total by default: %+v", p)
+	}
+	for {
+		pages = append(pages, p)
+		if p.next == "" {
+			break
+		}
+		p = get("page_size=4&cursor=" + p.next)
+	}
+	var seen []string
+	for _, p := range pages {
+		seen = append(seen, p.ids...)
+	}
+	if len(pages) != 3 || strings.Join(seen, ",") != strings.Join(wantIDs, ",") {
+		t.Fatalf("forward pages do not cover the list in order: %d pages, %v", len(pages), seen)
+	}
+
+	// and back from the last page
+	last := pages[len(pages)-1]
+	if last.prev == "" || len(last.ids) != 3 {
+		t.Fatalf("unexpected last page: %+v", last)
+	}
+	back := get("page_size=4&cursor=" + last.prev)
+	if strings.Join(back.ids, ",") != strings.Join(pages[1].ids, ",") || back.next == "" || back.prev == "" {
+		t.Fatalf("previous page differs from the forward one: %+v", back)
+	}
+	first := get("page_size=4&cursor=" + back.prev)
+	if strings.Join(first.ids, ",") != strings.Join(pages[0].ids, ",") || first.prev != "" || first.next == "" {
+		t.Fatalf("first page read backward differs: %+v", first)
+	}
+
+	if p := get("cursor=&page_size=4&include_total=true"); p.total != float64(11) || !counted {
+		t.Fatalf("expected the total when asked for, got %v", p.total)
+	}
+
+	for _, query := range []string{"cursor=bm9wZQ", "cursor=&page=2", "cursor=&include_total=maybe"} {
+		req := httptest.NewRequest(http.MethodGet, "/expenses?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, userID))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if env := decodeEnvelope(t, rec); rec.Code != http.StatusBadRequest || env.Message != "Validation failed" {
+			t.Fatalf("%s: expected a validation error, got %d %v", query, rec.Code, env.Errors)
+		}
+	}
+}
+
+func TestDebtCursorPagination(t *testing.T) {
+	userID := uuid.NewString()
+	due := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	var stored []*domain.Debt
+	for i := 0; i < 5; i++ {
+		stored = append(stored, &domain.Debt{ID: uuid.NewString(), UserID: userID, Amount: domain.Cents(500), DueDate: due.AddDate(0, 0, i/2)})
+	}
+	debtKey := func(d *domain.Debt) domain.PageCursor { return domain.PageCursor{Date: d.DueDate, ID: d.ID} }
+	debtRepo := fakeDebtRepo{
+		listByUserFn: func(_ context.Context, _ string, opts repository.ListOptions) ([]*domain.Debt, int, error) {
+			return keysetRead(stored, debtKey, false, *opts.Cursor, opts.Limit), 0, nil
+		},
+	}
+	uc := usecases.NewDebtUsecase(debtRepo, defaultCurrencyUsers("ETB"), nil, nil, nil)
+
+	first, _, cursors, err := uc.ListPageByUser(context.Background(), userID, repository.ListOptions{Limit: 3}, domain.PageCursor{})
+	if err != nil || len(first) != 3 || cursors.Prev != "" || cursors.Next == "" || first[0].DueDate.After(first[2].DueDate) {
+		t.Fatalf("unexpected first page: %d %+v %v", len(first), cursors, err)
+	}
+	next, err := domain.DecodePageCursor(cursors.Next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, cursors, err := uc.ListPageByUser(context.Background(), userID, repository.ListOptions{Limit: 3}, next)
+	if err != nil || len(second) != 2 || cursors.Next != "" || cursors.Prev == "" || second[0].DueDate.Before(first[2].DueDate) {
+		t.Fatalf("unexpected second page: %d %+v %v", len(second), cursors, err)
+	}
+	for _, d := range second {
+		for _, f := range first {
+			if d.ID == f.ID {
+				t.Fatalf("debt %s is on both pages", d.ID)
+			}
+		}
+	}
+}
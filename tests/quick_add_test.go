@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/infrastructure/quickadd"
+	"expense_tracker/repository"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+func TestQuickAddGrammar(t *testing.T) {
+	hints := domain.QuickAddHints{
+		Today: time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC), // a Wednesday
+		Categories: []*domain.Category{
+			{ID: "coffee", Name: "Coffee"}, {ID: "grocery", Name: "Grocery"},
+			{ID: "eating-out", Name: "Eating Out"}, {ID: "transport", Name: "Transport"},
+		},
+	}
+	expenses := []struct {
+		text, amount, currency, date, category, note string
+	}{
+		{"coffee 85 birr yesterday", "85.00", "ETB", "2026-10-13", "coffee", "coffee"},
+		{"2 coffees 170", "170.00", "", "2026-10-14", "coffee", "2 coffees"},
+		{"paid 300 for 2 tickets last friday", "300.00", "", "2026-10-09", "", "2 tickets"},
+		{"Groceries €42,30 on March 3 #transport", "42.30", "EUR", "2026-03-03", "transport", "Groceries"},
+		{"taxi $12 3 days ago", "12.00", "", "2026-10-11", "", "taxi"},
+		{"dinner out 1.5k usd on monday", "1500.00", "USD", "2026-10-12", "", "dinner out"},
+		{"eating out 1,250.50 2025-12-31", "1250.50", "", "2025-12-31", "eating-out", "eating out"},
+		{"spent 40 on dec 20", "40.00", "", "2025-12-20", "", ""},
+	}
+	for _, tc := range expenses {
+		result, err := quickadd.Grammar{}.Parse(context.Background(), tc.text, hints)
+		if err != nil || result.Kind != domain.QuickAddExpense {
+			t.Fatalf("%q: unexpected result %+v, %v", tc.text, result, err)
+		}
+		e := result.Expense
+		category := ""
+		if e.CategoryID != nil {
+			category = *e.CategoryID
+		}
+		if e.Amount.String() != tc.amount || e.Currency != tc.currency || e.ExpenseDate.Format("2006-01-02") != tc.date ||
+			category != tc.category || e.Note != tc.note {
+			t.Fatalf("%q: got %s %q %s %q %q", tc.text, e.Amount, e.Currency, e.ExpenseDate.Format("2006-01-02"), category, e.Note)
+		}
+	}
+
+	debts := []struct {
+		text, debtType, peer, amount, currency, due, note string
+	}{
+		{"lent Sara 500 due Friday", "lent", "Sara", "500.00", "", "2026-10-16", ""},
+		{"Sara owes me 300 for lunch", "lent", "Sara", "300.00", "", "", "lunch"},
+		{"borrowed 2000 birr from Abebe for rent by next month", "borrowed", "Abebe", "2000.00", "ETB", "2026-11-14", "rent"},
+		{"I owe Dawit Kebede 1,250.50 ETB next wednesday", "borrowed", "Dawit Kebede", "1250.50", "ETB", "2026-10-21", ""},
+		{"lent 200 to my brother in two weeks", "lent", "my brother", "200.00", "", "2026-10-28", ""},
+		{"lent money to Paul 50 on jan 5", "lent", "Paul", "50.00", "", "2027-01-05", "money"},
+	}
+	for _, tc := range debts {
+		result, err := quickadd.Grammar{}.Parse(context.Background(), tc.text, hints)
+		if err != nil || result.Kind != domain.QuickAddDebt {
+			t.Fatalf("%q: unexpected result %+v, %v", tc.text, result, err)
+		}
+		d := result.Debt
+		due, note := "", ""
+		if !d.DueDate.IsZero() {
+			due = d.DueDate.Format("2006-01-02")
+		}
+		if d.Note != nil {
+			note = *d.Note
+		}
+		if d.Type != tc.debtType || d.PeerName != tc.peer || d.Amount.String() != tc.amount || d.Currency != tc.currency ||
+			due != tc.due || note != tc.note {
+			t.Fatalf("%q: got %s %q %s %q %s %q", tc.text, d.Type, d.PeerName, d.Amount, d.Currency, due, note)
+		}
+	}
+
+	for _, text := range []string{"lunch with the team", "lent 500", "owe nothing"} {
+		if _, err := (quickadd.Grammar{}).Parse(context.Background(), text, hints); !errors.Is(err, domain.ErrQuickAddNotUnderstood) {
+			t.Fatalf("%q: expected the text not to be understood, got %v", text, err)
+		}
+	}
+}
+
+func TestQuickAddRoute(t *testing.T) {
+	var aiCalls int
+	aiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		aiCalls++
+		body, _ := io.ReadAll(r.Body)
+		reply := `{"kind": "expense", "amount": 12.5, "currency": "eur", "date": null, "category": "coffee", "note": "Flat white", "debt_type": null, "person": null}`
+		if !strings.Contains(string(body), "flat white") {
+			reply = "I could not read that"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": reply}}},
+		})
+	}))
+	defer aiServer.Close()
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GEMINI_API_URL", aiServer.URL)
+
+	jwtSvc := auth.NewJWTService("test-secret")
+	userID := uuid.New()
+	var createdExpenses []domain.CreateExpenseInput
+	expenseRepo := fakeExpenseRepo{
+		createFn: func(_ context.Context, in domain.CreateExpenseInput) (*domain.Expense, error) {
+			createdExpenses = append(createdExpenses, in)
+			return &domain.Expense{ID: in.ID, UserID: in.UserID, Amount: in.Amount, CategoryID: in.CategoryID, Note: in.Note, ExpenseDate: in.ExpenseDate}, nil
+		},
+	}
+	var createdDebts []*domain.Debt
+	debtRepo := fakeDebtRepo{createFn: func(_ context.Context, d *domain.Debt) error {
+		createdDebts = append(createdDebts, d)
+		return nil
+	}}
+	categoryRepo := fakeCategoryRepo{
+		listFn: func(_ context.Context, _ *string, _ repository.ListOptions) ([]*domain.Category, int, error) {
+			return []*domain.Category{{ID: "11111111-1111-1111-1111-111111111111", Name: "Coffee"}}, 1, nil
+		},
+	}
+	quickAddUC := usecases.NewQuickAddUseCase(categoryRepo, usecases.NewExpenseUseCase(expenseRepo, defaultCurrencyUsers("ETB")),
+		usecases.NewDebtUsecase(debtRepo, defaultCurrencyUsers("ETB"), nil, nil, nil), quickadd.Grammar{}, deliveryhttp.NewAIQuickAddParser(""))
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterQuickAddRoutes(mux, deliveryhttp.NewQuickAddHandler(quickAddUC))
+	handler := deliveryhttp.JWTAuthMiddleware(jwtSvc, nil, mux)
+
+	post := func(body interface{}) (*httptest.ResponseRecorder, apiEnvelope) {
+		req := newJSONRequest(t, http.MethodPost, "/quick-add", body)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, userID))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec, decodeEnvelope(t, rec)
+	}
+
+	rec, env := post(map[string]interface{}{"text": "coffee 85 birr yesterday", "today": "2026-10-14"})
+	var preview domain.QuickAddResult
+	if rec.Code != http.StatusOK || json.Unmarshal(env.Data, &preview) != nil || preview.Kind != domain.QuickAddExpense ||
+		preview.Parser != "grammar" || preview.Expense.Amount.String() != "85.00" || preview.Expense.Currency != "ETB" ||
+		preview.Expense.ExpenseDate.Format("2006-01-02") != "2026-10-13" || preview.Expense.CategoryID == nil {
+		t.Fatalf("unexpected preview: %d %s %v", rec.Code, env.Data, env.Errors)
+	}
+	if len(createdExpenses) != 0 || aiCalls != 0 {
+		t.Fatalf("a preview must not save anything or call the AI service")
+	}
+
+	rec, env = post(map[string]interface{}{"text": "coffee 85 birr yesterday", "commit": true})
+	var expense domain.Expense
+	if rec.Code != http.StatusCreated || json.Unmarshal(env.Data, &expense) != nil || len(createdExpenses) != 1 ||
+		expense.Amount.String() != "85.00" || createdExpenses[0].UserID != userID.String() ||
+		!createdExpenses[0].ExpenseDate.Equal(time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)) {
+		t.Fatalf("unexpected commit: %d %s %v", rec.Code, env.Data, env.Errors)
+	}
+
+	rec, env = post(map[string]interface{}{"text": "lent Sara 500", "commit": true})
+	var debt domain.Debt
+	wantDue := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 30)
+	if rec.Code != http.StatusCreated || json.Unmarshal(env.Data, &debt) != nil || len(createdDebts) != 1 ||
+		debt.Type != "lent" || debt.PeerName != "Sara" || debt.Amount.String() != "500.00" || !debt.DueDate.Equal(wantDue) {
+		t.Fatalf("unexpected debt: %d %s %v", rec.Code, env.Data, env.Errors)
+	}
+
+	// the grammar finds no amount, so the AI service reads it
+	rec, env = post(map[string]interface{}{"text": "a flat white at the usual place"})
+	if rec.Code != http.StatusOK || json.Unmarshal(env.Data, &preview) != nil || preview.Parser != "ai" ||
+		preview.Expense.Amount.String() != "12.50" || preview.Expense.Currency != "EUR" || preview.Expense.Note != "Flat white" ||
+		preview.Expense.CategoryID == nil || *preview.Expense.CategoryID != "11111111-1111-1111-1111-111111111111" || aiCalls != 1 {
+		t.Fatalf("unexpected AI preview: %d %s %v", rec.Code, env.Data, env.Errors)
+	}
+
+	for _, body := range []map[string]interface{}{
+		{"text": ""},
+		{"text": "lent Sara 500 yesterday"},
+		{"text": "coffee 85", "today": "14/10/2026"},
+		{"text": strings.Repeat("coffee ", 50) + "85"},
+	} {
+		if rec, env := post(body); rec.Code != http.StatusBadRequest || env.Message != "Validation failed" {
+			t.Fatalf("%v: expected a validation error, got %d %v", body, rec.Code, env.Errors)
+		}
+	}
+	if rec, env := post(map[string]interface{}{"text": "the usual"}); rec.Code != http.StatusBadRequest || env.Message != "Text not understood" || aiCalls != 2 {
+		t.Fatalf("expected the text not to be understood, got %d %s %v", rec.Code, env.Message, env.Errors)
+	}
+}
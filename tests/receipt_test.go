@@ -0,0 +1,382 @@
+package tests
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/infrastructure/receipt"
+	"expense_tracker/infrastructure/storage"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+// fakeReceiptDraftRepo keeps drafts in memory; Attach moves them to attachments
+type fakeReceiptDraftRepo struct {
+	drafts      map[string]*domain.ReceiptDraft
+	attachments []*domain.Attachment
+}
+
+func (f *fakeReceiptDraftRepo) Create(_ context.Context, d *domain.ReceiptDraft) error {
+	d.CreatedAt, d.UpdatedAt = time.Now().UTC(), time.Now().UTC()
+	f.drafts[d.ID] = d
+	return nil
+}
+
+func (f *fakeReceiptDraftRepo) GetByID(_ context.Context, id, userID string, ledgerID *string) (*domain.ReceiptDraft, error) {
+	if d, ok := f.drafts[id]; ok && d.UserID == userID && (d.LedgerID == nil) == (ledgerID == nil) {
+		copied := *d
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeReceiptDraftRepo) List(_ context.Context, userID string, _ *string) ([]*domain.ReceiptDraft, error) {
+	drafts := make([]*domain.ReceiptDraft, 0)
+	for _, d := range f.drafts {
+		if d.UserID == userID {
+			drafts = append(drafts, d)
+		}
+	}
+	return drafts, nil
+}
+
+func (f *fakeReceiptDraftRepo) Delete(_ context.Context, id, userID string, _ *string) error {
+	if d, ok := f.drafts[id]; ok && d.UserID == userID {
+		delete(f.drafts, id)
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeReceiptDraftRepo) ListDue(_ context.Context, now time.Time, limit int) ([]*domain.ReceiptDraft, error) {
+	due := make([]*domain.ReceiptDraft, 0)
+	for _, d := range f.drafts {
+		if d.Status == domain.ReceiptDraftPending && !d.NextAttemptAt.After(now) {
+			copied := *d
+			due = append(due, &copied)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].CreatedAt.Before(due[j].CreatedAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (f *fakeReceiptDraftRepo) MarkExtracted(_ context.Context, id, extractor string, data *domain.ReceiptData, expense *domain.CreateExpenseInput) error {
+	if d, ok := f.drafts[id]; ok {
+		d.Status, d.Extractor, d.Receipt, d.Expense, d.LastError = domain.ReceiptDraftReady, extractor, data, expense, nil
+		d.Attempts++
+	}
+	return nil
+}
+
+func (f *fakeReceiptDraftRepo) MarkAttemptFailed(_ context.Context, id, lastError string, next time.Time, status domain.ReceiptDraftStatus) error {
+	if d, ok := f.drafts[id]; ok {
+		d.Status, d.LastError, d.NextAttemptAt = status, &lastError, next
+		d.Attempts++
+	}
+	return nil
+}
+
+func (f *fakeReceiptDraftRepo) Attach(_ context.Context, id, userID string, a *domain.Attachment) error {
+	if d, ok := f.drafts[id]; !ok || d.UserID != userID {
+		return sql.ErrNoRows
+	}
+	delete(f.drafts, id)
+	a.ID = uuid.New().String()
+	f.attachments = append(f.attachments, a)
+	return nil
+}
+
+// receiptPDF builds a one-page PDF with a Flate-compressed content stream drawing each line with
+// font F1 (WinAnsi); lines starting with "#" are drawn as glyph codes with font F2, whose ToUnicode
+// map sends <0001>..<001A> to A..Z
+func receiptPDF(lines ...string) []byte {
+	var content bytes.Buffer
+	y := 760
+	for _, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			var codes strings.Builder
+			for _, r := range line[1:] {
+				fmt.Fprintf(&codes, "%04X", r-'A'+1)
+			}
+			fmt.Fprintf(&content, "BT /F2 10 Tf 72 %d Td <%s> Tj ET\n", y, codes.String())
+		} else {
+			label, amount, _ := strings.Cut(line, "|")
+			fmt.Fprintf(&content, "BT /F1 10 Tf 72 %d Td (%s) Tj", y, label)
+			if amount != "" {
+				fmt.Fprintf(&content, " 300 0 Td [(%s)] TJ", amount)
+			}
+			content.WriteString(" ET\n")
+		}
+		y -= 14
+	}
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, _ = zw.Write(content.Bytes())
+	_ = zw.Close()
+
+	cmap := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap\n1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+		"1 beginbfrange <0001> <001A> <0041> endbfrange\nendcmap end end"
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.5\n")
+	pdf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	pdf.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>\nendobj\n")
+	pdf.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /MediaBox [0 0 400 800] /Contents 4 0 R >>\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+	pdf.Write(compressed.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n")
+	pdf.WriteString("5 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>\nendobj\n")
+	pdf.WriteString("6 0 obj\n<< /Type /Font /Subtype /Type0 /BaseFont /Custom /Encoding /Identity-H /ToUnicode 7 0 R >>\nendobj\n")
+	fmt.Fprintf(&pdf, "7 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(cmap), cmap)
+	pdf.WriteString("trailer\n<< /Size 8 /Root 1 0 R >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func TestReceiptTextParsing(t *testing.T) {
+	data := receipt.ParseText(strings.Join([]string{
+		"CORNER CAFE",
+		"123 Main St",
+		"Tel 555-0134",
+		"Date: 03/14/2024 12:31",
+		"2 x Cappuccino 3.50 7.00",
+		"Croissant 2.80",
+		"Orange juice 1 @ 4.10 4.10",
+		"Subtotal 13.90",
+		"Tax 8% 1.11",
+		"TOTAL USD 15.01",
+		"Visa 15.01",
+		"Change 0.00",
+	}, "\n"))
+	if data.Merchant != "CORNER CAFE" || data.Currency != "USD" || data.Total == nil || data.Total.String() != "15.01" ||
+		data.Date == nil || data.Date.Format("2006-01-02") != "2024-03-14" {
+		t.Fatalf("unexpected receipt: %+v", data)
+	}
+	if len(data.LineItems) != 3 || data.LineItems[0].Description != "Cappuccino" || data.LineItems[0].Quantity != 2 ||
+		data.LineItems[0].Amount.String() != "7.00" || data.LineItems[2].Description != "Orange juice" {
+		t.Fatalf("unexpected line items: %+v", data.LineItems)
+	}
+
+	// day-first dates, decimal commas, and a total label whose amount is on the next line
+	data = receipt.ParseText("Bäckerei Müller\n12.05.2024\nBrot 1.234,50\nGesamtbetrag EUR\n1.234,50\nGrand total savings 3,00")
+	if data.Merchant != "Bäckerei Müller" || data.Date.Format("2006-01-02") != "2024-05-12" || data.Currency != "EUR" ||
+		data.Total == nil || data.Total.String() != "1234.50" {
+		t.Fatalf("unexpected receipt: %+v", data)
+	}
+
+	text, err := receipt.PDFText(receiptPDF("#GROCER", "Order date 2024-02-01", "Apples|2.40", "Milk|1.10", "Total EUR|3.50"))
+	if err != nil {
+		t.Fatalf("pdf text: %v", err)
+	}
+	if want := "GROCER\nOrder date 2024-02-01\nApples 2.40\nMilk 1.10\nTotal EUR 3.50\n"; text != want {
+		t.Fatalf("PDFText = %q, want %q", text, want)
+	}
+	if _, err := receipt.PDFText([]byte("\x89PNG\r\n\x1A\n")); err == nil {
+		t.Fatal("expected an error for a file that is not a PDF")
+	}
+}
+
+// bombPDF returns a one-page PDF whose page draws "Total 9.99" from object 3 and lists contents
+// as its /Contents, followed by streams objects 10, 11, ... each decompressing to size spaces
+func bombPDF(contents string, streams, size int) []byte {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, _ = zw.Write(bytes.Repeat([]byte(" "), size))
+	_ = zw.Close()
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.5\n")
+	pdf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	fmt.Fprintf(&pdf, "2 0 obj\n<< /Type /Pages /Kids [4 0 R] /Count 1 /Resources << /Font << /F1 5 0 R >> >> >>\nendobj\n")
+	text := "BT /F1 10 Tf 72 700 Td (Total 9.99) Tj ET"
+	fmt.Fprintf(&pdf, "3 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(text), text)
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Type /Page /Parent 2 0 R /Contents %s >>\nendobj\n", contents)
+	pdf.WriteString("5 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>\nendobj\n")
+	for i := 0; i < streams; i++ {
+		fmt.Fprintf(&pdf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", 10+i, compressed.Len())
+		pdf.Write(compressed.Bytes())
+		pdf.WriteString("\nendstream\nendobj\n")
+	}
+	pdf.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func TestReceiptPDFDecodeBudget(t *testing.T) {
+	// streams no page uses are never decompressed, however large
+	text, err := receipt.PDFText(bombPDF("3 0 R", 40, 8<<20))
+	if err != nil || !strings.Contains(text, "Total 9.99") {
+		t.Fatalf("unused streams must not stop the page being read: %q, %v", text, err)
+	}
+
+	unreadable := map[string][]byte{
+		"one stream over the budget": bombPDF("[3 0 R 10 0 R]", 1, 17<<20),
+		"streams over the budget":    bombPDF("[3 0 R 10 0 R 11 0 R 12 0 R]", 3, 6<<20),
+		"a stream listed many times": bombPDF("[3 0 R"+strings.Repeat(" 10 0 R", 40)+"]", 1, 1<<20),
+	}
+	for name, pdf := range unreadable {
+		_, err := receipt.PDFExtractor{}.Extract(context.Background(), "application/pdf", pdf)
+		if !errors.Is(err, domain.ErrUnreadableReceipt) {
+			t.Fatalf("%s: expected an unreadable receipt, got %v", name, err)
+		}
+	}
+}
+
+func TestReceiptDraftRoutes(t *testing.T) {
+	// the AI service reads the images: GIFs fail with a server error, other images are a lunch receipt
+	var aiCalls int
+	aiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		aiCalls++
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "image/gif") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		reply := "```json\n{\"merchant\": \"Noodle Bar\", \"date\": \"2024-06-02\", \"total\": \"18.40\", \"currency\": \"chf\", " +
+			"\"line_items\": [{\"description\": \"Ramen\", \"quantity\": 1, \"amount\": 18.4}, {\"description\": null, \"amount\": 1}]}\n```"
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": reply}}},
+		})
+	}))
+	defer aiServer.Close()
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GEMINI_API_URL", aiServer.URL)
+
+	jwtSvc := auth.NewJWTService("test-secret")
+	userID := uuid.New()
+	expenses := map[string]*domain.Expense{}
+	expenseRepo := fakeExpenseRepo{
+		createFn: func(_ context.Context, in domain.CreateExpenseInput) (*domain.Expense, error) {
+			e := &domain.Expense{ID: in.ID, UserID: in.UserID, Amount: in.Amount, Currency: in.Currency, Note: in.Note, ExpenseDate: in.ExpenseDate}
+			expenses[e.ID] = e
+			return e, nil
+		},
+		getFn: func(_ context.Context, id, _ string) (*domain.Expense, error) { return expenses[id], nil },
+	}
+	drafts := &fakeReceiptDraftRepo{drafts: map[string]*domain.ReceiptDraft{}}
+	store, err := storage.NewFileSystemStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	receiptUC := usecases.NewReceiptUseCase(drafts, usecases.NewExpenseUseCase(expenseRepo, defaultCurrencyUsers("ETB")), store,
+		receipt.PDFExtractor{}, deliveryhttp.NewAIReceiptExtractor("", nil))
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterExpenseRoutes(mux, deliveryhttp.NewExpenseHandler(usecases.NewExpenseUseCase(expenseRepo, defaultCurrencyUsers("ETB"))))
+	deliveryhttp.RegisterReceiptRoutes(mux, deliveryhttp.NewReceiptHandler(receiptUC))
+	handler := deliveryhttp.JWTAuthMiddleware(jwtSvc, nil, mux)
+
+	serve := func(req *http.Request) (*httptest.ResponseRecorder, apiEnvelope) {
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, userID))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec, decodeEnvelope(t, rec)
+	}
+	upload := func(filename string, content []byte) domain.ReceiptDraft {
+		t.Helper()
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("file", filename)
+		_, _ = part.Write(content)
+		_ = form.Close()
+		req := httptest.NewRequest(http.MethodPost, "/expenses/drafts/from-receipt", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		rec, env := serve(req)
+		var draft domain.ReceiptDraft
+		if rec.Code != http.StatusAccepted || json.Unmarshal(env.Data, &draft) != nil || draft.Status != domain.ReceiptDraftPending {
+			t.Fatalf("unexpected upload of %s: %d %s %v", filename, rec.Code, env.Data, env.Errors)
+		}
+		return draft
+	}
+	confirm := func(id, body string) (*httptest.ResponseRecorder, apiEnvelope) {
+		return serve(httptest.NewRequest(http.MethodPost, "/expenses/drafts/"+id+"/confirm", strings.NewReader(body)))
+	}
+
+	pdfDraft := upload("shop.pdf", receiptPDF("#GROCER", "Order date 2024-02-01", "Apples|2.40", "Milk|1.10", "Total EUR|3.50"))
+	imageDraft := upload("lunch.png", append([]byte("\x89PNG\r\n\x1A\n"), bytes.Repeat([]byte{1}, 64)...))
+	gifDraft := upload("blurry.gif", []byte("GIF89a\x01\x00\x01\x00"))
+
+	if rec, env := confirm(pdfDraft.ID, ""); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 confirming an unread draft, got %d %v", rec.Code, env.Errors)
+	}
+
+	ready, err := receiptUC.ProcessPending(context.Background())
+	if err != nil || ready != 2 {
+		t.Fatalf("ProcessPending = %d, %v; want 2 ready", ready, err)
+	}
+	if aiCalls != 2 {
+		t.Fatalf("the AI service should only see the two images, got %d calls", aiCalls)
+	}
+	if d := drafts.drafts[gifDraft.ID]; d.Status != domain.ReceiptDraftPending || d.Attempts != 1 || d.LastError == nil {
+		t.Fatalf("expected the GIF to be retried later, got %+v", d)
+	}
+
+	rec, env := serve(httptest.NewRequest(http.MethodGet, "/expenses/drafts/"+pdfDraft.ID, nil))
+	var got domain.ReceiptDraft
+	if rec.Code != http.StatusOK || json.Unmarshal(env.Data, &got) != nil {
+		t.Fatalf("unexpected get: %d %s", rec.Code, env.Data)
+	}
+	if got.Status != domain.ReceiptDraftReady || got.Extractor != "pdf-text" || got.Expense == nil ||
+		got.Expense.Amount.String() != "3.50" || got.Expense.Currency != "EUR" || got.Expense.Note != "GROCER" ||
+		got.Expense.ExpenseDate.Format("2006-01-02") != "2024-02-01" || len(got.Receipt.LineItems) != 2 {
+		t.Fatalf("unexpected draft: %+v %+v", got, got.Expense)
+	}
+
+	rec, env = confirm(pdfDraft.ID, "")
+	var expense domain.Expense
+	if rec.Code != http.StatusCreated || json.Unmarshal(env.Data, &expense) != nil || expense.ID != pdfDraft.ID || expense.Amount.String() != "3.50" {
+		t.Fatalf("unexpected confirm: %d %s %v", rec.Code, env.Data, env.Errors)
+	}
+	if len(drafts.attachments) != 1 || drafts.attachments[0].ExpenseID != expense.ID || drafts.attachments[0].StorageKey != "receipts/"+pdfDraft.ID {
+		t.Fatalf("expected the receipt to become the expense's attachment, got %+v", drafts.attachments)
+	}
+	if r, err := store.Open(context.Background(), "receipts/"+pdfDraft.ID); err != nil {
+		t.Fatalf("the attached receipt must be kept: %v", err)
+	} else {
+		r.Close()
+	}
+
+	if d := drafts.drafts[imageDraft.ID]; d.Extractor != "ai" || d.Expense.Amount.String() != "18.40" || d.Expense.Currency != "CHF" ||
+		d.Expense.Note != "Noodle Bar" || len(d.Receipt.LineItems) != 1 {
+		t.Fatalf("unexpected AI draft: %+v %+v", d, d.Expense)
+	}
+	if rec, env := confirm(imageDraft.ID, `{"amount": 0}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a zero amount, got %d %v", rec.Code, env.Errors)
+	}
+	rec, env = confirm(imageDraft.ID, `{"amount": 20, "note": "Team lunch", "expense_date": "2024-06-03"}`)
+	if rec.Code != http.StatusCreated || json.Unmarshal(env.Data, &expense) != nil ||
+		expense.Amount.String() != "20.00" || expense.Note != "Team lunch" || expense.ExpenseDate.Format("2006-01-02") != "2024-06-03" {
+		t.Fatalf("unexpected edited confirm: %d %s %v", rec.Code, env.Data, env.Errors)
+	}
+
+	rec, env = serve(httptest.NewRequest(http.MethodGet, "/expenses/drafts", nil))
+	var listed []domain.ReceiptDraft
+	if rec.Code != http.StatusOK || json.Unmarshal(env.Data, &listed) != nil || len(listed) != 1 || listed[0].ID != gifDraft.ID {
+		t.Fatalf("expected only the GIF draft left, got %d %s", rec.Code, env.Data)
+	}
+	if rec, _ := serve(httptest.NewRequest(http.MethodDelete, "/expenses/drafts/"+gifDraft.ID, nil)); rec.Code != http.StatusOK {
+		t.Fatalf("unexpected delete: %d", rec.Code)
+	}
+	if _, err := store.Open(context.Background(), "receipts/"+gifDraft.ID); err == nil {
+		t.Fatal("expected the deleted draft's receipt to be removed")
+	}
+	if rec, _ := serve(httptest.NewRequest(http.MethodGet, "/expenses/drafts/"+gifDraft.ID, nil)); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestNextOccurrenceClampsMonthEnd(t *testing.T) {
+	start := date(2024, time.January, 31)
+	want := []time.Time{
+		date(2024, time.February, 29),
+		date(2024, time.March, 31),
+		date(2024, time.April, 30),
+		date(2024, time.May, 31),
+	}
+	due := start
+	for _, w := range want {
+		next, ok := domain.NextOccurrence(domain.RecurrenceMonthly, start, due)
+		if !ok || !next.Equal(w) {
+			t.Fatalf("after %s: expected %s, got %s", due.Format("2006-01-02"), w.Format("2006-01-02"), next.Format("2006-01-02"))
+		}
+		due = next
+	}
+
+	if next, _ := domain.NextOccurrence(domain.RecurrenceMonthly, date(2023, time.January, 31), date(2023, time.January, 31)); !next.Equal(date(2023, time.February, 28)) {
+		t.Fatalf("expected Feb 28 in a non-leap year, got %s", next.Format("2006-01-02"))
+	}
+	if next, _ := domain.NextOccurrence(domain.RecurrenceWeekly, start, date(2024, time.February, 1)); !next.Equal(date(2024, time.February, 7)) {
+		t.Fatalf("unexpected weekly occurrence %s", next.Format("2006-01-02"))
+	}
+	if _, ok := domain.NextOccurrence("fortnightly", start, start); ok {
+		t.Fatal("expected unknown recurrence type to be rejected")
+	}
+}
+
+func TestRunRecurringExpensesCatchesUpIdempotently(t *testing.T) {
+	today := time.Now().UTC()
+	firstDue := date(today.Year(), today.Month(), today.Day()).AddDate(0, 0, -14)
+	template := &domain.Expense{
+		ID:              "template-1",
+		UserID:          "user-1",
+		Amount:          money(12.5),
+		IsRecurring:     true,
+		RecurrenceType:  domain.RecurrenceWeekly,
+		NextDueDate:     &firstDue,
+		RecurrenceStart: &firstDue,
+	}
+
+	generated := map[string]bool{}
+	repo := fakeExpenseRepo{
+		recurringDueFn: func(context.Context, string, int) ([]*domain.Expense, error) {
+			if template.NextDueDate.After(today) {
+				return nil, nil
+			}
+			return []*domain.Expense{template}, nil
+		},
+		materializeFn: func(_ context.Context, e *domain.Expense, dates []time.Time, nextDue *time.Time) (int, error) {
+			inserted := 0
+			for _, d := range dates {
+				key := e.ID + ":" + d.Format("2006-01-02")
+				if !generated[key] {
+					generated[key] = true
+					inserted++
+				}
+			}
+			e.NextDueDate = nextDue
+			return inserted, nil
+		},
+	}
+	uc := usecases.NewExpenseUseCase(repo, defaultCurrencyUsers("ETB"))
+
+	created, err := uc.RunRecurringExpenses(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created != 3 {
+		t.Fatalf("expected 3 missed weekly occurrences, got %d", created)
+	}
+	if want := firstDue.AddDate(0, 0, 21); !template.NextDueDate.Equal(want) {
+		t.Fatalf("expected next due %s, got %s", want.Format("2006-01-02"), template.NextDueDate.Format("2006-01-02"))
+	}
+
+	created, err = uc.RunRecurringExpenses(context.Background())
+	if err != nil || created != 0 {
+		t.Fatalf("second run should create nothing, got %d (%v)", created, err)
+	}
+}
+
+func TestCreateRecurringExpenseDefaultsNextDueDate(t *testing.T) {
+	var got domain.CreateExpenseInput
+	repo := fakeExpenseRepo{
+		createFn: func(_ context.Context, in domain.CreateExpenseInput) (*domain.Expense, error) {
+			got = in
+			return &domain.Expense{ID: "e-1"}, nil
+		},
+	}
+	uc := usecases.NewExpenseUseCase(repo, defaultCurrencyUsers("ETB"))
+
+	_, err := uc.Create(context.Background(), domain.CreateExpenseInput{
+		UserID:         uuid.NewString(),
+		Amount:         money(40),
+		IsRecurring:    true,
+		RecurrenceType: domain.RecurrenceMonthly,
+		ExpenseDate:    date(2024, time.January, 31),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.NextDueDate == nil || !got.NextDueDate.Equal(date(2024, time.February, 29)) {
+		t.Fatalf("unexpected next due date: %v", got.NextDueDate)
+	}
+	if got.RecurrenceStart == nil || !got.RecurrenceStart.Equal(date(2024, time.January, 31)) {
+		t.Fatalf("unexpected recurrence start: %v", got.RecurrenceStart)
+	}
+}
+
+func formatDates(dates []time.Time) string {
+	out := make([]string, 0, len(dates))
+	for _, d := range dates {
+		out = append(out, d.Format("2006-01-02"))
+	}
+	return strings.Join(out, ",")
+}
+
+func TestRecurrenceRuleExpansion(t *testing.T) {
+	until := date(2024, time.March, 31)
+	cases := []struct {
+		name  string
+		rule  domain.RecurrenceRule
+		start time.Time
+		want  string
+	}{
+		{"biweekly", domain.RecurrenceRule{Frequency: domain.RecurrenceWeekly, Interval: 2}, date(2024, time.January, 5),
+			"2024-01-05,2024-01-19,2024-02-02,2024-02-16"},
+		{"weekdays", domain.RecurrenceRule{Frequency: domain.RecurrenceWeekly, ByWeekday: []string{"FR", "MO"}}, date(2024, time.January, 3),
+			"2024-01-05,2024-01-08,2024-01-12,2024-01-15"},
+		{"1st and 15th", domain.RecurrenceRule{Frequency: domain.RecurrenceMonthly, ByMonthDay: []int{15, 1}}, date(2024, time.January, 10),
+			"2024-01-15,2024-02-01,2024-02-15,2024-03-01"},
+		{"last day quarterly", domain.RecurrenceRule{Frequency: domain.RecurrenceMonthly, Interval: 3, ByMonthDay: []int{-1}}, date(2024, time.January, 1),
+			"2024-01-31,2024-04-30,2024-07-31,2024-10-31"},
+		{"yearly leap day", domain.RecurrenceRule{Frequency: domain.RecurrenceYearly}, date(2024, time.February, 29),
+			"2024-02-29,2025-02-28,2026-02-28,2027-02-28"},
+		{"count", domain.RecurrenceRule{Frequency: domain.RecurrenceDaily, Count: 2}, date(2024, time.January, 1),
+			"2024-01-01,2024-01-02"},
+		{"until", domain.RecurrenceRule{Frequency: domain.RecurrenceMonthly, Until: &until}, date(2024, time.January, 31),
+			"2024-01-31,2024-02-29,2024-03-31"},
+	}
+	for _, tc := range cases {
+		if got := formatDates(tc.rule.Occurrences(tc.start, tc.start, time.Time{}, 4)); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+
+	rule := domain.RecurrenceRule{Frequency: domain.RecurrenceDaily, Count: 3}
+	if _, ok := rule.Next(date(2024, time.January, 1), date(2024, time.January, 3)); ok {
+		t.Fatal("expected series to end after count occurrences")
+	}
+}
+
+func TestRecurrenceRuleValidationAndPreview(t *testing.T) {
+	var created domain.CreateExpenseInput
+	repo := fakeExpenseRepo{
+		createFn: func(_ context.Context, in domain.CreateExpenseInput) (*domain.Expense, error) {
+			created = in
+			return &domain.Expense{ID: in.ID, UserID: in.UserID}, nil
+		},
+	}
+	handler := deliveryhttp.NewExpenseHandler(usecases.NewExpenseUseCase(repo, defaultCurrencyUsers("ETB")))
+	jwtSvc := auth.NewJWTService("test-secret")
+	authHeader := "Bearer " + makeAccessToken(t, jwtSvc, uuid.New())
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterExpenseRoutes(mux, handler)
+
+	do := func(target string, body interface{}) (int, apiEnvelope) {
+		req := newJSONRequest(t, http.MethodPost, target, body)
+		req.Header.Set("Authorization", authHeader)
+		rec := serveWithExpenseCategoryAuth(jwtSvc, req, mux.ServeHTTP)
+		return rec.Code, decodeEnvelope(t, rec)
+	}
+
+	invalid := []map[string]interface{}{
+		{"frequency": "hourly"},
+		{"frequency": "monthly", "by_weekday": []string{"MO"}},
+		{"frequency": "weekly", "by_weekday": []string{"XX"}},
+		{"frequency": "monthly", "by_month_day": []int{32}},
+		{"frequency": "daily", "interval": -1},
+		{"frequency": "daily", "count": 3, "until": "2026-12-31"},
+	}
+	for _, rule := range invalid {
+		code, env := do("/expenses", map[string]interface{}{
+			"amount": 10, "expense_date": "2026-01-01", "is_recurring": true, "recurrence_rule": rule,
+		})
+		if code != http.StatusBadRequest || env.Success {
+			t.Fatalf("expected 400 for rule %v, got %d", rule, code)
+		}
+	}
+
+	code, env := do("/expenses", map[string]interface{}{
+		"amount": 10, "expense_date": "2026-01-01", "is_recurring": true,
+		"recurrence_rule": map[string]interface{}{"frequency": "monthly", "by_month_day": []int{1, 15}},
+	})
+	if code != http.StatusCreated || !env.Success {
+		t.Fatalf("unexpected create response: code=%d env=%+v", code, env)
+	}
+	if created.RecurrenceType != domain.RecurrenceMonthly || created.NextDueDate == nil || !created.NextDueDate.Equal(date(2026, time.January, 15)) {
+		t.Fatalf("unexpected recurring input: type=%q next=%v", created.RecurrenceType, created.NextDueDate)
+	}
+
+	code, env = do("/expenses/recurrence-preview", map[string]interface{}{
+		"start_date":      "2026-01-30",
+		"limit":           3,
+		"recurrence_rule": map[string]interface{}{"frequency": "weekly", "interval": 2, "by_weekday": []string{"fr"}},
+	})
+	var preview deliveryhttp.RecurrencePreviewResponse
+	if err := json.Unmarshal(env.Data, &preview); err != nil || code != http.StatusOK {
+		t.Fatalf("unexpected preview response: code=%d env=%+v", code, env)
+	}
+	if got := strings.Join(preview.Dates, ","); got != "2026-01-30,2026-02-13,2026-02-27" {
+		t.Fatalf("unexpected preview dates: %s", got)
+	}
+}
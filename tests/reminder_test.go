@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+type fakeReminderRepo struct {
+	reminders map[string]*domain.Reminder
+	fired     []string
+}
+
+func (f *fakeReminderRepo) Create(_ context.Context, r *domain.Reminder) error {
+	if f.reminders == nil {
+		f.reminders = map[string]*domain.Reminder{}
+	}
+	r.CreatedAt = time.Now().UTC()
+	f.reminders[r.ID] = r
+	return nil
+}
+func (f *fakeReminderRepo) GetByID(_ context.Context, id string) (*domain.Reminder, error) {
+	return f.reminders[id], nil
+}
+func (f *fakeReminderRepo) ListByDebt(_ context.Context, debtID string) ([]*domain.Reminder, error) {
+	list := make([]*domain.Reminder, 0)
+	for _, r := range f.reminders {
+		if r.DebtID == debtID {
+			list = append(list, r)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].RemindAt.Before(list[j].RemindAt) })
+	return list, nil
+}
+func (f *fakeReminderRepo) CountByDebt(ctx context.Context, debtID string) (int, error) {
+	list, _ := f.ListByDebt(ctx, debtID)
+	return len(list), nil
+}
+func (f *fakeReminderRepo) Update(_ context.Context, r *domain.Reminder) error {
+	f.reminders[r.ID] = r
+	return nil
+}
+func (f *fakeReminderRepo) Delete(_ context.Context, id string) error {
+	delete(f.reminders, id)
+	return nil
+}
+func (f *fakeReminderRepo) ListDue(_ context.Context, now time.Time, _ int) ([]*domain.Reminder, error) {
+	due := make([]*domain.Reminder, 0)
+	for _, r := range f.reminders {
+		if r.Enabled && r.FiredAt == nil && !r.RemindAt.After(now) {
+			due = append(due, r)
+		}
+	}
+	return due, nil
+}
+func (f *fakeReminderRepo) MarkFired(_ context.Context, id string, firedAt time.Time) error {
+	f.reminders[id].FiredAt = &firedAt
+	f.fired = append(f.fired, id)
+	return nil
+}
+
+type fakeReminderQueue struct {
+	occurrences []string
+}
+
+func (q *fakeReminderQueue) EnqueueDebtReminder(_ context.Context, _ *domain.Debt, occurrence string) error {
+	q.occurrences = append(q.occurrences, occurrence)
+	return nil
+}
+
+func TestDebtReminderRoutes(t *testing.T) {
+	userID := uuid.New()
+	otherUserID := uuid.New()
+	jwtSvc := auth.NewJWTService("test-secret")
+	debtID := uuid.NewString()
+	debtRepo := fakeDebtRepo{
+		getByIDFn: func(context.Context, string) (*domain.Debt, error) {
+			return &domain.Debt{ID: debtID, UserID: userID.String(), Status: domain.DebtStatusPending}, nil
+		},
+	}
+	reminderRepo := &fakeReminderRepo{}
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterDebtRoutes(mux, deliveryhttp.NewDebtHandler(usecases.NewDebtUsecase(debtRepo, defaultCurrencyUsers("ETB"), reminderRepo, nil, nil), jwtSvc))
+
+	do := func(method, target string, body interface{}, user uuid.UUID) (*httptest.ResponseRecorder, apiEnvelope) {
+		rec := httptest.NewRecorder()
+		req := newJSONRequest(t, method, target, body)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, user))
+		mux.ServeHTTP(rec, req)
+		return rec, decodeEnvelope(t, rec)
+	}
+
+	remindAt := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	rec, env := do(http.MethodPost, "/debts/"+debtID+"/reminders", map[string]interface{}{"remind_at": remindAt}, userID)
+	if rec.Code != http.StatusCreated || !env.Success {
+		t.Fatalf("unexpected create response: code=%d env=%+v", rec.Code, env)
+	}
+	var created domain.Reminder
+	if err := json.Unmarshal(env.Data, &created); err != nil {
+		t.Fatalf("decode reminder: %v", err)
+	}
+	if !created.Enabled || !created.RemindAt.Equal(remindAt) {
+		t.Fatalf("unexpected reminder: %+v", created)
+	}
+
+	if rec, _ := do(http.MethodPost, "/debts/"+debtID+"/reminders", map[string]interface{}{"remind_at": time.Now().Add(-time.Hour)}, userID); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for past remind_at, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodGet, "/debts/"+debtID+"/reminders", nil, otherUserID); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another user's debt, got %d", rec.Code)
+	}
+
+	rec, env = do(http.MethodPatch, "/debts/"+debtID+"/reminders/"+created.ID, map[string]interface{}{"enabled": false}, userID)
+	if rec.Code != http.StatusOK || reminderRepo.reminders[created.ID].Enabled {
+		t.Fatalf("unexpected update response: code=%d env=%+v", rec.Code, env)
+	}
+
+	rec, env = do(http.MethodGet, "/debts/"+debtID+"/reminders", nil, userID)
+	var listed []domain.Reminder
+	if err := json.Unmarshal(env.Data, &listed); err != nil || rec.Code != http.StatusOK || len(listed) != 1 {
+		t.Fatalf("unexpected list response: code=%d env=%+v", rec.Code, env)
+	}
+
+	if rec, _ := do(http.MethodDelete, "/debts/"+debtID+"/reminders/"+uuid.NewString(), nil, userID); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown reminder, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodDelete, "/debts/"+debtID+"/reminders/"+created.ID, nil, userID); rec.Code != http.StatusOK || len(reminderRepo.reminders) != 0 {
+		t.Fatalf("unexpected delete response: code=%d", rec.Code)
+	}
+}
+
+func TestRunReminderCheckFiresScheduledReminders(t *testing.T) {
+	debtID := uuid.NewString()
+	dueID := uuid.NewString()
+	laterID := uuid.NewString()
+	reminderRepo := &fakeReminderRepo{reminders: map[string]*domain.Reminder{
+		dueID:   {ID: dueID, DebtID: debtID, RemindAt: time.Now().Add(-time.Minute), Enabled: true},
+		laterID: {ID: laterID, DebtID: debtID, RemindAt: time.Now().Add(time.Hour), Enabled: true},
+	}}
+	debtRepo := fakeDebtRepo{
+		getByIDFn: func(context.Context, string) (*domain.Debt, error) {
+			return &domain.Debt{ID: debtID, UserID: uuid.NewString(), Status: domain.DebtStatusPending}, nil
+		},
+	}
+	queue := &fakeReminderQueue{}
+	uc := usecases.NewDebtUsecase(debtRepo, defaultCurrencyUsers("ETB"), reminderRepo, nil, nil)
+	uc.SetReminderQueue(queue)
+
+	debts, err := uc.RunReminderCheck(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(debts) != 1 || len(queue.occurrences) != 1 || queue.occurrences[0] != "reminder:"+dueID {
+		t.Fatalf("expected one scheduled reminder to fire, got debts=%d occurrences=%v", len(debts), queue.occurrences)
+	}
+
+	// A second run must not fire the same reminder again
+	if _, err := uc.RunReminderCheck(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reminderRepo.fired) != 1 {
+		t.Fatalf("reminder fired more than once: %v", reminderRepo.fired)
+	}
+}
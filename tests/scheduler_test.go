@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"expense_tracker/infrastructure/scheduler"
+)
+
+type fakeLocker struct {
+	held bool
+}
+
+func (l fakeLocker) TryLock(context.Context, string) (func(), bool, error) {
+	if l.held {
+		return nil, false, nil
+	}
+	return func() {}, true, nil
+}
+
+func waitForRuns(t *testing.T, s *scheduler.Scheduler, name string, n int) []scheduler.RunRecord {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if runs := s.History(name); len(runs) >= n {
+			return runs
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %q did not run %d times", name, n)
+	return nil
+}
+
+func TestSchedulerRecordsRuns(t *testing.T) {
+	var calls int32
+	s := scheduler.New(fakeLocker{})
+	s.Register(scheduler.Job{
+		Name:     "overdue",
+		Interval: 10 * time.Millisecond,
+		Run: func(context.Context) (int64, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return 3, nil
+			}
+			return 0, errors.New("db down")
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	runs := waitForRuns(t, s, "overdue", 2)
+	cancel()
+	s.Wait()
+
+	if runs[0].RowsAffected != 3 || runs[0].Error != "" {
+		t.Fatalf("unexpected first run: %+v", runs[0])
+	}
+	if runs[1].Error != "db down" {
+		t.Fatalf("expected error to be recorded, got %+v", runs[1])
+	}
+	last, ok := s.LastRun("overdue")
+	if !ok || last.FinishedAt.Before(last.StartedAt) {
+		t.Fatalf("unexpected last run: %+v", last)
+	}
+}
+
+func TestSchedulerSkipsWhenLockHeld(t *testing.T) {
+	var calls int32
+	s := scheduler.New(fakeLocker{held: true})
+	s.Register(scheduler.Job{
+		Name:     "reminders",
+		Interval: time.Hour,
+		Run: func(context.Context) (int64, error) {
+			atomic.AddInt32(&calls, 1)
+			return 0, nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	runs := waitForRuns(t, s, "reminders", 1)
+	cancel()
+	s.Wait()
+
+	if !runs[0].Skipped {
+		t.Fatalf("expected run to be skipped, got %+v", runs[0])
+	}
+	if atomic.LoadInt32(&calls) != 0 {
+		t.Fatalf("job should not run without the lock")
+	}
+}
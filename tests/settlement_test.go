@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/repository"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+type fakeSettlementRepo struct {
+	paid  []repository.MemberTotal
+	debts []*domain.Debt
+}
+
+func (f *fakeSettlementRepo) PaidByMember(context.Context, string) ([]repository.MemberTotal, error) {
+	return f.paid, nil
+}
+func (f *fakeSettlementRepo) SettledByMember(_ context.Context, ledgerID string) ([]repository.MemberTotal, error) {
+	totals := make([]repository.MemberTotal, 0)
+	for _, d := range f.debts {
+		if d.LedgerID == nil || *d.LedgerID != ledgerID {
+			continue
+		}
+		amount := d.PaidAmount
+		if d.Type == "lent" {
+			amount = amount.Neg()
+		}
+		totals = append(totals, repository.MemberTotal{UserID: d.UserID, Currency: d.Currency, Total: amount})
+	}
+	return totals, nil
+}
+func (f *fakeSettlementRepo) Record(_ context.Context, payer, payee *domain.Debt) error {
+	for _, d := range []*domain.Debt{payer, payee} {
+		d.PaidAmount = d.Amount
+		f.debts = append(f.debts, d)
+	}
+	return nil
+}
+
+// newGroup creates a ledger owned by the first user with the others as editors
+func newGroup(t *testing.T, ledgers *fakeLedgerRepo, users ...uuid.UUID) string {
+	t.Helper()
+	ctx := context.Background()
+	id := uuid.New().String()
+	if err := ledgers.Create(ctx, &domain.Ledger{ID: id, Name: "Trip", CreatedBy: users[0].String()}); err != nil {
+		t.Fatalf("create ledger: %v", err)
+	}
+	for _, u := range users[1:] {
+		_ = ledgers.AddMember(ctx, &domain.LedgerMember{LedgerID: id, UserID: u.String(), Role: domain.LedgerRoleEditor})
+	}
+	return id
+}
+
+func TestSettleUpMinimizesTransfers(t *testing.T) {
+	ctx := context.Background()
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	ledgers := newFakeLedgerRepo()
+	groupID := newGroup(t, ledgers, a, b, c, d)
+	repo := &fakeSettlementRepo{paid: []repository.MemberTotal{
+		{UserID: a.String(), Currency: "ETB", Total: domain.Cents(30000)},
+		{UserID: b.String(), Currency: "ETB", Total: domain.Cents(10000)},
+		{UserID: c.String(), Currency: "USD", Total: domain.Cents(4000)},
+		{UserID: uuid.NewString(), Currency: "ETB", Total: domain.Cents(99900)}, // a former member
+	}}
+	uc := usecases.NewSettlementUseCase(repo, ledgers)
+
+	if _, err := uc.Settle(ctx, uuid.NewString(), groupID); !errors.Is(err, usecases.ErrLedgerNotFound) {
+		t.Fatalf("expected ErrLedgerNotFound for a non-member, got %v", err)
+	}
+
+	settlement, err := uc.Settle(ctx, b.String(), groupID)
+	if err != nil {
+		t.Fatalf("settle: %v", err)
+	}
+	if len(settlement.Balances) != 8 {
+		t.Fatalf("expected a balance per member and currency, got %d", len(settlement.Balances))
+	}
+	for _, bal := range settlement.Balances {
+		if bal.Currency == "ETB" && bal.Share != domain.Cents(10000) {
+			t.Fatalf("expected an equal ETB share of 100, got %+v", bal)
+		}
+	}
+	var etb, usd int
+	var usdTotal domain.Money
+	for _, tr := range settlement.Transfers {
+		switch tr.Currency {
+		case "ETB":
+			etb++
+			if tr.ToUserID != a.String() || tr.Amount != domain.Cents(10000) || (tr.FromUserID != c.String() && tr.FromUserID != d.String()) {
+				t.Fatalf("unexpected ETB transfer: %+v", tr)
+			}
+		case "USD":
+			usd++
+			usdTotal = usdTotal.Add(tr.Amount)
+			if tr.ToUserID != c.String() {
+				t.Fatalf("unexpected USD transfer: %+v", tr)
+			}
+		}
+	}
+	if etb != 2 || usd != 3 || usdTotal != domain.Cents(3000) {
+		t.Fatalf("expected 2 ETB and 3 USD transfers totalling 30, got %d, %d and %s", etb, usd, usdTotal)
+	}
+
+	payer, payee, err := uc.Record(ctx, c.String(), groupID, domain.SettlementPayment{
+		FromUserID: c.String(), ToUserID: a.String(), Amount: domain.Cents(10000), Currency: "etb",
+	})
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if payer.Type != "borrowed" || payer.UserID != c.String() || payee.Type != "lent" || payee.UserID != a.String() ||
+		payer.Status != domain.DebtStatusPaid || payer.Currency != "ETB" || *payer.LedgerID != groupID {
+		t.Fatalf("unexpected settlement debts: %+v %+v", payer, payee)
+	}
+
+	settlement, _ = uc.Settle(ctx, a.String(), groupID)
+	etb = 0
+	for _, tr := range settlement.Transfers {
+		if tr.Currency == "ETB" {
+			etb++
+			if tr.FromUserID != d.String() {
+				t.Fatalf("only d should still owe ETB, got %+v", tr)
+			}
+		}
+	}
+	if etb != 1 {
+		t.Fatalf("expected 1 ETB transfer after c settled, got %d", etb)
+	}
+}
+
+func TestSettlementRecordValidation(t *testing.T) {
+	ctx := context.Background()
+	a, b, c, viewer := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	ledgers := newFakeLedgerRepo()
+	groupID := newGroup(t, ledgers, a, b, c)
+	_ = ledgers.AddMember(ctx, &domain.LedgerMember{LedgerID: groupID, UserID: viewer.String(), Role: domain.LedgerRoleViewer})
+	uc := usecases.NewSettlementUseCase(&fakeSettlementRepo{}, ledgers)
+
+	cases := []struct {
+		name    string
+		user    uuid.UUID
+		payment domain.SettlementPayment
+		want    error
+	}{
+		{"viewer", viewer, domain.SettlementPayment{FromUserID: a.String(), ToUserID: b.String(), Amount: domain.Cents(100), Currency: "ETB"}, usecases.ErrLedgerReadOnly},
+		{"same member", a, domain.SettlementPayment{FromUserID: a.String(), ToUserID: a.String(), Amount: domain.Cents(100), Currency: "ETB"}, usecases.ErrSettlementParties},
+		{"not a member", a, domain.SettlementPayment{FromUserID: a.String(), ToUserID: uuid.NewString(), Amount: domain.Cents(100), Currency: "ETB"}, usecases.ErrSettlementParties},
+		{"zero amount", a, domain.SettlementPayment{FromUserID: a.String(), ToUserID: b.String(), Currency: "ETB"}, usecases.ErrAmountMustBePositive},
+		{"bad currency", a, domain.SettlementPayment{FromUserID: a.String(), ToUserID: b.String(), Amount: domain.Cents(100), Currency: "birr"}, usecases.ErrInvalidCurrency},
+		{"editor between others", c, domain.SettlementPayment{FromUserID: a.String(), ToUserID: b.String(), Amount: domain.Cents(100), Currency: "ETB"}, usecases.ErrSettlementNotParty},
+	}
+	for _, tc := range cases {
+		if _, _, err := uc.Record(ctx, tc.user.String(), groupID, tc.payment); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	// The owner can record a payment between two other members
+	payer, payee, err := uc.Record(ctx, a.String(), groupID, domain.SettlementPayment{FromUserID: b.String(), ToUserID: c.String(), Amount: domain.Cents(100), Currency: "ETB"})
+	if err != nil || payer.UserID != b.String() || payee.UserID != c.String() {
+		t.Fatalf("expected the owner to record a payment from b to c: %+v %+v (%v)", payer, payee, err)
+	}
+}
+
+func TestSettlementRoutes(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	a, b := uuid.New(), uuid.New()
+	ledgers := newFakeLedgerRepo()
+	groupID := newGroup(t, ledgers, a, b)
+	repo := &fakeSettlementRepo{paid: []repository.MemberTotal{{UserID: a.String(), Currency: "ETB", Total: domain.Cents(5000)}}}
+
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterSettlementRoutes(mux, deliveryhttp.NewSettlementHandler(usecases.NewSettlementUseCase(repo, ledgers), jwtSvc))
+	do := func(method, target string, user uuid.UUID, body interface{}) (*httptest.ResponseRecorder, apiEnvelope) {
+		req := newJSONRequest(t, method, target, body)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, user))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec, decodeEnvelope(t, rec)
+	}
+
+	rec, env := do(http.MethodGet, "/groups/"+groupID+"/settle", b, nil)
+	var settlement domain.Settlement
+	if rec.Code != http.StatusOK || json.Unmarshal(env.Data, &settlement) != nil || len(settlement.Transfers) != 1 ||
+		settlement.Transfers[0].FromUserID != b.String() || settlement.Transfers[0].Amount != domain.Cents(2500) {
+		t.Fatalf("unexpected settle response: code=%d data=%s", rec.Code, env.Data)
+	}
+	if rec, _ := do(http.MethodGet, "/groups/"+uuid.NewString()+"/settle", b, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another group, got %d", rec.Code)
+	}
+	if rec, _ := do(http.MethodPost, "/groups/"+groupID+"/settle", b, map[string]interface{}{
+		"from_user_id": b.String(), "to_user_id": a.String(), "amount": 25, "currency": "ETB", "paid_date": "2999-01-01",
+	}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a future paid_date, got %d", rec.Code)
+	}
+
+	rec, env = do(http.MethodPost, "/groups/"+groupID+"/settle", b, map[string]interface{}{
+		"from_user_id": b.String(), "to_user_id": a.String(), "amount": 25, "currency": "ETB",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("unexpected record response: code=%d errors=%v", rec.Code, env.Errors)
+	}
+	_, env = do(http.MethodGet, "/groups/"+groupID+"/settle", a, nil)
+	if json.Unmarshal(env.Data, &settlement) != nil || len(settlement.Transfers) != 0 {
+		t.Fatalf("expected the group to be settled, got %s", env.Data)
+	}
+}
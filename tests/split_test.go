@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+// fakeExpenseSplitRepo records what CreateSplit was asked to store
+type fakeExpenseSplitRepo struct {
+	input domain.CreateExpenseInput
+	debts []*domain.Debt
+}
+
+func (f *fakeExpenseSplitRepo) CreateSplit(_ context.Context, input domain.CreateExpenseInput, debts []*domain.Debt) (*domain.Expense, error) {
+	f.input, f.debts = input, debts
+	for _, d := range debts {
+		d.ExpenseID = &input.ID
+	}
+	return &domain.Expense{ID: input.ID, UserID: input.UserID, Amount: input.Amount, ExpenseDate: input.ExpenseDate}, nil
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	cases := []struct {
+		total   domain.Money
+		weights []float64
+		places  int
+		want    []domain.Money
+	}{
+		{money(100), []float64{1, 1, 1}, 2, []domain.Money{money(33.34), money(33.33), money(33.33)}},
+		{money(10), []float64{1, 1, 1, 1, 1, 1}, 2, []domain.Money{money(1.67), money(1.67), money(1.67), money(1.67), money(1.66), money(1.66)}},
+		{money(90), []float64{2, 1}, 2, []domain.Money{money(60), money(30)}},
+		{money(0.05), []float64{33.33, 33.33, 33.34}, 2, []domain.Money{money(0.02), money(0.01), money(0.02)}},
+		{money(-1), []float64{1, 2}, 2, []domain.Money{money(-0.33), money(-0.67)}},
+		{money(10), []float64{0, 0}, 2, []domain.Money{{}, {}}},
+		{money(1000), []float64{1, 1, 1}, 0, []domain.Money{money(334), money(333), money(333)}},
+		{domain.Mills(10000), []float64{1, 1, 1}, 3, []domain.Money{domain.Mills(3334), domain.Mills(3333), domain.Mills(3333)}},
+	}
+	for _, c := range cases {
+		parts := c.total.Allocate(c.weights, c.places)
+		var sum domain.Money
+		for i, p := range parts {
+			if p != c.want[i] {
+				t.Fatalf("Allocate(%s, %v) = %v; want %v", c.total, c.weights, parts, c.want)
+			}
+			sum = sum.Add(p)
+		}
+		if sum != c.total && c.weights[0] != 0 {
+			t.Fatalf("Allocate(%s, %v) adds up to %s", c.total, c.weights, sum)
+		}
+	}
+}
+
+func TestCreateSplit(t *testing.T) {
+	userID := uuid.NewString()
+	splitRepo := &fakeExpenseSplitRepo{}
+	contacts := &fakeContactRepo{}
+	sara := &domain.Contact{ID: uuid.NewString(), UserID: userID, Name: "Sara"}
+	contacts.contacts = append(contacts.contacts, sara)
+	uc := usecases.NewExpenseUseCase(fakeExpenseRepo{}, defaultCurrencyUsers("ETB"))
+	uc.SetSplitRepository(splitRepo, contacts)
+	input := func() domain.CreateExpenseInput {
+		return domain.CreateExpenseInput{UserID: userID, Amount: money(100), ExpenseDate: time.Now(), Note: "dinner"}
+	}
+
+	expense, debts, err := uc.CreateSplit(context.Background(), input(), domain.Split{
+		Method: domain.SplitEqual,
+		Participants: []domain.SplitParticipant{
+			{Self: true}, {Name: " abebe "}, {ContactID: &sara.ID},
+		},
+	})
+	if err != nil {
+		t.Fatalf("equal split: %v", err)
+	}
+	if expense.Amount != money(33.34) || len(debts) != 2 || debts[0].Amount != money(33.33) || debts[1].Amount != money(33.33) {
+		t.Fatalf("unexpected equal split: expense=%s debts=%+v", expense.Amount, debts)
+	}
+	if debts[0].Type != "lent" || debts[0].PeerName != "abebe" || debts[0].ContactID != nil || debts[0].Note == nil || *debts[0].Note != "dinner" {
+		t.Fatalf("unexpected debt for a named participant: %+v", debts[0])
+	}
+	if debts[1].PeerName != "Sara" || *debts[1].ContactID != sara.ID || *debts[1].ExpenseID != expense.ID {
+		t.Fatalf("unexpected debt for a contact: %+v", debts[1])
+	}
+	if due := debts[0].DueDate; due.Sub(time.Now()) < 29*24*time.Hour {
+		t.Fatalf("expected the default due date 30 days out, got %s", due)
+	}
+
+	_, debts, err = uc.CreateSplit(context.Background(), input(), domain.Split{
+		Method: domain.SplitShares,
+		Participants: []domain.SplitParticipant{
+			{Self: true, Shares: 1}, {Name: "Abebe", Shares: 2}, {Name: "Kid", Shares: 0},
+		},
+	})
+	if err != nil || len(debts) != 1 || debts[0].Amount != money(66.67) || splitRepo.input.Amount != money(33.33) {
+		t.Fatalf("unexpected shares split: debts=%+v expense=%s err=%v", debts, splitRepo.input.Amount, err)
+	}
+
+	invalid := []domain.Split{
+		{Method: "thirds", Participants: []domain.SplitParticipant{{Self: true}, {Name: "A"}}},
+		{Method: domain.SplitEqual, Participants: []domain.SplitParticipant{{Name: "A"}, {Name: "B"}}},
+		{Method: domain.SplitEqual, Participants: []domain.SplitParticipant{{Self: true}, {Name: "A"}, {Name: " a"}}},
+		{Method: domain.SplitPercentage, Participants: []domain.SplitParticipant{{Self: true, Percent: 50}, {Name: "A", Percent: 40}}},
+		{Method: domain.SplitExact, Participants: []domain.SplitParticipant{{Self: true, Amount: money(50)}, {Name: "A", Amount: money(49.99)}}},
+		{Method: domain.SplitExact, Participants: []domain.SplitParticipant{{Self: true}, {Name: "A", Amount: money(100)}}},
+	}
+	for _, split := range invalid {
+		if _, _, err := uc.CreateSplit(context.Background(), input(), split); !errors.Is(err, usecases.ErrInvalidSplit) {
+			t.Fatalf("expected ErrInvalidSplit for %+v, got %v", split, err)
+		}
+	}
+
+	missing := uuid.NewString()
+	_, _, err = uc.CreateSplit(context.Background(), input(), domain.Split{
+		Method:       domain.SplitEqual,
+		Participants: []domain.SplitParticipant{{Self: true}, {ContactID: &missing}},
+	})
+	if !errors.Is(err, usecases.ErrContactNotFound) {
+		t.Fatalf("expected ErrContactNotFound, got %v", err)
+	}
+
+	threeWays := domain.Split{Method: domain.SplitEqual, Participants: []domain.SplitParticipant{{Self: true}, {Name: "A"}, {Name: "B"}}}
+	yen := input()
+	yen.Amount, yen.Currency = money(1000), "JPY"
+	expense, debts, err = uc.CreateSplit(context.Background(), yen, threeWays)
+	if err != nil || expense.Amount != money(334) || debts[0].Amount != money(333) || debts[1].Amount != money(333) {
+		t.Fatalf("expected a yen split in whole yen: expense=%v debts=%+v err=%v", expense, debts, err)
+	}
+	dinar := input()
+	dinar.Amount, dinar.Currency = domain.Mills(10000), "KWD"
+	expense, debts, err = uc.CreateSplit(context.Background(), dinar, threeWays)
+	if err != nil || expense.Amount != domain.Mills(3334) || debts[0].Amount != domain.Mills(3333) {
+		t.Fatalf("expected a dinar split in fils: expense=%v debts=%+v err=%v", expense, debts, err)
+	}
+	dollars := input()
+	dollars.Amount, dollars.Currency = domain.Mills(12345), "USD"
+	if _, _, err := uc.CreateSplit(context.Background(), dollars, threeWays); !errors.Is(err, usecases.ErrAmountPrecision) {
+		t.Fatalf("expected ErrAmountPrecision for 12.345 USD, got %v", err)
+	}
+
+	// Without a currency the shares are in the user's default currency
+	yenUser := usecases.NewExpenseUseCase(fakeExpenseRepo{}, defaultCurrencyUsers("JPY"))
+	yenUser.SetSplitRepository(splitRepo, contacts)
+	yen = input()
+	yen.Amount = money(1000)
+	expense, debts, err = yenUser.CreateSplit(context.Background(), yen, threeWays)
+	if err != nil || expense.Amount != money(334) || debts[0].Amount != money(333) || debts[1].Amount != money(333) {
+		t.Fatalf("expected a split in whole yen for a JPY user: expense=%v debts=%+v err=%v", expense, debts, err)
+	}
+	dinarUser := usecases.NewExpenseUseCase(fakeExpenseRepo{}, defaultCurrencyUsers("KWD"))
+	dinarUser.SetSplitRepository(splitRepo, contacts)
+	dinar = input()
+	dinar.Amount = money(10)
+	expense, debts, err = dinarUser.CreateSplit(context.Background(), dinar, threeWays)
+	if err != nil || expense.Amount != domain.Mills(3334) || debts[0].Amount != domain.Mills(3333) {
+		t.Fatalf("expected a split in fils for a KWD user: expense=%v debts=%+v err=%v", expense, debts, err)
+	}
+}
+
+func TestSplitExpenseRoute(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	splitRepo := &fakeExpenseSplitRepo{}
+	uc := usecases.NewExpenseUseCase(fakeExpenseRepo{}, defaultCurrencyUsers("ETB"))
+	uc.SetSplitRepository(splitRepo, &fakeContactRepo{})
+	mux := http.NewServeMux()
+	deliveryhttp.RegisterExpenseRoutes(mux, deliveryhttp.NewExpenseHandler(uc))
+
+	post := func(body map[string]interface{}) (int, apiEnvelope) {
+		req := newJSONRequest(t, http.MethodPost, "/expenses", body)
+		req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, uuid.New()))
+		rec := serveWithExpenseCategoryAuth(jwtSvc, req, mux.ServeHTTP)
+		return rec.Code, decodeEnvelope(t, rec)
+	}
+
+	code, env := post(map[string]interface{}{
+		"amount": 60, "expense_date": "2026-03-01",
+		"split": map[string]interface{}{
+			"method": "percentage",
+			"participants": []map[string]interface{}{
+				{"self": true, "percent": 50}, {"name": "Abebe", "percent": 25}, {"name": "Sara", "percent": 25},
+			},
+		},
+	})
+	if code != http.StatusCreated || !strings.Contains(string(env.Data), `"amount":30.00`) || !strings.Contains(string(env.Data), `"peer_name":"Sara"`) {
+		t.Fatalf("unexpected split response: code=%d data=%s", code, env.Data)
+	}
+	if len(splitRepo.debts) != 2 || splitRepo.debts[0].Amount != money(15) {
+		t.Fatalf("unexpected debts: %+v", splitRepo.debts)
+	}
+
+	code, env = post(map[string]interface{}{
+		"amount": 60, "expense_date": "2026-03-01",
+		"split": map[string]interface{}{"method": "equal", "participants": []map[string]interface{}{{"self": true}}},
+	})
+	if code != http.StatusBadRequest || !strings.Contains(strings.Join(env.Errors, " "), "at least two participants") {
+		t.Fatalf("expected 400 for a split with one participant: code=%d errors=%v", code, env.Errors)
+	}
+
+	code, env = post(map[string]interface{}{"amount": 12.5, "currency": "JPY", "expense_date": "2026-03-01"})
+	if code != http.StatusBadRequest || !strings.Contains(strings.Join(env.Errors, " "), "decimal places") {
+		t.Fatalf("expected 400 for 12.5 JPY: code=%d errors=%v", code, env.Errors)
+	}
+}
@@ -0,0 +1,238 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	deliveryhttp "expense_tracker/delivery/http"
+	"expense_tracker/domain"
+	"expense_tracker/infrastructure/auth"
+	"expense_tracker/usecases"
+
+	"github.com/google/uuid"
+)
+
+type fakeSyncRepo struct {
+	watermark uint64
+}
+
+func (f fakeSyncRepo) Watermark(context.Context) (uint64, error) {
+	return f.watermark, nil
+}
+
+func TestSyncExpensesHandler(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	userID := uuid.New()
+	otherUserID := uuid.New()
+
+	newID := uuid.NewString()
+	syncedID := uuid.NewString()
+	changedID := uuid.NewString()
+	foreignID := uuid.NewString()
+	date := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var inserted []domain.CreateExpenseInput
+	repo := fakeExpenseRepo{
+		getByIDsFn: func(context.Context, []string) ([]*domain.Expense, error) {
+			return []*domain.Expense{
+				{ID: syncedID, UserID: userID.String(), Amount: money(10), Note: "Lunch", ExpenseDate: date},
+				{ID: changedID, UserID: userID.String(), Amount: money(99), ExpenseDate: date},
+				{ID: foreignID, UserID: otherUserID.String(), Amount: money(10), ExpenseDate: date},
+			}, nil
+		},
+		createBatchFn: func(_ context.Context, in []domain.CreateExpenseInput) ([]string, error) {
+			inserted = in
+			ids := make([]string, 0, len(in))
+			for _, item := range in {
+				ids = append(ids, item.ID)
+			}
+			return ids, nil
+		},
+	}
+	handler := deliveryhttp.NewSyncHandler(usecases.NewSyncUseCase(repo, fakeDebtRepo{}, fakeCategoryRepo{}, defaultCurrencyUsers("ETB"), fakeSyncRepo{}))
+
+	req := newJSONRequest(t, http.MethodPost, "/sync/expenses", map[string]interface{}{
+		"expenses": []map[string]interface{}{
+			{"id": newID, "amount": 5, "expense_date": "2026-01-01"},
+			{"id": syncedID, "amount": 10, "note": "Lunch", "expense_date": "2026-01-01"},
+			{"id": changedID, "amount": 10, "expense_date": "2026-01-01"},
+			{"id": foreignID, "amount": 10, "expense_date": "2026-01-01"},
+			{"amount": 10, "expense_date": "2026-01-01"},
+		},
+	})
+	req.Header.Set("Authorization", "Bearer "+makeAccessToken(t, jwtSvc, userID))
+	rec := serveWithExpenseCategoryAuth(jwtSvc, req, handler.SyncExpenses)
+
+	env := decodeEnvelope(t, rec)
+	if rec.Code != http.StatusOK || !env.Success {
+		t.Fatalf("unexpected sync response: code=%d env=%+v", rec.Code, env)
+	}
+	var result domain.SyncResult
+	if err := json.Unmarshal(env.Data, &result); err != nil {
+		t.Fatalf("decode sync result: %v", err)
+	}
+	if result.Inserted != 1 || result.Skipped != 1 || result.Conflicts != 2 || result.Invalid != 1 {
+		t.Fatalf("unexpected sync counts: %+v", result)
+	}
+	wantStatuses := []domain.SyncItemStatus{
+		domain.SyncItemInserted, domain.SyncItemSkipped, domain.SyncItemConflict, domain.SyncItemConflict, domain.SyncItemInvalid,
+	}
+	for i, want := range wantStatuses {
+		if result.Items[i].Status != want {
+			t.Fatalf("item %d: expected %s, got %+v", i, want, result.Items[i])
+		}
+	}
+	if len(inserted) != 1 || inserted[0].ID != newID || inserted[0].UserID != userID.String() {
+		t.Fatalf("unexpected inserted batch: %+v", inserted)
+	}
+}
+
+func TestSyncExpensesInsertedConcurrently(t *testing.T) {
+	userID := uuid.NewString()
+	sameID, changedID, foreignID, goneID := uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString()
+	date := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Another request stores every ID between the lookup and the insert, so nothing is inserted
+	lookups := 0
+	var reread []string
+	repo := fakeExpenseRepo{
+		getByIDsFn: func(_ context.Context, ids []string) ([]*domain.Expense, error) {
+			lookups++
+			if lookups == 1 {
+				return nil, nil
+			}
+			reread = ids
+			return []*domain.Expense{
+				{ID: sameID, UserID: userID, Amount: money(10), ExpenseDate: date},
+				{ID: changedID, UserID: userID, Amount: money(99), ExpenseDate: date},
+				{ID: foreignID, UserID: uuid.NewString(), Amount: money(10), ExpenseDate: date},
+			}, nil
+		},
+		createBatchFn: func(context.Context, []domain.CreateExpenseInput) ([]string, error) {
+			return nil, nil
+		},
+	}
+	uc := usecases.NewSyncUseCase(repo, fakeDebtRepo{}, fakeCategoryRepo{}, defaultCurrencyUsers("ETB"), fakeSyncRepo{})
+
+	inputs := make([]domain.CreateExpenseInput, 0, 4)
+	for _, id := range []string{sameID, changedID, foreignID, goneID} {
+		inputs = append(inputs, domain.CreateExpenseInput{ID: id, Amount: money(10), ExpenseDate: date})
+	}
+	result, err := uc.SyncExpenses(context.Background(), userID, inputs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reread) != 4 {
+		t.Fatalf("expected the IDs that were not inserted to be read again, got %v", reread)
+	}
+	want := []struct {
+		status domain.SyncItemStatus
+		reason string
+	}{
+		{domain.SyncItemSkipped, "already synced"},
+		{domain.SyncItemConflict, "expense already exists with different data"},
+		{domain.SyncItemConflict, "id is already in use"},
+		{domain.SyncItemConflict, "expense could not be stored"},
+	}
+	for i, w := range want {
+		if result.Items[i].Status != w.status || result.Items[i].Reason != w.reason {
+			t.Fatalf("item %d: expected %s (%s), got %+v", i, w.status, w.reason, result.Items[i])
+		}
+	}
+	if result.Inserted != 0 || result.Skipped != 1 || result.Conflicts != 3 {
+		t.Fatalf("unexpected sync counts: %+v", result)
+	}
+}
+
+func TestSyncExpensesChecksDefaultCurrencyPrecision(t *testing.T) {
+	var inserted []domain.CreateExpenseInput
+	repo := fakeExpenseRepo{
+		getByIDsFn: func(context.Context, []string) ([]*domain.Expense, error) { return nil, nil },
+		createBatchFn: func(_ context.Context, in []domain.CreateExpenseInput) ([]string, error) {
+			inserted = in
+			return []string{in[0].ID}, nil
+		},
+	}
+	uc := usecases.NewSyncUseCase(repo, fakeDebtRepo{}, fakeCategoryRepo{}, defaultCurrencyUsers("JPY"), fakeSyncRepo{})
+
+	date := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	result, err := uc.SyncExpenses(context.Background(), uuid.NewString(), []domain.CreateExpenseInput{
+		{ID: uuid.NewString(), Amount: money(12.5), Currency: "USD", ExpenseDate: date},
+		{ID: uuid.NewString(), Amount: money(12.5), ExpenseDate: date},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Inserted != 1 || result.Invalid != 1 || result.Items[1].Reason != usecases.ErrAmountPrecision.Error() || len(inserted) != 1 {
+		t.Fatalf("expected 12.5 without a currency to be invalid for a JPY user: %+v", result)
+	}
+}
+
+func TestSyncChangesHandler(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	userID := uuid.New()
+	deletedAt := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	// Transaction 52 took version 11 before transaction 41 took 12 and 13, but committed after it;
+	// the feed follows the transactions
+	var gotSince domain.SyncPosition
+	var gotBefore []uint64
+	expenseRepo := fakeExpenseRepo{
+		changedFn: func(_ context.Context, _ string, since domain.SyncPosition, before uint64, _ int) ([]*domain.Expense, error) {
+			gotSince = since
+			gotBefore = append(gotBefore, before)
+			return []*domain.Expense{{ID: "exp-1", Version: 14, SyncTxID: 50}, {ID: "exp-2", Version: 11, SyncTxID: 52, DeletedAt: &deletedAt}}, nil
+		},
+	}
+	debtRepo := fakeDebtRepo{
+		changedFn: func(_ context.Context, _ string, _ domain.SyncPosition, before uint64, _ int) ([]*domain.Debt, error) {
+			gotBefore = append(gotBefore, before)
+			return []*domain.Debt{{ID: "debt-1", Version: 12, SyncTxID: 41}}, nil
+		},
+	}
+	categoryRepo := fakeCategoryRepo{
+		changedFn: func(_ context.Context, _ string, _ domain.SyncPosition, before uint64, _ int) ([]*domain.Category, error) {
+			gotBefore = append(gotBefore, before)
+			return []*domain.Category{{ID: "cat-1", Version: 13, SyncTxID: 41}}, nil
+		},
+	}
+	handler := deliveryhttp.NewSyncHandler(usecases.NewSyncUseCase(expenseRepo, debtRepo, categoryRepo, defaultCurrencyUsers("ETB"), fakeSyncRepo{watermark: 60}))
+	authHeader := "Bearer " + makeAccessToken(t, jwtSvc, userID)
+
+	since := domain.SyncPosition{TxID: 40, Version: 10}
+	req := newJSONRequest(t, http.MethodGet, "/sync/changes?limit=3&since="+usecases.EncodeSyncCursor(since), nil)
+	req.Header.Set("Authorization", authHeader)
+	rec := serveWithExpenseCategoryAuth(jwtSvc, req, handler.GetChanges)
+	env := decodeEnvelope(t, rec)
+	if rec.Code != http.StatusOK || !env.Success {
+		t.Fatalf("unexpected changes response: code=%d env=%+v", rec.Code, env)
+	}
+	var changes domain.SyncChanges
+	if err := json.Unmarshal(env.Data, &changes); err != nil {
+		t.Fatalf("decode changes: %v", err)
+	}
+	if gotSince != since {
+		t.Fatalf("expected the cursor to decode to %+v, got %+v", since, gotSince)
+	}
+	if len(gotBefore) != 3 || gotBefore[0] != 60 || gotBefore[1] != 60 || gotBefore[2] != 60 {
+		t.Fatalf("every table must be read up to the same watermark, got %v", gotBefore)
+	}
+	// Transaction 41 and exp-1 of transaction 50 fit the page; the tombstone written by
+	// transaction 52 waits for the next call although its version is lower
+	if len(changes.Expenses) != 1 || changes.Expenses[0].ID != "exp-1" || len(changes.Debts) != 1 || len(changes.Categories) != 1 || !changes.HasMore {
+		t.Fatalf("unexpected page: %+v", changes)
+	}
+	if next, err := usecases.DecodeSyncCursor(changes.NextCursor); err != nil || next != (domain.SyncPosition{TxID: 50, Version: 14}) {
+		t.Fatalf("unexpected next cursor %q (%+v, %v)", changes.NextCursor, next, err)
+	}
+
+	badReq := newJSONRequest(t, http.MethodGet, "/sync/changes?since=not-a-cursor", nil)
+	badReq.Header.Set("Authorization", authHeader)
+	badRec := serveWithExpenseCategoryAuth(jwtSvc, badReq, handler.GetChanges)
+	if env := decodeEnvelope(t, badRec); badRec.Code != http.StatusBadRequest || env.Success {
+		t.Fatalf("unexpected invalid cursor response: code=%d env=%+v", badRec.Code, env)
+	}
+}
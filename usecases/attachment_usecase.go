@@ -0,0 +1,208 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+
+	"github.com/google/uuid"
+)
+
+// MaxAttachmentSize caps the size of an attachment, in bytes
+const MaxAttachmentSize = 10 << 20
+
+// maxAttachmentsPerExpense caps how many files one expense can have
+const maxAttachmentsPerExpense = 10
+
+var (
+	ErrAttachmentNotFound        = errors.New("attachment not found")
+	ErrAttachmentExpenseNotFound = errors.New("expense not found")
+	ErrAttachmentTooLarge        = fmt.Errorf("a file can be at most %d MB", MaxAttachmentSize>>20)
+	ErrUnsupportedAttachmentType = errors.New("file must be a JPEG, PNG, GIF, WebP or HEIC image or a PDF")
+	ErrTooManyAttachments        = fmt.Errorf("an expense can have at most %d attachments", maxAttachmentsPerExpense)
+)
+
+// attachmentExtensions name files uploaded without a usable name
+var attachmentExtensions = map[string]string{
+	"image/jpeg": ".jpg", "image/png": ".png", "image/gif": ".gif", "image/webp": ".webp",
+	"image/heic": ".heic", "application/pdf": ".pdf",
+}
+
+// AttachmentUseCase keeps files such as receipts with expenses
+type AttachmentUseCase struct {
+	repo        repository.AttachmentRepository
+	expenseRepo repository.ExpenseRepository
+	blobs       repository.BlobStore
+}
+
+// NewAttachmentUseCase creates an attachment usecase storing file contents in blobs
+func NewAttachmentUseCase(repo repository.AttachmentRepository, expenseRepo repository.ExpenseRepository, blobs repository.BlobStore) *AttachmentUseCase {
+	return &AttachmentUseCase{repo: repo, expenseRepo: expenseRepo, blobs: blobs}
+}
+
+// Upload stores file as an attachment of the expense. The content type is sniffed from the bytes
+// and must be an allowed one; whatever the upload claimed is ignored. Ledger viewers cannot upload.
+func (u *AttachmentUseCase) Upload(ctx context.Context, userID, expenseID, fileName string, file io.Reader) (*domain.Attachment, error) {
+	if _, err := u.expense(ctx, userID, expenseID); err != nil {
+		return nil, err
+	}
+	existing, err := u.repo.ListByExpense(ctx, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxAttachmentsPerExpense {
+		return nil, ErrTooManyAttachments
+	}
+
+	head := make([]byte, domain.AttachmentSniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := domain.SniffAttachmentType(head)
+	if contentType == "" {
+		return nil, ErrUnsupportedAttachmentType
+	}
+
+	id := uuid.New().String()
+	attachment := &domain.Attachment{
+		ID:          id,
+		ExpenseID:   expenseID,
+		UserID:      userID,
+		FileName:    attachmentFileName(fileName, contentType),
+		ContentType: contentType,
+		StorageKey:  "expenses/" + expenseID + "/" + id,
+	}
+	content := &sizeLimitReader{r: io.MultiReader(bytes.NewReader(head), file), left: MaxAttachmentSize}
+	if attachment.Size, err = u.blobs.Put(ctx, attachment.StorageKey, content); err != nil {
+		return nil, err
+	}
+	if err := u.repo.Create(ctx, attachment); err != nil {
+		_ = u.blobs.Delete(ctx, attachment.StorageKey)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLedgerReadOnly
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// List returns the attachments of an expense the user can read, oldest first
+func (u *AttachmentUseCase) List(ctx context.Context, userID, expenseID string) ([]*domain.Attachment, error) {
+	if _, err := u.expense(ctx, userID, expenseID); err != nil {
+		return nil, err
+	}
+	return u.repo.ListByExpense(ctx, expenseID)
+}
+
+// Open returns an attachment of an expense the user can read with its content, which the caller closes
+func (u *AttachmentUseCase) Open(ctx context.Context, userID, expenseID, id string) (*domain.Attachment, io.ReadCloser, error) {
+	attachment, err := u.get(ctx, userID, expenseID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := u.blobs.Open(ctx, attachment.StorageKey)
+	if errors.Is(err, repository.ErrBlobNotFound) {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, content, nil
+}
+
+// Delete removes an attachment of an expense the user can change, and then its content
+func (u *AttachmentUseCase) Delete(ctx context.Context, userID, expenseID, id string) error {
+	attachment, err := u.get(ctx, userID, expenseID, id)
+	if err != nil {
+		return err
+	}
+	if err := u.repo.Delete(ctx, expenseID, id, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLedgerReadOnly
+		}
+		return err
+	}
+	// the record is gone, so a blob left behind is unreachable and only costs space
+	_ = u.blobs.Delete(ctx, attachment.StorageKey)
+	return nil
+}
+
+// expense returns the expense when the user can read it
+func (u *AttachmentUseCase) expense(ctx context.Context, userID, expenseID string) (*domain.Expense, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	expense, err := u.expenseRepo.GetByID(ctx, expenseID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if expense == nil {
+		return nil, ErrAttachmentExpenseNotFound
+	}
+	return expense, nil
+}
+
+func (u *AttachmentUseCase) get(ctx context.Context, userID, expenseID, id string) (*domain.Attachment, error) {
+	if _, err := u.expense(ctx, userID, expenseID); err != nil {
+		return nil, err
+	}
+	attachment, err := u.repo.GetByID(ctx, expenseID, id)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, nil
+}
+
+// attachmentFileName keeps the last element of an uploaded file name without control characters
+// or quotes, at most 255 bytes; files without one are named after their type, like receipt.pdf
+func attachmentFileName(name, contentType string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' || r == unicode.ReplacementChar {
+			return -1
+		}
+		return r
+	}, name))
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == ".." {
+		return "receipt" + attachmentExtensions[contentType]
+	}
+	return name
+}
+
+// sizeLimitReader fails with ErrAttachmentTooLarge once more than left bytes have been read
+type sizeLimitReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.left+1 {
+		p = p[:l.left+1]
+	}
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	if l.left < 0 {
+		return 0, ErrAttachmentTooLarge
+	}
+	return n, err
+}
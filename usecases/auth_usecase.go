@@ -101,7 +101,7 @@ func (a *authUsecase) Register(ctx context.Context, in RegisterInput) (domain.Us
 		Name:            in.Name,
 		Email:           in.Email,
 		PasswordHash:    hash,
-		BudgetingStyle:  "flexible",
+		BudgetingStyle:  domain.BudgetingStyleFlexible,
 		DefaultCurrency: "ETB",
 	}
 
@@ -0,0 +1,324 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"expense_tracker/domain"
+)
+
+// maxEnvelopeRolloverMonths bounds how far back envelope rollovers are computed
+const maxEnvelopeRolloverMonths = 12
+
+// BudgetPlan is the report section explaining how the user's budgeting style is doing in a
+// report period
+type BudgetPlan struct {
+	Style       domain.BudgetingStyle `json:"style"`
+	Summary     string                `json:"summary"`
+	Income      domain.Money          `json:"income"` // planned income for the period, or income received when none is planned
+	Allocations []BudgetAllocation    `json:"allocations"`
+	Unallocated *domain.Money         `json:"unallocated,omitempty"` // zero-based: income not assigned to a budget
+	Warnings    []string              `json:"warnings"`
+}
+
+// BudgetAllocation is one line of a plan: a category budget, the overall budget or a 50/30/20 bucket
+type BudgetAllocation struct {
+	Name       string              `json:"name"`
+	CategoryID *string             `json:"category_id,omitempty"`
+	Bucket     domain.BudgetBucket `json:"bucket,omitempty"`
+	Allocated  domain.Money        `json:"allocated"`
+	Rollover   domain.Money        `json:"rollover,omitzero"` // envelope: unspent amount carried from earlier months
+	Spent      domain.Money        `json:"spent"`
+	Remaining  domain.Money        `json:"remaining"`
+}
+
+// CategorySpending is spending in a period by category ID, plus the total including uncategorized
+type CategorySpending struct {
+	ByCategory map[string]domain.Money
+	Total      domain.Money
+}
+
+// BudgetPeriod is what a strategy sees of a report period. Monthly amounts (budgets, income)
+// are multiplied by Share to get the amount for the period.
+type BudgetPeriod struct {
+	Start          time.Time
+	End            time.Time
+	Share          float64
+	Income         domain.Money // planned monthly income
+	RecordedIncome domain.Money // income received in the period, used when no monthly income is planned
+	Budgets        []*domain.Budget
+	Spending       CategorySpending
+	// MonthSpending loads spending for the calendar month starting at month
+	MonthSpending func(ctx context.Context, month time.Time) (CategorySpending, error)
+}
+
+// BudgetStrategy computes allocations, warnings and rollovers for one budgeting style
+type BudgetStrategy interface {
+	Style() domain.BudgetingStyle
+	Plan(ctx context.Context, period BudgetPeriod) (*BudgetPlan, error)
+}
+
+// BudgetStrategyFor returns the strategy for a style; unknown styles are treated as flexible
+func BudgetStrategyFor(style domain.BudgetingStyle) BudgetStrategy {
+	switch style {
+	case domain.BudgetingStyleEnvelope:
+		return envelopeStrategy{}
+	case domain.BudgetingStyleZeroBased:
+		return zeroBasedStrategy{}
+	case domain.BudgetingStyleFiftyThirtyTwenty:
+		return fiftyThirtyTwentyStrategy{}
+	default:
+		return flexibleStrategy{}
+	}
+}
+
+// flexibleStrategy treats each budget as an independent limit for the period
+type flexibleStrategy struct{}
+
+func (flexibleStrategy) Style() domain.BudgetingStyle { return domain.BudgetingStyleFlexible }
+
+func (s flexibleStrategy) Plan(_ context.Context, period BudgetPeriod) (*BudgetPlan, error) {
+	plan := newBudgetPlan(s.Style(), period)
+	over := 0
+	for _, budget := range period.Budgets {
+		line := budgetAllocation(budget, period.Share, period.Spending)
+		plan.Allocations = append(plan.Allocations, line)
+		if line.Remaining.IsNegative() {
+			over++
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s is over budget by %s", line.Name, line.Remaining.Neg()))
+		}
+	}
+
+	switch {
+	case len(period.Budgets) == 0:
+		plan.Summary = "No budgets set."
+	case over == 0:
+		plan.Summary = fmt.Sprintf("All %d budgets are within their limits.", len(period.Budgets))
+	default:
+		plan.Summary = fmt.Sprintf("%d of %d budgets are over their limits.", over, len(period.Budgets))
+	}
+	return plan, nil
+}
+
+// envelopeStrategy carries what is left in each budget at the end of a month into the next
+// one. Overspending empties the envelope but does not borrow from the next month. The carried
+// amount is available in full to any period starting in that month.
+type envelopeStrategy struct{}
+
+func (envelopeStrategy) Style() domain.BudgetingStyle { return domain.BudgetingStyleEnvelope }
+
+func (s envelopeStrategy) Plan(ctx context.Context, period BudgetPeriod) (*BudgetPlan, error) {
+	plan := newBudgetPlan(s.Style(), period)
+	rollovers, err := envelopeRollovers(ctx, period)
+	if err != nil {
+		return nil, err
+	}
+
+	totalRollover, overdrawn := domain.Money{}, 0
+	for _, budget := range period.Budgets {
+		line := budgetAllocation(budget, period.Share, period.Spending)
+		line.Rollover = rollovers[budget.ID]
+		line.Remaining = line.Allocated.Add(line.Rollover).Sub(line.Spent)
+		totalRollover = totalRollover.Add(line.Rollover)
+		plan.Allocations = append(plan.Allocations, line)
+		if line.Remaining.IsNegative() {
+			overdrawn++
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s envelope is overdrawn by %s", line.Name, line.Remaining.Neg()))
+		}
+	}
+
+	if len(period.Budgets) == 0 {
+		plan.Summary = "No envelopes set; create budgets to fill them each month."
+		return plan, nil
+	}
+	plan.Summary = fmt.Sprintf("%s was carried over from earlier months; %d of %d envelopes are overdrawn.",
+		totalRollover, overdrawn, len(period.Budgets))
+	return plan, nil
+}
+
+// envelopeRollovers returns, by budget ID, the amount carried into the month of period.Start
+func envelopeRollovers(ctx context.Context, period BudgetPeriod) (map[string]domain.Money, error) {
+	current := time.Date(period.Start.Year(), period.Start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	first := current.AddDate(0, -maxEnvelopeRolloverMonths, 0)
+	earliest := current
+	for _, budget := range period.Budgets {
+		created := time.Date(budget.CreatedAt.Year(), budget.CreatedAt.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if created.Before(earliest) {
+			earliest = created
+		}
+	}
+	if earliest.Before(first) {
+		earliest = first
+	}
+
+	carry := make(map[string]domain.Money, len(period.Budgets))
+	if period.MonthSpending == nil {
+		return carry, nil
+	}
+	for month := earliest; month.Before(current); month = month.AddDate(0, 1, 0) {
+		spending, err := period.MonthSpending(ctx, month)
+		if err != nil {
+			return nil, err
+		}
+		for _, budget := range period.Budgets {
+			if budget.CreatedAt.After(month.AddDate(0, 1, 0)) {
+				continue // the envelope did not exist yet
+			}
+			left := carry[budget.ID].Add(budget.Amount).Sub(spentFor(budget, spending))
+			if left.IsNegative() {
+				left = domain.Money{}
+			}
+			carry[budget.ID] = left
+		}
+	}
+	return carry, nil
+}
+
+// zeroBasedStrategy expects every unit of planned income to be assigned to a category budget
+type zeroBasedStrategy struct{}
+
+func (zeroBasedStrategy) Style() domain.BudgetingStyle { return domain.BudgetingStyleZeroBased }
+
+func (s zeroBasedStrategy) Plan(_ context.Context, period BudgetPeriod) (*BudgetPlan, error) {
+	plan := newBudgetPlan(s.Style(), period)
+	var assigned, budgetedSpending domain.Money
+	for _, budget := range period.Budgets {
+		line := budgetAllocation(budget, period.Share, period.Spending)
+		plan.Allocations = append(plan.Allocations, line)
+		if budget.CategoryID == nil {
+			continue // the overall budget is a cap, not an assignment of income
+		}
+		assigned = assigned.Add(line.Allocated)
+		budgetedSpending = budgetedSpending.Add(line.Spent)
+		if line.Remaining.IsNegative() {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s is over budget by %s", line.Name, line.Remaining.Neg()))
+		}
+	}
+
+	unallocated := plan.Income.Sub(assigned)
+	plan.Unallocated = &unallocated
+	if unbudgeted := period.Spending.Total.Sub(budgetedSpending); unbudgeted.IsPositive() {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s was spent in categories without a budget", unbudgeted))
+	}
+
+	switch {
+	case !plan.Income.IsPositive():
+		plan.Warnings = append(plan.Warnings, "no income is planned or recorded; zero-based budgeting assigns all of it to budgets")
+		plan.Summary = "Set your monthly income or record income to plan a zero-based budget."
+	case unallocated.IsPositive():
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s of income is not assigned to a budget", unallocated))
+		plan.Summary = fmt.Sprintf("%s of %s income is still unassigned.", unallocated, plan.Income)
+	case unallocated.IsNegative():
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("budgets exceed income by %s", unallocated.Neg()))
+		plan.Summary = fmt.Sprintf("Budgets assign %s more than the %s income.", unallocated.Neg(), plan.Income)
+	default:
+		plan.Summary = "Every unit of income is assigned to a budget."
+	}
+	return plan, nil
+}
+
+// fiftyThirtyTwentyStrategy splits income into 50% needs, 30% wants and 20% savings. Spending
+// is placed in a bucket by its category budget's bucket; anything else counts as wants. What is
+// left of income after needs and wants is what the period saves.
+type fiftyThirtyTwentyStrategy struct{}
+
+func (fiftyThirtyTwentyStrategy) Style() domain.BudgetingStyle {
+	return domain.BudgetingStyleFiftyThirtyTwenty
+}
+
+var fiftyThirtyTwentyShares = []struct {
+	bucket domain.BudgetBucket
+	name   string
+	share  float64
+}{
+	{domain.BudgetBucketNeeds, "Needs", 0.5},
+	{domain.BudgetBucketWants, "Wants", 0.3},
+	{domain.BudgetBucketSavings, "Savings", 0.2},
+}
+
+func (s fiftyThirtyTwentyStrategy) Plan(_ context.Context, period BudgetPeriod) (*BudgetPlan, error) {
+	plan := newBudgetPlan(s.Style(), period)
+
+	spent := map[domain.BudgetBucket]domain.Money{}
+	var mapped domain.Money
+	for _, budget := range period.Budgets {
+		if budget.CategoryID == nil || budget.Bucket == "" {
+			continue
+		}
+		amount := period.Spending.ByCategory[*budget.CategoryID]
+		spent[budget.Bucket] = spent[budget.Bucket].Add(amount)
+		mapped = mapped.Add(amount)
+	}
+	spent[domain.BudgetBucketWants] = spent[domain.BudgetBucketWants].Add(period.Spending.Total.Sub(mapped))
+
+	for _, item := range fiftyThirtyTwentyShares {
+		target := plan.Income.Mul(item.share)
+		plan.Allocations = append(plan.Allocations, BudgetAllocation{
+			Name:      item.name,
+			Bucket:    item.bucket,
+			Allocated: target,
+			Spent:     spent[item.bucket],
+			Remaining: target.Sub(spent[item.bucket]),
+		})
+	}
+
+	if !plan.Income.IsPositive() {
+		plan.Warnings = append(plan.Warnings, "no income is planned or recorded; the 50/30/20 split is a share of it")
+		plan.Summary = "Set your monthly income or record income to compare spending with the 50/30/20 split."
+		return plan, nil
+	}
+
+	needs := spent[domain.BudgetBucketNeeds].Float64() / plan.Income.Float64() * 100
+	wants := spent[domain.BudgetBucketWants].Float64() / plan.Income.Float64() * 100
+	saved := 100 - needs - wants
+	if needs > 50 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("needs take %.1f%% of income, above the 50%% target", needs))
+	}
+	if wants > 30 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("wants take %.1f%% of income, above the 30%% target", wants))
+	}
+	if saved < 20 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("%.1f%% of income is left for savings, below the 20%% target", saved))
+	}
+	plan.Summary = fmt.Sprintf("Needs %.1f%%, wants %.1f%%, savings %.1f%% of income.", needs, wants, saved)
+	return plan, nil
+}
+
+func newBudgetPlan(style domain.BudgetingStyle, period BudgetPeriod) *BudgetPlan {
+	income := period.Income.Mul(period.Share)
+	if income.IsZero() {
+		income = period.RecordedIncome
+	}
+	return &BudgetPlan{
+		Style:       style,
+		Income:      income,
+		Allocations: make([]BudgetAllocation, 0, len(period.Budgets)),
+		Warnings:    make([]string, 0),
+	}
+}
+
+// budgetAllocation is the prorated budget line for the period without any rollover
+func budgetAllocation(budget *domain.Budget, share float64, spending CategorySpending) BudgetAllocation {
+	name := budget.CategoryName
+	if budget.CategoryID == nil {
+		name = "Overall"
+	}
+	allocated := budget.Amount.Mul(share)
+	spent := spentFor(budget, spending)
+	return BudgetAllocation{
+		Name:       name,
+		CategoryID: budget.CategoryID,
+		Bucket:     budget.Bucket,
+		Allocated:  allocated,
+		Spent:      spent,
+		Remaining:  allocated.Sub(spent),
+	}
+}
+
+func spentFor(budget *domain.Budget, spending CategorySpending) domain.Money {
+	if budget.CategoryID == nil {
+		return spending.Total
+	}
+	return spending.ByCategory[*budget.CategoryID]
+}
@@ -0,0 +1,154 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+)
+
+var (
+	ErrInvalidBudgetAmount    = errors.New("amount must be positive")
+	ErrBudgetExists           = errors.New("a budget already exists for this category")
+	ErrBudgetNotFound         = errors.New("budget not found")
+	ErrBudgetCategoryNotFound = errors.New("category not found")
+	ErrInvalidBudgetBucket    = errors.New("bucket must be one of needs, wants, savings")
+	ErrOverallBudgetBucket    = errors.New("bucket can only be set on a category budget")
+)
+
+// BudgetUseCase manages monthly budgets per category and overall
+type BudgetUseCase struct {
+	repo         repository.BudgetRepository
+	categoryRepo repository.CategoryRepository
+	userRepo     repository.UserRepository
+}
+
+// NewBudgetUseCase creates a budget usecase; userRepo supplies the default currency budgets are in
+func NewBudgetUseCase(repo repository.BudgetRepository, categoryRepo repository.CategoryRepository, userRepo repository.UserRepository) *BudgetUseCase {
+	return &BudgetUseCase{repo: repo, categoryRepo: categoryRepo, userRepo: userRepo}
+}
+
+// Create sets a monthly limit for a category visible to the user, or an overall limit when
+// categoryID is nil. The budget is the user's own, or the shared ledger's when ledgerID is not
+// nil. Each category (and the overall budget) can only have one budget. bucket is optional and
+// only allowed on category budgets. The amount is in the creator's default currency.
+func (u *BudgetUseCase) Create(ctx context.Context, userID string, ledgerID, categoryID *string, amount domain.Money, bucket domain.BudgetBucket) (*domain.Budget, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	if !amount.IsPositive() {
+		return nil, ErrInvalidBudgetAmount
+	}
+	if err := validateBudgetBucket(categoryID, bucket); err != nil {
+		return nil, err
+	}
+	if err := checkPrecision(ctx, u.userRepo, userID, "", amount); err != nil {
+		return nil, err
+	}
+
+	budget := &domain.Budget{UserID: userID, LedgerID: ledgerID, CategoryID: categoryID, Amount: amount, Bucket: bucket}
+	if categoryID != nil {
+		category, err := u.categoryRepo.GetByID(ctx, *categoryID, &userID)
+		if err != nil {
+			return nil, err
+		}
+		if category == nil {
+			return nil, ErrBudgetCategoryNotFound
+		}
+		budget.CategoryName = category.Name
+	}
+
+	existing, err := u.repo.GetByCategory(ctx, userID, ledgerID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrBudgetExists
+	}
+
+	if err := u.repo.Create(ctx, budget); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// List returns the user's own budgets, or the ledger's when ledgerID is not nil, overall budget first
+func (u *BudgetUseCase) List(ctx context.Context, userID string, ledgerID *string) ([]*domain.Budget, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	if ledgerID != nil {
+		return u.repo.ListByLedger(ctx, *ledgerID)
+	}
+	return u.repo.ListByUser(ctx, userID)
+}
+
+// GetByID returns one of the user's budgets or a budget of one of the user's ledgers
+func (u *BudgetUseCase) GetByID(ctx context.Context, userID, id string) (*domain.Budget, error) {
+	budget, err := u.repo.GetByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil {
+		return nil, ErrBudgetNotFound
+	}
+	return budget, nil
+}
+
+// Update changes a budget's monthly limit, and its bucket when bucket is not nil (empty clears it)
+func (u *BudgetUseCase) Update(ctx context.Context, userID, id string, amount domain.Money, bucket *domain.BudgetBucket) (*domain.Budget, error) {
+	if !amount.IsPositive() {
+		return nil, ErrInvalidBudgetAmount
+	}
+	budget, err := u.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	// A ledger budget is in the default currency of the member who created it
+	if err := checkPrecision(ctx, u.userRepo, budget.UserID, "", amount); err != nil {
+		return nil, err
+	}
+	if bucket != nil {
+		if err := validateBudgetBucket(budget.CategoryID, *bucket); err != nil {
+			return nil, err
+		}
+		budget.Bucket = *bucket
+	}
+	budget.Amount = amount
+	if err := u.repo.Update(ctx, budget, userID); err != nil {
+		return nil, budgetWriteError(err)
+	}
+	return budget, nil
+}
+
+// Delete removes one of the user's budgets or a budget of a ledger the user can write to
+func (u *BudgetUseCase) Delete(ctx context.Context, userID, id string) error {
+	if _, err := u.GetByID(ctx, userID, id); err != nil {
+		return err
+	}
+	return budgetWriteError(u.repo.Delete(ctx, id, userID))
+}
+
+// budgetWriteError reports a budget the user can read but not change (only ledger viewers get
+// that far) as ErrLedgerReadOnly
+func budgetWriteError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrLedgerReadOnly
+	}
+	return err
+}
+
+func validateBudgetBucket(categoryID *string, bucket domain.BudgetBucket) error {
+	if bucket == "" {
+		return nil
+	}
+	if !domain.ValidBudgetBucket(bucket) {
+		return ErrInvalidBudgetBucket
+	}
+	if categoryID == nil {
+		return ErrOverallBudgetBucket
+	}
+	return nil
+}
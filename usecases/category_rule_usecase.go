@@ -0,0 +1,272 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+)
+
+var (
+	ErrCategoryRuleNotFound         = errors.New("category rule not found")
+	ErrCategoryRuleCategoryNotFound = errors.New("category not found")
+	ErrCategoryRuleNoCondition      = errors.New("a rule needs at least one of note_contains, note_pattern, min_amount, max_amount, weekdays")
+	ErrInvalidCategoryRulePattern   = errors.New("note_pattern is not a valid regular expression")
+	ErrInvalidCategoryRuleAmounts   = errors.New("min_amount and max_amount must be positive and min_amount at most max_amount")
+	ErrInvalidCategoryRuleWeekday   = errors.New("weekdays must be between 0 (Sunday) and 6 (Saturday)")
+)
+
+// categoryRulePageSize is how many existing expenses are read per query when applying rules
+const categoryRulePageSize = 500
+
+// Categorizer files new expenses that have no category
+type Categorizer interface {
+	// Categorize sets the category of each input without one that a rule of the user (or of
+	// ledgerID when set) matches
+	Categorize(ctx context.Context, userID string, ledgerID *string, inputs []*domain.CreateExpenseInput) error
+}
+
+// CategoryRuleUseCase manages the rules that categorize expenses and applies them
+type CategoryRuleUseCase struct {
+	repo         repository.CategoryRuleRepository
+	categoryRepo repository.CategoryRepository
+	expenseRepo  repository.ExpenseRepository
+}
+
+// NewCategoryRuleUseCase creates a category rule usecase; expenseRepo lists the expenses Apply recategorizes
+func NewCategoryRuleUseCase(repo repository.CategoryRuleRepository, categoryRepo repository.CategoryRepository, expenseRepo repository.ExpenseRepository) *CategoryRuleUseCase {
+	return &CategoryRuleUseCase{repo: repo, categoryRepo: categoryRepo, expenseRepo: expenseRepo}
+}
+
+// Create adds a rule of the user, or of the shared ledger when ledgerID is not nil. Its category
+// must be a global one or one of the same owner.
+func (u *CategoryRuleUseCase) Create(ctx context.Context, userID string, ledgerID *string, input domain.CategoryRuleInput) (*domain.CategoryRule, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	rule := &domain.CategoryRule{UserID: userID, LedgerID: ledgerID}
+	if err := u.fill(ctx, userID, rule, input); err != nil {
+		return nil, err
+	}
+	if err := u.repo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// List returns the user's own rules, or the ledger's when ledgerID is not nil, in the order they are tried
+func (u *CategoryRuleUseCase) List(ctx context.Context, userID string, ledgerID *string) ([]*domain.CategoryRule, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	return u.rules(ctx, userID, ledgerID)
+}
+
+// GetByID returns one of the user's rules or a rule of one of the user's ledgers
+func (u *CategoryRuleUseCase) GetByID(ctx context.Context, userID, id string) (*domain.CategoryRule, error) {
+	rule, err := u.repo.GetByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrCategoryRuleNotFound
+	}
+	return rule, nil
+}
+
+// Update replaces the category, conditions and priority of a rule; it keeps its owner
+func (u *CategoryRuleUseCase) Update(ctx context.Context, userID, id string, input domain.CategoryRuleInput) (*domain.CategoryRule, error) {
+	rule, err := u.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.fill(ctx, userID, rule, input); err != nil {
+		return nil, err
+	}
+	if err := u.repo.Update(ctx, rule, userID); err != nil {
+		return nil, categoryRuleWriteError(err)
+	}
+	return rule, nil
+}
+
+// Delete removes one of the user's rules or a rule of a ledger the user can write to
+func (u *CategoryRuleUseCase) Delete(ctx context.Context, userID, id string) error {
+	if _, err := u.GetByID(ctx, userID, id); err != nil {
+		return err
+	}
+	return categoryRuleWriteError(u.repo.Delete(ctx, id, userID))
+}
+
+// Categorize implements Categorizer; inputs of another ledger than ledgerID are left alone
+func (u *CategoryRuleUseCase) Categorize(ctx context.Context, userID string, ledgerID *string, inputs []*domain.CreateExpenseInput) error {
+	pending := make([]*domain.CreateExpenseInput, 0, len(inputs))
+	for _, input := range inputs {
+		if input.CategoryID == nil && sameLedger(input.LedgerID, ledgerID) {
+			pending = append(pending, input)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	rules, err := u.rules(ctx, userID, ledgerID)
+	if err != nil {
+		return err
+	}
+	for _, input := range pending {
+		if rule := firstMatch(rules, input.Amount, input.Note, input.ExpenseDate); rule != nil {
+			categoryID := rule.CategoryID
+			input.CategoryID = &categoryID
+		}
+	}
+	return nil
+}
+
+// Apply runs the user's rules (or the ledger's when ledgerID is not nil) over existing expenses
+// and files each one a rule matches under that rule's category, in one transaction. Expenses that
+// already have a category are recategorized too unless options.OnlyUncategorized is set.
+func (u *CategoryRuleUseCase) Apply(ctx context.Context, userID string, ledgerID *string, options domain.ApplyCategoryRulesOptions) (*domain.ApplyCategoryRulesResult, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	if options.FromDate != nil && options.ToDate != nil && options.ToDate.Before(*options.FromDate) {
+		return nil, ErrInvalidDateRange
+	}
+	rules, err := u.rules(ctx, userID, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.ApplyCategoryRulesResult{DryRun: options.DryRun, Changes: []domain.CategoryChange{}}
+	if len(rules) == 0 {
+		return result, nil
+	}
+	filter := domain.ExpenseFilter{
+		UserID:   userID,
+		LedgerID: ledgerID,
+		FromDate: options.FromDate,
+		ToDate:   options.ToDate,
+		Limit:    categoryRulePageSize,
+	}
+	for {
+		expenses, total, err := u.expenseRepo.List(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range expenses {
+			if options.OnlyUncategorized && e.CategoryID != nil {
+				continue
+			}
+			result.Checked++
+			rule := firstMatch(rules, e.Amount, e.Note, e.ExpenseDate)
+			if rule == nil || (e.CategoryID != nil && *e.CategoryID == rule.CategoryID) {
+				continue
+			}
+			result.Changes = append(result.Changes, domain.CategoryChange{
+				ExpenseID:      e.ID,
+				RuleID:         rule.ID,
+				FromCategoryID: e.CategoryID,
+				CategoryID:     rule.CategoryID,
+			})
+		}
+		filter.Offset += len(expenses)
+		if len(expenses) == 0 || filter.Offset >= total {
+			break
+		}
+	}
+
+	result.Changed = len(result.Changes)
+	if options.DryRun || len(result.Changes) == 0 {
+		return result, nil
+	}
+	categories := make(map[string]string, len(result.Changes))
+	for _, change := range result.Changes {
+		categories[change.ExpenseID] = change.CategoryID
+	}
+	if result.Changed, err = u.repo.Recategorize(ctx, userID, categories); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// fill validates input and copies it onto rule, whose owner is already set
+func (u *CategoryRuleUseCase) fill(ctx context.Context, userID string, rule *domain.CategoryRule, input domain.CategoryRuleInput) error {
+	var weekdays []time.Weekday
+	seen := make(map[time.Weekday]bool)
+	for _, day := range input.Weekdays {
+		if day < time.Sunday || day > time.Saturday {
+			return ErrInvalidCategoryRuleWeekday
+		}
+		if !seen[day] {
+			seen[day] = true
+			weekdays = append(weekdays, day)
+		}
+	}
+	sort.Slice(weekdays, func(i, j int) bool { return weekdays[i] < weekdays[j] })
+
+	rule.NoteContains = strings.TrimSpace(input.NoteContains)
+	rule.NotePattern = input.NotePattern
+	rule.MinAmount = input.MinAmount
+	rule.MaxAmount = input.MaxAmount
+	rule.Weekdays = weekdays
+	rule.Priority = input.Priority
+	if !rule.HasCondition() {
+		return ErrCategoryRuleNoCondition
+	}
+	if err := rule.Compile(); err != nil {
+		return ErrInvalidCategoryRulePattern
+	}
+	if (rule.MinAmount != nil && !rule.MinAmount.IsPositive()) || (rule.MaxAmount != nil && !rule.MaxAmount.IsPositive()) ||
+		(rule.MinAmount != nil && rule.MaxAmount != nil && rule.MinAmount.Cmp(*rule.MaxAmount) > 0) {
+		return ErrInvalidCategoryRuleAmounts
+	}
+
+	category, err := u.categoryRepo.GetByID(ctx, input.CategoryID, &userID)
+	if err != nil {
+		return err
+	}
+	if category == nil || (category.UserID != nil && !sameLedger(category.LedgerID, rule.LedgerID)) {
+		return ErrCategoryRuleCategoryNotFound
+	}
+	rule.CategoryID = category.ID
+	rule.CategoryName = category.Name
+	return nil
+}
+
+func (u *CategoryRuleUseCase) rules(ctx context.Context, userID string, ledgerID *string) ([]*domain.CategoryRule, error) {
+	if ledgerID != nil {
+		return u.repo.ListByLedger(ctx, *ledgerID)
+	}
+	return u.repo.ListByUser(ctx, userID)
+}
+
+// firstMatch returns the first of rules, which are in the order they are tried, matching the expense
+func firstMatch(rules []*domain.CategoryRule, amount domain.Money, note string, date time.Time) *domain.CategoryRule {
+	for _, rule := range rules {
+		if rule.Matches(amount, note, date) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// sameLedger reports whether a and b name the same ledger, or both none
+func sameLedger(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// categoryRuleWriteError reports a rule the user can read but not change (only ledger viewers get
+// that far) as ErrLedgerReadOnly
+func categoryRuleWriteError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrLedgerReadOnly
+	}
+	return err
+}
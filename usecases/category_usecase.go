@@ -31,6 +31,11 @@ func (uc *CategoryUseCase) List(ctx context.Context, userID *string, options rep
 	return uc.categoryRepo.List(ctx, userID, options)
 }
 
+// ListByLedger returns global categories and those of the ledger, which userID must be a member of
+func (uc *CategoryUseCase) ListByLedger(ctx context.Context, ledgerID, userID string, options repository.ListOptions) ([]*domain.Category, int, error) {
+	return uc.categoryRepo.ListByLedger(ctx, ledgerID, userID, options)
+}
+
 // Update updates a category (ownership: only own or global when userID nil)
 func (uc *CategoryUseCase) Update(ctx context.Context, id string, userID *string, input domain.UpdateCategoryInput) (*domain.Category, error) {
 	return uc.categoryRepo.Update(ctx, id, userID, input)
@@ -0,0 +1,145 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrContactNameRequired = errors.New("contact name is required")
+	ErrContactExists       = errors.New("a contact with this name already exists")
+	ErrContactNotFound     = errors.New("contact not found")
+	ErrContactHasDebts     = errors.New("contact still has debts")
+)
+
+// ContactUseCase manages the contacts debts are linked to
+type ContactUseCase struct {
+	repo repository.ContactRepository
+}
+
+// NewContactUseCase creates a contact usecase
+func NewContactUseCase(repo repository.ContactRepository) *ContactUseCase {
+	return &ContactUseCase{repo: repo}
+}
+
+// Create adds a contact. Names are trimmed and compared ignoring case, so each person is only
+// added once.
+func (u *ContactUseCase) Create(ctx context.Context, userID, name string, note *string) (*domain.Contact, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	name = domain.NormalizeContactName(name)
+	if name == "" {
+		return nil, ErrContactNameRequired
+	}
+	existing, err := u.repo.GetByName(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrContactExists
+	}
+
+	contact := &domain.Contact{ID: uuid.New().String(), UserID: userID, Name: name, Note: note}
+	if err := u.repo.Create(ctx, contact); err != nil {
+		return nil, err
+	}
+	return contact, nil
+}
+
+// List returns the user's contacts by name
+func (u *ContactUseCase) List(ctx context.Context, userID string) ([]*domain.Contact, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	return u.repo.List(ctx, userID)
+}
+
+// GetByID returns one of the user's contacts
+func (u *ContactUseCase) GetByID(ctx context.Context, userID, id string) (*domain.Contact, error) {
+	contact, err := u.repo.GetByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if contact == nil {
+		return nil, ErrContactNotFound
+	}
+	return contact, nil
+}
+
+// Update renames the contact and replaces its note. The new name is shown on all of its debts.
+func (u *ContactUseCase) Update(ctx context.Context, userID, id, name string, note *string) (*domain.Contact, error) {
+	name = domain.NormalizeContactName(name)
+	if name == "" {
+		return nil, ErrContactNameRequired
+	}
+	contact, err := u.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	other, err := u.repo.GetByName(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if other != nil && other.ID != contact.ID {
+		return nil, ErrContactExists
+	}
+
+	contact.Name = name
+	contact.Note = note
+	if err := u.repo.Update(ctx, contact); err != nil {
+		return nil, err
+	}
+	return contact, nil
+}
+
+// Delete removes a contact that no longer has any debts
+func (u *ContactUseCase) Delete(ctx context.Context, userID, id string) error {
+	if _, err := u.GetByID(ctx, userID, id); err != nil {
+		return err
+	}
+	count, err := u.repo.CountDebts(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrContactHasDebts
+	}
+	return u.repo.Delete(ctx, id, userID)
+}
+
+// Balances returns the contact with what is outstanding with them in each currency
+func (u *ContactUseCase) Balances(ctx context.Context, userID, id string) (*domain.Contact, []domain.ContactBalance, error) {
+	contact, err := u.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	balances, err := u.repo.Balances(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return contact, balances, nil
+}
+
+// findOrCreateContact returns the user's contact with the given name, adding it on first use
+func findOrCreateContact(ctx context.Context, repo repository.ContactRepository, userID, name string) (*domain.Contact, error) {
+	name = domain.NormalizeContactName(name)
+	if name == "" {
+		return nil, ErrContactNameRequired
+	}
+	contact, err := repo.GetByName(ctx, userID, name)
+	if err != nil || contact != nil {
+		return contact, err
+	}
+
+	contact = &domain.Contact{ID: uuid.New().String(), UserID: userID, Name: name}
+	if err := repo.Create(ctx, contact); err != nil {
+		return nil, err
+	}
+	return contact, nil
+}
@@ -18,20 +18,52 @@ var (
 	ErrAmountMustBePositive = errors.New("amount must be positive")
 	ErrDueDateInPast        = errors.New("due date cannot be in the past")
 	ErrDebtAlreadyPaid      = errors.New("debt is already paid")
+	ErrReminderNotFound     = errors.New("reminder not found")
+	ErrRemindAtRequired     = errors.New("remind_at is required")
+	ErrRemindAtInPast       = errors.New("remind_at cannot be in the past")
+	ErrTooManyReminders     = errors.New("a debt can have at most 10 reminders")
+	ErrPaymentExceedsDebt   = errors.New("payment is more than the outstanding balance")
+	ErrPaidDateInFuture     = errors.New("paid_date cannot be in the future")
+	ErrAmountBelowPaid      = errors.New("amount cannot be less than what has already been repaid")
 )
 
+// MaxRemindersPerDebt caps how many reminders can be scheduled for one debt
+const MaxRemindersPerDebt = 10
+
+// dueRemindersBatchSize is how many scheduled reminders one check fires
+const dueRemindersBatchSize = 100
+
 type DebtUsecase struct {
-	repo repository.DebtRepository
-	now  func() time.Time
+	repo         repository.DebtRepository
+	userRepo     repository.UserRepository
+	reminderRepo repository.ReminderRepository
+	paymentRepo  repository.DebtPaymentRepository
+	contactRepo  repository.ContactRepository
+	reminders    ReminderQueue
+	now          func() time.Time
 }
 
-func NewDebtUsecase(repo repository.DebtRepository) *DebtUsecase {
+// NewDebtUsecase creates the debt usecase. userRepo supplies the default currency of debts
+// created without one. paymentRepo may be nil, in which case MarkPaid only flips the status and
+// repayments cannot be recorded; contactRepo may be nil, in which case debts keep their
+// free-text peer name and are not linked to contacts.
+func NewDebtUsecase(repo repository.DebtRepository, userRepo repository.UserRepository, reminderRepo repository.ReminderRepository, paymentRepo repository.DebtPaymentRepository, contactRepo repository.ContactRepository) *DebtUsecase {
 	return &DebtUsecase{
-		repo: repo,
-		now:  time.Now,
+		repo:         repo,
+		userRepo:     userRepo,
+		reminderRepo: reminderRepo,
+		paymentRepo:  paymentRepo,
+		contactRepo:  contactRepo,
+		now:          time.Now,
 	}
 }
 
+// SetReminderQueue makes RunReminderCheck queue notifications for due debts. Without a queue
+// the check only stamps remind_at and sent_at.
+func (u *DebtUsecase) SetReminderQueue(q ReminderQueue) {
+	u.reminders = q
+}
+
 func (u *DebtUsecase) Create(ctx context.Context, debt *domain.Debt) error {
 	if debt == nil {
 		return errors.New("debt is required")
@@ -48,18 +80,28 @@ func (u *DebtUsecase) Create(ctx context.Context, debt *domain.Debt) error {
 	if debt.Type == "" {
 		return ErrDebtTypeRequired
 	}
-	if debt.PeerName == "" {
+	if debt.ContactID == nil && domain.NormalizeContactName(debt.PeerName) == "" {
 		return ErrPeerNameRequired
 	}
-	if debt.Amount <= 0 {
+	if !debt.Amount.IsPositive() {
 		return ErrAmountMustBePositive
 	}
+	debt.Currency = domain.NormalizeCurrency(debt.Currency)
+	if debt.Currency != "" && !domain.ValidCurrency(debt.Currency) {
+		return ErrInvalidCurrency
+	}
+	if err := checkPrecision(ctx, u.userRepo, debt.UserID, debt.Currency, debt.Amount); err != nil {
+		return err
+	}
 	if isDateInPast(debt.DueDate, u.now().UTC()) {
 		return ErrDueDateInPast
 	}
 	if debt.Status == "" {
 		debt.Status = domain.DebtStatusPending
 	}
+	if err := u.linkContact(ctx, debt, debt.UserID); err != nil {
+		return err
+	}
 	debt.CreatedAt = u.now().UTC()
 
 	return u.repo.Create(ctx, debt)
@@ -84,23 +126,68 @@ func (u *DebtUsecase) Update(ctx context.Context, debt *domain.Debt) error {
 	if debt.Type == "" {
 		return ErrDebtTypeRequired
 	}
-	if debt.PeerName == "" {
+	if debt.ContactID == nil && domain.NormalizeContactName(debt.PeerName) == "" {
 		return ErrPeerNameRequired
 	}
-	if debt.Amount <= 0 {
+	if !debt.Amount.IsPositive() {
 		return ErrAmountMustBePositive
 	}
+	if debt.Amount.Cmp(existing.PaidAmount) < 0 {
+		return ErrAmountBelowPaid
+	}
+	debt.Currency = domain.NormalizeCurrency(debt.Currency)
+	if debt.Currency == "" {
+		debt.Currency = existing.Currency
+	} else if !domain.ValidCurrency(debt.Currency) {
+		return ErrInvalidCurrency
+	}
+	if !debt.Amount.FitsCurrency(debt.Currency) {
+		return ErrAmountPrecision
+	}
 	if isDateInPast(debt.DueDate, u.now().UTC()) {
 		return ErrDueDateInPast
 	}
 
 	debt.UserID = existing.UserID
 	debt.Status = existing.Status
+	if existing.PaidAmount.IsPositive() && debt.Amount == existing.PaidAmount {
+		debt.Status = domain.DebtStatusPaid // lowered to what has been repaid
+	}
 	debt.CreatedAt = existing.CreatedAt
+	if err := u.linkContact(ctx, debt, existing.UserID); err != nil {
+		return err
+	}
 
 	return u.repo.Update(ctx, debt)
 }
 
+// linkContact points the debt at the contact given by ContactID or, without one, at the user's
+// contact named PeerName, which is added on first use. PeerName becomes the contact's name.
+func (u *DebtUsecase) linkContact(ctx context.Context, debt *domain.Debt, userID string) error {
+	if u.contactRepo == nil {
+		return nil
+	}
+	if debt.ContactID != nil {
+		contact, err := u.contactRepo.GetByID(ctx, *debt.ContactID, userID)
+		if err != nil {
+			return err
+		}
+		if contact == nil {
+			return ErrContactNotFound
+		}
+		debt.PeerName = contact.Name
+		return nil
+	}
+
+	contact, err := findOrCreateContact(ctx, u.contactRepo, userID, debt.PeerName)
+	if err != nil {
+		return err
+	}
+	debt.ContactID = &contact.ID
+	debt.PeerName = contact.Name
+	return nil
+}
+
 func (u *DebtUsecase) GetByID(ctx context.Context, id string) (*domain.Debt, error) {
 	if id == "" {
 		return nil, ErrDebtIDRequired
@@ -115,6 +202,24 @@ func (u *DebtUsecase) ListByUser(ctx context.Context, userID string, options rep
 	return u.repo.ListByUser(ctx, userID, options)
 }
 
+// ListPageByUser returns the page of options.Limit debts at cursor, soonest due first, with the
+// cursors of the pages around it. The total is 0 when options.SkipTotal is set.
+func (u *DebtUsecase) ListPageByUser(ctx context.Context, userID string, options repository.ListOptions, cursor domain.PageCursor) ([]*domain.Debt, int, domain.PageCursors, error) {
+	if userID == "" {
+		return nil, 0, domain.PageCursors{}, ErrUserIDRequired
+	}
+	limit := options.Limit
+	options.Cursor, options.Limit, options.Offset = &cursor, limit+1, 0
+	debts, total, err := u.repo.ListByUser(ctx, userID, options)
+	if err != nil {
+		return nil, 0, domain.PageCursors{}, err
+	}
+	debts, cursors := cursorPage(debts, cursor, limit, func(d *domain.Debt) domain.PageCursor {
+		return domain.PageCursor{Date: d.DueDate, ID: d.ID}
+	})
+	return debts, total, cursors, nil
+}
+
 func (u *DebtUsecase) ListUpcoming(ctx context.Context, userID string, days int, options repository.ListOptions) ([]*domain.Debt, int, error) {
 	if userID == "" {
 		return nil, 0, ErrUserIDRequired
@@ -137,35 +242,220 @@ func (u *DebtUsecase) MarkPaid(ctx context.Context, id string) (*domain.Debt, er
 		return nil, ErrDebtAlreadyPaid
 	}
 
+	// Record the rest as a payment so reports see the repayment on the day it happened
+	if u.paymentRepo != nil && existing.Balance.IsPositive() {
+		_, debt, err := u.AddPayment(ctx, existing, existing.Balance, time.Time{}, nil)
+		return debt, err
+	}
 	return u.repo.MarkPaid(ctx, id)
 }
 
+// AddPayment records a repayment of the debt dated paidDate (today when zero) and returns it
+// with the updated debt. The debt is marked paid once its balance reaches zero.
+func (u *DebtUsecase) AddPayment(ctx context.Context, debt *domain.Debt, amount domain.Money, paidDate time.Time, note *string) (*domain.DebtPayment, *domain.Debt, error) {
+	if debt == nil || debt.ID == "" {
+		return nil, nil, ErrDebtIDRequired
+	}
+	if u.paymentRepo == nil {
+		return nil, nil, errors.New("debt payments are not configured")
+	}
+	if debt.Status == domain.DebtStatusPaid {
+		return nil, nil, ErrDebtAlreadyPaid
+	}
+	if !amount.IsPositive() {
+		return nil, nil, ErrAmountMustBePositive
+	}
+	if !amount.FitsCurrency(debt.Currency) {
+		return nil, nil, ErrAmountPrecision
+	}
+	if amount.Cmp(debt.Balance) > 0 {
+		return nil, nil, ErrPaymentExceedsDebt
+	}
+	today := u.now().UTC()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if paidDate.IsZero() {
+		paidDate = today
+	}
+	if paidDate.After(today) {
+		return nil, nil, ErrPaidDateInFuture
+	}
+
+	payment := &domain.DebtPayment{
+		ID:       uuid.New().String(),
+		DebtID:   debt.ID,
+		Amount:   amount,
+		PaidDate: paidDate,
+		Note:     note,
+	}
+	updated, err := u.paymentRepo.Create(ctx, payment)
+	if err != nil {
+		return nil, nil, err
+	}
+	if updated == nil {
+		// another payment got in first and the balance no longer covers this one
+		return nil, nil, ErrPaymentExceedsDebt
+	}
+	return payment, updated, nil
+}
+
+// ListPayments returns the debt's repayments, oldest first
+func (u *DebtUsecase) ListPayments(ctx context.Context, debtID string) ([]*domain.DebtPayment, error) {
+	if debtID == "" {
+		return nil, ErrDebtIDRequired
+	}
+	if u.paymentRepo == nil {
+		return []*domain.DebtPayment{}, nil
+	}
+	return u.paymentRepo.ListByDebt(ctx, debtID)
+}
+
 func (u *DebtUsecase) RunOverdueCheck(ctx context.Context) (int64, error) {
 	nowUTC := u.now().UTC().Format("2006-01-02")
 	return u.repo.SetOverdue(ctx, nowUTC)
 }
 
+// RunReminderCheck fires due scheduled reminders, then the default reminders for debts without
+// a schedule of their own. It returns the debts that were reminded.
 func (u *DebtUsecase) RunReminderCheck(ctx context.Context) ([]*domain.Debt, error) {
 	now := u.now().UTC()
 	nowDate := now.Format("2006-01-02")
 	nowTimestamp := now.Format("2006-01-02 15:04:05")
 
-	debts, err := u.repo.GetDueForReminder(ctx, nowDate)
+	var reminded []*domain.Debt
+
+	due, err := u.reminderRepo.ListDue(ctx, now, dueRemindersBatchSize)
 	if err != nil {
 		return nil, err
 	}
+	for _, reminder := range due {
+		debt, err := u.repo.GetByID(ctx, reminder.DebtID)
+		if err != nil {
+			return nil, err
+		}
+		if err := u.remind(ctx, debt, "reminder:"+reminder.ID, nowTimestamp); err != nil {
+			return nil, err
+		}
+		if err := u.reminderRepo.MarkFired(ctx, reminder.ID, now); err != nil {
+			return nil, err
+		}
+		reminded = append(reminded, debt)
+	}
 
+	debts, err := u.repo.GetDueForReminder(ctx, nowDate)
+	if err != nil {
+		return nil, err
+	}
 	for _, debt := range debts {
 		if debt == nil {
 			continue
 		}
-		err = u.repo.UpdateReminder(ctx, debt.ID, nowTimestamp, nowTimestamp)
-		if err != nil {
+		if err := u.remind(ctx, debt, nowDate, nowTimestamp); err != nil {
 			return nil, err
 		}
+		reminded = append(reminded, debt)
 	}
 
-	return debts, nil
+	return reminded, nil
+}
+
+// remind queues a notification for the debt, or just stamps it when no queue is configured.
+// occurrence identifies the reminder so re-runs never queue it twice.
+func (u *DebtUsecase) remind(ctx context.Context, debt *domain.Debt, occurrence, nowTimestamp string) error {
+	if u.reminders == nil {
+		return u.repo.UpdateReminder(ctx, debt.ID, nowTimestamp, nowTimestamp)
+	}
+	// sent_at is stamped by the notification usecase once delivery succeeds
+	if err := u.reminders.EnqueueDebtReminder(ctx, debt, occurrence); err != nil {
+		return err
+	}
+	return u.repo.SetRemindAt(ctx, debt.ID, nowTimestamp)
+}
+
+// ListReminders returns the debt's scheduled reminders
+func (u *DebtUsecase) ListReminders(ctx context.Context, debtID string) ([]*domain.Reminder, error) {
+	if debtID == "" {
+		return nil, ErrDebtIDRequired
+	}
+	return u.reminderRepo.ListByDebt(ctx, debtID)
+}
+
+// CreateReminder schedules a reminder for an unpaid debt. Once a debt has a reminder the
+// default schedule no longer applies to it.
+func (u *DebtUsecase) CreateReminder(ctx context.Context, debt *domain.Debt, remindAt time.Time, enabled bool) (*domain.Reminder, error) {
+	if debt == nil || debt.ID == "" {
+		return nil, ErrDebtIDRequired
+	}
+	if debt.Status == domain.DebtStatusPaid {
+		return nil, ErrDebtAlreadyPaid
+	}
+	if remindAt.IsZero() {
+		return nil, ErrRemindAtRequired
+	}
+	if remindAt.Before(u.now()) {
+		return nil, ErrRemindAtInPast
+	}
+	count, err := u.reminderRepo.CountByDebt(ctx, debt.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxRemindersPerDebt {
+		return nil, ErrTooManyReminders
+	}
+
+	reminder := &domain.Reminder{
+		ID:       uuid.New().String(),
+		DebtID:   debt.ID,
+		RemindAt: remindAt.UTC(),
+		Enabled:  enabled,
+	}
+	if err := u.reminderRepo.Create(ctx, reminder); err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
+
+// UpdateReminder changes a reminder's time and/or enabled flag. Moving a reminder that already
+// fired schedules it again.
+func (u *DebtUsecase) UpdateReminder(ctx context.Context, debtID, id string, remindAt *time.Time, enabled *bool) (*domain.Reminder, error) {
+	reminder, err := u.getReminder(ctx, debtID, id)
+	if err != nil {
+		return nil, err
+	}
+	if remindAt != nil {
+		if remindAt.Before(u.now()) {
+			return nil, ErrRemindAtInPast
+		}
+		if !remindAt.Equal(reminder.RemindAt) {
+			reminder.RemindAt = remindAt.UTC()
+			reminder.FiredAt = nil
+		}
+	}
+	if enabled != nil {
+		reminder.Enabled = *enabled
+	}
+	if err := u.reminderRepo.Update(ctx, reminder); err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
+
+// DeleteReminder removes a reminder from the debt's schedule
+func (u *DebtUsecase) DeleteReminder(ctx context.Context, debtID, id string) error {
+	if _, err := u.getReminder(ctx, debtID, id); err != nil {
+		return err
+	}
+	return u.reminderRepo.Delete(ctx, id)
+}
+
+func (u *DebtUsecase) getReminder(ctx context.Context, debtID, id string) (*domain.Reminder, error) {
+	reminder, err := u.reminderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if reminder == nil || reminder.DebtID != debtID {
+		return nil, ErrReminderNotFound
+	}
+	return reminder, nil
 }
 
 func isDateInPast(date time.Time, nowUTC time.Time) bool {
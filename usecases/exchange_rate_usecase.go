@@ -0,0 +1,210 @@
+package usecases
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCurrency      = errors.New("currency must be a 3-letter ISO 4217 code")
+	ErrAmountPrecision      = errors.New("amount has more decimal places than its currency allows")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrInvalidExchangeRate  = errors.New("invalid exchange rate")
+	ErrNoExchangeRates      = errors.New("no exchange rates to import")
+	ErrTooManyExchangeRates = errors.New("at most 10000 exchange rates can be imported at once")
+)
+
+// maxExchangeRateImport caps how many rates one import may contain
+const maxExchangeRateImport = 10000
+
+// Exchange rate file formats accepted by ParseExchangeRates
+const (
+	ExchangeRateFormatCSV  = "csv"
+	ExchangeRateFormatJSON = "json"
+)
+
+// ExchangeRateUseCase loads and lists the exchange rates report totals are converted with
+type ExchangeRateUseCase struct {
+	repo repository.ExchangeRateRepository
+}
+
+// NewExchangeRateUseCase creates a new exchange rate use case
+func NewExchangeRateUseCase(repo repository.ExchangeRateRepository) *ExchangeRateUseCase {
+	return &ExchangeRateUseCase{repo: repo}
+}
+
+// Import validates the rates and stores them, replacing rates already stored for the same pair
+// and date. Nothing is stored when any rate is invalid.
+func (uc *ExchangeRateUseCase) Import(ctx context.Context, rates []domain.ExchangeRate) (int, error) {
+	if len(rates) == 0 {
+		return 0, ErrNoExchangeRates
+	}
+	if len(rates) > maxExchangeRateImport {
+		return 0, ErrTooManyExchangeRates
+	}
+	for i := range rates {
+		rate := &rates[i]
+		rate.Base = domain.NormalizeCurrency(rate.Base)
+		rate.Quote = domain.NormalizeCurrency(rate.Quote)
+		if !domain.ValidCurrency(rate.Base) || !domain.ValidCurrency(rate.Quote) {
+			return 0, fmt.Errorf("%w %d: %w", ErrInvalidExchangeRate, i+1, ErrInvalidCurrency)
+		}
+		if rate.Base == rate.Quote {
+			return 0, fmt.Errorf("%w %d: base and quote must differ", ErrInvalidExchangeRate, i+1)
+		}
+		if rate.Rate <= 0 {
+			return 0, fmt.Errorf("%w %d: rate must be positive", ErrInvalidExchangeRate, i+1)
+		}
+		if rate.Date.IsZero() {
+			return 0, fmt.Errorf("%w %d: date is required", ErrInvalidExchangeRate, i+1)
+		}
+	}
+	return uc.repo.Upsert(ctx, rates)
+}
+
+// LoadFile imports the rates in a local .csv or .json file
+func (uc *ExchangeRateUseCase) LoadFile(ctx context.Context, path string) (int, error) {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	rates, err := ParseExchangeRates(f, format)
+	if err != nil {
+		return 0, err
+	}
+	return uc.Import(ctx, rates)
+}
+
+// List returns stored rates, newest first
+func (uc *ExchangeRateUseCase) List(ctx context.Context, filter domain.ExchangeRateFilter) ([]*domain.ExchangeRate, int, error) {
+	filter.Base = domain.NormalizeCurrency(filter.Base)
+	filter.Quote = domain.NormalizeCurrency(filter.Quote)
+	if (filter.Base != "" && !domain.ValidCurrency(filter.Base)) || (filter.Quote != "" && !domain.ValidCurrency(filter.Quote)) {
+		return nil, 0, ErrInvalidCurrency
+	}
+	return uc.repo.List(ctx, filter)
+}
+
+// exchangeRateRecord is one rate in an import file
+type exchangeRateRecord struct {
+	Date  string  `json:"date"` // YYYY-MM-DD
+	Base  string  `json:"base"`
+	Quote string  `json:"quote"`
+	Rate  float64 `json:"rate"`
+}
+
+// ParseExchangeRates reads rates in CSV (a header row with date, base, quote and rate columns in
+// any order) or JSON (an array of {"date", "base", "quote", "rate"} objects)
+func ParseExchangeRates(r io.Reader, format string) ([]domain.ExchangeRate, error) {
+	var records []exchangeRateRecord
+	switch format {
+	case ExchangeRateFormatJSON:
+		if err := json.NewDecoder(r).Decode(&records); err != nil {
+			return nil, fmt.Errorf("invalid exchange rate JSON: %w", err)
+		}
+	case ExchangeRateFormatCSV:
+		var err error
+		if records, err = parseExchangeRateCSV(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported exchange rate format %q (use csv or json)", format)
+	}
+
+	rates := make([]domain.ExchangeRate, 0, len(records))
+	for i, rec := range records {
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(rec.Date))
+		if err != nil {
+			return nil, fmt.Errorf("%w %d: date must use YYYY-MM-DD", ErrInvalidExchangeRate, i+1)
+		}
+		rates = append(rates, domain.ExchangeRate{Base: rec.Base, Quote: rec.Quote, Rate: rec.Rate, Date: date})
+	}
+	return rates, nil
+}
+
+func parseExchangeRateCSV(r io.Reader) ([]exchangeRateRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid exchange rate CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "base", "quote", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("exchange rate CSV is missing the %q column", name)
+		}
+	}
+
+	records := make([]exchangeRateRecord, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		rate, err := strconv.ParseFloat(strings.TrimSpace(row[columns["rate"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w %d: rate must be a number", ErrInvalidExchangeRate, i+1)
+		}
+		records = append(records, exchangeRateRecord{
+			Date:  row[columns["date"]],
+			Base:  row[columns["base"]],
+			Quote: row[columns["quote"]],
+			Rate:  rate,
+		})
+	}
+	return records, nil
+}
+
+// storedCurrency returns the currency a record is stored in: currency itself, or when it is
+// empty the user's default currency, which the repositories fill in
+func storedCurrency(ctx context.Context, users repository.UserRepository, userID, currency string) (string, error) {
+	if currency != "" {
+		return currency, nil
+	}
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return "", ErrUserIDRequired
+	}
+	user, err := users.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", ErrUserNotFound
+	}
+	return user.DefaultCurrency, nil
+}
+
+// checkPrecision returns ErrAmountPrecision when amount has more decimal places than the
+// currency it is stored in allows (see storedCurrency)
+func checkPrecision(ctx context.Context, users repository.UserRepository, userID, currency string, amount domain.Money) error {
+	currency, err := storedCurrency(ctx, users, userID, currency)
+	if err != nil {
+		return err
+	}
+	if !amount.FitsCurrency(currency) {
+		return ErrAmountPrecision
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidSplit is returned, wrapped with the reason, when a split definition cannot be applied
+var ErrInvalidSplit = errors.New("invalid split")
+
+// maxSplitParticipants caps how many people one expense can be split among
+const maxSplitParticipants = 50
+
+// splitDueDays is how long after today the debts of a split are due when no due date is given
+const splitDueDays = 30
+
+// SetSplitRepository enables CreateSplit. contactRepo resolves participants given by contact_id.
+func (uc *ExpenseUseCase) SetSplitRepository(splitRepo repository.ExpenseSplitRepository, contactRepo repository.ContactRepository) {
+	uc.splitRepo = splitRepo
+	uc.contactRepo = contactRepo
+}
+
+// CreateSplit records an expense the user paid for and shared. input.Amount is the total: the
+// user's share becomes the expense and each other participant's share a lent debt linked to the
+// expense and to the participant's contact. Category rules see the total. Nothing is stored when
+// any part fails.
+func (uc *ExpenseUseCase) CreateSplit(ctx context.Context, input domain.CreateExpenseInput, split domain.Split) (*domain.Expense, []*domain.Debt, error) {
+	if uc.splitRepo == nil {
+		return nil, nil, errors.New("expense splits are not configured")
+	}
+	if input.IsRecurring {
+		return nil, nil, fmt.Errorf("%w: a recurring expense cannot be split", ErrInvalidSplit)
+	}
+	currency, err := storedCurrency(ctx, uc.userRepo, input.UserID, input.Currency)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !input.Amount.FitsCurrency(currency) {
+		return nil, nil, ErrAmountPrecision
+	}
+	amounts, self, err := splitAmounts(input.Amount, split, domain.CurrencyExponent(currency))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := uc.categorize(ctx, &input); err != nil {
+		return nil, nil, err
+	}
+
+	today := uc.now().UTC()
+	dueDate := split.DueDate
+	if dueDate.IsZero() {
+		dueDate = time.Date(today.Year(), today.Month(), today.Day()+splitDueDays, 0, 0, 0, 0, time.UTC)
+	}
+	if isDateInPast(dueDate, today) {
+		return nil, nil, ErrDueDateInPast
+	}
+	if input.ID == "" {
+		input.ID = uuid.New().String()
+	}
+
+	var note *string
+	if input.Note != "" {
+		note = &input.Note
+	}
+	debts := make([]*domain.Debt, 0, len(split.Participants)-1)
+	for i, p := range split.Participants {
+		if i == self || !amounts[i].IsPositive() {
+			continue
+		}
+		debt := &domain.Debt{
+			ID:       uuid.New().String(),
+			UserID:   input.UserID,
+			Type:     "lent",
+			Amount:   amounts[i],
+			DueDate:  dueDate,
+			Status:   domain.DebtStatusPending,
+			Note:     note,
+			PeerName: domain.NormalizeContactName(p.Name),
+		}
+		if p.ContactID != nil {
+			if uc.contactRepo == nil {
+				return nil, nil, ErrContactNotFound
+			}
+			contact, err := uc.contactRepo.GetByID(ctx, *p.ContactID, input.UserID)
+			if err != nil {
+				return nil, nil, err
+			}
+			if contact == nil {
+				return nil, nil, ErrContactNotFound
+			}
+			debt.ContactID = &contact.ID
+			debt.PeerName = contact.Name
+		}
+		debts = append(debts, debt)
+	}
+
+	input.Amount = amounts[self]
+	expense, err := uc.splitRepo.CreateSplit(ctx, input, debts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return expense, debts, nil
+}
+
+// splitAmounts divides total among the split's participants, to places decimal places (the
+// currency's minor unit) and adding up to total, and returns the index of the user's own share
+func splitAmounts(total domain.Money, split domain.Split, places int) ([]domain.Money, int, error) {
+	if !domain.ValidSplitMethod(split.Method) {
+		return nil, 0, fmt.Errorf("%w: method must be one of equal, exact, percentage, shares", ErrInvalidSplit)
+	}
+	participants := split.Participants
+	if len(participants) < 2 {
+		return nil, 0, fmt.Errorf("%w: at least two participants are needed", ErrInvalidSplit)
+	}
+	if len(participants) > maxSplitParticipants {
+		return nil, 0, fmt.Errorf("%w: at most %d participants are allowed", ErrInvalidSplit, maxSplitParticipants)
+	}
+
+	self := -1
+	seen := map[string]bool{}
+	for i, p := range participants {
+		if p.Self {
+			if self >= 0 {
+				return nil, 0, fmt.Errorf("%w: only one participant can be you", ErrInvalidSplit)
+			}
+			self = i
+			continue
+		}
+		var key string
+		switch {
+		case p.ContactID != nil:
+			key = "id:" + *p.ContactID
+		case domain.NormalizeContactName(p.Name) != "":
+			key = "name:" + strings.ToLower(domain.NormalizeContactName(p.Name))
+		default:
+			return nil, 0, fmt.Errorf("%w: participant %d needs a name or contact_id", ErrInvalidSplit, i+1)
+		}
+		if seen[key] {
+			return nil, 0, fmt.Errorf("%w: participant %d is listed twice", ErrInvalidSplit, i+1)
+		}
+		seen[key] = true
+	}
+	if self < 0 {
+		return nil, 0, fmt.Errorf("%w: one participant must be you (\"self\": true)", ErrInvalidSplit)
+	}
+
+	weights := make([]float64, len(participants))
+	switch split.Method {
+	case domain.SplitEqual:
+		for i := range weights {
+			weights[i] = 1
+		}
+	case domain.SplitShares:
+		var sum float64
+		for i, p := range participants {
+			if p.Shares < 0 || math.IsInf(p.Shares, 0) || math.IsNaN(p.Shares) {
+				return nil, 0, fmt.Errorf("%w: shares cannot be negative", ErrInvalidSplit)
+			}
+			weights[i] = p.Shares
+			sum += p.Shares
+		}
+		if sum == 0 {
+			return nil, 0, fmt.Errorf("%w: at least one participant needs shares", ErrInvalidSplit)
+		}
+	case domain.SplitPercentage:
+		var sum float64
+		for i, p := range participants {
+			if p.Percent < 0 || p.Percent > 100 || math.IsNaN(p.Percent) {
+				return nil, 0, fmt.Errorf("%w: percentages must be between 0 and 100", ErrInvalidSplit)
+			}
+			weights[i] = p.Percent
+			sum += p.Percent
+		}
+		if math.Abs(sum-100) > 1e-9 {
+			return nil, 0, fmt.Errorf("%w: percentages must add up to 100", ErrInvalidSplit)
+		}
+	case domain.SplitExact:
+		amounts := make([]domain.Money, len(participants))
+		var sum domain.Money
+		for i, p := range participants {
+			if p.Amount.IsNegative() {
+				return nil, 0, fmt.Errorf("%w: amounts cannot be negative", ErrInvalidSplit)
+			}
+			amounts[i] = p.Amount
+			sum = sum.Add(p.Amount)
+		}
+		if sum != total {
+			return nil, 0, fmt.Errorf("%w: amounts add up to %s, not the expense amount %s", ErrInvalidSplit, sum, total)
+		}
+		return checkOwnShare(amounts, self)
+	}
+
+	return checkOwnShare(total.Allocate(weights, places), self)
+}
+
+func checkOwnShare(amounts []domain.Money, self int) ([]domain.Money, int, error) {
+	if !amounts[self].IsPositive() {
+		return nil, 0, fmt.Errorf("%w: your share must be positive", ErrInvalidSplit)
+	}
+	return amounts, self, nil
+}
@@ -2,26 +2,81 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"expense_tracker/domain"
 	"expense_tracker/repository"
+	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
+)
+
+// maxRecurringCatchUp caps how many missed occurrences of one template are generated per run
+const maxRecurringCatchUp = 366
+
+// recurringBatchSize is how many due templates one run processes
+const recurringBatchSize = 500
+
+// maxSearchQuery caps how long an expense search query can be
+const maxSearchQuery = 200
+
+// maxSearchCategories caps how many categories one expense search can pick
+const maxSearchCategories = 50
+
+var (
+	ErrSearchQueryTooLong      = fmt.Errorf("q can be at most %d characters", maxSearchQuery)
+	ErrInvalidSearchAmounts    = errors.New("min_amount and max_amount must not be negative and min_amount at most max_amount")
+	ErrInvalidSearchSort       = errors.New("sort must be date or amount")
+	ErrTooManySearchCategories = fmt.Errorf("at most %d category_id values can be given", maxSearchCategories)
 )
 
 // ExpenseUseCase handles expense business logic
 type ExpenseUseCase struct {
 	expenseRepo repository.ExpenseRepository
+	userRepo    repository.UserRepository
+	splitRepo   repository.ExpenseSplitRepository
+	contactRepo repository.ContactRepository
+	categorizer Categorizer
+	now         func() time.Time
+}
+
+// NewExpenseUseCase creates a new expense use case; userRepo supplies the default currency of
+// expenses created without one
+func NewExpenseUseCase(expenseRepo repository.ExpenseRepository, userRepo repository.UserRepository) *ExpenseUseCase {
+	return &ExpenseUseCase{expenseRepo: expenseRepo, userRepo: userRepo, now: time.Now}
 }
 
-// NewExpenseUseCase creates a new expense use case
-func NewExpenseUseCase(expenseRepo repository.ExpenseRepository) *ExpenseUseCase {
-	return &ExpenseUseCase{expenseRepo: expenseRepo}
+// SetCategorizer makes Create and CreateSplit file expenses without a category by the category rules
+func (uc *ExpenseUseCase) SetCategorizer(c Categorizer) {
+	uc.categorizer = c
 }
 
-// Create creates a new expense for the given user (ownership enforced by userID)
+// Create creates a new expense for the given user (ownership enforced by userID).
+// A recurring expense without next_due_date is next due at the rule's first occurrence after expense_date.
 func (uc *ExpenseUseCase) Create(ctx context.Context, input domain.CreateExpenseInput) (*domain.Expense, error) {
+	if err := checkPrecision(ctx, uc.userRepo, input.UserID, input.Currency, input.Amount); err != nil {
+		return nil, err
+	}
+	if err := uc.categorize(ctx, &input); err != nil {
+		return nil, err
+	}
+	if input.IsRecurring {
+		rule := effectiveRule(input.RecurrenceType, input.RecurrenceRule)
+		input.RecurrenceRule = &rule
+		input.RecurrenceType = rule.Frequency
+		input.NextDueDate, input.RecurrenceStart = recurrenceSchedule(rule, input.ExpenseDate, input.NextDueDate)
+	}
 	return uc.expenseRepo.Create(ctx, input)
 }
 
+// categorize sets the category of input from the rules when it has none and a categorizer is set
+func (uc *ExpenseUseCase) categorize(ctx context.Context, input *domain.CreateExpenseInput) error {
+	if uc.categorizer == nil || input.CategoryID != nil {
+		return nil
+	}
+	return uc.categorizer.Categorize(ctx, input.UserID, input.LedgerID, []*domain.CreateExpenseInput{input})
+}
+
 // GetByID returns an expense by ID if it belongs to the user
 func (uc *ExpenseUseCase) GetByID(ctx context.Context, id, userID string) (*domain.Expense, error) {
 	return uc.expenseRepo.GetByID(ctx, id, userID)
@@ -35,16 +90,205 @@ func (uc *ExpenseUseCase) List(ctx context.Context, filter domain.ExpenseFilter)
 	return uc.expenseRepo.List(ctx, filter)
 }
 
-// Update updates an expense; ownership enforced (userID)
+// ListPage returns the page of filter.Limit expenses at cursor, newest first, with the cursors of
+// the pages around it. The total is 0 when filter.SkipTotal is set.
+func (uc *ExpenseUseCase) ListPage(ctx context.Context, filter domain.ExpenseFilter, cursor domain.PageCursor) ([]*domain.Expense, int, domain.PageCursors, error) {
+	if filter.UserID == "" {
+		return nil, 0, domain.PageCursors{}, nil
+	}
+	limit := filter.Limit
+	filter.Cursor, filter.Limit, filter.Offset = &cursor, limit+1, 0
+	items, total, err := uc.expenseRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, domain.PageCursors{}, err
+	}
+	items, cursors := cursorPage(items, cursor, limit, func(e *domain.Expense) domain.PageCursor {
+		return domain.PageCursor{Date: e.ExpenseDate, ID: e.ID}
+	})
+	return items, total, cursors, nil
+}
+
+// Search returns a page of the expenses matching search, how many match and the facet counts
+func (uc *ExpenseUseCase) Search(ctx context.Context, search domain.ExpenseSearch) ([]*domain.Expense, int, *domain.ExpenseFacets, error) {
+	if search.UserID == "" {
+		return nil, 0, nil, ErrUserIDRequired
+	}
+	search.Query = strings.TrimSpace(search.Query)
+	if utf8.RuneCountInString(search.Query) > maxSearchQuery {
+		return nil, 0, nil, ErrSearchQueryTooLong
+	}
+	if (search.MinAmount != nil && search.MinAmount.IsNegative()) || (search.MaxAmount != nil && search.MaxAmount.IsNegative()) ||
+		(search.MinAmount != nil && search.MaxAmount != nil && search.MinAmount.Cmp(*search.MaxAmount) > 0) {
+		return nil, 0, nil, ErrInvalidSearchAmounts
+	}
+	if search.FromDate != nil && search.ToDate != nil && search.ToDate.Before(*search.FromDate) {
+		return nil, 0, nil, ErrInvalidDateRange
+	}
+	if len(search.CategoryIDs) > maxSearchCategories {
+		return nil, 0, nil, ErrTooManySearchCategories
+	}
+	switch search.Sort {
+	case "":
+		search.Sort = domain.ExpenseSortDate
+	case domain.ExpenseSortDate, domain.ExpenseSortAmount:
+	default:
+		return nil, 0, nil, ErrInvalidSearchSort
+	}
+	return uc.expenseRepo.Search(ctx, search)
+}
+
+// Update updates an expense; ownership enforced (userID). Changing the recurrence or next_due_date
+// re-anchors the series at the new next due date. A recurrence_type without recurrence_rule
+// replaces the rule with a simple every-1-unit rule.
 func (uc *ExpenseUseCase) Update(ctx context.Context, id, userID string, input domain.UpdateExpenseInput) (*domain.Expense, error) {
+	if input.Amount != nil || input.Currency != nil {
+		if err := uc.checkUpdatePrecision(ctx, id, userID, input); err != nil {
+			return nil, err
+		}
+	}
+	if input.IsRecurring != nil || input.RecurrenceType != nil || input.RecurrenceRule != nil || input.NextDueDate != nil {
+		existing, err := uc.expenseRepo.GetByID(ctx, id, userID)
+		if err != nil || existing == nil {
+			return nil, err
+		}
+		isRecurring := existing.IsRecurring
+		if input.IsRecurring != nil {
+			isRecurring = *input.IsRecurring
+		}
+		if isRecurring {
+			rule := existing.Rule()
+			if input.RecurrenceRule != nil {
+				rule = *input.RecurrenceRule
+			} else if input.RecurrenceType != nil {
+				rule = domain.RecurrenceRule{Frequency: *input.RecurrenceType}
+			}
+			input.RecurrenceRule = &rule
+			input.RecurrenceType = &rule.Frequency
+			expDate := existing.ExpenseDate
+			if input.ExpenseDate != nil {
+				expDate = *input.ExpenseDate
+			}
+			nextDue := existing.NextDueDate
+			if input.NextDueDate != nil {
+				nextDue = input.NextDueDate
+			}
+			input.NextDueDate, input.RecurrenceStart = recurrenceSchedule(rule, expDate, nextDue)
+		}
+	}
 	return uc.expenseRepo.Update(ctx, id, userID, input)
 }
 
+// checkUpdatePrecision returns ErrAmountPrecision when the amount and currency the expense will
+// have after input do not fit, reading the one input leaves unchanged from the stored expense
+func (uc *ExpenseUseCase) checkUpdatePrecision(ctx context.Context, id, userID string, input domain.UpdateExpenseInput) error {
+	if input.Amount == nil || input.Currency == nil {
+		existing, err := uc.expenseRepo.GetByID(ctx, id, userID)
+		if err != nil || existing == nil {
+			return err
+		}
+		if input.Amount == nil {
+			input.Amount = &existing.Amount
+		}
+		if input.Currency == nil {
+			input.Currency = &existing.Currency
+		}
+	}
+	if !input.Amount.FitsCurrency(*input.Currency) {
+		return ErrAmountPrecision
+	}
+	return nil
+}
+
 // Delete deletes an expense; ownership enforced (userID)
 func (uc *ExpenseUseCase) Delete(ctx context.Context, id, userID string) error {
 	return uc.expenseRepo.Delete(ctx, id, userID)
 }
 
+// RunRecurringExpenses generates the due occurrences of every recurring expense up to today and
+// advances each template's next_due_date. Re-running it never duplicates an occurrence.
+// It returns the number of expenses created.
+func (uc *ExpenseUseCase) RunRecurringExpenses(ctx context.Context) (int64, error) {
+	today := uc.now().UTC()
+	templates, err := uc.expenseRepo.ListRecurringDue(ctx, today.Format("2006-01-02"), recurringBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var created int64
+	for _, template := range templates {
+		if err := ctx.Err(); err != nil {
+			return created, err
+		}
+		dates, nextDue, ok := dueOccurrences(template.Rule(), template.NextDueDate, template.RecurrenceStart, today)
+		if !ok {
+			continue
+		}
+		n, err := uc.expenseRepo.MaterializeOccurrences(ctx, template, dates, nextDue)
+		if err != nil {
+			return created, err
+		}
+		created += int64(n)
+	}
+	return created, nil
+}
+
+// PreviewRecurrence returns the first limit occurrences of rule for a series starting at start
+func (uc *ExpenseUseCase) PreviewRecurrence(rule domain.RecurrenceRule, start time.Time, limit int) []time.Time {
+	return rule.Occurrences(start, start, time.Time{}, limit)
+}
+
+// dueOccurrences lists a recurring template's occurrences from next_due_date up to today and the
+// next_due_date that follows them (nil once the series has ended). ok is false when the
+// template cannot be expanded.
+func dueOccurrences(rule domain.RecurrenceRule, nextDueDate, recurrenceStart *time.Time, today time.Time) ([]time.Time, *time.Time, bool) {
+	if nextDueDate == nil || !domain.ValidRecurrenceType(rule.Frequency) {
+		return nil, nil, false
+	}
+	start := *nextDueDate
+	if recurrenceStart != nil {
+		start = *recurrenceStart
+	}
+
+	dates := rule.Occurrences(start, *nextDueDate, today, maxRecurringCatchUp)
+	after := today
+	if len(dates) == maxRecurringCatchUp {
+		after = dates[len(dates)-1]
+	}
+	next, ok := rule.Next(start, after)
+	if !ok {
+		return dates, nil, true
+	}
+	return dates, &next, true
+}
+
+// effectiveRule returns rule, or a simple every-1-unit rule of recType when rule is nil
+func effectiveRule(recType domain.RecurrenceType, rule *domain.RecurrenceRule) domain.RecurrenceRule {
+	if rule != nil {
+		return *rule
+	}
+	return domain.RecurrenceRule{Frequency: recType}
+}
+
+// recurrenceSchedule returns the next due date and series anchor for a recurring expense:
+// the given next due date anchors the series, otherwise it starts at expenseDate. The next due
+// date is nil when the rule has no occurrence left.
+func recurrenceSchedule(rule domain.RecurrenceRule, expenseDate time.Time, nextDue *time.Time) (*time.Time, *time.Time) {
+	if nextDue != nil {
+		start := *nextDue
+		first, ok := rule.First(start)
+		if !ok {
+			return nil, &start
+		}
+		return &first, &start
+	}
+	start := expenseDate
+	next, ok := rule.Next(start, expenseDate)
+	if !ok {
+		return nil, &start
+	}
+	return &next, &start
+}
+
 // ParseExpenseFilter builds filter from query params (from_date, to_date, category_id)
 func ParseExpenseFilter(userID string, fromDate, toDate *time.Time, categoryID *string) domain.ExpenseFilter {
 	return domain.ExpenseFilter{
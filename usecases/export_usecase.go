@@ -0,0 +1,189 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidExportType   = errors.New("type must be one of expenses, debts, report")
+	ErrInvalidExportFormat = errors.New("format must be one of csv, json, xlsx")
+	ErrExportDatesRequired = errors.New("from and to are required for a report")
+)
+
+// ExportEncoder writes exports in one file format (implementations live in infrastructure/export)
+type ExportEncoder interface {
+	// NewWriter starts a file in w holding one table; sheet names it where the format can (XLSX)
+	NewWriter(w io.Writer, sheet string) ExportWriter
+}
+
+// ExportWriter writes the header and rows of one table. Row values are strings, domain.Money,
+// bools or nil (no value), in the order of the header's columns.
+type ExportWriter interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []interface{}) error
+	// Close finishes the file; it does not close the underlying writer
+	Close() error
+}
+
+var (
+	expenseExportColumns = []string{"id", "expense_date", "amount", "currency", "category_id", "category_name",
+		"note", "is_recurring", "recurrence_type", "ledger_id", "created_at"}
+	debtExportColumns = []string{"id", "type", "peer_name", "contact_id", "amount", "paid_amount", "balance",
+		"currency", "due_date", "status", "note", "expense_id", "created_at"}
+	reportExportColumns = []string{"section", "name", "amount", "budgeted", "remaining"}
+)
+
+// ExportUseCase writes expenses, debts and reports as files
+type ExportUseCase struct {
+	repo     repository.ExportRepository
+	reports  ReportUsecase
+	encoders map[domain.ExportFormat]ExportEncoder
+}
+
+// NewExportUseCase creates an export usecase writing the formats encoders has an entry for
+func NewExportUseCase(repo repository.ExportRepository, reports ReportUsecase, encoders map[domain.ExportFormat]ExportEncoder) *ExportUseCase {
+	return &ExportUseCase{repo: repo, reports: reports, encoders: encoders}
+}
+
+// Validate checks request without reading anything, so callers can reject it before output starts
+func (u *ExportUseCase) Validate(userID string, request domain.ExportRequest) error {
+	if userID == "" {
+		return ErrUserIDRequired
+	}
+	if !domain.ValidExportType(request.Type) {
+		return ErrInvalidExportType
+	}
+	if _, ok := u.encoders[request.Format]; !ok {
+		return ErrInvalidExportFormat
+	}
+	from, to := request.Filter.FromDate, request.Filter.ToDate
+	if request.Type == domain.ExportTypeReport && (from == nil || to == nil) {
+		return ErrExportDatesRequired
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return ErrInvalidDateRange
+	}
+	return nil
+}
+
+// Export writes the records request asks for to w, one row at a time. Expenses follow
+// request.Filter like GET /expenses (in its ledger when LedgerID is set); debts are the user's own,
+// by due date; a report has the period's totals and one row per category. Nothing is written when
+// the request is invalid; a read failing part way leaves the file unfinished.
+func (u *ExportUseCase) Export(ctx context.Context, w io.Writer, userID string, request domain.ExportRequest) error {
+	if err := u.Validate(userID, request); err != nil {
+		return err
+	}
+	filter := request.Filter
+	filter.UserID = userID
+	encoder := u.encoders[request.Format]
+
+	switch request.Type {
+	case domain.ExportTypeDebts:
+		out := encoder.NewWriter(w, "Debts")
+		if err := out.WriteHeader(debtExportColumns); err != nil {
+			return err
+		}
+		err := u.repo.StreamDebts(ctx, userID, filter.FromDate, filter.ToDate, func(d *domain.Debt) error {
+			return out.WriteRow([]interface{}{
+				d.ID, d.Type, d.PeerName, optional(d.ContactID), d.Amount, d.PaidAmount, d.Balance,
+				d.Currency, d.DueDate.Format("2006-01-02"), string(d.Status), optional(d.Note),
+				optional(d.ExpenseID), d.CreatedAt.UTC().Format(time.RFC3339),
+			})
+		})
+		if err != nil {
+			return err
+		}
+		return out.Close()
+
+	case domain.ExportTypeReport:
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return ErrUserIDRequired
+		}
+		report, err := u.reports.GetWeeklyReport(ctx, id, *filter.FromDate, *filter.ToDate)
+		if err != nil {
+			return err
+		}
+		out := encoder.NewWriter(w, "Report")
+		if err := out.WriteHeader(reportExportColumns); err != nil {
+			return err
+		}
+		for _, row := range reportRows(report) {
+			if err := out.WriteRow(row); err != nil {
+				return err
+			}
+		}
+		return out.Close()
+
+	default:
+		out := encoder.NewWriter(w, "Expenses")
+		if err := out.WriteHeader(expenseExportColumns); err != nil {
+			return err
+		}
+		err := u.repo.StreamExpenses(ctx, filter, func(e *domain.ExpenseRecord) error {
+			var categoryName interface{}
+			if e.CategoryID != nil {
+				categoryName = e.CategoryName
+			}
+			var recurrenceType interface{}
+			if e.IsRecurring {
+				recurrenceType = string(e.RecurrenceType)
+			}
+			return out.WriteRow([]interface{}{
+				e.ID, e.ExpenseDate.Format("2006-01-02"), e.Amount, e.Currency, optional(e.CategoryID), categoryName,
+				e.Note, e.IsRecurring, recurrenceType, optional(e.LedgerID), e.CreatedAt.UTC().Format(time.RFC3339),
+			})
+		})
+		if err != nil {
+			return err
+		}
+		return out.Close()
+	}
+}
+
+// reportRows lays a report out as rows of reportExportColumns: the period's totals, with the
+// overall budget on the expense row, then each category with its budget, then the amounts left
+// out of the totals for lack of an exchange rate, in their own currency
+func reportRows(report WeeklyReport) [][]interface{} {
+	expense := []interface{}{"total", "expense", report.TotalExpense, nil, nil}
+	if report.Budget != nil {
+		expense[3], expense[4] = report.Budget.Budgeted, report.Budget.Remaining
+	}
+	rows := [][]interface{}{
+		expense,
+		{"total", "income", report.TotalIncome, nil, nil},
+		{"total", "net_cash_flow", report.NetCashFlow, nil, nil},
+		{"total", "lent", report.TotalLent, nil, nil},
+		{"total", "borrowed", report.TotalBorrowed, nil, nil},
+		{"total", "lent_repaid", report.LentRepaid, nil, nil},
+		{"total", "borrowed_repaid", report.BorrowedRepaid, nil, nil},
+	}
+	for _, c := range report.CategoryBreakdown {
+		row := []interface{}{"category", c.CategoryName, c.Total, nil, nil}
+		if c.Budget != nil {
+			row[3], row[4] = c.Budget.Budgeted, c.Budget.Remaining
+		}
+		rows = append(rows, row)
+	}
+	for _, m := range report.MissingRates {
+		rows = append(rows, []interface{}{"missing_rate", m.Kind + " " + m.Currency, m.Amount, nil, nil})
+	}
+	return rows
+}
+
+// optional returns *s, or nil (no value) when s is nil
+func optional(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
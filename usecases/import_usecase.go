@@ -0,0 +1,279 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidImportFormat = errors.New("format must be one of csv, ofx, qif")
+	ErrInvalidImportFile   = errors.New("the statement could not be read")
+	ErrTooManyImportRows   = fmt.Errorf("a statement can have at most %d transactions", maxImportRows)
+)
+
+// maxImportRows caps how many transactions one statement can hold
+const maxImportRows = 5000
+
+// importPageSize is how many existing expenses or categories are read per query
+const importPageSize = 500
+
+// StatementParser reads the transactions of a bank statement (implementations live in infrastructure/statement)
+type StatementParser interface {
+	Parse(r io.Reader, options domain.ImportOptions) ([]domain.StatementRow, error)
+}
+
+// ImportUseCase turns bank statements into expenses
+type ImportUseCase struct {
+	expenseRepo  repository.ExpenseRepository
+	categoryRepo repository.CategoryRepository
+	userRepo     repository.UserRepository
+	parsers      map[domain.ImportFormat]StatementParser
+	categorizer  Categorizer
+}
+
+// NewImportUseCase creates an import usecase reading the formats parsers has an entry for;
+// categoryRepo resolves the category names found in statements and userRepo the default
+// currency of rows without one
+func NewImportUseCase(expenseRepo repository.ExpenseRepository, categoryRepo repository.CategoryRepository, userRepo repository.UserRepository, parsers map[domain.ImportFormat]StatementParser) *ImportUseCase {
+	return &ImportUseCase{expenseRepo: expenseRepo, categoryRepo: categoryRepo, userRepo: userRepo, parsers: parsers}
+}
+
+// SetCategorizer makes imports file the rows without a (known) category by the category rules
+func (u *ImportUseCase) SetCategorizer(c Categorizer) {
+	u.categorizer = c
+}
+
+// Preview reads the statement and returns what importing it would do, without saving anything.
+// Money going out becomes an expense of the user (in ledgerID when set); money coming in is
+// skipped. A row is a duplicate when an expense with the same date, amount and note exists;
+// each existing expense matches one row at most.
+func (u *ImportUseCase) Preview(ctx context.Context, userID string, ledgerID *string, file io.Reader, options domain.ImportOptions) (*domain.ImportResult, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	parser, ok := u.parsers[options.Format]
+	if !ok {
+		return nil, ErrInvalidImportFormat
+	}
+	fallback := domain.NormalizeCurrency(options.Currency)
+	if fallback != "" && !domain.ValidCurrency(fallback) {
+		return nil, ErrInvalidCurrency
+	}
+	// Rows are checked against the currency they will be stored in
+	fallback, err := storedCurrency(ctx, u.userRepo, userID, fallback)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := parser.Parse(file, options)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+	if len(rows) > maxImportRows {
+		return nil, ErrTooManyImportRows
+	}
+	categories, err := u.categoryIDs(ctx, userID, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.ImportResult{Format: options.Format, Rows: make([]domain.ImportRow, len(rows))}
+	inputs := make([]*domain.CreateExpenseInput, 0, len(rows))
+	for i, row := range rows {
+		result.Rows[i] = importRow(row, userID, ledgerID, fallback, options.InvertAmounts, categories)
+		if result.Rows[i].Expense != nil {
+			inputs = append(inputs, result.Rows[i].Expense)
+		}
+	}
+	if u.categorizer != nil {
+		if err := u.categorizer.Categorize(ctx, userID, ledgerID, inputs); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.markDuplicates(ctx, userID, ledgerID, result.Rows); err != nil {
+		return nil, err
+	}
+	result.Count()
+	return result, nil
+}
+
+// Commit imports the statement's new rows, and its duplicates too when includeDuplicates is set,
+// in one transaction
+func (u *ImportUseCase) Commit(ctx context.Context, userID string, ledgerID *string, file io.Reader, options domain.ImportOptions, includeDuplicates bool) (*domain.ImportResult, error) {
+	result, err := u.Preview(ctx, userID, ledgerID, file, options)
+	if err != nil {
+		return nil, err
+	}
+	inputs := make([]domain.CreateExpenseInput, 0, result.New)
+	for _, row := range result.Rows {
+		if row.Status == domain.ImportRowNew || (includeDuplicates && row.Status == domain.ImportRowDuplicate) {
+			inputs = append(inputs, *row.Expense)
+		}
+	}
+	inserted, err := u.expenseRepo.CreateBatch(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(inserted))
+	for _, id := range inserted {
+		done[id] = true
+	}
+	for i := range result.Rows {
+		if row := &result.Rows[i]; row.Expense != nil && done[row.Expense.ID] {
+			row.Status = domain.ImportRowImported
+		}
+	}
+	result.Committed = true
+	result.Count()
+	return result, nil
+}
+
+// importRow turns a statement row into an expense to create, or says why it is left out
+func importRow(row domain.StatementRow, userID string, ledgerID *string, fallback string, invert bool, categories map[string]string) domain.ImportRow {
+	out := domain.ImportRow{Line: row.Line}
+	if row.Error != "" {
+		out.Status, out.Message = domain.ImportRowInvalid, row.Error
+		return out
+	}
+	amount := row.Amount
+	if invert {
+		amount = amount.Neg()
+	}
+	if !amount.IsNegative() {
+		out.Status, out.Message = domain.ImportRowSkipped, "not money going out"
+		return out
+	}
+	currency := domain.NormalizeCurrency(row.Currency)
+	if currency == "" {
+		currency = fallback
+	} else if !domain.ValidCurrency(currency) {
+		out.Status, out.Message = domain.ImportRowInvalid, fmt.Sprintf("currency %q is not a 3-letter ISO 4217 code", row.Currency)
+		return out
+	}
+	if !amount.FitsCurrency(currency) {
+		out.Status, out.Message = domain.ImportRowInvalid, fmt.Sprintf("amount %s has more decimal places than %s allows", amount.Neg(), currency)
+		return out
+	}
+
+	out.Status = domain.ImportRowNew
+	out.Expense = &domain.CreateExpenseInput{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		LedgerID:    ledgerID,
+		Amount:      amount.Neg(),
+		Currency:    currency,
+		Note:        strings.TrimSpace(row.Note),
+		ExpenseDate: row.Date,
+	}
+	if name := strings.TrimSpace(row.Category); name != "" {
+		if id, ok := categories[strings.ToLower(name)]; ok {
+			out.Expense.CategoryID = &id
+		} else {
+			out.Message = fmt.Sprintf("no category named %q", name)
+		}
+	}
+	return out
+}
+
+// categoryIDs maps the lower-cased names of the categories the user can file expenses under
+// (in ledgerID when set) to their IDs
+func (u *ImportUseCase) categoryIDs(ctx context.Context, userID string, ledgerID *string) (map[string]string, error) {
+	categories, err := fileableCategories(ctx, u.categoryRepo, userID, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]string)
+	for _, c := range categories {
+		if _, taken := ids[strings.ToLower(c.Name)]; !taken {
+			ids[strings.ToLower(c.Name)] = c.ID
+		}
+	}
+	return ids, nil
+}
+
+// fileableCategories returns all the categories the user can file expenses under: global and
+// their own, or global and ledgerID's when set
+func fileableCategories(ctx context.Context, repo repository.CategoryRepository, userID string, ledgerID *string) ([]*domain.Category, error) {
+	var all []*domain.Category
+	for offset := 0; ; offset += importPageSize {
+		options := repository.ListOptions{Limit: importPageSize, Offset: offset}
+		var categories []*domain.Category
+		var total int
+		var err error
+		if ledgerID != nil {
+			categories, total, err = repo.ListByLedger(ctx, *ledgerID, userID, options)
+		} else {
+			categories, total, err = repo.List(ctx, &userID, options)
+		}
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, categories...)
+		if len(categories) == 0 || offset+len(categories) >= total {
+			return all, nil
+		}
+	}
+}
+
+// markDuplicates flags the new rows matching an existing expense on date, amount and note
+func (u *ImportUseCase) markDuplicates(ctx context.Context, userID string, ledgerID *string, rows []domain.ImportRow) error {
+	var from, to time.Time
+	for _, row := range rows {
+		if row.Status != domain.ImportRowNew {
+			continue
+		}
+		if date := row.Expense.ExpenseDate; from.IsZero() || date.Before(from) {
+			from = date
+		}
+		if date := row.Expense.ExpenseDate; date.After(to) {
+			to = date
+		}
+	}
+	if from.IsZero() {
+		return nil
+	}
+
+	existing := make(map[string][]string)
+	filter := domain.ExpenseFilter{UserID: userID, LedgerID: ledgerID, FromDate: &from, ToDate: &to, Limit: importPageSize}
+	for {
+		expenses, total, err := u.expenseRepo.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, e := range expenses {
+			key := duplicateKey(e.ExpenseDate, e.Amount, e.Note)
+			existing[key] = append(existing[key], e.ID)
+		}
+		filter.Offset += len(expenses)
+		if len(expenses) == 0 || filter.Offset >= total {
+			break
+		}
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Status != domain.ImportRowNew {
+			continue
+		}
+		key := duplicateKey(row.Expense.ExpenseDate, row.Expense.Amount, row.Expense.Note)
+		if ids := existing[key]; len(ids) > 0 {
+			row.Status = domain.ImportRowDuplicate
+			row.DuplicateOf = &ids[0]
+			existing[key] = ids[1:]
+		}
+	}
+	return nil
+}
+
+// duplicateKey compares notes ignoring case and runs of whitespace
+func duplicateKey(date time.Time, amount domain.Money, note string) string {
+	return date.Format("2006-01-02") + "|" + amount.String() + "|" + strings.ToLower(strings.Join(strings.Fields(note), " "))
+}
@@ -0,0 +1,254 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+)
+
+var (
+	ErrInvalidIncomeAmount          = errors.New("amount must be positive")
+	ErrIncomeSourceRequired         = errors.New("source is required")
+	ErrIncomeRecurrenceRequired     = errors.New("recurrence_rule is required for recurring income")
+	ErrIncomeNotFound               = errors.New("income not found")
+	ErrIncomeCategoryNotFound       = errors.New("income category not found")
+	ErrIncomeCategoryNameRequired   = errors.New("name is required")
+	ErrGlobalIncomeCategoryReadOnly = errors.New("global income categories cannot be deleted")
+)
+
+// IncomeUseCase handles incomes and income categories
+type IncomeUseCase struct {
+	incomeRepo   repository.IncomeRepository
+	categoryRepo repository.IncomeCategoryRepository
+	userRepo     repository.UserRepository
+	now          func() time.Time
+}
+
+// NewIncomeUseCase creates a new income use case; userRepo supplies the default currency of
+// incomes created without one
+func NewIncomeUseCase(incomeRepo repository.IncomeRepository, categoryRepo repository.IncomeCategoryRepository, userRepo repository.UserRepository) *IncomeUseCase {
+	return &IncomeUseCase{incomeRepo: incomeRepo, categoryRepo: categoryRepo, userRepo: userRepo, now: time.Now}
+}
+
+// Create records an income for the user. A recurring income without next_due_date is next due
+// at the rule's first occurrence after received_date.
+func (uc *IncomeUseCase) Create(ctx context.Context, input domain.CreateIncomeInput) (*domain.Income, error) {
+	if input.UserID == "" {
+		return nil, ErrUserIDRequired
+	}
+	input.Source = strings.TrimSpace(input.Source)
+	if input.Source == "" {
+		return nil, ErrIncomeSourceRequired
+	}
+	if !input.Amount.IsPositive() {
+		return nil, ErrInvalidIncomeAmount
+	}
+	input.Currency = domain.NormalizeCurrency(input.Currency)
+	if input.Currency != "" && !domain.ValidCurrency(input.Currency) {
+		return nil, ErrInvalidCurrency
+	}
+	if err := checkPrecision(ctx, uc.userRepo, input.UserID, input.Currency, input.Amount); err != nil {
+		return nil, err
+	}
+	if err := uc.checkCategory(ctx, input.UserID, input.CategoryID); err != nil {
+		return nil, err
+	}
+	if input.IsRecurring {
+		if input.RecurrenceRule == nil {
+			return nil, ErrIncomeRecurrenceRequired
+		}
+		input.NextDueDate, input.RecurrenceStart = recurrenceSchedule(*input.RecurrenceRule, input.ReceivedDate, input.NextDueDate)
+	}
+	return uc.incomeRepo.Create(ctx, input)
+}
+
+// GetByID returns one of the user's incomes
+func (uc *IncomeUseCase) GetByID(ctx context.Context, userID, id string) (*domain.Income, error) {
+	income, err := uc.incomeRepo.GetByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if income == nil {
+		return nil, ErrIncomeNotFound
+	}
+	return income, nil
+}
+
+// List returns the user's incomes, newest first
+func (uc *IncomeUseCase) List(ctx context.Context, filter domain.IncomeFilter) ([]*domain.Income, int, error) {
+	if filter.UserID == "" {
+		return nil, 0, ErrUserIDRequired
+	}
+	return uc.incomeRepo.List(ctx, filter)
+}
+
+// Update changes an income. Changing the recurrence or next_due_date re-anchors the series at
+// the new next due date, as for expenses.
+func (uc *IncomeUseCase) Update(ctx context.Context, userID, id string, input domain.UpdateIncomeInput) (*domain.Income, error) {
+	existing, err := uc.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Source != nil {
+		source := strings.TrimSpace(*input.Source)
+		if source == "" {
+			return nil, ErrIncomeSourceRequired
+		}
+		input.Source = &source
+	}
+	if input.Amount != nil && !input.Amount.IsPositive() {
+		return nil, ErrInvalidIncomeAmount
+	}
+	if input.Currency != nil {
+		currency := domain.NormalizeCurrency(*input.Currency)
+		if !domain.ValidCurrency(currency) {
+			return nil, ErrInvalidCurrency
+		}
+		input.Currency = &currency
+	}
+	amount, currency := existing.Amount, existing.Currency
+	if input.Amount != nil {
+		amount = *input.Amount
+	}
+	if input.Currency != nil {
+		currency = *input.Currency
+	}
+	if !amount.FitsCurrency(currency) {
+		return nil, ErrAmountPrecision
+	}
+	if input.CategoryID != nil && *input.CategoryID != "" {
+		if err := uc.checkCategory(ctx, userID, input.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.IsRecurring != nil || input.RecurrenceRule != nil || input.NextDueDate != nil {
+		isRecurring := existing.IsRecurring
+		if input.IsRecurring != nil {
+			isRecurring = *input.IsRecurring
+		}
+		if isRecurring {
+			rule := existing.RecurrenceRule
+			if input.RecurrenceRule != nil {
+				rule = input.RecurrenceRule
+			}
+			if rule == nil {
+				return nil, ErrIncomeRecurrenceRequired
+			}
+			received := existing.ReceivedDate
+			if input.ReceivedDate != nil {
+				received = *input.ReceivedDate
+			}
+			nextDue := existing.NextDueDate
+			if input.NextDueDate != nil {
+				nextDue = input.NextDueDate
+			}
+			input.RecurrenceRule = rule
+			input.NextDueDate, input.RecurrenceStart = recurrenceSchedule(*rule, received, nextDue)
+		}
+	}
+
+	income, err := uc.incomeRepo.Update(ctx, id, userID, input)
+	if err != nil {
+		return nil, err
+	}
+	if income == nil {
+		return nil, ErrIncomeNotFound
+	}
+	return income, nil
+}
+
+// Delete removes one of the user's incomes
+func (uc *IncomeUseCase) Delete(ctx context.Context, userID, id string) error {
+	if _, err := uc.GetByID(ctx, userID, id); err != nil {
+		return err
+	}
+	return uc.incomeRepo.Delete(ctx, id, userID)
+}
+
+// RunRecurringIncome generates the due occurrences of every recurring income up to today and
+// advances each template's next_due_date. It returns the number of incomes created.
+func (uc *IncomeUseCase) RunRecurringIncome(ctx context.Context) (int64, error) {
+	today := uc.now().UTC()
+	templates, err := uc.incomeRepo.ListRecurringDue(ctx, today.Format("2006-01-02"), recurringBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var created int64
+	for _, template := range templates {
+		if err := ctx.Err(); err != nil {
+			return created, err
+		}
+		if template.RecurrenceRule == nil {
+			continue
+		}
+		dates, nextDue, ok := dueOccurrences(*template.RecurrenceRule, template.NextDueDate, template.RecurrenceStart, today)
+		if !ok {
+			continue
+		}
+		n, err := uc.incomeRepo.MaterializeOccurrences(ctx, template, dates, nextDue)
+		if err != nil {
+			return created, err
+		}
+		created += int64(n)
+	}
+	return created, nil
+}
+
+// ListCategories returns the global income categories and the user's own
+func (uc *IncomeUseCase) ListCategories(ctx context.Context, userID string) ([]*domain.IncomeCategory, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	return uc.categoryRepo.List(ctx, userID)
+}
+
+// CreateCategory adds a user-defined income category
+func (uc *IncomeUseCase) CreateCategory(ctx context.Context, userID, name string) (*domain.IncomeCategory, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrIncomeCategoryNameRequired
+	}
+	category := &domain.IncomeCategory{Name: name, UserID: &userID}
+	if err := uc.categoryRepo.Create(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// DeleteCategory removes one of the user's income categories; its incomes become uncategorized
+func (uc *IncomeUseCase) DeleteCategory(ctx context.Context, userID, id string) error {
+	category, err := uc.categoryRepo.GetByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		return ErrIncomeCategoryNotFound
+	}
+	if category.UserID == nil {
+		return ErrGlobalIncomeCategoryReadOnly
+	}
+	return uc.categoryRepo.Delete(ctx, id, userID)
+}
+
+func (uc *IncomeUseCase) checkCategory(ctx context.Context, userID string, categoryID *string) error {
+	if categoryID == nil {
+		return nil
+	}
+	category, err := uc.categoryRepo.GetByID(ctx, *categoryID, userID)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		return ErrIncomeCategoryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLedgerNameRequired   = errors.New("ledger name is required")
+	ErrLedgerNotFound       = errors.New("ledger not found")
+	ErrLedgerOwnerRequired  = errors.New("only ledger owners can do this")
+	ErrLedgerReadOnly       = errors.New("viewers cannot change a ledger's records")
+	ErrInvalidLedgerRole    = errors.New("role must be one of owner, editor, viewer")
+	ErrLedgerUserNotFound   = errors.New("no user with this email")
+	ErrLedgerMemberExists   = errors.New("user is already a member of this ledger")
+	ErrLedgerMemberNotFound = errors.New("ledger member not found")
+	ErrLastLedgerOwner      = errors.New("a ledger must keep at least one owner")
+)
+
+// LedgerUseCase manages shared ledgers and who may read and write them
+type LedgerUseCase struct {
+	repo     repository.LedgerRepository
+	userRepo repository.UserRepository
+}
+
+// NewLedgerUseCase creates a ledger usecase; userRepo finds the users added as members by email
+func NewLedgerUseCase(repo repository.LedgerRepository, userRepo repository.UserRepository) *LedgerUseCase {
+	return &LedgerUseCase{repo: repo, userRepo: userRepo}
+}
+
+// Create adds a ledger with the user as its owner
+func (u *LedgerUseCase) Create(ctx context.Context, userID, name string) (*domain.Ledger, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrLedgerNameRequired
+	}
+	ledger := &domain.Ledger{ID: uuid.New().String(), Name: name, CreatedBy: userID}
+	if err := u.repo.Create(ctx, ledger); err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+// List returns the ledgers the user is a member of, with the user's role in each
+func (u *LedgerUseCase) List(ctx context.Context, userID string) ([]*domain.Ledger, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	return u.repo.ListByUser(ctx, userID)
+}
+
+// GetByID returns a ledger the user is a member of
+func (u *LedgerUseCase) GetByID(ctx context.Context, userID, id string) (*domain.Ledger, error) {
+	ledger, err := u.repo.GetByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if ledger == nil {
+		return nil, ErrLedgerNotFound
+	}
+	return ledger, nil
+}
+
+// Role returns the user's role in the ledger, or "" when the user is not a member
+func (u *LedgerUseCase) Role(ctx context.Context, ledgerID, userID string) (domain.LedgerRole, error) {
+	member, err := u.repo.GetMember(ctx, ledgerID, userID)
+	if err != nil || member == nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// Rename changes the ledger's name; owners only
+func (u *LedgerUseCase) Rename(ctx context.Context, userID, id, name string) (*domain.Ledger, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrLedgerNameRequired
+	}
+	ledger, err := u.ownedLedger(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	ledger.Name = name
+	if err := u.repo.Update(ctx, ledger); err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+// Delete removes the ledger and its budgets; owners only. Its expenses and categories go back to
+// the members who recorded them.
+func (u *LedgerUseCase) Delete(ctx context.Context, userID, id string) error {
+	if _, err := u.ownedLedger(ctx, userID, id); err != nil {
+		return err
+	}
+	return u.repo.Delete(ctx, id)
+}
+
+// Members lists the members of a ledger the user belongs to
+func (u *LedgerUseCase) Members(ctx context.Context, userID, id string) ([]*domain.LedgerMember, error) {
+	if _, err := u.GetByID(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return u.repo.ListMembers(ctx, id)
+}
+
+// AddMember adds the user registered with email to the ledger with the given role; owners only
+func (u *LedgerUseCase) AddMember(ctx context.Context, userID, id, email string, role domain.LedgerRole) (*domain.LedgerMember, error) {
+	if !domain.ValidLedgerRole(role) {
+		return nil, ErrInvalidLedgerRole
+	}
+	if _, err := u.ownedLedger(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	user, err := u.userRepo.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrLedgerUserNotFound
+	}
+	existing, err := u.repo.GetMember(ctx, id, user.UserID.String())
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrLedgerMemberExists
+	}
+
+	member := &domain.LedgerMember{LedgerID: id, UserID: user.UserID.String(), Name: user.Name, Email: user.Email, Role: role}
+	if err := u.repo.AddMember(ctx, member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// UpdateMemberRole changes a member's role; owners only. The last owner cannot step down.
+func (u *LedgerUseCase) UpdateMemberRole(ctx context.Context, userID, id, memberID string, role domain.LedgerRole) (*domain.LedgerMember, error) {
+	if !domain.ValidLedgerRole(role) {
+		return nil, ErrInvalidLedgerRole
+	}
+	if _, err := u.ownedLedger(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	member, err := u.member(ctx, id, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role == domain.LedgerRoleOwner && role != domain.LedgerRoleOwner {
+		if err := u.keepAnOwner(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.repo.UpdateMemberRole(ctx, id, memberID, role); err != nil {
+		return nil, err
+	}
+	member.Role = role
+	return member, nil
+}
+
+// RemoveMember takes a member out of the ledger. Owners can remove anyone and every member can
+// leave; the last owner cannot.
+func (u *LedgerUseCase) RemoveMember(ctx context.Context, userID, id, memberID string) error {
+	ledger, err := u.GetByID(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && ledger.Role != domain.LedgerRoleOwner {
+		return ErrLedgerOwnerRequired
+	}
+	member, err := u.member(ctx, id, memberID)
+	if err != nil {
+		return err
+	}
+	if member.Role == domain.LedgerRoleOwner {
+		if err := u.keepAnOwner(ctx, id); err != nil {
+			return err
+		}
+	}
+	return u.repo.RemoveMember(ctx, id, memberID)
+}
+
+// ownedLedger returns the ledger when the user is one of its owners
+func (u *LedgerUseCase) ownedLedger(ctx context.Context, userID, id string) (*domain.Ledger, error) {
+	ledger, err := u.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if ledger.Role != domain.LedgerRoleOwner {
+		return nil, ErrLedgerOwnerRequired
+	}
+	return ledger, nil
+}
+
+func (u *LedgerUseCase) member(ctx context.Context, id, memberID string) (*domain.LedgerMember, error) {
+	member, err := u.repo.GetMember(ctx, id, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrLedgerMemberNotFound
+	}
+	return member, nil
+}
+
+// keepAnOwner fails when the ledger has a single owner left, before that owner is demoted or removed
+func (u *LedgerUseCase) keepAnOwner(ctx context.Context, id string) error {
+	owners, err := u.repo.CountOwners(ctx, id)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastLedgerOwner
+	}
+	return nil
+}
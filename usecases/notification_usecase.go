@@ -0,0 +1,329 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+
+	"github.com/google/uuid"
+)
+
+// Notifier delivers a notification on one channel (implementations live in infrastructure/notify)
+type Notifier interface {
+	Channel() domain.NotificationChannel
+	Send(ctx context.Context, n *domain.Notification) error
+}
+
+// ReminderQueue queues reminder notifications for due debts
+type ReminderQueue interface {
+	EnqueueDebtReminder(ctx context.Context, debt *domain.Debt, occurrence string) error
+}
+
+// MaxNotificationAttempts is how many times a notification is tried before it is marked failed
+const MaxNotificationAttempts = 6
+
+const notificationBatchSize = 100
+
+// notificationRetryDelays is the backoff after the 1st, 2nd, ... failed attempt
+var notificationRetryDelays = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+}
+
+var (
+	ErrInvalidNotificationChannel   = errors.New("channel must be one of: email, webhook, log")
+	ErrDuplicateNotificationChannel = errors.New("each channel can only be listed once")
+	ErrInvalidNotificationEmail     = errors.New("email channel requires a valid email address as target")
+	ErrInvalidNotificationWebhook   = errors.New("webhook channel requires a public http(s) URL as target")
+)
+
+// NotificationUseCase queues reminder notifications, delivers them through the configured
+// notifiers and retries failed sends
+type NotificationUseCase struct {
+	repo        repository.NotificationRepository
+	userRepo    repository.UserRepository
+	debtRepo    repository.DebtRepository
+	expenseRepo repository.ExpenseRepository
+	notifiers   map[domain.NotificationChannel]Notifier
+	now         func() time.Time
+}
+
+// NewNotificationUseCase creates a notification usecase. Channels without a notifier are
+// accepted in preferences, but deliveries on them fail until the channel is configured.
+func NewNotificationUseCase(
+	repo repository.NotificationRepository,
+	userRepo repository.UserRepository,
+	debtRepo repository.DebtRepository,
+	expenseRepo repository.ExpenseRepository,
+	notifiers ...Notifier,
+) *NotificationUseCase {
+	byChannel := make(map[domain.NotificationChannel]Notifier, len(notifiers))
+	for _, n := range notifiers {
+		byChannel[n.Channel()] = n
+	}
+	return &NotificationUseCase{
+		repo:        repo,
+		userRepo:    userRepo,
+		debtRepo:    debtRepo,
+		expenseRepo: expenseRepo,
+		notifiers:   byChannel,
+		now:         time.Now,
+	}
+}
+
+// EnqueueDebtReminder queues a reminder for the debt on each of the owner's channels. occurrence
+// identifies the reminder (a scheduled reminder or a default-schedule day), so queuing is
+// idempotent per debt, occurrence and channel.
+func (u *NotificationUseCase) EnqueueDebtReminder(ctx context.Context, debt *domain.Debt, occurrence string) error {
+	title := "Debt reminder"
+	var body string
+	if debt.Type == "borrowed" {
+		body = fmt.Sprintf("You owe %s %s, due on %s.", debt.PeerName, debt.Amount, debt.DueDate.Format("2006-01-02"))
+	} else {
+		body = fmt.Sprintf("%s owes you %s, due on %s.", debt.PeerName, debt.Amount, debt.DueDate.Format("2006-01-02"))
+	}
+	key := fmt.Sprintf("%s:%s:%s", domain.NotificationSubjectDebt, debt.ID, occurrence)
+	_, err := u.enqueue(ctx, debt.UserID, domain.NotificationSubjectDebt, debt.ID, key, title, body)
+	return err
+}
+
+// RunRecurringExpenseReminderCheck queues reminders for recurring expenses due today or tomorrow.
+// Each occurrence is reminded once; it returns the number of notifications queued.
+func (u *NotificationUseCase) RunRecurringExpenseReminderCheck(ctx context.Context) (int64, error) {
+	expenses, err := u.expenseRepo.ListRecurringDueForReminder(ctx, u.now().UTC().Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+
+	var queued int64
+	for _, expense := range expenses {
+		if expense == nil || expense.NextDueDate == nil {
+			continue
+		}
+		due := expense.NextDueDate.Format("2006-01-02")
+		body := fmt.Sprintf("Recurring expense of %s is due on %s.", expense.Amount, due)
+		if expense.Note != "" {
+			body = fmt.Sprintf("Recurring expense %q of %s is due on %s.", expense.Note, expense.Amount, due)
+		}
+		key := fmt.Sprintf("%s:%s:%s", domain.NotificationSubjectRecurringExpense, expense.ID, due)
+		n, err := u.enqueue(ctx, expense.UserID, domain.NotificationSubjectRecurringExpense, expense.ID, key, "Upcoming recurring expense", body)
+		if err != nil {
+			return queued, err
+		}
+		queued += n
+	}
+	return queued, nil
+}
+
+func (u *NotificationUseCase) enqueue(ctx context.Context, userID, subjectType, subjectID, key, title, body string) (int64, error) {
+	prefs, err := u.channelsFor(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var queued int64
+	for _, pref := range prefs {
+		n := &domain.Notification{
+			UserID:        userID,
+			Channel:       pref.Channel,
+			Target:        pref.Target,
+			SubjectType:   subjectType,
+			SubjectID:     subjectID,
+			DedupeKey:     key + ":" + string(pref.Channel),
+			Title:         title,
+			Body:          body,
+			Status:        domain.NotificationStatusPending,
+			NextAttemptAt: u.now().UTC(),
+		}
+		created, err := u.repo.Enqueue(ctx, n)
+		if err != nil {
+			return queued, err
+		}
+		if created {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// channelsFor returns the user's enabled channels. Users who never set preferences get email
+// when SMTP is configured, and the log channel otherwise.
+func (u *NotificationUseCase) channelsFor(ctx context.Context, userID string) ([]*domain.NotificationPreference, error) {
+	prefs, err := u.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefs) > 0 {
+		enabled := make([]*domain.NotificationPreference, 0, len(prefs))
+		for _, p := range prefs {
+			if p.Enabled {
+				enabled = append(enabled, p)
+			}
+		}
+		return enabled, nil
+	}
+
+	if _, ok := u.notifiers[domain.NotificationChannelEmail]; ok {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return nil, err
+		}
+		user, err := u.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil && user.Email != "" {
+			return []*domain.NotificationPreference{{UserID: userID, Channel: domain.NotificationChannelEmail, Target: user.Email, Enabled: true}}, nil
+		}
+	}
+	return []*domain.NotificationPreference{{UserID: userID, Channel: domain.NotificationChannelLog, Enabled: true}}, nil
+}
+
+// DeliverPending sends due notifications. A failed send is retried with backoff and only marked
+// failed after MaxNotificationAttempts. It returns the number of notifications sent.
+func (u *NotificationUseCase) DeliverPending(ctx context.Context) (int64, error) {
+	due, err := u.repo.ListDue(ctx, u.now().UTC(), notificationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var sent int64
+	for _, n := range due {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+
+		var sendErr error
+		if notifier, ok := u.notifiers[n.Channel]; ok {
+			sendErr = notifier.Send(ctx, n)
+		} else {
+			sendErr = fmt.Errorf("channel %q is not configured", n.Channel)
+		}
+
+		now := u.now().UTC()
+		if sendErr != nil {
+			attempts := n.Attempts + 1
+			status := domain.NotificationStatusPending
+			if attempts >= MaxNotificationAttempts {
+				status = domain.NotificationStatusFailed
+			}
+			if err := u.repo.MarkAttemptFailed(ctx, n.ID, sendErr.Error(), now.Add(retryDelay(attempts)), status); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		if err := u.repo.MarkSent(ctx, n.ID, now); err != nil {
+			return sent, err
+		}
+		if err := u.markSubjectSent(ctx, n, now); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// markSubjectSent stamps the reminded record once a notification about it was delivered
+func (u *NotificationUseCase) markSubjectSent(ctx context.Context, n *domain.Notification, sentAt time.Time) error {
+	switch n.SubjectType {
+	case domain.NotificationSubjectDebt:
+		return u.debtRepo.UpdateReminder(ctx, n.SubjectID, n.CreatedAt.UTC().Format("2006-01-02 15:04:05"), sentAt.Format("2006-01-02 15:04:05"))
+	case domain.NotificationSubjectRecurringExpense:
+		return u.expenseRepo.MarkReminderSent(ctx, n.SubjectID, sentAt)
+	}
+	return nil
+}
+
+func retryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > len(notificationRetryDelays) {
+		return notificationRetryDelays[len(notificationRetryDelays)-1]
+	}
+	return notificationRetryDelays[attempts-1]
+}
+
+// GetPreferences returns the user's notification channel preferences
+func (u *NotificationUseCase) GetPreferences(ctx context.Context, userID string) ([]*domain.NotificationPreference, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	return u.repo.GetPreferences(ctx, userID)
+}
+
+// UpdatePreferences validates and replaces the user's notification channel preferences
+func (u *NotificationUseCase) UpdatePreferences(ctx context.Context, userID string, prefs []*domain.NotificationPreference) ([]*domain.NotificationPreference, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+
+	seen := make(map[domain.NotificationChannel]bool, len(prefs))
+	for _, p := range prefs {
+		if p == nil {
+			return nil, ErrInvalidNotificationChannel
+		}
+		switch p.Channel {
+		case domain.NotificationChannelEmail:
+			if _, err := mail.ParseAddress(p.Target); err != nil {
+				return nil, ErrInvalidNotificationEmail
+			}
+		case domain.NotificationChannelWebhook:
+			if !publicWebhookURL(p.Target) {
+				return nil, ErrInvalidNotificationWebhook
+			}
+		case domain.NotificationChannelLog:
+			p.Target = ""
+		default:
+			return nil, ErrInvalidNotificationChannel
+		}
+		if seen[p.Channel] {
+			return nil, ErrDuplicateNotificationChannel
+		}
+		seen[p.Channel] = true
+		p.UserID = userID
+	}
+
+	if err := u.repo.ReplacePreferences(ctx, userID, prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// ListNotifications returns the user's notifications with their delivery status, newest first
+func (u *NotificationUseCase) ListNotifications(ctx context.Context, userID string, options repository.ListOptions) ([]*domain.Notification, int, error) {
+	if userID == "" {
+		return nil, 0, ErrUserIDRequired
+	}
+	return u.repo.ListByUser(ctx, userID, options)
+}
+
+// publicWebhookURL reports whether target is an http(s) URL whose host is not obviously internal:
+// not localhost and not a loopback, private or otherwise non-public IP address. Names are only
+// resolved when a webhook is sent, where the notifier checks the address it connects to.
+func publicWebhookURL(target string) bool {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return domain.WebhookAddressAllowed(addr)
+	}
+	return true
+}
@@ -0,0 +1,32 @@
+package usecases
+
+import "expense_tracker/domain"
+
+// cursorPage trims the rows of the page at cursor, read with one row more than limit to find out
+// whether the list goes on, and returns them with the cursors of the pages before and after them.
+// key returns a row's position.
+func cursorPage[T any](rows []T, cursor domain.PageCursor, limit int, key func(T) domain.PageCursor) ([]T, domain.PageCursors) {
+	more := len(rows) > limit
+	if more {
+		if cursor.Backward {
+			rows = rows[len(rows)-limit:] // read nearest the cursor first, so the extra row is the first
+		} else {
+			rows = rows[:limit]
+		}
+	}
+	var cursors domain.PageCursors
+	if len(rows) == 0 {
+		return rows, cursors
+	}
+	// a backward page always has the page it came from after it, and a forward page not at the
+	// start always has the one it came from before it
+	if more || cursor.Backward {
+		cursors.Next = key(rows[len(rows)-1]).Encode()
+	}
+	if (more && cursor.Backward) || (!cursor.Backward && !cursor.IsStart()) {
+		prev := key(rows[0])
+		prev.Backward = true
+		cursors.Prev = prev.Encode()
+	}
+	return rows, cursors
+}
@@ -0,0 +1,192 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+
+	"github.com/google/uuid"
+)
+
+// maxQuickAddText caps how long a quick-add text can be
+const maxQuickAddText = 300
+
+// quickAddDueDays is how many days after today a quick-added debt without a due date is due
+const quickAddDueDays = 30
+
+var (
+	ErrQuickAddTextRequired = errors.New("text is required")
+	ErrQuickAddTextTooLong  = fmt.Errorf("text can be at most %d characters", maxQuickAddText)
+	ErrQuickAddDebtInLedger = errors.New("debts cannot be added to a shared ledger")
+	ErrQuickAddNotStored    = errors.New("the expense could not be created")
+)
+
+// QuickAddParser reads an expense or a debt from a short text. It returns an error wrapping
+// domain.ErrQuickAddNotUnderstood for text it cannot read; the next parser is tried.
+// (Implementations live in infrastructure/quickadd and delivery/http.)
+type QuickAddParser interface {
+	Name() string
+	Parse(ctx context.Context, text string, hints domain.QuickAddHints) (*domain.QuickAddResult, error)
+}
+
+// QuickAddUseCase turns texts such as "coffee 85 birr yesterday" or "lent Sara 500 due Friday"
+// into expenses and debts
+type QuickAddUseCase struct {
+	categoryRepo repository.CategoryRepository
+	expenses     *ExpenseUseCase
+	debts        *DebtUsecase
+	parsers      []QuickAddParser
+	categorizer  Categorizer
+	now          func() time.Time
+}
+
+// NewQuickAddUseCase creates a quick-add usecase trying parsers in order
+func NewQuickAddUseCase(categoryRepo repository.CategoryRepository, expenses *ExpenseUseCase, debts *DebtUsecase, parsers ...QuickAddParser) *QuickAddUseCase {
+	return &QuickAddUseCase{categoryRepo: categoryRepo, expenses: expenses, debts: debts, parsers: parsers, now: time.Now}
+}
+
+// SetCategorizer makes previews of expenses without a category suggest one from the category rules
+func (u *QuickAddUseCase) SetCategorizer(c Categorizer) {
+	u.categorizer = c
+}
+
+// Preview reads text into the expense or debt it describes, in ledgerID when set, without saving
+// it. Relative dates count from today, the user's date (zero = today in UTC). A debt without a due
+// date is due in 30 days.
+func (u *QuickAddUseCase) Preview(ctx context.Context, userID string, ledgerID *string, text string, today time.Time) (*domain.QuickAddResult, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrQuickAddTextRequired
+	}
+	if utf8.RuneCountInString(text) > maxQuickAddText {
+		return nil, ErrQuickAddTextTooLong
+	}
+	if today.IsZero() {
+		today = u.now().UTC()
+	}
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	categories, err := fileableCategories(ctx, u.categoryRepo, userID, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	hints := domain.QuickAddHints{Today: today, Categories: categories}
+
+	var result *domain.QuickAddResult
+	var firstErr error
+	for _, parser := range u.parsers {
+		parsed, err := parser.Parse(ctx, text, hints)
+		if err == nil && !quickAddComplete(parsed) {
+			err = fmt.Errorf("%w: %s read no amount or person", domain.ErrQuickAddNotUnderstood, parser.Name())
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		parsed.Parser = parser.Name()
+		result = parsed
+		break
+	}
+	if result == nil {
+		if firstErr == nil {
+			firstErr = domain.ErrQuickAddNotUnderstood
+		}
+		return nil, firstErr
+	}
+
+	if result.Kind == domain.QuickAddDebt {
+		if ledgerID != nil {
+			return nil, ErrQuickAddDebtInLedger
+		}
+		debt := result.Debt
+		debt.ID, debt.UserID, debt.Status = uuid.New().String(), userID, domain.DebtStatusPending
+		debt.Currency = quickAddCurrency(debt.Currency)
+		if debt.DueDate.IsZero() {
+			debt.DueDate = today.AddDate(0, 0, quickAddDueDays)
+		}
+		if debt.DueDate.Before(today) {
+			return nil, ErrDueDateInPast
+		}
+		return result, nil
+	}
+
+	expense := result.Expense
+	expense.ID, expense.UserID, expense.LedgerID = uuid.New().String(), userID, ledgerID
+	expense.Currency = quickAddCurrency(expense.Currency)
+	if expense.ExpenseDate.IsZero() {
+		expense.ExpenseDate = today
+	}
+	if expense.CategoryID != nil && !hasCategory(categories, *expense.CategoryID) {
+		expense.CategoryID = nil
+	}
+	if expense.CategoryID == nil && u.categorizer != nil {
+		if err := u.categorizer.Categorize(ctx, userID, ledgerID, []*domain.CreateExpenseInput{expense}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Commit reads text like Preview and creates the expense or debt. It returns the created expense
+// for expenses; a debt is created in place in the result.
+func (u *QuickAddUseCase) Commit(ctx context.Context, userID string, ledgerID *string, text string, today time.Time) (*domain.QuickAddResult, *domain.Expense, error) {
+	result, err := u.Preview(ctx, userID, ledgerID, text, today)
+	if err != nil {
+		return nil, nil, err
+	}
+	if result.Kind == domain.QuickAddDebt {
+		if err := u.debts.Create(ctx, result.Debt); err != nil {
+			return nil, nil, err
+		}
+		return result, nil, nil
+	}
+	expense, err := u.expenses.Create(ctx, *result.Expense)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrQuickAddNotStored, err)
+	}
+	return result, expense, nil
+}
+
+// quickAddComplete reports whether a parser returned the record of its kind with a positive
+// amount and, for debts, a type and a person
+func quickAddComplete(result *domain.QuickAddResult) bool {
+	switch {
+	case result == nil:
+		return false
+	case result.Kind == domain.QuickAddExpense && result.Expense != nil:
+		return result.Expense.Amount.IsPositive()
+	case result.Kind == domain.QuickAddDebt && result.Debt != nil:
+		return result.Debt.Amount.IsPositive() && (result.Debt.Type == "lent" || result.Debt.Type == "borrowed") &&
+			domain.NormalizeContactName(result.Debt.PeerName) != ""
+	}
+	return false
+}
+
+// quickAddCurrency normalizes a parsed currency, dropping anything that is not a currency code
+// so the user's default currency is used
+func quickAddCurrency(currency string) string {
+	if currency = domain.NormalizeCurrency(currency); domain.ValidCurrency(currency) {
+		return currency
+	}
+	return ""
+}
+
+func hasCategory(categories []*domain.Category, id string) bool {
+	for _, c := range categories {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
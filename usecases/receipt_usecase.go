@@ -0,0 +1,351 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+
+	"github.com/google/uuid"
+)
+
+// MaxReceiptAttempts is how many times reading a receipt is tried before the draft is marked failed
+const MaxReceiptAttempts = 3
+
+// maxReceiptDrafts caps how many unconfirmed drafts a user can have in a ledger (or their own records)
+const maxReceiptDrafts = 50
+
+// receiptBatchSize is how many drafts one ProcessPending run reads
+const receiptBatchSize = 20
+
+var receiptRetryDelays = []time.Duration{time.Minute, 10 * time.Minute}
+
+var (
+	ErrReceiptDraftNotFound    = errors.New("receipt draft not found")
+	ErrReceiptDraftNotReady    = errors.New("the receipt is still being read")
+	ErrTooManyReceiptDrafts    = fmt.Errorf("at most %d receipts can wait to be confirmed", maxReceiptDrafts)
+	ErrInvalidReceiptExpense   = errors.New("the expense needs an amount greater than zero and an expense_date")
+	ErrReceiptExpenseNotStored = errors.New("the expense could not be created")
+)
+
+// ReceiptExtractor reads merchant, date, total and line items from a receipt. It returns an error
+// wrapping domain.ErrUnreadableReceipt for files it cannot read; other errors are retried.
+// (Implementations live in infrastructure/receipt and delivery/http.)
+type ReceiptExtractor interface {
+	Name() string
+	Extract(ctx context.Context, contentType string, content []byte) (*domain.ReceiptData, error)
+}
+
+// ReceiptExpenses creates the expenses of confirmed drafts; ExpenseUseCase implements it
+type ReceiptExpenses interface {
+	Create(ctx context.Context, input domain.CreateExpenseInput) (*domain.Expense, error)
+	GetByID(ctx context.Context, id, userID string) (*domain.Expense, error)
+}
+
+// ReceiptUseCase turns photographed or downloaded receipts into expenses: an uploaded receipt
+// becomes a pending draft, ProcessPending reads it in the background, and the user confirms the
+// expense it suggests, which keeps the receipt as an attachment.
+type ReceiptUseCase struct {
+	repo        repository.ReceiptDraftRepository
+	expenses    ReceiptExpenses
+	blobs       repository.BlobStore
+	extractors  []ReceiptExtractor
+	categorizer Categorizer
+	now         func() time.Time
+}
+
+// NewReceiptUseCase creates a receipt usecase trying extractors in order
+func NewReceiptUseCase(repo repository.ReceiptDraftRepository, expenses ReceiptExpenses, blobs repository.BlobStore, extractors ...ReceiptExtractor) *ReceiptUseCase {
+	return &ReceiptUseCase{repo: repo, expenses: expenses, blobs: blobs, extractors: extractors, now: time.Now}
+}
+
+// SetCategorizer makes read drafts suggest a category from the category rules
+func (u *ReceiptUseCase) SetCategorizer(c Categorizer) {
+	u.categorizer = c
+}
+
+// Upload stores a receipt, which must be an image or a PDF allowed as an attachment, as a
+// pending draft of the user in ledgerID (nil = their own records)
+func (u *ReceiptUseCase) Upload(ctx context.Context, userID string, ledgerID *string, fileName string, file io.Reader) (*domain.ReceiptDraft, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	existing, err := u.repo.List(ctx, userID, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxReceiptDrafts {
+		return nil, ErrTooManyReceiptDrafts
+	}
+
+	head := make([]byte, domain.AttachmentSniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := domain.SniffAttachmentType(head)
+	if contentType == "" {
+		return nil, ErrUnsupportedAttachmentType
+	}
+
+	id := uuid.New().String()
+	draft := &domain.ReceiptDraft{
+		ID:            id,
+		UserID:        userID,
+		LedgerID:      ledgerID,
+		FileName:      attachmentFileName(fileName, contentType),
+		ContentType:   contentType,
+		StorageKey:    "receipts/" + id,
+		Status:        domain.ReceiptDraftPending,
+		NextAttemptAt: u.now().UTC(),
+	}
+	content := &sizeLimitReader{r: io.MultiReader(bytes.NewReader(head), file), left: MaxAttachmentSize}
+	if draft.Size, err = u.blobs.Put(ctx, draft.StorageKey, content); err != nil {
+		return nil, err
+	}
+	if err := u.repo.Create(ctx, draft); err != nil {
+		_ = u.blobs.Delete(ctx, draft.StorageKey)
+		return nil, err
+	}
+	return draft, nil
+}
+
+// List returns the user's unconfirmed drafts in ledgerID, newest first
+func (u *ReceiptUseCase) List(ctx context.Context, userID string, ledgerID *string) ([]*domain.ReceiptDraft, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	return u.repo.List(ctx, userID, ledgerID)
+}
+
+func (u *ReceiptUseCase) Get(ctx context.Context, userID string, ledgerID *string, id string) (*domain.ReceiptDraft, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	draft, err := u.repo.GetByID(ctx, id, userID, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if draft == nil {
+		return nil, ErrReceiptDraftNotFound
+	}
+	return draft, nil
+}
+
+// Delete discards a draft and its receipt
+func (u *ReceiptUseCase) Delete(ctx context.Context, userID string, ledgerID *string, id string) error {
+	draft, err := u.Get(ctx, userID, ledgerID, id)
+	if err != nil {
+		return err
+	}
+	if err := u.repo.Delete(ctx, id, userID, ledgerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReceiptDraftNotFound
+		}
+		return err
+	}
+	// the record is gone, so a blob left behind is unreachable and only costs space
+	_ = u.blobs.Delete(ctx, draft.StorageKey)
+	return nil
+}
+
+// Confirm creates the expense a draft suggests, with changes applied, and keeps the receipt as its
+// attachment. The amount, currency, category, note and expense date of changes are used when set.
+// Drafts that could not be read can be confirmed too, once changes give an amount and a date.
+// The expense gets the draft's ID, so confirming again after a failure does not create it twice.
+func (u *ReceiptUseCase) Confirm(ctx context.Context, userID string, ledgerID *string, id string, changes domain.UpdateExpenseInput) (*domain.Expense, error) {
+	draft, err := u.Get(ctx, userID, ledgerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if draft.Status == domain.ReceiptDraftPending {
+		return nil, ErrReceiptDraftNotReady
+	}
+
+	input := domain.CreateExpenseInput{ID: draft.ID}
+	if draft.Expense != nil {
+		input = *draft.Expense
+	}
+	input.ID, input.UserID, input.LedgerID = draft.ID, draft.UserID, draft.LedgerID
+	if changes.Amount != nil {
+		input.Amount = *changes.Amount
+	}
+	if changes.Currency != nil {
+		input.Currency = *changes.Currency
+	}
+	if changes.CategoryID != nil {
+		input.CategoryID = changes.CategoryID
+		if *changes.CategoryID == "" {
+			input.CategoryID = nil
+		}
+	}
+	if changes.Note != nil {
+		input.Note = *changes.Note
+	}
+	if changes.ExpenseDate != nil {
+		input.ExpenseDate = *changes.ExpenseDate
+	}
+	if !input.Amount.IsPositive() || input.ExpenseDate.IsZero() {
+		return nil, ErrInvalidReceiptExpense
+	}
+
+	expense, err := u.expenses.GetByID(ctx, draft.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if expense == nil {
+		if expense, err = u.expenses.Create(ctx, input); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrReceiptExpenseNotStored, err)
+		}
+	}
+
+	attachment := &domain.Attachment{
+		ExpenseID:   expense.ID,
+		UserID:      userID,
+		FileName:    draft.FileName,
+		ContentType: draft.ContentType,
+		Size:        draft.Size,
+		StorageKey:  draft.StorageKey,
+	}
+	if err := u.repo.Attach(ctx, draft.ID, userID, attachment); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReceiptDraftNotFound
+		}
+		return nil, err
+	}
+	return expense, nil
+}
+
+// ProcessPending reads the receipts of due pending drafts. Each extractor is tried in turn until
+// one finds the total; when none does, the most complete partial result is kept. A draft fails
+// right away when no extractor can read it, and after MaxReceiptAttempts when they keep erroring.
+// It returns the number of drafts made ready.
+func (u *ReceiptUseCase) ProcessPending(ctx context.Context) (int64, error) {
+	due, err := u.repo.ListDue(ctx, u.now().UTC(), receiptBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var ready int64
+	for _, draft := range due {
+		if err := ctx.Err(); err != nil {
+			return ready, err
+		}
+		extractor, data, readErr := u.extract(ctx, draft)
+		if data != nil {
+			expense := receiptExpense(draft, data, u.now().UTC())
+			if u.categorizer != nil && expense.CategoryID == nil {
+				if err := u.categorizer.Categorize(ctx, draft.UserID, draft.LedgerID, []*domain.CreateExpenseInput{expense}); err != nil {
+					return ready, err
+				}
+			}
+			if err := u.repo.MarkExtracted(ctx, draft.ID, extractor, data, expense); err != nil {
+				return ready, err
+			}
+			ready++
+			continue
+		}
+
+		attempts := draft.Attempts + 1
+		status := domain.ReceiptDraftPending
+		if errors.Is(readErr, domain.ErrUnreadableReceipt) || attempts >= MaxReceiptAttempts {
+			status = domain.ReceiptDraftFailed
+		}
+		next := u.now().UTC().Add(receiptRetryDelays[min(attempts, len(receiptRetryDelays))-1])
+		if err := u.repo.MarkAttemptFailed(ctx, draft.ID, readErr.Error(), next, status); err != nil {
+			return ready, err
+		}
+	}
+	return ready, nil
+}
+
+// extract runs the extractors over the draft's receipt. With no result, the error is the last one
+// that is worth retrying, or ErrUnreadableReceipt when no extractor could read the file.
+func (u *ReceiptUseCase) extract(ctx context.Context, draft *domain.ReceiptDraft) (string, *domain.ReceiptData, error) {
+	content, err := u.readReceipt(ctx, draft.StorageKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var best *domain.ReceiptData
+	var bestName string
+	readErr := error(domain.ErrUnreadableReceipt)
+	for _, extractor := range u.extractors {
+		data, err := extractor.Extract(ctx, draft.ContentType, content)
+		if err != nil {
+			if !errors.Is(err, domain.ErrUnreadableReceipt) {
+				readErr = fmt.Errorf("%s: %w", extractor.Name(), err)
+			}
+			continue
+		}
+		if data.Total != nil {
+			return extractor.Name(), data, nil
+		}
+		if best == nil || receiptFields(data) > receiptFields(best) {
+			best, bestName = data, extractor.Name()
+		}
+	}
+	if best != nil && receiptFields(best) > 0 {
+		return bestName, best, nil
+	}
+	return "", nil, readErr
+}
+
+// readReceipt returns the content of a receipt; a missing blob makes it unreadable
+func (u *ReceiptUseCase) readReceipt(ctx context.Context, key string) ([]byte, error) {
+	r, err := u.blobs.Open(ctx, key)
+	if errors.Is(err, repository.ErrBlobNotFound) {
+		return nil, fmt.Errorf("%w: the file is missing", domain.ErrUnreadableReceipt)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, MaxAttachmentSize))
+}
+
+// receiptFields counts the fields an extractor found
+func receiptFields(data *domain.ReceiptData) int {
+	n := len(data.LineItems)
+	if data.Merchant != "" {
+		n++
+	}
+	if data.Date != nil {
+		n++
+	}
+	if data.Total != nil {
+		n++
+	}
+	return n
+}
+
+// receiptExpense is the expense a read receipt suggests: its total, currency and date, noted with
+// the merchant. Without a date the expense is dated the day the receipt was read; without a total
+// the amount is left 0 for the user to fill in.
+func receiptExpense(draft *domain.ReceiptDraft, data *domain.ReceiptData, now time.Time) *domain.CreateExpenseInput {
+	input := &domain.CreateExpenseInput{
+		ID:          draft.ID,
+		UserID:      draft.UserID,
+		LedgerID:    draft.LedgerID,
+		Note:        strings.TrimSpace(data.Merchant),
+		ExpenseDate: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+	}
+	if data.Total != nil && data.Total.IsPositive() {
+		input.Amount = *data.Total
+	}
+	if currency := domain.NormalizeCurrency(data.Currency); domain.ValidCurrency(currency) {
+		input.Currency = currency
+	}
+	if data.Date != nil {
+		input.ExpenseDate = time.Date(data.Date.Year(), data.Date.Month(), data.Date.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return input
+}
@@ -3,7 +3,9 @@ package usecases
 import (
 	"context"
 	"errors"
+	"expense_tracker/domain"
 	"expense_tracker/repository"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,10 +13,16 @@ import (
 
 // Daily Report Model
 type DailyReport struct {
-	Date          string  `json:"date"`
-	TotalExpense  float64 `json:"total-expense"`
-	TotalLent     float64 `json:"total-lent"`
-	TotalBorrowed float64 `json:"total-borrowed"`
+	Date           string               `json:"date"`
+	TotalExpense   domain.Money         `json:"total-expense"`
+	TotalLent      domain.Money         `json:"total-lent"`
+	TotalBorrowed  domain.Money         `json:"total-borrowed"`
+	LentRepaid     domain.Money         `json:"lent-repaid"`     // repayments received in the period on money lent
+	BorrowedRepaid domain.Money         `json:"borrowed-repaid"` // repayments made in the period on money borrowed
+	TotalIncome    domain.Money         `json:"total-income"`
+	NetCashFlow    domain.Money         `json:"net-cash-flow"` // income minus expenses
+	SavingsRate    *float64             `json:"savings-rate"`  // percent of income not spent; null without income
+	MissingRates   []domain.MissingRate `json:"missing-rates"` // amounts left out of the totals for lack of an exchange rate
 }
 
 type ReportUsecase interface {
@@ -31,24 +39,51 @@ var ErrInvalidDateRange = errors.New("end date must be on or after start date")
 type WeeklyReport struct {
 	StartDate         string                  `json:"start_date"`
 	EndDate           string                  `json:"end_date"`
-	TotalExpense      float64                 `json:"total_expense"`
-	TotalLent         float64                 `json:"total_lent"`
-	TotalBorrowed     float64                 `json:"total_borrowed"`
+	TotalExpense      domain.Money            `json:"total_expense"`
+	TotalLent         domain.Money            `json:"total_lent"`
+	TotalBorrowed     domain.Money            `json:"total_borrowed"`
+	LentRepaid        domain.Money            `json:"lent_repaid"`     // repayments received in the period on money lent
+	BorrowedRepaid    domain.Money            `json:"borrowed_repaid"` // repayments made in the period on money borrowed
+	TotalIncome       domain.Money            `json:"total_income"`
+	NetCashFlow       domain.Money            `json:"net_cash_flow"` // income minus expenses
+	SavingsRate       *float64                `json:"savings_rate"`  // percent of income not spent; null without income
 	CategoryBreakdown []WeeklyCategorySummary `json:"category_breakdown"`
+	Budget            *BudgetStatus           `json:"budget,omitempty"`      // overall budget, when set
+	BudgetPlan        *BudgetPlan             `json:"budget_plan,omitempty"` // status of the user's budgeting style
+	MissingRates      []domain.MissingRate    `json:"missing_rates"`         // amounts left out of the totals for lack of an exchange rate
 }
 
 type WeeklyCategorySummary struct {
-	CategoryName string  `json:"category_name"`
-	Total        float64 `json:"total"`
+	CategoryID   *string       `json:"category_id,omitempty"`
+	CategoryName string        `json:"category_name"`
+	Total        domain.Money  `json:"total"`
+	Budget       *BudgetStatus `json:"budget,omitempty"` // category budget, when set
+}
+
+// BudgetStatus compares spending in a report period with the budget for that period. Monthly
+// budgets are prorated by day for periods other than one calendar month.
+type BudgetStatus struct {
+	Budgeted    domain.Money `json:"budgeted"`
+	Spent       domain.Money `json:"spent"`
+	Remaining   domain.Money `json:"remaining"` // negative when over budget
+	PercentUsed float64      `json:"percent_used"`
+	OverBudget  bool         `json:"over_budget"`
 }
 
 type reportUsecase struct {
 	expenseRepo repository.ExpenseRepository
 	debtRepo    repository.DebtReportRepository
+	budgetRepo  repository.BudgetRepository
+	userRepo    repository.UserRepository
+	incomeRepo  repository.IncomeRepository
+	rateRepo    repository.MissingRateRepository
 }
 
-func NewReportUsecase(expenseRepo repository.ExpenseRepository, debtRepo repository.DebtReportRepository) ReportUsecase {
-	return &reportUsecase{expenseRepo: expenseRepo, debtRepo: debtRepo}
+// NewReportUsecase creates the report usecase; budgetRepo may be nil to report without budgets,
+// userRepo may be nil to leave out the budgeting style's plan, incomeRepo may be nil to report
+// without income and rateRepo may be nil to report no missing exchange rates
+func NewReportUsecase(expenseRepo repository.ExpenseRepository, debtRepo repository.DebtReportRepository, budgetRepo repository.BudgetRepository, userRepo repository.UserRepository, incomeRepo repository.IncomeRepository, rateRepo repository.MissingRateRepository) ReportUsecase {
+	return &reportUsecase{expenseRepo: expenseRepo, debtRepo: debtRepo, budgetRepo: budgetRepo, userRepo: userRepo, incomeRepo: incomeRepo, rateRepo: rateRepo}
 }
 
 // Daily Usecase Logic
@@ -69,21 +104,50 @@ func (r *reportUsecase) GetDailyReport(ctx context.Context, userID uuid.UUID, da
 		return DailyReport{}, err
 	}
 
+	repaid, err := r.repayments(ctx, userID, date, date)
+	if err != nil {
+		return DailyReport{}, err
+	}
+
+	flow, err := r.cashFlow(ctx, userID, date, date, totalExpense)
+	if err != nil {
+		return DailyReport{}, err
+	}
+
+	missing, err := r.missingRates(ctx, userID, date, date)
+	if err != nil {
+		return DailyReport{}, err
+	}
+
 	return DailyReport{
-		Date:          date.Format("2006-01-02"),
-		TotalExpense:  totalExpense,
-		TotalLent:     totalLent,
-		TotalBorrowed: totalBorrowed,
+		Date:           date.Format("2006-01-02"),
+		TotalExpense:   totalExpense,
+		TotalLent:      totalLent,
+		TotalBorrowed:  totalBorrowed,
+		LentRepaid:     repaid.lent,
+		BorrowedRepaid: repaid.borrowed,
+		TotalIncome:    flow.income,
+		NetCashFlow:    flow.net,
+		SavingsRate:    flow.savingsRate,
+		MissingRates:   missing,
 	}, nil
 }
 
 // Monthly Usecase Logic
 type MonthlyReport struct {
 	Month             string                  `json:"month"`
-	TotalExpense      float64                 `json:"total_expense"`
-	TotalLent         float64                 `json:"total_lent"`
-	TotalBorrowed     float64                 `json:"total_borrowed"`
+	TotalExpense      domain.Money            `json:"total_expense"`
+	TotalLent         domain.Money            `json:"total_lent"`
+	TotalBorrowed     domain.Money            `json:"total_borrowed"`
+	LentRepaid        domain.Money            `json:"lent_repaid"`     // repayments received in the period on money lent
+	BorrowedRepaid    domain.Money            `json:"borrowed_repaid"` // repayments made in the period on money borrowed
+	TotalIncome       domain.Money            `json:"total_income"`
+	NetCashFlow       domain.Money            `json:"net_cash_flow"` // income minus expenses
+	SavingsRate       *float64                `json:"savings_rate"`  // percent of income not spent; null without income
 	CategoryBreakdown []WeeklyCategorySummary `json:"category_breakdown"`
+	Budget            *BudgetStatus           `json:"budget,omitempty"`      // overall budget, when set
+	BudgetPlan        *BudgetPlan             `json:"budget_plan,omitempty"` // status of the user's budgeting style
+	MissingRates      []domain.MissingRate    `json:"missing_rates"`         // amounts left out of the totals for lack of an exchange rate
 }
 
 func (r *reportUsecase) GetMonthlyReport(ctx context.Context, userID uuid.UUID, year int, month time.Month) (MonthlyReport, error) {
@@ -105,11 +169,26 @@ func (r *reportUsecase) GetMonthlyReport(ctx context.Context, userID uuid.UUID,
 	categoryBreakdown := make([]WeeklyCategorySummary, 0, len(categoryTotals))
 	for _, item := range categoryTotals {
 		categoryBreakdown = append(categoryBreakdown, WeeklyCategorySummary{
+			CategoryID:   item.CategoryID,
 			CategoryName: item.CategoryName,
 			Total:        item.Total,
 		})
 	}
 
+	budgets, err := r.listBudgets(ctx, userID)
+	if err != nil {
+		return MonthlyReport{}, err
+	}
+	categoryBreakdown, overallBudget := applyBudgets(budgets, startDate, endDate, totalExpense, categoryBreakdown)
+	flow, err := r.cashFlow(ctx, userID, startDate, endDate, totalExpense)
+	if err != nil {
+		return MonthlyReport{}, err
+	}
+	budgetPlan, err := r.budgetPlan(ctx, userID, startDate, endDate, totalExpense, flow.income, categoryBreakdown, budgets)
+	if err != nil {
+		return MonthlyReport{}, err
+	}
+
 	totalLent, err := r.debtRepo.SumByDateRangeAndType(ctx, userID, startDate, endDate, "lent")
 	if err != nil {
 		return MonthlyReport{}, err
@@ -120,12 +199,30 @@ func (r *reportUsecase) GetMonthlyReport(ctx context.Context, userID uuid.UUID,
 		return MonthlyReport{}, err
 	}
 
+	repaid, err := r.repayments(ctx, userID, startDate, endDate)
+	if err != nil {
+		return MonthlyReport{}, err
+	}
+
+	missing, err := r.missingRates(ctx, userID, startDate, endDate)
+	if err != nil {
+		return MonthlyReport{}, err
+	}
+
 	return MonthlyReport{
 		Month:             startDate.Format("2006-01"),
 		TotalExpense:      totalExpense,
 		TotalLent:         totalLent,
 		TotalBorrowed:     totalBorrowed,
+		LentRepaid:        repaid.lent,
+		BorrowedRepaid:    repaid.borrowed,
+		TotalIncome:       flow.income,
+		NetCashFlow:       flow.net,
+		SavingsRate:       flow.savingsRate,
 		CategoryBreakdown: categoryBreakdown,
+		Budget:            overallBudget,
+		BudgetPlan:        budgetPlan,
+		MissingRates:      missing,
 	}, nil
 }
 
@@ -147,11 +244,26 @@ func (r *reportUsecase) GetWeeklyReport(ctx context.Context, userID uuid.UUID, s
 	categoryBreakdown := make([]WeeklyCategorySummary, 0, len(categoryTotals))
 	for _, item := range categoryTotals {
 		categoryBreakdown = append(categoryBreakdown, WeeklyCategorySummary{
+			CategoryID:   item.CategoryID,
 			CategoryName: item.CategoryName,
 			Total:        item.Total,
 		})
 	}
 
+	budgets, err := r.listBudgets(ctx, userID)
+	if err != nil {
+		return WeeklyReport{}, err
+	}
+	categoryBreakdown, overallBudget := applyBudgets(budgets, startDate, endDate, totalExpense, categoryBreakdown)
+	flow, err := r.cashFlow(ctx, userID, startDate, endDate, totalExpense)
+	if err != nil {
+		return WeeklyReport{}, err
+	}
+	budgetPlan, err := r.budgetPlan(ctx, userID, startDate, endDate, totalExpense, flow.income, categoryBreakdown, budgets)
+	if err != nil {
+		return WeeklyReport{}, err
+	}
+
 	totalLent, err := r.debtRepo.SumByDateRangeAndType(ctx, userID, startDate, endDate, "lent")
 	if err != nil {
 		return WeeklyReport{}, err
@@ -162,12 +274,197 @@ func (r *reportUsecase) GetWeeklyReport(ctx context.Context, userID uuid.UUID, s
 		return WeeklyReport{}, err
 	}
 
+	repaid, err := r.repayments(ctx, userID, startDate, endDate)
+	if err != nil {
+		return WeeklyReport{}, err
+	}
+
+	missing, err := r.missingRates(ctx, userID, startDate, endDate)
+	if err != nil {
+		return WeeklyReport{}, err
+	}
+
 	return WeeklyReport{
 		StartDate:         startDate.Format("2006-01-02"),
 		EndDate:           endDate.Format("2006-01-02"),
 		TotalExpense:      totalExpense,
 		TotalLent:         totalLent,
 		TotalBorrowed:     totalBorrowed,
+		LentRepaid:        repaid.lent,
+		BorrowedRepaid:    repaid.borrowed,
+		TotalIncome:       flow.income,
+		NetCashFlow:       flow.net,
+		SavingsRate:       flow.savingsRate,
 		CategoryBreakdown: categoryBreakdown,
+		Budget:            overallBudget,
+		BudgetPlan:        budgetPlan,
+		MissingRates:      missing,
 	}, nil
 }
+
+func (r *reportUsecase) listBudgets(ctx context.Context, userID uuid.UUID) ([]*domain.Budget, error) {
+	if r.budgetRepo == nil {
+		return nil, nil
+	}
+	return r.budgetRepo.ListByUser(ctx, userID.String())
+}
+
+// applyBudgets attaches each category budget's status to the breakdown, adding budgeted
+// categories with no spending, and returns the overall budget status (nil when not set).
+func applyBudgets(budgets []*domain.Budget, startDate, endDate time.Time, totalExpense domain.Money, breakdown []WeeklyCategorySummary) ([]WeeklyCategorySummary, *BudgetStatus) {
+	share := monthShare(startDate, endDate)
+	var overall *BudgetStatus
+	for _, budget := range budgets {
+		budgeted := budget.Amount.Mul(share)
+		if budget.CategoryID == nil {
+			overall = newBudgetStatus(budgeted, totalExpense)
+			continue
+		}
+
+		found := false
+		for i := range breakdown {
+			if breakdown[i].CategoryID != nil && *breakdown[i].CategoryID == *budget.CategoryID {
+				breakdown[i].Budget = newBudgetStatus(budgeted, breakdown[i].Total)
+				found = true
+				break
+			}
+		}
+		if !found {
+			breakdown = append(breakdown, WeeklyCategorySummary{
+				CategoryID:   budget.CategoryID,
+				CategoryName: budget.CategoryName,
+				Budget:       newBudgetStatus(budgeted, domain.Money{}),
+			})
+		}
+	}
+	return breakdown, overall
+}
+
+// budgetPlan runs the strategy for the user's budgeting style over the report period
+func (r *reportUsecase) budgetPlan(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time, totalExpense, totalIncome domain.Money, breakdown []WeeklyCategorySummary, budgets []*domain.Budget) (*BudgetPlan, error) {
+	if r.userRepo == nil {
+		return nil, nil
+	}
+	user, err := r.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	return BudgetStrategyFor(user.BudgetingStyle).Plan(ctx, BudgetPeriod{
+		Start:          startDate,
+		End:            endDate,
+		Share:          monthShare(startDate, endDate),
+		Income:         user.MonthlyIncome,
+		RecordedIncome: totalIncome,
+		Budgets:        budgets,
+		Spending:       categorySpending(breakdown, totalExpense),
+		MonthSpending: func(ctx context.Context, month time.Time) (CategorySpending, error) {
+			totals, err := r.expenseRepo.CategoryBreakdownByDateRange(ctx, userID, month, month.AddDate(0, 1, -1))
+			if err != nil {
+				return CategorySpending{}, err
+			}
+			spending := CategorySpending{ByCategory: make(map[string]domain.Money, len(totals))}
+			for _, item := range totals {
+				if item.CategoryID != nil {
+					spending.ByCategory[*item.CategoryID] = spending.ByCategory[*item.CategoryID].Add(item.Total)
+				}
+				spending.Total = spending.Total.Add(item.Total)
+			}
+			return spending, nil
+		},
+	})
+}
+
+type repayments struct {
+	lent     domain.Money
+	borrowed domain.Money
+}
+
+// repayments totals debt payments made in the range, by the type of debt they repay
+func (r *reportUsecase) repayments(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) (repayments, error) {
+	lent, err := r.debtRepo.SumPaymentsByDateRangeAndType(ctx, userID, startDate, endDate, "lent")
+	if err != nil {
+		return repayments{}, err
+	}
+	borrowed, err := r.debtRepo.SumPaymentsByDateRangeAndType(ctx, userID, startDate, endDate, "borrowed")
+	if err != nil {
+		return repayments{}, err
+	}
+	return repayments{lent: lent, borrowed: borrowed}, nil
+}
+
+// missingRates lists the amounts dated in the range that the totals leave out because no exchange
+// rate converts them; always a list, so clients can tell complete totals from partial ones
+func (r *reportUsecase) missingRates(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]domain.MissingRate, error) {
+	missing := []domain.MissingRate{}
+	if r.rateRepo == nil {
+		return missing, nil
+	}
+	found, err := r.rateRepo.MissingRates(ctx, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return append(missing, found...), nil
+}
+
+type cashFlow struct {
+	income      domain.Money
+	net         domain.Money
+	savingsRate *float64
+}
+
+// cashFlow returns income received in the range, income minus expenses and the share of income
+// not spent (nil when there is no income)
+func (r *reportUsecase) cashFlow(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time, totalExpense domain.Money) (cashFlow, error) {
+	var flow cashFlow
+	if r.incomeRepo != nil {
+		income, err := r.incomeRepo.SumByDateRange(ctx, userID, startDate, endDate)
+		if err != nil {
+			return cashFlow{}, err
+		}
+		flow.income = income
+	}
+	flow.net = flow.income.Sub(totalExpense)
+	if flow.income.IsPositive() {
+		rate := math.Round(flow.net.Float64()/flow.income.Float64()*1000) / 10
+		flow.savingsRate = &rate
+	}
+	return flow, nil
+}
+
+func categorySpending(breakdown []WeeklyCategorySummary, total domain.Money) CategorySpending {
+	spending := CategorySpending{ByCategory: make(map[string]domain.Money, len(breakdown)), Total: total}
+	for _, item := range breakdown {
+		if item.CategoryID != nil {
+			spending.ByCategory[*item.CategoryID] = spending.ByCategory[*item.CategoryID].Add(item.Total)
+		}
+	}
+	return spending
+}
+
+// monthShare returns how many months the inclusive date range covers, counting each day as a
+// fraction of its own month (a full calendar month is exactly 1)
+func monthShare(startDate, endDate time.Time) float64 {
+	share := 0.0
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		share += 1 / float64(daysInMonth)
+	}
+	return share
+}
+
+func newBudgetStatus(budgeted, spent domain.Money) *BudgetStatus {
+	status := &BudgetStatus{
+		Budgeted:   budgeted,
+		Spent:      spent,
+		Remaining:  budgeted.Sub(spent),
+		OverBudget: spent.Cmp(budgeted) > 0,
+	}
+	if budgeted.IsPositive() {
+		status.PercentUsed = math.Round(spent.Float64()/budgeted.Float64()*1000) / 10
+	}
+	return status
+}
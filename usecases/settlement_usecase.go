@@ -0,0 +1,249 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSettlementParties  = errors.New("from_user_id and to_user_id must be two different members of the group")
+	ErrSettlementNotParty = errors.New("only the payer, the payee or the group's owner can record a payment")
+)
+
+// SettlementUseCase works out who pays whom in a group (a shared ledger) and records the payments
+type SettlementUseCase struct {
+	repo       repository.SettlementRepository
+	ledgerRepo repository.LedgerRepository
+	now        func() time.Time
+}
+
+// NewSettlementUseCase creates a settlement usecase; ledgerRepo checks membership and lists the members
+func NewSettlementUseCase(repo repository.SettlementRepository, ledgerRepo repository.LedgerRepository) *SettlementUseCase {
+	return &SettlementUseCase{repo: repo, ledgerRepo: ledgerRepo, now: time.Now}
+}
+
+// Settle returns each member's balance per currency and the transfers that settle them. Every
+// expense of the group is shared equally among its current members and credited to the member
+// who recorded it; settle-up payments already made count toward the balances. Expenses and
+// payments of former members are left out.
+func (u *SettlementUseCase) Settle(ctx context.Context, userID, groupID string) (*domain.Settlement, error) {
+	if _, err := u.group(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	members, err := u.ledgerRepo.ListMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	paid, err := u.repo.PaidByMember(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	settled, err := u.repo.SettledByMember(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return settle(groupID, members, paid, settled), nil
+}
+
+// Record stores a settle-up payment from one member to another as paid debts and returns them:
+// borrowed for the payer and lent for the payee. Viewers cannot record payments, and editors
+// only payments they make or receive; the owner can record one between any two members.
+func (u *SettlementUseCase) Record(ctx context.Context, userID, groupID string, payment domain.SettlementPayment) (*domain.Debt, *domain.Debt, error) {
+	ledger, err := u.group(ctx, userID, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ledger.Role.CanWrite() {
+		return nil, nil, ErrLedgerReadOnly
+	}
+	if !payment.Amount.IsPositive() {
+		return nil, nil, ErrAmountMustBePositive
+	}
+	currency := domain.NormalizeCurrency(payment.Currency)
+	if !domain.ValidCurrency(currency) {
+		return nil, nil, ErrInvalidCurrency
+	}
+	if !payment.Amount.FitsCurrency(currency) {
+		return nil, nil, ErrAmountPrecision
+	}
+	today := u.now().UTC()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	paidDate := payment.PaidDate
+	if paidDate.IsZero() {
+		paidDate = today
+	}
+	if paidDate.After(today) {
+		return nil, nil, ErrPaidDateInFuture
+	}
+
+	if payment.FromUserID == payment.ToUserID {
+		return nil, nil, ErrSettlementParties
+	}
+	from, err := u.ledgerRepo.GetMember(ctx, groupID, payment.FromUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := u.ledgerRepo.GetMember(ctx, groupID, payment.ToUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if from == nil || to == nil {
+		return nil, nil, ErrSettlementParties
+	}
+	if ledger.Role != domain.LedgerRoleOwner && userID != from.UserID && userID != to.UserID {
+		return nil, nil, ErrSettlementNotParty
+	}
+
+	debt := func(owner, peer *domain.LedgerMember, debtType string) *domain.Debt {
+		return &domain.Debt{
+			ID:       uuid.New().String(),
+			UserID:   owner.UserID,
+			Type:     debtType,
+			PeerName: domain.NormalizeContactName(memberName(peer)),
+			LedgerID: &groupID,
+			Amount:   payment.Amount,
+			Currency: currency,
+			DueDate:  paidDate,
+			Status:   domain.DebtStatusPaid,
+			Note:     payment.Note,
+		}
+	}
+	payer, payee := debt(from, to, "borrowed"), debt(to, from, "lent")
+	if err := u.repo.Record(ctx, payer, payee); err != nil {
+		return nil, nil, err
+	}
+	return payer, payee, nil
+}
+
+// group returns the ledger when the user is one of its members
+func (u *SettlementUseCase) group(ctx context.Context, userID, groupID string) (*domain.Ledger, error) {
+	ledger, err := u.ledgerRepo.GetByID(ctx, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if ledger == nil {
+		return nil, ErrLedgerNotFound
+	}
+	return ledger, nil
+}
+
+// settle builds the balances of the members in each currency, sorted by currency and then in
+// member order, and the transfers that settle each currency
+func settle(groupID string, members []*domain.LedgerMember, paid, settled []repository.MemberTotal) *domain.Settlement {
+	index := make(map[string]int, len(members))
+	for i, m := range members {
+		index[m.UserID] = i
+	}
+	currencies := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, t := range append(append([]repository.MemberTotal{}, paid...), settled...) {
+		if _, ok := index[t.UserID]; ok && !seen[t.Currency] {
+			seen[t.Currency] = true
+			currencies = append(currencies, t.Currency)
+		}
+	}
+	sort.Strings(currencies)
+
+	result := &domain.Settlement{GroupID: groupID, Balances: []domain.MemberBalance{}, Transfers: []domain.Transfer{}}
+	for _, currency := range currencies {
+		balances := make([]domain.MemberBalance, len(members))
+		for i, m := range members {
+			balances[i] = domain.MemberBalance{UserID: m.UserID, Name: memberName(m), Currency: currency}
+		}
+		var total domain.Money
+		for _, t := range paid {
+			if i, ok := index[t.UserID]; ok && t.Currency == currency {
+				balances[i].Paid = balances[i].Paid.Add(t.Total)
+				total = total.Add(t.Total)
+			}
+		}
+		for _, t := range settled {
+			if i, ok := index[t.UserID]; ok && t.Currency == currency {
+				balances[i].Settled = balances[i].Settled.Add(t.Total)
+			}
+		}
+		weights := make([]float64, len(members))
+		for i := range weights {
+			weights[i] = 1
+		}
+		for i, share := range total.Allocate(weights, domain.CurrencyExponent(currency)) {
+			balances[i].Share = share
+			balances[i].Net = balances[i].Paid.Sub(share).Add(balances[i].Settled)
+		}
+		result.Balances = append(result.Balances, balances...)
+		result.Transfers = append(result.Transfers, simplifyDebts(balances)...)
+	}
+	return result
+}
+
+// simplifyDebts returns transfers that settle net balances in one currency: the member who owes
+// the most pays the member owed the most as much as settles one of them, until nobody is owed.
+// This takes at most one transfer fewer than the members with a balance, and each member only
+// pays or only receives.
+func simplifyDebts(balances []domain.MemberBalance) []domain.Transfer {
+	type party struct {
+		member *domain.MemberBalance
+		amount domain.Money
+	}
+	var debtors, creditors []*party
+	for i := range balances {
+		b := &balances[i]
+		switch {
+		case b.Net.IsNegative():
+			debtors = append(debtors, &party{member: b, amount: b.Net.Neg()})
+		case b.Net.IsPositive():
+			creditors = append(creditors, &party{member: b, amount: b.Net})
+		}
+	}
+	largest := func(parties []*party) {
+		sort.SliceStable(parties, func(i, j int) bool {
+			if c := parties[i].amount.Cmp(parties[j].amount); c != 0 {
+				return c > 0
+			}
+			return parties[i].member.UserID < parties[j].member.UserID
+		})
+	}
+
+	transfers := make([]domain.Transfer, 0)
+	for len(debtors) > 0 && len(creditors) > 0 {
+		largest(debtors)
+		largest(creditors)
+		debtor, creditor := debtors[0], creditors[0]
+		amount := debtor.amount
+		if creditor.amount.Cmp(amount) < 0 {
+			amount = creditor.amount
+		}
+		transfers = append(transfers, domain.Transfer{
+			FromUserID: debtor.member.UserID,
+			FromName:   debtor.member.Name,
+			ToUserID:   creditor.member.UserID,
+			ToName:     creditor.member.Name,
+			Amount:     amount,
+			Currency:   debtor.member.Currency,
+		})
+		debtor.amount = debtor.amount.Sub(amount)
+		creditor.amount = creditor.amount.Sub(amount)
+		if debtor.amount.IsZero() {
+			debtors = debtors[1:]
+		}
+		if creditor.amount.IsZero() {
+			creditors = creditors[1:]
+		}
+	}
+	return transfers
+}
+
+// memberName is the member's name, or their email when they have not set one
+func memberName(m *domain.LedgerMember) string {
+	if m.Name != "" {
+		return m.Name
+	}
+	return m.Email
+}
@@ -0,0 +1,379 @@
+package usecases
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"expense_tracker/domain"
+	"expense_tracker/repository"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxSyncBatchSize caps the number of items accepted by one sync request
+	MaxSyncBatchSize = 500
+	// DefaultSyncChangesLimit and MaxSyncChangesLimit bound one page of the change feed
+	DefaultSyncChangesLimit = 100
+	MaxSyncChangesLimit     = 500
+)
+
+var (
+	ErrSyncBatchEmpty    = errors.New("sync batch must contain at least one item")
+	ErrSyncBatchTooLarge = errors.New("sync batch must contain at most 500 items")
+	ErrInvalidSyncCursor = errors.New("invalid sync cursor")
+	ErrInvalidSyncLimit  = errors.New("limit must be between 1 and 500")
+)
+
+// SyncUseCase handles offline sync of client-created records and the server change feed
+type SyncUseCase struct {
+	expenseRepo  repository.ExpenseRepository
+	debtRepo     repository.DebtRepository
+	categoryRepo repository.CategoryRepository
+	userRepo     repository.UserRepository
+	syncRepo     repository.SyncRepository
+	categorizer  Categorizer
+}
+
+// NewSyncUseCase creates a new sync use case; userRepo supplies the default currency of
+// expenses synced without one
+func NewSyncUseCase(expenseRepo repository.ExpenseRepository, debtRepo repository.DebtRepository, categoryRepo repository.CategoryRepository, userRepo repository.UserRepository, syncRepo repository.SyncRepository) *SyncUseCase {
+	return &SyncUseCase{expenseRepo: expenseRepo, debtRepo: debtRepo, categoryRepo: categoryRepo, userRepo: userRepo, syncRepo: syncRepo}
+}
+
+// SetCategorizer makes SyncExpenses file expenses without a category by the category rules
+func (uc *SyncUseCase) SetCategorizer(c Categorizer) {
+	uc.categorizer = c
+}
+
+// SyncExpenses inserts a batch of expenses created offline. Each input must carry the
+// client-generated UUID so a retried batch is idempotent: exact duplicates are skipped,
+// IDs that exist with different data (or belong to another user) are reported as conflicts,
+// and all new rows are inserted in one transaction. Amounts with more decimal places than the
+// currency they are stored in allows are invalid. Results are returned in input order.
+// Inputs without a category are categorized before they are compared, so a retried batch
+// matches what the first attempt stored as long as the rules have not changed.
+func (uc *SyncUseCase) SyncExpenses(ctx context.Context, userID string, inputs []domain.CreateExpenseInput) (*domain.SyncResult, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	if len(inputs) == 0 {
+		return nil, ErrSyncBatchEmpty
+	}
+	if len(inputs) > MaxSyncBatchSize {
+		return nil, ErrSyncBatchTooLarge
+	}
+
+	if uc.categorizer != nil {
+		inputs = append([]domain.CreateExpenseInput(nil), inputs...)
+		pending := make([]*domain.CreateExpenseInput, len(inputs))
+		for i := range inputs {
+			inputs[i].UserID = userID
+			pending[i] = &inputs[i]
+		}
+		if err := uc.categorizer.Categorize(ctx, userID, nil, pending); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		ids = append(ids, input.ID)
+	}
+	existing, err := uc.expenseRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	existingByID := make(map[string]*domain.Expense, len(existing))
+	for _, e := range existing {
+		existingByID[e.ID] = e
+	}
+
+	var defaultCurrency string
+	for _, input := range inputs {
+		if input.Currency == "" {
+			if defaultCurrency, err = storedCurrency(ctx, uc.userRepo, userID, ""); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	items := make([]domain.SyncItemResult, len(inputs))
+	pending := make(map[string]domain.CreateExpenseInput)
+	var toInsert []domain.CreateExpenseInput
+	for i, input := range inputs {
+		input.UserID = userID
+		items[i] = domain.SyncItemResult{ID: input.ID}
+
+		currency := input.Currency
+		if currency == "" {
+			currency = defaultCurrency
+		}
+		if !input.Amount.FitsCurrency(currency) {
+			items[i].Status = domain.SyncItemInvalid
+			items[i].Reason = ErrAmountPrecision.Error()
+			continue
+		}
+
+		if prev, ok := pending[input.ID]; ok {
+			// Same UUID twice in one batch: only the first copy is inserted
+			if sameExpense(expenseFromInput(prev), input) {
+				items[i].Status = domain.SyncItemSkipped
+				items[i].Reason = "duplicate item in batch"
+			} else {
+				items[i].Status = domain.SyncItemConflict
+				items[i].Reason = "id appears more than once in batch with different data"
+			}
+			continue
+		}
+
+		if e, ok := existingByID[input.ID]; ok {
+			items[i].Status, items[i].Reason = existingExpenseStatus(e, userID, input)
+			continue
+		}
+
+		pending[input.ID] = input
+		toInsert = append(toInsert, input)
+	}
+
+	if len(toInsert) > 0 {
+		insertedIDs, err := uc.expenseRepo.CreateBatch(ctx, toInsert)
+		if err != nil {
+			return nil, err
+		}
+		inserted := make(map[string]bool, len(insertedIDs))
+		for _, id := range insertedIDs {
+			inserted[id] = true
+		}
+		var raced []string
+		for i := range items {
+			if items[i].Status != "" {
+				continue
+			}
+			if inserted[items[i].ID] {
+				items[i].Status = domain.SyncItemInserted
+			} else {
+				raced = append(raced, items[i].ID)
+			}
+		}
+
+		// Another request inserted these UUIDs between our lookup and insert: the row may be
+		// someone else's or hold different data, so judge it like one found by the lookup
+		if len(raced) > 0 {
+			stored, err := uc.expenseRepo.GetByIDs(ctx, raced)
+			if err != nil {
+				return nil, err
+			}
+			storedByID := make(map[string]*domain.Expense, len(stored))
+			for _, e := range stored {
+				storedByID[e.ID] = e
+			}
+			for i := range items {
+				if items[i].Status != "" {
+					continue
+				}
+				if e, ok := storedByID[items[i].ID]; ok {
+					items[i].Status, items[i].Reason = existingExpenseStatus(e, userID, pending[items[i].ID])
+				} else {
+					items[i].Status = domain.SyncItemConflict
+					items[i].Reason = "expense could not be stored"
+				}
+			}
+		}
+	}
+
+	result := &domain.SyncResult{Items: make([]domain.SyncItemResult, 0, len(items))}
+	for _, item := range items {
+		result.Add(item)
+	}
+	return result, nil
+}
+
+// GetChanges returns every expense, debt and category created, updated or deleted for the user
+// after cursor, oldest change first. An empty cursor starts from the beginning. The returned
+// NextCursor is passed back on the next call; HasMore reports that another page is waiting.
+func (uc *SyncUseCase) GetChanges(ctx context.Context, userID, cursor string, limit int) (*domain.SyncChanges, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+	if limit < 1 || limit > MaxSyncChangesLimit {
+		return nil, ErrInvalidSyncLimit
+	}
+	since, err := DecodeSyncCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Versions are taken in write order, so a transaction may still commit a row with a lower
+	// version than rows already handed out. The feed is ordered by the writing transaction
+	// instead, and read only up to the oldest transaction that may still be running: every
+	// earlier one has finished, and anything written later gets a higher transaction id, so no
+	// change can appear behind the cursor. All three tables share the one watermark.
+	watermark, err := uc.syncRepo.Watermark(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra row per table so we know whether anything is left after this page
+	expenses, err := uc.expenseRepo.ListChangedSince(ctx, userID, since, watermark, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	debts, err := uc.debtRepo.ListChangedSince(ctx, userID, since, watermark, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := uc.categoryRepo.ListChangedSince(ctx, userID, since, watermark, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]domain.SyncPosition, 0, len(expenses)+len(debts)+len(categories))
+	for _, e := range expenses {
+		positions = append(positions, domain.SyncPosition{TxID: e.SyncTxID, Version: e.Version})
+	}
+	for _, d := range debts {
+		positions = append(positions, domain.SyncPosition{TxID: d.SyncTxID, Version: d.Version})
+	}
+	for _, c := range categories {
+		positions = append(positions, domain.SyncPosition{TxID: c.SyncTxID, Version: c.Version})
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Before(positions[j]) })
+
+	changes := &domain.SyncChanges{
+		Expenses:   []*domain.Expense{},
+		Debts:      []*domain.Debt{},
+		Categories: []*domain.Category{},
+		NextCursor: EncodeSyncCursor(since),
+	}
+	if len(positions) == 0 {
+		return changes, nil
+	}
+
+	// Positions are unique across the three tables, so the page ends at the limit-th smallest
+	upTo := positions[len(positions)-1]
+	if len(positions) > limit {
+		upTo = positions[limit-1]
+		changes.HasMore = true
+	}
+	for _, e := range expenses {
+		if !upTo.Before(domain.SyncPosition{TxID: e.SyncTxID, Version: e.Version}) {
+			changes.Expenses = append(changes.Expenses, e)
+		}
+	}
+	for _, d := range debts {
+		if !upTo.Before(domain.SyncPosition{TxID: d.SyncTxID, Version: d.Version}) {
+			changes.Debts = append(changes.Debts, d)
+		}
+	}
+	for _, c := range categories {
+		if !upTo.Before(domain.SyncPosition{TxID: c.SyncTxID, Version: c.Version}) {
+			changes.Categories = append(changes.Categories, c)
+		}
+	}
+	changes.NextCursor = EncodeSyncCursor(upTo)
+	return changes, nil
+}
+
+// EncodeSyncCursor turns a change feed position into the opaque cursor handed to clients
+func EncodeSyncCursor(pos domain.SyncPosition) string {
+	raw := strconv.FormatUint(pos.TxID, 10) + "." + strconv.FormatInt(pos.Version, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeSyncCursor parses a cursor produced by EncodeSyncCursor; an empty cursor is the start
+// of the feed
+func DecodeSyncCursor(cursor string) (domain.SyncPosition, error) {
+	if cursor == "" {
+		return domain.SyncPosition{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return domain.SyncPosition{}, ErrInvalidSyncCursor
+	}
+	txID, version, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return domain.SyncPosition{}, ErrInvalidSyncCursor
+	}
+	var pos domain.SyncPosition
+	if pos.TxID, err = strconv.ParseUint(txID, 10, 64); err != nil {
+		return domain.SyncPosition{}, ErrInvalidSyncCursor
+	}
+	if pos.Version, err = strconv.ParseInt(version, 10, 64); err != nil || pos.Version < 0 {
+		return domain.SyncPosition{}, ErrInvalidSyncCursor
+	}
+	return pos, nil
+}
+
+// existingExpenseStatus decides what syncing input means for an expense already stored under its
+// ID: skipped when it is the user's and holds the same data, a conflict otherwise
+func existingExpenseStatus(e *domain.Expense, userID string, input domain.CreateExpenseInput) (domain.SyncItemStatus, string) {
+	switch {
+	case e.UserID != userID:
+		return domain.SyncItemConflict, "id is already in use"
+	case e.DeletedAt != nil:
+		return domain.SyncItemConflict, "expense was deleted"
+	case sameExpense(e, input):
+		return domain.SyncItemSkipped, "already synced"
+	default:
+		return domain.SyncItemConflict, "expense already exists with different data"
+	}
+}
+
+// sameExpense reports whether an existing expense holds exactly the data of a sync input
+func sameExpense(e *domain.Expense, in domain.CreateExpenseInput) bool {
+	if e.Amount != in.Amount ||
+		e.IsRecurring != in.IsRecurring ||
+		e.RecurrenceType != in.RecurrenceType ||
+		!sameRule(e.RecurrenceRule, in.RecurrenceRule) ||
+		e.ReminderEnabled != in.ReminderEnabled ||
+		e.Note != in.Note ||
+		!sameDate(&e.ExpenseDate, &in.ExpenseDate) ||
+		!sameDate(e.NextDueDate, in.NextDueDate) {
+		return false
+	}
+	return sameOptionalString(e.CategoryID, in.CategoryID)
+}
+
+func expenseFromInput(in domain.CreateExpenseInput) *domain.Expense {
+	return &domain.Expense{
+		ID:              in.ID,
+		UserID:          in.UserID,
+		Amount:          in.Amount,
+		CategoryID:      in.CategoryID,
+		IsRecurring:     in.IsRecurring,
+		RecurrenceType:  in.RecurrenceType,
+		RecurrenceRule:  in.RecurrenceRule,
+		NextDueDate:     in.NextDueDate,
+		ReminderEnabled: in.ReminderEnabled,
+		Note:            in.Note,
+		ExpenseDate:     in.ExpenseDate,
+	}
+}
+
+func sameRule(a, b *domain.RecurrenceRule) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+func sameOptionalString(a, b *string) bool {
+	if a == nil || *a == "" {
+		return b == nil || *b == ""
+	}
+	return b != nil && *a == *b
+}
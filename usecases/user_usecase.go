@@ -2,6 +2,7 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"expense_tracker/domain"
 	"expense_tracker/repository"
 
@@ -14,11 +15,17 @@ type UserUsecase interface {
 }
 
 type UpdateUserInput struct {
-	Name            *string
-	BudgetingStyle  *string
-	DefaultCurrency *string
+	Name            *string                `json:"name"`
+	BudgetingStyle  *domain.BudgetingStyle `json:"budgeting_style"`
+	MonthlyIncome   *domain.Money          `json:"monthly_income"`
+	DefaultCurrency *string                `json:"default_currency"`
 }
 
+var (
+	ErrInvalidBudgetingStyle = errors.New("budgeting_style must be one of flexible, envelope, zero_based, 50_30_20")
+	ErrInvalidMonthlyIncome  = errors.New("monthly_income must not be negative")
+)
+
 type userUsecase struct {
 	userRepo repository.UserRepository
 }
@@ -36,6 +43,13 @@ func (u *userUsecase) GetByID(ctx context.Context, userID uuid.UUID) (domain.Use
 }
 
 func (u *userUsecase) Update(ctx context.Context, userID uuid.UUID, input UpdateUserInput) error {
+	if input.BudgetingStyle != nil && !domain.ValidBudgetingStyle(*input.BudgetingStyle) {
+		return ErrInvalidBudgetingStyle
+	}
+	if input.MonthlyIncome != nil && input.MonthlyIncome.IsNegative() {
+		return ErrInvalidMonthlyIncome
+	}
+
 	user, err := u.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return err
@@ -47,9 +61,15 @@ func (u *userUsecase) Update(ctx context.Context, userID uuid.UUID, input Update
 	if input.BudgetingStyle != nil {
 		user.BudgetingStyle = *input.BudgetingStyle
 	}
+	if input.MonthlyIncome != nil {
+		user.MonthlyIncome = *input.MonthlyIncome
+	}
 	if input.DefaultCurrency != nil {
 		user.DefaultCurrency = *input.DefaultCurrency
 	}
+	if (input.MonthlyIncome != nil || input.DefaultCurrency != nil) && !user.MonthlyIncome.FitsCurrency(user.DefaultCurrency) {
+		return ErrAmountPrecision
+	}
 
 	return u.userRepo.Update(ctx, user)
 }